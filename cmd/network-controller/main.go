@@ -2,25 +2,42 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/rancher/wrangler/v3/pkg/leader"
 	"github.com/rancher/wrangler/v3/pkg/signals"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
 
+	"github.com/harvester/harvester-network-controller/pkg/agentapi"
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	"github.com/harvester/harvester-network-controller/pkg/controller/agent"
 	"github.com/harvester/harvester-network-controller/pkg/controller/manager"
+	"github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned"
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
+	"github.com/harvester/harvester-network-controller/pkg/network/fault"
+	"github.com/harvester/harvester-network-controller/pkg/network/inspect"
+	"github.com/harvester/harvester-network-controller/pkg/network/preflight"
+	"github.com/harvester/harvester-network-controller/pkg/network/render"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
+	"github.com/harvester/harvester-network-controller/pkg/utils/crd"
 )
 
 const (
-	name               = "harvester-network-controller"
-	defaultThreadCount = 2
+	name                  = "harvester-network-controller"
+	defaultThreadCount    = 2
+	apiServerPollInterval = 10 * time.Second
 )
 
 var (
@@ -82,6 +99,21 @@ func main() {
 			Value:  "rancher/harvester-network-helper:master-head",
 			Usage:  "The image of harvester network helper, defaults to rancher/harvester-network-helper.",
 		},
+		cli.Float64Flag{
+			Name:   "kube-api-qps",
+			EnvVar: "KUBE_API_QPS",
+			Usage:  "The QPS all the API clients of the controllers share together, 0 leaves every client to the client-go default",
+		},
+		cli.IntFlag{
+			Name:   "kube-api-burst",
+			EnvVar: "KUBE_API_BURST",
+			Usage:  "The burst all the API clients of the controllers share together, only effective with kube-api-qps",
+		},
+		cli.StringFlag{
+			Name:   "controller-rate-limits",
+			EnvVar: "CONTROLLER_RATE_LIMITS",
+			Usage:  "Comma separated reconcile rate limits of the controllers by the kind, e.g. VlanStatus=5:10 for 5 QPS and a burst of 10",
+		},
 	}
 
 	app.Commands = []cli.Command{
@@ -89,13 +121,108 @@ func main() {
 			Name:   "manager",
 			Usage:  "Run manager",
 			Action: managerRun,
-			Flags:  commonFlags,
+			Flags: append(commonFlags, cli.StringFlag{
+				Name:   "tenant-labels",
+				EnvVar: "TENANT_LABELS",
+				Value:  utils.KeyRancherProjectID,
+				Usage:  "Comma separated keys of the namespace labels propagated to the NADs and their helper jobs",
+			}, cli.StringFlag{
+				Name:   "crd-bootstrap",
+				EnvVar: "CRD_BOOTSTRAP",
+				Value:  string(crd.BootstrapVerify),
+				Usage:  "How the CRDs are treated at startup: off, verify to refuse running against stale CRDs, or install to install or update them first",
+			}, cli.StringFlag{
+				Name:   "metrics-address",
+				EnvVar: "METRICS_ADDRESS",
+				Value:  "",
+				Usage:  "The address to serve the metrics on, e.g. :9094, the metrics are not served if it's empty",
+			}),
 		},
 		{
 			Name:   "agent",
 			Usage:  "Run agent",
 			Action: agentRun,
-			Flags:  commonFlags,
+			Flags: append(commonFlags,
+				cli.StringFlag{
+					Name:   "metrics-address",
+					EnvVar: "METRICS_ADDRESS",
+					Value:  "",
+					Usage:  "The address to serve the metrics on, e.g. :9094, the metrics are not served if it's empty",
+				},
+				cli.StringFlag{
+					Name:   "api-socket",
+					EnvVar: "API_SOCKET",
+					Value:  "",
+					Usage:  "The unix socket to serve the inspect, preflight and resync operations on, e.g. /var/run/harvester-network/agent.sock, they are not served if it's empty",
+				},
+				cli.BoolFlag{
+					Name:   "uplink-utilization",
+					EnvVar: "UPLINK_UTILIZATION",
+					Usage:  "Sample the 1-minute average throughput of the uplinks into the vlanstatuses and the metrics",
+				},
+				cli.StringFlag{
+					Name:   "state-file",
+					EnvVar: "STATE_FILE",
+					Usage:  "The file to cache the desired network of the node in, which is enforced at startup if the API server is unreachable",
+				},
+				cli.StringFlag{
+					Name:   "capture-dir",
+					EnvVar: "CAPTURE_DIR",
+					Usage:  "The directory to write the packet captures in, e.g. /var/log/harvester-network-controller/captures which is collected by the support bundle, the captures are refused if it's empty",
+				},
+				// for the chaos tests only
+				cli.StringFlag{
+					Name:   "fault-injection",
+					Usage:  "The JSON file of the rules to inject the faults into the netlink operations",
+					Hidden: true,
+				},
+			),
+		},
+		{
+			Name:   "inspect",
+			Usage:  "Print the network state managed by the agent on this node and the related vlanstatuses",
+			Action: inspectRun,
+			Flags: append(commonFlags, cli.StringFlag{
+				Name:  "output, o",
+				Value: "yaml",
+				Usage: "Output format, json or yaml",
+			}),
+		},
+		{
+			Name:   "preflight",
+			Usage:  "Check a vlanconfig against the NICs of this node before applying it",
+			Action: preflightRun,
+			Flags: append(commonFlags,
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "The manifest of the vlanconfig",
+				},
+				cli.StringFlag{
+					Name:  "output, o",
+					Value: "text",
+					Usage: "Output format, text, json or yaml",
+				},
+			),
+		},
+		{
+			Name:   "render",
+			Usage:  "Print the bonds, bridges and VLAN IDs expected on every node from the manifests without touching the system",
+			Action: renderRun,
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "file, f",
+					Usage: "The manifests of the cluster networks, vlanconfigs, NADs and nodes, - reads the standard input",
+				},
+				cli.StringSliceFlag{
+					Name:  "node",
+					Usage: "The name of an unlabeled node to match the vlanconfigs against besides the nodes in the manifests",
+				},
+				cli.StringFlag{
+					Name:  "output, o",
+					Value: "yaml",
+					Usage: "Output format, json or yaml",
+				},
+			},
 		},
 	}
 
@@ -108,7 +235,8 @@ func main() {
 	}
 }
 
-func run(c *cli.Context, registerFuncList []config.RegisterFunc, leaderelection bool) error {
+// fallback is started before the controllers are registered, which needs the API server
+func run(c *cli.Context, registerFuncList []config.RegisterFunc, fallback config.RegisterFunc, leaderelection bool) error {
 	masterURL := c.String("master")
 	kubeconfig := c.String("kubeconfig")
 	namespace := c.String("namespace")
@@ -125,20 +253,60 @@ func run(c *cli.Context, registerFuncList []config.RegisterFunc, leaderelection
 
 	ctx := signals.SetupSignalContext()
 
+	if address := c.String("metrics-address"); address != "" {
+		metrics.Serve(ctx, address)
+	}
+	if path := c.String("api-socket"); path != "" {
+		apiNodeName, err := localNodeName(c)
+		if err != nil {
+			logrus.Fatalf("Error getting the node name: %s", err.Error())
+		}
+		if err := agentapi.Serve(ctx, path, apiNodeName); err != nil {
+			logrus.Fatalf("Error serving the agent API: %s", err.Error())
+		}
+	}
+	if path := c.String("fault-injection"); path != "" {
+		if err := fault.Enable(path); err != nil {
+			logrus.Fatalf("Error enabling fault injection: %s", err.Error())
+		}
+	}
+
+	kindRateLimits, err := config.ParseKindRateLimits(c.String("controller-rate-limits"))
+	if err != nil {
+		logrus.Fatalf("Error parsing the controller rate limits: %s", err.Error())
+	}
+
+	crdBootstrap, err := crd.ParseBootstrapMode(c.String("crd-bootstrap"))
+	if err != nil {
+		logrus.Fatalf("Error parsing the CRD bootstrap mode: %s", err.Error())
+	}
+
 	cfg, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
 	if err != nil {
 		logrus.Fatalf("Error building config from flags: %s", err.Error())
 	}
 
+	// the client of the leader election isn't rate limited along with the controllers, or it may fail to renew the lease
 	client, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		logrus.Fatalf("Error get client from kubeconfig: %s", err.Error())
 	}
 
 	options := &config.Options{
-		Namespace:   namespace,
-		NodeName:    nodeName,
-		HelperImage: helperImage,
+		Namespace:         namespace,
+		NodeName:          nodeName,
+		HelperImage:       helperImage,
+		Version:           VERSION,
+		TenantLabels:      utils.SplitLabelKeys(c.String("tenant-labels")),
+		UplinkUtilization: c.Bool("uplink-utilization"),
+		StateFile:         c.String("state-file"),
+		CaptureDir:        c.String("capture-dir"),
+		RateLimits: config.RateLimits{
+			QPS:   float32(c.Float64("kube-api-qps")),
+			Burst: c.Int("kube-api-burst"),
+			Kinds: kindRateLimits,
+		},
+		CRDBootstrap: crdBootstrap,
 	}
 
 	management, err := config.SetupManagement(ctx, cfg, options)
@@ -146,7 +314,19 @@ func run(c *cli.Context, registerFuncList []config.RegisterFunc, leaderelection
 		logrus.Fatalf("Error building harvester controllers: %s", err.Error())
 	}
 
+	if fallback != nil {
+		go func() {
+			if err := fallback(ctx, management); err != nil {
+				logrus.Warnf("Error running the fallback: %s", err.Error())
+			}
+		}()
+	}
+
 	callback := func(ctx context.Context) {
+		// wait for the API server instead of crashing, the fallback enforces the cached network meanwhile
+		if fallback != nil {
+			waitForAPIServer(ctx, client)
+		}
 		if err := management.Register(ctx, cfg, registerFuncList); err != nil {
 			panic(err)
 		}
@@ -167,10 +347,194 @@ func run(c *cli.Context, registerFuncList []config.RegisterFunc, leaderelection
 	return nil
 }
 
+func waitForAPIServer(ctx context.Context, client kubernetes.Interface) {
+	_ = wait.PollUntilContextCancel(ctx, apiServerPollInterval, true, func(context.Context) (bool, error) {
+		if _, err := client.Discovery().ServerVersion(); err != nil {
+			logrus.Warnf("API server is unreachable, error: %s", err.Error())
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
 func managerRun(c *cli.Context) error {
-	return run(c, manager.RegisterFuncList, true)
+	return run(c, manager.RegisterFuncList, nil, true)
 }
 
 func agentRun(c *cli.Context) error {
-	return run(c, agent.RegisterFuncList, false)
+	return run(c, agent.RegisterFuncList, agent.Fallback, false)
+}
+
+func inspectRun(c *cli.Context) error {
+	output := c.String("output")
+	if output != "json" && output != "yaml" {
+		return fmt.Errorf("unsupported output format %s", output)
+	}
+	nodeName, err := localNodeName(c)
+	if err != nil {
+		return err
+	}
+
+	state, err := inspect.Collect(nodeName)
+	if err != nil {
+		return fmt.Errorf("collect network state failed, error: %w", err)
+	}
+
+	// the local state is still printed if the API server is not reachable
+	if vss, err := listVlanStatuses(c, nodeName); err != nil {
+		logrus.Warnf("skip mapping to the vlanstatuses, error: %s", err.Error())
+	} else {
+		state.AttachVlanStatuses(vss)
+	}
+
+	var bytes []byte
+	if output == "json" {
+		bytes, err = json.MarshalIndent(state, "", "  ")
+	} else {
+		bytes, err = yaml.Marshal(state)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(bytes))
+
+	return nil
+}
+
+func localNodeName(c *cli.Context) (string, error) {
+	if nodeName := c.String("node-name"); nodeName != "" {
+		return nodeName, nil
+	}
+
+	return os.Hostname()
+}
+
+func preflightRun(c *cli.Context) error {
+	output := c.String("output")
+	if output != "text" && output != "json" && output != "yaml" {
+		return fmt.Errorf("unsupported output format %s", output)
+	}
+	file := c.String("file")
+	if file == "" {
+		return fmt.Errorf("the manifest of the vlanconfig is required")
+	}
+	nodeName, err := localNodeName(c)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	vc := &networkv1.VlanConfig{}
+	if err := yaml.UnmarshalStrict(bytes, vc); err != nil {
+		return fmt.Errorf("parse %s failed, error: %w", file, err)
+	}
+
+	report, err := preflight.Run(nodeName, vc)
+	if err != nil {
+		return err
+	}
+
+	switch output {
+	case "json":
+		bytes, err = json.MarshalIndent(report, "", "  ")
+	case "yaml":
+		bytes, err = yaml.Marshal(report)
+	default:
+		bytes = []byte(report.String())
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(bytes))
+
+	if !report.Passed {
+		return cli.NewExitError("", 1)
+	}
+	return nil
+}
+
+func renderRun(c *cli.Context) error {
+	output := c.String("output")
+	if output != "json" && output != "yaml" {
+		return fmt.Errorf("unsupported output format %s", output)
+	}
+	files := c.StringSlice("file")
+	if len(files) == 0 {
+		return fmt.Errorf("the manifests are required")
+	}
+
+	manifests := &render.Manifests{}
+	for _, file := range files {
+		m, err := decodeManifests(file)
+		if err != nil {
+			return fmt.Errorf("parse %s failed, error: %w", file, err)
+		}
+		manifests.ClusterNetworks = append(manifests.ClusterNetworks, m.ClusterNetworks...)
+		manifests.VlanConfigs = append(manifests.VlanConfigs, m.VlanConfigs...)
+		manifests.NADs = append(manifests.NADs, m.NADs...)
+		manifests.Nodes = append(manifests.Nodes, m.Nodes...)
+	}
+	for _, node := range c.StringSlice("node") {
+		manifests.Nodes = append(manifests.Nodes, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: node}})
+	}
+
+	layout, err := render.Render(manifests)
+	if err != nil {
+		return err
+	}
+
+	var bytes []byte
+	if output == "json" {
+		bytes, err = json.MarshalIndent(layout, "", "  ")
+	} else {
+		bytes, err = yaml.Marshal(layout)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(bytes))
+
+	return nil
+}
+
+func decodeManifests(file string) (*render.Manifests, error) {
+	if file == "-" {
+		return render.Decode(os.Stdin)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return render.Decode(f)
+}
+
+func listVlanStatuses(c *cli.Context, nodeName string) ([]*networkv1.VlanStatus, error) {
+	cfg, err := clientcmd.BuildConfigFromFlags(c.String("master"), c.String("kubeconfig"))
+	if err != nil {
+		return nil, err
+	}
+	client, err := versioned.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := client.NetworkV1beta1().VlanStatuses().List(context.Background(), metav1.ListOptions{
+		LabelSelector: labels.Set{utils.KeyNodeLabel: utils.LabelValue(nodeName)}.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	vss := make([]*networkv1.VlanStatus, 0, len(list.Items))
+	for i := range list.Items {
+		vss = append(vss, &list.Items[i])
+	}
+
+	return vss, nil
 }
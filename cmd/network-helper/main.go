@@ -26,7 +26,7 @@ var (
 func main() {
 	app := cli.NewApp()
 	app.Name = name
-	app.Usage = "network-helper is to help get the network information through DHCP protocol from the pod within the VLAN network"
+	app.Usage = "network-helper is to help get the network information through DHCP protocol or IPv6 router advertisements from the pod within the VLAN network"
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
 			Name:   "kubeconfig, k",
@@ -53,6 +53,12 @@ func main() {
 			Value:  "",
 			Usage:  "DHCP server IP address",
 		},
+		cli.StringFlag{
+			Name:   "ipfamily",
+			EnvVar: nad.JobEnvIPFamily,
+			Value:  string(utils.IPv4),
+			Usage:  "IP family of the network, ipv4, ipv6 or dual",
+		},
 	}
 	app.Action = func(c *cli.Context) {
 		if err := run(c); err != nil {
@@ -72,6 +78,7 @@ func run(c *cli.Context) error {
 	kubeconfig := c.String("kubeconfig")
 	networks := c.String("nadnetworks")
 	dhcpServerIPAddr := c.String("dhcpserver")
+	family := (&utils.Layer3NetworkConf{IPFamily: utils.IPFamily(c.String("ipfamily"))}).GetIPFamily()
 
 	cfg, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
 	if err != nil {
@@ -89,7 +96,7 @@ func run(c *cli.Context) error {
 	netHelper := helper.New(cni)
 
 	for i := range selectedNetworks {
-		networkConf := netHelper.GetVLANLayer3Network(&selectedNetworks[i], dhcpServerIPAddr)
+		networkConf := netHelper.GetVLANLayer3Network(&selectedNetworks[i], dhcpServerIPAddr, family)
 
 		if err := netHelper.RecordToNad(&selectedNetworks[i], networkConf); err != nil {
 			return fmt.Errorf("failed to record to nad cr, error: %w", err)
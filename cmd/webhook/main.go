@@ -16,6 +16,7 @@ import (
 	"github.com/rancher/wrangler/v3/pkg/start"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 
@@ -32,10 +33,14 @@ import (
 	ctlkubevirtv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubevirt.io/v1"
 	ctlnetwork "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/settings"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
+	"github.com/harvester/harvester-network-controller/pkg/webhook/audit"
 	"github.com/harvester/harvester-network-controller/pkg/webhook/clusternetwork"
 	"github.com/harvester/harvester-network-controller/pkg/webhook/hostnetworkconfig"
 	"github.com/harvester/harvester-network-controller/pkg/webhook/nad"
+	"github.com/harvester/harvester-network-controller/pkg/webhook/nicclaim"
+	"github.com/harvester/harvester-network-controller/pkg/webhook/packetcapture"
 	"github.com/harvester/harvester-network-controller/pkg/webhook/subnet"
 	"github.com/harvester/harvester-network-controller/pkg/webhook/vlanconfig"
 )
@@ -52,6 +57,7 @@ var (
 
 func main() {
 	var options config.Options
+	var auditOptions auditOptions
 	logLevel := "info"
 
 	flags := []cli.Flag{
@@ -97,6 +103,19 @@ func main() {
 			Usage:       "The system username that performs garbage collection",
 			Value:       "system:serviceaccount:kube-system:generic-garbage-collector",
 		},
+		cli.BoolFlag{
+			Name:        "audit",
+			EnvVar:      "AUDIT",
+			Usage:       "Record the changes of the cluster networks, vlanconfigs and NADs into NetworkChangeLogs",
+			Destination: &auditOptions.enabled,
+		},
+		cli.DurationFlag{
+			Name:        "audit-retention",
+			EnvVar:      "AUDIT_RETENTION",
+			Usage:       "How long the NetworkChangeLogs are kept",
+			Value:       30 * 24 * time.Hour,
+			Destination: &auditOptions.retention,
+		},
 	}
 
 	logrus.Infof("Starting %v version %v", name, VERSION)
@@ -112,7 +131,7 @@ func main() {
 	app.Flags = flags
 	app.Action = func(_ *cli.Context) {
 		utils.SetLogLevel(logLevel)
-		if err := run(ctx, cfg, &options); err != nil {
+		if err := run(ctx, cfg, &options, &auditOptions); err != nil {
 			logrus.Fatalf("run webhook server failed: %v", err)
 		}
 	}
@@ -122,7 +141,12 @@ func main() {
 	}
 }
 
-func run(ctx context.Context, cfg *rest.Config, options *config.Options) error {
+type auditOptions struct {
+	enabled   bool
+	retention time.Duration
+}
+
+func run(ctx context.Context, cfg *rest.Config, options *config.Options, auditOptions *auditOptions) error {
 	// check if subnet crd exists
 	crdExists, err := isSubnetsCRDPresent(ctx, cfg)
 	if err != nil {
@@ -134,20 +158,38 @@ func run(ctx context.Context, cfg *rest.Config, options *config.Options) error {
 		return err
 	}
 
+	// the MTUs are validated against the cluster-wide bounds
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	settings.SyncMTUBounds(ctx, dynamicClient)
+
 	webhookServer := server.NewWebhookServer(ctx, cfg, name, options)
 
 	if err := webhookServer.RegisterMutators(
 		nad.NewNadMutator(c.cnCache, c.vcCache),
-		vlanconfig.NewVlanConfigMutator(c.nodeCache),
+		vlanconfig.NewVlanConfigMutator(c.nodeCache, c.cnCache),
 	); err != nil {
 		return fmt.Errorf("failed to register mutators: %v", err)
 	}
 
+	// the changes of the network resources are recorded for the incident reviews if the audit is enabled
+	audited := func(v admission.Validator) admission.Validator { return v }
+	if auditOptions.enabled {
+		recorder := audit.NewRecorder(c.changeLogClient)
+		audited = recorder.Wrap
+		go recorder.Prune(ctx, c.changeLogCache, auditOptions.retention)
+	}
+
 	validators := []admission.Validator{
-		clusternetwork.NewCnValidator(c.nadCache, c.vmiCache, c.vcCache),
-		nad.NewNadValidator(c.vmCache, c.vmiCache, c.cnCache, c.vcCache, c.kubeovnsubnetCache, crdExists, c.hostNetworkConfigCache, c.nadCache),
-		vlanconfig.NewVlanConfigValidator(c.nadCache, c.vcCache, c.vsCache, c.vmiCache, c.cnCache),
+		audited(clusternetwork.NewCnValidator(c.nadCache, c.vmiCache, c.vcCache, c.vsCache, c.cnCache)),
+		audited(nad.NewNadValidator(c.vmCache, c.vmiCache, c.cnCache, c.vcCache, c.kubeovnsubnetCache, crdExists, c.hostNetworkConfigCache, c.nadCache)),
+		audited(vlanconfig.NewVlanConfigValidator(c.nadCache, c.vcCache, c.vsCache, c.vmiCache, c.cnCache, c.nicClaimCache,
+			c.lmCache, c.nnsCache, c.nodeCache)),
 		hostnetworkconfig.NewHostNetworkConfigValidator(c.nadCache, c.cnCache, c.hostNetworkConfigCache, c.vcCache, c.vsCache, c.nodeCache, c.vmCache),
+		nicclaim.NewNetworkInterfaceClaimValidator(c.nicClaimCache, c.vcCache, c.vsCache),
+		packetcapture.NewPacketCaptureValidator(c.cnCache, c.vsCache),
 	}
 
 	if crdExists {
@@ -182,6 +224,11 @@ type caches struct {
 	kubeovnsubnetCache     kubeovnnetworkv1.SubnetCache
 	kubeovnvpcCache        kubeovnnetworkv1.VpcCache
 	hostNetworkConfigCache ctlnetworkv1.HostNetworkConfigCache
+	nicClaimCache          ctlnetworkv1.NetworkInterfaceClaimCache
+	lmCache                ctlnetworkv1.LinkMonitorCache
+	nnsCache               ctlnetworkv1.NodeNetworkStateCache
+	changeLogClient        ctlnetworkv1.NetworkChangeLogClient
+	changeLogCache         ctlnetworkv1.NetworkChangeLogCache
 }
 
 func newCaches(ctx context.Context, cfg *rest.Config, threadiness int, crdExists bool) (*caches, error) {
@@ -206,6 +253,11 @@ func newCaches(ctx context.Context, cfg *rest.Config, threadiness int, crdExists
 		cnCache:                harvesterNetworkFactory.Network().V1beta1().ClusterNetwork().Cache(),
 		nodeCache:              coreFactory.Core().V1().Node().Cache(),
 		hostNetworkConfigCache: harvesterNetworkFactory.Network().V1beta1().HostNetworkConfig().Cache(),
+		nicClaimCache:          harvesterNetworkFactory.Network().V1beta1().NetworkInterfaceClaim().Cache(),
+		lmCache:                harvesterNetworkFactory.Network().V1beta1().LinkMonitor().Cache(),
+		nnsCache:               harvesterNetworkFactory.Network().V1beta1().NodeNetworkState().Cache(),
+		changeLogClient:        harvesterNetworkFactory.Network().V1beta1().NetworkChangeLog(),
+		changeLogCache:         harvesterNetworkFactory.Network().V1beta1().NetworkChangeLog().Cache(),
 	}
 
 	if crdExists {
@@ -215,8 +267,9 @@ func newCaches(ctx context.Context, cfg *rest.Config, threadiness int, crdExists
 		c.kubeovnvpcCache = kubeovnFactory.Kubeovn().V1().Vpc().Cache()
 	}
 	// Indexer must be added before starting the informer, otherwise panic `cannot add indexers to running index` happens
-	c.vmiCache.AddIndexer(utils.VMByNetworkIndex, vmiByNetwork)
+	c.vmiCache.AddIndexer(utils.VMByNetworkIndex, utils.VMIByNetwork)
 	c.vmCache.AddIndexer(utils.VMByNetworkIndex, vmByNetwork)
+	c.vsCache.AddIndexer(utils.VlanStatusByNodeIndex, utils.VlanStatusByNode)
 
 	if err := start.All(ctx, threadiness, starters...); err != nil {
 		return nil, err
@@ -225,18 +278,6 @@ func newCaches(ctx context.Context, cfg *rest.Config, threadiness int, crdExists
 	return c, nil
 }
 
-func vmiByNetwork(obj *kubevirtv1.VirtualMachineInstance) ([]string, error) {
-	networks := obj.Spec.Networks
-	networkNameList := make([]string, 0, len(networks))
-	for _, network := range networks {
-		if network.NetworkSource.Multus == nil {
-			continue
-		}
-		networkNameList = append(networkNameList, network.NetworkSource.Multus.NetworkName)
-	}
-	return networkNameList, nil
-}
-
 func vmByNetwork(obj *kubevirtv1.VirtualMachine) ([]string, error) {
 	networks := obj.Spec.Template.Spec.Networks
 	networkNameList := make([]string, 0, len(networks))
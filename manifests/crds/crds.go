@@ -0,0 +1,9 @@
+// Package crds embeds the CRDs of the network controller, so that the manager can install and verify them at startup
+package crds
+
+import "embed"
+
+// FS holds the CRD manifests generated from the API types
+//
+//go:embed *.yaml
+var FS embed.FS
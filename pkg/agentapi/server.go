@@ -0,0 +1,191 @@
+// Package agentapi serves the operations of the agent on a local unix socket, so that the host agents and the support
+// tooling of the node, e.g. harvester-node-manager, reach them without going through the Kubernetes API, which may be
+// unreachable while the control plane is degraded. The requests and the responses are JSON over HTTP.
+package agentapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/inspect"
+	"github.com/harvester/harvester-network-controller/pkg/network/preflight"
+)
+
+const (
+	PathInspect   = "/v1/inspect"
+	PathPreflight = "/v1/preflight"
+	PathResync    = "/v1/resync"
+
+	readHeaderTimeout = 10 * time.Second
+	// the manifest of a vlanconfig is small, a larger body is rejected
+	maxBodyBytes = 1 << 20
+)
+
+// ResyncFunc enqueues the objects of a kind the agent reconciles again and returns how many are enqueued
+type ResyncFunc func() (int, error)
+
+var resyncs = struct {
+	mutex sync.Mutex
+	funcs map[string]ResyncFunc
+}{funcs: make(map[string]ResyncFunc)}
+
+// RegisterResync registers the resync of the objects of the kind, the controllers register it once they are started
+func RegisterResync(kind string, fn ResyncFunc) {
+	resyncs.mutex.Lock()
+	defer resyncs.mutex.Unlock()
+	resyncs.funcs[kind] = fn
+}
+
+// ResyncResult is the response of the resync, the number of the enqueued objects by the kind
+type ResyncResult struct {
+	Enqueued map[string]int `json:"enqueued"`
+}
+
+// ErrorResult is the response of a failed request
+type ErrorResult struct {
+	Error string `json:"error"`
+}
+
+// Serve serves the operations of the agent of the node on the unix socket in the background until the context is
+// done. The socket is only accessible by root, a stale socket left by a previous agent is replaced.
+func Serve(ctx context.Context, path, node string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create the directory of %s failed, error: %w", path, err)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove the stale socket %s failed, error: %w", path, err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("listen on %s failed, error: %w", path, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return fmt.Errorf("restrict the access to %s failed, error: %w", path, err)
+	}
+
+	server := &http.Server{
+		Handler:           NewHandler(node),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			logrus.Warnf("shut down agent API server failed, error: %s", err.Error())
+		}
+	}()
+	go func() {
+		logrus.Infof("serve agent API on %s", path)
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Errorf("serve agent API on %s failed, error: %s", path, err.Error())
+		}
+	}()
+
+	return nil
+}
+
+// NewHandler returns the handler of the operations of the agent of the node
+func NewHandler(node string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathInspect, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+		state, err := inspect.Collect(node)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("collect network state failed, error: %w", err))
+			return
+		}
+		writeJSON(w, http.StatusOK, state)
+	})
+	mux.HandleFunc(PathPreflight, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		vc := &networkv1.VlanConfig{}
+		// the manifest is either JSON or YAML
+		if err := yaml.UnmarshalStrict(body, vc); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("parse the vlanconfig failed, error: %w", err))
+			return
+		}
+		report, err := preflight.Run(node, vc)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	})
+	mux.HandleFunc(PathResync, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+		result, err := resync()
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+
+	return mux
+}
+
+func resync() (*ResyncResult, error) {
+	resyncs.mutex.Lock()
+	defer resyncs.mutex.Unlock()
+	if len(resyncs.funcs) == 0 {
+		return nil, fmt.Errorf("the controllers are not started yet, the network cached on the node is enforced meanwhile")
+	}
+
+	kinds := make([]string, 0, len(resyncs.funcs))
+	for kind := range resyncs.funcs {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	result := &ResyncResult{Enqueued: make(map[string]int, len(kinds))}
+	for _, kind := range kinds {
+		count, err := resyncs.funcs[kind]()
+		if err != nil {
+			return nil, fmt.Errorf("resync %s failed, error: %w", kind, err)
+		}
+		result.Enqueued[kind] = count
+	}
+	logrus.Infof("resync requested over the agent API, enqueued %v", result.Enqueued)
+
+	return result, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.Warnf("write agent API response failed, error: %s", err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResult{Error: err.Error()})
+}
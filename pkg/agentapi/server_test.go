@@ -0,0 +1,116 @@
+package agentapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func resetResyncs() {
+	resyncs.mutex.Lock()
+	defer resyncs.mutex.Unlock()
+	resyncs.funcs = make(map[string]ResyncFunc)
+}
+
+func TestResync(t *testing.T) {
+	defer resetResyncs()
+	handler := NewHandler("node1")
+
+	tests := []struct {
+		name     string
+		register map[string]ResyncFunc
+		status   int
+		enqueued map[string]int
+	}{
+		{
+			name:   "controllers not started",
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "enqueue all kinds",
+			register: map[string]ResyncFunc{
+				"vlanconfigs":     func() (int, error) { return 2, nil },
+				"clusternetworks": func() (int, error) { return 3, nil },
+			},
+			status:   http.StatusOK,
+			enqueued: map[string]int{"vlanconfigs": 2, "clusternetworks": 3},
+		},
+		{
+			name: "cache error",
+			register: map[string]ResyncFunc{
+				"vlanconfigs": func() (int, error) { return 0, errors.New("not synced") },
+			},
+			status: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		resetResyncs()
+		for kind, fn := range tc.register {
+			RegisterResync(kind, fn)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, PathResync, nil))
+		assert.Equal(t, tc.status, recorder.Code, tc.name)
+		if tc.status != http.StatusOK {
+			continue
+		}
+		result := &ResyncResult{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), result), tc.name)
+		assert.Equal(t, tc.enqueued, result.Enqueued, tc.name)
+	}
+}
+
+func TestBadRequests(t *testing.T) {
+	handler := NewHandler("node1")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{
+			name:   "resync by GET",
+			method: http.MethodGet,
+			path:   PathResync,
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "inspect by POST",
+			method: http.MethodPost,
+			path:   PathInspect,
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "preflight with unknown field",
+			method: http.MethodPost,
+			path:   PathPreflight,
+			body:   `{"spec": {"clusterNetwork": "cn1", "unknown": true}}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "preflight with invalid manifest",
+			method: http.MethodPost,
+			path:   PathPreflight,
+			body:   "spec: [",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		assert.Equal(t, tc.status, recorder.Code, tc.name)
+		result := &ErrorResult{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), result), tc.name)
+		assert.NotEmpty(t, result.Error, tc.name)
+	}
+}
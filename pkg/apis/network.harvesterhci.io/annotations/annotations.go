@@ -0,0 +1,244 @@
+// Package annotations is the documented contract of the annotations the network controller reads and writes on the
+// resources, which the external tooling depends on. Every key is described with the resource it's set on and its
+// format. A renamed key keeps its previous names in Deprecated, they are still read as a fallback and the manager
+// migrates them forward to the current name, so the tooling has a release to follow the rename.
+package annotations
+
+import (
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io"
+)
+
+// Version is the version of the contract, it's increased whenever a key is renamed or its format changes
+const Version = "v1"
+
+// The names of the current keys
+const (
+	KeyMatchedNodes          = network.GroupName + "/matched-nodes"
+	KeyInheritedUplinkFields = network.GroupName + "/inherited-uplink-fields"
+	KeyCloneRequest          = network.GroupName + "/clone"
+	KeyCloneResult           = network.GroupName + "/clone-result"
+	KeyClonedFrom            = network.GroupName + "/cloned-from"
+	KeyForceTeardown         = network.GroupName + "/force-teardown"
+
+	KeyUplinkMTU            = network.GroupName + "/uplink-mtu"
+	KeyMTUSourceVlanConfig  = network.GroupName + "/mtu-source-vc"
+	KeyVlanIDSetStr         = network.GroupName + "/vlan-id-set-str"
+	KeyVlanIDSetStrHash     = network.GroupName + "/vlan-id-set-str-hash"
+	KeyDeletedVlanConfigs   = network.GroupName + "/deleted-vlanconfigs"
+	KeyRestoreVlanConfig    = network.GroupName + "/restore-vlanconfig"
+	KeyRestoreResult        = network.GroupName + "/restore-result"
+	KeyDeletionConfirmation = network.GroupName + "/deletion-confirmation"
+
+	KeyNetworkRoute     = network.GroupName + "/route"
+	KeyVlanDHCPServerIP = network.GroupName + "/vlan-dhcp-server-ip"
+	KeyUplink           = network.GroupName + "/uplink"
+	KeyTTL              = network.GroupName + "/ttl"
+	KeyQoSMarking       = network.GroupName + "/qos-marking"
+
+	KeyLLDPNeighbors = network.GroupName + "/lldp-neighbors"
+
+	KeyUnhealthyNetworks = network.GroupName + "/unhealthy-networks"
+)
+
+// Key is an annotation of the contract
+type Key struct {
+	// Name is the current name of the key
+	Name string
+	// Deprecated are the previous names of the key, the newest first
+	Deprecated []string
+	// Resources are the kinds of the resources the key is set on
+	Resources []string
+	// Description is the meaning and the format of the value
+	Description string
+}
+
+// The keys of the contract
+var (
+	MatchedNodes = Key{
+		Name:        KeyMatchedNodes,
+		Resources:   []string{"VlanConfig", "HostNetworkConfig"},
+		Description: "the nodes the resource matches, a JSON array of the node names, set by the webhook and the manager",
+	}
+	InheritedUplinkFields = Key{
+		Name:        KeyInheritedUplinkFields,
+		Resources:   []string{"VlanConfig"},
+		Description: "the uplink sections inherited from the cluster network, comma separated, e.g. bondOptions,linkAttributes",
+	}
+	CloneRequest = Key{
+		Name:        KeyCloneRequest,
+		Resources:   []string{"VlanConfig"},
+		Description: `the request to clone the vlanconfig in JSON, e.g. {"name":"rack2","nodeSelector":{"rack":"2"}}`,
+	}
+	CloneResult = Key{
+		Name:        KeyCloneResult,
+		Resources:   []string{"VlanConfig"},
+		Description: "the result of the last clone request",
+	}
+	ClonedFrom = Key{
+		Name:        KeyClonedFrom,
+		Resources:   []string{"VlanConfig"},
+		Description: "the name of the vlanconfig the vlanconfig is cloned from",
+	}
+	ForceTeardown = Key{
+		Name:        KeyForceTeardown,
+		Resources:   []string{"VlanConfig"},
+		Description: `"true" tears down the bridge on the nodes the vlanconfig leaves even if VM ports are still attached to it`,
+	}
+	UplinkMTU = Key{
+		Name:        KeyUplinkMTU,
+		Resources:   []string{"ClusterNetwork"},
+		Description: "the MTU of the uplinks of the cluster network in decimal",
+	}
+	MTUSourceVlanConfig = Key{
+		Name:        KeyMTUSourceVlanConfig,
+		Resources:   []string{"ClusterNetwork"},
+		Description: "the name of the vlanconfig the uplink MTU is synced from",
+	}
+	VlanIDSetStr = Key{
+		Name:        KeyVlanIDSetStr,
+		Resources:   []string{"ClusterNetwork"},
+		Description: "the VLAN IDs of the NADs of the cluster network, comma separated",
+	}
+	VlanIDSetStrHash = Key{
+		Name:        KeyVlanIDSetStrHash,
+		Resources:   []string{"ClusterNetwork"},
+		Description: "the hash of the VLAN IDs of the NADs of the cluster network",
+	}
+	DeletedVlanConfigs = Key{
+		Name:        KeyDeletedVlanConfigs,
+		Resources:   []string{"ClusterNetwork"},
+		Description: "the snapshots of the recently deleted vlanconfigs of the cluster network in JSON",
+	}
+	RestoreVlanConfig = Key{
+		Name:        KeyRestoreVlanConfig,
+		Resources:   []string{"ClusterNetwork"},
+		Description: "the request to restore the deleted vlanconfig of the name",
+	}
+	RestoreResult = Key{
+		Name:        KeyRestoreResult,
+		Resources:   []string{"ClusterNetwork"},
+		Description: "the result of the last restore request",
+	}
+	DeletionConfirmation = Key{
+		Name:        KeyDeletionConfirmation,
+		Resources:   []string{"ClusterNetwork"},
+		Description: "confirm or abort the deletion of the cluster network in its grace period",
+	}
+	NetworkRoute = Key{
+		Name:        KeyNetworkRoute,
+		Resources:   []string{"NetworkAttachmentDefinition"},
+		Description: "the layer 3 settings of the network in JSON, i.e. the route mode, IP family, CIDR and gateway",
+	}
+	Uplink = Key{
+		Name:        KeyUplink,
+		Resources:   []string{"NetworkAttachmentDefinition"},
+		Description: "false keeps the VLAN IDs of the network off the uplinks, for the VM-to-VM traffic local to the nodes",
+	}
+	TTL = Key{
+		Name:        KeyTTL,
+		Resources:   []string{"NetworkAttachmentDefinition", "VlanConfig"},
+		Description: "how long the object lives after its creation as a duration, e.g. 2h, the manager deletes it once expired",
+	}
+	QoSMarking = Key{
+		Name:        KeyQoSMarking,
+		Resources:   []string{"NetworkAttachmentDefinition"},
+		Description: `the 802.1p PCP and the DSCP marked on the frames of the network leaving the uplinks in JSON, e.g. {"pcp":5,"dscp":46}`,
+	}
+	VlanDHCPServerIP = Key{
+		Name:        KeyVlanDHCPServerIP,
+		Resources:   []string{"NetworkAttachmentDefinition"},
+		Description: "the IP of the DHCP server found on the network",
+	}
+	LLDPNeighbors = Key{
+		Name:        KeyLLDPNeighbors,
+		Resources:   []string{"Node"},
+		Description: "the LLDP neighbors of the NICs in JSON, set by an LLDP agent",
+	}
+	UnhealthyNetworks = Key{
+		Name:        KeyUnhealthyNetworks,
+		Resources:   []string{"Pod"},
+		Description: "the unhealthy cluster networks the virt-launcher pod is descheduled for, comma separated",
+	}
+)
+
+// Keys are all the keys of the contract
+var Keys = []Key{
+	MatchedNodes, InheritedUplinkFields, CloneRequest, CloneResult, ClonedFrom, ForceTeardown,
+	UplinkMTU, MTUSourceVlanConfig, VlanIDSetStr, VlanIDSetStrHash, DeletedVlanConfigs, RestoreVlanConfig,
+	RestoreResult, DeletionConfirmation,
+	NetworkRoute, VlanDHCPServerIP, Uplink, TTL, QoSMarking,
+	LLDPNeighbors, UnhealthyNetworks,
+}
+
+// Get returns the value of the key, it falls back to the deprecated names if the current one is not set
+func (k Key) Get(obj metav1.Object) (string, bool) {
+	annotations := obj.GetAnnotations()
+	for _, name := range k.names() {
+		if value, ok := annotations[name]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Value returns the value of the key, it's empty if the key is not set
+func (k Key) Value(obj metav1.Object) string {
+	value, _ := k.Get(obj)
+	return value
+}
+
+// Set sets the value of the key under its current name and removes the deprecated names
+func (k Key) Set(obj metav1.Object, value string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	for _, name := range k.Deprecated {
+		delete(annotations, name)
+	}
+	annotations[k.Name] = value
+	obj.SetAnnotations(annotations)
+}
+
+// Delete removes the key under all its names
+func (k Key) Delete(obj metav1.Object) {
+	annotations := obj.GetAnnotations()
+	for _, name := range k.names() {
+		delete(annotations, name)
+	}
+}
+
+// Migrate moves the value of a deprecated name to the current name, it returns true if the annotations are changed
+func (k Key) Migrate(obj metav1.Object) bool {
+	annotations := obj.GetAnnotations()
+	if !slices.ContainsFunc(k.Deprecated, func(name string) bool {
+		_, ok := annotations[name]
+		return ok
+	}) {
+		return false
+	}
+
+	value, _ := k.Get(obj)
+	k.Set(obj, value)
+	return true
+}
+
+// Migrate moves the values of all the deprecated names of the object to the current names, it returns true if the
+// annotations are changed
+func Migrate(obj metav1.Object) bool {
+	changed := false
+	for _, key := range Keys {
+		if key.Migrate(obj) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (k Key) names() []string {
+	return append([]string{k.Name}, k.Deprecated...)
+}
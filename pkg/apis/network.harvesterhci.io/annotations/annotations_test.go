@@ -0,0 +1,111 @@
+package annotations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKeyDeprecatedNames(t *testing.T) {
+	key := Key{Name: "example.io/new", Deprecated: []string{"example.io/old", "example.io/older"}}
+
+	obj := &metav1.ObjectMeta{Annotations: map[string]string{"example.io/older": "v0"}}
+	value, ok := key.Get(obj)
+	assert.True(t, ok)
+	assert.Equal(t, "v0", value)
+
+	// the current name wins over the deprecated ones
+	obj.Annotations["example.io/new"] = "v1"
+	assert.Equal(t, "v1", key.Value(obj))
+
+	key.Set(obj, "v2")
+	assert.Equal(t, map[string]string{"example.io/new": "v2"}, obj.Annotations)
+
+	key.Delete(obj)
+	_, ok = key.Get(obj)
+	assert.False(t, ok)
+}
+
+func TestKeyMigrate(t *testing.T) {
+	key := Key{Name: "example.io/new", Deprecated: []string{"example.io/old"}}
+
+	obj := &metav1.ObjectMeta{Annotations: map[string]string{"example.io/old": "v0", "other": "x"}}
+	assert.True(t, key.Migrate(obj))
+	assert.Equal(t, map[string]string{"example.io/new": "v0", "other": "x"}, obj.Annotations)
+	assert.False(t, key.Migrate(obj))
+
+	assert.False(t, Migrate(&metav1.ObjectMeta{Annotations: map[string]string{KeyUplinkMTU: "9000"}}))
+}
+
+func TestTypedAccessors(t *testing.T) {
+	obj := &metav1.ObjectMeta{}
+
+	assert.NoError(t, SetMatchedNodes(obj, []string{"node2", "node1", "node2"}))
+	assert.Equal(t, `["node1","node2"]`, obj.Annotations[KeyMatchedNodes])
+	nodes, err := GetMatchedNodes(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node1", "node2"}, nodes)
+
+	_, ok, err := GetUplinkMTU(obj)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	SetUplinkMTU(obj, 9000)
+	mtu, ok, err := GetUplinkMTU(obj)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 9000, mtu)
+	UplinkMTU.Set(obj, "jumbo")
+	_, _, err = GetUplinkMTU(obj)
+	assert.Error(t, err)
+
+	SetInheritedUplinkFields(obj, []string{"linkAttributes", "bondOptions"})
+	assert.Equal(t, "bondOptions,linkAttributes", obj.Annotations[KeyInheritedUplinkFields])
+	assert.Equal(t, []string{"bondOptions", "linkAttributes"}, GetInheritedUplinkFields(obj))
+	SetInheritedUplinkFields(obj, nil)
+	assert.NotContains(t, obj.Annotations, KeyInheritedUplinkFields)
+	assert.Nil(t, GetInheritedUplinkFields(obj))
+
+	_, ok, err = GetTTL(obj)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	TTL.Set(obj, "90m")
+	ttl, ok, err := GetTTL(obj)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Minute, ttl)
+	for _, invalid := range []string{"1d", "-1h", "0s"} {
+		TTL.Set(obj, invalid)
+		_, _, err = GetTTL(obj)
+		assert.Error(t, err, invalid)
+	}
+
+	marking, err := GetQoSMarking(obj)
+	assert.NoError(t, err)
+	assert.Nil(t, marking)
+	QoSMarking.Set(obj, `{"pcp":0,"dscp":46}`)
+	marking, err = GetQoSMarking(obj)
+	assert.NoError(t, err)
+	if assert.NotNil(t, marking) {
+		assert.Equal(t, uint8(0), *marking.PCP)
+		assert.Equal(t, uint8(46), *marking.DSCP)
+	}
+	for _, invalid := range []string{`{}`, `{"pcp":8}`, `{"dscp":64}`, `{"cos":1}`, `5`} {
+		QoSMarking.Set(obj, invalid)
+		_, err = GetQoSMarking(obj)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestKeysAreUnique(t *testing.T) {
+	names := map[string]bool{}
+	for _, key := range Keys {
+		for _, name := range key.names() {
+			assert.False(t, names[name], "duplicated key %s", name)
+			names[name] = true
+		}
+		assert.NotEmpty(t, key.Resources, key.Name)
+		assert.NotEmpty(t, key.Description, key.Name)
+	}
+}
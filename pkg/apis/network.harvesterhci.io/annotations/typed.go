@@ -0,0 +1,166 @@
+package annotations
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetMatchedNodes returns the nodes the object matches, it's nil if the annotation is not set or empty
+func GetMatchedNodes(obj metav1.Object) ([]string, error) {
+	return DecodeMatchedNodes(MatchedNodes.Value(obj))
+}
+
+func SetMatchedNodes(obj metav1.Object, nodes []string) error {
+	value, err := EncodeMatchedNodes(nodes)
+	if err != nil {
+		return err
+	}
+	MatchedNodes.Set(obj, value)
+	return nil
+}
+
+// DecodeMatchedNodes parses the value of the annotation KeyMatchedNodes, an empty value means no node is matched
+func DecodeMatchedNodes(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var nodes []string
+	if err := json.Unmarshal([]byte(value), &nodes); err != nil {
+		return nil, fmt.Errorf("invalid matched nodes %s, error: %w", value, err)
+	}
+
+	return nodes, nil
+}
+
+// EncodeMatchedNodes returns the value of the annotation KeyMatchedNodes, the nodes are sorted and deduplicated so
+// that the value is stable
+func EncodeMatchedNodes(nodes []string) (string, error) {
+	sorted := make([]string, 0, len(nodes))
+	seen := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		if !seen[node] {
+			seen[node] = true
+			sorted = append(sorted, node)
+		}
+	}
+	sort.Strings(sorted)
+
+	bytes, err := json.Marshal(sorted)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
+
+// GetUplinkMTU returns the uplink MTU of the cluster network, ok is false if the annotation is not set
+func GetUplinkMTU(obj metav1.Object) (mtu int, ok bool, err error) {
+	value, ok := UplinkMTU.Get(obj)
+	if !ok {
+		return 0, false, nil
+	}
+
+	mtu, err = strconv.Atoi(value)
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid %s %s, error: %w", KeyUplinkMTU, value, err)
+	}
+	return mtu, true, nil
+}
+
+func SetUplinkMTU(obj metav1.Object, mtu int) {
+	UplinkMTU.Set(obj, strconv.Itoa(mtu))
+}
+
+// GetInheritedUplinkFields returns the uplink sections the vlanconfig inherits from its cluster network
+func GetInheritedUplinkFields(obj metav1.Object) []string {
+	value := InheritedUplinkFields.Value(obj)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// SetInheritedUplinkFields records the inherited uplink sections, the annotation is removed if there is none
+func SetInheritedUplinkFields(obj metav1.Object, fields []string) {
+	if len(fields) == 0 {
+		InheritedUplinkFields.Delete(obj)
+		return
+	}
+	sorted := slices.Clone(fields)
+	slices.Sort(sorted)
+	InheritedUplinkFields.Set(obj, strings.Join(sorted, ","))
+}
+
+// GetTTL returns the time to live of the object, ok is false if the annotation is not set
+func GetTTL(obj metav1.Object) (ttl time.Duration, ok bool, err error) {
+	value, ok := TTL.Get(obj)
+	if !ok {
+		return 0, false, nil
+	}
+
+	ttl, err = time.ParseDuration(value)
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid %s %s, error: %w", KeyTTL, value, err)
+	}
+	if ttl <= 0 {
+		return 0, true, fmt.Errorf("invalid %s %s, it must be positive", KeyTTL, value)
+	}
+	return ttl, true, nil
+}
+
+// Marking is the QoS marking of the frames of a network leaving the uplinks, a nil field is left as it is
+type Marking struct {
+	// PCP is the 802.1p priority code point of the VLAN tag, 0..7
+	PCP *uint8 `json:"pcp,omitempty"`
+	// DSCP is the differentiated services code point of the IPv4 and IPv6 headers, 0..63
+	DSCP *uint8 `json:"dscp,omitempty"`
+}
+
+// GetQoSMarking returns the QoS marking of the NAD, it's nil if the annotation is not set
+func GetQoSMarking(obj metav1.Object) (*Marking, error) {
+	value, ok := QoSMarking.Get(obj)
+	if !ok {
+		return nil, nil
+	}
+
+	marking := &Marking{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(marking); err != nil {
+		return nil, fmt.Errorf("invalid %s %s, error: %w", KeyQoSMarking, value, err)
+	}
+	if marking.PCP == nil && marking.DSCP == nil {
+		return nil, fmt.Errorf("invalid %s %s, it needs the pcp or the dscp", KeyQoSMarking, value)
+	}
+	if marking.PCP != nil && *marking.PCP > 7 {
+		return nil, fmt.Errorf("invalid %s %s, the pcp must be in range [0..7]", KeyQoSMarking, value)
+	}
+	if marking.DSCP != nil && *marking.DSCP > 63 {
+		return nil, fmt.Errorf("invalid %s %s, the dscp must be in range [0..63]", KeyQoSMarking, value)
+	}
+
+	return marking, nil
+}
+
+// Equal returns true if both markings set the same PCP and DSCP
+func (m *Marking) Equal(other *Marking) bool {
+	if m == nil || other == nil {
+		return m == other
+	}
+	return equalUint8(m.PCP, other.PCP) && equalUint8(m.DSCP, other.DSCP)
+}
+
+func equalUint8(a, b *uint8) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
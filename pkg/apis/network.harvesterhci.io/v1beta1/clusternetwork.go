@@ -6,15 +6,287 @@ import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:resource:shortName=cn;cns,scope=Cluster
+// +kubebuilder:printcolumn:name="TYPE",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="DESCRIPTION",type=string,JSONPath=`.spec.description`
+// +kubebuilder:printcolumn:name="OWNER",type=string,JSONPath=`.spec.ownership.owner`
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=`.metadata.creationTimestamp`
 
 type ClusterNetwork struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	// +optional
+	Spec ClusterNetworkSpec `json:"spec,omitempty"`
+	// +optional
 	Status ClusterNetworkStatus `json:"status"`
 }
 
+type ClusterNetworkSpec struct {
+	// +optional
+	Description string `json:"description,omitempty"`
+	// Type is how the VM networks of the cluster network reach the other nodes, VLAN trunks them on the uplinks of the
+	// physical NICs and VXLAN tunnels them over the node IPs for the fabrics without VLAN provisioning. It's immutable
+	// +optional
+	// +kubebuilder:default:="VLAN"
+	// +kubebuilder:validation:Enum:=VLAN;VXLAN
+	Type ClusterNetworkType `json:"type,omitempty"`
+	// VXLAN is the tunnel settings of a cluster network of type VXLAN
+	// +optional
+	VXLAN *VXLANOptions `json:"vxlan,omitempty"`
+	// Datapath is how the bridge of a cluster network of type VLAN separates the VLANs on every node. VLANFiltering
+	// trunks all the VLAN IDs through one VLAN aware bridge, BridgePerVID creates a bridge and a VLAN sub-interface of
+	// the uplink per VLAN ID for the NICs whose drivers misbehave with VLAN filtering. Macvlan and Ipvlan have no bridge,
+	// the VMs are attached to the uplink or its VLAN sub-interface per VLAN ID by macvlan or ipvlan links. OVS trunks the
+	// VLAN IDs through an Open vSwitch bridge for the OVN integration and the OVS flows. It's immutable
+	// +optional
+	// +kubebuilder:default:="VLANFiltering"
+	// +kubebuilder:validation:Enum:=VLANFiltering;BridgePerVID;Macvlan;Ipvlan;OVS
+	Datapath Datapath `json:"datapath,omitempty"`
+	// Ownership tracks who owns the physical network segment, it's inherited by the vlanconfigs of the cluster network
+	// +optional
+	Ownership *Ownership `json:"ownership,omitempty"`
+	// UplinkDefaults are inherited by the vlanconfigs of the cluster network which omit the corresponding uplink settings
+	// +optional
+	UplinkDefaults *UplinkDefaults `json:"uplinkDefaults,omitempty"`
+	// Multicast is the IGMP/MLD snooping and querier settings of the bridge of the cluster network on every node,
+	// the kernel default of snooping without querier applies if omitted
+	// +optional
+	Multicast *MulticastOptions `json:"multicast,omitempty"`
+	// Neighbor is the ARP/ND settings of the ports of the bridge of the cluster network, which cut the broadcast load
+	// on a network with many VMs
+	// +optional
+	Neighbor *NeighborOptions `json:"neighbor,omitempty"`
+	// VIDRemoval sequences the removal of the VLAN IDs from the uplink after their NADs are deleted, e.g. with the
+	// namespace, after the VMs using them are torn down. The VLAN IDs are kept for at most 300 seconds while VM ports
+	// use them if omitted
+	// +optional
+	VIDRemoval *VIDRemovalOptions `json:"vidRemoval,omitempty"`
+	// DeletionGracePeriodSeconds keeps the vlanconfigs, and so the bridges and uplinks on the nodes, of a deleted
+	// cluster network for the seconds, until the deletion is confirmed or aborted with the annotation
+	// network.harvesterhci.io/deletion-confirmation. The cluster network is deleted immediately if it's 0
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=604800
+	DeletionGracePeriodSeconds int `json:"deletionGracePeriodSeconds,omitempty"`
+	// MaxVIDs caps the number of the VLAN IDs trunked on the uplinks of the cluster network, every VLAN ID costs MAC
+	// table entries on the switches for each VM. The NADs beyond the cap are rejected, 0 means 512
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=4093
+	MaxVIDs int `json:"maxVIDs,omitempty"`
+	// MaxMTU is the largest MTU the fabric of the cluster network forwards, e.g. 1500 if the switches don't support
+	// jumbo frames. The vlanconfigs and NADs with a larger MTU are rejected instead of losing the large packets
+	// silently, 0 means no limit. It's validated against the cluster-wide MTU bounds
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=65535
+	MaxMTU int `json:"maxMTU,omitempty"`
+	// Descheduling moves the VMs attached to the NADs of the cluster network away from a node whose network of the
+	// cluster network turns unhealthy, the VMs are left alone if omitted
+	// +optional
+	Descheduling *DeschedulingOptions `json:"descheduling,omitempty"`
+	// QoS prioritizes the egress traffic of the cluster network over the other cluster networks sharing the physical
+	// NICs, e.g. to protect the storage replication during VM traffic bursts. Only one cluster network can be
+	// prioritized
+	// +optional
+	QoS *QoSOptions `json:"qos,omitempty"`
+	// StaticVIDs are programmed on the uplinks of the cluster network on every node besides the VLAN IDs of the NADs,
+	// e.g. for the VLAN sub-interfaces of the host which no NAD refers to
+	// +optional
+	// +kubebuilder:validation:MaxItems:=4094
+	StaticVIDs []uint16 `json:"staticVIDs,omitempty"`
+	// UntaggedVID is the VLAN ID of the VMs of the untagged NADs, it leaves the uplinks tagged so that they land on the
+	// VLAN at the switch. The default VLAN ID 1 leaves the uplinks untagged to the native VLAN of the switch port
+	// +optional
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=4094
+	UntaggedVID uint16 `json:"untaggedVID,omitempty"`
+}
+
+type ClusterNetworkType string
+
+const (
+	ClusterNetworkTypeVLAN ClusterNetworkType = "VLAN"
+	// ClusterNetworkTypeVXLAN creates a VXLAN tunnel endpoint on every node as the uplink of the bridge, the broadcast
+	// traffic is replicated to the other nodes having the cluster network
+	ClusterNetworkTypeVXLAN ClusterNetworkType = "VXLAN"
+)
+
+type Datapath string
+
+const (
+	DatapathVLANFiltering Datapath = "VLANFiltering"
+	// DatapathBridgePerVID attaches the VLAN sub-interface <cluster network>.<VID> of the uplink to the bridge
+	// <cluster network>-<VID> without VLAN filtering, the access NADs are pointed at the bridge of their VLAN ID
+	DatapathBridgePerVID Datapath = "BridgePerVID"
+	// DatapathMacvlan creates no bridge and no bond is attached to one, the NADs are realized as macvlan links in bridge
+	// mode on the bond <cluster network>-bo or its VLAN sub-interface <cluster network>.<VID>
+	DatapathMacvlan Datapath = "Macvlan"
+	// DatapathIpvlan is DatapathMacvlan with ipvlan links in l2 mode, which share the MAC address of the uplink for the
+	// NICs and switch ports limiting the MAC addresses
+	DatapathIpvlan Datapath = "Ipvlan"
+	// DatapathOVS creates the Open vSwitch bridge <cluster network>-br instead of a Linux bridge, the bond is added as
+	// a port trunking the VLAN IDs and the NADs are realized by the OVS CNI. Open vSwitch has to run on the nodes.
+	DatapathOVS Datapath = "OVS"
+)
+
+type VXLANOptions struct {
+	// the VXLAN network identifier, unique among the cluster networks
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=16777215
+	VNI int `json:"vni"`
+	// the UDP destination port of the tunnels, 0 means 4789
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=65535
+	Port int `json:"port,omitempty"`
+}
+
+type QoSPriority string
+
+const (
+	QoSPriorityNormal QoSPriority = "Normal"
+	// QoSPriorityHigh sets the skb priority of the traffic leaving the uplink, so that the qdiscs of the physical NICs
+	// transmit it first
+	QoSPriorityHigh QoSPriority = "High"
+)
+
+type QoSOptions struct {
+	// +optional
+	// +kubebuilder:default:="Normal"
+	// +kubebuilder:validation:Enum:=Normal;High
+	Priority QoSPriority `json:"priority,omitempty"`
+}
+
+// Ownership is propagated into the labels of the vlanstatuses, so the values must be valid label values
+type Ownership struct {
+	// the owner or team of the physical network segment
+	// +optional
+	// +kubebuilder:validation:MaxLength:=63
+	// +kubebuilder:validation:Pattern:=`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`
+	Owner string `json:"owner,omitempty"`
+	// the reference of the external ticket the physical network segment is tracked with
+	// +optional
+	// +kubebuilder:validation:MaxLength:=63
+	// +kubebuilder:validation:Pattern:=`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`
+	TicketRef string `json:"ticketRef,omitempty"`
+}
+
+type DeschedulingPolicy string
+
+const (
+	// DeschedulingAnnotate labels the virt-launcher pods of the VMs as descheduling candidates, e.g. for the
+	// descheduler to evict them
+	DeschedulingAnnotate DeschedulingPolicy = "Annotate"
+	// DeschedulingMigrate live migrates the VMs to the other nodes
+	DeschedulingMigrate DeschedulingPolicy = "Migrate"
+)
+
+type DeschedulingOptions struct {
+	// +kubebuilder:validation:Enum:=Annotate;Migrate
+	Policy DeschedulingPolicy `json:"policy"`
+	// Degraded deschedules the VMs from a node whose uplink is degraded as well, not only not ready
+	// +optional
+	Degraded bool `json:"degraded,omitempty"`
+	// the seconds the network has to stay unhealthy before the VMs are descheduled, 0 means 60
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=3600
+	DelaySeconds int `json:"delaySeconds,omitempty"`
+}
+
+type VIDRemovalPolicy string
+
+const (
+	// VIDRemovalWaitForPorts keeps a VLAN ID on the uplink while the VM ports on the bridge still use it
+	VIDRemovalWaitForPorts VIDRemovalPolicy = "WaitForPorts"
+	// VIDRemovalImmediate removes a VLAN ID from the uplink as soon as no NAD has it
+	VIDRemovalImmediate VIDRemovalPolicy = "Immediate"
+)
+
+type VIDRemovalOptions struct {
+	// +optional
+	// +kubebuilder:default:="WaitForPorts"
+	// +kubebuilder:validation:Enum:=WaitForPorts;Immediate
+	Policy VIDRemovalPolicy `json:"policy,omitempty"`
+	// the maximum seconds to keep a VLAN ID for the VM ports, 0 means 300
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=3600
+	MaxWaitSeconds int `json:"maxWaitSeconds,omitempty"`
+}
+
+// UplinkDefaults is the default uplink settings of a cluster network.
+// A vlanconfig inherits a section if it omits the section, and keeps following the default until it overrides
+// the section with its own value.
+type UplinkDefaults struct {
+	// +optional
+	LinkAttrs *LinkAttrs `json:"linkAttributes,omitempty"`
+	// +optional
+	BondOptions *BondOptions `json:"bondOptions,omitempty"`
+	// +optional
+	QueueOptions *QueueOptions `json:"queueOptions,omitempty"`
+}
+
+type MulticastOptions struct {
+	// Snooping restricts the multicast traffic to the ports having joined the groups
+	// +optional
+	Snooping bool `json:"snooping"`
+	// Querier makes the bridge send the IGMP/MLD queries if there is no multicast router on the network
+	// +optional
+	Querier bool `json:"querier"`
+	// VLANs overrides the settings per VID, the per-VLAN multicast context of the bridge is enabled if there is any and
+	// the other VLANs follow the settings of the cluster network
+	// +optional
+	// +listType=map
+	// +listMapKey=vid
+	VLANs []VlanMulticastOptions `json:"vlans,omitempty"`
+}
+
+type VlanMulticastOptions struct {
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	VID uint16 `json:"vid"`
+	// +optional
+	Snooping bool `json:"snooping"`
+	// +optional
+	Querier bool `json:"querier"`
+}
+
+type NeighborOptions struct {
+	// ProxyARP makes the bridge answer the ARP requests received on the ports on behalf of the neighbors it knows
+	// +optional
+	ProxyARP bool `json:"proxyARP"`
+	// Suppression stops flooding the ARP/ND requests to the ports if the bridge has answered them
+	// +optional
+	Suppression bool `json:"suppression"`
+}
+
 type ClusterNetworkStatus struct {
 	// +optional
 	Conditions []Condition `json:"conditions,omitempty"`
+	// InUse is the NADs of the cluster network attached to the running pods and VMs, their VLAN IDs are kept on the
+	// uplinks until the last consumer is gone even if the NAD is deleted
+	// +optional
+	InUse []NetworkUsage `json:"inUse,omitempty"`
+}
+
+type NetworkUsage struct {
+	// NAD is the <namespace>/<name> of the NAD
+	NAD string `json:"nad"`
+	// VIDs are the VLAN IDs of the NAD recorded when it's in use
+	// +optional
+	VIDs []uint16 `json:"vids,omitempty"`
+	// Deleted is true if the NAD is deleted while it's still in use
+	// +optional
+	Deleted bool `json:"deleted,omitempty"`
+	// Consumers are the <namespace>/<name> of the pods attached to the NAD according to their multus network status
+	Consumers []string `json:"consumers"`
+	// PreviousVIDs are the VLAN IDs of the NAD before they are changed, which are kept on the uplinks while the stale
+	// consumers still use them
+	// +optional
+	PreviousVIDs []uint16 `json:"previousVIDs,omitempty"`
+	// StaleConsumers are the consumers attached before the VLAN IDs of the NAD are changed, which keep using the
+	// previous VLAN IDs until they are restarted or migrated
+	// +optional
+	StaleConsumers []string `json:"staleConsumers,omitempty"`
 }
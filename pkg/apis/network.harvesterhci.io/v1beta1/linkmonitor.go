@@ -11,6 +11,23 @@ const (
 	LinkUnknown LinkState = "unknown"
 )
 
+// LinkOwner is the component which owns a link on the node, a physical NIC owned by nothing is free to be used as an
+// uplink
+type LinkOwner string
+
+const (
+	LinkOwnerNone LinkOwner = ""
+	// LinkOwnerController is the bridges, bonds and VLAN sub-interfaces of the cluster networks and the NICs enslaved
+	// by them
+	LinkOwnerController LinkOwner = "network-controller"
+	// LinkOwnerSystem is the loopback and the management network set up by the OS
+	LinkOwnerSystem   LinkOwner = "system"
+	LinkOwnerCanal    LinkOwner = "canal"
+	LinkOwnerCilium   LinkOwner = "cilium"
+	LinkOwnerKubeVirt LinkOwner = "kubevirt"
+	LinkOwnerUnknown  LinkOwner = "unknown"
+)
+
 // +genclient
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -60,4 +77,7 @@ type LinkStatus struct {
 	State LinkState `json:"state,omitempty"`
 	// +optional
 	MasterIndex int `json:"masterIndex,omitempty"`
+	// Owner is empty if the link is a free physical NIC
+	// +optional
+	Owner LinkOwner `json:"owner,omitempty"`
 }
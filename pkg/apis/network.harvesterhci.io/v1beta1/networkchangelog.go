@@ -0,0 +1,45 @@
+package v1beta1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=ncl;ncls,scope=Cluster
+// +kubebuilder:printcolumn:name="KIND",type=string,JSONPath=`.spec.kind`
+// +kubebuilder:printcolumn:name="NAMESPACE",type=string,JSONPath=`.spec.namespace`
+// +kubebuilder:printcolumn:name="NAME",type=string,JSONPath=`.spec.name`
+// +kubebuilder:printcolumn:name="OPERATION",type=string,JSONPath=`.spec.operation`
+// +kubebuilder:printcolumn:name="USER",type=string,JSONPath=`.spec.user`
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=`.metadata.creationTimestamp`
+
+// NetworkChangeLog records a change of a cluster network, vlanconfig or NAD admitted by the webhook, it's only written
+// if the webhook is started with --audit. It's written at the admission, a change rejected afterwards by another
+// admission step or the API server is recorded though it's never persisted
+type NetworkChangeLog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              NetworkChangeLogSpec `json:"spec"`
+}
+
+type NetworkChangeLogSpec struct {
+	// the kind of the changed resource, ClusterNetwork, VlanConfig or NetworkAttachmentDefinition
+	Kind string `json:"kind"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// CREATE, UPDATE or DELETE
+	Operation string `json:"operation"`
+	// the user making the change
+	User string `json:"user"`
+	// +optional
+	Groups    []string    `json:"groups,omitempty"`
+	Timestamp metav1.Time `json:"timestamp"`
+	// the summary of the changed fields of the spec, labels and annotations, one field per entry in the form of
+	// "<path>: <old> -> <new>"
+	// +optional
+	Changes []string `json:"changes,omitempty"`
+	// true if the summary is cut short
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+}
@@ -0,0 +1,40 @@
+package v1beta1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=nicclaim;nicclaims,scope=Cluster
+// +kubebuilder:printcolumn:name="NODE",type=string,JSONPath=`.spec.node`
+// +kubebuilder:printcolumn:name="OWNER",type=string,JSONPath=`.spec.owner`
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=`.metadata.creationTimestamp`
+
+// NetworkInterfaceClaim reserves NICs of a node for the exclusive use of another component, e.g. the SR-IOV operator,
+// PCI passthrough or storage, the claimed NICs are not allowed to be used by the vlanconfigs and vice versa
+type NetworkInterfaceClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              NetworkInterfaceClaimSpec `json:"spec"`
+	// +optional
+	Status NetworkInterfaceClaimStatus `json:"status,omitempty"`
+}
+
+type NetworkInterfaceClaimSpec struct {
+	// the node where the NICs are
+	// +kubebuilder:validation:MinLength=1
+	Node string `json:"node"`
+	// the names of the NICs on the node
+	// +kubebuilder:validation:MinItems=1
+	NICs []string `json:"nics"`
+	// the component claiming the NICs
+	// +kubebuilder:validation:MinLength=1
+	Owner string `json:"owner"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+type NetworkInterfaceClaimStatus struct {
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
@@ -0,0 +1,66 @@
+package v1beta1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=nns;nnss,scope=Cluster
+// +kubebuilder:printcolumn:name="QINQ",type=boolean,JSONPath=`.status.capabilities.qinq`
+// +kubebuilder:printcolumn:name="SRIOV",type=boolean,JSONPath=`.status.capabilities.sriov`
+// +kubebuilder:printcolumn:name="VXLANOFFLOAD",type=boolean,JSONPath=`.status.capabilities.vxlanOffload`
+// +kubebuilder:printcolumn:name="MAXMTU",type=integer,JSONPath=`.status.capabilities.maxMTU`
+// +kubebuilder:printcolumn:name="AGENT",type=string,JSONPath=`.status.agentVersion`,priority=1
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=`.metadata.creationTimestamp`
+
+// NodeNetworkState reports the network capabilities of the node named after it, the agent discovers them once at
+// startup as they're fixed by the hardware and the kernel
+type NodeNetworkState struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status NodeNetworkStateStatus `json:"status"`
+}
+
+type NodeNetworkStateStatus struct {
+	// +optional
+	Capabilities NodeCapabilities `json:"capabilities,omitempty"`
+	// the physical NICs of the node
+	// +optional
+	NICs []NICCapabilities `json:"nics,omitempty"`
+	// the time the capabilities were discovered
+	// +optional
+	DiscoveredAt *metav1.Time `json:"discoveredAt,omitempty"`
+	// the build version of the agent which discovered the capabilities
+	// +optional
+	AgentVersion string `json:"agentVersion,omitempty"`
+}
+
+// NodeCapabilities summarizes the capabilities of the NICs and the kernel of the node
+type NodeCapabilities struct {
+	// the kernel can stack VLAN tags, i.e. 802.1ad and a VLAN sub-interface below the VLAN filtering bridge like the
+	// service VLAN of the uplink
+	QinQ bool `json:"qinq"`
+	// any NIC supports SR-IOV virtual functions
+	SRIOV bool `json:"sriov"`
+	// any NIC offloads the segmentation of the VXLAN traffic
+	VxlanOffload bool `json:"vxlanOffload"`
+	// the largest MTU supported by any NIC, 0 if no NIC reports it
+	// +optional
+	MaxMTU int `json:"maxMTU,omitempty"`
+	// the agent can reach the ovsdb-server of the node with ovs-vsctl, which the datapath OVS requires
+	// +optional
+	OVS bool `json:"ovs,omitempty"`
+}
+
+type NICCapabilities struct {
+	Name string `json:"name"`
+	// the largest MTU the driver accepts, 0 if the kernel doesn't report it
+	// +optional
+	MaxMTU int `json:"maxMTU,omitempty"`
+	// the number of the SR-IOV virtual functions the NIC supports, 0 if it doesn't support SR-IOV
+	// +optional
+	SriovTotalVFs int `json:"sriovTotalVFs,omitempty"`
+	// +optional
+	VxlanOffload bool `json:"vxlanOffload,omitempty"`
+}
@@ -0,0 +1,92 @@
+package v1beta1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +kubebuilder:validation:Enum=Running;Succeeded;Failed
+type PacketCapturePhase string
+
+const (
+	PacketCaptureRunning   PacketCapturePhase = "Running"
+	PacketCaptureSucceeded PacketCapturePhase = "Succeeded"
+	PacketCaptureFailed    PacketCapturePhase = "Failed"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=pcap;pcaps,scope=Cluster
+// +kubebuilder:printcolumn:name="NODE",type=string,JSONPath=`.spec.nodeName`
+// +kubebuilder:printcolumn:name="CLUSTERNETWORK",type=string,JSONPath=`.spec.clusterNetwork`
+// +kubebuilder:printcolumn:name="VID",type=integer,JSONPath=`.spec.vid`
+// +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="FRAMES",type=integer,JSONPath=`.status.frames`
+// +kubebuilder:printcolumn:name="FILE",type=string,JSONPath=`.status.file`,priority=1
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=`.metadata.creationTimestamp`
+
+// PacketCapture captures the frames of a cluster network on a node into a pcap file once, for a limited time and up to
+// a limited size. The agent of the node writes the file into its capture directory, which is collected by the support
+// bundle, and removes it together with the PacketCapture. The spec can't be changed after creation.
+type PacketCapture struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PacketCaptureSpec `json:"spec"`
+	// +optional
+	Status PacketCaptureStatus `json:"status,omitempty"`
+}
+
+type PacketCaptureSpec struct {
+	NodeName       string `json:"nodeName"`
+	ClusterNetwork string `json:"clusterNetwork"`
+	// the VLAN ID of the frames captured, 0 captures the frames of all the VLAN IDs and the untagged ones
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=4094
+	VID uint16 `json:"vid,omitempty"`
+	// the port of the bridge of the cluster network to capture on, e.g. the tap of a VM, the uplink of the cluster
+	// network if empty
+	// +optional
+	Interface string `json:"interface,omitempty"`
+	// +optional
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=3600
+	// +kubebuilder:default:=60
+	DurationSeconds int `json:"durationSeconds,omitempty"`
+	// the capture is stopped once the pcap file reaches the size
+	// +optional
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=256
+	// +kubebuilder:default:=16
+	MaxSizeMiB int `json:"maxSizeMiB,omitempty"`
+	// the bytes kept of each frame, 0 keeps the whole frame
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=65535
+	SnapLen int `json:"snapLen,omitempty"`
+}
+
+type PacketCaptureStatus struct {
+	// +optional
+	Phase PacketCapturePhase `json:"phase,omitempty"`
+	// the reason of the failure
+	// +optional
+	Message string `json:"message,omitempty"`
+	// the link captured on
+	// +optional
+	Interface string `json:"interface,omitempty"`
+	// the path of the pcap file on the node
+	// +optional
+	File string `json:"file,omitempty"`
+	// +optional
+	Frames int64 `json:"frames,omitempty"`
+	// the size of the pcap file
+	// +optional
+	Bytes int64 `json:"bytes,omitempty"`
+	// the capture is stopped by the size limit before the duration elapses
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// +optional
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+}
@@ -12,28 +12,186 @@ import (
 // +kubebuilder:resource:shortName=vc;vcs,scope=Cluster
 // +kubebuilder:printcolumn:name="CLUSTERNETWORK",type=string,JSONPath=`.spec.clusterNetwork`
 // +kubebuilder:printcolumn:name="DESCRIPTION",type=string,JSONPath=`.spec.description`
+// +kubebuilder:printcolumn:name="OWNER",type=string,JSONPath=`.spec.ownership.owner`
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=`.metadata.creationTimestamp`
 
 type VlanConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	Spec              VlanConfigSpec `json:"spec"`
+	// +optional
+	Status VlanConfigStatus `json:"status,omitempty"`
+}
+
+type VlanConfigStatus struct {
+	// the nodes which still hold the interfaces of the vlanconfig while it's being deleted
+	// +optional
+	TeardownPendingNodes []string `json:"teardownPendingNodes,omitempty"`
 }
 
 type VlanConfigSpec struct {
 	// +optional
-	Description    string            `json:"description,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Ownership overrides the fields set in the ownership of the cluster network
+	// +optional
+	Ownership      *Ownership        `json:"ownership,omitempty"`
 	ClusterNetwork string            `json:"clusterNetwork"`
 	NodeSelector   map[string]string `json:"nodeSelector,omitempty"`
 	Uplink         Uplink            `json:"uplink"`
+	// TopologyOverrides adjust the uplink on the matched nodes of a zone or rack, so that one vlanconfig covers the
+	// racks cabled differently. The first override matching the labels of a node applies
+	// +optional
+	TopologyOverrides []TopologyOverride `json:"topologyOverrides,omitempty"`
+}
+
+// TopologyOverride replaces the set fields of the uplink on the nodes whose topology label has any of the values
+type TopologyOverride struct {
+	// the node label of the topology, topology.kubernetes.io/zone if omitted, e.g. a rack label
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+	// +kubebuilder:validation:MinItems:=1
+	Values []string `json:"values"`
+	// +optional
+	NICs []string `json:"nics,omitempty"`
+	// +optional
+	BackupNICs []string `json:"backupNICs,omitempty"`
+	// the MTU of the uplink, it can't be less than the MTU of the vlanconfig which the NADs of the cluster network follow
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	MTU int `json:"mtu,omitempty"`
+	// +optional
+	BondOptions *BondOptions `json:"bondOptions,omitempty"`
 }
 
 type Uplink struct {
 	NICs []string `json:"nics,omitempty"`
+	// BackupNICs are the NICs in nics which never carry the traffic while any other NIC has carrier, e.g. a USB NIC or
+	// an LTE bridge as the fallback link of an edge deployment. Only valid in the active-backup mode
+	// +optional
+	BackupNICs []string `json:"backupNICs,omitempty"`
 	// +optional
 	LinkAttrs *LinkAttrs `json:"linkAttributes,omitempty"`
 	// +optional
 	BondOptions *BondOptions `json:"bondOptions,omitempty"`
+	// SharedBond attaches the cluster network to a VLAN sub-interface of a bond shared with other cluster networks
+	// +optional
+	SharedBond *SharedBond `json:"sharedBond,omitempty"`
+	// ServiceVLAN attaches the bridge to the VLAN sub-interface <cluster network>-bo.<vid> of the bond rather than the
+	// bond, where the provider hands off all the VM networks on a single tagged service VLAN
+	// +optional
+	// +kubebuilder:validation:Minimum:=2
+	// +kubebuilder:validation:Maximum:=4094
+	ServiceVLAN uint16 `json:"serviceVLAN,omitempty"`
+	// FabricB is the uplink group connected to the second fabric, the NICs above are connected to the first fabric.
+	// Each group is bonded separately, only one bond is attached to the bridge at a time. Fabric A is preferred,
+	// the bridge fails over to fabric B when fabric A loses carrier.
+	// +optional
+	FabricB *FabricUplink `json:"fabricB,omitempty"`
+	// +optional
+	QueueOptions *QueueOptions `json:"queueOptions,omitempty"`
+	// ethtool settings of the NICs, the NIC must be one of the uplink NICs
+	// +optional
+	NICTuning []NICTuning `json:"nicTuning,omitempty"`
+	// seconds the uplink has to keep carrier after it's set up before the vlanstatus turns ready, to wait for the
+	// switch port to finish STP learning or LACP negotiation, 0 means the vlanstatus turns ready immediately
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=600
+	CarrierSettleSeconds int `json:"carrierSettleSeconds,omitempty"`
+	// LoopDetection probes the uplink for a layer 2 loop before it's attached to the bridge, the uplink is kept
+	// detached if a loop is detected
+	// +optional
+	LoopDetection *LoopDetection `json:"loopDetection,omitempty"`
+	// JumboVerification pings a target on the VLAN with the packets of the full MTU which must not be fragmented once
+	// the MTU of the uplink is at least 9000, the vlanstatus only turns ready if they're answered
+	// +optional
+	JumboVerification *JumboVerification `json:"jumboVerification,omitempty"`
+}
+
+// JumboVerification verifies the jumbo frames end to end, e.g. a switch drops them silently despite the MTU configured
+// on the node
+type JumboVerification struct {
+	// the VLAN ID the pings are tagged with, 0 means untagged
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=4094
+	VID uint16 `json:"vid,omitempty"`
+	// the IPv4 address answering the pings, e.g. the gateway of the VLAN or a peer node
+	Target string `json:"target"`
+	// a spare IPv4 address in the subnet of the target the pings are sent from, it's not assigned to any interface
+	Source string `json:"source"`
+	// milliseconds to wait for the answer, 0 means 1000
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=10000
+	TimeoutMilliseconds int `json:"timeoutMilliseconds,omitempty"`
+}
+
+// LoopDetection sends broadcast probe frames out of the uplink and checks whether they come back, e.g. the NICs are
+// cabled to switch ports which are bridged without STP
+type LoopDetection struct {
+	// the VLAN ID the probe frames are tagged with, 0 means untagged
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=4094
+	VID uint16 `json:"vid,omitempty"`
+	// milliseconds to wait for the probe frames to come back, 0 means 500
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=5000
+	TimeoutMilliseconds int `json:"timeoutMilliseconds,omitempty"`
+}
+
+// NICTuning adjusts the ring buffer and interrupt coalescing of a NIC, the omitted parameters are kept as they are
+type NICTuning struct {
+	Name string `json:"name"`
+	// +optional
+	Ring *RingOptions `json:"ring,omitempty"`
+	// +optional
+	Coalesce *CoalesceOptions `json:"coalesce,omitempty"`
+}
+
+// RingOptions is equivalent to `ethtool -G <nic> rx <rx> tx <tx>`
+type RingOptions struct {
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	RX uint32 `json:"rx,omitempty"`
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	TX uint32 `json:"tx,omitempty"`
+}
+
+// CoalesceOptions is equivalent to `ethtool -C <nic> adaptive-rx on rx-usecs <rxUsecs> ...`
+type CoalesceOptions struct {
+	// +optional
+	AdaptiveRX *bool `json:"adaptiveRX,omitempty"`
+	// +optional
+	AdaptiveTX *bool `json:"adaptiveTX,omitempty"`
+	// +optional
+	RXUsecs *uint32 `json:"rxUsecs,omitempty"`
+	// +optional
+	RXFrames *uint32 `json:"rxFrames,omitempty"`
+	// +optional
+	TXUsecs *uint32 `json:"txUsecs,omitempty"`
+	// +optional
+	TXFrames *uint32 `json:"txFrames,omitempty"`
+}
+
+type FabricUplink struct {
+	// +kubebuilder:validation:MinItems:=1
+	NICs []string `json:"nics"`
+}
+
+// SharedBond is a bond shared by multiple cluster networks, each cluster network has its own bridge
+// whose uplink is the VLAN sub-interface <name>.<vid> of the bond.
+// All vlanconfigs sharing the bond must have the same NICs, link attributes and bond options.
+type SharedBond struct {
+	// +kubebuilder:validation:MinLength:=1
+	// +kubebuilder:validation:MaxLength:=10
+	Name string `json:"name"`
+	// +kubebuilder:validation:Minimum:=2
+	// +kubebuilder:validation:Maximum:=4094
+	VID uint16 `json:"vid"`
 }
 
 type LinkAttrs struct {
@@ -48,15 +206,85 @@ type LinkAttrs struct {
 	HardwareAddr net.HardwareAddr `json:"hardwareAddr,omitempty"`
 }
 
+// QueueOptions configures the transmit queues of the uplink bond and optionally the bridge
+type QueueOptions struct {
+	// root queue discipline, the kernel default is kept if omitted
+	// +optional
+	Qdisc Qdisc `json:"qdisc,omitempty"`
+	// queue discipline attached to every TX queue, only valid when the root qdisc is mq
+	// +optional
+	// +kubebuilder:validation:Enum={"fq","fq_codel"}
+	PerQueueQdisc Qdisc `json:"perQueueQdisc,omitempty"`
+	// packet limit of the fq/fq_codel qdiscs, 0 means the kernel default
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	Limit uint32 `json:"limit,omitempty"`
+	// number of TX/RX queues of the bond, 0 means the kernel default, changing it recreates the bond
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=1024
+	NumTxQueues int `json:"numTxQueues,omitempty"`
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=1024
+	NumRxQueues int `json:"numRxQueues,omitempty"`
+	// apply the root qdisc to the bridge as well
+	// +optional
+	ApplyToBridge bool `json:"applyToBridge,omitempty"`
+}
+
+// +kubebuilder:validation:Enum={"fq","fq_codel","mq"}
+
+type Qdisc string
+
+const (
+	QdiscFq      Qdisc = "fq"
+	QdiscFqCodel Qdisc = "fq_codel"
+	QdiscMq      Qdisc = "mq"
+)
+
 // reference: https://www.kernel.org/doc/Documentation/networking/bonding.txt
 type BondOptions struct {
 	// +optional
 	// +kubebuilder:default:="active-backup"
 	Mode BondMode `json:"mode,omitempty"`
+	// milliseconds between the checks of the carrier of the slaves, -1 for the default 100. 0 turns the link
+	// monitoring of the bond off for the NICs without miimon support, e.g. in the nested clusters, the agent tracks the
+	// carrier of the NICs over netlink instead. It's not omitted when 0, it would be defaulted to -1 again otherwise.
 	// +optional
 	// +kubebuilder:validation:Minimum:=-1
 	// +kubebuilder:default:=-1
-	Miimon int `json:"miimon,omitempty"`
+	Miimon int `json:"miimon"`
+	// milliseconds to wait before disabling a slave after its link failure is detected, a multiple of miimon
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	DownDelay int `json:"downDelay,omitempty"`
+	// milliseconds to wait before enabling a slave after its link recovery is detected, a multiple of miimon
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	UpDelay int `json:"upDelay,omitempty"`
+	// the hash policy to pick the slave to transmit on, only meaningful in the modes balance-xor, 802.3ad and
+	// balance-tlb, where it defaults to layer2 like the kernel does
+	// +optional
+	XmitHashPolicy XmitHashPolicy `json:"xmitHashPolicy,omitempty"`
+	// the rate the LACP partner is asked to transmit the LACPDUs at, fast every second and slow every 30 seconds,
+	// only meaningful in the mode 802.3ad, where it defaults to slow like the kernel does
+	// +optional
+	LacpRate LacpRate `json:"lacpRate,omitempty"`
+	// milliseconds between the ARP probes to the arpIPTargets monitoring the slaves, an alternative to miimon for the
+	// NICs whose drivers report the carrier incorrectly. It replaces miimon, which must be omitted or 0, and isn't
+	// supported in the modes 802.3ad, balance-tlb and balance-alb.
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	ArpInterval int `json:"arpInterval,omitempty"`
+	// the IPv4 addresses probed by the ARP monitoring, e.g. the gateway of the network behind the uplink
+	// +optional
+	// +kubebuilder:validation:MaxItems:=16
+	ArpIPTargets []string `json:"arpIPTargets,omitempty"`
+	// which slaves validate the ARP replies they receive, rather than taking any traffic as a sign of life, none by
+	// default like the kernel does
+	// +optional
+	ArpValidate ArpValidate `json:"arpValidate,omitempty"`
 }
 
 // +kubebuilder:validation:Enum={"balance-rr","active-backup","balance-xor","broadcast","802.3ad","balance-tlb","balance-alb"}
@@ -72,3 +300,36 @@ const (
 	BondModeBalanceTlb   BondMode = "balance-tlb"
 	BondModeBalanceAlb   BondMode = "balance-alb"
 )
+
+// +kubebuilder:validation:Enum={"none","active","backup","all"}
+
+type ArpValidate string
+
+const (
+	ArpValidateNone   ArpValidate = "none"
+	ArpValidateActive ArpValidate = "active"
+	ArpValidateBackup ArpValidate = "backup"
+	ArpValidateAll    ArpValidate = "all"
+)
+
+// +kubebuilder:validation:Enum={"slow","fast"}
+
+type LacpRate string
+
+const (
+	LacpRateSlow LacpRate = "slow"
+	LacpRateFast LacpRate = "fast"
+)
+
+// +kubebuilder:validation:Enum={"layer2","layer2+3","layer3+4","encap2+3","encap3+4","vlan+srcmac"}
+
+type XmitHashPolicy string
+
+const (
+	XmitHashPolicyLayer2     XmitHashPolicy = "layer2"
+	XmitHashPolicyLayer23    XmitHashPolicy = "layer2+3"
+	XmitHashPolicyLayer34    XmitHashPolicy = "layer3+4"
+	XmitHashPolicyEncap23    XmitHashPolicy = "encap2+3"
+	XmitHashPolicyEncap34    XmitHashPolicy = "encap3+4"
+	XmitHashPolicyVlanSrcMAC XmitHashPolicy = "vlan+srcmac"
+)
@@ -13,7 +13,11 @@ import (
 // +kubebuilder:printcolumn:name="CLUSTERNETWORK",type=string,JSONPath=`.status.clusterNetwork`
 // +kubebuilder:printcolumn:name="VLANCONFIG",type=string,JSONPath=`.status.vlanConfig`
 // +kubebuilder:printcolumn:name="NODE",type=string,JSONPath=`.status.node`
+// +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="DESCRIPTION",type=string,JSONPath=`.spec.description`
+// +kubebuilder:printcolumn:name="ACTIVENIC",type=string,JSONPath=`.status.activeNIC`,priority=1
+// +kubebuilder:printcolumn:name="AGENT",type=string,JSONPath=`.status.agentVersion`,priority=1
+// +kubebuilder:printcolumn:name="RECONCILED",type="date",JSONPath=`.status.lastReconciledAt`,priority=1
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=`.metadata.creationTimestamp`
 
 type VlanStatus struct {
@@ -32,11 +36,177 @@ type VlStatus struct {
 
 	Node string `json:"node"`
 	// +optional
+	Phase VlanPhase `json:"phase,omitempty"`
+	// +optional
 	LocalAreas []LocalArea `json:"localAreas,omitempty"`
+	// the fabric whose bond is attached to the bridge, only set when fabric B is configured
+	// +optional
+	ActiveFabric Fabric `json:"activeFabric,omitempty"`
+	// the NICs enslaved to the uplink by the last successful setup
+	// +optional
+	UplinkNICs []string `json:"uplinkNICs,omitempty"`
+	// the negotiated speed of the uplink NICs
+	// +optional
+	LinkSpeeds []LinkSpeed `json:"linkSpeeds,omitempty"`
+	// the time since when the uplink keeps carrier, only recorded if the vlanconfig requires the carrier to settle
+	// +optional
+	CarrierUpSince *metav1.Time `json:"carrierUpSince,omitempty"`
+	// the multicast settings in effect on the bridge of the cluster network
+	// +optional
+	Multicast *MulticastStatus `json:"multicast,omitempty"`
+	// the VLAN IDs programmed on the uplink of the bridge of the cluster network
+	// +optional
+	VIDs []uint16 `json:"vids,omitempty"`
+	// the MTU of the uplink set by the last successful setup
+	// +optional
+	MTU int `json:"mtu,omitempty"`
+	// the negotiation of an 802.3ad uplink with the switch
+	// +optional
+	LACP *LACPStatus `json:"lacp,omitempty"`
+	// the NIC carrying the traffic of an active-backup uplink
+	// +optional
+	ActiveNIC string `json:"activeNIC,omitempty"`
+	// true if the traffic is carried by one of the backup NICs of the uplink as all the other NICs lost carrier
+	// +optional
+	OnBackupNIC bool `json:"onBackupNIC,omitempty"`
+	// true if the bond of the uplink has no link monitoring and the agent tracks the carrier of its NICs instead
+	// +optional
+	CarrierTracked bool `json:"carrierTracked,omitempty"`
+	// the uplink NICs without carrier, only tracked along with CarrierTracked
+	// +optional
+	NICsWithoutCarrier []string `json:"nicsWithoutCarrier,omitempty"`
+	// the average throughput of the uplink over the last sampling interval, only sampled if the agent is started with
+	// --uplink-utilization
+	// +optional
+	Utilization *UplinkUtilization `json:"utilization,omitempty"`
+	// the last time the agent reconciled the vlanconfig on the node, it's only refreshed every few minutes if the
+	// reconcile changes nothing else
+	// +optional
+	LastReconciledAt *metav1.Time `json:"lastReconciledAt,omitempty"`
+	// the build version of the agent which reconciled the vlanconfig last
+	// +optional
+	AgentVersion string `json:"agentVersion,omitempty"`
+	// the vlanconfig features the agent understands, the webhook refuses a vlanconfig using a feature not understood
+	// by the agents of its nodes
+	// +optional
+	Features []string `json:"features,omitempty"`
+	// the generation of the vlanconfig observed by the last reconcile
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// the latest transitions of the condition Ready, the oldest first, to investigate the intermittent issues after the
+	// fact. The oldest are dropped beyond 16 transitions.
+	// +optional
+	ReadyTransitions []ReadyTransition `json:"readyTransitions,omitempty"`
+	// the steps of the last setup, or of the last failed teardown, in the order they run. The steps after a failed
+	// one are pending.
+	// +optional
+	Steps []SetupStep `json:"steps,omitempty"`
 	// +optional
 	Conditions []Condition `json:"conditions,omitempty"`
 }
 
+// SetupStep is the outcome of a step of the setup or the teardown of the VLAN on the node
+type SetupStep struct {
+	Name  string    `json:"name"`
+	Phase StepPhase `json:"phase"`
+	// the error of the failed step
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:validation:Enum={"Succeeded","Failed","Skipped","Pending"}
+type StepPhase string
+
+const (
+	StepSucceeded StepPhase = "Succeeded"
+	StepFailed    StepPhase = "Failed"
+	// StepSkipped means the step doesn't apply, e.g. the steps of the NICs on an overlay cluster network
+	StepSkipped StepPhase = "Skipped"
+	// StepPending means the step isn't run as an earlier step failed
+	StepPending StepPhase = "Pending"
+)
+
+// ReadyTransition is a change of the condition Ready of the vlanstatus
+type ReadyTransition struct {
+	Time  metav1.Time `json:"time"`
+	Ready bool        `json:"ready"`
+	// the message of the condition Ready, why the network turned not ready
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// +kubebuilder:validation:Enum={"Active","Deleting"}
+
+type VlanPhase string
+
+type MulticastStatus struct {
+	Snooping bool `json:"snooping"`
+	Querier  bool `json:"querier"`
+	// whether the VLANs have their own multicast context
+	VlanSnooping bool `json:"vlanSnooping"`
+	// the settings of the VLANs, only reported if the VLANs have their own multicast context
+	// +optional
+	VLANs []VlanMulticastOptions `json:"vlans,omitempty"`
+}
+
+const (
+	// VlanPhaseActive means the VLAN is set up on the node
+	VlanPhaseActive VlanPhase = "Active"
+	// VlanPhaseDeleting means the VLAN is being torn down on the node, the vlanstatus is kept with the error if the
+	// teardown fails
+	VlanPhaseDeleting VlanPhase = "Deleting"
+)
+
+type Fabric string
+
+const (
+	FabricA Fabric = "A"
+	FabricB Fabric = "B"
+)
+
+type LinkSpeed struct {
+	NIC string `json:"nic"`
+	// the negotiated speed in Mb/s, 0 if it is unknown, e.g. the NIC has no carrier
+	Speed uint32 `json:"speed"`
+	// the highest speed in Mb/s the NIC supports or has ever negotiated
+	MaxSpeed uint32 `json:"maxSpeed"`
+}
+
+// LACPStatus is the state of the LACP negotiation of an 802.3ad bond
+type LACPStatus struct {
+	// the aggregator the bond transmits on
+	AggregatorID int `json:"aggregatorID"`
+	// the number of the NICs in the active aggregator
+	NumPorts int `json:"numPorts"`
+	// the system MAC address of the partner, i.e. the switch, empty if no LACPDU is received
+	// +optional
+	PartnerMAC string `json:"partnerMAC,omitempty"`
+	// +optional
+	Ports []LACPPort `json:"ports,omitempty"`
+}
+
+type LACPPort struct {
+	NIC string `json:"nic"`
+	// the aggregator the NIC is attached to
+	AggregatorID int `json:"aggregatorID"`
+	// the NIC is in the active aggregator and synchronized, collecting and distributing with the partner
+	Bundled bool `json:"bundled"`
+}
+
+type UplinkUtilization struct {
+	// the received bits per second
+	RxBitsPerSecond uint64 `json:"rxBitsPerSecond"`
+	// the transmitted bits per second
+	TxBitsPerSecond uint64 `json:"txBitsPerSecond"`
+	// the higher one of the received and transmitted throughput in percent of the total speed of the uplink NICs,
+	// omitted if the speed is unknown or the uplink is idle
+	// +optional
+	Percent int `json:"percent,omitempty"`
+	// the length of the sampling interval
+	IntervalSeconds int         `json:"intervalSeconds"`
+	SampledAt       metav1.Time `json:"sampledAt"`
+}
+
 type LocalArea struct {
 	VID  uint16 `json:"vlanID"`
 	CIDR string `json:"cidr,omitempty"`
@@ -59,4 +229,23 @@ type Condition struct {
 
 var (
 	Ready condition.Cond = "ready"
+	// Degraded is true if any uplink NIC negotiates a speed below its maximum, e.g. a 10G NIC links at 1G due to a bad cable
+	Degraded condition.Cond = "degraded"
+	// Migrating is true while the VLAN is being torn down because the vlanconfig is moved to another cluster network
+	Migrating condition.Cond = "migrating"
+	// TeardownBlocked is true while the teardown of the bridge is deferred because VM ports are still attached to it,
+	// the message lists the ports and their NADs
+	TeardownBlocked condition.Cond = "teardownBlocked"
+	// Terminating is true while a deleted cluster network keeps its vlanconfigs in the deletion grace period
+	Terminating condition.Cond = "terminating"
+	// VIDsMissing is true if the uplink lacks any VLAN ID required by the NADs of the cluster network for a while, e.g.
+	// the agent missed an event, the message lists the missing VLAN IDs
+	VIDsMissing condition.Cond = "vidsMissing"
+	// MTUMismatch is an informational condition of a cluster network whose MTU diverges from the mgmt network in a way
+	// known to cause problems, e.g. the storage network has a smaller MTU than mgmt
+	MTUMismatch condition.Cond = "mtuMismatch"
+	// Drifted is true if the links of the uplink were changed outside the agent, e.g. the bridge is removed or a NIC is
+	// released from the bond, and the agent is re-applying the vlanconfig. The message lists the drift found last, it's
+	// kept with the reason Reapplied once the drift is modified back.
+	Drifted condition.Cond = "drifted"
 )
@@ -31,6 +31,11 @@ import (
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BondOptions) DeepCopyInto(out *BondOptions) {
 	*out = *in
+	if in.ArpIPTargets != nil {
+		in, out := &in.ArpIPTargets, &out.ArpIPTargets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -49,6 +54,7 @@ func (in *ClusterNetwork) DeepCopyInto(out *ClusterNetwork) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 	return
 }
@@ -104,6 +110,67 @@ func (in *ClusterNetworkList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterNetworkSpec) DeepCopyInto(out *ClusterNetworkSpec) {
+	*out = *in
+	if in.VXLAN != nil {
+		in, out := &in.VXLAN, &out.VXLAN
+		*out = new(VXLANOptions)
+		**out = **in
+	}
+	if in.Ownership != nil {
+		in, out := &in.Ownership, &out.Ownership
+		*out = new(Ownership)
+		**out = **in
+	}
+	if in.UplinkDefaults != nil {
+		in, out := &in.UplinkDefaults, &out.UplinkDefaults
+		*out = new(UplinkDefaults)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Multicast != nil {
+		in, out := &in.Multicast, &out.Multicast
+		*out = new(MulticastOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Neighbor != nil {
+		in, out := &in.Neighbor, &out.Neighbor
+		*out = new(NeighborOptions)
+		**out = **in
+	}
+	if in.VIDRemoval != nil {
+		in, out := &in.VIDRemoval, &out.VIDRemoval
+		*out = new(VIDRemovalOptions)
+		**out = **in
+	}
+	if in.Descheduling != nil {
+		in, out := &in.Descheduling, &out.Descheduling
+		*out = new(DeschedulingOptions)
+		**out = **in
+	}
+	if in.QoS != nil {
+		in, out := &in.QoS, &out.QoS
+		*out = new(QoSOptions)
+		**out = **in
+	}
+	if in.StaticVIDs != nil {
+		in, out := &in.StaticVIDs, &out.StaticVIDs
+		*out = make([]uint16, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterNetworkSpec.
+func (in *ClusterNetworkSpec) DeepCopy() *ClusterNetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterNetworkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterNetworkStatus) DeepCopyInto(out *ClusterNetworkStatus) {
 	*out = *in
@@ -112,6 +179,13 @@ func (in *ClusterNetworkStatus) DeepCopyInto(out *ClusterNetworkStatus) {
 		*out = make([]Condition, len(*in))
 		copy(*out, *in)
 	}
+	if in.InUse != nil {
+		in, out := &in.InUse, &out.InUse
+		*out = make([]NetworkUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -125,6 +199,52 @@ func (in *ClusterNetworkStatus) DeepCopy() *ClusterNetworkStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CoalesceOptions) DeepCopyInto(out *CoalesceOptions) {
+	*out = *in
+	if in.AdaptiveRX != nil {
+		in, out := &in.AdaptiveRX, &out.AdaptiveRX
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AdaptiveTX != nil {
+		in, out := &in.AdaptiveTX, &out.AdaptiveTX
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RXUsecs != nil {
+		in, out := &in.RXUsecs, &out.RXUsecs
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.RXFrames != nil {
+		in, out := &in.RXFrames, &out.RXFrames
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.TXUsecs != nil {
+		in, out := &in.TXUsecs, &out.TXUsecs
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.TXFrames != nil {
+		in, out := &in.TXFrames, &out.TXFrames
+		*out = new(uint32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CoalesceOptions.
+func (in *CoalesceOptions) DeepCopy() *CoalesceOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(CoalesceOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -141,6 +261,43 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeschedulingOptions) DeepCopyInto(out *DeschedulingOptions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeschedulingOptions.
+func (in *DeschedulingOptions) DeepCopy() *DeschedulingOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(DeschedulingOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricUplink) DeepCopyInto(out *FabricUplink) {
+	*out = *in
+	if in.NICs != nil {
+		in, out := &in.NICs, &out.NICs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricUplink.
+func (in *FabricUplink) DeepCopy() *FabricUplink {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricUplink)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostNetworkConfig) DeepCopyInto(out *HostNetworkConfig) {
 	*out = *in
@@ -279,6 +436,59 @@ func (in *HostNetworkConfigStatus) DeepCopy() *HostNetworkConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JumboVerification) DeepCopyInto(out *JumboVerification) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JumboVerification.
+func (in *JumboVerification) DeepCopy() *JumboVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(JumboVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LACPPort) DeepCopyInto(out *LACPPort) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LACPPort.
+func (in *LACPPort) DeepCopy() *LACPPort {
+	if in == nil {
+		return nil
+	}
+	out := new(LACPPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LACPStatus) DeepCopyInto(out *LACPStatus) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]LACPPort, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LACPStatus.
+func (in *LACPStatus) DeepCopy() *LACPStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LACPStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LinkAttrs) DeepCopyInto(out *LinkAttrs) {
 	*out = *in
@@ -421,6 +631,22 @@ func (in *LinkMonitorStatus) DeepCopy() *LinkMonitorStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinkSpeed) DeepCopyInto(out *LinkSpeed) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinkSpeed.
+func (in *LinkSpeed) DeepCopy() *LinkSpeed {
+	if in == nil {
+		return nil
+	}
+	out := new(LinkSpeed)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LinkStatus) DeepCopyInto(out *LinkStatus) {
 	*out = *in
@@ -454,80 +680,123 @@ func (in *LocalArea) DeepCopy() *LocalArea {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TargetLinkRule) DeepCopyInto(out *TargetLinkRule) {
+func (in *LoopDetection) DeepCopyInto(out *LoopDetection) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetLinkRule.
-func (in *TargetLinkRule) DeepCopy() *TargetLinkRule {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoopDetection.
+func (in *LoopDetection) DeepCopy() *LoopDetection {
 	if in == nil {
 		return nil
 	}
-	out := new(TargetLinkRule)
+	out := new(LoopDetection)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Uplink) DeepCopyInto(out *Uplink) {
+func (in *MulticastOptions) DeepCopyInto(out *MulticastOptions) {
 	*out = *in
-	if in.NICs != nil {
-		in, out := &in.NICs, &out.NICs
-		*out = make([]string, len(*in))
+	if in.VLANs != nil {
+		in, out := &in.VLANs, &out.VLANs
+		*out = make([]VlanMulticastOptions, len(*in))
 		copy(*out, *in)
 	}
-	if in.LinkAttrs != nil {
-		in, out := &in.LinkAttrs, &out.LinkAttrs
-		*out = new(LinkAttrs)
-		(*in).DeepCopyInto(*out)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MulticastOptions.
+func (in *MulticastOptions) DeepCopy() *MulticastOptions {
+	if in == nil {
+		return nil
 	}
-	if in.BondOptions != nil {
-		in, out := &in.BondOptions, &out.BondOptions
-		*out = new(BondOptions)
-		**out = **in
+	out := new(MulticastOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MulticastStatus) DeepCopyInto(out *MulticastStatus) {
+	*out = *in
+	if in.VLANs != nil {
+		in, out := &in.VLANs, &out.VLANs
+		*out = make([]VlanMulticastOptions, len(*in))
+		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Uplink.
-func (in *Uplink) DeepCopy() *Uplink {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MulticastStatus.
+func (in *MulticastStatus) DeepCopy() *MulticastStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(Uplink)
+	out := new(MulticastStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VlStatus) DeepCopyInto(out *VlStatus) {
+func (in *NICCapabilities) DeepCopyInto(out *NICCapabilities) {
 	*out = *in
-	if in.LocalAreas != nil {
-		in, out := &in.LocalAreas, &out.LocalAreas
-		*out = make([]LocalArea, len(*in))
-		copy(*out, *in)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NICCapabilities.
+func (in *NICCapabilities) DeepCopy() *NICCapabilities {
+	if in == nil {
+		return nil
 	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]Condition, len(*in))
-		copy(*out, *in)
+	out := new(NICCapabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NICTuning) DeepCopyInto(out *NICTuning) {
+	*out = *in
+	if in.Ring != nil {
+		in, out := &in.Ring, &out.Ring
+		*out = new(RingOptions)
+		**out = **in
+	}
+	if in.Coalesce != nil {
+		in, out := &in.Coalesce, &out.Coalesce
+		*out = new(CoalesceOptions)
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlStatus.
-func (in *VlStatus) DeepCopy() *VlStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NICTuning.
+func (in *NICTuning) DeepCopy() *NICTuning {
 	if in == nil {
 		return nil
 	}
-	out := new(VlStatus)
+	out := new(NICTuning)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VlanConfig) DeepCopyInto(out *VlanConfig) {
+func (in *NeighborOptions) DeepCopyInto(out *NeighborOptions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NeighborOptions.
+func (in *NeighborOptions) DeepCopy() *NeighborOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(NeighborOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkChangeLog) DeepCopyInto(out *NetworkChangeLog) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -535,18 +804,18 @@ func (in *VlanConfig) DeepCopyInto(out *VlanConfig) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlanConfig.
-func (in *VlanConfig) DeepCopy() *VlanConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkChangeLog.
+func (in *NetworkChangeLog) DeepCopy() *NetworkChangeLog {
 	if in == nil {
 		return nil
 	}
-	out := new(VlanConfig)
+	out := new(NetworkChangeLog)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VlanConfig) DeepCopyObject() runtime.Object {
+func (in *NetworkChangeLog) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -554,13 +823,13 @@ func (in *VlanConfig) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VlanConfigList) DeepCopyInto(out *VlanConfigList) {
+func (in *NetworkChangeLogList) DeepCopyInto(out *NetworkChangeLogList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]VlanConfig, len(*in))
+		*out = make([]NetworkChangeLog, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -568,18 +837,18 @@ func (in *VlanConfigList) DeepCopyInto(out *VlanConfigList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlanConfigList.
-func (in *VlanConfigList) DeepCopy() *VlanConfigList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkChangeLogList.
+func (in *NetworkChangeLogList) DeepCopy() *NetworkChangeLogList {
 	if in == nil {
 		return nil
 	}
-	out := new(VlanConfigList)
+	out := new(NetworkChangeLogList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VlanConfigList) DeepCopyObject() runtime.Object {
+func (in *NetworkChangeLogList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -587,25 +856,903 @@ func (in *VlanConfigList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VlanConfigSpec) DeepCopyInto(out *VlanConfigSpec) {
+func (in *NetworkChangeLogSpec) DeepCopyInto(out *NetworkChangeLogSpec) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	in.Uplink.DeepCopyInto(&out.Uplink)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlanConfigSpec.
-func (in *VlanConfigSpec) DeepCopy() *VlanConfigSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkChangeLogSpec.
+func (in *NetworkChangeLogSpec) DeepCopy() *NetworkChangeLogSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VlanConfigSpec)
+	out := new(NetworkChangeLogSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInterfaceClaim) DeepCopyInto(out *NetworkInterfaceClaim) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterfaceClaim.
+func (in *NetworkInterfaceClaim) DeepCopy() *NetworkInterfaceClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkInterfaceClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NetworkInterfaceClaim) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInterfaceClaimList) DeepCopyInto(out *NetworkInterfaceClaimList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NetworkInterfaceClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterfaceClaimList.
+func (in *NetworkInterfaceClaimList) DeepCopy() *NetworkInterfaceClaimList {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkInterfaceClaimList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NetworkInterfaceClaimList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInterfaceClaimSpec) DeepCopyInto(out *NetworkInterfaceClaimSpec) {
+	*out = *in
+	if in.NICs != nil {
+		in, out := &in.NICs, &out.NICs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterfaceClaimSpec.
+func (in *NetworkInterfaceClaimSpec) DeepCopy() *NetworkInterfaceClaimSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkInterfaceClaimSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInterfaceClaimStatus) DeepCopyInto(out *NetworkInterfaceClaimStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterfaceClaimStatus.
+func (in *NetworkInterfaceClaimStatus) DeepCopy() *NetworkInterfaceClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkInterfaceClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkUsage) DeepCopyInto(out *NetworkUsage) {
+	*out = *in
+	if in.VIDs != nil {
+		in, out := &in.VIDs, &out.VIDs
+		*out = make([]uint16, len(*in))
+		copy(*out, *in)
+	}
+	if in.Consumers != nil {
+		in, out := &in.Consumers, &out.Consumers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreviousVIDs != nil {
+		in, out := &in.PreviousVIDs, &out.PreviousVIDs
+		*out = make([]uint16, len(*in))
+		copy(*out, *in)
+	}
+	if in.StaleConsumers != nil {
+		in, out := &in.StaleConsumers, &out.StaleConsumers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkUsage.
+func (in *NetworkUsage) DeepCopy() *NetworkUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeCapabilities) DeepCopyInto(out *NodeCapabilities) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeCapabilities.
+func (in *NodeCapabilities) DeepCopy() *NodeCapabilities {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeCapabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkState) DeepCopyInto(out *NodeNetworkState) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkState.
+func (in *NodeNetworkState) DeepCopy() *NodeNetworkState {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeNetworkState) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkStateList) DeepCopyInto(out *NodeNetworkStateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeNetworkState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkStateList.
+func (in *NodeNetworkStateList) DeepCopy() *NodeNetworkStateList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkStateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeNetworkStateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkStateStatus) DeepCopyInto(out *NodeNetworkStateStatus) {
+	*out = *in
+	out.Capabilities = in.Capabilities
+	if in.NICs != nil {
+		in, out := &in.NICs, &out.NICs
+		*out = make([]NICCapabilities, len(*in))
+		copy(*out, *in)
+	}
+	if in.DiscoveredAt != nil {
+		in, out := &in.DiscoveredAt, &out.DiscoveredAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkStateStatus.
+func (in *NodeNetworkStateStatus) DeepCopy() *NodeNetworkStateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkStateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ownership) DeepCopyInto(out *Ownership) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ownership.
+func (in *Ownership) DeepCopy() *Ownership {
+	if in == nil {
+		return nil
+	}
+	out := new(Ownership)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacketCapture) DeepCopyInto(out *PacketCapture) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacketCapture.
+func (in *PacketCapture) DeepCopy() *PacketCapture {
+	if in == nil {
+		return nil
+	}
+	out := new(PacketCapture)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PacketCapture) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacketCaptureList) DeepCopyInto(out *PacketCaptureList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PacketCapture, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacketCaptureList.
+func (in *PacketCaptureList) DeepCopy() *PacketCaptureList {
+	if in == nil {
+		return nil
+	}
+	out := new(PacketCaptureList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PacketCaptureList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacketCaptureSpec) DeepCopyInto(out *PacketCaptureSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacketCaptureSpec.
+func (in *PacketCaptureSpec) DeepCopy() *PacketCaptureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PacketCaptureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacketCaptureStatus) DeepCopyInto(out *PacketCaptureStatus) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacketCaptureStatus.
+func (in *PacketCaptureStatus) DeepCopy() *PacketCaptureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PacketCaptureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QoSOptions) DeepCopyInto(out *QoSOptions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QoSOptions.
+func (in *QoSOptions) DeepCopy() *QoSOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(QoSOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueueOptions) DeepCopyInto(out *QueueOptions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueueOptions.
+func (in *QueueOptions) DeepCopy() *QueueOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(QueueOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadyTransition) DeepCopyInto(out *ReadyTransition) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadyTransition.
+func (in *ReadyTransition) DeepCopy() *ReadyTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadyTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RingOptions) DeepCopyInto(out *RingOptions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RingOptions.
+func (in *RingOptions) DeepCopy() *RingOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(RingOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetupStep) DeepCopyInto(out *SetupStep) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetupStep.
+func (in *SetupStep) DeepCopy() *SetupStep {
+	if in == nil {
+		return nil
+	}
+	out := new(SetupStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedBond) DeepCopyInto(out *SharedBond) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedBond.
+func (in *SharedBond) DeepCopy() *SharedBond {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedBond)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetLinkRule) DeepCopyInto(out *TargetLinkRule) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetLinkRule.
+func (in *TargetLinkRule) DeepCopy() *TargetLinkRule {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetLinkRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyOverride) DeepCopyInto(out *TopologyOverride) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NICs != nil {
+		in, out := &in.NICs, &out.NICs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BackupNICs != nil {
+		in, out := &in.BackupNICs, &out.BackupNICs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BondOptions != nil {
+		in, out := &in.BondOptions, &out.BondOptions
+		*out = new(BondOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyOverride.
+func (in *TopologyOverride) DeepCopy() *TopologyOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Uplink) DeepCopyInto(out *Uplink) {
+	*out = *in
+	if in.NICs != nil {
+		in, out := &in.NICs, &out.NICs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BackupNICs != nil {
+		in, out := &in.BackupNICs, &out.BackupNICs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LinkAttrs != nil {
+		in, out := &in.LinkAttrs, &out.LinkAttrs
+		*out = new(LinkAttrs)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BondOptions != nil {
+		in, out := &in.BondOptions, &out.BondOptions
+		*out = new(BondOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SharedBond != nil {
+		in, out := &in.SharedBond, &out.SharedBond
+		*out = new(SharedBond)
+		**out = **in
+	}
+	if in.FabricB != nil {
+		in, out := &in.FabricB, &out.FabricB
+		*out = new(FabricUplink)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.QueueOptions != nil {
+		in, out := &in.QueueOptions, &out.QueueOptions
+		*out = new(QueueOptions)
+		**out = **in
+	}
+	if in.NICTuning != nil {
+		in, out := &in.NICTuning, &out.NICTuning
+		*out = make([]NICTuning, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LoopDetection != nil {
+		in, out := &in.LoopDetection, &out.LoopDetection
+		*out = new(LoopDetection)
+		**out = **in
+	}
+	if in.JumboVerification != nil {
+		in, out := &in.JumboVerification, &out.JumboVerification
+		*out = new(JumboVerification)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Uplink.
+func (in *Uplink) DeepCopy() *Uplink {
+	if in == nil {
+		return nil
+	}
+	out := new(Uplink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UplinkDefaults) DeepCopyInto(out *UplinkDefaults) {
+	*out = *in
+	if in.LinkAttrs != nil {
+		in, out := &in.LinkAttrs, &out.LinkAttrs
+		*out = new(LinkAttrs)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BondOptions != nil {
+		in, out := &in.BondOptions, &out.BondOptions
+		*out = new(BondOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.QueueOptions != nil {
+		in, out := &in.QueueOptions, &out.QueueOptions
+		*out = new(QueueOptions)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UplinkDefaults.
+func (in *UplinkDefaults) DeepCopy() *UplinkDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(UplinkDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UplinkUtilization) DeepCopyInto(out *UplinkUtilization) {
+	*out = *in
+	in.SampledAt.DeepCopyInto(&out.SampledAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UplinkUtilization.
+func (in *UplinkUtilization) DeepCopy() *UplinkUtilization {
+	if in == nil {
+		return nil
+	}
+	out := new(UplinkUtilization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VIDRemovalOptions) DeepCopyInto(out *VIDRemovalOptions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VIDRemovalOptions.
+func (in *VIDRemovalOptions) DeepCopy() *VIDRemovalOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(VIDRemovalOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VXLANOptions) DeepCopyInto(out *VXLANOptions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VXLANOptions.
+func (in *VXLANOptions) DeepCopy() *VXLANOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(VXLANOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VlStatus) DeepCopyInto(out *VlStatus) {
+	*out = *in
+	if in.LocalAreas != nil {
+		in, out := &in.LocalAreas, &out.LocalAreas
+		*out = make([]LocalArea, len(*in))
+		copy(*out, *in)
+	}
+	if in.UplinkNICs != nil {
+		in, out := &in.UplinkNICs, &out.UplinkNICs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LinkSpeeds != nil {
+		in, out := &in.LinkSpeeds, &out.LinkSpeeds
+		*out = make([]LinkSpeed, len(*in))
+		copy(*out, *in)
+	}
+	if in.CarrierUpSince != nil {
+		in, out := &in.CarrierUpSince, &out.CarrierUpSince
+		*out = (*in).DeepCopy()
+	}
+	if in.Multicast != nil {
+		in, out := &in.Multicast, &out.Multicast
+		*out = new(MulticastStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VIDs != nil {
+		in, out := &in.VIDs, &out.VIDs
+		*out = make([]uint16, len(*in))
+		copy(*out, *in)
+	}
+	if in.LACP != nil {
+		in, out := &in.LACP, &out.LACP
+		*out = new(LACPStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NICsWithoutCarrier != nil {
+		in, out := &in.NICsWithoutCarrier, &out.NICsWithoutCarrier
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Utilization != nil {
+		in, out := &in.Utilization, &out.Utilization
+		*out = new(UplinkUtilization)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastReconciledAt != nil {
+		in, out := &in.LastReconciledAt, &out.LastReconciledAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Features != nil {
+		in, out := &in.Features, &out.Features
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReadyTransitions != nil {
+		in, out := &in.ReadyTransitions, &out.ReadyTransitions
+		*out = make([]ReadyTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]SetupStep, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlStatus.
+func (in *VlStatus) DeepCopy() *VlStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VlStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VlanConfig) DeepCopyInto(out *VlanConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlanConfig.
+func (in *VlanConfig) DeepCopy() *VlanConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VlanConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VlanConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VlanConfigList) DeepCopyInto(out *VlanConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VlanConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlanConfigList.
+func (in *VlanConfigList) DeepCopy() *VlanConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(VlanConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VlanConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VlanConfigSpec) DeepCopyInto(out *VlanConfigSpec) {
+	*out = *in
+	if in.Ownership != nil {
+		in, out := &in.Ownership, &out.Ownership
+		*out = new(Ownership)
+		**out = **in
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Uplink.DeepCopyInto(&out.Uplink)
+	if in.TopologyOverrides != nil {
+		in, out := &in.TopologyOverrides, &out.TopologyOverrides
+		*out = make([]TopologyOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlanConfigSpec.
+func (in *VlanConfigSpec) DeepCopy() *VlanConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VlanConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VlanConfigStatus) DeepCopyInto(out *VlanConfigStatus) {
+	*out = *in
+	if in.TeardownPendingNodes != nil {
+		in, out := &in.TeardownPendingNodes, &out.TeardownPendingNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlanConfigStatus.
+func (in *VlanConfigStatus) DeepCopy() *VlanConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VlanConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VlanMulticastOptions) DeepCopyInto(out *VlanMulticastOptions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlanMulticastOptions.
+func (in *VlanMulticastOptions) DeepCopy() *VlanMulticastOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(VlanMulticastOptions)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -108,3 +108,71 @@ func NewHostNetworkConfig(namespace, name string, obj HostNetworkConfig) *HostNe
 	obj.Namespace = namespace
 	return &obj
 }
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkInterfaceClaimList is a list of NetworkInterfaceClaim resources
+type NetworkInterfaceClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NetworkInterfaceClaim `json:"items"`
+}
+
+func NewNetworkInterfaceClaim(namespace, name string, obj NetworkInterfaceClaim) *NetworkInterfaceClaim {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("NetworkInterfaceClaim").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkChangeLogList is a list of NetworkChangeLog resources
+type NetworkChangeLogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NetworkChangeLog `json:"items"`
+}
+
+func NewNetworkChangeLog(namespace, name string, obj NetworkChangeLog) *NetworkChangeLog {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("NetworkChangeLog").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeNetworkStateList is a list of NodeNetworkState resources
+type NodeNetworkStateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NodeNetworkState `json:"items"`
+}
+
+func NewNodeNetworkState(namespace, name string, obj NodeNetworkState) *NodeNetworkState {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("NodeNetworkState").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PacketCaptureList is a list of PacketCapture resources
+type PacketCaptureList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []PacketCapture `json:"items"`
+}
+
+func NewPacketCapture(namespace, name string, obj PacketCapture) *PacketCapture {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("PacketCapture").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
@@ -28,11 +28,15 @@ import (
 )
 
 var (
-	ClusterNetworkResourceName    = "clusternetworks"
-	HostNetworkConfigResourceName = "hostnetworkconfigs"
-	LinkMonitorResourceName       = "linkmonitors"
-	VlanConfigResourceName        = "vlanconfigs"
-	VlanStatusResourceName        = "vlanstatuses"
+	ClusterNetworkResourceName        = "clusternetworks"
+	HostNetworkConfigResourceName     = "hostnetworkconfigs"
+	LinkMonitorResourceName           = "linkmonitors"
+	NetworkChangeLogResourceName      = "networkchangelogs"
+	NetworkInterfaceClaimResourceName = "networkinterfaceclaims"
+	NodeNetworkStateResourceName      = "nodenetworkstates"
+	PacketCaptureResourceName         = "packetcaptures"
+	VlanConfigResourceName            = "vlanconfigs"
+	VlanStatusResourceName            = "vlanstatuses"
 )
 
 // SchemeGroupVersion is group version used to register these objects
@@ -62,6 +66,14 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&HostNetworkConfigList{},
 		&LinkMonitor{},
 		&LinkMonitorList{},
+		&NetworkChangeLog{},
+		&NetworkChangeLogList{},
+		&NetworkInterfaceClaim{},
+		&NetworkInterfaceClaimList{},
+		&NodeNetworkState{},
+		&NodeNetworkStateList{},
+		&PacketCapture{},
+		&PacketCaptureList{},
 		&VlanConfig{},
 		&VlanConfigList{},
 		&VlanStatus{},
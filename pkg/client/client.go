@@ -0,0 +1,197 @@
+// Package client is the stable API for the other Harvester components to ask about the networks managed by the
+// network controller, e.g. the MTU of a cluster network or the NADs a VM can attach to on a node, so that they don't
+// parse the annotations and look up the vlanstatuses on their own. It only reads the caches passed in, and doesn't
+// require any indexer on them.
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// Client answers the questions about the networks from the caches
+type Client struct {
+	cnCache  ctlnetworkv1.ClusterNetworkCache
+	vsCache  ctlnetworkv1.VlanStatusCache
+	nadCache ctlcniv1.NetworkAttachmentDefinitionCache
+}
+
+func New(cnCache ctlnetworkv1.ClusterNetworkCache, vsCache ctlnetworkv1.VlanStatusCache,
+	nadCache ctlcniv1.NetworkAttachmentDefinitionCache) *Client {
+	return &Client{
+		cnCache:  cnCache,
+		vsCache:  vsCache,
+		nadCache: nadCache,
+	}
+}
+
+// ClusterNetworkMTU returns the MTU of the uplinks of the cluster network, the default MTU if it's not configured
+func (c *Client) ClusterNetworkMTU(cnName string) (int, error) {
+	cn, err := c.cnCache.Get(cnName)
+	if err != nil {
+		return 0, err
+	}
+
+	return utils.GetMTUOfClusterNetwork(cn)
+}
+
+// NadMTU returns the MTU of the interfaces attached to the NAD, which is the MTU in the config of the NAD or else the
+// MTU of its cluster network
+func (c *Client) NadMTU(nad *nadv1.NetworkAttachmentDefinition) (int, error) {
+	nc, err := utils.DecodeNadConfigToNetConf(nad)
+	if err != nil {
+		return 0, err
+	}
+	if nc.MTU != 0 {
+		return nc.MTU, nil
+	}
+	cnName := clusterNetworkOf(nad)
+	if cnName == "" {
+		return 0, fmt.Errorf("nad %s/%s has no cluster network", nad.Namespace, nad.Name)
+	}
+
+	return c.ClusterNetworkMTU(cnName)
+}
+
+// IsReadyOnNode returns whether the network of the cluster network is ready on the node, and the reason if it's not.
+// The cluster network mgmt is always ready, it's set up when the node is installed.
+func (c *Client) IsReadyOnNode(cnName, node string) (bool, string, error) {
+	if cnName == utils.ManagementClusterNetworkName {
+		return true, "", nil
+	}
+
+	vs, err := c.vlanStatus(cnName, node)
+	if apierrors.IsNotFound(err) {
+		return false, fmt.Sprintf("cluster network %s is not configured on node %s", cnName, node), nil
+	} else if err != nil {
+		return false, "", err
+	}
+	if !networkv1.Ready.IsTrue(vs.Status) {
+		return false, networkv1.Ready.GetMessage(vs.Status), nil
+	}
+
+	return true, "", nil
+}
+
+// ClusterNetworksOnNode returns the sorted names of the cluster networks ready on the node, including mgmt
+func (c *Client) ClusterNetworksOnNode(node string) ([]string, error) {
+	vss, err := c.vlanStatusesOnNode(node)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{utils.ManagementClusterNetworkName}
+	for _, vs := range vss {
+		if networkv1.Ready.IsTrue(vs.Status) {
+			names = append(names, vs.Status.ClusterNetwork)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// CheckNadOnNode returns an error telling why a VM on the node can't attach to the NAD, e.g. the cluster network isn't
+// ready on the node or the VLAN IDs of the NAD aren't programmed on the uplink yet
+func (c *Client) CheckNadOnNode(nad *nadv1.NetworkAttachmentDefinition, node string) error {
+	cnName := clusterNetworkOf(nad)
+	if cnName == "" {
+		return fmt.Errorf("nad %s/%s has no cluster network", nad.Namespace, nad.Name)
+	}
+	if cnName == utils.ManagementClusterNetworkName {
+		return nil
+	}
+
+	vs, err := c.vlanStatus(cnName, node)
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("cluster network %s is not configured on node %s", cnName, node)
+	} else if err != nil {
+		return err
+	}
+
+	return utils.CheckVlanStatusCoversNad(vs, nad)
+}
+
+// NadsOnNode returns the NADs in the namespace, all namespaces if it's empty, which a VM on the node can attach to
+func (c *Client) NadsOnNode(namespace, node string) ([]*nadv1.NetworkAttachmentDefinition, error) {
+	nads, err := c.nadCache.List(namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	vss, err := c.vlanStatusesOnNode(node)
+	if err != nil {
+		return nil, err
+	}
+	byCN := make(map[string]*networkv1.VlanStatus, len(vss))
+	for _, vs := range vss {
+		byCN[vs.Status.ClusterNetwork] = vs
+	}
+
+	available := make([]*nadv1.NetworkAttachmentDefinition, 0, len(nads))
+	for _, nad := range nads {
+		if nad.DeletionTimestamp != nil {
+			continue
+		}
+		cnName := clusterNetworkOf(nad)
+		if cnName == utils.ManagementClusterNetworkName {
+			available = append(available, nad)
+			continue
+		}
+		if vs, ok := byCN[cnName]; ok && utils.CheckVlanStatusCoversNad(vs, nad) == nil {
+			available = append(available, nad)
+		}
+	}
+
+	return available, nil
+}
+
+func clusterNetworkOf(nad *nadv1.NetworkAttachmentDefinition) string {
+	return nad.Labels[utils.KeyClusterNetworkLabel]
+}
+
+// vlanStatus looks the vlanstatus up by the labels rather than the ambiguous name
+func (c *Client) vlanStatus(cnName, node string) (*networkv1.VlanStatus, error) {
+	vss, err := c.vsCache.List(labels.Set{
+		utils.KeyClusterNetworkLabel: cnName,
+		utils.KeyNodeLabel:           utils.LabelValue(node),
+	}.AsSelector())
+	if err != nil {
+		return nil, err
+	}
+	for _, vs := range vss {
+		if utils.IsVlanStatusOf(vs, cnName, node) {
+			return vs, nil
+		}
+	}
+
+	return nil, apierrors.NewNotFound(networkv1.Resource("vlanstatus"), utils.VlanStatusName(cnName, node))
+}
+
+// vlanStatusesOnNode returns the vlanstatuses of the node, one per cluster network
+func (c *Client) vlanStatusesOnNode(node string) ([]*networkv1.VlanStatus, error) {
+	vss, err := c.vsCache.List(labels.Set{utils.KeyNodeLabel: utils.LabelValue(node)}.AsSelector())
+	if err != nil {
+		return nil, err
+	}
+
+	onNode := make([]*networkv1.VlanStatus, 0, len(vss))
+	seen := make(map[string]bool, len(vss))
+	for _, vs := range vss {
+		if vs.Status.Node != node || seen[vs.Status.ClusterNetwork] {
+			continue
+		}
+		seen[vs.Status.ClusterNetwork] = true
+		onNode = append(onNode, vs)
+	}
+
+	return onNode, nil
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+	"github.com/harvester/harvester-network-controller/pkg/utils/fakeclients"
+)
+
+func newNad(name, cn string, vid int) *nadv1.NetworkAttachmentDefinition {
+	return &nadv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			Labels:    map[string]string{utils.KeyClusterNetworkLabel: cn},
+		},
+		Spec: nadv1.NetworkAttachmentDefinitionSpec{
+			Config: fmt.Sprintf(`{"cniVersion":"0.3.1","name":"%s","type":"bridge","bridge":"%s-br","promiscMode":true,"vlan":%d,"ipam":{}}`,
+				name, cn, vid),
+		},
+	}
+}
+
+func TestClient(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	c := New(fakeclients.ClusterNetworkCache(clientset.NetworkV1beta1().ClusterNetworks),
+		fakeclients.VlanStatusCache(clientset.NetworkV1beta1().VlanStatuses),
+		fakeclients.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions))
+
+	_, err := fakeclients.ClusterNetworkClient(clientset.NetworkV1beta1().ClusterNetworks).Create(&networkv1.ClusterNetwork{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Annotations: map[string]string{utils.KeyUplinkMTU: "9000"}},
+	})
+	assert.NoError(t, err)
+	vs := &networkv1.VlanStatus{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: utils.VlanStatusName("data", "node1"),
+			Labels: map[string]string{
+				utils.KeyClusterNetworkLabel: "data",
+				utils.KeyNodeLabel:           utils.LabelValue("node1"),
+			},
+		},
+		Status: networkv1.VlStatus{
+			ClusterNetwork: "data",
+			Node:           "node1",
+			Features:       []string{utils.FeatureVIDStatus},
+			VIDs:           []uint16{100},
+		},
+	}
+	networkv1.Ready.SetStatusBool(vs, true)
+	_, err = fakeclients.VlanStatusClient(clientset.NetworkV1beta1().VlanStatuses).Create(vs)
+	assert.NoError(t, err)
+	vlan100, vlan200, mgmt := newNad("vlan100", "data", 100), newNad("vlan200", "data", 200), newNad("mgmt100", "mgmt", 100)
+	for _, nad := range []*nadv1.NetworkAttachmentDefinition{vlan100, vlan200, mgmt} {
+		_, err := clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions(nad.Namespace).Create(context.TODO(), nad, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	mtu, err := c.ClusterNetworkMTU("data")
+	assert.NoError(t, err)
+	assert.Equal(t, 9000, mtu)
+	mtu, err = c.NadMTU(vlan100)
+	assert.NoError(t, err)
+	assert.Equal(t, 9000, mtu, "the NAD without MTU follows its cluster network")
+
+	ready, _, err := c.IsReadyOnNode("data", "node1")
+	assert.NoError(t, err)
+	assert.True(t, ready)
+	ready, reason, err := c.IsReadyOnNode("data", "node2")
+	assert.NoError(t, err)
+	assert.False(t, ready)
+	assert.Contains(t, reason, "not configured on node node2")
+
+	cns, err := c.ClusterNetworksOnNode("node1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"data", utils.ManagementClusterNetworkName}, cns)
+	cns, err = c.ClusterNetworksOnNode("node2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{utils.ManagementClusterNetworkName}, cns)
+
+	assert.NoError(t, c.CheckNadOnNode(vlan100, "node1"))
+	assert.ErrorContains(t, c.CheckNadOnNode(vlan200, "node1"), "not programmed")
+	assert.NoError(t, c.CheckNadOnNode(mgmt, "node2"))
+
+	nads, err := c.NadsOnNode("", "node1")
+	assert.NoError(t, err)
+	names := make([]string, 0, len(nads))
+	for _, nad := range nads {
+		names = append(names, nad.Name)
+	}
+	assert.ElementsMatch(t, []string{"vlan100", "mgmt100"}, names)
+}
@@ -30,6 +30,10 @@ func main() {
 					networkv1.VlanStatus{},
 					networkv1.LinkMonitor{},
 					networkv1.HostNetworkConfig{},
+					networkv1.NetworkInterfaceClaim{},
+					networkv1.NetworkChangeLog{},
+					networkv1.NodeNetworkState{},
+					networkv1.PacketCapture{},
 				},
 				GenerateTypes:   true,
 				GenerateClients: true,
@@ -53,6 +57,7 @@ func main() {
 				Types: []interface{}{
 					kubevirtv1.VirtualMachine{},
 					kubevirtv1.VirtualMachineInstance{},
+					kubevirtv1.VirtualMachineInstanceMigration{},
 				},
 				GenerateTypes:   false,
 				GenerateClients: true,
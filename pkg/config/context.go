@@ -16,6 +16,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
@@ -24,10 +25,16 @@ import (
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	ctlcni "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io"
 	kubeovncni "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubeovn.io"
+	ctlkubevirt "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubevirt.io"
 	ctlnetwork "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io"
+	"github.com/harvester/harvester-network-controller/pkg/localarea"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
 	networkcrd "github.com/harvester/harvester-network-controller/pkg/utils/crd"
 )
 
+// the writes of different objects run in parallel, the writes of one object are serialized anyway
+const statusWriters = 2
+
 var (
 	localSchemeBuilder = runtime.SchemeBuilder{
 		networkv1.AddToScheme,
@@ -47,6 +54,22 @@ type Options struct {
 	Namespace   string
 	HelperImage string
 	NodeName    string
+	// Version is the build version of the binary
+	Version string
+	// TenantLabels are the keys of the namespace labels propagated to the NADs and their helper jobs
+	TenantLabels []string
+	// UplinkUtilization makes the agent sample the throughput of the uplinks into the vlanstatuses and the metrics
+	UplinkUtilization bool
+	// StateFile is the file the agent caches the desired network of the node in, to enforce it at startup if the API
+	// server is unreachable
+	StateFile string
+	// CaptureDir is the directory the agent writes the pcap files of the PacketCaptures in, the captures are refused
+	// if it's empty
+	CaptureDir string
+	// RateLimits are the client-side limits of the API requests and the reconciles
+	RateLimits RateLimits
+	// CRDBootstrap is how the manager installs and verifies the CRDs at startup
+	CRDBootstrap networkcrd.BootstrapMode
 }
 
 type Management struct {
@@ -56,13 +79,28 @@ type Management struct {
 
 	HarvesterNetworkFactory *ctlnetwork.Factory
 
-	CniFactory     *ctlcni.Factory
-	CoreFactory    *ctlcore.Factory
-	AppsFactory    *ctlapps.Factory
-	BatchFactory   *ctlbatch.Factory
-	kubeovnFactory *kubeovncni.Factory
+	CniFactory      *ctlcni.Factory
+	CoreFactory     *ctlcore.Factory
+	AppsFactory     *ctlapps.Factory
+	BatchFactory    *ctlbatch.Factory
+	kubeovnFactory  *kubeovncni.Factory
+	KubevirtFactory *ctlkubevirt.Factory
 
 	ClientSet *kubernetes.Clientset
+	// DynamicClient reads the resources whose API isn't vendored, e.g. the Harvester settings
+	DynamicClient dynamic.Interface
+	// Locks serializes the parallel workers of the controllers on the resources they share, e.g. the links of a
+	// cluster network on the node, see the utils.LockKeyOf* functions for the keys
+	Locks *utils.KeyMutex
+	// Converged is opened once the agent has converged the node network at startup, the agent controllers changing
+	// the links wait for it so that they only apply the changes made afterwards
+	Converged *utils.Gate
+	// StatusWriter writes the statuses reported by the controllers in the background, so that the reconciles don't
+	// wait for the API server
+	StatusWriter *utils.StatusWriter
+	// LocalAreas are the sources of the VLAN IDs programmed on the uplinks of the cluster networks, the NADs and the
+	// static VLAN IDs of the cluster networks by default. Another source is added in a RegisterFunc
+	LocalAreas *localarea.Sources
 
 	Options *Options
 
@@ -74,7 +112,7 @@ func (s *Management) Start(threadiness int) error {
 }
 
 func (s *Management) Register(ctx context.Context, config *rest.Config, registerFuncList []RegisterFunc) error {
-	if err := createCRDsIfNotExisted(ctx, config); err != nil {
+	if err := createCRDsIfNotExisted(ctx, config, s.Options.CRDBootstrap); err != nil {
 		return err
 	}
 
@@ -94,16 +132,20 @@ func (s *Management) NewRecorder(componentName, namespace, nodeName string) reco
 	return eventBroadcaster.NewRecorder(Scheme, corev1.EventSource{Component: componentName, Host: nodeName})
 }
 
-func createCRDsIfNotExisted(ctx context.Context, config *rest.Config) error {
+func createCRDsIfNotExisted(ctx context.Context, config *rest.Config, bootstrap networkcrd.BootstrapMode) error {
 	factory, err := networkcrd.NewFactoryFromClient(ctx, config)
 	if err != nil {
 		return err
 	}
-	return factory.
+	if err := factory.
 		BatchCreateCRDsIfNotExisted(
 			createNetworkAttachmentDefinitionCRD(),
 		).
-		BatchWait()
+		BatchWait(); err != nil {
+		return err
+	}
+
+	return factory.Bootstrap(bootstrap)
 }
 
 func createNetworkAttachmentDefinitionCRD() wcrd.CRD {
@@ -114,7 +156,13 @@ func createNetworkAttachmentDefinitionCRD() wcrd.CRD {
 }
 
 func SetupManagement(ctx context.Context, restConfig *rest.Config, options *Options) (*Management, error) {
-	factory, err := controller.NewSharedControllerFactoryFromConfig(restConfig, Scheme)
+	kindRateLimiter, err := kindRateLimiters(Scheme, options.RateLimits.Kinds)
+	if err != nil {
+		return nil, err
+	}
+	restConfig = rateLimitedConfig(restConfig, &options.RateLimits)
+	factory, err := controller.NewSharedControllerFactoryFromConfigWithOptions(restConfig, Scheme,
+		&controller.SharedControllerFactoryOptions{KindRateLimiter: kindRateLimiter})
 	if err != nil {
 		return nil, err
 	}
@@ -124,9 +172,13 @@ func SetupManagement(ctx context.Context, restConfig *rest.Config, options *Opti
 	}
 
 	management := &Management{
-		ctx:     ctx,
-		Options: options,
+		ctx:          ctx,
+		Options:      options,
+		Locks:        utils.NewKeyMutex(),
+		Converged:    utils.NewGate(),
+		StatusWriter: utils.NewStatusWriter(),
 	}
+	go management.StatusWriter.Run(ctx, statusWriters)
 
 	harvesterNetwork, err := ctlnetwork.NewFactoryFromConfigWithOptions(restConfig, opts)
 	if err != nil {
@@ -162,6 +214,7 @@ func SetupManagement(ctx context.Context, restConfig *rest.Config, options *Opti
 	}
 	management.CniFactory = cni
 	management.starters = append(management.starters, cni)
+	management.LocalAreas = localarea.NewDefaultSources(cni.K8s().V1().NetworkAttachmentDefinition().Cache())
 
 	kubeovncni, err := kubeovncni.NewFactoryFromConfigWithOptions(restConfig, opts)
 	if err != nil {
@@ -170,10 +223,24 @@ func SetupManagement(ctx context.Context, restConfig *rest.Config, options *Opti
 	management.kubeovnFactory = kubeovncni
 	management.starters = append(management.starters, kubeovncni)
 
+	kubevirt, err := ctlkubevirt.NewFactoryFromConfigWithOptions(restConfig, opts)
+	if err != nil {
+		return nil, err
+	}
+	management.KubevirtFactory = kubevirt
+	management.starters = append(management.starters, kubevirt)
+
 	management.ClientSet, err = kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return nil, err
 	}
 
+	management.DynamicClient, err = dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	registerIndexers(management)
+
 	return management, nil
 }
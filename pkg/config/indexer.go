@@ -0,0 +1,18 @@
+package config
+
+import (
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// registerIndexers adds the indexes shared by the controllers for the common queries, they must be added before the
+// informers are started
+func registerIndexers(management *Management) {
+	network := management.HarvesterNetworkFactory.Network().V1beta1()
+	network.VlanStatus().Cache().AddIndexer(utils.VlanStatusByNodeIndex, utils.VlanStatusByNode)
+	network.VlanStatus().Cache().AddIndexer(utils.VlanStatusByClusterNetworkAndNodeIndex,
+		utils.VlanStatusByClusterNetworkAndNode)
+	network.VlanConfig().Cache().AddIndexer(utils.VlanConfigByClusterNetworkIndex, utils.VlanConfigByClusterNetwork)
+
+	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	nads.Cache().AddIndexer(utils.NadByBridgeIndex, utils.NadByBridge)
+}
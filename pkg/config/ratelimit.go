@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// RateLimits are the client-side limits protecting the API server from a burst of requests, e.g. every agent writing
+// the vlanstatus at the same time after hundreds of NADs are updated
+type RateLimits struct {
+	// QPS and Burst limit the requests of all the clients of the controllers together, there is no global limit if QPS
+	// is 0 and every client is limited on its own by the client-go defaults
+	QPS   float32
+	Burst int
+	// Kinds limit how fast the controllers of the kinds are allowed to reconcile, by the kind, e.g. VlanStatus
+	Kinds map[string]KindRateLimit
+}
+
+// KindRateLimit is the rate of the reconciles of the controllers of a kind
+type KindRateLimit struct {
+	QPS   float64
+	Burst int
+}
+
+// ParseKindRateLimits parses the comma separated limits in the format <kind>=<qps>:<burst>, e.g.
+// "VlanStatus=5:10,NetworkAttachmentDefinition=10:20"
+func ParseKindRateLimits(value string) (map[string]KindRateLimit, error) {
+	limits := make(map[string]KindRateLimit)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kind, limit, ok := strings.Cut(item, "=")
+		if !ok || kind == "" {
+			return nil, fmt.Errorf("invalid rate limit %q, the format is <kind>=<qps>:<burst>", item)
+		}
+		qpsStr, burstStr, ok := strings.Cut(limit, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q, the format is <kind>=<qps>:<burst>", item)
+		}
+		qps, err := strconv.ParseFloat(qpsStr, 64)
+		if err != nil || qps <= 0 {
+			return nil, fmt.Errorf("invalid qps %q of kind %s", qpsStr, kind)
+		}
+		burst, err := strconv.Atoi(burstStr)
+		if err != nil || burst <= 0 {
+			return nil, fmt.Errorf("invalid burst %q of kind %s", burstStr, kind)
+		}
+		limits[kind] = KindRateLimit{QPS: qps, Burst: burst}
+	}
+
+	return limits, nil
+}
+
+// rateLimitedConfig returns a copy of the config whose clients share a single token bucket, the config is returned
+// as it is if there is no global limit
+func rateLimitedConfig(config *rest.Config, limits *RateLimits) *rest.Config {
+	if limits == nil || limits.QPS <= 0 {
+		return config
+	}
+
+	burst := limits.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	limited := rest.CopyConfig(config)
+	limited.QPS, limited.Burst = limits.QPS, burst
+	limited.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(limits.QPS, burst)
+
+	return limited
+}
+
+// kindRateLimiters resolves the kinds in the scheme and returns the rate limiters of their work queues, which keep the
+// default per-item backoff of the controllers and add the limit of the kind on top
+func kindRateLimiters(scheme *runtime.Scheme, kinds map[string]KindRateLimit) (map[schema.GroupVersionKind]workqueue.RateLimiter, error) {
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	gvks := make(map[string][]schema.GroupVersionKind)
+	for gvk := range scheme.AllKnownTypes() {
+		if _, ok := kinds[gvk.Kind]; ok {
+			gvks[gvk.Kind] = append(gvks[gvk.Kind], gvk)
+		}
+	}
+
+	limiters := make(map[schema.GroupVersionKind]workqueue.RateLimiter)
+	for kind, limit := range kinds {
+		matched := gvks[kind]
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("unknown kind %s", kind)
+		}
+		sort.Slice(matched, func(i, j int) bool { return matched[i].String() < matched[j].String() })
+		for _, gvk := range matched {
+			if gvk.Group != matched[0].Group {
+				return nil, fmt.Errorf("kind %s is ambiguous, it's in both %s and %s", kind, matched[0].Group, gvk.Group)
+			}
+		}
+
+		// the versions of the kind share the limit
+		limiter := workqueue.NewMaxOfRateLimiter(
+			// the same as the default rate limiter of the controllers
+			workqueue.NewItemFastSlowRateLimiter(time.Millisecond, 2*time.Minute, 30),
+			workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 30*time.Second),
+			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(limit.QPS), limit.Burst)},
+		)
+		for _, gvk := range matched {
+			limiters[gvk] = limiter
+		}
+	}
+
+	return limiters, nil
+}
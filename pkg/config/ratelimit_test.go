@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/rest"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestParseKindRateLimits(t *testing.T) {
+	limits, err := ParseKindRateLimits(" VlanStatus=2.5:5, NetworkAttachmentDefinition=10:20,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]KindRateLimit{
+		"VlanStatus":                  {QPS: 2.5, Burst: 5},
+		"NetworkAttachmentDefinition": {QPS: 10, Burst: 20},
+	}, limits)
+
+	limits, err = ParseKindRateLimits("")
+	assert.NoError(t, err)
+	assert.Empty(t, limits)
+
+	for _, invalid := range []string{"VlanStatus", "VlanStatus=5", "=5:10", "VlanStatus=0:10", "VlanStatus=5:x"} {
+		_, err := ParseKindRateLimits(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestKindRateLimiters(t *testing.T) {
+	limiters, err := kindRateLimiters(Scheme, map[string]KindRateLimit{"VlanStatus": {QPS: 5, Burst: 10}})
+	assert.NoError(t, err)
+	assert.Contains(t, limiters, networkv1.SchemeGroupVersion.WithKind("VlanStatus"))
+
+	_, err = kindRateLimiters(Scheme, map[string]KindRateLimit{"NoSuchKind": {QPS: 5, Burst: 10}})
+	assert.ErrorContains(t, err, "unknown kind")
+}
+
+func TestRateLimitedConfig(t *testing.T) {
+	config := &rest.Config{Host: "https://127.0.0.1:6443"}
+	assert.Same(t, config, rateLimitedConfig(config, &RateLimits{}))
+
+	limited := rateLimitedConfig(config, &RateLimits{QPS: 20, Burst: 40})
+	assert.NotSame(t, config, limited)
+	assert.NotNil(t, limited.RateLimiter)
+	assert.Nil(t, config.RateLimiter)
+	assert.Equal(t, float32(20), limited.RateLimiter.QPS())
+}
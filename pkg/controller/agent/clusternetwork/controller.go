@@ -3,17 +3,22 @@ package clusternetwork
 import (
 	"context"
 	"errors"
-
-	"github.com/vishvananda/netlink"
+	"fmt"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
 
+	"github.com/harvester/harvester-network-controller/pkg/agentapi"
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/localarea"
 
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
+	"github.com/harvester/harvester-network-controller/pkg/network"
 	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/monitor"
 	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
@@ -23,45 +28,99 @@ const (
 )
 
 type Handler struct {
-	cnCache   ctlnetworkv1.ClusterNetworkCache
-	cnClient  ctlnetworkv1.ClusterNetworkClient
-	nadCache  ctlcniv1.NetworkAttachmentDefinitionCache
-	nadClient ctlcniv1.NetworkAttachmentDefinitionClient
+	nodeName     string
+	cnCache      ctlnetworkv1.ClusterNetworkCache
+	cnClient     ctlnetworkv1.ClusterNetworkClient
+	cnController ctlnetworkv1.ClusterNetworkController
+	nadCache     ctlcniv1.NetworkAttachmentDefinitionCache
+	nadClient    ctlcniv1.NetworkAttachmentDefinitionClient
+	vsCache      ctlnetworkv1.VlanStatusCache
+	vsClient     ctlnetworkv1.VlanStatusClient
+
+	deferred     *deferredVIDs
+	promisc      *nadRefs
+	markings     *nadRefs
+	qinq         *nadRefs
+	locks        *utils.KeyMutex
+	converged    *utils.Gate
+	statusWriter *utils.StatusWriter
+	localAreas   *localarea.Sources
 }
 
 func Register(ctx context.Context, management *config.Management) error {
 	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
 	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	vss := management.HarvesterNetworkFactory.Network().V1beta1().VlanStatus()
 	handler := Handler{
-		cnCache:   cns.Cache(),
-		cnClient:  cns,
-		nadClient: nads,
-		nadCache:  nads.Cache(),
+		nodeName:     management.Options.NodeName,
+		cnCache:      cns.Cache(),
+		cnClient:     cns,
+		cnController: cns,
+		nadClient:    nads,
+		nadCache:     nads.Cache(),
+		vsCache:      vss.Cache(),
+		vsClient:     vss,
+		deferred:     newDeferredVIDs(),
+		promisc:      newNadRefs(),
+		markings:     newNadRefs(),
+		qinq:         newNadRefs(),
+		locks:        management.Locks,
+		converged:    management.Converged,
+		statusWriter: management.StatusWriter,
+		localAreas:   management.LocalAreas,
 	}
 
+	vmPortMonitor := monitor.NewMonitor(&monitor.Handler{
+		NewLink: handler.onVMPortChange,
+		DelLink: handler.onVMPortDelete,
+	})
+	vmPortMonitor.AddPattern(vmPortMonitorKey, monitor.NewPattern(typeVeth, ""))
+	go vmPortMonitor.Start(ctx)
+
 	cns.OnChange(ctx, controllerName, handler.OnChange)
+	nads.OnChange(ctx, controllerName, handler.onNadChange)
+	// the sources changing on their own wake up the cluster networks whose VLAN IDs changed
+	management.LocalAreas.Watch(ctx, cns.Enqueue)
+	agentapi.RegisterResync("clusternetworks", handler.resync)
 	return nil
 }
 
+// resync enqueues all the cluster networks on request of the agent API
+func (h Handler) resync() (int, error) {
+	cns, err := h.cnCache.List(labels.Everything())
+	if err != nil {
+		return 0, err
+	}
+	for _, cn := range cns {
+		h.cnController.Enqueue(cn.Name)
+	}
+
+	return len(cns), nil
+}
+
 // to support vlan trunk mode nad
 // the vlan set of a specific cluster network is computed dynamically via the nad list
 func (h Handler) OnChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
 	if cn == nil || cn.DeletionTimestamp != nil {
 		return nil, nil
 	}
+	// the vids of the cluster networks are added in one batch while the agent converges the node network at startup
+	h.converged.Wait()
+	defer metrics.ObserveReconcile(controllerName)()
 	logrus.Infof("cluster network %s has been changed, vid hash: %v", cn.Name, cn.Annotations[utils.KeyVlanIDSetStrHash])
+	defer h.locks.Lock(utils.LockKeyOfClusterNetwork(cn.Name))()
 
-	v, err := vlan.GetVlan(cn.Name)
+	v, err := vlan.GetVlanWithDatapath(cn.Name, cn.Spec.Datapath)
 	if err != nil {
 		// vlanconfig controller sets up the non-mgmt cn; mgmt cn is setup by wicked daemon service
-		if errors.As(err, &netlink.LinkNotFoundError{}) {
+		if errors.Is(err, network.ErrLinkNotFound) {
 			logrus.Infof("cluster network %s is not set on this node, skip", cn.Name)
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	cnVlans, err := utils.GeVlanIDSetFromClusterNetwork(cn.Name, h.nadCache)
+	cnVlans, err := h.localAreas.VlanIDSetOf(cn)
 	if err != nil {
 		logrus.Infof("cluster network %s failed to get vlanset %s", cn.Name, err.Error())
 		return nil, err
@@ -92,6 +151,9 @@ func (h Handler) OnChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.Cl
 	if err != nil {
 		return nil, err
 	}
+	if removed, err = h.removableVIDs(cn, v, removed); err != nil {
+		return nil, fmt.Errorf("cluster network %s failed to check the vids in use, error: %w", cn.Name, err)
+	}
 	logrus.Infof("cluster network %s will add %v vlans, remove %v vlans", cn.Name, added.GetVlanCount(), removed.GetVlanCount())
 
 	err = v.AddLocalAreas(added)
@@ -103,5 +165,44 @@ func (h Handler) OnChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.Cl
 		return nil, err
 	}
 
+	if err := v.EnsureUntaggedVID(utils.UntaggedVIDOf(cn)); err != nil {
+		return nil, fmt.Errorf("cluster network %s failed to set the untagged vid, error: %w", cn.Name, err)
+	}
+
+	if err := h.ensureQinQ(cn, v); err != nil {
+		return nil, fmt.Errorf("cluster network %s failed to set the QinQ bridges, error: %w", cn.Name, err)
+	}
+
+	multicast, err := h.ensureMulticast(cn, v, cnVlans)
+	if err != nil {
+		return nil, fmt.Errorf("cluster network %s failed to set multicast, error: %w", cn.Name, err)
+	}
+
+	if v.Bridge() != nil {
+		if err := v.Bridge().EnsureNeighbor(neighborConfig(cn.Spec.Neighbor)); err != nil {
+			return nil, fmt.Errorf("cluster network %s failed to set neighbor options, error: %w", cn.Name, err)
+		}
+	}
+
+	if err := h.ensurePromisc(cn, v); err != nil {
+		return nil, fmt.Errorf("cluster network %s failed to set promiscuous mode, error: %w", cn.Name, err)
+	}
+
+	if err := h.ensurePriority(cn, v); err != nil {
+		return nil, fmt.Errorf("cluster network %s failed to set the QoS priority, error: %w", cn.Name, err)
+	}
+
+	if err := h.ensureMarkings(cn, v); err != nil {
+		return nil, fmt.Errorf("cluster network %s failed to set the QoS markings, error: %w", cn.Name, err)
+	}
+
+	programmedVlans, err := v.ToVlanIDSet()
+	if err != nil {
+		return nil, err
+	}
+	if err := h.updateBridgeStatus(cn.Name, multicast, programmedVlans.VIDs()); err != nil {
+		return nil, err
+	}
+
 	return cn, nil
 }
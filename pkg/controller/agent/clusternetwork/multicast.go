@@ -0,0 +1,62 @@
+package clusternetwork
+
+import (
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// ensureMulticast applies the multicast settings of the cluster network to the bridge and returns the settings in
+// effect, nil for a bridge-less cluster network
+func (h Handler) ensureMulticast(cn *networkv1.ClusterNetwork, v *vlan.Vlan, vlans *utils.VlanIDSet) (*networkv1.MulticastStatus, error) {
+	br := v.Bridge()
+	if br == nil {
+		return nil, nil
+	}
+	vids := append([]uint16{utils.DefaultVlanID}, vlans.VIDs()...)
+
+	if err := br.EnsureMulticast(multicastConfig(cn.Spec.Multicast), vids); err != nil {
+		return nil, err
+	}
+	state, err := br.Multicast()
+	if err != nil {
+		return nil, err
+	}
+
+	return multicastStatus(state), nil
+}
+
+func multicastConfig(options *networkv1.MulticastOptions) *iface.MulticastConfig {
+	if options == nil {
+		return nil
+	}
+
+	cfg := &iface.MulticastConfig{Snooping: options.Snooping, Querier: options.Querier}
+	if len(options.VLANs) > 0 {
+		cfg.Vlans = make(map[uint16]iface.VlanMulticastConfig, len(options.VLANs))
+		for _, o := range options.VLANs {
+			cfg.Vlans[o.VID] = iface.VlanMulticastConfig{Snooping: o.Snooping, Querier: o.Querier}
+		}
+	}
+
+	return cfg
+}
+
+func multicastStatus(state *iface.MulticastState) *networkv1.MulticastStatus {
+	status := &networkv1.MulticastStatus{
+		Snooping:     state.Snooping,
+		Querier:      state.Querier,
+		VlanSnooping: state.VlanSnooping,
+	}
+	for _, vid := range state.SortedVIDs() {
+		cfg := state.Vlans[vid]
+		status.VLANs = append(status.VLANs, networkv1.VlanMulticastOptions{
+			VID:      vid,
+			Snooping: cfg.Snooping,
+			Querier:  cfg.Querier,
+		})
+	}
+
+	return status
+}
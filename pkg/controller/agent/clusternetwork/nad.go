@@ -0,0 +1,80 @@
+package clusternetwork
+
+import (
+	"sync"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// nadRef is the cluster network a NAD references with a setting applied to the bridge or the uplink, and the value
+// of the setting
+type nadRef struct {
+	cnName string
+	value  string
+}
+
+// nadRefs records the references of the NADs to the settings of their cluster networks, e.g. the promiscuous mode,
+// so that the cluster network is reconciled once the NAD is deleted or changes the setting, the NAD is gone from the
+// cache by then
+type nadRefs struct {
+	mutex *sync.Mutex
+	refs  map[string]nadRef
+}
+
+func newNadRefs() *nadRefs {
+	return &nadRefs{
+		mutex: new(sync.Mutex),
+		refs:  make(map[string]nadRef),
+	}
+}
+
+// set records the reference of the NAD, an empty cluster network drops the reference, and returns the reference
+// recorded before
+func (r *nadRefs) set(key string, ref nadRef) nadRef {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	previous := r.refs[key]
+	if ref.cnName == "" {
+		delete(r.refs, key)
+	} else {
+		r.refs[key] = ref
+	}
+	return previous
+}
+
+// onNadChange reconciles the cluster networks whose references of the promiscuous mode, the QoS marking or QinQ are
+// changed by the NAD
+func (h Handler) onNadChange(key string, nad *nadv1.NetworkAttachmentDefinition) (*nadv1.NetworkAttachmentDefinition, error) {
+	promisc, marking, qinq := nadRef{}, nadRef{}, nadRef{}
+	if nad != nil && isPromiscNad(nad) {
+		promisc.cnName = nad.Labels[utils.KeyClusterNetworkLabel]
+	}
+	if nad != nil && nad.DeletionTimestamp == nil {
+		if value, ok := nad.Annotations[utils.KeyQoSMarking]; ok {
+			// the VIDs the marking applies to are in the config
+			marking = nadRef{cnName: nad.Labels[utils.KeyClusterNetworkLabel], value: value + "/" + nad.Spec.Config}
+		}
+	}
+
+	if nad != nil {
+		if value := qinqRef(nad); value != "" {
+			qinq = nadRef{cnName: nad.Labels[utils.KeyClusterNetworkLabel], value: value}
+		}
+	}
+
+	for refs, ref := range map[*nadRefs]nadRef{h.promisc: promisc, h.markings: marking, h.qinq: qinq} {
+		previous := refs.set(key, ref)
+		if previous == ref {
+			continue
+		}
+		for _, name := range []string{previous.cnName, ref.cnName} {
+			if name != "" {
+				h.cnController.Enqueue(name)
+			}
+		}
+	}
+
+	return nad, nil
+}
@@ -0,0 +1,59 @@
+package clusternetwork
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	// the ports of the VMs attached to the bridge by the bridge CNI
+	vmPortMonitorKey = "vm-port"
+	typeVeth         = "veth"
+)
+
+func neighborConfig(options *networkv1.NeighborOptions) iface.NeighborConfig {
+	if options == nil {
+		return iface.NeighborConfig{}
+	}
+
+	return iface.NeighborConfig{ProxyARP: options.ProxyARP, Suppress: options.Suppression}
+}
+
+// onVMPortChange applies the ARP/ND settings of the cluster network to the VM ports attached to its bridge after the
+// cluster network is reconciled
+func (h Handler) onVMPortChange(_ string, update *netlink.LinkUpdate) error {
+	masterIndex := update.Link.Attrs().MasterIndex
+	if masterIndex == 0 {
+		return nil
+	}
+	master, err := network.Handle().LinkByIndex(masterIndex)
+	if err != nil {
+		// the port may be detached from the bridge in the meantime
+		logrus.Debugf("get master of %s failed, error: %s", update.Link.Attrs().Name, err.Error())
+		return nil
+	}
+	if master.Type() != iface.TypeBridge || !strings.HasSuffix(master.Attrs().Name, utils.BridgeSuffix) {
+		return nil
+	}
+
+	cn, err := h.cnCache.Get(strings.TrimSuffix(master.Attrs().Name, utils.BridgeSuffix))
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// the ports are attached without proxy ARP and suppression
+	if cn.Spec.Neighbor == nil {
+		return nil
+	}
+
+	return iface.EnsurePortNeighbor(update.Link, neighborConfig(cn.Spec.Neighbor))
+}
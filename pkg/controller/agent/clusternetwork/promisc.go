@@ -0,0 +1,65 @@
+package clusternetwork
+
+import (
+	"fmt"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+func isPromiscNad(nad *nadv1.NetworkAttachmentDefinition) bool {
+	if nad.DeletionTimestamp != nil {
+		return false
+	}
+	nc, err := utils.DecodeNadConfigToNetConf(nad)
+	if err != nil {
+		return false
+	}
+	return nc.IsBridgeCNI() && nc.PromiscMode
+}
+
+// ensurePromisc turns the promiscuous mode of the bridge and the uplink on while any NAD of the cluster network
+// requests it, and off after the last one goes away. The bridge of mgmt is left as the node installation sets it up.
+func (h Handler) ensurePromisc(cn *networkv1.ClusterNetwork, v *vlan.Vlan) error {
+	if cn.Name == utils.ManagementClusterNetworkName {
+		return nil
+	}
+
+	nads, err := h.nadCache.List("", labels.Set{utils.KeyClusterNetworkLabel: cn.Name}.AsSelector())
+	if err != nil {
+		return err
+	}
+	refs := 0
+	for _, nad := range nads {
+		if isPromiscNad(nad) {
+			refs++
+		}
+	}
+
+	links := []netlink.Link{}
+	if v.Bridge() != nil {
+		links = append(links, v.Bridge())
+	}
+	if v.Uplink() != nil {
+		links = append(links, v.Uplink())
+	}
+	for _, l := range links {
+		changed, err := iface.EnsurePromisc(l, refs > 0)
+		if err != nil {
+			return fmt.Errorf("set promiscuous mode of %s failed, error: %w", l.Attrs().Name, err)
+		}
+		if changed {
+			logrus.Infof("cluster network %s turns the promiscuous mode of %s to %t, %d NAD(s) request it",
+				cn.Name, l.Attrs().Name, refs > 0, refs)
+		}
+	}
+
+	return nil
+}
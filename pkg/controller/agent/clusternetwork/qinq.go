@@ -0,0 +1,39 @@
+package clusternetwork
+
+import (
+	"fmt"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// qinqRef returns the S-VLAN and the C-VLAN of the QinQ NAD as the value of its reference, empty if it's not QinQ. The
+// QinQ NADs are kept out of the VLAN IDs of the cluster network, so they reconcile it on their own.
+func qinqRef(nad *nadv1.NetworkAttachmentDefinition) string {
+	if nad.DeletionTimestamp != nil {
+		return ""
+	}
+	nc, err := utils.DecodeNadConfigToNetConf(nad)
+	if err != nil || !nc.IsBridgeCNI() || !nc.IsQinQ() {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", nc.OuterVlan, nc.Vlan)
+}
+
+// ensureQinQ builds the QinQ bridges of the S-VLANs of the QinQ NADs of the cluster network
+func (h Handler) ensureQinQ(cn *networkv1.ClusterNetwork, v *vlan.Vlan) error {
+	nads, err := h.nadCache.List("", labels.Set{utils.KeyClusterNetworkLabel: cn.Name}.AsSelector())
+	if err != nil {
+		return err
+	}
+	sets, err := utils.QinQVlanIDSets(nads)
+	if err != nil {
+		return err
+	}
+
+	return v.EnsureQinQ(sets)
+}
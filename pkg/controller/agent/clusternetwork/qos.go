@@ -0,0 +1,54 @@
+package clusternetwork
+
+import (
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// ensurePriority sets the skb priority of the traffic leaving the uplink of a prioritized cluster network, the qdiscs
+// of the physical NICs shared with the other cluster networks then transmit it first. The traffic of the VLAN
+// sub-interfaces of a shared bond keeps the priority on the way to the slaves.
+func (h Handler) ensurePriority(cn *networkv1.ClusterNetwork, v *vlan.Vlan) error {
+	if v.Uplink() == nil {
+		return nil
+	}
+
+	priority := uint32(0)
+	if utils.IsPrioritized(cn) {
+		priority = iface.PriorityHigh
+	}
+
+	return iface.EnsureEgressPriority(v.Uplink(), priority)
+}
+
+// ensureMarkings marks the frames of the VIDs of the NADs with the QoS marking when they leave the uplink, so that the
+// physical fabric honors the priority of e.g. the voice networks. A VID marked differently by several NADs, which the
+// webhook rejects, follows the first NAD.
+func (h Handler) ensureMarkings(cn *networkv1.ClusterNetwork, v *vlan.Vlan) error {
+	if v.Uplink() == nil {
+		return nil
+	}
+
+	nads, err := h.nadCache.List("", labels.Set{utils.KeyClusterNetworkLabel: cn.Name}.AsSelector())
+	if err != nil {
+		return err
+	}
+	markings, err := utils.QoSMarkingsOfNads(nads)
+	if markings == nil {
+		return err
+	} else if err != nil {
+		logrus.Warnf("cluster network %s has conflicting QoS markings, %s", cn.Name, err.Error())
+	}
+
+	desired := make(map[uint16]iface.Marking, len(markings))
+	for vid, marking := range markings {
+		desired[vid] = iface.Marking{PCP: marking.PCP, DSCP: marking.DSCP}
+	}
+
+	return iface.EnsureEgressMarkings(v.Uplink(), desired)
+}
@@ -0,0 +1,40 @@
+package clusternetwork
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// updateBridgeStatus reports the multicast settings in effect and the VLAN IDs programmed on the uplink in the
+// vlanstatus of the node, the manager checks the VLAN IDs before a VM is migrated to the node
+func (h Handler) updateBridgeStatus(cnName string, multicast *networkv1.MulticastStatus, vids []uint16) error {
+	vs, err := utils.GetVlanStatus(h.vsCache, cnName, h.nodeName)
+	if apierrors.IsNotFound(err) {
+		// the vlanstatus is created by the vlanconfig controller once the VLAN is set up
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not get vlanstatus of cluster network %s, error: %w", cnName, err)
+	}
+	if reflect.DeepEqual(vs.Status.Multicast, multicast) && slices.Equal(vs.Status.VIDs, vids) {
+		return nil
+	}
+
+	// the write doesn't hold back the reconcile of the bridge, a newer write replaces it if it's still pending
+	h.statusWriter.Submit(utils.StatusKeyOfBridgeStatus(cnName, h.nodeName), func() error {
+		if _, err := utils.UpdateVlanStatus(h.vsClient, vs, func(vs *networkv1.VlanStatus) {
+			vs.Status.Multicast = multicast
+			vs.Status.VIDs = vids
+		}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to update vlanstatus %s, error: %w", vs.Name, err)
+		}
+		return nil
+	})
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package clusternetwork
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	defaultVIDRemovalMaxWait = 300 * time.Second
+	vidRemovalRecheckPeriod  = 5 * time.Second
+)
+
+// deferredVIDs records since when the removal of the VIDs has been deferred per cluster network
+type deferredVIDs struct {
+	mutex *sync.Mutex
+	since map[string]map[uint16]time.Time
+}
+
+func newDeferredVIDs() *deferredVIDs {
+	return &deferredVIDs{
+		mutex: new(sync.Mutex),
+		since: make(map[string]map[uint16]time.Time),
+	}
+}
+
+func vidRemovalPolicy(options *networkv1.VIDRemovalOptions) (networkv1.VIDRemovalPolicy, time.Duration) {
+	if options == nil {
+		return networkv1.VIDRemovalWaitForPorts, defaultVIDRemovalMaxWait
+	}
+
+	policy, maxWait := options.Policy, time.Duration(options.MaxWaitSeconds)*time.Second
+	if policy == "" {
+		policy = networkv1.VIDRemovalWaitForPorts
+	}
+	if maxWait == 0 {
+		maxWait = defaultVIDRemovalMaxWait
+	}
+
+	return policy, maxWait
+}
+
+// removableVIDs returns the VIDs which can be removed from the uplink right now. The removal of a VID is deferred
+// while the VM ports on the bridge still use it, e.g. the NAD is deleted with its namespace before the VMs are torn
+// down or migrated away, so that the VMs don't lose packets in the meantime. The cluster network is requeued until
+// the deferred VIDs are removed.
+func (h Handler) removableVIDs(cn *networkv1.ClusterNetwork, v *vlan.Vlan, removed *utils.VlanIDSet) (*utils.VlanIDSet, error) {
+	policy, maxWait := vidRemovalPolicy(cn.Spec.VIDRemoval)
+	if policy == networkv1.VIDRemovalImmediate || removed.GetVlanCount() == 0 {
+		h.deferred.set(cn.Name, nil)
+		return removed, nil
+	}
+
+	users, err := v.VlanUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	previous := h.deferred.get(cn.Name)
+	deferred := map[uint16]time.Time{}
+	removable := utils.NewVlanIDSet()
+	if err := removed.WalkVIDs(cn.Name, func(vid uint16) error {
+		ports := users[vid]
+		if len(ports) == 0 {
+			return removable.SetUint16VID(vid)
+		}
+		since, ok := previous[vid]
+		if !ok {
+			since = now
+		}
+		if now.Sub(since) >= maxWait {
+			logrus.Warnf("cluster network %s removes vid %d after waiting for %s, it's still used by ports %v",
+				cn.Name, vid, maxWait, ports)
+			return removable.SetUint16VID(vid)
+		}
+		logrus.Infof("cluster network %s defers the removal of vid %d used by ports %v", cn.Name, vid, ports)
+		deferred[vid] = since
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	h.deferred.set(cn.Name, deferred)
+	if len(deferred) > 0 {
+		h.cnController.EnqueueAfter(cn.Name, vidRemovalRecheckPeriod)
+	}
+
+	return removable, nil
+}
+
+// onVMPortDelete rechecks the deferred VIDs as soon as a VM port is removed
+func (h Handler) onVMPortDelete(_ string, _ *netlink.LinkUpdate) error {
+	for _, cnName := range h.deferred.clusterNetworks() {
+		h.cnController.Enqueue(cnName)
+	}
+
+	return nil
+}
+
+func (d *deferredVIDs) get(cnName string) map[uint16]time.Time {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.since[cnName]
+}
+
+func (d *deferredVIDs) set(cnName string, since map[uint16]time.Time) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(since) == 0 {
+		delete(d.since, cnName)
+		return
+	}
+	d.since[cnName] = since
+}
+
+func (d *deferredVIDs) clusterNetworks() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	names := make([]string, 0, len(d.since))
+	for name := range d.since {
+		names = append(names, name)
+	}
+	return names
+}
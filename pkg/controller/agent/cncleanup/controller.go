@@ -0,0 +1,186 @@
+// Package cncleanup tears down the bridge and the uplink of a cluster network left on the node after the cluster
+// network is deleted directly, i.e. without the removal of its vlanconfigs tearing them down first.
+package cncleanup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/matcher"
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	controllerName = "harvester-network-cn-cleanup-controller"
+)
+
+type Handler struct {
+	nodeName   string
+	nodeClient ctlcorev1.NodeClient
+	vcCache    ctlnetworkv1.VlanConfigCache
+	vsCache    ctlnetworkv1.VlanStatusCache
+	vsClient   ctlnetworkv1.VlanStatusClient
+
+	locks     *utils.KeyMutex
+	converged *utils.Gate
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
+	vcs := management.HarvesterNetworkFactory.Network().V1beta1().VlanConfig()
+	vss := management.HarvesterNetworkFactory.Network().V1beta1().VlanStatus()
+	nodes := management.CoreFactory.Core().V1().Node()
+
+	handler := &Handler{
+		nodeName:   management.Options.NodeName,
+		nodeClient: nodes,
+		vcCache:    vcs.Cache(),
+		vsCache:    vss.Cache(),
+		vsClient:   vss,
+		locks:      management.Locks,
+		converged:  management.Converged,
+	}
+
+	// the removal is handled on the deletion event rather than with a finalizer, which is shared by the agents of all
+	// nodes and would be removed by the first one, so that every agent cleans up its own node
+	cns.OnChange(ctx, controllerName, handler.OnRemove)
+	return nil
+}
+
+// OnRemove tears down the bridge of the removed cluster network once no vlanconfig of it matches this node anymore.
+// The vlanconfigs still matching the node keep the bridge, it's torn down on their removal as usual.
+func (h Handler) OnRemove(name string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn != nil || name == "" || name == utils.ManagementClusterNetworkName {
+		return cn, nil
+	}
+	h.converged.Wait()
+	defer metrics.ObserveReconcile(controllerName)()
+	defer h.locks.Lock(utils.LockKeyOfClusterNetwork(name))()
+
+	users, err := h.bridgeUsers(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) > 0 {
+		logrus.Infof("cluster network %s has been removed, its bridge is kept for vlanconfig(s) %v on node %s",
+			name, users, h.nodeName)
+		return nil, nil
+	}
+
+	if err := teardown(name); err != nil {
+		return nil, fmt.Errorf("tear down the bridge of the removed cluster network %s failed, error: %w", name, err)
+	}
+	if err := h.deleteVlanStatus(name); err != nil {
+		return nil, err
+	}
+	if err := h.removeNodeLabel(name); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// bridgeUsers returns the names of the vlanconfigs of the cluster network which match this node
+func (h Handler) bridgeUsers(name string) ([]string, error) {
+	vcs, err := h.vcCache.GetByIndex(utils.VlanConfigByClusterNetworkIndex, name)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]string, 0)
+	for _, vc := range vcs {
+		if vc.DeletionTimestamp != nil || vc.Spec.ClusterNetwork != name {
+			continue
+		}
+		isMatched, err := matcher.IsMatched(vc, h.nodeName)
+		if err != nil {
+			return nil, err
+		}
+		if isMatched {
+			users = append(users, vc.Name)
+		}
+	}
+
+	return users, nil
+}
+
+// teardown removes the uplink and the bridge of the cluster network, the bridge alone if it has lost its uplink
+func teardown(name string) error {
+	v, err := vlan.LookupVlan(name)
+	if err == nil {
+		logrus.Infof("tear down the uplink %s of the removed cluster network %s", v.Uplink().Attrs().Name, name)
+		return v.Teardown()
+	} else if !errors.Is(err, network.ErrLinkNotFound) {
+		return err
+	}
+
+	// the bridges per VID and the QinQ bridges have lost their uplinks with the uplink of the cluster network
+	if err := vlan.RemoveBridgesPerVID(name); err != nil {
+		return err
+	}
+	if err := vlan.RemoveQinQBridges(name); err != nil {
+		return err
+	}
+	if err := vlan.RemoveOVSBridge(name); err != nil {
+		return err
+	}
+	br := iface.NewBridge(utils.GenerateBridgeName(name))
+	if err := br.Fetch(); errors.Is(err, network.ErrLinkNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	logrus.Infof("remove the bridge %s without uplink of the removed cluster network %s", br.Name, name)
+
+	return iface.NewLink(br).Remove()
+}
+
+// deleteVlanStatus deletes the vlanstatus of the cluster network on this node, which its vlanconfig has left behind
+func (h Handler) deleteVlanStatus(name string) error {
+	vs, err := utils.GetVlanStatus(h.vsCache, name, h.nodeName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := h.vsClient.Delete(vs.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete vlanstatus %s, error: %w", vs.Name, err)
+	}
+	metrics.ForgetNetwork(h.nodeName, name)
+
+	return nil
+}
+
+func (h Handler) removeNodeLabel(name string) error {
+	defer h.locks.Lock(utils.LockKeyOfNode(h.nodeName))()
+	node, err := h.nodeClient.Get(h.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	key := utils.GetLabelKeyOfClusterNetwork(name)
+	if _, ok := node.Labels[key]; !ok {
+		return nil
+	}
+	nodeCopy := node.DeepCopy()
+	delete(nodeCopy.Labels, key)
+	if _, err := h.nodeClient.Update(nodeCopy); err != nil {
+		return fmt.Errorf("remove label of cluster network %s from node %s failed, error: %w", name, h.nodeName, err)
+	}
+
+	return nil
+}
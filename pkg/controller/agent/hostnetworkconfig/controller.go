@@ -11,11 +11,11 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 
-	"github.com/vishvananda/netlink"
-
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
+	"github.com/harvester/harvester-network-controller/pkg/network"
 	"github.com/harvester/harvester-network-controller/pkg/network/iface"
 	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
@@ -38,6 +38,7 @@ type Handler struct {
 	cnCache           ctlnetworkv1.ClusterNetworkCache
 	cnController      ctlnetworkv1.ClusterNetworkController
 
+	locks         *utils.KeyMutex
 	mu            sync.Mutex
 	leaseManagers map[string]*LeaseManager
 	mgmtIntfName  string
@@ -59,6 +60,7 @@ func Register(ctx context.Context, management *config.Management) error {
 		cnCache:           cns.Cache(),
 		cnController:      cns,
 		leaseManagers:     make(map[string]*LeaseManager),
+		locks:             management.Locks,
 	}
 
 	if mgmtIntf, err = iface.GetMgmtInterface(); err != nil {
@@ -75,7 +77,7 @@ func Register(ctx context.Context, management *config.Management) error {
 func checkifHostNetworkInterfaceExists(hnc *networkv1.HostNetworkConfig) (bool, error) {
 	v, err := vlan.GetVlan(hnc.Spec.ClusterNetwork)
 	if err != nil {
-		if errors.As(err, &netlink.LinkNotFoundError{}) {
+		if errors.Is(err, network.ErrLinkNotFound) {
 			return false, nil
 		}
 		return false, err
@@ -87,9 +89,9 @@ func checkifHostNetworkInterfaceExists(hnc *networkv1.HostNetworkConfig) (bool,
 	}
 
 	vlanIntf := utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID)
-	_, err = netlink.LinkByName(vlanIntf)
+	_, err = network.Handle().LinkByName(vlanIntf)
 	if err != nil {
-		if errors.As(err, &netlink.LinkNotFoundError{}) {
+		if errors.Is(network.Classify(err), network.ErrLinkNotFound) {
 			return false, nil
 		}
 		return false, err
@@ -102,8 +104,10 @@ func (h *Handler) OnChange(_ string, hnc *networkv1.HostNetworkConfig) (*network
 	if hnc == nil || hnc.DeletionTimestamp != nil {
 		return nil, nil
 	}
+	defer metrics.ObserveReconcile(ControllerName)()
 
 	logrus.Infof("hostnetwork config %s is changed, spec: %+v", hnc.Name, hnc.Spec)
+	defer h.locks.Lock(utils.LockKeyOfClusterNetwork(hnc.Spec.ClusterNetwork))()
 
 	matchNodeSet, err := h.matchNode(hnc.Spec.NodeSelector)
 	if err != nil {
@@ -144,7 +148,7 @@ func (h *Handler) OnChange(_ string, hnc *networkv1.HostNetworkConfig) (*network
 
 	v, err := vlan.GetVlan(hnc.Spec.ClusterNetwork)
 	if err != nil {
-		if errors.As(err, &netlink.LinkNotFoundError{}) {
+		if errors.Is(err, network.ErrLinkNotFound) {
 			logrus.Infof("cluster network %s is not set on this node, skip", hnc.Spec.ClusterNetwork)
 			//stop and delete all lease manaagers assosciated with the cluster network (if uplink removed due to vlanconfig changes/deletion)
 			h.stopLeaseManager(utils.GetClusterNetworkVlanDevice(hnc.Spec.ClusterNetwork, hnc.Spec.VlanID))
@@ -222,7 +226,7 @@ func (h *Handler) updateHostNetworkReadyStatus(hnc *networkv1.HostNetworkConfig,
 func (h *Handler) removeHostNetworkInterface(hnc *networkv1.HostNetworkConfig, onChange bool) (*networkv1.HostNetworkConfig, error) {
 	v, err := vlan.GetVlan(hnc.Spec.ClusterNetwork)
 	if err != nil {
-		if errors.As(err, &netlink.LinkNotFoundError{}) {
+		if errors.Is(err, network.ErrLinkNotFound) {
 			logrus.Infof("cluster network %s is not set on this node, skip", hnc.Spec.ClusterNetwork)
 			return nil, nil
 		}
@@ -231,7 +235,7 @@ func (h *Handler) removeHostNetworkInterface(hnc *networkv1.HostNetworkConfig, o
 
 	bridgelink, err := v.GetBridgelink()
 	if err != nil {
-		if errors.As(err, &netlink.LinkNotFoundError{}) {
+		if errors.Is(err, network.ErrLinkNotFound) {
 			return nil, nil
 		} else {
 			return nil, fmt.Errorf("failed to get link for bridge %s, error: %w", v.Bridge().Name, err)
@@ -267,8 +271,10 @@ func (h *Handler) OnRemove(_ string, hnc *networkv1.HostNetworkConfig) (*network
 	if hnc == nil {
 		return nil, nil
 	}
+	defer metrics.ObserveReconcile(ControllerName)()
 
 	logrus.Infof("hostnetwork config %s has been removed, spec: %+v", hnc.Name, hnc.Spec)
+	defer h.locks.Lock(utils.LockKeyOfClusterNetwork(hnc.Spec.ClusterNetwork))()
 
 	return h.removeHostNetworkInterface(hnc, false)
 }
@@ -439,7 +445,8 @@ func (h *Handler) startLeaseManager(bridgelink *iface.Link, vlanID uint16) (err
 }
 
 func (h *Handler) addNodeAnnotation(underlayIntfName string, underlay bool) error {
-	node, err := h.nodeCache.Get(h.nodeName)
+	defer h.locks.Lock(utils.LockKeyOfNode(h.nodeName))()
+	node, err := h.nodeClient.Get(h.nodeName, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
@@ -12,6 +12,8 @@ import (
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/inspect"
 	"github.com/harvester/harvester-network-controller/pkg/network/monitor"
 )
 
@@ -135,7 +137,7 @@ func (h Handler) isRuleChange(lm *networkv1.LinkMonitor) bool {
 	return true
 }
 
-func linkToLinkStatus(l netlink.Link) networkv1.LinkStatus {
+func linkToLinkStatus(l netlink.Link, owner networkv1.LinkOwner) networkv1.LinkStatus {
 	linkStatus := networkv1.LinkStatus{
 		Name:        l.Attrs().Name,
 		Index:       l.Attrs().Index,
@@ -143,6 +145,7 @@ func linkToLinkStatus(l netlink.Link) networkv1.LinkStatus {
 		MAC:         l.Attrs().HardwareAddr.String(),
 		Promiscuous: l.Attrs().Promisc != 0,
 		MasterIndex: l.Attrs().MasterIndex,
+		Owner:       owner,
 	}
 
 	switch l.Attrs().OperState {
@@ -200,9 +203,16 @@ func (h Handler) syncLinkStatus(lm *networkv1.LinkMonitor) error {
 	if err != nil {
 		return err
 	}
+	// the owner of a link depends on its master or parent, which may not match the pattern
+	allLinks, err := network.Handle().LinkList()
+	if err != nil {
+		return err
+	}
+	owners := inspect.ClassifyOwners(allLinks)
+
 	linkStatusList := make([]networkv1.LinkStatus, len(links))
 	for i, link := range links {
-		linkStatusList[i] = linkToLinkStatus(link)
+		linkStatusList[i] = linkToLinkStatus(link, owners[link.Attrs().Index])
 	}
 
 	return h.updateStatus(lm, linkStatusList)
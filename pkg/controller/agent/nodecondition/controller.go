@@ -0,0 +1,194 @@
+package nodecondition
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/kernel"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	controllerName = "harvester-network-node-condition-controller"
+
+	reasonVlanReady     = "VlanReady"
+	reasonVlanNotReady  = "VlanNotReady"
+	reasonNoVlanNetwork = "NoVlanNetwork"
+
+	reasonKernelReady   = "KernelReady"
+	reasonKernelMissing = "KernelPrerequisitesMissing"
+)
+
+// Handler mirrors the aggregate state of the VLAN networks on the node as a node condition, so that the broken VM
+// networking shows up in the node status without digging into the vlanstatuses
+type Handler struct {
+	nodeName       string
+	nodeClient     ctlcorev1.NodeClient
+	nodeCache      ctlcorev1.NodeCache
+	nodeController ctlcorev1.NodeController
+	vsCache        ctlnetworkv1.VlanStatusCache
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	nodes := management.CoreFactory.Core().V1().Node()
+	vss := management.HarvesterNetworkFactory.Network().V1beta1().VlanStatus()
+
+	handler := &Handler{
+		nodeName:       management.Options.NodeName,
+		nodeClient:     nodes,
+		nodeCache:      nodes.Cache(),
+		nodeController: nodes,
+		vsCache:        vss.Cache(),
+	}
+
+	for _, m := range kernel.MissingOnHost() {
+		logrus.Warnf("kernel prerequisite of node %s: %s", handler.nodeName, m)
+	}
+
+	nodes.OnChange(ctx, controllerName, handler.OnNodeChange)
+	vss.OnChange(ctx, controllerName, handler.OnVlanStatusChange)
+
+	return nil
+}
+
+// OnVlanStatusChange hands the vlanstatuses of the node over to the node handler, the vlanstatus is nil if it's
+// deleted
+func (h Handler) OnVlanStatusChange(_ string, vs *networkv1.VlanStatus) (*networkv1.VlanStatus, error) {
+	if vs == nil || vs.Status.Node == h.nodeName {
+		h.nodeController.Enqueue(h.nodeName)
+	}
+
+	return vs, nil
+}
+
+func (h Handler) OnNodeChange(_ string, node *corev1.Node) (*corev1.Node, error) {
+	if node == nil || node.DeletionTimestamp != nil || node.Name != h.nodeName {
+		return node, nil
+	}
+
+	vss, err := h.vsCache.GetByIndex(utils.VlanStatusByNodeIndex, h.nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vlanstatuses of node %s, error: %w", h.nodeName, err)
+	}
+
+	now := metav1.Now()
+	conditions := node.Status.Conditions
+	changedConds := make([]corev1.NodeCondition, 0, 2)
+	for _, cond := range []corev1.NodeCondition{vlanReadyCondition(vss), kernelReadyCondition(kernel.MissingOnHost())} {
+		var changed bool
+		if conditions, changed = setCondition(conditions, cond, now); changed {
+			changedConds = append(changedConds, cond)
+		}
+	}
+	if len(changedConds) == 0 {
+		return node, nil
+	}
+
+	nodeCopy := node.DeepCopy()
+	nodeCopy.Status.Conditions = conditions
+	updated, err := h.nodeClient.UpdateStatus(nodeCopy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update conditions of node %s, error: %w", h.nodeName, err)
+	}
+	for _, cond := range changedConds {
+		logrus.Infof("condition %s of node %s is %s, reason: %s, message: %s", cond.Type, h.nodeName, cond.Status,
+			cond.Reason, cond.Message)
+	}
+
+	return updated, nil
+}
+
+// kernelReadyCondition is true if the kernel has all the modules and options the agent relies on
+func kernelReadyCondition(missing []kernel.Missing) corev1.NodeCondition {
+	cond := corev1.NodeCondition{Type: utils.NodeConditionKernelReady}
+	if len(missing) == 0 {
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = reasonKernelReady
+		cond.Message = "the kernel has the modules and options required by the VLAN networks"
+		return cond
+	}
+
+	msgs := make([]string, 0, len(missing))
+	for _, m := range missing {
+		msgs = append(msgs, m.String())
+	}
+	cond.Status = corev1.ConditionFalse
+	cond.Reason = reasonKernelMissing
+	cond.Message = strings.Join(msgs, "; ")
+	return cond
+}
+
+// vlanReadyCondition is true if all VLAN networks of the node are ready
+func vlanReadyCondition(vss []*networkv1.VlanStatus) corev1.NodeCondition {
+	cond := corev1.NodeCondition{Type: utils.NodeConditionVlanReady}
+	if len(vss) == 0 {
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = reasonNoVlanNetwork
+		cond.Message = "no VLAN network is configured on the node"
+		return cond
+	}
+
+	notReady := make([]string, 0)
+	for _, vs := range vss {
+		if networkv1.Ready.IsTrue(vs) {
+			continue
+		}
+		msg := fmt.Sprintf("cluster network %s is not ready", vs.Status.ClusterNetwork)
+		if m := networkv1.Ready.GetMessage(vs); m != "" {
+			msg = fmt.Sprintf("%s: %s", msg, m)
+		}
+		notReady = append(notReady, msg)
+	}
+	if len(notReady) == 0 {
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = reasonVlanReady
+		cond.Message = "all VLAN networks are ready"
+		return cond
+	}
+
+	sort.Strings(notReady)
+	cond.Status = corev1.ConditionFalse
+	cond.Reason = reasonVlanNotReady
+	cond.Message = strings.Join(notReady, "; ")
+	return cond
+}
+
+// setCondition adds or updates the condition and returns whether the conditions change, the heartbeat isn't
+// refreshed if nothing changes to avoid updating the node all the time
+func setCondition(conditions []corev1.NodeCondition, cond corev1.NodeCondition,
+	now metav1.Time) ([]corev1.NodeCondition, bool) {
+	cond.LastHeartbeatTime = now
+	cond.LastTransitionTime = now
+
+	result := make([]corev1.NodeCondition, 0, len(conditions)+1)
+	found := false
+	for _, c := range conditions {
+		if c.Type != cond.Type {
+			result = append(result, c)
+			continue
+		}
+		found = true
+		if c.Status == cond.Status && c.Reason == cond.Reason && c.Message == cond.Message {
+			return conditions, false
+		}
+		if c.Status == cond.Status {
+			cond.LastTransitionTime = c.LastTransitionTime
+		}
+		result = append(result, cond)
+	}
+	if !found {
+		result = append(result, cond)
+	}
+
+	return result, true
+}
@@ -0,0 +1,117 @@
+// Package nodestate publishes the network capabilities of the node discovered at startup as its NodeNetworkState
+package nodestate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/capability"
+)
+
+const (
+	controllerName = "harvester-network-node-state-controller"
+)
+
+type Handler struct {
+	nodeName     string
+	agentVersion string
+	nodeCache    ctlcorev1.NodeCache
+	nnsClient    ctlnetworkv1.NodeNetworkStateClient
+
+	discover func() (*networkv1.NodeNetworkStateStatus, error)
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	nnss := management.HarvesterNetworkFactory.Network().V1beta1().NodeNetworkState()
+	nodes := management.CoreFactory.Core().V1().Node()
+
+	handler := &Handler{
+		nodeName:     management.Options.NodeName,
+		agentVersion: management.Options.Version,
+		nodeCache:    nodes.Cache(),
+		nnsClient:    nnss,
+		discover:     capability.DiscoverOnHost,
+	}
+
+	nnss.OnChange(ctx, controllerName, handler.OnChange)
+	// the NodeNetworkState doesn't exist on the first start of the agent
+	nnss.Enqueue(handler.nodeName)
+
+	return nil
+}
+
+// OnChange recreates the NodeNetworkState of the node if it's deleted and restores the discovered capabilities if
+// they're changed by others
+func (h Handler) OnChange(name string, nns *networkv1.NodeNetworkState) (*networkv1.NodeNetworkState, error) {
+	if name != h.nodeName || (nns != nil && nns.DeletionTimestamp != nil) {
+		return nns, nil
+	}
+
+	status, err := h.discover()
+	if err != nil {
+		return nil, fmt.Errorf("discover the network capabilities of node %s failed, error: %w", h.nodeName, err)
+	}
+	status.AgentVersion = h.agentVersion
+
+	if nns == nil {
+		return h.create(status)
+	}
+
+	// the discovery time is kept if nothing else changes
+	status.DiscoveredAt = nns.Status.DiscoveredAt
+	if reflect.DeepEqual(nns.Status, *status) {
+		return nns, nil
+	}
+	now := metav1.Now()
+	status.DiscoveredAt = &now
+
+	nnsCopy := nns.DeepCopy()
+	nnsCopy.Status = *status
+	updated, err := h.nnsClient.Update(nnsCopy)
+	if err != nil {
+		return nil, fmt.Errorf("update nodenetworkstate %s failed, error: %w", h.nodeName, err)
+	}
+
+	return updated, nil
+}
+
+// create owns the NodeNetworkState by the node, so that it's removed together with the node
+func (h Handler) create(status *networkv1.NodeNetworkStateStatus) (*networkv1.NodeNetworkState, error) {
+	node, err := h.nodeCache.Get(h.nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := metav1.Now()
+	status.DiscoveredAt = &now
+	nns := &networkv1.NodeNetworkState{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: h.nodeName,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "v1",
+					Kind:       "Node",
+					Name:       node.Name,
+					UID:        node.UID,
+				},
+			},
+		},
+		Status: *status,
+	}
+
+	created, err := h.nnsClient.Create(nns)
+	if err != nil {
+		return nil, fmt.Errorf("create nodenetworkstate %s failed, error: %w", h.nodeName, err)
+	}
+	logrus.Infof("the network capabilities of node %s: %+v", h.nodeName, created.Status.Capabilities)
+
+	return created, nil
+}
@@ -0,0 +1,259 @@
+// Package packetcapture runs the PacketCaptures of the node, each captures the frames of a cluster network into a pcap
+// file in the capture directory of the agent once
+package packetcapture
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/capture"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	controllerName = "harvester-network-packet-capture-controller"
+
+	bytesPerMiB = 1 << 20
+	// the defaults of the CRD, in case the PacketCapture is created without them
+	defaultDurationSeconds = 60
+	defaultMaxSizeMiB      = 16
+)
+
+type Handler struct {
+	ctx        context.Context
+	nodeName   string
+	captureDir string
+	pcClient   ctlnetworkv1.PacketCaptureClient
+
+	mutex sync.Mutex
+	// the cancel functions of the captures running on the node
+	running map[string]context.CancelFunc
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	pcs := management.HarvesterNetworkFactory.Network().V1beta1().PacketCapture()
+
+	handler := &Handler{
+		ctx:        ctx,
+		nodeName:   management.Options.NodeName,
+		captureDir: management.Options.CaptureDir,
+		pcClient:   pcs,
+		running:    make(map[string]context.CancelFunc),
+	}
+
+	pcs.OnChange(ctx, controllerName, handler.OnChange)
+
+	return nil
+}
+
+// OnChange starts the capture of a new PacketCapture of the node, and stops the capture and removes its pcap file once
+// the PacketCapture is removed. No finalizer is added, so that the PacketCaptures of a removed node are not stuck.
+func (h *Handler) OnChange(name string, pc *networkv1.PacketCapture) (*networkv1.PacketCapture, error) {
+	if pc == nil {
+		return nil, h.remove(name)
+	}
+	if pc.DeletionTimestamp != nil || pc.Spec.NodeName != h.nodeName {
+		return pc, nil
+	}
+	defer metrics.ObserveReconcile(controllerName)()
+
+	switch pc.Status.Phase {
+	case "":
+		return h.start(pc)
+	case networkv1.PacketCaptureRunning:
+		if h.isRunning(pc.Name) {
+			return pc, nil
+		}
+		// the capture is lost together with the agent, the frames captured so far are kept
+		return h.finish(pc, capture.Result{Bytes: fileSize(pc.Status.File)},
+			errors.New("the capture is interrupted, e.g. the agent is restarted"))
+	default:
+		return pc, nil
+	}
+}
+
+func (h *Handler) start(pc *networkv1.PacketCapture) (*networkv1.PacketCapture, error) {
+	if h.captureDir == "" {
+		return h.finish(pc, capture.Result{}, fmt.Errorf("packet capture is disabled on node %s", h.nodeName))
+	}
+
+	link, err := captureLink(pc)
+	if err != nil {
+		return h.finish(pc, capture.Result{}, err)
+	}
+
+	if err := os.MkdirAll(h.captureDir, 0700); err != nil {
+		return nil, fmt.Errorf("create capture directory %s failed, error: %w", h.captureDir, err)
+	}
+	path := h.pcapFile(pc.Name)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("create pcap file %s failed, error: %w", path, err)
+	}
+
+	now := metav1.Now()
+	updated, err := utils.UpdatePacketCapture(h.pcClient, pc, func(pc *networkv1.PacketCapture) {
+		pc.Status = networkv1.PacketCaptureStatus{
+			Phase:     networkv1.PacketCaptureRunning,
+			Interface: link,
+			File:      path,
+			StartedAt: &now,
+		}
+	})
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("update packet capture %s failed, error: %w", pc.Name, err)
+	}
+
+	ctx, cancel := context.WithCancel(h.ctx)
+	h.mutex.Lock()
+	h.running[pc.Name] = cancel
+	h.mutex.Unlock()
+
+	duration, maxSize := pc.Spec.DurationSeconds, pc.Spec.MaxSizeMiB
+	if duration <= 0 {
+		duration = defaultDurationSeconds
+	}
+	if maxSize <= 0 {
+		maxSize = defaultMaxSizeMiB
+	}
+	opts := capture.Options{
+		Link:     link,
+		VID:      pc.Spec.VID,
+		SnapLen:  pc.Spec.SnapLen,
+		MaxBytes: int64(maxSize) * bytesPerMiB,
+		Duration: time.Duration(duration) * time.Second,
+	}
+	logrus.Infof("start packet capture %s on %s of cluster network %s, vid %d, duration %s", pc.Name, link,
+		pc.Spec.ClusterNetwork, opts.VID, opts.Duration)
+	go h.run(ctx, updated, f, opts)
+
+	return updated, nil
+}
+
+// run captures into the file and records the result in the PacketCapture, the result is dropped if the PacketCapture
+// is removed meanwhile
+func (h *Handler) run(ctx context.Context, pc *networkv1.PacketCapture, f *os.File, opts capture.Options) {
+	defer func() {
+		h.mutex.Lock()
+		delete(h.running, pc.Name)
+		h.mutex.Unlock()
+	}()
+
+	w := bufio.NewWriter(f)
+	res, err := capture.Run(ctx, opts, w)
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	logrus.Infof("packet capture %s finished, frames: %d, bytes: %d, truncated: %t", pc.Name, res.Frames, res.Bytes,
+		res.Truncated)
+	if _, err := h.finish(pc, res, err); err != nil && !apierrors.IsNotFound(err) {
+		logrus.Errorf("record the result of packet capture %s failed, error: %s", pc.Name, err.Error())
+	}
+}
+
+// finish records the result of the capture, the capture is failed if the error is not nil
+func (h *Handler) finish(pc *networkv1.PacketCapture, res capture.Result, err error) (*networkv1.PacketCapture, error) {
+	now := metav1.Now()
+	return utils.UpdatePacketCapture(h.pcClient, pc, func(pc *networkv1.PacketCapture) {
+		pc.Status.Phase = networkv1.PacketCaptureSucceeded
+		pc.Status.Message = ""
+		if err != nil {
+			pc.Status.Phase = networkv1.PacketCaptureFailed
+			pc.Status.Message = err.Error()
+		}
+		pc.Status.Frames = res.Frames
+		pc.Status.Bytes = res.Bytes
+		pc.Status.Truncated = res.Truncated
+		pc.Status.FinishedAt = &now
+	})
+}
+
+// remove stops the capture of the removed PacketCapture and removes its pcap file. The agents of the other nodes
+// don't have the file.
+func (h *Handler) remove(name string) error {
+	h.mutex.Lock()
+	if cancel, ok := h.running[name]; ok {
+		cancel()
+	}
+	h.mutex.Unlock()
+
+	if h.captureDir == "" {
+		return nil
+	}
+	if err := os.Remove(h.pcapFile(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove the pcap file of packet capture %s failed, error: %w", name, err)
+	}
+
+	return nil
+}
+
+func (h *Handler) isRunning(name string) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	_, ok := h.running[name]
+	return ok
+}
+
+func (h *Handler) pcapFile(name string) string {
+	return filepath.Join(h.captureDir, name+".pcap")
+}
+
+// captureLink returns the uplink of the cluster network, or the interface of the spec after making sure it's a port
+// of the Linux bridge of the cluster network, so that a capture can't be pointed at any link of the node
+func captureLink(pc *networkv1.PacketCapture) (string, error) {
+	v, err := vlan.LookupVlan(pc.Spec.ClusterNetwork)
+	if err != nil {
+		return "", fmt.Errorf("cluster network %s is not set up on node %s, error: %w", pc.Spec.ClusterNetwork,
+			pc.Spec.NodeName, err)
+	}
+	if pc.Spec.Interface == "" {
+		return v.Uplink().Attrs().Name, nil
+	}
+
+	if v.Bridge() == nil {
+		return "", fmt.Errorf("cluster network %s has no Linux bridge, only its uplink can be captured",
+			pc.Spec.ClusterNetwork)
+	}
+	l, err := network.Handle().LinkByName(pc.Spec.Interface)
+	if err != nil {
+		return "", network.Classify(err)
+	}
+	if l.Attrs().MasterIndex != v.Bridge().Index {
+		return "", fmt.Errorf("%s is not a port of bridge %s", pc.Spec.Interface, v.Bridge().Name)
+	}
+
+	return pc.Spec.Interface, nil
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
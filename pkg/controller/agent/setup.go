@@ -3,8 +3,12 @@ package agent
 import (
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	"github.com/harvester/harvester-network-controller/pkg/controller/agent/clusternetwork"
+	"github.com/harvester/harvester-network-controller/pkg/controller/agent/cncleanup"
 	"github.com/harvester/harvester-network-controller/pkg/controller/agent/hostnetworkconfig"
 	"github.com/harvester/harvester-network-controller/pkg/controller/agent/linkmonitor"
+	"github.com/harvester/harvester-network-controller/pkg/controller/agent/nodecondition"
+	"github.com/harvester/harvester-network-controller/pkg/controller/agent/nodestate"
+	"github.com/harvester/harvester-network-controller/pkg/controller/agent/packetcapture"
 	"github.com/harvester/harvester-network-controller/pkg/controller/agent/vlanconfig"
 )
 
@@ -12,5 +16,13 @@ var RegisterFuncList = []config.RegisterFunc{
 	vlanconfig.Register,
 	linkmonitor.Register,
 	clusternetwork.Register,
+	cncleanup.Register,
 	hostnetworkconfig.Register,
+	nodecondition.Register,
+	nodestate.Register,
+	packetcapture.Register,
 }
+
+// Fallback enforces the network cached on the node if the controllers don't converge it in time, e.g. the API server
+// is unreachable at startup
+var Fallback config.RegisterFunc = vlanconfig.EnforceCachedState
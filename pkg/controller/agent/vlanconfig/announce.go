@@ -0,0 +1,63 @@
+package vlanconfig
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/sirupsen/logrus"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// announceStations sends the RARP announcements of the MAC addresses of the VMs out of the uplink once their traffic
+// has moved to another NIC or fabric, or the uplink has been reconfigured, so that the switches don't keep sending the
+// frames of the VMs to the previous port until the entries age out. It's best effort, the switches relearn the MAC
+// addresses from the next frames of the VMs anyway.
+func (h Handler) announceStations(s *setupState) {
+	cnName := s.vc.Spec.ClusterNetwork
+	vs, err := utils.GetVlanStatus(h.vsCache, cnName, h.nodeName)
+	// nothing has been learned before the first setup
+	if apierrors.IsNotFound(err) {
+		return
+	} else if err != nil {
+		logrus.Warnf("skip announcing the VMs of cluster network %s, error: %s", cnName, err.Error())
+		return
+	}
+	reason := uplinkMoved(vs, s)
+	if reason == "" {
+		return
+	}
+
+	stations, err := h.links.Stations(s.v)
+	if err != nil {
+		logrus.Warnf("skip announcing the VMs of cluster network %s, error: %s", cnName, err.Error())
+		return
+	}
+	if len(stations) == 0 {
+		return
+	}
+
+	name := s.uplink.Attrs().Name
+	logrus.Infof("announce %d MAC address(es) of cluster network %s out of %s as %s", len(stations), cnName, name, reason)
+	// the rounds are spread over a while, the setup doesn't wait for them
+	go func() {
+		if err := h.links.Announce(name, stations); err != nil {
+			logrus.Warnf("failed to announce the VMs of cluster network %s, error: %s", cnName, err.Error())
+		}
+	}()
+}
+
+// uplinkMoved returns why the traffic of the VMs leaves the node through another port than the vlanstatus recorded,
+// empty if it doesn't
+func uplinkMoved(vs *networkv1.VlanStatus, s *setupState) string {
+	switch {
+	case vs.Status.ActiveFabric != s.activeFabric:
+		return "the uplink fails over from fabric " + string(vs.Status.ActiveFabric) + " to " + string(s.activeFabric)
+	case vs.Status.ActiveNIC != "" && s.activeNIC != "" && vs.Status.ActiveNIC != s.activeNIC:
+		return "the active NIC changes from " + vs.Status.ActiveNIC + " to " + s.activeNIC
+	case s.membershipChanged:
+		return "the uplink is reconfigured"
+	}
+
+	return ""
+}
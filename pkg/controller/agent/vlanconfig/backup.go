@@ -0,0 +1,23 @@
+package vlanconfig
+
+import (
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// ensureActiveNIC keeps the traffic of the uplink bond of fabric A off the backup NICs while any other NIC has
+// carrier, and returns the active NIC if the bond is in the active-backup mode
+func ensureActiveNIC(vc *networkv1.VlanConfig) (string, error) {
+	opts := vc.Spec.Uplink.BondOptions
+	if opts != nil && opts.Mode != "" && opts.Mode != networkv1.BondMoDeActiveBackup {
+		return "", nil
+	}
+
+	name := vc.Spec.ClusterNetwork + utils.BondSuffix
+	if vc.Spec.Uplink.SharedBond != nil {
+		name = vc.Spec.Uplink.SharedBond.Name
+	}
+
+	return iface.EnsureActiveSlave(name, vc.Spec.Uplink.BackupNICs)
+}
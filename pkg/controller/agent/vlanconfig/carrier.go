@@ -0,0 +1,43 @@
+package vlanconfig
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func carrierSettleTime(vc *networkv1.VlanConfig) time.Duration {
+	return time.Duration(vc.Spec.Uplink.CarrierSettleSeconds) * time.Second
+}
+
+// settleCarrier records since when the uplink keeps carrier and returns how long it still has to keep carrier before
+// the vlanstatus turns ready, 0 if it has settled. The switch port may still be in STP learning or LACP negotiation
+// right after the uplink gains carrier.
+func settleCarrier(vs *networkv1.VlanStatus, settle time.Duration, hasCarrier bool, now time.Time) time.Duration {
+	if settle == 0 {
+		vs.Status.CarrierUpSince = nil
+		return 0
+	}
+
+	if !hasCarrier {
+		// check again later, the uplink gaining carrier doesn't always trigger a reconcile
+		vs.Status.CarrierUpSince = nil
+		return settle
+	}
+	if vs.Status.CarrierUpSince == nil {
+		vs.Status.CarrierUpSince = &metav1.Time{Time: now}
+	}
+
+	return max(0, settle-now.Sub(vs.Status.CarrierUpSince.Time))
+}
+
+func settleMessage(vs *networkv1.VlanStatus, settle time.Duration) string {
+	if vs.Status.CarrierUpSince == nil {
+		return "waiting for the uplink to gain carrier"
+	}
+
+	return fmt.Sprintf("waiting for the carrier of the uplink to settle for %s", settle)
+}
@@ -0,0 +1,83 @@
+package vlanconfig
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// the deferred teardown is rechecked in the period besides the rate limited retries of the failed vlanconfig, whose
+// backoff grows too long for the VMs migrated away in the meantime
+const teardownRecheckPeriod = 10 * time.Second
+
+// teardownBlockedError defers the teardown of the bridge while its consumers remain, the VMs would lose their network
+// otherwise
+type teardownBlockedError struct {
+	consumers *utils.BridgeConsumers
+}
+
+func (e *teardownBlockedError) Error() string {
+	return "the bridge is still used by " + e.consumers.String()
+}
+
+// isTeardownForced returns true if the vlanconfig of the vlanstatus is annotated to tear down the bridge regardless of
+// its consumers, e.g. the ports left by a crashed VM which are never removed
+func (h Handler) isTeardownForced(vs *networkv1.VlanStatus) (bool, error) {
+	vc, err := h.vcCache.Get(vs.Status.VlanConfig)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return vc.Annotations[utils.KeyForceTeardown] == utils.ValueTrue, nil
+}
+
+// checkConsumers fails the teardown if the VMs or the pods on this node are still attached to the bridge, and rechecks
+// it later. The NADs of the ports are taken from the cache to tell the administrator which networks are affected.
+func (h Handler) checkConsumers(s *teardownState) error {
+	users, err := h.links.VlanUsers(s.v)
+	if err != nil {
+		return err
+	}
+	nads, err := utils.NewNadGetter(h.nadCache).ListNadsOnClusterNetwork(s.vs.Status.ClusterNetwork)
+	if err != nil {
+		return err
+	}
+	var pvid uint16 = utils.DefaultVlanID
+	if s.v.Bridge() != nil {
+		pvid = s.v.Bridge().DefaultPVID()
+	}
+	consumers, err := utils.NewBridgeConsumers(nads, users, pvid)
+	if err != nil {
+		return err
+	}
+	if consumers.IsEmpty() {
+		return nil
+	}
+
+	if s.forced {
+		logrus.Warnf("tear down the bridge of cluster network %s which is still used by %s as forced by vlanconfig %s",
+			s.vs.Status.ClusterNetwork, consumers, s.vs.Status.VlanConfig)
+		return nil
+	}
+	logrus.Infof("defer the teardown of the bridge of cluster network %s which is still used by %s",
+		s.vs.Status.ClusterNetwork, consumers)
+	h.vcController.EnqueueAfter(s.vs.Status.VlanConfig, teardownRecheckPeriod)
+
+	return &teardownBlockedError{consumers: consumers}
+}
+
+// teardownBlockedMessage returns the consumers the teardown waits for, ok is false if the teardown is not blocked
+func teardownBlockedMessage(teardownErr error) (msg string, ok bool) {
+	var blocked *teardownBlockedError
+	if !errors.As(teardownErr, &blocked) {
+		return "", false
+	}
+	return blocked.consumers.String(), true
+}
@@ -2,10 +2,13 @@ package vlanconfig
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -14,27 +17,43 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 
+	"github.com/harvester/harvester-network-controller/pkg/agentapi"
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/hooks"
+	"github.com/harvester/harvester-network-controller/pkg/localarea"
+	"github.com/harvester/harvester-network-controller/pkg/matcher"
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
+	"github.com/harvester/harvester-network-controller/pkg/network"
 	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/kernel"
+	"github.com/harvester/harvester-network-controller/pkg/network/monitor"
 	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
 
 const (
 	ControllerName = "harvester-network-vlanconfig-controller"
+
+	fabricMonitorKey = "fabric"
+	nicMonitorKey    = "nic"
+	bridgeMonitorKey = "bridge"
+
+	// the lastReconciledAt of an unchanged vlanstatus is refreshed if it's older than the interval
+	reconcileStampInterval = 5 * time.Minute
 )
 
 type Handler struct {
 	nodeName                    string
 	nodeClient                  ctlcorev1.NodeClient
 	nodeCache                   ctlcorev1.NodeCache
-	nadCache                    ctlcniv1.NetworkAttachmentDefinitionCache
 	vcClient                    ctlnetworkv1.VlanConfigClient
 	vcCache                     ctlnetworkv1.VlanConfigCache
+	vcController                ctlnetworkv1.VlanConfigController
 	vsClient                    ctlnetworkv1.VlanStatusClient
 	vsCache                     ctlnetworkv1.VlanStatusCache
 	cnClient                    ctlnetworkv1.ClusterNetworkClient
@@ -42,6 +61,21 @@ type Handler struct {
 	cnController                ctlnetworkv1.ClusterNetworkController
 	hostNetworkConfigCache      ctlnetworkv1.HostNetworkConfigCache
 	hostNetworkConfigController ctlnetworkv1.HostNetworkConfigController
+	nicClaimCache               ctlnetworkv1.NetworkInterfaceClaimCache
+	dynamicClient               dynamic.Interface
+	links                       linkOps
+	hooks                       *hooks.Runner
+	locks                       *utils.KeyMutex
+	converged                   *utils.Gate
+	statusWriter                *utils.StatusWriter
+	localAreas                  *localarea.Sources
+	nadCache                    ctlcniv1.NetworkAttachmentDefinitionCache
+	agentVersion                string
+	// the file the desired network of the node is cached in, see utils.NodeState
+	stateFile string
+	// the cluster networks whose links changed, to be audited for drift
+	driftEvents chan string
+	topology    *topologyLabels
 }
 
 func Register(ctx context.Context, management *config.Management) error {
@@ -51,14 +85,15 @@ func Register(ctx context.Context, management *config.Management) error {
 	nodes := management.CoreFactory.Core().V1().Node()
 	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
 	hns := management.HarvesterNetworkFactory.Network().V1beta1().HostNetworkConfig()
+	claims := management.HarvesterNetworkFactory.Network().V1beta1().NetworkInterfaceClaim()
 
 	handler := &Handler{
 		nodeName:                    management.Options.NodeName,
 		nodeClient:                  nodes,
 		nodeCache:                   nodes.Cache(),
-		nadCache:                    nads.Cache(),
 		vcClient:                    vcs,
 		vcCache:                     vcs.Cache(),
+		vcController:                vcs,
 		vsClient:                    vss,
 		vsCache:                     vss.Cache(),
 		cnClient:                    cns,
@@ -66,14 +101,56 @@ func Register(ctx context.Context, management *config.Management) error {
 		cnController:                cns,
 		hostNetworkConfigCache:      hns.Cache(),
 		hostNetworkConfigController: hns,
+		nicClaimCache:               claims.Cache(),
+		dynamicClient:               management.DynamicClient,
+		links:                       netlinkOps{},
+		hooks:                       hooks.NewRunner(hooks.SettingLoader(management.DynamicClient, hooks.SettingName)),
+		locks:                       management.Locks,
+		converged:                   management.Converged,
+		statusWriter:                management.StatusWriter,
+		localAreas:                  management.LocalAreas,
+		nadCache:                    nads.Cache(),
+		agentVersion:                management.Options.Version,
+		stateFile:                   management.Options.StateFile,
+		driftEvents:                 make(chan string, driftEventsSize),
+		topology:                    &topologyLabels{},
 	}
 
 	if err := handler.initialize(); err != nil {
 		return fmt.Errorf("initialize error: %w", err)
 	}
 
+	// watch the carrier of the fabric bonds to fail over between fabric A and fabric B,
+	// the speed of the NICs to report the degraded uplinks, and the removal of the bridges and bonds to heal them
+	uplinkMonitor := monitor.NewMonitor(&monitor.Handler{
+		NewLink: handler.onUplinkLinkChange,
+		DelLink: handler.onUplinkLinkDelete,
+	})
+	uplinkMonitor.AddPattern(fabricMonitorKey, monitor.NewPattern(iface.TypeBond,
+		"("+utils.BondSuffix+"|"+utils.FabricBBondSuffix+")$"))
+	uplinkMonitor.AddPattern(nicMonitorKey, monitor.NewPattern(iface.TypeDevice, ""))
+	uplinkMonitor.AddPattern(bridgeMonitorKey, monitor.NewPattern(iface.TypeBridge, utils.BridgeSuffix+"$"))
+	go uplinkMonitor.Start(ctx)
+
 	vcs.OnChange(ctx, ControllerName, handler.OnChange)
 	vcs.OnRemove(ctx, ControllerName, handler.OnRemove)
+	claims.OnChange(ctx, ControllerName, handler.onNICClaimChange)
+	cns.OnChange(ctx, ControllerName, handler.onClusterNetworkChange)
+	nodes.OnChange(ctx, ControllerName, handler.onNodeChange)
+	agentapi.RegisterResync("vlanconfigs", handler.resync)
+
+	go handler.converge(ctx, management.Converged, vcs.Informer().HasSynced, vss.Informer().HasSynced,
+		cns.Informer().HasSynced, nads.Informer().HasSynced)
+	go handler.auditDrift(ctx)
+	if management.Options.UplinkUtilization {
+		go handler.sampleUtilization(ctx)
+	}
+	if handler.stateFile != "" {
+		go func() {
+			management.Converged.Wait()
+			handler.saveStates(ctx)
+		}()
+	}
 
 	return nil
 }
@@ -82,8 +159,14 @@ func (h Handler) OnChange(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanCo
 	if vc == nil || vc.DeletionTimestamp != nil {
 		return nil, nil
 	}
+	h.converged.Wait()
+	defer metrics.ObserveReconcile(ControllerName)()
 	logrus.Infof("vlan config %s has been changed, spec: %+v", vc.Name, vc.Spec)
 
+	if err := h.loadExcludedInterfaces(); err != nil {
+		return nil, err
+	}
+
 	isMatched, err := h.MatchNode(vc)
 	if err != nil {
 		return nil, err
@@ -97,6 +180,11 @@ func (h Handler) OnChange(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanCo
 	// vlanconfig can be migrated from one cn to another, the vs helps to clean the bridge on source cn
 	if (!isMatched && vs != nil) || (isMatched && vs != nil && !matchClusterNetwork(vc, vs)) {
 		logrus.Infof("the staled vs %s on cn %s is to be removed", vs.Name, vs.Status.ClusterNetwork)
+		if !matchClusterNetwork(vc, vs) {
+			if vs, err = h.setMigrating(vs, vc.Spec.ClusterNetwork); err != nil {
+				return nil, err
+			}
+		}
 		if err := h.removeVLAN(vs); err != nil {
 			return nil, err
 		}
@@ -107,6 +195,8 @@ func (h Handler) OnChange(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanCo
 		if err := h.setupVLAN(vc); err != nil {
 			return nil, err
 		}
+	} else if err := h.wakeUpOverlayPeers(vc); err != nil {
+		return nil, err
 	}
 
 	return vc, nil
@@ -116,9 +206,15 @@ func (h Handler) OnRemove(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanCo
 	if vc == nil {
 		return nil, nil
 	}
+	h.converged.Wait()
+	defer metrics.ObserveReconcile(ControllerName)()
 
 	logrus.Infof("vlan config %s has been removed", vc.Name)
 
+	if err := h.loadExcludedInterfaces(); err != nil {
+		return nil, err
+	}
+
 	vs, err := h.getVlanStatus(vc)
 	if err != nil {
 		return nil, err
@@ -130,6 +226,10 @@ func (h Handler) OnRemove(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanCo
 		}
 	}
 
+	if err := h.wakeUpOverlayPeers(vc); err != nil {
+		return nil, err
+	}
+
 	return vc, nil
 }
 
@@ -140,35 +240,85 @@ func (h Handler) initialize() error {
 	return nil
 }
 
-// MatchNode will also return the executed vlanconfig with the same clusterNetwork on this node if existing
+// resync enqueues all the vlanconfigs on request of the agent API, the ones not matching this node are skipped by
+// OnChange
+func (h Handler) resync() (int, error) {
+	vcs, err := h.vcCache.List(labels.Everything())
+	if err != nil {
+		return 0, err
+	}
+	for _, vc := range vcs {
+		h.vcController.Enqueue(vc.Name)
+	}
+
+	return len(vcs), nil
+}
+
+// MatchNode returns true if the vlanconfig matches this node and takes effect on it, i.e. it wins over the other
+// vlanconfigs of the same cluster network matching this node
 func (h Handler) MatchNode(vc *networkv1.VlanConfig) (bool, error) {
-	if vc.Annotations[utils.KeyMatchedNodes] == "" {
-		return false, nil
+	isMatched, err := matcher.IsMatched(vc, h.nodeName)
+	if err != nil || !isMatched {
+		return false, err
 	}
 
-	var matchedNodes []string
-	if err := json.Unmarshal([]byte(vc.Annotations[utils.KeyMatchedNodes]), &matchedNodes); err != nil {
+	winner, err := h.resolve(vc)
+	if err != nil {
 		return false, err
 	}
+	if winner != nil && winner.Name != vc.Name {
+		logrus.Warnf("vc %s overlaps with vc %s on this node %s, the latter takes effect", vc.Name, winner.Name, h.nodeName)
+		return false, nil
+	}
+
+	logrus.Infof("vc %v matches this node: %s", vc.Name, h.nodeName)
+	return true, nil
+}
 
-	for _, n := range matchedNodes {
-		if n == h.nodeName {
-			logrus.Infof("vc %v matchedNodes: %+v, include this node: %s", vc.Name, matchedNodes, h.nodeName)
-			return true, nil
+// resolve returns the vlanconfig taking effect on this node among the vlanconfigs of the same cluster network
+func (h Handler) resolve(vc *networkv1.VlanConfig) (*networkv1.VlanConfig, error) {
+	vcs, err := h.vcCache.GetByIndex(utils.VlanConfigByClusterNetworkIndex, vc.Spec.ClusterNetwork)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []*networkv1.VlanConfig{vc}
+	for _, other := range vcs {
+		if other.Name == vc.Name || other.Spec.ClusterNetwork != vc.Spec.ClusterNetwork {
+			continue
 		}
+		if isMatched, err := matcher.IsMatched(other, h.nodeName); err != nil {
+			return nil, err
+		} else if isMatched {
+			candidates = append(candidates, other)
+		}
+	}
+	if len(candidates) == 1 {
+		return vc, nil
 	}
 
-	return false, nil
+	active := ""
+	vs, err := utils.GetVlanStatus(h.vsCache, vc.Spec.ClusterNetwork, h.nodeName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	} else if err == nil {
+		active = vs.Status.VlanConfig
+	}
+
+	return matcher.Resolve(candidates, active), nil
 }
 
 func (h Handler) getVlanStatus(vc *networkv1.VlanConfig) (*networkv1.VlanStatus, error) {
-	vss, err := h.vsCache.List(labels.Set(map[string]string{
-		utils.KeyVlanConfigLabel: vc.Name,
-		utils.KeyNodeLabel:       h.nodeName,
-	}).AsSelector())
+	vssOnNode, err := h.vsCache.GetByIndex(utils.VlanStatusByNodeIndex, h.nodeName)
 	if err != nil {
 		return nil, err
 	}
+	vss := make([]*networkv1.VlanStatus, 0, 1)
+	for _, vs := range vssOnNode {
+		if vs.Status.VlanConfig == vc.Name {
+			vss = append(vss, vs)
+		}
+	}
 
 	switch len(vss) {
 	case 0:
@@ -185,34 +335,36 @@ func matchClusterNetwork(vc *networkv1.VlanConfig, vs *networkv1.VlanStatus) boo
 	return vc.Spec.ClusterNetwork == vs.Status.ClusterNetwork
 }
 
-// only sets up uplink & vlan bridge, vids are added by clusternetwork controller
+// setupVLAN sets up the uplink and the bridge with the vids of the cluster network, the clusternetwork controller woken
+// up afterwards removes the stale vids and applies the other settings of the cluster network
 func (h Handler) setupVLAN(vc *networkv1.VlanConfig) error {
-	var v *vlan.Vlan
-	var setupErr error
-	var uplink *iface.Link
-
-	// construct uplink
-	uplink, setupErr = setUplink(vc)
-	if setupErr != nil {
-		goto updateStatus
+	vc, err := h.effectiveVlanConfig(vc)
+	if err != nil {
+		return err
 	}
-	// set up VLAN bridge
-	v = vlan.NewVlan(vc.Spec.ClusterNetwork)
-	if setupErr = v.Setup(uplink); setupErr != nil {
-		goto updateStatus
+	// the parallel workers must not change the links of the cluster network or the shared bond at the same time
+	defer h.locks.Lock(utils.LockKeyOfClusterNetwork(vc.Spec.ClusterNetwork))()
+	if sharedBond := vc.Spec.Uplink.SharedBond; sharedBond != nil {
+		defer h.locks.Lock(utils.LockKeyOfLink(sharedBond.Name))()
 	}
 
-updateStatus:
+	state := &setupState{vc: vc}
+	steps, setupErr := utils.RunSteps(h.setupSteps(state), network.IsRetryable)
+
 	// Update status and still return setup error if not nil
-	if err := h.updateStatus(vc, setupErr); err != nil {
-		return fmt.Errorf("update status into vlanstatus %s failed, error: %w, setup error: %v",
-			h.statusName(vc.Spec.ClusterNetwork), err, setupErr)
+	settleWait, err := h.updateStatus(state, steps, setupErr)
+	if err != nil {
+		return fmt.Errorf("update status into vlanstatus of cluster network %s failed, error: %w, setup error: %v",
+			vc.Spec.ClusterNetwork, err, setupErr)
 	}
 	if setupErr != nil {
 		return fmt.Errorf("set up VLAN failed, vlanconfig: %s, node: %s, error: %w", vc.Name, h.nodeName, setupErr)
 	}
-	// update node labels for pod scheduling
-	if err := h.addNodeLabel(vc); err != nil {
+	// update node labels for pod scheduling once the carrier of the uplink has settled
+	if settleWait > 0 {
+		logrus.Infof("wait %s for the carrier of the uplink of vlanconfig %s to settle", settleWait, vc.Name)
+		h.vcController.EnqueueAfter(vc.Name, settleWait)
+	} else if err := h.addNodeLabel(vc); err != nil {
 		return fmt.Errorf("add node label to node %s for vlanconfig %s failed, error: %w", h.nodeName, vc.Name, err)
 	}
 
@@ -227,7 +379,7 @@ updateStatus:
 	return nil
 }
 
-// after clusternetwork bridge is set up, wake up cluster network to add vids
+// after clusternetwork bridge is set up, wake up cluster network to remove the stale vids
 func (h Handler) wakeUpClusterNetwork(vc *networkv1.VlanConfig) error {
 	_, err := h.cnCache.Get(vc.Spec.ClusterNetwork)
 	if err == nil {
@@ -262,30 +414,33 @@ func (h Handler) reconcileHostNetwork(clusterNetwork string) error {
 
 // remove clusternetwork bridge will remove the vids automatically
 func (h Handler) removeVLAN(vs *networkv1.VlanStatus) error {
-	var v *vlan.Vlan
-	var teardownErr error
+	defer h.locks.Lock(utils.LockKeyOfClusterNetwork(vs.Status.ClusterNetwork))()
 
-	v, teardownErr = vlan.GetVlan(vs.Status.ClusterNetwork)
-	// We take it granted that `LinkNotFound` means the VLAN has been torn down.
-	if teardownErr != nil {
-		// ignore the LinkNotFound error
-		if errors.As(teardownErr, &netlink.LinkNotFoundError{}) {
-			teardownErr = nil
-		}
-		goto updateStatus
+	vs, err := h.setDeleting(vs)
+	if err != nil {
+		return err
 	}
-	if teardownErr = v.Teardown(); teardownErr != nil {
-		goto updateStatus
+
+	// the bridge may be still used by another vlanconfig of the same cluster network, e.g. the node is moved from
+	// one vlanconfig to another by changing the node selectors
+	users, err := h.bridgeUsers(vs)
+	if err != nil {
+		return err
 	}
 
-updateStatus:
-	if err := h.removeNodeLabel(vs); err != nil {
+	forced, err := h.isTeardownForced(vs)
+	if err != nil {
 		return err
 	}
-	if err := h.deleteStatus(vs, teardownErr); err != nil {
-		return fmt.Errorf("update status into vlanstatus %s failed, error: %w, teardown error: %v",
-			h.statusName(vs.Status.ClusterNetwork), err, teardownErr)
+
+	steps, teardownErr := utils.RunSteps(h.teardownSteps(&teardownState{vs: vs, users: users, forced: forced}),
+		network.IsRetryable)
+	if len(users) == 0 {
+		if err := h.removeNodeLabel(vs); err != nil {
+			return err
+		}
 	}
+	h.deleteStatus(vs, steps, teardownErr)
 	if teardownErr != nil {
 		return fmt.Errorf("tear down VLAN failed, vlanconfig: %s, node: %s, error: %w", vs.Status.VlanConfig, h.nodeName, teardownErr)
 	}
@@ -298,10 +453,180 @@ updateStatus:
 	return nil
 }
 
-func setUplink(vc *networkv1.VlanConfig) (*iface.Link, error) {
+// bridgeUsers returns the other vlanconfigs of the same cluster network which match this node
+func (h Handler) bridgeUsers(vs *networkv1.VlanStatus) ([]*networkv1.VlanConfig, error) {
+	vcs, err := h.vcCache.GetByIndex(utils.VlanConfigByClusterNetworkIndex, vs.Status.ClusterNetwork)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*networkv1.VlanConfig, 0)
+	for _, vc := range vcs {
+		if vc.Name == vs.Status.VlanConfig || vc.DeletionTimestamp != nil || vc.Spec.ClusterNetwork != vs.Status.ClusterNetwork {
+			continue
+		}
+		isMatched, err := h.MatchNode(vc)
+		if err != nil {
+			return nil, err
+		}
+		if isMatched {
+			users = append(users, vc)
+		}
+	}
+
+	return users, nil
+}
+
+// releaseVLAN only removes the contribution of this vlanconfig, i.e. the NICs not used by the other users,
+// and leaves the bridge and the uplink to the users, which are reconciled to take them over
+func (h Handler) releaseVLAN(s *teardownState) error {
+	keep := make([]string, 0)
+	names := make([]string, 0, len(s.users))
+	for _, vc := range s.users {
+		effective, err := h.effectiveVlanConfig(vc)
+		if err != nil {
+			return err
+		}
+		keep = append(keep, uplinkNICs(effective)...)
+		names = append(names, vc.Name)
+	}
+	logrus.Infof("skip tearing down the uplink of cluster network %s which is still used by vlanconfig(s) %v",
+		s.vs.Status.ClusterNetwork, names)
+
+	if err := h.links.ReleaseUplinkSlaves(s.v, keep); err != nil {
+		return err
+	}
+	for _, vc := range s.users {
+		h.vcController.Enqueue(vc.Name)
+	}
+
+	return nil
+}
+
+// requireKernel fails the setup with the kernel prerequisites of the vlanconfig the node lacks
+func requireKernel(vc *networkv1.VlanConfig) error {
+	names := []string{kernel.ModuleBonding, kernel.ModuleBridge, kernel.OptionBridgeVlanFiltering}
+	if vlanUplinkName(vc) != "" {
+		names = append(names, kernel.Module8021Q)
+	}
+	return kernel.Require(names...)
+}
+
+// vlanUplinkName returns the VLAN sub-interface of the bond the bridge is attached to, empty if the bridge is attached
+// to the bond directly
+func vlanUplinkName(vc *networkv1.VlanConfig) string {
+	if sharedBond := vc.Spec.Uplink.SharedBond; sharedBond != nil {
+		return utils.GetClusterNetworkBrVlanDevice(sharedBond.Name, sharedBond.VID)
+	}
+	if vid := vc.Spec.Uplink.ServiceVLAN; vid != 0 {
+		return utils.GetClusterNetworkBrVlanDevice(vc.Spec.ClusterNetwork+utils.BondSuffix, vid)
+	}
+	return ""
+}
+
+// setUplink sets up the bond of fabric A, and the bond of fabric B if configured
+func setUplink(vc *networkv1.VlanConfig) (uplink, standby *iface.Link, err error) {
+	sharedBond := vc.Spec.Uplink.SharedBond
+	if err := vlan.RemoveStaleUplink(vc.Spec.ClusterNetwork, vlanUplinkName(vc)); err != nil {
+		return nil, nil, fmt.Errorf("remove stale uplink of cluster network %s failed, error: %w", vc.Spec.ClusterNetwork, err)
+	}
+
+	name := vc.Spec.ClusterNetwork + utils.BondSuffix
+	if sharedBond != nil {
+		name = sharedBond.Name
+	}
+	uplink, err = ensureUplinkBond(newBond(vc, name), vc.Spec.Uplink.NICs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := uplink.EnsureQdisc(queueConfig(vc)); err != nil {
+		return nil, nil, err
+	}
+	if err := tuneNICs(vc); err != nil {
+		return nil, nil, err
+	}
+
+	// the bridge of a cluster network sharing the bond is attached to the VLAN sub-interface of the bond
+	if sharedBond != nil {
+		uplink, err = uplink.EnsureVlanSubInterface(sharedBond.VID)
+		return uplink, nil, err
+	}
+	if vid := vc.Spec.Uplink.ServiceVLAN; vid != 0 {
+		uplink, err = uplink.EnsureVlanSubInterface(vid)
+		return uplink, nil, err
+	}
+
+	fabricBName := utils.GenerateFabricBBondName(vc.Spec.ClusterNetwork)
+	if vc.Spec.Uplink.FabricB == nil {
+		return uplink, nil, removeLinkIfExists(fabricBName)
+	}
+	standby, err = ensureUplinkBond(newBond(vc, fabricBName), vc.Spec.Uplink.FabricB.NICs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("set up the uplink of fabric B failed, error: %w", err)
+	}
+	if err := standby.EnsureQdisc(queueConfig(vc)); err != nil {
+		return nil, nil, err
+	}
+
+	return uplink, standby, nil
+}
+
+// tuneNICs applies the ring buffer and interrupt coalescing tuning to the NICs of the uplink, the tuning which the
+// driver doesn't support is skipped
+func tuneNICs(vc *networkv1.VlanConfig) error {
+	for _, t := range vc.Spec.Uplink.NICTuning {
+		if t.Ring != nil {
+			if err := iface.EnsureRing(t.Name, &iface.RingConfig{RX: t.Ring.RX, TX: t.Ring.TX}); errors.Is(err, network.ErrUnsupportedByDriver) {
+				logrus.Warnf("skip the ring tuning of %s, error: %s", t.Name, err.Error())
+			} else if err != nil {
+				return err
+			}
+		}
+		if t.Coalesce != nil {
+			if err := iface.EnsureCoalesce(t.Name, &iface.CoalesceConfig{
+				AdaptiveRX: t.Coalesce.AdaptiveRX,
+				AdaptiveTX: t.Coalesce.AdaptiveTX,
+				RXUsecs:    t.Coalesce.RXUsecs,
+				RXFrames:   t.Coalesce.RXFrames,
+				TXUsecs:    t.Coalesce.TXUsecs,
+				TXFrames:   t.Coalesce.TXFrames,
+			}); errors.Is(err, network.ErrUnsupportedByDriver) {
+				logrus.Warnf("skip the coalesce tuning of %s, error: %s", t.Name, err.Error())
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func queueConfig(vc *networkv1.VlanConfig) *iface.QueueConfig {
+	opts := vc.Spec.Uplink.QueueOptions
+	if opts == nil || opts.Qdisc == "" {
+		return nil
+	}
+
+	return &iface.QueueConfig{
+		Qdisc:         string(opts.Qdisc),
+		PerQueueQdisc: string(opts.PerQueueQdisc),
+		Limit:         opts.Limit,
+	}
+}
+
+// the root qdisc of the bridge follows the uplink if it is required
+func bridgeQueueConfig(vc *networkv1.VlanConfig) *iface.QueueConfig {
+	if vc.Spec.Uplink.QueueOptions == nil || !vc.Spec.Uplink.QueueOptions.ApplyToBridge {
+		return nil
+	}
+
+	return queueConfig(vc)
+}
+
+func newBond(vc *networkv1.VlanConfig, name string) *netlink.Bond {
 	// set link attributes
 	linkAttrs := netlink.NewLinkAttrs()
-	linkAttrs.Name = vc.Spec.ClusterNetwork + utils.BondSuffix
+	linkAttrs.Name = name
 	if vc.Spec.Uplink.LinkAttrs != nil {
 		linkAttrs.MTU = vc.Spec.Uplink.LinkAttrs.MTU
 		linkAttrs.TxQLen = vc.Spec.Uplink.LinkAttrs.TxQLen
@@ -309,6 +634,10 @@ func setUplink(vc *networkv1.VlanConfig) (*iface.Link, error) {
 			linkAttrs.HardwareAddr = vc.Spec.Uplink.LinkAttrs.HardwareAddr
 		}
 	}
+	if vc.Spec.Uplink.QueueOptions != nil {
+		linkAttrs.NumTxQueues = vc.Spec.Uplink.QueueOptions.NumTxQueues
+		linkAttrs.NumRxQueues = vc.Spec.Uplink.QueueOptions.NumRxQueues
+	}
 	// Note: do not use &netlink.Bond{}
 	bond := netlink.NewLinkBond(linkAttrs)
 	// set bonding mode
@@ -325,28 +654,189 @@ func setUplink(vc *networkv1.VlanConfig) (*iface.Link, error) {
 	}
 
 	bond.Miimon = miimon
-	b := iface.NewBond(bond, vc.Spec.Uplink.NICs)
+
+	// the delays default to 0 like the kernel does, so that they're reset once removed from the bond options
+	bond.UpDelay, bond.DownDelay = 0, 0
+	if vc.Spec.Uplink.BondOptions != nil {
+		bond.UpDelay = vc.Spec.Uplink.BondOptions.UpDelay
+		bond.DownDelay = vc.Spec.Uplink.BondOptions.DownDelay
+	}
+
+	// the hash policy is reset to the kernel default once removed from the bond options, and left unset in the
+	// modes ignoring it
+	if utils.IsHashingBondMode(utils.BondModeOf(vc.Spec.Uplink.BondOptions)) {
+		bond.XmitHashPolicy = netlink.BOND_XMIT_HASH_POLICY_LAYER2
+		if policy := vc.Spec.Uplink.BondOptions.XmitHashPolicy; policy != "" {
+			bond.XmitHashPolicy = netlink.StringToBondXmitHashPolicy(string(policy))
+		}
+	}
+	// the LACP rate is likewise reset to slow once removed, the kernel refuses it in the other modes
+	if utils.BondModeOf(vc.Spec.Uplink.BondOptions) == networkv1.BondMode8023AD {
+		bond.LacpRate = netlink.BOND_LACP_RATE_SLOW
+		if rate := vc.Spec.Uplink.BondOptions.LacpRate; rate != "" {
+			bond.LacpRate = netlink.StringToBondLacpRate(string(rate))
+		}
+	}
+	// the ARP monitoring replaces miimon, it's turned off once removed in the modes supporting it, and the empty
+	// targets clear the ones left by it
+	if utils.IsArpMonitorBondMode(utils.BondModeOf(vc.Spec.Uplink.BondOptions)) {
+		bond.ArpInterval, bond.ArpIpTargets, bond.ArpValidate = 0, []net.IP{}, netlink.BOND_ARP_VALIDATE_NONE
+		if opts := vc.Spec.Uplink.BondOptions; opts != nil && opts.ArpInterval > 0 {
+			bond.Miimon = 0
+			bond.ArpInterval = opts.ArpInterval
+			bond.ArpIpTargets = utils.ArpIPTargetsOf(opts)
+			if opts.ArpValidate != "" {
+				bond.ArpValidate = netlink.StringToBondArpValidateMap[string(opts.ArpValidate)]
+			}
+		}
+	}
+
+	return bond
+}
+
+func ensureUplinkBond(bond *netlink.Bond, nics []string) (*iface.Link, error) {
+	// adopt the bond set up by the previous agent as it is to avoid link flapping, e.g. after the agent is upgraded
+	uplink, err := vlan.AdoptUplink(bond, nics)
+	if err != nil {
+		return nil, err
+	}
+	if uplink != nil {
+		return uplink, nil
+	}
+
+	b := iface.NewBond(bond, nics)
 	if err := b.EnsureBond(); err != nil {
 		return nil, err
 	}
+	if err := vlan.StampUplink(b, vlan.UplinkFingerprint(bond, nics)); err != nil {
+		return nil, err
+	}
 
 	return &iface.Link{Link: b}, nil
 }
 
-func (h Handler) updateStatus(vc *networkv1.VlanConfig, setupErr error) error {
+func removeLinkIfExists(name string) error {
+	l, err := network.Handle().LinkByName(name)
+	if errors.Is(network.Classify(err), network.ErrLinkNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	logrus.Infof("remove the link %s which is no longer required", name)
+	link := iface.NewLink(l)
+	if err := link.SetNoMaster(); err != nil {
+		return err
+	}
+
+	return link.Remove()
+}
+
+// selectFabric returns the uplink to be attached to the bridge and detaches the other one to avoid loops.
+// Fabric A is preferred, the bridge fails over to fabric B only if fabric A has no carrier while fabric B has.
+func selectFabric(uplink, standby *iface.Link) (*iface.Link, networkv1.Fabric, error) {
+	if standby == nil {
+		return uplink, "", nil
+	}
+
+	if err := uplink.Fetch(); err != nil {
+		return nil, "", err
+	}
+	if err := standby.Fetch(); err != nil {
+		return nil, "", err
+	}
+
+	uplinkCarrier, err := iface.CarrierOf(uplink)
+	if err != nil {
+		return nil, "", err
+	}
+	standbyCarrier, err := iface.CarrierOf(standby)
+	if err != nil {
+		return nil, "", err
+	}
+	active, inactive, fabric := uplink, standby, networkv1.FabricA
+	if !uplinkCarrier && standbyCarrier {
+		active, inactive, fabric = standby, uplink, networkv1.FabricB
+	}
+
+	if err := inactive.SetNoMaster(); err != nil {
+		return nil, "", fmt.Errorf("detach %s from the bridge failed, error: %w", inactive.Attrs().Name, err)
+	}
+	if fabric == networkv1.FabricB {
+		logrus.Warnf("fabric A uplink %s has no carrier, fail over to fabric B uplink %s", uplink.Attrs().Name, standby.Attrs().Name)
+	}
+
+	return active, fabric, nil
+}
+
+func (h Handler) onUplinkLinkChange(key string, update *netlink.LinkUpdate) error {
+	switch key {
+	case nicMonitorKey:
+		return h.onNICLinkChange(key, update)
+	case bridgeMonitorKey:
+		return nil
+	default:
+		return h.onFabricLinkChange(key, update)
+	}
+}
+
+// onFabricLinkChange enqueues the vlanconfig to fail over or fail back when the carrier of a fabric bond changes
+func (h Handler) onFabricLinkChange(_ string, update *netlink.LinkUpdate) error {
+	name := update.Link.Attrs().Name
+	isFabricA := strings.HasSuffix(name, utils.BondSuffix)
+	cn := strings.TrimSuffix(strings.TrimSuffix(name, utils.BondSuffix), utils.FabricBBondSuffix)
+
+	vs, err := utils.GetVlanStatus(h.vsCache, cn, h.nodeName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// the bond of fabric A may be detached from the bridge outside the agent
+	if update.Link.Attrs().MasterIndex == 0 {
+		h.notifyDrift(cn)
+	}
+
+	// the bond fails over between its slaves
+	if bond, ok := update.Link.(*netlink.Bond); ok && isFabricA && vs.Status.ActiveNIC != "" &&
+		iface.ActiveSlaveName(bond) != vs.Status.ActiveNIC {
+		logrus.Infof("active slave of %s changes, reconcile vlanconfig %s", name, vs.Status.VlanConfig)
+		h.vcController.Enqueue(vs.Status.VlanConfig)
+		return nil
+	}
+
+	hasCarrier := update.Link.Attrs().OperState == netlink.OperUp
+	active := vs.Status.ActiveFabric
+	if (isFabricA && active == networkv1.FabricA && !hasCarrier) ||
+		(isFabricA && active == networkv1.FabricB && hasCarrier) ||
+		(!isFabricA && active == networkv1.FabricB && !hasCarrier) {
+		logrus.Infof("carrier of %s changes to %t, reconcile vlanconfig %s", name, hasCarrier, vs.Status.VlanConfig)
+		h.vcController.Enqueue(vs.Status.VlanConfig)
+	}
+
+	return nil
+}
+
+// updateStatus updates the vlanstatus and returns how long the carrier of the uplink still has to settle
+func (h Handler) updateStatus(s *setupState, steps []networkv1.SetupStep, setupErr error) (time.Duration, error) {
+	vc, activeNIC := s.vc, s.activeNIC
 	var vStatus *networkv1.VlanStatus
-	name := h.statusName(vc.Spec.ClusterNetwork)
-	vs, getErr := h.vsCache.Get(name)
+	name := utils.VlanStatusName(vc.Spec.ClusterNetwork, h.nodeName)
+	vs, getErr := utils.GetVlanStatus(h.vsCache, vc.Spec.ClusterNetwork, h.nodeName)
+	if getErr == nil {
+		name = vs.Name
+	}
 	if getErr != nil && !apierrors.IsNotFound(getErr) {
-		return fmt.Errorf("could not get vlanstatus %s, error: %w", name, getErr)
+		return 0, fmt.Errorf("could not get vlanstatus %s, error: %w", name, getErr)
 	} else if apierrors.IsNotFound(getErr) {
 		vStatus = &networkv1.VlanStatus{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: name,
 				Labels: map[string]string{
-					utils.KeyVlanConfigLabel:     vc.Name,
+					utils.KeyVlanConfigLabel:     utils.LabelValue(vc.Name),
 					utils.KeyClusterNetworkLabel: vc.Spec.ClusterNetwork,
-					utils.KeyNodeLabel:           h.nodeName,
+					utils.KeyNodeLabel:           utils.LabelValue(h.nodeName),
 				},
 				OwnerReferences: []metav1.OwnerReference{
 					{
@@ -364,63 +854,235 @@ func (h Handler) updateStatus(vc *networkv1.VlanConfig, setupErr error) error {
 
 	vStatus.Labels = map[string]string{
 		utils.KeyClusterNetworkLabel: vc.Spec.ClusterNetwork,
-		utils.KeyVlanConfigLabel:     vc.Name,
-		utils.KeyNodeLabel:           h.nodeName,
+		utils.KeyVlanConfigLabel:     utils.LabelValue(vc.Name),
+		utils.KeyNodeLabel:           utils.LabelValue(h.nodeName),
+	}
+	cn, err := h.cnCache.Get(vc.Spec.ClusterNetwork)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return 0, fmt.Errorf("could not get cluster network %s, error: %w", vc.Spec.ClusterNetwork, err)
 	}
+	utils.SetOwnershipLabels(vStatus.Labels, utils.EffectiveOwnership(cn, vc))
 	vStatus.Status.ClusterNetwork = vc.Spec.ClusterNetwork
 	vStatus.Status.VlanConfig = vc.Name
 	vStatus.Status.LinkMonitor = vc.Spec.ClusterNetwork
 	vStatus.Status.Node = h.nodeName
+	vStatus.Status.Phase = networkv1.VlanPhaseActive
+	// the deferred teardown is called off once the vlanconfig takes effect on the node again
+	if networkv1.TeardownBlocked.IsTrue(vStatus) {
+		networkv1.TeardownBlocked.SetStatusBool(vStatus, false)
+		networkv1.TeardownBlocked.Message(vStatus, "")
+	}
+	// the drift is modified back once the setup succeeds, the message keeps the drift found last
+	if setupErr == nil && networkv1.Drifted.IsTrue(vStatus) {
+		networkv1.Drifted.SetStatusBool(vStatus, false)
+		networkv1.Drifted.Reason(vStatus, driftReapplied)
+	}
+	vStatus.Status.ActiveFabric = s.activeFabric
+	vStatus.Status.ActiveNIC = activeNIC
+	vStatus.Status.OnBackupNIC = activeNIC != "" && slices.Contains(vc.Spec.Uplink.BackupNICs, activeNIC)
+	vStatus.Status.CarrierTracked = utils.IsCarrierTracked(vc.Spec.Uplink.BondOptions)
+	vStatus.Status.NICsWithoutCarrier = observeNICsWithoutCarrier(vc)
 	if setupErr == nil {
-		networkv1.Ready.SetStatusBool(vStatus, true)
-		networkv1.Ready.Message(vStatus, "")
-	} else {
+		vStatus.Status.UplinkNICs = uplinkNICs(vc)
+		vStatus.Status.MTU = utils.MTUDefaultTo(utils.GetMTUFromVlanConfig(vc))
+	}
+	vStatus.Status.LinkSpeeds = observeLinkSpeeds(uplinkNICs(vc), vStatus.Status.LinkSpeeds)
+	vStatus.Status.LACP = nil
+	if setupErr == nil {
+		vStatus.Status.LACP = observeLACP(vc)
+	}
+	vStatus.Status.AgentVersion = h.agentVersion
+	vStatus.Status.Features = utils.AgentFeatures
+	vStatus.Status.ObservedGeneration = vc.Generation
+	vStatus.Status.Steps = steps
+	previousDegraded := networkv1.Degraded.GetStatus(vStatus)
+	setDegraded(vStatus)
+	now := time.Now()
+	utils.StampConditionTransition(vStatus, networkv1.Degraded, previousDegraded, now)
+	settle := carrierSettleTime(vc)
+	settleWait := settleCarrier(vStatus, settle, s.hasCarrier && setupErr == nil, now)
+	switch {
+	case setupErr != nil:
 		networkv1.Ready.SetStatusBool(vStatus, false)
 		networkv1.Ready.Message(vStatus, setupErr.Error())
+	case settleWait > 0:
+		networkv1.Ready.SetStatusBool(vStatus, false)
+		networkv1.Ready.Message(vStatus, settleMessage(vStatus, settle))
+	default:
+		networkv1.Ready.SetStatusBool(vStatus, true)
+		networkv1.Ready.Message(vStatus, "")
+	}
+	previous := ""
+	if getErr == nil {
+		previous = networkv1.Ready.GetStatus(vs)
 	}
+	utils.StampConditionTransition(vStatus, networkv1.Ready, previous, now)
+	if utils.RecordReadyTransition(vStatus, previous, now) {
+		logrus.Infof("the condition Ready of vlanstatus %s transitions to %s", name, networkv1.Ready.GetStatus(vStatus))
+	}
+	unreadySince, unready := utils.UnhealthySince(vStatus, false)
+	metrics.ObserveNetworkReady(h.nodeName, vc.Spec.ClusterNetwork, !unready, unreadySince)
 
 	if getErr != nil {
-		if _, err := h.vsClient.Create(vStatus); err != nil {
-			return fmt.Errorf("failed to create vlanstatus %s, error: %w", name, err)
-		}
-	} else {
-		if reflect.DeepEqual(vs, vStatus) {
+		stampReconciled(nil, vStatus, now)
+		h.writeStatus(vc, func() error {
+			if err := h.createStatus(vStatus); err != nil {
+				return fmt.Errorf("failed to create vlanstatus %s, error: %w", name, err)
+			}
 			return nil
+		})
+	} else {
+		if !stampReconciled(vs, vStatus, now) {
+			return settleWait, nil
 		}
-		if _, err := h.vsClient.Update(vStatus); err != nil {
-			return fmt.Errorf("failed to update vlanstatus %s, error: %w", name, err)
+		h.writeStatus(vc, func() error {
+			if _, err := h.vsClient.Update(vStatus); err != nil {
+				return fmt.Errorf("failed to update vlanstatus %s, error: %w", name, err)
+			}
+			return nil
+		})
+	}
+
+	return settleWait, nil
+}
+
+// writeStatus hands the write of the vlanstatus over to the status writer, so that the setup of the links neither
+// waits for the API server nor fails because of it. The vlanstatus computed from a stale cache is dropped on a
+// conflict, and the vlanconfig is reconciled again to compute it from the latest one.
+func (h Handler) writeStatus(vc *networkv1.VlanConfig, write func() error) {
+	h.statusWriter.Submit(utils.StatusKeyOfVlanStatus(vc.Spec.ClusterNetwork, h.nodeName), func() error {
+		err := write()
+		if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+			logrus.Infof("the vlanstatus of vlanconfig %s is stale, reconcile it again: %s", vc.Name, err.Error())
+			h.vcController.Enqueue(vc.Name)
+			return nil
 		}
+		return err
+	})
+}
+
+// createStatus creates the vlanstatus with a generated name if its name is taken by the vlanstatus of another cluster
+// network or node, see utils.VlanStatusName
+func (h Handler) createStatus(vs *networkv1.VlanStatus) error {
+	_, err := h.vsClient.Create(vs)
+	if !apierrors.IsAlreadyExists(err) {
+		return err
 	}
 
-	return nil
+	taken, getErr := h.vsClient.Get(vs.Name, metav1.GetOptions{})
+	if getErr != nil {
+		return errors.Join(err, getErr)
+	}
+	// the cache hasn't caught up with the vlanstatus created by the last reconcile
+	if utils.IsVlanStatusOf(taken, vs.Status.ClusterNetwork, vs.Status.Node) {
+		return err
+	}
+
+	logrus.Warnf("vlanstatus name %s is taken by cluster network %s on node %s, create it with a generated name",
+		vs.Name, taken.Status.ClusterNetwork, taken.Status.Node)
+	vs.GenerateName = utils.NamePrefix(vs.Name)
+	vs.Name = ""
+	_, err = h.vsClient.Create(vs)
+	return err
+}
+
+// stampReconciled records the time of the reconcile in the vlanstatus, it returns false if the vlanstatus is unchanged
+// and the recorded time is recent enough to be kept, so that the steady state doesn't write it on every reconcile
+func stampReconciled(vs, vStatus *networkv1.VlanStatus, now time.Time) bool {
+	if vs != nil && reflect.DeepEqual(vs, vStatus) && vs.Status.LastReconciledAt != nil &&
+		now.Sub(vs.Status.LastReconciledAt.Time) < reconcileStampInterval {
+		return false
+	}
+
+	ts := metav1.NewTime(now)
+	vStatus.Status.LastReconciledAt = &ts
+	return true
 }
 
-func (h Handler) deleteStatus(vs *networkv1.VlanStatus, teardownErr error) error {
+// setDeleting marks the vlanstatus to be in the phase Deleting before tearing down the VLAN, it's written
+// synchronously as the manager relies on the phase to tell the VLAN of the node is going away
+func (h Handler) setDeleting(vs *networkv1.VlanStatus) (*networkv1.VlanStatus, error) {
+	if vs.Status.Phase == networkv1.VlanPhaseDeleting {
+		return vs, nil
+	}
+
+	updated, err := utils.UpdateVlanStatus(h.vsClient, vs, func(vs *networkv1.VlanStatus) {
+		vs.Status.Phase = networkv1.VlanPhaseDeleting
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update vlanstatus %s, error: %w", vs.Name, err)
+	}
+
+	return updated, nil
+}
+
+// setMigrating reports the transition on the vlanstatus of the source cluster network before tearing it down,
+// the vlanstatus is kept with the teardown error if the teardown fails
+func (h Handler) setMigrating(vs *networkv1.VlanStatus, target string) (*networkv1.VlanStatus, error) {
+	msg := fmt.Sprintf("migrating from cluster network %s to %s", vs.Status.ClusterNetwork, target)
+	if networkv1.Migrating.IsTrue(vs) && networkv1.Migrating.GetMessage(vs) == msg {
+		return vs, nil
+	}
+
+	updated, err := utils.UpdateVlanStatus(h.vsClient, vs, func(vs *networkv1.VlanStatus) {
+		networkv1.Migrating.SetStatusBool(vs, true)
+		networkv1.Migrating.Message(vs, msg)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update vlanstatus %s, error: %w", vs.Name, err)
+	}
+	logrus.Infof("vlanconfig %s is %s on node %s", vs.Status.VlanConfig, msg, h.nodeName)
+
+	return updated, nil
+}
+
+// deleteStatus reports the teardown error in the vlanstatus, or deletes the vlanstatus once the VLAN is torn down.
+// Both go through the status writer after the writes of the setup, which are replaced if still pending, so that a
+// vlanstatus deleted is never written again by a stale setup.
+func (h Handler) deleteStatus(vs *networkv1.VlanStatus, steps []networkv1.SetupStep, teardownErr error) {
+	key := utils.StatusKeyOfVlanStatus(vs.Status.ClusterNetwork, h.nodeName)
 	if teardownErr != nil {
-		vsCopy := vs.DeepCopy()
-		networkv1.Ready.SetStatusBool(vsCopy, false)
-		networkv1.Ready.Message(vsCopy, teardownErr.Error())
-		if _, err := h.vsClient.Update(vsCopy); err != nil {
-			return fmt.Errorf("failed to update vlanstatus %s, error: %w", vs.Name, err)
-		}
+		h.statusWriter.Submit(key, func() error {
+			if _, err := utils.UpdateVlanStatus(h.vsClient, vs, func(vs *networkv1.VlanStatus) {
+				previous := networkv1.Ready.GetStatus(vs)
+				networkv1.Ready.SetStatusBool(vs, false)
+				networkv1.Ready.Message(vs, teardownErr.Error())
+				now := time.Now()
+				utils.StampConditionTransition(vs, networkv1.Ready, previous, now)
+				utils.RecordReadyTransition(vs, previous, now)
+				msg, blocked := teardownBlockedMessage(teardownErr)
+				networkv1.TeardownBlocked.SetStatusBool(vs, blocked)
+				networkv1.TeardownBlocked.Message(vs, msg)
+				vs.Status.Steps = steps
+			}); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to update vlanstatus %s, error: %w", vs.Name, err)
+			}
+			return nil
+		})
+		metrics.ObserveNetworkReady(h.nodeName, vs.Status.ClusterNetwork, false, time.Now())
 	} else {
-		if err := h.vsClient.Delete(vs.Name, &metav1.DeleteOptions{}); err != nil {
-			return fmt.Errorf("failed to delete vlanstatus %s, error: %w", vs.Name, err)
-		}
+		h.statusWriter.Submit(key, func() error {
+			if err := h.vsClient.Delete(vs.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete vlanstatus %s, error: %w", vs.Name, err)
+			}
+			return nil
+		})
+		metrics.ForgetNetwork(h.nodeName, vs.Status.ClusterNetwork)
 	}
-
-	return nil
 }
 
 func (h Handler) addNodeLabel(vc *networkv1.VlanConfig) error {
-	node, err := h.nodeCache.Get(h.nodeName)
+	// read the node from the API server rather than the cache under the lock, the cache may not have caught up with the
+	// update of another worker yet
+	defer h.locks.Lock(utils.LockKeyOfNode(h.nodeName))()
+	node, err := h.nodeClient.Get(h.nodeName, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
 	// Since the length of cluster network isn't bigger than 12, the length of key will less than 63.
 	key := utils.GetLabelKeyOfClusterNetwork(vc.Spec.ClusterNetwork)
 	if node.Labels != nil && node.Labels[key] == utils.ValueTrue &&
-		node.Labels[utils.KeyVlanConfigLabel] == vc.Name {
+		node.Labels[utils.KeyVlanConfigLabel] == utils.LabelValue(vc.Name) {
 		return nil
 	}
 
@@ -429,7 +1091,7 @@ func (h Handler) addNodeLabel(vc *networkv1.VlanConfig) error {
 		nodeCopy.Labels = make(map[string]string)
 	}
 	nodeCopy.Labels[key] = utils.ValueTrue
-	nodeCopy.Labels[utils.KeyVlanConfigLabel] = vc.Name
+	nodeCopy.Labels[utils.KeyVlanConfigLabel] = utils.LabelValue(vc.Name)
 
 	if _, err := h.nodeClient.Update(nodeCopy); err != nil {
 		return fmt.Errorf("add labels for vlanconfig %s to node %s failed, error: %w", vc.Name, h.nodeName, err)
@@ -439,17 +1101,21 @@ func (h Handler) addNodeLabel(vc *networkv1.VlanConfig) error {
 }
 
 func (h Handler) removeNodeLabel(vs *networkv1.VlanStatus) error {
-	node, err := h.nodeCache.Get(h.nodeName)
+	defer h.locks.Lock(utils.LockKeyOfNode(h.nodeName))()
+	node, err := h.nodeClient.Get(h.nodeName, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
 
 	key := utils.GetLabelKeyOfClusterNetwork(vs.Status.ClusterNetwork)
 	if node.Labels != nil && (node.Labels[key] == utils.ValueTrue ||
-		node.Labels[utils.KeyVlanConfigLabel] == vs.Status.VlanConfig) {
+		node.Labels[utils.KeyVlanConfigLabel] == utils.LabelValue(vs.Status.VlanConfig)) {
 		nodeCopy := node.DeepCopy()
 		delete(nodeCopy.Labels, key)
-		delete(nodeCopy.Labels, utils.KeyVlanConfigLabel)
+		// the label may have been set by the vlanconfig of another cluster network meanwhile
+		if nodeCopy.Labels[utils.KeyVlanConfigLabel] == utils.LabelValue(vs.Status.VlanConfig) {
+			delete(nodeCopy.Labels, utils.KeyVlanConfigLabel)
+		}
 		if _, err := h.nodeClient.Update(nodeCopy); err != nil {
 			return fmt.Errorf("remove labels for vlanconfig %s from node %s failed, error: %w", vs.Status.VlanConfig, h.nodeName, err)
 		}
@@ -458,7 +1124,36 @@ func (h Handler) removeNodeLabel(vs *networkv1.VlanStatus) error {
 	return nil
 }
 
-// vlanstatus name: <vc name>-<node name>-<crc32 checksum>
-func (h Handler) statusName(clusterNetwork string) string {
-	return utils.Name("", clusterNetwork, h.nodeName)
+// onClusterNetworkChange propagates the ownership of the cluster network into the labels of the vlanstatus
+func (h Handler) onClusterNetworkChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn == nil || cn.DeletionTimestamp != nil {
+		return cn, nil
+	}
+
+	vs, err := utils.GetVlanStatus(h.vsCache, cn.Name, h.nodeName)
+	if apierrors.IsNotFound(err) {
+		return cn, nil
+	} else if err != nil {
+		return nil, err
+	}
+	vc, err := h.vcCache.Get(vs.Status.VlanConfig)
+	if apierrors.IsNotFound(err) {
+		return cn, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if _, err := utils.UpdateVlanStatus(h.vsClient, vs, func(vs *networkv1.VlanStatus) {
+		labels := vs.Labels
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		if utils.SetOwnershipLabels(labels, utils.EffectiveOwnership(cn, vc)) {
+			vs.Labels = labels
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update ownership labels of vlanstatus %s, error: %w", vs.Name, err)
+	}
+
+	return cn, nil
 }
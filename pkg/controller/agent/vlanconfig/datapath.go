@@ -0,0 +1,23 @@
+package vlanconfig
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// datapathOf returns the datapath of the cluster network of the vlanconfig, the VLAN filtering if the cluster network
+// is gone
+func (h Handler) datapathOf(vc *networkv1.VlanConfig) (networkv1.Datapath, error) {
+	cn, err := h.cnCache.Get(vc.Spec.ClusterNetwork)
+	if apierrors.IsNotFound(err) {
+		return networkv1.DatapathVLANFiltering, nil
+	} else if err != nil {
+		return "", err
+	}
+	if cn.Spec.Datapath == "" {
+		return networkv1.DatapathVLANFiltering, nil
+	}
+
+	return cn.Spec.Datapath, nil
+}
@@ -0,0 +1,190 @@
+package vlanconfig
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	// the uplinks of the node are audited for drift at the interval, and shortly after a netlink event on their links
+	driftAuditInterval = 5 * time.Minute
+	// the netlink events of a cluster network are audited once they settle, e.g. a bond and its slaves are removed
+	driftSettleTime = 2 * time.Second
+	// the reason of the condition Drifted once the drift is modified back
+	driftReapplied = "Reapplied"
+	// the cluster networks waiting for an audit beyond the size are left to the periodic audit
+	driftEventsSize = 64
+)
+
+// auditDrift audits the uplinks of the node every interval, and the uplink of a cluster network shortly after a
+// netlink event on its links, until the context is done
+func (h Handler) auditDrift(ctx context.Context) {
+	h.converged.Wait()
+
+	ticker := time.NewTicker(driftAuditInterval)
+	defer ticker.Stop()
+	pending := make(map[string]bool)
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.auditUplinks()
+		case cn := <-h.driftEvents:
+			pending[cn] = true
+			if settled == nil {
+				settled = time.After(driftSettleTime)
+			}
+		case <-settled:
+			for cn := range pending {
+				h.auditClusterNetwork(cn)
+			}
+			clear(pending)
+			settled = nil
+		}
+	}
+}
+
+// notifyDrift asks for an audit of the uplink of the cluster network, the event is dropped if the audit is busy as
+// the periodic audit catches up with it
+func (h Handler) notifyDrift(cn string) {
+	select {
+	case h.driftEvents <- cn:
+	default:
+	}
+}
+
+func (h Handler) auditUplinks() {
+	vss, err := h.vsCache.GetByIndex(utils.VlanStatusByNodeIndex, h.nodeName)
+	if err != nil {
+		logrus.Warnf("skip auditing the uplinks, error: %s", err.Error())
+		return
+	}
+	for _, vs := range vss {
+		h.auditUplink(vs)
+	}
+}
+
+func (h Handler) auditClusterNetwork(cn string) {
+	vs, err := utils.GetVlanStatus(h.vsCache, cn, h.nodeName)
+	if apierrors.IsNotFound(err) {
+		return
+	} else if err != nil {
+		logrus.Warnf("skip auditing the uplink of cluster network %s, error: %s", cn, err.Error())
+		return
+	}
+	h.auditUplink(vs)
+}
+
+// auditUplink records the drift of the uplink of the vlanstatus in the condition Drifted and enqueues the vlanconfig
+// to re-apply it. Only the uplink set up as the vlanconfig is now is audited, the one being set up, failed or torn
+// down is left to the reconcile in progress.
+func (h Handler) auditUplink(vs *networkv1.VlanStatus) {
+	if vs.Status.Phase != networkv1.VlanPhaseActive || !networkv1.Ready.IsTrue(vs) {
+		return
+	}
+	vc, err := h.vcCache.Get(vs.Status.VlanConfig)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			logrus.Warnf("skip auditing the uplink of vlanconfig %s, error: %s", vs.Status.VlanConfig, err.Error())
+		}
+		return
+	}
+	if vc.DeletionTimestamp != nil || vc.Generation != vs.Status.ObservedGeneration {
+		return
+	}
+
+	drifts, err := h.detectDrift(vc)
+	if err != nil {
+		logrus.Warnf("failed to audit the uplink of vlanconfig %s, error: %s", vc.Name, err.Error())
+		return
+	}
+	if len(drifts) == 0 {
+		return
+	}
+
+	message := strings.Join(drifts, "; ")
+	logrus.Warnf("the uplink of vlanconfig %s drifts, re-apply it: %s", vc.Name, message)
+	if _, err := utils.UpdateVlanStatus(h.vsClient, vs, func(vs *networkv1.VlanStatus) {
+		networkv1.Drifted.SetStatusBool(vs, true)
+		networkv1.Drifted.Reason(vs, "")
+		networkv1.Drifted.Message(vs, message)
+	}); err != nil {
+		logrus.Warnf("failed to record the drift of vlanstatus %s, error: %s", vs.Name, err.Error())
+	}
+	h.vcController.Enqueue(vc.Name)
+}
+
+// detectDrift compares the links of the node with the uplink the setup of the vlanconfig sets up. The VTEP of an
+// overlay has no bond to drift.
+func (h Handler) detectDrift(vc *networkv1.VlanConfig) ([]string, error) {
+	vc, err := h.effectiveVlanConfig(vc)
+	if err != nil {
+		return nil, err
+	}
+	if overlay, err := h.overlayOf(vc); err != nil || overlay != nil {
+		return nil, err
+	}
+	dp, err := h.datapathOf(vc)
+	if err != nil {
+		return nil, err
+	}
+
+	// the links are not audited while the setup is changing them
+	defer h.locks.Lock(utils.LockKeyOfClusterNetwork(vc.Spec.ClusterNetwork))()
+	return vlan.Drift(desiredUplink(vc, dp))
+}
+
+// desiredUplink returns the bonds setUplink sets up for the vlanconfig and the links attached to the bridge
+func desiredUplink(vc *networkv1.VlanConfig, dp networkv1.Datapath) *vlan.DesiredUplink {
+	name := vc.Spec.ClusterNetwork + utils.BondSuffix
+	if sharedBond := vc.Spec.Uplink.SharedBond; sharedBond != nil {
+		name = sharedBond.Name
+	}
+	d := &vlan.DesiredUplink{
+		Bonds: []vlan.DesiredBond{{Bond: newBond(vc, name), NICs: vc.Spec.Uplink.NICs}},
+		Ports: []string{name},
+	}
+
+	if sub := vlanUplinkName(vc); sub != "" {
+		d.Ports = []string{sub}
+	} else if fabricB := vc.Spec.Uplink.FabricB; fabricB != nil {
+		fabricBName := utils.GenerateFabricBBondName(vc.Spec.ClusterNetwork)
+		d.Bonds = append(d.Bonds, vlan.DesiredBond{Bond: newBond(vc, fabricBName), NICs: fabricB.NICs})
+		d.Ports = append(d.Ports, fabricBName)
+	}
+
+	if br := vlan.NewVlanWithDatapath(vc.Spec.ClusterNetwork, dp).Bridge(); br != nil {
+		d.Bridge = br.Name
+	}
+
+	return d
+}
+
+// onUplinkLinkDelete asks for an audit of the cluster network whose bridge or bond is removed, the removed NICs are
+// left to the setup failing on them
+func (h Handler) onUplinkLinkDelete(key string, update *netlink.LinkUpdate) error {
+	if key == nicMonitorKey {
+		return nil
+	}
+
+	name := update.Link.Attrs().Name
+	for _, suffix := range []string{utils.BridgeSuffix, utils.BondSuffix, utils.FabricBBondSuffix} {
+		if strings.HasSuffix(name, suffix) {
+			h.notifyDrift(strings.TrimSuffix(name, suffix))
+			return nil
+		}
+	}
+
+	return nil
+}
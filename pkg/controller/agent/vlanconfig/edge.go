@@ -0,0 +1,150 @@
+package vlanconfig
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	// how long the agent waits to converge the network at startup before it falls back to the network cached on the node
+	stateFallbackTimeout = 30 * time.Second
+	stateSaveInterval    = time.Minute
+)
+
+// EnforceCachedState enforces the desired network cached on the node if the agent hasn't converged the network in
+// time, e.g. the node is rebooted while the API server is unreachable and the agent can't even register its
+// controllers. It's a no-op if there is no state file.
+func EnforceCachedState(ctx context.Context, management *config.Management) error {
+	if management.Options.StateFile == "" {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-time.After(stateFallbackTimeout):
+	}
+	if management.Converged.IsOpen() {
+		return nil
+	}
+
+	h := Handler{
+		nodeName:  management.Options.NodeName,
+		locks:     management.Locks,
+		stateFile: management.Options.StateFile,
+	}
+
+	return h.applyCachedState()
+}
+
+// applyCachedState only sets up the links, the vlanstatuses are reconciled by the controllers once the API server is
+// reachable again
+func (h Handler) applyCachedState() error {
+	state, err := utils.LoadNodeState(h.stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the cached network of node %s, error: %w", h.nodeName, err)
+	}
+	if state == nil || state.Node != h.nodeName {
+		logrus.Infof("no cached network of node %s to enforce while the API server is unreachable", h.nodeName)
+		return nil
+	}
+
+	logrus.Warnf("network is not converged in %s, enforce the network of node %s cached at %s", stateFallbackTimeout,
+		h.nodeName, state.SavedAt)
+	for i := range state.ClusterNetworks {
+		if err := h.applyCachedClusterNetwork(&state.ClusterNetworks[i]); err != nil {
+			logrus.Warnf("failed to enforce the cached cluster network %s, error: %v", state.ClusterNetworks[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (h Handler) applyCachedClusterNetwork(s *utils.ClusterNetworkState) error {
+	defer h.locks.Lock(utils.LockKeyOfClusterNetwork(s.Name))()
+	vc := s.VlanConfig
+	if sharedBond := vc.Spec.Uplink.SharedBond; sharedBond != nil {
+		defer h.locks.Lock(utils.LockKeyOfLink(sharedBond.Name))()
+	}
+
+	uplink, standby, err := setUplink(vc)
+	if err != nil {
+		return err
+	}
+	if _, err := ensureActiveNIC(vc); err != nil {
+		return err
+	}
+	if uplink, _, err = selectFabric(uplink, standby); err != nil {
+		return err
+	}
+	if err := detectLoop(vc, uplink); err != nil {
+		return err
+	}
+	v := vlan.NewVlanWithDatapath(s.Name, s.Datapath)
+	if err := v.Setup(uplink); err != nil {
+		return err
+	}
+
+	vids, err := s.VlanIDSet()
+	if err != nil {
+		return err
+	}
+	existing, err := v.ToVlanIDSet()
+	if err != nil {
+		return err
+	}
+	added, _, err := vids.Diff(existing)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("cluster network %s will add %v cached vlans", s.Name, added.GetVlanCount())
+
+	return v.AddLocalAreas(added)
+}
+
+// saveStates caches the desired network of the node computed from the caches every minute until the context is done.
+// The caches keep the last known state while the API server is unreachable, so do the links.
+func (h Handler) saveStates(ctx context.Context) {
+	wait.UntilWithContext(ctx, func(_ context.Context) {
+		if err := h.saveState(); err != nil {
+			logrus.Warnf("failed to cache the network of node %s, error: %v", h.nodeName, err)
+		}
+	}, stateSaveInterval)
+}
+
+func (h Handler) saveState() error {
+	desired, err := h.desiredState()
+	if err != nil {
+		return err
+	}
+
+	state := &utils.NodeState{
+		Node:            h.nodeName,
+		SavedAt:         time.Now().UTC(),
+		ClusterNetworks: make([]utils.ClusterNetworkState, 0, len(desired)),
+	}
+	for _, s := range desired {
+		cs := utils.NewClusterNetworkState(s.vc, s.vids)
+		cs.Datapath = s.datapath
+		state.ClusterNetworks = append(state.ClusterNetworks, cs)
+	}
+	sort.Slice(state.ClusterNetworks, func(i, j int) bool {
+		return state.ClusterNetworks[i].Name < state.ClusterNetworks[j].Name
+	})
+
+	written, err := utils.SaveNodeState(h.stateFile, state)
+	if written {
+		logrus.Infof("cached the network of %d cluster networks of node %s", len(state.ClusterNetworks), h.nodeName)
+	}
+
+	return err
+}
@@ -0,0 +1,29 @@
+package vlanconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/harvester/harvester-network-controller/pkg/hooks"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// ExcludedInterfacesSettingName is the Harvester setting holding the comma separated glob patterns of the interfaces
+// the agent must never enslave or delete, e.g. "mgmt*,eno1", in addition to network.DefaultExcludedInterfaces
+const ExcludedInterfacesSettingName = "network-controller-excluded-interfaces"
+
+// loadExcludedInterfaces refreshes the exclusion list from the setting before the links are changed, the previous
+// list is kept if the setting can't be read or is invalid
+func (h Handler) loadExcludedInterfaces() error {
+	value, err := hooks.GetSetting(context.Background(), h.dynamicClient, ExcludedInterfacesSettingName)
+	if err != nil {
+		return fmt.Errorf("failed to get setting %s, error: %w", ExcludedInterfacesSettingName, err)
+	}
+	patterns, err := network.ParseExcludedInterfaces(value)
+	if err != nil {
+		return fmt.Errorf("invalid setting %s, error: %w", ExcludedInterfacesSettingName, err)
+	}
+	network.SetExcludedInterfaces(patterns)
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package vlanconfig
+
+import (
+	"context"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/hooks"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// uplinkMembershipChanged returns true if the node joins the cluster network or the NICs of its uplink change, the
+// setup hooks are only run for such a change rather than every reconcile
+func (h Handler) uplinkMembershipChanged(vc *networkv1.VlanConfig) (bool, error) {
+	vs, err := utils.GetVlanStatus(h.vsCache, vc.Spec.ClusterNetwork, h.nodeName)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return vs.Status.VlanConfig != vc.Name || vs.Status.Phase != networkv1.VlanPhaseActive ||
+		!sameNICs(vs.Status.UplinkNICs, uplinkNICs(vc)), nil
+}
+
+func (h Handler) runHooks(event hooks.Event, clusterNetwork, vcName string, nics []string) error {
+	return h.hooks.Run(context.Background(), &hooks.Payload{
+		Event:          event,
+		Node:           h.nodeName,
+		ClusterNetwork: clusterNetwork,
+		VlanConfig:     vcName,
+		NICs:           nics,
+	})
+}
+
+// runTeardownHook runs the teardown hooks only if the node leaves the cluster network, i.e. the VLAN has been set up
+// successfully and the bridge isn't handed over to another vlanconfig
+func (h Handler) runTeardownHook(event hooks.Event, vs *networkv1.VlanStatus, users []*networkv1.VlanConfig) error {
+	if len(users) > 0 || len(vs.Status.UplinkNICs) == 0 {
+		return nil
+	}
+
+	return h.runHooks(event, vs.Status.ClusterNetwork, vs.Status.VlanConfig, vs.Status.UplinkNICs)
+}
+
+func sameNICs(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
@@ -0,0 +1,38 @@
+package vlanconfig
+
+import (
+	"net"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/jumbo"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// verifyJumbo pings the target of the jumbo verification out of the uplink with the packets of the full MTU once the
+// MTU is large enough, the uplink is attached to the bridge already but the vlanstatus doesn't turn ready on failure
+func verifyJumbo(vc *networkv1.VlanConfig, uplink *iface.Link) error {
+	opts := vc.Spec.Uplink.JumboVerification
+	mtu := utils.GetMTUFromVlanConfig(vc)
+	if opts == nil || mtu < utils.JumboMTU {
+		return nil
+	}
+
+	probe := &jumbo.Probe{
+		Link:    uplink.Attrs().Name,
+		VID:     opts.VID,
+		Source:  net.ParseIP(opts.Source),
+		Target:  net.ParseIP(opts.Target),
+		MTU:     mtu,
+		Timeout: time.Duration(opts.TimeoutMilliseconds) * time.Millisecond,
+	}
+	if err := probe.Verify(); err != nil {
+		logrus.Warnf("the jumbo frames of cluster network %s are not verified, error: %s", vc.Spec.ClusterNetwork, err.Error())
+		return err
+	}
+
+	return nil
+}
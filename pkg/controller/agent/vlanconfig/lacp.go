@@ -0,0 +1,64 @@
+package vlanconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// observeLACP reads the LACP negotiation of the uplink bond of fabric A, nil if the bond is not in the mode 802.3ad
+func observeLACP(vc *networkv1.VlanConfig) *networkv1.LACPStatus {
+	if utils.BondModeOf(vc.Spec.Uplink.BondOptions) != networkv1.BondMode8023AD {
+		return nil
+	}
+
+	name := vc.Spec.ClusterNetwork + utils.BondSuffix
+	if vc.Spec.Uplink.SharedBond != nil {
+		name = vc.Spec.Uplink.SharedBond.Name
+	}
+	state, err := iface.BondLACPState(name)
+	if err != nil {
+		logrus.Debugf("skip the LACP state of %s, error: %s", name, err.Error())
+		return nil
+	}
+	if state == nil {
+		return nil
+	}
+
+	status := &networkv1.LACPStatus{
+		AggregatorID: state.AggregatorID,
+		NumPorts:     state.NumPorts,
+		Ports:        make([]networkv1.LACPPort, 0, len(state.Ports)),
+	}
+	// the partner MAC address is all zeros until a LACPDU is received
+	if mac := state.PartnerMAC; len(mac) != 0 && strings.Trim(mac.String(), "0:") != "" {
+		status.PartnerMAC = mac.String()
+	}
+	for _, p := range state.Ports {
+		status.Ports = append(status.Ports, networkv1.LACPPort{NIC: p.Name, AggregatorID: p.AggregatorID, Bundled: p.Bundled})
+	}
+
+	return status
+}
+
+// lacpDegradedMessage describes the NICs left out of the active aggregator, it's empty if there is none. Such a NIC
+// is usually cabled to a switch port which is not in the port channel, or to another switch without MLAG.
+func lacpDegradedMessage(lacp *networkv1.LACPStatus) string {
+	if lacp == nil {
+		return ""
+	}
+
+	details := make([]string, 0)
+	for _, p := range lacp.Ports {
+		if !p.Bundled {
+			details = append(details, fmt.Sprintf("NIC %s isn't bundled in the LACP aggregator %d", p.NIC, lacp.AggregatorID))
+		}
+	}
+
+	return strings.Join(details, ", ")
+}
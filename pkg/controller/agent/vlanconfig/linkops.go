@@ -0,0 +1,128 @@
+package vlanconfig
+
+import (
+	"net"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/announce"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// linkOps are the operations the steps of the setup and the teardown run on the links of the node, so that the steps
+// are tested against a fake rather than the kernel
+type linkOps interface {
+	RequireKernel(vc *networkv1.VlanConfig) error
+	// SetUplink returns the uplink of fabric A, and the one of fabric B if it's configured
+	SetUplink(vc *networkv1.VlanConfig) (uplink, standby *iface.Link, err error)
+	EnsureVtep(cfg *iface.VtepConfig) (*iface.Link, error)
+	EnsureFloodPeers(vtep *iface.Link, peers []net.IP) error
+	EnsureActiveNIC(vc *networkv1.VlanConfig) (string, error)
+	// SelectFabric returns the uplink to be attached to the bridge and the fabric it belongs to
+	SelectFabric(uplink, standby *iface.Link) (*iface.Link, networkv1.Fabric, error)
+	DetectLoop(vc *networkv1.VlanConfig, uplink *iface.Link) error
+	// SetupBridge attaches the uplink to the bridge of the datapath and returns the VLAN of the cluster network
+	SetupBridge(vc *networkv1.VlanConfig, datapath networkv1.Datapath, uplink *iface.Link) (*vlan.Vlan, error)
+	ProgrammedVIDs(v *vlan.Vlan) (*utils.VlanIDSet, error)
+	AddVIDs(v *vlan.Vlan, vids *utils.VlanIDSet) error
+	HasCarrier(uplink *iface.Link) (bool, error)
+	Stations(v *vlan.Vlan) ([]announce.Station, error)
+	Announce(uplink string, stations []announce.Station) error
+	VerifyJumbo(vc *networkv1.VlanConfig, uplink *iface.Link) error
+
+	// LookupVlan returns the VLAN of the cluster network on the node, network.ErrLinkNotFound if it's gone
+	LookupVlan(cnName string) (*vlan.Vlan, error)
+	VlanUsers(v *vlan.Vlan) (map[uint16][]string, error)
+	ReleaseUplinkSlaves(v *vlan.Vlan, keep []string) error
+	Teardown(v *vlan.Vlan) error
+}
+
+// netlinkOps are the linkOps changing the links of the node through netlink
+type netlinkOps struct{}
+
+var _ linkOps = netlinkOps{}
+
+func (netlinkOps) RequireKernel(vc *networkv1.VlanConfig) error {
+	return requireKernel(vc)
+}
+
+func (netlinkOps) SetUplink(vc *networkv1.VlanConfig) (uplink, standby *iface.Link, err error) {
+	return setUplink(vc)
+}
+
+func (netlinkOps) EnsureVtep(cfg *iface.VtepConfig) (*iface.Link, error) {
+	return iface.EnsureVtep(cfg)
+}
+
+func (netlinkOps) EnsureFloodPeers(vtep *iface.Link, peers []net.IP) error {
+	return vtep.EnsureFloodPeers(peers)
+}
+
+func (netlinkOps) EnsureActiveNIC(vc *networkv1.VlanConfig) (string, error) {
+	return ensureActiveNIC(vc)
+}
+
+func (netlinkOps) SelectFabric(uplink, standby *iface.Link) (*iface.Link, networkv1.Fabric, error) {
+	return selectFabric(uplink, standby)
+}
+
+func (netlinkOps) DetectLoop(vc *networkv1.VlanConfig, uplink *iface.Link) error {
+	return detectLoop(vc, uplink)
+}
+
+func (netlinkOps) SetupBridge(vc *networkv1.VlanConfig, datapath networkv1.Datapath, uplink *iface.Link) (*vlan.Vlan, error) {
+	v := vlan.NewVlanWithDatapath(vc.Spec.ClusterNetwork, datapath)
+	if err := v.Setup(uplink); err != nil {
+		return nil, err
+	}
+	// a bridge-less or OVS cluster network has no Linux bridge to queue on
+	if v.Bridge() == nil {
+		return v, nil
+	}
+	return v, iface.NewLink(v.Bridge()).EnsureQdisc(bridgeQueueConfig(vc))
+}
+
+func (netlinkOps) ProgrammedVIDs(v *vlan.Vlan) (*utils.VlanIDSet, error) {
+	return v.ToVlanIDSet()
+}
+
+func (netlinkOps) AddVIDs(v *vlan.Vlan, vids *utils.VlanIDSet) error {
+	return v.AddLocalAreas(vids)
+}
+
+func (netlinkOps) HasCarrier(uplink *iface.Link) (bool, error) {
+	if err := uplink.Fetch(); err != nil {
+		return false, err
+	}
+	// the carrier of the bond without link monitoring is tracked on its NICs
+	return iface.CarrierOf(uplink)
+}
+
+func (netlinkOps) Stations(v *vlan.Vlan) ([]announce.Station, error) {
+	return v.Stations()
+}
+
+func (netlinkOps) Announce(uplink string, stations []announce.Station) error {
+	return announce.Send(uplink, stations, announce.DefaultRounds, announce.DefaultInterval)
+}
+
+func (netlinkOps) VerifyJumbo(vc *networkv1.VlanConfig, uplink *iface.Link) error {
+	return verifyJumbo(vc, uplink)
+}
+
+func (netlinkOps) LookupVlan(cnName string) (*vlan.Vlan, error) {
+	return vlan.LookupVlan(cnName)
+}
+
+func (netlinkOps) VlanUsers(v *vlan.Vlan) (map[uint16][]string, error) {
+	return v.VlanUsers()
+}
+
+func (netlinkOps) ReleaseUplinkSlaves(v *vlan.Vlan, keep []string) error {
+	return v.ReleaseUplinkSlaves(keep)
+}
+
+func (netlinkOps) Teardown(v *vlan.Vlan) error {
+	return v.Teardown()
+}
@@ -0,0 +1,44 @@
+package vlanconfig
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/loop"
+	"github.com/harvester/harvester-network-controller/pkg/network/ovs"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// detectLoop probes the uplink for a loop before it's attached to the bridge and starts forwarding.
+// The uplink attached already is not probed again, it would receive the frames flooded by the bridge.
+func detectLoop(vc *networkv1.VlanConfig, uplink *iface.Link) error {
+	opts := vc.Spec.Uplink.LoopDetection
+	if opts == nil {
+		return nil
+	}
+
+	if err := uplink.Fetch(); err != nil {
+		return err
+	}
+	if index := uplink.Attrs().MasterIndex; index != 0 {
+		master, err := network.Handle().LinkByIndex(index)
+		// the bond of an OVS cluster network is enslaved by the kernel datapath of Open vSwitch
+		if err == nil && (master.Attrs().Name == utils.GenerateBridgeName(vc.Spec.ClusterNetwork) ||
+			master.Attrs().Name == ovs.DatapathName) {
+			return nil
+		}
+	}
+
+	name := uplink.Attrs().Name
+	err := loop.Detect(name, opts.VID, time.Duration(opts.TimeoutMilliseconds)*time.Millisecond)
+	if errors.Is(err, loop.ErrLoopDetected) {
+		logrus.Errorf("keep %s detached from the bridge of cluster network %s, error: %s", name, vc.Spec.ClusterNetwork, err.Error())
+	}
+
+	return err
+}
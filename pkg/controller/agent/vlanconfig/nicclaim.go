@@ -0,0 +1,37 @@
+package vlanconfig
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/matcher"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// checkNICClaims refuses to enslave the NICs claimed by another component on the node, the webhook denies the
+// conflicts but a claim may still race with a vlanconfig
+func (h Handler) checkNICClaims(vc *networkv1.VlanConfig) error {
+	return utils.CheckNICsNotClaimed(h.nicClaimCache, h.nodeName, uplinkNICs(vc))
+}
+
+// onNICClaimChange reconciles the vlanconfigs of the node once a claim on the node changes, e.g. a released NIC
+// becomes available to the vlanconfig. The claim is nil if it's deleted, which may be on any node.
+func (h Handler) onNICClaimChange(_ string, claim *networkv1.NetworkInterfaceClaim) (*networkv1.NetworkInterfaceClaim, error) {
+	if claim != nil && claim.Spec.Node != h.nodeName {
+		return claim, nil
+	}
+
+	vcs, err := h.vcCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, vc := range vcs {
+		if isMatched, err := matcher.IsMatched(vc, h.nodeName); err != nil {
+			return nil, err
+		} else if isMatched {
+			h.vcController.Enqueue(vc.Name)
+		}
+	}
+
+	return claim, nil
+}
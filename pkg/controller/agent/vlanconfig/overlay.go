@@ -0,0 +1,137 @@
+package vlanconfig
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/matcher"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// overlayOf returns the cluster network of the vlanconfig if it's an overlay, nil otherwise
+func (h Handler) overlayOf(vc *networkv1.VlanConfig) (*networkv1.ClusterNetwork, error) {
+	cn, err := h.cnCache.Get(vc.Spec.ClusterNetwork)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !utils.IsOverlay(cn) {
+		return nil, nil
+	}
+
+	return cn, nil
+}
+
+// setVtep sets up the VXLAN tunnel endpoint of the overlay cluster network on the internal IP of this node, it
+// takes the place of the uplink bond
+func (h Handler) setVtep(vc *networkv1.VlanConfig, cn *networkv1.ClusterNetwork) (*iface.Link, error) {
+	node, err := h.nodeCache.Get(h.nodeName)
+	if err != nil {
+		return nil, err
+	}
+	localIP := nodeInternalIP(node)
+	if localIP == nil {
+		return nil, fmt.Errorf("node %s has no internal IP for the VTEP of cluster network %s", h.nodeName, cn.Name)
+	}
+
+	return h.links.EnsureVtep(&iface.VtepConfig{
+		Name:    utils.GenerateVtepName(cn.Name),
+		VNI:     cn.Spec.VXLAN.VNI,
+		Port:    utils.VxlanPortOf(cn),
+		LocalIP: localIP,
+		MTU:     utils.GetMTUFromVlanConfig(vc),
+	})
+}
+
+// floodOverlayPeers makes the VTEP replicate the broadcast traffic to the other nodes having the overlay
+func (h Handler) floodOverlayPeers(cn *networkv1.ClusterNetwork, vtep *iface.Link) error {
+	peers, err := h.overlayPeers(cn)
+	if err != nil {
+		return fmt.Errorf("list the peers of cluster network %s failed, error: %w", cn.Name, err)
+	}
+
+	return h.links.EnsureFloodPeers(vtep, peers)
+}
+
+// overlayPeers returns the internal IPs of the other nodes matched by the vlanconfigs of the overlay cluster network
+func (h Handler) overlayPeers(cn *networkv1.ClusterNetwork) ([]net.IP, error) {
+	vcs, err := h.vcCache.GetByIndex(utils.VlanConfigByClusterNetworkIndex, cn.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]bool)
+	for _, vc := range vcs {
+		if vc.DeletionTimestamp != nil {
+			continue
+		}
+		matched, err := matcher.MatchedNodesOf(vc)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range matched {
+			nodes[node] = true
+		}
+	}
+	delete(nodes, h.nodeName)
+
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	peers := make([]net.IP, 0, len(names))
+	for _, name := range names {
+		node, err := h.nodeCache.Get(name)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if ip := nodeInternalIP(node); ip != nil {
+			peers = append(peers, ip)
+		}
+	}
+
+	return peers, nil
+}
+
+// wakeUpOverlayPeers re-programs the peers of the VTEP on this node once another vlanconfig of the overlay cluster
+// network changes its nodes or is removed, by enqueuing the vlanconfig taking effect on this node
+func (h Handler) wakeUpOverlayPeers(vc *networkv1.VlanConfig) error {
+	cn, err := h.overlayOf(vc)
+	if err != nil || cn == nil {
+		return err
+	}
+
+	vs, err := utils.GetVlanStatus(h.vsCache, cn.Name, h.nodeName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if vs.Status.VlanConfig != vc.Name {
+		h.vcController.Enqueue(vs.Status.VlanConfig)
+	}
+
+	return nil
+}
+
+func nodeInternalIP(node *corev1.Node) net.IP {
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == corev1.NodeInternalIP {
+			if ip := net.ParseIP(addr.Address); ip != nil {
+				return ip
+			}
+		}
+	}
+	return nil
+}
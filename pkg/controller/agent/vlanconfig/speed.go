@@ -0,0 +1,175 @@
+package vlanconfig
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// uplinkNICs returns the NICs of all uplink groups
+func uplinkNICs(vc *networkv1.VlanConfig) []string {
+	if vc.Spec.Uplink.FabricB == nil {
+		return vc.Spec.Uplink.NICs
+	}
+
+	nics := make([]string, 0, len(vc.Spec.Uplink.NICs)+len(vc.Spec.Uplink.FabricB.NICs))
+	nics = append(nics, vc.Spec.Uplink.NICs...)
+	return append(nics, vc.Spec.Uplink.FabricB.NICs...)
+}
+
+// observeLinkSpeeds samples the negotiated speed of the NICs.
+// The maximum speed is the highest one of the supported speed and the speeds negotiated before, so that a NIC
+// whose driver does not report the supported link modes is still caught if it negotiates a lower speed than it used to.
+func observeLinkSpeeds(nics []string, previous []networkv1.LinkSpeed) []networkv1.LinkSpeed {
+	speeds := make([]networkv1.LinkSpeed, 0, len(nics))
+	for _, nic := range nics {
+		speed, maxSpeed, err := iface.LinkSpeed(nic)
+		if err != nil {
+			logrus.Debugf("skip the speed of %s, error: %s", nic, err.Error())
+		}
+		maxSpeed = max(maxSpeed, speed)
+		for _, p := range previous {
+			if p.NIC == nic {
+				maxSpeed = max(maxSpeed, p.MaxSpeed)
+			}
+		}
+		speeds = append(speeds, networkv1.LinkSpeed{NIC: nic, Speed: speed, MaxSpeed: maxSpeed})
+	}
+
+	return speeds
+}
+
+// observeNICsWithoutCarrier returns the NICs without carrier if the agent tracks the carrier of the uplink, nil
+// otherwise. The bond without link monitoring takes them as up, see iface.CarrierOf.
+func observeNICsWithoutCarrier(vc *networkv1.VlanConfig) []string {
+	if !utils.IsCarrierTracked(vc.Spec.Uplink.BondOptions) {
+		return nil
+	}
+	nics, err := iface.NICsWithoutCarrier(uplinkNICs(vc))
+	if err != nil {
+		logrus.Warnf("skip the carrier of the NICs of vlanconfig %s, error: %s", vc.Name, err.Error())
+		return nil
+	}
+	if len(nics) == 0 {
+		return nil
+	}
+
+	return nics
+}
+
+// degradedMessage describes the NICs negotiating a speed below the maximum, it's empty if there is none.
+// A NIC without carrier is not taken as degraded, it's up to the bond to fail over.
+func degradedMessage(speeds []networkv1.LinkSpeed) string {
+	details := make([]string, 0)
+	for _, s := range speeds {
+		if s.Speed != 0 && s.Speed < s.MaxSpeed {
+			details = append(details, fmt.Sprintf("NIC %s links at %dMb/s below %dMb/s", s.NIC, s.Speed, s.MaxSpeed))
+		}
+	}
+
+	return strings.Join(details, ", ")
+}
+
+func setDegraded(vs *networkv1.VlanStatus) {
+	msg := degradedMessage(vs.Status.LinkSpeeds)
+	if lacpMsg := lacpDegradedMessage(vs.Status.LACP); lacpMsg != "" {
+		if msg != "" {
+			msg += ", "
+		}
+		msg += lacpMsg
+	}
+	if vs.Status.OnBackupNIC {
+		if msg != "" {
+			msg += ", "
+		}
+		msg += "the traffic is carried by the backup NIC " + vs.Status.ActiveNIC
+	}
+	// the bond without link monitoring keeps the NICs without carrier, the traffic hashed onto them is lost
+	if len(vs.Status.NICsWithoutCarrier) != 0 {
+		if msg != "" {
+			msg += ", "
+		}
+		msg += "the NICs " + strings.Join(vs.Status.NICsWithoutCarrier, ", ") + " have no carrier and no link monitoring"
+	}
+	if msg == "" {
+		networkv1.Degraded.SetStatusBool(vs, false)
+		networkv1.Degraded.Message(vs, "")
+		return
+	}
+
+	logrus.Warnf("uplink of cluster network %s is degraded: %s", vs.Status.ClusterNetwork, msg)
+	networkv1.Degraded.SetStatusBool(vs, true)
+	networkv1.Degraded.Message(vs, msg)
+}
+
+// onNICLinkChange enqueues the vlanconfig if an uplink NIC negotiates a speed different from the recorded one, or
+// regains carrier while the uplink is on a backup NIC, or changes its carrier while the agent tracks it
+func (h Handler) onNICLinkChange(_ string, update *netlink.LinkUpdate) error {
+	nic := update.Link.Attrs().Name
+	if update.Link.Attrs().MasterIndex == 0 {
+		return h.onNICReleased(nic)
+	}
+	hasCarrier := update.Link.Attrs().OperState == netlink.OperUp
+
+	vss, err := h.vsCache.GetByIndex(utils.VlanStatusByNodeIndex, h.nodeName)
+	if err != nil {
+		return err
+	}
+
+	for _, vs := range vss {
+		// the bond without link monitoring doesn't fail over on its own
+		if vs.Status.CarrierTracked && slices.Contains(vs.Status.UplinkNICs, nic) &&
+			hasCarrier == slices.Contains(vs.Status.NICsWithoutCarrier, nic) {
+			logrus.Infof("carrier of %s changes to %t, reconcile vlanconfig %s", nic, hasCarrier, vs.Status.VlanConfig)
+			h.vcController.Enqueue(vs.Status.VlanConfig)
+			continue
+		}
+		if !hasCarrier {
+			continue
+		}
+		// fail back from the backup NIC once another NIC regains carrier
+		if vs.Status.OnBackupNIC && nic != vs.Status.ActiveNIC && slices.Contains(vs.Status.UplinkNICs, nic) {
+			logrus.Infof("%s regains carrier, reconcile vlanconfig %s to fail back from the backup NIC %s", nic,
+				vs.Status.VlanConfig, vs.Status.ActiveNIC)
+			h.vcController.Enqueue(vs.Status.VlanConfig)
+			continue
+		}
+		idx := slices.IndexFunc(vs.Status.LinkSpeeds, func(s networkv1.LinkSpeed) bool { return s.NIC == nic })
+		if idx == -1 {
+			continue
+		}
+		speed, _, err := iface.LinkSpeed(nic)
+		if err != nil {
+			return err
+		}
+		if speed != vs.Status.LinkSpeeds[idx].Speed {
+			logrus.Infof("speed of %s changes to %dMb/s, reconcile vlanconfig %s", nic, speed, vs.Status.VlanConfig)
+			h.vcController.Enqueue(vs.Status.VlanConfig)
+		}
+	}
+
+	return nil
+}
+
+// onNICReleased asks for an audit of the cluster networks whose uplink NIC is released from the bond, e.g. outside
+// the agent
+func (h Handler) onNICReleased(nic string) error {
+	vss, err := h.vsCache.GetByIndex(utils.VlanStatusByNodeIndex, h.nodeName)
+	if err != nil {
+		return err
+	}
+	for _, vs := range vss {
+		if slices.Contains(vs.Status.UplinkNICs, nic) {
+			h.notifyDrift(vs.Status.ClusterNetwork)
+		}
+	}
+
+	return nil
+}
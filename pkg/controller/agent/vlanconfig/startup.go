@@ -0,0 +1,138 @@
+package vlanconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// nodeState is the desired network of the node, i.e. the vlanconfig taking effect and the VIDs of every cluster
+// network set up on the node
+type nodeState map[string]*clusterNetworkState
+
+type clusterNetworkState struct {
+	vc       *networkv1.VlanConfig
+	vids     *utils.VlanIDSet
+	datapath networkv1.Datapath
+}
+
+// converge computes the desired network of the node from the synced caches in one pass and applies the difference
+// to the links, instead of letting the controllers reconcile every vlanconfig and cluster network on their own,
+// which re-programs the bridges and VIDs many times on a node with many NADs. The gate is opened afterwards even if
+// it fails, then the controllers take over and retry.
+func (h Handler) converge(ctx context.Context, converged *utils.Gate, synced ...cache.InformerSynced) {
+	defer converged.Open()
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		logrus.Warnf("caches are not synced, skip converging the network of node %s", h.nodeName)
+		return
+	}
+
+	if err := h.loadExcludedInterfaces(); err != nil {
+		logrus.Warnf("failed to load the excluded interfaces, only the default ones are excluded, error: %v", err)
+	}
+
+	start := time.Now()
+	state, err := h.desiredState()
+	if err != nil {
+		logrus.Warnf("failed to compute the desired network of node %s, error: %v", h.nodeName, err)
+		return
+	}
+
+	names := make([]string, 0, len(state))
+	for name := range state {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failed := 0
+	for _, name := range names {
+		if err := h.applyState(name, state[name]); err != nil {
+			logrus.Warnf("failed to converge cluster network %s on node %s, error: %v", name, h.nodeName, err)
+			failed++
+		}
+	}
+	logrus.Infof("converged %d cluster networks on node %s in %s, %d failed", len(names), h.nodeName,
+		time.Since(start).Round(time.Millisecond), failed)
+}
+
+func (h Handler) desiredState() (nodeState, error) {
+	vcs, err := h.vcCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	state := make(nodeState)
+	for _, vc := range vcs {
+		if vc.DeletionTimestamp != nil {
+			continue
+		}
+		isMatched, err := h.MatchNode(vc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match vlanconfig %s, error: %w", vc.Name, err)
+		}
+		if !isMatched {
+			continue
+		}
+
+		cn, err := h.cnCache.Get(vc.Spec.ClusterNetwork)
+		// the vlanconfigs are kept if the deletion of their cluster network is aborted until it's recreated
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get cluster network %s, error: %w", vc.Spec.ClusterNetwork, err)
+		}
+		vids, err := h.localAreas.VlanIDSetOf(cn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the vids of cluster network %s, error: %w", cn.Name, err)
+		}
+		effective, err := h.effectiveVlanConfig(vc)
+		if err != nil {
+			return nil, err
+		}
+		state[cn.Name] = &clusterNetworkState{vc: effective, vids: vids, datapath: cn.Spec.Datapath}
+	}
+
+	return state, nil
+}
+
+// applyState sets up the bridge and uplink of the cluster network and adds the missing VIDs in one batch. The VIDs
+// are only added here, the cluster network controller removes the stale ones as it defers the removal for the VMs.
+func (h Handler) applyState(name string, s *clusterNetworkState) error {
+	if err := h.setupVLAN(s.vc); err != nil {
+		return err
+	}
+
+	defer h.locks.Lock(utils.LockKeyOfClusterNetwork(name))()
+	v, err := vlan.GetVlanWithDatapath(name, s.datapath)
+	if err != nil {
+		// the vlanconfig failed to set up, which is reported in its vlanstatus
+		if errors.Is(err, network.ErrLinkNotFound) {
+			return nil
+		}
+		return err
+	}
+	existing, err := v.ToVlanIDSet()
+	if err != nil {
+		return err
+	}
+	added, _, err := s.vids.Diff(existing)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("cluster network %s will add %v vlans at startup", name, added.GetVlanCount())
+
+	return v.AddLocalAreas(added)
+}
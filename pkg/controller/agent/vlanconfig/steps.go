@@ -0,0 +1,290 @@
+package vlanconfig
+
+import (
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/hooks"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// The steps of the setup of the VLAN on the node, in the order they run. The setup only adds the VIDs missing on the
+// uplink, the stale ones are removed by the clusternetwork controller, which is woken up once the setup succeeds and
+// keeps the VIDs still used by the VMs.
+const (
+	stepPrerequisites     = "prerequisites"
+	stepResolveVIDs       = "resolveVIDs"
+	stepPreSetupHook      = "preSetupHook"
+	stepUplink            = "uplink"
+	stepFailover          = "failover"
+	stepLoopDetection     = "loopDetection"
+	stepBridge            = "bridge"
+	stepProgramVIDs       = "programVIDs"
+	stepCarrier           = "carrier"
+	stepAnnounce          = "announce"
+	stepJumboVerification = "jumboVerification"
+	stepPostSetupHook     = "postSetupHook"
+)
+
+// The steps of the teardown of the VLAN on the node, in the order they run
+const (
+	stepLookup           = "lookup"
+	stepRelease          = "release"
+	stepConsumers        = "consumers"
+	stepPreTeardownHook  = "preTeardownHook"
+	stepTeardown         = "teardown"
+	stepPostTeardownHook = "postTeardownHook"
+)
+
+// the steps changing the links are retried in place if the kernel is busy with the links
+const netlinkStepRetries = 2
+
+// setupState is the state the steps of the setup share
+type setupState struct {
+	vc *networkv1.VlanConfig
+	// the overlay cluster network of the vlanconfig, nil if it's a VLAN one
+	overlay           *networkv1.ClusterNetwork
+	datapath          networkv1.Datapath
+	membershipChanged bool
+	// the VIDs of the cluster network, nil if the cluster network doesn't exist yet
+	vids *utils.VlanIDSet
+	// the uplink attached to the bridge, and the bond of the fabric not attached if fabric B is configured
+	uplink, standby *iface.Link
+	v               *vlan.Vlan
+	activeFabric    networkv1.Fabric
+	activeNIC       string
+	hasCarrier      bool
+}
+
+// setupSteps returns the steps setting up the uplink and the bridge of the vlanconfig. The VTEP is the uplink of an
+// overlay, there are no NICs to fail over and no loops behind it.
+func (h Handler) setupSteps(s *setupState) []utils.Step {
+	vc := s.vc
+	isOverlay := func() bool { return s.overlay != nil }
+	membershipUnchanged := func() bool { return !s.membershipChanged }
+
+	return []utils.Step{
+		{
+			Name: stepPrerequisites,
+			Run: func() (err error) {
+				if err = h.links.RequireKernel(vc); err != nil {
+					return err
+				}
+				if err = h.checkNICClaims(vc); err != nil {
+					return err
+				}
+				if s.membershipChanged, err = h.uplinkMembershipChanged(vc); err != nil {
+					return err
+				}
+				if s.overlay, err = h.overlayOf(vc); err != nil {
+					return err
+				}
+				s.datapath, err = h.datapathOf(vc)
+				return err
+			},
+		},
+		{
+			Name: stepResolveVIDs,
+			Run: func() (err error) {
+				s.vids, err = h.resolveVIDs(vc)
+				return err
+			},
+		},
+		{
+			Name: stepPreSetupHook,
+			Skip: membershipUnchanged,
+			Run: func() error {
+				return h.runHooks(hooks.EventPreSetup, vc.Spec.ClusterNetwork, vc.Name, uplinkNICs(vc))
+			},
+		},
+		{
+			Name:    stepUplink,
+			Retries: netlinkStepRetries,
+			Run: func() (err error) {
+				if s.overlay != nil {
+					s.uplink, err = h.setVtep(vc, s.overlay)
+					return err
+				}
+				s.uplink, s.standby, err = h.links.SetUplink(vc)
+				return err
+			},
+		},
+		{
+			// keep the traffic off the backup NICs while any other NIC has carrier, and pick the fabric to attach
+			// if there are two uplink groups
+			Name: stepFailover,
+			Skip: isOverlay,
+			Run: func() (err error) {
+				if s.activeNIC, err = h.links.EnsureActiveNIC(vc); err != nil {
+					return err
+				}
+				s.uplink, s.activeFabric, err = h.links.SelectFabric(s.uplink, s.standby)
+				return err
+			},
+		},
+		{
+			// refuse to attach the uplink to the bridge if there is a loop behind it
+			Name: stepLoopDetection,
+			Skip: func() bool { return s.overlay != nil || vc.Spec.Uplink.LoopDetection == nil },
+			Run:  func() error { return h.links.DetectLoop(vc, s.uplink) },
+		},
+		{
+			Name:    stepBridge,
+			Retries: netlinkStepRetries,
+			Run: func() (err error) {
+				s.v, err = h.links.SetupBridge(vc, s.datapath, s.uplink)
+				return err
+			},
+		},
+		{
+			Name:    stepProgramVIDs,
+			Skip:    func() bool { return s.vids == nil },
+			Retries: netlinkStepRetries,
+			Run:     func() error { return h.programVIDs(s.v, s.vids) },
+		},
+		{
+			Name: stepCarrier,
+			Run: func() error {
+				// the VTEP has no carrier of its own, it's ready once it floods to the peers
+				if s.overlay != nil {
+					if err := h.floodOverlayPeers(s.overlay, s.uplink); err != nil {
+						return err
+					}
+					s.hasCarrier = true
+					return nil
+				}
+				hasCarrier, err := h.links.HasCarrier(s.uplink)
+				if err != nil {
+					return err
+				}
+				s.hasCarrier = hasCarrier
+				return nil
+			},
+		},
+		{
+			// tell the switches where the VMs are at once after the failover, the uplink without carrier can't
+			Name: stepAnnounce,
+			Skip: func() bool { return s.overlay != nil || !s.hasCarrier },
+			Run: func() error {
+				h.announceStations(s)
+				return nil
+			},
+		},
+		{
+			// the uplink without carrier can't be verified, it's not ready anyway
+			Name: stepJumboVerification,
+			Skip: func() bool { return s.overlay != nil || !s.hasCarrier || vc.Spec.Uplink.JumboVerification == nil },
+			Run:  func() error { return h.links.VerifyJumbo(vc, s.uplink) },
+		},
+		{
+			// the post-setup hook is run again with the pre-setup hook if it fails, as the membership is only
+			// recorded once the setup succeeds
+			Name: stepPostSetupHook,
+			Skip: membershipUnchanged,
+			Run: func() error {
+				return h.runHooks(hooks.EventPostSetup, vc.Spec.ClusterNetwork, vc.Name, uplinkNICs(vc))
+			},
+		},
+	}
+}
+
+// teardownState is the state the steps of the teardown share
+type teardownState struct {
+	vs *networkv1.VlanStatus
+	// the other vlanconfigs of the cluster network taking over the bridge
+	users []*networkv1.VlanConfig
+	v     *vlan.Vlan
+	// the bridge is torn down even if it's still used, see utils.KeyForceTeardown
+	forced bool
+	// the bridge is gone, e.g. torn down by an earlier teardown whose post-teardown hook failed
+	gone bool
+}
+
+// teardownSteps returns the steps tearing down the bridge of the vlanstatus, or releasing the NICs of the vlanconfig
+// if the bridge is still used by another vlanconfig. The removal of the bridge removes the VIDs as well.
+func (h Handler) teardownSteps(s *teardownState) []utils.Step {
+	isGone := func() bool { return s.gone }
+
+	return []utils.Step{
+		{
+			Name: stepLookup,
+			Run: func() (err error) {
+				s.v, err = h.links.LookupVlan(s.vs.Status.ClusterNetwork)
+				// We take it granted that `LinkNotFound` means the VLAN has been torn down.
+				if errors.Is(err, network.ErrLinkNotFound) {
+					s.gone = true
+					return nil
+				}
+				return err
+			},
+		},
+		{
+			// the bridge and the uplink are left to the users, there is nothing else to tear down
+			Name: stepRelease,
+			Skip: func() bool { return s.gone || len(s.users) == 0 },
+			Run: func() error {
+				if err := h.releaseVLAN(s); err != nil {
+					return err
+				}
+				return utils.ErrSkipRemainingSteps
+			},
+		},
+		{
+			// the teardown is deferred while the VMs on this node are still attached to the bridge
+			Name: stepConsumers,
+			Skip: isGone,
+			Run:  func() error { return h.checkConsumers(s) },
+		},
+		{
+			Name: stepPreTeardownHook,
+			Skip: isGone,
+			Run:  func() error { return h.runTeardownHook(hooks.EventPreTeardown, s.vs, s.users) },
+		},
+		{
+			Name:    stepTeardown,
+			Skip:    isGone,
+			Retries: netlinkStepRetries,
+			Run:     func() error { return h.links.Teardown(s.v) },
+		},
+		{
+			// the post-teardown hook is retried if it failed after the teardown
+			Name: stepPostTeardownHook,
+			Run:  func() error { return h.runTeardownHook(hooks.EventPostTeardown, s.vs, s.users) },
+		},
+	}
+}
+
+// resolveVIDs returns the VIDs of the cluster network of the vlanconfig, nil if the cluster network doesn't exist yet,
+// whose VIDs are programmed by the clusternetwork controller once it's created
+func (h Handler) resolveVIDs(vc *networkv1.VlanConfig) (*utils.VlanIDSet, error) {
+	cn, err := h.cnCache.Get(vc.Spec.ClusterNetwork)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return h.localAreas.VlanIDSetOf(cn)
+}
+
+// programVIDs adds the VIDs missing on the uplink, e.g. after the uplink is recreated
+func (h Handler) programVIDs(v *vlan.Vlan, vids *utils.VlanIDSet) error {
+	programmed, err := h.links.ProgrammedVIDs(v)
+	if err != nil {
+		return err
+	}
+	added, _, err := vids.Diff(programmed)
+	if err != nil {
+		return err
+	}
+	if added.GetVlanCount() == 0 {
+		return nil
+	}
+
+	return h.links.AddVIDs(v, added)
+}
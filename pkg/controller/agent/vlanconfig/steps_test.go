@@ -0,0 +1,384 @@
+package vlanconfig
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/fake"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/localarea"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/announce"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+	"github.com/harvester/harvester-network-controller/pkg/utils/fakeclients"
+)
+
+const (
+	testNode = "node1"
+	testCN   = "data"
+	testVC   = "data-vc"
+)
+
+// fakeLinks records the linkOps called by the steps, the op named in errs fails with the error
+type fakeLinks struct {
+	calls []string
+	errs  map[string]error
+	// busy is how many times SetUplink fails with EBUSY before it succeeds
+	busy       int
+	programmed []uint16
+	added      []uint16
+	users      map[uint16][]string
+	kept       []string
+}
+
+var _ linkOps = &fakeLinks{}
+
+func (f *fakeLinks) call(op string) error {
+	f.calls = append(f.calls, op)
+	return f.errs[op]
+}
+
+func (f *fakeLinks) RequireKernel(_ *networkv1.VlanConfig) error {
+	return f.call("RequireKernel")
+}
+
+func (f *fakeLinks) SetUplink(_ *networkv1.VlanConfig) (uplink, standby *iface.Link, err error) {
+	if err := f.call("SetUplink"); err != nil {
+		return nil, nil, err
+	}
+	if f.busy > 0 {
+		f.busy--
+		return nil, nil, network.Classify(unix.EBUSY)
+	}
+	return newTestLink("data-bo"), nil, nil
+}
+
+func (f *fakeLinks) EnsureVtep(_ *iface.VtepConfig) (*iface.Link, error) {
+	return newTestLink("data-vtep"), f.call("EnsureVtep")
+}
+
+func (f *fakeLinks) EnsureFloodPeers(_ *iface.Link, _ []net.IP) error {
+	return f.call("EnsureFloodPeers")
+}
+
+func (f *fakeLinks) EnsureActiveNIC(_ *networkv1.VlanConfig) (string, error) {
+	return "", f.call("EnsureActiveNIC")
+}
+
+func (f *fakeLinks) SelectFabric(uplink, _ *iface.Link) (*iface.Link, networkv1.Fabric, error) {
+	return uplink, networkv1.FabricA, f.call("SelectFabric")
+}
+
+func (f *fakeLinks) DetectLoop(_ *networkv1.VlanConfig, _ *iface.Link) error {
+	return f.call("DetectLoop")
+}
+
+func (f *fakeLinks) SetupBridge(vc *networkv1.VlanConfig, _ networkv1.Datapath, _ *iface.Link) (*vlan.Vlan, error) {
+	if err := f.call("SetupBridge"); err != nil {
+		return nil, err
+	}
+	return vlan.NewVlan(vc.Spec.ClusterNetwork), nil
+}
+
+func (f *fakeLinks) ProgrammedVIDs(_ *vlan.Vlan) (*utils.VlanIDSet, error) {
+	if err := f.call("ProgrammedVIDs"); err != nil {
+		return nil, err
+	}
+	vis := utils.NewVlanIDSet()
+	for _, vid := range f.programmed {
+		if err := vis.SetUint16VID(vid); err != nil {
+			return nil, err
+		}
+	}
+	return vis, nil
+}
+
+func (f *fakeLinks) AddVIDs(_ *vlan.Vlan, vids *utils.VlanIDSet) error {
+	f.added = append(f.added, vids.VIDs()...)
+	return f.call("AddVIDs")
+}
+
+func (f *fakeLinks) HasCarrier(_ *iface.Link) (bool, error) {
+	return true, f.call("HasCarrier")
+}
+
+func (f *fakeLinks) Stations(_ *vlan.Vlan) ([]announce.Station, error) {
+	return nil, f.call("Stations")
+}
+
+func (f *fakeLinks) Announce(_ string, _ []announce.Station) error {
+	return f.call("Announce")
+}
+
+func (f *fakeLinks) VerifyJumbo(_ *networkv1.VlanConfig, _ *iface.Link) error {
+	return f.call("VerifyJumbo")
+}
+
+func (f *fakeLinks) LookupVlan(cnName string) (*vlan.Vlan, error) {
+	if err := f.call("LookupVlan"); err != nil {
+		return nil, err
+	}
+	return vlan.NewVlan(cnName), nil
+}
+
+func (f *fakeLinks) VlanUsers(_ *vlan.Vlan) (map[uint16][]string, error) {
+	return f.users, f.call("VlanUsers")
+}
+
+func (f *fakeLinks) ReleaseUplinkSlaves(_ *vlan.Vlan, keep []string) error {
+	f.kept = keep
+	return f.call("ReleaseUplinkSlaves")
+}
+
+func (f *fakeLinks) Teardown(_ *vlan.Vlan) error {
+	return f.call("Teardown")
+}
+
+func newTestLink(name string) *iface.Link {
+	return &iface.Link{Link: &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: name}}}
+}
+
+// fakeVlanConfigController records the vlanconfigs enqueued by the steps
+type fakeVlanConfigController struct {
+	ctlnetworkv1.VlanConfigController
+	enqueued []string
+}
+
+func (c *fakeVlanConfigController) Enqueue(name string) {
+	c.enqueued = append(c.enqueued, name)
+}
+
+func (c *fakeVlanConfigController) EnqueueAfter(name string, _ time.Duration) {
+	c.enqueued = append(c.enqueued, name)
+}
+
+func newTestHandler(t *testing.T, links *fakeLinks, cn *networkv1.ClusterNetwork) (Handler, *fakeVlanConfigController) {
+	nchclientset := fake.NewSimpleClientset()
+	if cn != nil {
+		_, err := nchclientset.NetworkV1beta1().ClusterNetworks().Create(t.Context(), cn, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	vcController := &fakeVlanConfigController{}
+
+	return Handler{
+		nodeName:      testNode,
+		links:         links,
+		cnCache:       fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks),
+		vsCache:       fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses),
+		nicClaimCache: fakeclients.NetworkInterfaceClaimCache(nchclientset.NetworkV1beta1().NetworkInterfaceClaims),
+		nadCache:      fakeclients.NetworkAttachmentDefinitionCache(nchclientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		vcController:  vcController,
+		localAreas:    localarea.NewSources(localarea.StaticSource),
+	}, vcController
+}
+
+func newTestVlanConfig(name string, nics ...string) *networkv1.VlanConfig {
+	return &networkv1.VlanConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: networkv1.VlanConfigSpec{
+			ClusterNetwork: testCN,
+			Uplink:         networkv1.Uplink{NICs: nics},
+		},
+	}
+}
+
+func newTestClusterNetwork(vids ...uint16) *networkv1.ClusterNetwork {
+	return &networkv1.ClusterNetwork{
+		ObjectMeta: metav1.ObjectMeta{Name: testCN},
+		Spec:       networkv1.ClusterNetworkSpec{StaticVIDs: vids},
+	}
+}
+
+func newTestVlanStatus(nics ...string) *networkv1.VlanStatus {
+	return &networkv1.VlanStatus{
+		ObjectMeta: metav1.ObjectMeta{Name: utils.VlanStatusName(testCN, testNode)},
+		Status: networkv1.VlStatus{
+			ClusterNetwork: testCN,
+			VlanConfig:     testVC,
+			Node:           testNode,
+			UplinkNICs:     nics,
+		},
+	}
+}
+
+func phasesOf(results []networkv1.SetupStep) map[string]networkv1.StepPhase {
+	phases := make(map[string]networkv1.StepPhase, len(results))
+	for _, result := range results {
+		phases[result.Name] = result.Phase
+	}
+	return phases
+}
+
+func TestSetupSteps(t *testing.T) {
+	links := &fakeLinks{programmed: []uint16{100}}
+	h, _ := newTestHandler(t, links, newTestClusterNetwork(100, 200))
+
+	results, err := utils.RunSteps(h.setupSteps(&setupState{vc: newTestVlanConfig(testVC, "eth1")}), network.IsRetryable)
+	assert.NoError(t, err)
+
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.Name)
+	}
+	assert.Equal(t, []string{stepPrerequisites, stepResolveVIDs, stepPreSetupHook, stepUplink, stepFailover,
+		stepLoopDetection, stepBridge, stepProgramVIDs, stepCarrier, stepAnnounce, stepJumboVerification,
+		stepPostSetupHook}, names)
+	phases := phasesOf(results)
+	assert.Equal(t, networkv1.StepSucceeded, phases[stepProgramVIDs])
+	assert.Equal(t, networkv1.StepSkipped, phases[stepLoopDetection], "the loop detection isn't configured")
+	assert.Equal(t, networkv1.StepSkipped, phases[stepJumboVerification], "the jumbo verification isn't configured")
+
+	assert.Equal(t, []string{"RequireKernel", "SetUplink", "EnsureActiveNIC", "SelectFabric", "SetupBridge",
+		"ProgrammedVIDs", "AddVIDs", "HasCarrier"}, links.calls)
+	assert.Equal(t, []uint16{200}, links.added, "only the missing VIDs are added")
+}
+
+func TestProgramVIDsStep(t *testing.T) {
+	tests := []struct {
+		name          string
+		cn            *networkv1.ClusterNetwork
+		programmed    []uint16
+		errs          map[string]error
+		expectedPhase networkv1.StepPhase
+		expectedAdded []uint16
+	}{
+		{
+			name:          "the cluster network doesn't exist yet",
+			expectedPhase: networkv1.StepSkipped,
+		},
+		{
+			name:          "all the VIDs are programmed",
+			cn:            newTestClusterNetwork(100, 200),
+			programmed:    []uint16{100, 200, 300},
+			expectedPhase: networkv1.StepSucceeded,
+		},
+		{
+			name:          "the missing VIDs are added",
+			cn:            newTestClusterNetwork(100, 200),
+			expectedPhase: networkv1.StepSucceeded,
+			expectedAdded: []uint16{100, 200},
+		},
+		{
+			name:          "the programmed VIDs can't be read",
+			cn:            newTestClusterNetwork(100),
+			errs:          map[string]error{"ProgrammedVIDs": unix.ENOBUFS},
+			expectedPhase: networkv1.StepFailed,
+		},
+		{
+			name:          "the VIDs can't be added",
+			cn:            newTestClusterNetwork(100),
+			errs:          map[string]error{"AddVIDs": unix.EINVAL},
+			expectedPhase: networkv1.StepFailed,
+			expectedAdded: []uint16{100},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			links := &fakeLinks{programmed: tc.programmed, errs: tc.errs}
+			h, _ := newTestHandler(t, links, tc.cn)
+
+			results, err := utils.RunSteps(h.setupSteps(&setupState{vc: newTestVlanConfig(testVC, "eth1")}), network.IsRetryable)
+			assert.Equal(t, tc.expectedPhase == networkv1.StepFailed, err != nil, err)
+			assert.Equal(t, tc.expectedPhase, phasesOf(results)[stepProgramVIDs])
+			assert.Equal(t, tc.expectedAdded, links.added)
+		})
+	}
+}
+
+func TestSetupStepsFailed(t *testing.T) {
+	t.Run("the busy uplink is retried in place", func(t *testing.T) {
+		links := &fakeLinks{busy: 1}
+		h, _ := newTestHandler(t, links, newTestClusterNetwork())
+
+		results, err := utils.RunSteps(h.setupSteps(&setupState{vc: newTestVlanConfig(testVC, "eth1")}), network.IsRetryable)
+		assert.NoError(t, err)
+		assert.Equal(t, networkv1.StepSucceeded, phasesOf(results)[stepUplink])
+		assert.Equal(t, []string{"RequireKernel", "SetUplink", "SetUplink"}, links.calls[:3])
+	})
+
+	t.Run("the steps behind the failed bridge are pending", func(t *testing.T) {
+		links := &fakeLinks{errs: map[string]error{"SetupBridge": unix.EPERM}}
+		h, _ := newTestHandler(t, links, newTestClusterNetwork(100))
+
+		results, err := utils.RunSteps(h.setupSteps(&setupState{vc: newTestVlanConfig(testVC, "eth1")}), network.IsRetryable)
+		assert.True(t, errors.Is(err, unix.EPERM))
+		phases := phasesOf(results)
+		assert.Equal(t, networkv1.StepFailed, phases[stepBridge])
+		for _, name := range []string{stepProgramVIDs, stepCarrier, stepAnnounce, stepJumboVerification, stepPostSetupHook} {
+			assert.Equal(t, networkv1.StepPending, phases[name], name)
+		}
+		assert.Equal(t, "SetupBridge", links.calls[len(links.calls)-1], "a non-retryable error isn't retried")
+	})
+
+	t.Run("the missing kernel module fails the prerequisites", func(t *testing.T) {
+		links := &fakeLinks{errs: map[string]error{"RequireKernel": errors.New("module 8021q is missing")}}
+		h, _ := newTestHandler(t, links, nil)
+
+		results, err := utils.RunSteps(h.setupSteps(&setupState{vc: newTestVlanConfig(testVC, "eth1")}), network.IsRetryable)
+		assert.Error(t, err)
+		assert.Equal(t, networkv1.StepFailed, phasesOf(results)[stepPrerequisites])
+		assert.Equal(t, []string{"RequireKernel"}, links.calls)
+	})
+}
+
+func TestTeardownSteps(t *testing.T) {
+	tests := []struct {
+		name             string
+		links            *fakeLinks
+		users            []*networkv1.VlanConfig
+		expectErr        bool
+		expectedCalls    []string
+		expectedKept     []string
+		expectedEnqueued []string
+	}{
+		{
+			name:          "the bridge is torn down",
+			links:         &fakeLinks{},
+			expectedCalls: []string{"LookupVlan", "VlanUsers", "Teardown"},
+		},
+		{
+			name:          "the bridge is gone",
+			links:         &fakeLinks{errs: map[string]error{"LookupVlan": network.Classify(unix.ENODEV)}},
+			expectedCalls: []string{"LookupVlan"},
+		},
+		{
+			name:             "the bridge is still used by the VMs",
+			links:            &fakeLinks{users: map[uint16][]string{100: {"vm1-tap"}}},
+			expectErr:        true,
+			expectedCalls:    []string{"LookupVlan", "VlanUsers"},
+			expectedEnqueued: []string{testVC},
+		},
+		{
+			name:             "the bridge is handed over to another vlanconfig",
+			links:            &fakeLinks{},
+			users:            []*networkv1.VlanConfig{newTestVlanConfig("data-vc2", "eth2")},
+			expectedCalls:    []string{"LookupVlan", "ReleaseUplinkSlaves"},
+			expectedKept:     []string{"eth2"},
+			expectedEnqueued: []string{"data-vc2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, vcController := newTestHandler(t, tc.links, nil)
+
+			_, err := utils.RunSteps(h.teardownSteps(&teardownState{vs: newTestVlanStatus("eth1"), users: tc.users}),
+				network.IsRetryable)
+			assert.Equal(t, tc.expectErr, err != nil, err)
+			assert.Equal(t, tc.expectedCalls, tc.links.calls)
+			assert.Equal(t, tc.expectedKept, tc.links.kept)
+			assert.Equal(t, tc.expectedEnqueued, vcController.enqueued)
+		})
+	}
+}
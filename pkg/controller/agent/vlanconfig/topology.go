@@ -0,0 +1,79 @@
+package vlanconfig
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// topologyLabels remembers the values of the topology labels of this node the vlanconfigs were last enqueued with
+type topologyLabels struct {
+	mutex  sync.Mutex
+	values map[string]string
+}
+
+// changed records the current values and returns true if they differ from the recorded ones. The first values are
+// only recorded, all the vlanconfigs are reconciled once the agent starts anyway.
+func (t *topologyLabels) changed(values map[string]string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	changed := t.values != nil && !maps.Equal(t.values, values)
+	t.values = values
+	return changed
+}
+
+// effectiveVlanConfig returns the vlanconfig with the topology override matching the labels of this node applied
+func (h Handler) effectiveVlanConfig(vc *networkv1.VlanConfig) (*networkv1.VlanConfig, error) {
+	if len(vc.Spec.TopologyOverrides) == 0 {
+		return vc, nil
+	}
+
+	node, err := h.nodeCache.Get(h.nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s for the topology overrides of vlanconfig %s, error: %w",
+			h.nodeName, vc.Name, err)
+	}
+
+	return utils.ApplyTopologyOverride(vc, node.Labels), nil
+}
+
+// onNodeChange enqueues the vlanconfigs with topology overrides once a topology label of this node changes, e.g. the
+// node is moved to another rack, as another override may take effect
+func (h Handler) onNodeChange(_ string, node *corev1.Node) (*corev1.Node, error) {
+	if node == nil || node.DeletionTimestamp != nil || node.Name != h.nodeName {
+		return node, nil
+	}
+
+	vcs, err := h.vcCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	withOverrides := make([]string, 0)
+	values := make(map[string]string)
+	for _, vc := range vcs {
+		if len(vc.Spec.TopologyOverrides) == 0 {
+			continue
+		}
+		withOverrides = append(withOverrides, vc.Name)
+		for i := range vc.Spec.TopologyOverrides {
+			key := utils.TopologyKeyOf(&vc.Spec.TopologyOverrides[i])
+			values[key] = node.Labels[key]
+		}
+	}
+
+	if !h.topology.changed(values) {
+		return node, nil
+	}
+	for _, name := range withOverrides {
+		h.vcController.Enqueue(name)
+	}
+
+	return node, nil
+}
@@ -0,0 +1,101 @@
+package vlanconfig
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const utilizationInterval = time.Minute
+
+// counterSample is the byte counters of an uplink at a point in time
+type counterSample struct {
+	index   int
+	rxBytes uint64
+	txBytes uint64
+	at      time.Time
+}
+
+// utilizationOf computes the average throughput between two samples of the same uplink, ok is false if the samples
+// are not comparable, e.g. the uplink is recreated or its counters are reset
+func utilizationOf(prev, cur counterSample, speeds []networkv1.LinkSpeed) (u networkv1.UplinkUtilization, ok bool) {
+	elapsed := cur.at.Sub(prev.at).Seconds()
+	if prev.index != cur.index || elapsed <= 0 || cur.rxBytes < prev.rxBytes || cur.txBytes < prev.txBytes {
+		return u, false
+	}
+
+	u.RxBitsPerSecond = uint64(float64(cur.rxBytes-prev.rxBytes) * 8 / elapsed)
+	u.TxBitsPerSecond = uint64(float64(cur.txBytes-prev.txBytes) * 8 / elapsed)
+	u.IntervalSeconds = int(elapsed + 0.5)
+	u.SampledAt = metav1.NewTime(cur.at)
+
+	// the speed is in Mb/s, only the NICs with carrier count
+	var speed uint64
+	for _, s := range speeds {
+		speed += uint64(s.Speed)
+	}
+	if speed != 0 {
+		u.Percent = int(max(u.RxBitsPerSecond, u.TxBitsPerSecond) * 100 / (speed * 1000 * 1000))
+	}
+
+	return u, true
+}
+
+// sampleUtilization records the throughput of the uplinks on the node every minute until the context is done
+func (h Handler) sampleUtilization(ctx context.Context) {
+	samples := make(map[string]counterSample)
+	wait.UntilWithContext(ctx, func(_ context.Context) {
+		h.sampleUplinks(samples)
+	}, utilizationInterval)
+}
+
+func (h Handler) sampleUplinks(samples map[string]counterSample) {
+	vss, err := h.vsCache.GetByIndex(utils.VlanStatusByNodeIndex, h.nodeName)
+	if err != nil {
+		logrus.Warnf("skip sampling the uplinks, error: %s", err.Error())
+		return
+	}
+
+	now := time.Now()
+	sampled := make(map[string]bool, len(vss))
+	for _, vs := range vss {
+		cn := vs.Status.ClusterNetwork
+		v, err := vlan.LookupVlan(cn)
+		if err != nil || v.Uplink() == nil || v.Uplink().Attrs().Statistics == nil {
+			continue
+		}
+		attrs := v.Uplink().Attrs()
+		cur := counterSample{index: attrs.Index, rxBytes: attrs.Statistics.RxBytes, txBytes: attrs.Statistics.TxBytes, at: now}
+		prev, found := samples[cn]
+		samples[cn], sampled[cn] = cur, true
+		if !found {
+			continue
+		}
+		u, ok := utilizationOf(prev, cur, vs.Status.LinkSpeeds)
+		if !ok {
+			continue
+		}
+
+		metrics.ObserveUplinkThroughput(h.nodeName, cn, u.RxBitsPerSecond, u.TxBitsPerSecond)
+		if _, err := utils.UpdateVlanStatus(h.vsClient, vs, func(vs *networkv1.VlanStatus) {
+			vs.Status.Utilization = &u
+		}); err != nil {
+			logrus.Warnf("failed to update the utilization of vlanstatus %s, error: %s", vs.Name, err.Error())
+		}
+	}
+
+	// forget the uplinks torn down
+	for cn := range samples {
+		if !sampled[cn] {
+			delete(samples, cn)
+		}
+	}
+}
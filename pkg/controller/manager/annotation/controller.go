@@ -0,0 +1,98 @@
+package annotation
+
+import (
+	"context"
+
+	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/sirupsen/logrus"
+
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+)
+
+const controllerName = "harvester-network-manager-annotation-controller"
+
+// Handler migrates the annotations set under the deprecated names of the keys of the annotation contract forward to
+// the current names
+type Handler struct {
+	cnClient  ctlnetworkv1.ClusterNetworkClient
+	vcClient  ctlnetworkv1.VlanConfigClient
+	hncClient ctlnetworkv1.HostNetworkConfigClient
+	nadClient ctlcniv1.NetworkAttachmentDefinitionClient
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
+	vcs := management.HarvesterNetworkFactory.Network().V1beta1().VlanConfig()
+	hncs := management.HarvesterNetworkFactory.Network().V1beta1().HostNetworkConfig()
+	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+
+	handler := Handler{
+		cnClient:  cns,
+		vcClient:  vcs,
+		hncClient: hncs,
+		nadClient: nads,
+	}
+
+	cns.OnChange(ctx, controllerName, handler.OnClusterNetworkChange)
+	vcs.OnChange(ctx, controllerName, handler.OnVlanConfigChange)
+	hncs.OnChange(ctx, controllerName, handler.OnHostNetworkConfigChange)
+	nads.OnChange(ctx, controllerName, handler.OnNadChange)
+
+	return nil
+}
+
+func (h Handler) OnClusterNetworkChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn == nil || cn.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	cnCopy := cn.DeepCopy()
+	if !annotations.Migrate(cnCopy) {
+		return cn, nil
+	}
+	logrus.Infof("migrate the deprecated annotations of cluster network %s", cn.Name)
+	return h.cnClient.Update(cnCopy)
+}
+
+func (h Handler) OnVlanConfigChange(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanConfig, error) {
+	if vc == nil || vc.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	vcCopy := vc.DeepCopy()
+	if !annotations.Migrate(vcCopy) {
+		return vc, nil
+	}
+	logrus.Infof("migrate the deprecated annotations of vlanconfig %s", vc.Name)
+	return h.vcClient.Update(vcCopy)
+}
+
+func (h Handler) OnHostNetworkConfigChange(_ string, hnc *networkv1.HostNetworkConfig) (*networkv1.HostNetworkConfig, error) {
+	if hnc == nil || hnc.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	hncCopy := hnc.DeepCopy()
+	if !annotations.Migrate(hncCopy) {
+		return hnc, nil
+	}
+	logrus.Infof("migrate the deprecated annotations of host network config %s", hnc.Name)
+	return h.hncClient.Update(hncCopy)
+}
+
+func (h Handler) OnNadChange(_ string, nad *cniv1.NetworkAttachmentDefinition) (*cniv1.NetworkAttachmentDefinition, error) {
+	if nad == nil || nad.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	nadCopy := nad.DeepCopy()
+	if !annotations.Migrate(nadCopy) {
+		return nad, nil
+	}
+	logrus.Infof("migrate the deprecated annotations of nad %s/%s", nad.Namespace, nad.Name)
+	return h.nadClient.Update(nadCopy)
+}
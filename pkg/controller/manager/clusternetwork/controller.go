@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 
 	"github.com/sirupsen/logrus"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/harvester/harvester-network-controller/pkg/network/iface"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/v3/pkg/generic"
 )
 
 const (
@@ -31,6 +33,10 @@ type Handler struct {
 	lmClient          ctlnetworkv1.LinkMonitorClient
 	lmCache           ctlnetworkv1.LinkMonitorCache
 	cnClient          ctlnetworkv1.ClusterNetworkClient
+	cnCache           ctlnetworkv1.ClusterNetworkCache
+	cnController      ctlnetworkv1.ClusterNetworkController
+	vcClient          ctlnetworkv1.VlanConfigClient
+	vcCache           ctlnetworkv1.VlanConfigCache
 	nadClient         ctlcniv1.NetworkAttachmentDefinitionClient
 	nadCache          ctlcniv1.NetworkAttachmentDefinitionCache
 	hostNetworkCache  ctlnetworkv1.HostNetworkConfigCache
@@ -44,11 +50,16 @@ func Register(ctx context.Context, management *config.Management) error {
 	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
 	hns := management.HarvesterNetworkFactory.Network().V1beta1().HostNetworkConfig()
 	nodes := management.CoreFactory.Core().V1().Node()
+	vcs := management.HarvesterNetworkFactory.Network().V1beta1().VlanConfig()
 
 	h := Handler{
 		lmClient:          lms,
 		lmCache:           lms.Cache(),
 		cnClient:          cns,
+		cnCache:           cns.Cache(),
+		cnController:      cns,
+		vcClient:          vcs,
+		vcCache:           vcs.Cache(),
 		nadClient:         nads,
 		nadCache:          nads.Cache(),
 		hostNetworkCache:  hns.Cache(),
@@ -63,6 +74,9 @@ func Register(ctx context.Context, management *config.Management) error {
 	cns.OnChange(ctx, controllerName, h.EnsureLinkMonitor)
 	cns.OnChange(ctx, controllerName, h.SetNadReadyLabel)
 	cns.OnChange(ctx, controllerName, h.SetHostNetworkStatus)
+	cns.OnChange(ctx, controllerName, h.PropagateUplinkDefaults)
+	cns.OnChange(ctx, controllerName, h.CheckMTUConsistency)
+	nads.OnChange(ctx, controllerName, h.enqueueStorageNetwork)
 	cns.OnRemove(ctx, controllerName, h.DeleteLinkMonitor)
 
 	return nil
@@ -89,6 +103,13 @@ func (h Handler) DeleteLinkMonitor(_ string, cn *networkv1.ClusterNetwork) (*net
 		return nil, nil
 	}
 
+	// keep the finalizer without logging an error while the deletion is held
+	if held, err := h.holdDeletion(cn); err != nil {
+		return nil, err
+	} else if held {
+		return cn, generic.ErrSkip
+	}
+
 	if err := h.deleteLinkMonitor(cn.Name); err != nil {
 		return nil, fmt.Errorf("delete link monitor for cluster network %s failed, error: %w", cn.Name, err)
 	}
@@ -123,7 +144,7 @@ func (h Handler) ensureLinkMonitor(name string) error {
 		},
 		Spec: networkv1.LinkMonitorSpec{
 			TargetLinkRule: networkv1.TargetLinkRule{
-				NameRule: name + "(" + utils.BridgeSuffix + "|" + utils.BondSuffix + ")",
+				NameRule: name + "(" + utils.BridgeSuffix + "|" + utils.BondSuffix + "|" + utils.FabricBBondSuffix + ")",
 			}},
 	}); err != nil {
 		return err
@@ -149,9 +170,7 @@ func (h Handler) setNadReadyLabel(cn *networkv1.ClusterNetwork) error {
 		isReady = utils.ValueTrue
 	}
 
-	nads, err := h.nadCache.List("", labels.Set(map[string]string{
-		utils.KeyClusterNetworkLabel: cn.Name,
-	}).AsSelector())
+	nads, err := h.nadCache.GetByIndex(utils.NadByBridgeIndex, utils.GetBridgeNameOfClusterNetwork(cn.Name))
 	if err != nil {
 		return err
 	}
@@ -173,6 +192,37 @@ func (h Handler) setNadReadyLabel(cn *networkv1.ClusterNetwork) error {
 	return nil
 }
 
+// PropagateUplinkDefaults applies the uplink defaults of the cluster network to the vlanconfigs inheriting them
+func (h Handler) PropagateUplinkDefaults(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn == nil || cn.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	vcs, err := h.vcCache.GetByIndex(utils.VlanConfigByClusterNetworkIndex, cn.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vc := range vcs {
+		if vc.DeletionTimestamp != nil {
+			continue
+		}
+		// the webhook records the inherited sections when the vlanconfig is updated
+		uplink, _ := utils.InheritUplinkDefaults(vc, vc, cn.Spec.UplinkDefaults)
+		if reflect.DeepEqual(uplink, vc.Spec.Uplink) {
+			continue
+		}
+		vcCopy := vc.DeepCopy()
+		vcCopy.Spec.Uplink = uplink
+		if _, err := h.vcClient.Update(vcCopy); err != nil {
+			return nil, fmt.Errorf("propagate uplink defaults of cluster network %s to vlanconfig %s failed, error: %w",
+				cn.Name, vc.Name, err)
+		}
+	}
+
+	return cn, nil
+}
+
 func (h Handler) setHNNodeStatusUnready(hnCopy *networkv1.HostNetworkConfig) error {
 	nodes, err := h.nodeCache.List(labels.Everything())
 	if err != nil {
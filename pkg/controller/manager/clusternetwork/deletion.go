@@ -0,0 +1,87 @@
+package clusternetwork
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const vcDeletionRecheckPeriod = 5 * time.Second
+
+// holdDeletion returns true if the deleted cluster network has to keep its finalizer, i.e. it's in the deletion grace
+// period or its vlanconfigs are still being deleted. The vlanconfigs are deleted after the grace period expires or the
+// deletion is confirmed, and kept if the deletion is aborted, so that a cluster network recreated with the same name
+// takes them over without tearing down the bridges on the nodes.
+func (h Handler) holdDeletion(cn *networkv1.ClusterNetwork) (bool, error) {
+	if cn.Spec.DeletionGracePeriodSeconds == 0 || cn.DeletionTimestamp == nil {
+		return false, nil
+	}
+
+	switch confirmation := cn.Annotations[utils.KeyDeletionConfirmation]; confirmation {
+	case utils.ValueAbort:
+		logrus.Warnf("the deletion of cluster network %s is aborted, its vlanconfigs are kept", cn.Name)
+		return false, nil
+	case utils.ValueConfirm:
+	default:
+		if confirmation != "" {
+			logrus.Warnf("cluster network %s has invalid deletion confirmation %q, expected %q or %q", cn.Name,
+				confirmation, utils.ValueConfirm, utils.ValueAbort)
+		}
+		grace := time.Duration(cn.Spec.DeletionGracePeriodSeconds) * time.Second
+		if remaining := time.Until(cn.DeletionTimestamp.Add(grace)); remaining > 0 {
+			msg := fmt.Sprintf("the vlanconfigs are kept until %s unless the deletion is confirmed or aborted",
+				cn.DeletionTimestamp.Add(grace).UTC().Format(time.RFC3339))
+			if err := h.setTerminating(cn, msg); err != nil {
+				return false, err
+			}
+			h.cnController.EnqueueAfter(cn.Name, remaining)
+			return true, nil
+		}
+	}
+
+	vcs, err := h.vcCache.GetByIndex(utils.VlanConfigByClusterNetworkIndex, cn.Name)
+	if err != nil {
+		return false, err
+	}
+	if len(vcs) == 0 {
+		return false, nil
+	}
+
+	names := make([]string, 0, len(vcs))
+	for _, vc := range vcs {
+		names = append(names, vc.Name)
+		if vc.DeletionTimestamp != nil {
+			continue
+		}
+		if err := h.vcClient.Delete(vc.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to delete vlanconfig %s, error: %w", vc.Name, err)
+		}
+	}
+	if err := h.setTerminating(cn, fmt.Sprintf("waiting for the vlanconfigs %v to be deleted", names)); err != nil {
+		return false, err
+	}
+	h.cnController.EnqueueAfter(cn.Name, vcDeletionRecheckPeriod)
+
+	return true, nil
+}
+
+func (h Handler) setTerminating(cn *networkv1.ClusterNetwork, msg string) error {
+	if networkv1.Terminating.IsTrue(cn.Status) && networkv1.Terminating.GetMessage(cn.Status) == msg {
+		return nil
+	}
+
+	if _, err := utils.UpdateClusterNetwork(h.cnClient, cn, func(cn *networkv1.ClusterNetwork) {
+		networkv1.Terminating.SetStatusBool(&cn.Status, true)
+		networkv1.Terminating.Message(&cn.Status, msg)
+	}); err != nil {
+		return fmt.Errorf("failed to set cluster network %s terminating, error: %w", cn.Name, err)
+	}
+
+	return nil
+}
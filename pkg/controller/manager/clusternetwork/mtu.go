@@ -0,0 +1,79 @@
+package clusternetwork
+
+import (
+	"fmt"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// CheckMTUConsistency reports the MTU of the cluster network diverging from the mgmt network in the condition
+// MTUMismatch. It's informational only, the MTUs are left as they are.
+func (h Handler) CheckMTUConsistency(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn == nil || cn.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	// the MTU of mgmt may have changed, recheck the others
+	if cn.Name == utils.ManagementClusterNetworkName {
+		cns, err := h.cnCache.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range cns {
+			if other.Name != cn.Name {
+				h.cnController.Enqueue(other.Name)
+			}
+		}
+		return cn, nil
+	}
+
+	mgmt, err := h.cnCache.Get(utils.ManagementClusterNetworkName)
+	if apierrors.IsNotFound(err) {
+		return cn, nil
+	} else if err != nil {
+		return nil, err
+	}
+	nads, err := h.nadCache.List("", labels.Set{utils.KeyClusterNetworkLabel: cn.Name}.AsSelector())
+	if err != nil {
+		return nil, err
+	}
+	msg, err := utils.CheckMTUAgainstMgmt(cn, mgmt, nads)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the MTU of cluster network %s, error: %w", cn.Name, err)
+	}
+
+	mismatched := msg != ""
+	if networkv1.MTUMismatch.IsTrue(cn.Status) == mismatched && networkv1.MTUMismatch.GetMessage(cn.Status) == msg {
+		return cn, nil
+	}
+	// don't add the condition to the cluster networks which never mismatch
+	if !mismatched && networkv1.MTUMismatch.GetStatus(cn.Status) == "" {
+		return cn, nil
+	}
+	if mismatched {
+		logrus.Warnf("cluster network %s: %s", cn.Name, msg)
+	}
+
+	return utils.UpdateClusterNetwork(h.cnClient, cn, func(cn *networkv1.ClusterNetwork) {
+		networkv1.MTUMismatch.SetStatusBool(&cn.Status, mismatched)
+		networkv1.MTUMismatch.Message(&cn.Status, msg)
+	})
+}
+
+// enqueueStorageNetwork rechecks the cluster network of a storage network NAD when the NAD is added or removed
+func (h Handler) enqueueStorageNetwork(_ string, nad *nadv1.NetworkAttachmentDefinition) (*nadv1.NetworkAttachmentDefinition, error) {
+	if nad == nil || !utils.IsStorageNetworkNad(nad) {
+		return nad, nil
+	}
+	if cnName := nad.Labels[utils.KeyClusterNetworkLabel]; cnName != "" {
+		h.cnController.Enqueue(cnName)
+	}
+
+	return nad, nil
+}
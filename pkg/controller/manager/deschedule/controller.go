@@ -0,0 +1,271 @@
+package deschedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlkubevirtv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubevirt.io/v1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	controllerName = "harvester-network-manager-deschedule-controller"
+
+	defaultDelay = 60 * time.Second
+	// recheck an unhealthy network for the VMs started on the node or failed to be descheduled since
+	recheckInterval = 30 * time.Second
+	// the failed migrations of a VMI are retried with a backoff doubling from recheckInterval, and given up after the
+	// attempts until the network recovers
+	maxMigrationAttempts = 5
+)
+
+// Handler deschedules the VMs attached to the NADs of a cluster network from a node whose network of the cluster
+// network stays unhealthy, according to the descheduling options of the cluster network. The VMs are either hinted
+// by labeling their virt-launcher pods, e.g. for the descheduler to evict them, or live migrated.
+type Handler struct {
+	cnCache      ctlnetworkv1.ClusterNetworkCache
+	vsController ctlnetworkv1.VlanStatusController
+	nadCache     ctlcniv1.NetworkAttachmentDefinitionCache
+	vmiCache     ctlkubevirtv1.VirtualMachineInstanceCache
+	vmimCache    ctlkubevirtv1.VirtualMachineInstanceMigrationCache
+	vmimClient   ctlkubevirtv1.VirtualMachineInstanceMigrationClient
+	podCache     ctlcorev1.PodCache
+	podClient    ctlcorev1.PodClient
+	clock        *utils.UnhealthyClock
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
+	vss := management.HarvesterNetworkFactory.Network().V1beta1().VlanStatus()
+	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	vmis := management.KubevirtFactory.Kubevirt().V1().VirtualMachineInstance()
+	vmims := management.KubevirtFactory.Kubevirt().V1().VirtualMachineInstanceMigration()
+	pods := management.CoreFactory.Core().V1().Pod()
+
+	handler := Handler{
+		cnCache:      cns.Cache(),
+		vsController: vss,
+		nadCache:     nads.Cache(),
+		vmiCache:     vmis.Cache(),
+		vmimCache:    vmims.Cache(),
+		vmimClient:   vmims,
+		podCache:     pods.Cache(),
+		podClient:    pods,
+		clock:        utils.NewUnhealthyClock(),
+	}
+	// Indexer must be added before starting the informer
+	handler.vmiCache.AddIndexer(utils.VMByNetworkIndex, utils.VMIByNetwork)
+
+	vss.OnChange(ctx, controllerName, handler.OnChange)
+
+	return nil
+}
+
+func (h Handler) OnChange(key string, vs *networkv1.VlanStatus) (*networkv1.VlanStatus, error) {
+	if vs == nil || vs.DeletionTimestamp != nil {
+		h.clock.Forget(key)
+		return nil, nil
+	}
+
+	cnName, node := vs.Status.ClusterNetwork, vs.Status.Node
+	cn, err := h.cnCache.Get(cnName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	options := cn.Spec.Descheduling
+	since, unhealthy := utils.UnhealthySince(vs, options != nil && options.Degraded)
+	if options == nil || !unhealthy {
+		h.clock.Forget(key)
+		return vs, h.clearHints(cnName, node)
+	}
+	// the delay of a network with an unknown unhealthy time starts when it's first seen unhealthy
+	since = h.clock.Since(key, since, time.Now())
+
+	delay := defaultDelay
+	if options.DelaySeconds > 0 {
+		delay = time.Duration(options.DelaySeconds) * time.Second
+	}
+	if remaining := time.Until(since.Add(delay)); remaining > 0 {
+		h.vsController.EnqueueAfter(vs.Name, remaining)
+		return vs, nil
+	}
+
+	vmis, err := h.affectedVmis(cnName, node)
+	if err != nil {
+		return nil, err
+	}
+	for _, vmi := range vmis {
+		switch options.Policy {
+		case networkv1.DeschedulingAnnotate:
+			err = h.hint(vmi, cnName, node)
+		case networkv1.DeschedulingMigrate:
+			err = h.migrate(vmi, cnName, since)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	h.vsController.EnqueueAfter(vs.Name, recheckInterval)
+	return vs, nil
+}
+
+// affectedVmis returns the VMIs on the node attached to the NADs of the cluster network
+func (h Handler) affectedVmis(cnName, node string) ([]*kubevirtv1.VirtualMachineInstance, error) {
+	nads, err := utils.NewNadGetter(h.nadCache).ListNadsOnClusterNetwork(cnName)
+	if err != nil {
+		return nil, err
+	}
+	vmis, err := utils.NewVmiGetter(h.vmiCache).WhoUseNads(nads, true, mapset.NewSet(node))
+	if err != nil {
+		return nil, err
+	}
+
+	// a VMI attached to several NADs is listed more than once
+	seen := mapset.NewSet[string]()
+	affected := make([]*kubevirtv1.VirtualMachineInstance, 0, len(vmis))
+	for _, vmi := range vmis {
+		if seen.Add(vmi.Namespace + "/" + vmi.Name) {
+			affected = append(affected, vmi)
+		}
+	}
+	return affected, nil
+}
+
+// hint labels the virt-launcher pods of the VMI on the node and records the unhealthy cluster network on them
+func (h Handler) hint(vmi *kubevirtv1.VirtualMachineInstance, cnName, node string) error {
+	pods, err := h.podCache.List(vmi.Namespace, labels.Set{kubevirtv1.CreatedByLabel: string(vmi.UID)}.AsSelector())
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		if pod.Spec.NodeName != node || pod.DeletionTimestamp != nil {
+			continue
+		}
+		networks := utils.AddUnhealthyNetwork(pod.Annotations[utils.KeyUnhealthyNetworks], cnName)
+		if pod.Labels[utils.KeyDeschedule] == utils.ValueTrue && pod.Annotations[utils.KeyUnhealthyNetworks] == networks {
+			continue
+		}
+
+		podCopy := pod.DeepCopy()
+		if podCopy.Labels == nil {
+			podCopy.Labels = make(map[string]string)
+		}
+		if podCopy.Annotations == nil {
+			podCopy.Annotations = make(map[string]string)
+		}
+		podCopy.Labels[utils.KeyDeschedule] = utils.ValueTrue
+		podCopy.Annotations[utils.KeyUnhealthyNetworks] = networks
+		logrus.Infof("hint descheduling pod %s/%s of vmi %s for unhealthy cluster network %s on node %s",
+			pod.Namespace, pod.Name, vmi.Name, cnName, node)
+		if _, err := h.podClient.Update(podCopy); err != nil {
+			return fmt.Errorf("failed to hint descheduling pod %s/%s, error: %w", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// clearHints removes the cluster network from the hints of the virt-launcher pods on the node
+func (h Handler) clearHints(cnName, node string) error {
+	pods, err := h.podCache.List(corev1.NamespaceAll, labels.Set{utils.KeyDeschedule: utils.ValueTrue}.AsSelector())
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		if pod.Spec.NodeName != node {
+			continue
+		}
+		networks := utils.RemoveUnhealthyNetwork(pod.Annotations[utils.KeyUnhealthyNetworks], cnName)
+		if networks == pod.Annotations[utils.KeyUnhealthyNetworks] {
+			continue
+		}
+
+		podCopy := pod.DeepCopy()
+		if networks == "" {
+			delete(podCopy.Labels, utils.KeyDeschedule)
+			delete(podCopy.Annotations, utils.KeyUnhealthyNetworks)
+		} else {
+			podCopy.Annotations[utils.KeyUnhealthyNetworks] = networks
+		}
+		if _, err := h.podClient.Update(podCopy); err != nil {
+			return fmt.Errorf("failed to clear descheduling hint of pod %s/%s, error: %w", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// migrate live migrates the VMI unless it's not live migratable or being migrated already. The migrations failed since
+// the network became unhealthy are backed off and capped by maxMigrationAttempts.
+func (h Handler) migrate(vmi *kubevirtv1.VirtualMachineInstance, cnName string, since time.Time) error {
+	if !vmi.IsMigratable() {
+		return nil
+	}
+
+	vmims, err := h.vmimCache.List(vmi.Namespace, labels.Everything())
+	if err != nil {
+		return err
+	}
+	failed := 0
+	var lastFailed time.Time
+	for _, vmim := range vmims {
+		if vmim.Spec.VMIName != vmi.Name {
+			continue
+		}
+		if !vmim.IsFinal() {
+			return nil
+		}
+		if vmim.Status.Phase != kubevirtv1.MigrationFailed || vmim.Labels[utils.KeyClusterNetworkLabel] != cnName ||
+			vmim.CreationTimestamp.Time.Before(since) {
+			continue
+		}
+		failed++
+		if vmim.CreationTimestamp.Time.After(lastFailed) {
+			lastFailed = vmim.CreationTimestamp.Time
+		}
+	}
+	if failed >= maxMigrationAttempts {
+		logrus.Debugf("give up migrating vmi %s/%s for unhealthy cluster network %s after %d failed attempts",
+			vmi.Namespace, vmi.Name, cnName, failed)
+		return nil
+	}
+	if failed > 0 && time.Since(lastFailed) < recheckInterval<<(failed-1) {
+		return nil
+	}
+
+	vmim := &kubevirtv1.VirtualMachineInstanceMigration{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: vmi.Name + "-network-",
+			Namespace:    vmi.Namespace,
+			Labels:       map[string]string{utils.KeyClusterNetworkLabel: cnName},
+		},
+		Spec: kubevirtv1.VirtualMachineInstanceMigrationSpec{VMIName: vmi.Name},
+	}
+	logrus.Infof("migrate vmi %s/%s away from node %s for unhealthy cluster network %s", vmi.Namespace, vmi.Name,
+		vmi.Status.NodeName, cnName)
+	if _, err := h.vmimClient.Create(vmim); err != nil {
+		return fmt.Errorf("failed to migrate vmi %s/%s, error: %w", vmi.Namespace, vmi.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlkubevirtv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubevirt.io/v1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	controllerName = "harvester-network-manager-migration-controller"
+
+	// ConditionTargetNetworkReady tells whether the network of the target node covers the NADs of the VM
+	ConditionTargetNetworkReady kubevirtv1.VirtualMachineInstanceMigrationConditionType = "TargetNetworkReady"
+	reasonTargetNetworkNotReady                                                         = "TargetNetworkNotReady"
+
+	// recheck the migration until its target pod is scheduled
+	targetRecheckInterval = 2 * time.Second
+)
+
+// Handler verifies that the vlanstatuses of the target node of a VM live migration cover all the NADs of the VM, i.e.
+// the cluster networks are ready, the VLAN IDs are programmed and the uplink MTU is large enough, once the target
+// pod is scheduled. A migration whose target network doesn't cover the NADs is aborted with the condition
+// TargetNetworkReady false instead of hanging on the CNI errors of the target pod.
+type Handler struct {
+	vmimClient     ctlkubevirtv1.VirtualMachineInstanceMigrationClient
+	vmimController ctlkubevirtv1.VirtualMachineInstanceMigrationController
+	vmiCache       ctlkubevirtv1.VirtualMachineInstanceCache
+	podCache       ctlcorev1.PodCache
+	nadCache       ctlcniv1.NetworkAttachmentDefinitionCache
+	vsCache        ctlnetworkv1.VlanStatusCache
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	vmims := management.KubevirtFactory.Kubevirt().V1().VirtualMachineInstanceMigration()
+	vmis := management.KubevirtFactory.Kubevirt().V1().VirtualMachineInstance()
+	pods := management.CoreFactory.Core().V1().Pod()
+	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	vss := management.HarvesterNetworkFactory.Network().V1beta1().VlanStatus()
+
+	handler := Handler{
+		vmimClient:     vmims,
+		vmimController: vmims,
+		vmiCache:       vmis.Cache(),
+		podCache:       pods.Cache(),
+		nadCache:       nads.Cache(),
+		vsCache:        vss.Cache(),
+	}
+
+	vmims.OnChange(ctx, controllerName, handler.OnChange)
+
+	return nil
+}
+
+func (h Handler) OnChange(_ string, vmim *kubevirtv1.VirtualMachineInstanceMigration) (*kubevirtv1.VirtualMachineInstanceMigration, error) {
+	if vmim == nil || vmim.DeletionTimestamp != nil || !isPreparing(vmim) || hasCondition(vmim) {
+		return nil, nil
+	}
+
+	node, err := h.targetNode(vmim)
+	if err != nil {
+		return nil, err
+	}
+	if node == "" {
+		h.vmimController.EnqueueAfter(vmim.Namespace, vmim.Name, targetRecheckInterval)
+		return vmim, nil
+	}
+
+	notReady, err := h.checkTargetNetwork(vmim, node)
+	if err != nil {
+		return nil, err
+	}
+
+	vmimCopy := vmim.DeepCopy()
+	setCondition(vmimCopy, notReady)
+	if _, err := h.vmimClient.UpdateStatus(vmimCopy); err != nil {
+		return nil, fmt.Errorf("failed to update status of migration %s/%s, error: %w", vmim.Namespace, vmim.Name, err)
+	}
+	if notReady == "" {
+		return vmim, nil
+	}
+
+	// deleting the migration aborts it
+	logrus.Warnf("abort migration %s/%s of vmi %s to node %s, error: %s", vmim.Namespace, vmim.Name, vmim.Spec.VMIName,
+		node, notReady)
+	if err := h.vmimClient.Delete(vmim.Namespace, vmim.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to abort migration %s/%s, error: %w", vmim.Namespace, vmim.Name, err)
+	}
+
+	return vmim, nil
+}
+
+// isPreparing returns true if the migration hasn't started to transfer the VM yet
+func isPreparing(vmim *kubevirtv1.VirtualMachineInstanceMigration) bool {
+	switch vmim.Status.Phase {
+	case kubevirtv1.MigrationPhaseUnset, kubevirtv1.MigrationPending, kubevirtv1.MigrationScheduling,
+		kubevirtv1.MigrationScheduled, kubevirtv1.MigrationPreparingTarget:
+		return true
+	default:
+		return false
+	}
+}
+
+func hasCondition(vmim *kubevirtv1.VirtualMachineInstanceMigration) bool {
+	for _, c := range vmim.Status.Conditions {
+		if c.Type == ConditionTargetNetworkReady {
+			return true
+		}
+	}
+	return false
+}
+
+func setCondition(vmim *kubevirtv1.VirtualMachineInstanceMigration, notReady string) {
+	c := kubevirtv1.VirtualMachineInstanceMigrationCondition{
+		Type:               ConditionTargetNetworkReady,
+		Status:             corev1.ConditionTrue,
+		LastProbeTime:      metav1.Now(),
+		LastTransitionTime: metav1.Now(),
+	}
+	if notReady != "" {
+		c.Status = corev1.ConditionFalse
+		c.Reason = reasonTargetNetworkNotReady
+		c.Message = notReady
+	}
+	vmim.Status.Conditions = append(vmim.Status.Conditions, c)
+}
+
+// targetNode returns the node the target pod of the migration is scheduled to, it's empty if the pod is not
+// scheduled yet
+func (h Handler) targetNode(vmim *kubevirtv1.VirtualMachineInstanceMigration) (string, error) {
+	if vmim.Status.MigrationState != nil && vmim.Status.MigrationState.TargetNode != "" {
+		return vmim.Status.MigrationState.TargetNode, nil
+	}
+
+	pods, err := h.podCache.List(vmim.Namespace, labels.Set{kubevirtv1.MigrationJobLabel: string(vmim.UID)}.AsSelector())
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" {
+			return pod.Spec.NodeName, nil
+		}
+	}
+
+	return "", nil
+}
+
+// checkTargetNetwork returns the reason why the network of the node doesn't cover the NADs of the VM, it's empty if
+// the network covers them
+func (h Handler) checkTargetNetwork(vmim *kubevirtv1.VirtualMachineInstanceMigration, node string) (string, error) {
+	vmi, err := h.vmiCache.Get(vmim.Namespace, vmim.Spec.VMIName)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	for _, network := range vmi.Spec.Networks {
+		if network.Multus == nil {
+			continue
+		}
+		// multus network name can be <networkName> or <namespace>/<networkName>
+		namespace, name := vmi.Namespace, network.Multus.NetworkName
+		if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+			namespace, name = parts[0], parts[1]
+		}
+		nad, err := h.nadCache.Get(namespace, name)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		cnName := utils.GetNadLabel(nad, utils.KeyClusterNetworkLabel)
+		// the mgmt cluster network is set up on every node
+		if cnName == "" || cnName == utils.ManagementClusterNetworkName {
+			continue
+		}
+
+		vs, err := utils.GetVlanStatus(h.vsCache, cnName, node)
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("cluster network %s of nad %s/%s is not set up on node %s", cnName, namespace, name, node), nil
+		} else if err != nil {
+			return "", err
+		}
+		if err := utils.CheckVlanStatusCoversNad(vs, nad); err != nil {
+			return err.Error(), nil
+		}
+	}
+
+	return "", nil
+}
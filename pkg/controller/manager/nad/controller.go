@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"reflect"
 	"sync"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/go-ping/ping"
 	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	ctlbatchv1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/batch/v1"
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	"github.com/tidwall/sjson"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -23,10 +25,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io"
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
 
@@ -37,6 +41,7 @@ const (
 	jobServiceAccountName = "harvester-network-helper"
 	JobEnvNadNetwork      = "NAD_NETWORKS"
 	JobEnvDHCPServer      = "DHCP_SERVER"
+	JobEnvIPFamily        = "IP_FAMILY"
 
 	defaultInterface = "net1"
 
@@ -57,15 +62,18 @@ type checkMap struct {
 }
 
 type Handler struct {
-	namespace   string
-	helperImage string
-
-	jobClient ctlbatchv1.JobClient
-	jobCache  ctlbatchv1.JobCache
-	nadClient ctlcniv1.NetworkAttachmentDefinitionClient
-	nadCache  ctlcniv1.NetworkAttachmentDefinitionCache
-	cnClient  ctlnetworkv1.ClusterNetworkClient
-	cnCache   ctlnetworkv1.ClusterNetworkCache
+	namespace    string
+	helperImage  string
+	tenantLabels []string
+
+	jobClient     ctlbatchv1.JobClient
+	jobCache      ctlbatchv1.JobCache
+	nadClient     ctlcniv1.NetworkAttachmentDefinitionClient
+	nadCache      ctlcniv1.NetworkAttachmentDefinitionCache
+	nadController ctlcniv1.NetworkAttachmentDefinitionController
+	cnClient      ctlnetworkv1.ClusterNetworkClient
+	cnCache       ctlnetworkv1.ClusterNetworkCache
+	nsCache       ctlcorev1.NamespaceCache
 
 	*checkMap
 }
@@ -74,27 +82,38 @@ func Register(ctx context.Context, management *config.Management) error {
 	jobs := management.BatchFactory.Batch().V1().Job()
 	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
 	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
+	namespaces := management.CoreFactory.Core().V1().Namespace()
 
 	handler := &Handler{
-		namespace:   management.Options.Namespace,
-		helperImage: management.Options.HelperImage,
-		jobClient:   jobs,
-		jobCache:    jobs.Cache(),
-		nadClient:   nads,
-		nadCache:    nads.Cache(),
-		cnClient:    cns,
-		cnCache:     cns.Cache(),
+		namespace:     management.Options.Namespace,
+		helperImage:   management.Options.HelperImage,
+		tenantLabels:  management.Options.TenantLabels,
+		jobClient:     jobs,
+		jobCache:      jobs.Cache(),
+		nadClient:     nads,
+		nadCache:      nads.Cache(),
+		nadController: nads,
+		cnClient:      cns,
+		cnCache:       cns.Cache(),
 		checkMap: &checkMap{
 			items: make(map[nameWithNamespace]string),
 			mutex: new(sync.RWMutex),
 		},
 	}
 
+	// the namespaces are watched only if the tenant labels are propagated
+	if len(handler.tenantLabels) > 0 {
+		handler.nsCache = namespaces.Cache()
+	}
+
 	go handler.CheckConnectivityPeriodically()
 
 	nads.OnChange(ctx, ControllerName, handler.OnChange)
 	nads.OnRemove(ctx, ControllerName, handler.OnRemove)
 	cns.OnChange(ctx, ControllerName, handler.OnCNChange)
+	if len(handler.tenantLabels) > 0 {
+		namespaces.OnChange(ctx, ControllerName, handler.OnNamespaceChange)
+	}
 	return nil
 }
 
@@ -105,7 +124,7 @@ func (h Handler) OnCNChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.
 	}
 
 	// MTU annotation is not set
-	curMTU := cn.Annotations[utils.KeyUplinkMTU]
+	curMTU := annotations.UplinkMTU.Value(cn)
 	if curMTU == "" {
 		return nil, nil
 	}
@@ -117,9 +136,7 @@ func (h Handler) OnCNChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.
 		return nil, nil
 	}
 
-	nads, err := h.nadCache.List("", labels.Set(map[string]string{
-		utils.KeyClusterNetworkLabel: cn.Name,
-	}).AsSelector())
+	nads, err := h.nadCache.GetByIndex(utils.NadByBridgeIndex, utils.GetBridgeNameOfClusterNetwork(cn.Name))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list cluster network %v related nads, error %w", cn.Name, err)
 	}
@@ -151,6 +168,29 @@ func (h Handler) OnCNChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.
 	return nil, nil
 }
 
+// OnNamespaceChange hands the NADs of the namespace over to the nad handler to propagate the tenant labels
+func (h Handler) OnNamespaceChange(_ string, ns *corev1.Namespace) (*corev1.Namespace, error) {
+	if ns == nil || ns.DeletionTimestamp != nil {
+		return ns, nil
+	}
+
+	nads, err := h.nadCache.List(ns.Name, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, nad := range nads {
+		lbs := make(map[string]string, len(h.tenantLabels))
+		for key, value := range nad.Labels {
+			lbs[key] = value
+		}
+		if utils.SetTenantLabels(lbs, h.tenantLabels, ns.Labels) {
+			h.nadController.Enqueue(nad.Namespace, nad.Name)
+		}
+	}
+
+	return ns, nil
+}
+
 // nad manager controller ensures all labels and sync cn
 func (h Handler) OnChange(_ string, nad *cniv1.NetworkAttachmentDefinition) (*cniv1.NetworkAttachmentDefinition, error) {
 	if nad == nil || nad.DeletionTimestamp != nil {
@@ -180,10 +220,12 @@ func (h Handler) OnChange(_ string, nad *cniv1.NetworkAttachmentDefinition) (*cn
 		if err := h.clearJob(nad); err != nil {
 			return nil, err
 		}
+		metrics.ForgetDHCPProbe(nad.Namespace, nad.Name)
 	} else {
 		if err := h.EnsureJob2GetLayer3NetworkInfo(nad, netconf); err != nil {
 			return nil, err
 		}
+		observeDHCPProbe(nad, netconf)
 	}
 	// nad change triggers the re-compute of cn's vlanset
 	if err := h.UpdateClusterNetworkVlanSet(nad); err != nil {
@@ -209,6 +251,7 @@ func (h Handler) OnRemove(_ string, nad *cniv1.NetworkAttachmentDefinition) (*cn
 	if err := h.clearJob(nad); err != nil {
 		return nil, err
 	}
+	metrics.ForgetDHCPProbe(nad.Namespace, nad.Name)
 
 	// nad change triggers the re-compute of cn's vlanset
 	// due to the existing of trunk mode nad, deleting any nad might not cause changes on the birdge's vlan
@@ -226,6 +269,30 @@ func (h Handler) OnRemove(_ string, nad *cniv1.NetworkAttachmentDefinition) (*cn
 	return nad, nil
 }
 
+// observeDHCPProbe exports the result of the DHCP probe the helper job recorded on the nad, the probe of an outdated
+// route is of the previous VLAN and forgotten until the new job probes again
+func observeDHCPProbe(nad *cniv1.NetworkAttachmentDefinition, netconf *utils.NetConf) {
+	networkConf, err := utils.NewLayer3NetworkConfFromNad(nad)
+	if err != nil || networkConf.Mode != utils.Auto || networkConf.Outdated || networkConf.DHCPProbe == nil {
+		metrics.ForgetDHCPProbe(nad.Namespace, nad.Name)
+		return
+	}
+
+	probe := networkConf.DHCPProbe
+	metrics.ObserveDHCPProbe(metrics.DHCPProbe{
+		Namespace:      nad.Namespace,
+		NAD:            nad.Name,
+		ClusterNetwork: utils.GetNadLabel(nad, utils.KeyClusterNetworkLabel),
+		VID:            uint16(netconf.Vlan), //nolint:gosec
+		Server:         probe.ServerIP,
+		Subnet:         networkConf.CIDR,
+		Gateway:        networkConf.Gateway,
+		Succeeded:      probe.Succeeded(),
+		Latency:        time.Duration(probe.LatencyMs) * time.Millisecond,
+		Time:           probe.ProbedAt(),
+	})
+}
+
 // if nad is updated, return true
 func (h Handler) ensureLabels(nad *cniv1.NetworkAttachmentDefinition) (*utils.NetConf, bool, error) {
 	// always recheck the labels to ensure they are correct
@@ -253,6 +320,13 @@ func (h Handler) ensureLabels(nad *cniv1.NetworkAttachmentDefinition) (*utils.Ne
 	} else {
 		utils.SetNadLabel(nadCopy, utils.KeyNetworkReady, utils.ValueFalse)
 	}
+	if len(h.tenantLabels) > 0 {
+		ns, err := h.nsCache.Get(nad.Namespace)
+		if err != nil {
+			return nil, false, err
+		}
+		utils.SetTenantLabels(nadCopy.Labels, h.tenantLabels, ns.Labels)
+	}
 	if reflect.DeepEqual(nad.Labels, nadCopy.Labels) {
 		return netconf, false, nil
 	}
@@ -278,7 +352,7 @@ func (h Handler) UpdateClusterNetworkVlanSet(nad *cniv1.NetworkAttachmentDefinit
 		return nil
 	}
 
-	vids, err := utils.GeVlanIDSetFromClusterNetwork(cn.Name, h.nadCache)
+	vids, err := utils.GetVlanIDSetOfClusterNetwork(cn, h.nadCache)
 	if err != nil {
 		logrus.Infof("cluster network %s failed to get vlanset %s", cn.Name, err.Error())
 		return err
@@ -428,7 +502,7 @@ func (h Handler) createOrUpdateJob(nad *cniv1.NetworkAttachmentDefinition, l2net
 		}
 
 		// create job
-		job, err = constructJob(nil, h.namespace, h.helperImage, nad, l2netconf, l3netconf)
+		job, err = constructJob(nil, h.namespace, h.helperImage, h.tenantLabels, nad, l2netconf, l3netconf)
 		if err != nil {
 			return err
 		}
@@ -445,7 +519,7 @@ func (h Handler) createOrUpdateJob(nad *cniv1.NetworkAttachmentDefinition, l2net
 	}
 
 	// is already existing, update if some fields are invalid
-	jobCopy, err := constructJob(job, h.namespace, h.helperImage, nad, l2netconf, l3netconf)
+	jobCopy, err := constructJob(job, h.namespace, h.helperImage, h.tenantLabels, nad, l2netconf, l3netconf)
 	if err != nil {
 		return err
 	}
@@ -462,7 +536,7 @@ func (h Handler) createOrUpdateJob(nad *cniv1.NetworkAttachmentDefinition, l2net
 	return nil
 }
 
-func constructJob(cur *batchv1.Job, namespace, image string, nad *cniv1.NetworkAttachmentDefinition, netconf *utils.NetConf, l3netconf *utils.Layer3NetworkConf) (*batchv1.Job, error) {
+func constructJob(cur *batchv1.Job, namespace, image string, tenantLabels []string, nad *cniv1.NetworkAttachmentDefinition, netconf *utils.NetConf, l3netconf *utils.Layer3NetworkConf) (*batchv1.Job, error) {
 	job := &batchv1.Job{}
 	if cur != nil {
 		job = cur.DeepCopy()
@@ -479,6 +553,14 @@ func constructJob(cur *batchv1.Job, namespace, image string, nad *cniv1.NetworkA
 		utils.SetDHCPInfo2JobLabels(job.Labels, netconf, l3netconf)
 	}
 
+	// the helper job is charged to the tenant of the nad
+	if len(tenantLabels) > 0 {
+		if job.Labels == nil {
+			job.Labels = make(map[string]string)
+		}
+		utils.SetTenantLabels(job.Labels, tenantLabels, nad.Labels)
+	}
+
 	selectedNetworks, err := utils.NadSelectedNetworks([]cniv1.NetworkSelectionElement{
 		{
 			InterfaceRequest: defaultInterface,
@@ -514,6 +596,10 @@ func constructJob(cur *batchv1.Job, namespace, image string, nad *cniv1.NetworkA
 					Name:  JobEnvDHCPServer,
 					Value: l3netconf.GetDHCPServerIPAddr(),
 				},
+				{
+					Name:  JobEnvIPFamily,
+					Value: string(l3netconf.GetIPFamily()),
+				},
 			},
 			ImagePullPolicy: corev1.PullIfNotPresent,
 		},
@@ -551,24 +637,19 @@ func (h Handler) CheckConnectivityPeriodically() {
 
 	for range ticker.C {
 		h.mutex.RLock()
-		for nn, gw := range h.items {
-			go func(nn nameWithNamespace, gw string) {
-				if err := h.checkConnectivity(nn.namespace, nn.name, gw); err != nil {
+		for nn := range h.items {
+			go func(nn nameWithNamespace) {
+				if err := h.checkConnectivity(nn.namespace, nn.name); err != nil {
 					logrus.Error(err)
 					return
 				}
-			}(nn, gw)
+			}(nn)
 		}
 		h.mutex.RUnlock()
 	}
 }
 
-func (h Handler) checkConnectivity(namespace, name, gw string) error {
-	connectivity, err := pingGW(gw)
-	if err != nil {
-		return err
-	}
-
+func (h Handler) checkConnectivity(namespace, name string) error {
 	nad, err := h.nadCache.Get(namespace, name)
 	if err != nil {
 		return fmt.Errorf("get cache of %s/%s failed, error: %s", namespace, name, err)
@@ -582,29 +663,37 @@ func (h Handler) checkConnectivity(namespace, name, gw string) error {
 		}
 	}
 
-	if networkConf.Connectivity == connectivity {
-		return nil
-	}
-	networkConf.Connectivity = connectivity
-
-	return h.updateNetworkConf(nad, networkConf)
+	return h.initializeConnectivity(nad, networkConf)
 }
 
+// initializeConnectivity pings the gateways of the network, both of them for the dual stack
 func (h Handler) initializeConnectivity(nad *cniv1.NetworkAttachmentDefinition, networkConf *utils.Layer3NetworkConf) error {
-	connectivity, err := pingGW(networkConf.Gateway)
+	connectivity, err := pingGW(networkConf.Gateway, networkConf.Connectivity)
 	if err != nil {
 		return err
 	}
+	ipv6Connectivity := networkConf.IPv6Connectivity
+	if networkConf.IPv6Gateway != "" {
+		if ipv6Connectivity, err = pingGW(networkConf.IPv6Gateway, ipv6Connectivity); err != nil {
+			return err
+		}
+	}
 
-	if networkConf.Connectivity == connectivity {
+	if networkConf.Connectivity == connectivity && networkConf.IPv6Connectivity == ipv6Connectivity {
 		return nil
 	}
 	networkConf.Connectivity = connectivity
+	networkConf.IPv6Connectivity = ipv6Connectivity
 
 	return h.updateNetworkConf(nad, networkConf)
 }
 
-func pingGW(gw string) (utils.Connectivity, error) {
+// pingGW returns the connectivity of the gateway, the current one is kept for a link-local IPv6 gateway, which can't be
+// pinged from outside the network and is reported reachable by the helper job as it has advertised itself
+func pingGW(gw string, current utils.Connectivity) (utils.Connectivity, error) {
+	if ip := net.ParseIP(gw); ip != nil && ip.IsLinkLocalUnicast() {
+		return current, nil
+	}
 	connectivity := utils.PingFailed
 
 	pinger, err := ping.NewPinger(gw)
@@ -0,0 +1,294 @@
+package networkusage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const controllerName = "harvester-network-manager-network-usage-controller"
+
+// Handler counts the pods attached to the NADs of a cluster network according to their multus network status and
+// records the NADs in use in the cluster network status. The VLAN IDs of a NAD deleted prematurely, e.g. with its
+// namespace before the VMs are torn down, are kept on the uplinks until the last consumer is gone. Likewise the
+// previous VLAN IDs of a NAD whose VLAN ID is changed are kept until the consumers attached before are gone.
+type Handler struct {
+	cnClient     ctlnetworkv1.ClusterNetworkClient
+	cnCache      ctlnetworkv1.ClusterNetworkCache
+	cnController ctlnetworkv1.ClusterNetworkController
+	nadCache     ctlcniv1.NetworkAttachmentDefinitionCache
+	podCache     ctlcorev1.PodCache
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
+	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	pods := management.CoreFactory.Core().V1().Pod()
+
+	handler := &Handler{
+		cnClient:     cns,
+		cnCache:      cns.Cache(),
+		cnController: cns,
+		nadCache:     nads.Cache(),
+		podCache:     pods.Cache(),
+	}
+	// Indexer must be added before starting the informer
+	handler.podCache.AddIndexer(utils.PodByNetworkIndex, podByNetwork)
+
+	cns.OnChange(ctx, controllerName, handler.OnChange)
+	nads.OnChange(ctx, controllerName, handler.OnNadChange)
+	pods.OnChange(ctx, controllerName, handler.OnPodChange)
+
+	return nil
+}
+
+func podByNetwork(pod *corev1.Pod) ([]string, error) {
+	networks, err := utils.AttachedNetworks(pod)
+	if err != nil {
+		// don't fail the informer because of an invalid annotation
+		logrus.Warnf("skip indexing pod %s/%s, error: %s", pod.Namespace, pod.Name, err.Error())
+		return nil, nil
+	}
+	return networks, nil
+}
+
+// OnNadChange hands the cluster network of the NAD over to the cluster network handler, the NAD is nil if it's deleted
+func (h Handler) OnNadChange(_ string, nad *cniv1.NetworkAttachmentDefinition) (*cniv1.NetworkAttachmentDefinition, error) {
+	if nad == nil {
+		return nil, h.enqueueClusterNetworksInUse()
+	}
+	if cnName := utils.GetNadLabel(nad, utils.KeyClusterNetworkLabel); cnName != "" {
+		h.cnController.Enqueue(cnName)
+	}
+
+	return nad, nil
+}
+
+// OnPodChange hands the cluster networks of the NADs the pod is attached to over to the cluster network handler, the
+// pod is nil if it's deleted
+func (h Handler) OnPodChange(_ string, pod *corev1.Pod) (*corev1.Pod, error) {
+	if pod == nil || pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded ||
+		pod.Status.Phase == corev1.PodFailed {
+		return pod, h.enqueueClusterNetworksInUse()
+	}
+
+	networks, err := utils.AttachedNetworks(pod)
+	if err != nil {
+		logrus.Warnf("skip counting the networks of pod %s/%s, error: %s", pod.Namespace, pod.Name, err.Error())
+		return pod, nil
+	}
+	for _, network := range networks {
+		namespace, name, _ := strings.Cut(network, "/")
+		nad, err := h.nadCache.Get(namespace, name)
+		if apierrors.IsNotFound(err) {
+			if err := h.enqueueClusterNetworksInUse(); err != nil {
+				return nil, err
+			}
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if cnName := utils.GetNadLabel(nad, utils.KeyClusterNetworkLabel); cnName != "" {
+			h.cnController.Enqueue(cnName)
+		}
+	}
+
+	return pod, nil
+}
+
+func (h Handler) OnChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn == nil || cn.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	usages, err := h.networkUsages(cn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count the network usages of cluster network %s, error: %w", cn.Name, err)
+	}
+	if reflect.DeepEqual(usages, cn.Status.InUse) {
+		return cn, nil
+	}
+
+	cnCopy := cn.DeepCopy()
+	cnCopy.Status.InUse = usages
+	vids, err := utils.GetVlanIDSetOfClusterNetwork(cnCopy, h.nadCache)
+	if err != nil {
+		return nil, err
+	}
+	vidstr, vidhash := vids.VidSetToStringHash()
+
+	return utils.UpdateClusterNetwork(h.cnClient, cn, func(cn *networkv1.ClusterNetwork) {
+		cn.Status.InUse = usages
+		if !utils.AreClusterNetworkVlanAnnotationsUnchanged(cn, vidstr, vidhash) {
+			utils.SetClusterNetworkVlanAnnotations(cn, vidstr, vidhash)
+		}
+	})
+}
+
+// networkUsages returns the NADs of the cluster network having consumers in the order of their names, followed by
+// the deleted NADs still having consumers
+func (h Handler) networkUsages(cn *networkv1.ClusterNetwork) ([]networkv1.NetworkUsage, error) {
+	nads, err := utils.NewNadGetter(h.nadCache).ListNadsOnClusterNetwork(cn.Name)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(nads, func(i, j int) bool {
+		return nads[i].Namespace+"/"+nads[i].Name < nads[j].Namespace+"/"+nads[j].Name
+	})
+
+	recorded := make(map[string]*networkv1.NetworkUsage, len(cn.Status.InUse))
+	for i := range cn.Status.InUse {
+		recorded[cn.Status.InUse[i].NAD] = &cn.Status.InUse[i]
+	}
+
+	var usages []networkv1.NetworkUsage
+	existing := make(map[string]bool, len(nads))
+	for _, nad := range nads {
+		name := nad.Namespace + "/" + nad.Name
+		existing[name] = true
+		consumers, err := h.consumers(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(consumers) == 0 {
+			continue
+		}
+		vids, err := utils.VIDsOfNad(nad)
+		if err != nil {
+			return nil, err
+		}
+		usage := networkv1.NetworkUsage{
+			NAD:       name,
+			VIDs:      vids,
+			Deleted:   nad.DeletionTimestamp != nil,
+			Consumers: consumers,
+		}
+		trackStaleConsumers(cn.Name, &usage, recorded[name])
+		usages = append(usages, usage)
+	}
+
+	for _, usage := range cn.Status.InUse {
+		if existing[usage.NAD] {
+			continue
+		}
+		consumers, err := h.consumers(usage.NAD)
+		if err != nil {
+			return nil, err
+		}
+		if len(consumers) == 0 {
+			logrus.Infof("cluster network %s releases vids %v of deleted nad %s", cn.Name, usage.VIDs, usage.NAD)
+			continue
+		}
+		deleted := networkv1.NetworkUsage{
+			NAD:       usage.NAD,
+			VIDs:      usage.VIDs,
+			Deleted:   true,
+			Consumers: consumers,
+		}
+		trackStaleConsumers(cn.Name, &deleted, &usage)
+		usages = append(usages, deleted)
+	}
+
+	return usages, nil
+}
+
+// trackStaleConsumers records the consumers attached before the VLAN IDs of the NAD are changed. They keep using the
+// previous VLAN IDs, which stay on the uplinks, until they are restarted or migrated, instead of being cut off.
+func trackStaleConsumers(cnName string, usage, recorded *networkv1.NetworkUsage) {
+	if recorded == nil {
+		return
+	}
+
+	previousVIDs, stale := recorded.PreviousVIDs, recorded.StaleConsumers
+	if !reflect.DeepEqual(usage.VIDs, recorded.VIDs) {
+		previousVIDs = mergeVIDs(previousVIDs, recorded.VIDs)
+		stale = append(append([]string{}, stale...), recorded.Consumers...)
+	}
+
+	current := make(map[string]bool, len(usage.Consumers))
+	for _, consumer := range usage.Consumers {
+		current[consumer] = true
+	}
+	var staleConsumers []string
+	for _, consumer := range stale {
+		if current[consumer] {
+			staleConsumers = append(staleConsumers, consumer)
+			// the consumer is recorded only once if the VLAN IDs are changed several times
+			delete(current, consumer)
+		}
+	}
+	sort.Strings(staleConsumers)
+
+	if len(staleConsumers) == 0 || len(previousVIDs) == 0 {
+		if len(recorded.StaleConsumers) > 0 {
+			logrus.Infof("cluster network %s releases previous vids %v of nad %s", cnName, recorded.PreviousVIDs, usage.NAD)
+		}
+		return
+	}
+	if !reflect.DeepEqual(staleConsumers, recorded.StaleConsumers) {
+		logrus.Warnf("pods %v still use the previous vids %v of nad %s on cluster network %s, restart or migrate "+
+			"them to use vids %v", staleConsumers, previousVIDs, usage.NAD, cnName, usage.VIDs)
+	}
+	usage.PreviousVIDs = previousVIDs
+	usage.StaleConsumers = staleConsumers
+}
+
+// mergeVIDs returns the union of the VIDs in ascending order
+func mergeVIDs(a, b []uint16) []uint16 {
+	set := make(map[uint16]bool, len(a)+len(b))
+	merged := make([]uint16, 0, len(a)+len(b))
+	for _, vid := range append(append([]uint16{}, a...), b...) {
+		if !set[vid] {
+			set[vid] = true
+			merged = append(merged, vid)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i] < merged[j] })
+
+	return merged
+}
+
+func (h Handler) consumers(nad string) ([]string, error) {
+	pods, err := h.podCache.GetByIndex(utils.PodByNetworkIndex, nad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pods via %s %s, error: %w", utils.PodByNetworkIndex, nad, err)
+	}
+
+	consumers := make([]string, 0, len(pods))
+	// the terminating pods keep consuming the network until they are gone
+	for _, pod := range pods {
+		consumers = append(consumers, pod.Namespace+"/"+pod.Name)
+	}
+	sort.Strings(consumers)
+
+	return consumers, nil
+}
+
+func (h Handler) enqueueClusterNetworksInUse() error {
+	cns, err := h.cnCache.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, cn := range cns {
+		if len(cn.Status.InUse) > 0 {
+			h.cnController.Enqueue(cn.Name)
+		}
+	}
+
+	return nil
+}
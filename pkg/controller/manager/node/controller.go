@@ -12,9 +12,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/matcher"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
 
@@ -113,8 +115,8 @@ func (h Handler) updateHostNetworkConfigMatchedNodeAnnotation(hnc *networkv1.Hos
 	}
 
 	s := mapset.NewSet[string]()
-	if hnc.Annotations != nil && hnc.Annotations[utils.KeyMatchedNodes] != "" {
-		if err := s.UnmarshalJSON([]byte(hnc.Annotations[utils.KeyMatchedNodes])); err != nil {
+	if value := annotations.MatchedNodes.Value(hnc); value != "" {
+		if err := s.UnmarshalJSON([]byte(value)); err != nil {
 			return err
 		}
 	}
@@ -138,7 +140,7 @@ func (h Handler) updateHostNetworkConfigMatchedNodeAnnotation(hnc *networkv1.Hos
 	if err != nil {
 		return err
 	}
-	hncCopy.Annotations[utils.KeyMatchedNodes] = string(bytes)
+	annotations.MatchedNodes.Set(hncCopy, string(bytes))
 	if _, err := h.hostNetworkConfigClient.Update(hncCopy); err != nil {
 		return err
 	}
@@ -147,20 +149,14 @@ func (h Handler) updateHostNetworkConfigMatchedNodeAnnotation(hnc *networkv1.Hos
 }
 
 func (h Handler) updateMatchedNodeAnnotation(vc *networkv1.VlanConfig, node *corev1.Node) error {
-	selector, err := utils.NewSelector(vc.Spec.NodeSelector)
+	nodes, err := matcher.MatchedNodesOf(vc)
 	if err != nil {
 		return err
 	}
 
-	s := mapset.NewSet[string]()
-	if vc.Annotations != nil && vc.Annotations[utils.KeyMatchedNodes] != "" {
-		if err := s.UnmarshalJSON([]byte(vc.Annotations[utils.KeyMatchedNodes])); err != nil {
-			return err
-		}
-	}
-
+	s := mapset.NewSet(nodes...)
 	newSet := s.Clone()
-	if ok := selector.Matches(labels.Set(node.Labels)); ok {
+	if matcher.MatchesNode(vc.Spec.NodeSelector, node.Labels) {
 		newSet.Add(node.Name)
 	} else {
 		newSet.Remove(node.Name)
@@ -174,11 +170,11 @@ func (h Handler) updateMatchedNodeAnnotation(vc *networkv1.VlanConfig, node *cor
 	if vcCopy.Annotations == nil {
 		vcCopy.Annotations = map[string]string{}
 	}
-	bytes, err := newSet.MarshalJSON()
+	value, err := matcher.EncodeMatchedNodes(newSet.ToSlice())
 	if err != nil {
 		return err
 	}
-	vcCopy.Annotations[utils.KeyMatchedNodes] = string(bytes)
+	annotations.MatchedNodes.Set(vcCopy, value)
 	if _, err := h.vcClient.Update(vcCopy); err != nil {
 		return err
 	}
@@ -240,40 +236,40 @@ func (h Handler) removeNodeFromVlanConfig(nodeName string) error {
 }
 
 func (h Handler) removeNodeFromOneVlanConfig(vc *networkv1.VlanConfig, nodeName string) error {
-	if vc.Annotations == nil || vc.Annotations[utils.KeyMatchedNodes] == "" {
-		return nil
-	}
-
-	s := mapset.NewSet[string]()
-	if err := s.UnmarshalJSON([]byte(vc.Annotations[utils.KeyMatchedNodes])); err != nil {
+	nodes, err := matcher.MatchedNodesOf(vc)
+	if err != nil {
 		return err
 	}
 
+	s := mapset.NewSet(nodes...)
 	if s.Contains(nodeName) {
 		s.Remove(nodeName)
-		bytes, err := s.MarshalJSON()
+		value, err := matcher.EncodeMatchedNodes(s.ToSlice())
 		if err != nil {
 			return err
 		}
 		vcCopy := vc.DeepCopy()
-		vcCopy.Annotations[utils.KeyMatchedNodes] = string(bytes)
+		annotations.MatchedNodes.Set(vcCopy, value)
 		if _, err := h.vcClient.Update(vcCopy); err != nil {
 			return err
 		}
 
 		vss, err := h.vsCache.List(labels.Set{
-			utils.KeyVlanConfigLabel: vc.Name,
-			utils.KeyNodeLabel:       nodeName,
+			utils.KeyVlanConfigLabel: utils.LabelValue(vc.Name),
+			utils.KeyNodeLabel:       utils.LabelValue(nodeName),
 		}.AsSelector())
 		if err != nil {
 			return err
 		}
-		if len(vss) == 0 {
+		for _, vs := range vss {
+			if vs.Status.Node != nodeName || vs.Status.VlanConfig != vc.Name {
+				continue
+			}
+			if err := h.vsClient.Delete(vs.Name, &metav1.DeleteOptions{}); err != nil {
+				return err
+			}
 			return nil
 		}
-		if err := h.vsClient.Delete(vss[0].Name, &metav1.DeleteOptions{}); err != nil {
-			return err
-		}
 	}
 
 	return nil
@@ -2,15 +2,31 @@ package manager
 
 import (
 	"github.com/harvester/harvester-network-controller/pkg/config"
+	"github.com/harvester/harvester-network-controller/pkg/controller/manager/annotation"
 	"github.com/harvester/harvester-network-controller/pkg/controller/manager/clusternetwork"
+	"github.com/harvester/harvester-network-controller/pkg/controller/manager/deschedule"
+	"github.com/harvester/harvester-network-controller/pkg/controller/manager/migration"
 	"github.com/harvester/harvester-network-controller/pkg/controller/manager/nad"
+	"github.com/harvester/harvester-network-controller/pkg/controller/manager/networkusage"
 	"github.com/harvester/harvester-network-controller/pkg/controller/manager/node"
+	"github.com/harvester/harvester-network-controller/pkg/controller/manager/switchport"
+	"github.com/harvester/harvester-network-controller/pkg/controller/manager/ttl"
+	"github.com/harvester/harvester-network-controller/pkg/controller/manager/vidcheck"
 	"github.com/harvester/harvester-network-controller/pkg/controller/manager/vlanconfig"
+	"github.com/harvester/harvester-network-controller/pkg/settings"
 )
 
 var RegisterFuncList = []config.RegisterFunc{
+	settings.Register,
 	nad.Register,
 	vlanconfig.Register,
 	node.Register,
 	clusternetwork.Register,
+	switchport.Register,
+	networkusage.Register,
+	annotation.Register,
+	migration.Register,
+	deschedule.Register,
+	ttl.Register,
+	vidcheck.Register,
 }
@@ -0,0 +1,314 @@
+package switchport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlkubevirtv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubevirt.io/v1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/hooks"
+	"github.com/harvester/harvester-network-controller/pkg/localarea"
+	"github.com/harvester/harvester-network-controller/pkg/matcher"
+	"github.com/harvester/harvester-network-controller/pkg/switchport"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	controllerName = "harvester-network-manager-switchport-controller"
+	// noEndpoint is recorded as the endpoint the state of a node is posted to if no external API is configured
+	noEndpoint = "none"
+)
+
+// Handler exports the desired switch-side state of a node, i.e. the VLAN IDs required on its uplinks, to a configmap
+// for audit, and posts it to the external API configured in the Harvester setting
+// network-controller-switchport-automation whenever it changes, i.e. the vlanconfigs matching the node, their cluster
+// networks and NADs, the VMs on the node or the LLDP neighbors of the node change. The state is posted again after the
+// manager restarts or the setting changes to another URL, the external API is expected to be idempotent.
+type Handler struct {
+	ctx            context.Context
+	namespace      string
+	setting        *hooks.SettingInformer
+	nodeController ctlcorev1.NodeController
+	cmClient       ctlcorev1.ConfigMapClient
+	cnCache        ctlnetworkv1.ClusterNetworkCache
+	vcCache        ctlnetworkv1.VlanConfigCache
+	vsCache        ctlnetworkv1.VlanStatusCache
+	nadCache       ctlcniv1.NetworkAttachmentDefinitionCache
+	vmiCache       ctlkubevirtv1.VirtualMachineInstanceCache
+	localAreas     *localarea.Sources
+
+	// the hash of the state exported last time per node, and the one posted last time together with the endpoint
+	exported map[string]string
+	posted   map[string]string
+	mutex    *sync.Mutex
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	nodes := management.CoreFactory.Core().V1().Node()
+	vcs := management.HarvesterNetworkFactory.Network().V1beta1().VlanConfig()
+	vss := management.HarvesterNetworkFactory.Network().V1beta1().VlanStatus()
+	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
+	vmis := management.KubevirtFactory.Kubevirt().V1().VirtualMachineInstance()
+	cms := management.CoreFactory.Core().V1().ConfigMap()
+
+	handler := &Handler{
+		ctx:            ctx,
+		namespace:      management.Options.Namespace,
+		setting:        hooks.NewSettingInformer(management.DynamicClient, switchport.SettingName),
+		nodeController: nodes,
+		cmClient:       cms,
+		cnCache:        cns.Cache(),
+		vcCache:        vcs.Cache(),
+		vsCache:        vss.Cache(),
+		nadCache:       nads.Cache(),
+		vmiCache:       vmis.Cache(),
+		localAreas:     management.LocalAreas,
+		exported:       make(map[string]string),
+		posted:         make(map[string]string),
+		mutex:          new(sync.Mutex),
+	}
+	// Indexer must be added before starting the informer
+	handler.vmiCache.AddIndexer(vmiByNodeIndex, vmiByNode)
+
+	nodes.OnChange(ctx, controllerName, handler.OnNodeChange)
+	vcs.OnChange(ctx, controllerName, handler.OnVlanConfigChange)
+	nads.OnChange(ctx, controllerName, handler.OnNadChange)
+	cns.OnChange(ctx, controllerName, handler.OnClusterNetworkChange)
+	vmis.OnChange(ctx, controllerName, handler.OnVmiChange)
+	// the states of all the nodes are posted again to the external API the setting changes to
+	if err := handler.setting.OnChange(handler.enqueueSyncedNodes); err != nil {
+		return err
+	}
+	handler.setting.Run(ctx)
+
+	return nil
+}
+
+// OnVlanConfigChange hands the nodes matched by the vlanconfig over to the node handler, the vlanconfig is nil if
+// it's deleted
+func (h Handler) OnVlanConfigChange(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanConfig, error) {
+	if vc == nil {
+		h.enqueueSyncedNodes()
+		return nil, nil
+	}
+
+	if err := h.enqueueMatchedNodes(vc); err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+// OnNadChange hands the nodes of the cluster network over to the node handler as the VLAN IDs to trunk may change
+func (h Handler) OnNadChange(_ string, nad *cniv1.NetworkAttachmentDefinition) (*cniv1.NetworkAttachmentDefinition, error) {
+	if nad == nil {
+		h.enqueueSyncedNodes()
+		return nil, nil
+	}
+
+	cnName := utils.GetNadLabel(nad, utils.KeyClusterNetworkLabel)
+	if cnName == "" {
+		return nad, nil
+	}
+	vcs, err := h.vcCache.GetByIndex(utils.VlanConfigByClusterNetworkIndex, cnName)
+	if err != nil {
+		return nil, err
+	}
+	for _, vc := range vcs {
+		if err := h.enqueueMatchedNodes(vc); err != nil {
+			return nil, err
+		}
+	}
+
+	return nad, nil
+}
+
+// OnClusterNetworkChange hands the nodes of the cluster network over to the node handler, e.g. as its static VLAN IDs
+// change
+func (h Handler) OnClusterNetworkChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn == nil {
+		h.enqueueSyncedNodes()
+		return nil, nil
+	}
+
+	vcs, err := h.vcCache.GetByIndex(utils.VlanConfigByClusterNetworkIndex, cn.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, vc := range vcs {
+		if err := h.enqueueMatchedNodes(vc); err != nil {
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+func (h Handler) OnNodeChange(_ string, node *corev1.Node) (*corev1.Node, error) {
+	if node == nil || node.DeletionTimestamp != nil {
+		return node, nil
+	}
+	if utils.HasWitnessNodeLabelKey(node.Labels) {
+		return node, nil
+	}
+
+	state, err := h.desiredState(node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the switch port state of node %s, error: %w", node.Name, err)
+	}
+	body, hash, err := state.Encode()
+	if err != nil {
+		return nil, err
+	}
+	if h.syncedHash(h.exported, node.Name) != hash {
+		if err := h.exportState(node, body); err != nil {
+			return nil, fmt.Errorf("failed to export the switch port state of node %s, error: %w", node.Name, err)
+		}
+		h.setSyncedHash(h.exported, node.Name, hash)
+	}
+
+	value, err := h.setting.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get setting %s, error: %w", switchport.SettingName, err)
+	}
+	cfg, err := switchport.Parse(value)
+	if err != nil {
+		// wait for the setting to be fixed, which triggers the node handler again
+		logrus.Errorf("skip posting the switch port state of node %s, error: %s", node.Name, err.Error())
+		return node, nil
+	}
+	endpoint := noEndpoint
+	if cfg != nil {
+		endpoint = cfg.URL
+	}
+	postedHash := hash + "@" + endpoint
+	if h.syncedHash(h.posted, node.Name) == postedHash {
+		return node, nil
+	}
+	if cfg == nil {
+		// skip the node until either its state or the setting changes
+		h.setSyncedHash(h.posted, node.Name, postedHash)
+		return node, nil
+	}
+
+	ctx, cancel := context.WithTimeout(h.ctx, cfg.Timeout())
+	defer cancel()
+	if err := cfg.Post(ctx, body); err != nil {
+		return nil, fmt.Errorf("failed to post the switch port state of node %s to %s, error: %w", node.Name, cfg.URL, err)
+	}
+	logrus.Infof("posted the switch port state of node %s with %d uplinks", node.Name, len(state.Uplinks))
+	h.setSyncedHash(h.posted, node.Name, postedHash)
+
+	return node, nil
+}
+
+func (h Handler) desiredState(node *corev1.Node) (*switchport.State, error) {
+	neighbors, err := switchport.ParseNeighbors(node.Annotations[utils.KeyLLDPNeighbors])
+	if err != nil {
+		// the switch ports are reported unknown
+		logrus.Warnf("ignore the LLDP neighbors of node %s, error: %s", node.Name, err.Error())
+		neighbors = map[string]switchport.Neighbor{}
+	}
+
+	vcs, err := h.vcCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	candidates := map[string][]*networkv1.VlanConfig{}
+	for _, vc := range vcs {
+		if isMatched, err := matcher.IsMatched(vc, node.Name); err != nil {
+			return nil, err
+		} else if isMatched {
+			candidates[vc.Spec.ClusterNetwork] = append(candidates[vc.Spec.ClusterNetwork], vc)
+		}
+	}
+
+	inUseVIDs, err := h.inUseVIDs(node.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &switchport.State{Node: node.Name, Uplinks: []switchport.Uplink{}}
+	for cnName, cnCandidates := range candidates {
+		active := ""
+		vs, err := utils.GetVlanStatus(h.vsCache, cnName, node.Name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		} else if err == nil {
+			active = vs.Status.VlanConfig
+		}
+		vc := matcher.Resolve(cnCandidates, active)
+		if vc == nil {
+			continue
+		}
+
+		cn, err := h.cnCache.Get(cnName)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		vidSet, err := h.localAreas.VlanIDSetOf(cn)
+		if err != nil {
+			return nil, err
+		}
+		vids := []uint16{}
+		if err := vidSet.WalkVIDs(cnName, func(vid uint16) error {
+			vids = append(vids, vid)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		state.Uplinks = append(state.Uplinks, switchport.NewUplink(vc, vids, inUseVIDs[cnName], neighbors))
+	}
+	state.Sort()
+
+	return state, nil
+}
+
+func (h Handler) enqueueMatchedNodes(vc *networkv1.VlanConfig) error {
+	nodes, err := matcher.MatchedNodesOf(vc)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		h.nodeController.Enqueue(node)
+	}
+	// the nodes the vlanconfig doesn't match any more
+	h.enqueueSyncedNodes()
+
+	return nil
+}
+
+// enqueueSyncedNodes enqueues the nodes whose state has been exported, i.e. all nodes known to the handler
+func (h Handler) enqueueSyncedNodes() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for node := range h.exported {
+		h.nodeController.Enqueue(node)
+	}
+}
+
+func (h Handler) syncedHash(synced map[string]string, node string) string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return synced[node]
+}
+
+func (h Handler) setSyncedHash(synced map[string]string, node, hash string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	synced[node] = hash
+}
@@ -0,0 +1,146 @@
+package switchport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	vmiByNodeIndex = "vm.harvesterhci.io/vmi-by-node"
+
+	// RequiredVlansConfigMapPrefix prefixes the configmaps holding the VLAN IDs required on the uplinks of the nodes
+	RequiredVlansConfigMapPrefix = "required-vlans-"
+	// RequiredVlansKey is the key of the state in JSON in the configmap
+	RequiredVlansKey = "required-vlans.json"
+)
+
+func vmiByNode(vmi *kubevirtv1.VirtualMachineInstance) ([]string, error) {
+	if vmi.Status.NodeName == "" || vmi.IsFinal() {
+		return nil, nil
+	}
+	return []string{vmi.Status.NodeName}, nil
+}
+
+// OnVmiChange hands the node of the VMI over to the node handler as the VLAN IDs in use on the node may change, the VMI
+// is nil if it's deleted
+func (h Handler) OnVmiChange(_ string, vmi *kubevirtv1.VirtualMachineInstance) (*kubevirtv1.VirtualMachineInstance, error) {
+	if vmi == nil {
+		h.enqueueSyncedNodes()
+		return nil, nil
+	}
+	if vmi.Status.NodeName != "" {
+		h.nodeController.Enqueue(vmi.Status.NodeName)
+	}
+	// the source node of a migrated VMI
+	if vmi.Status.MigrationState != nil && vmi.Status.MigrationState.SourceNode != "" {
+		h.nodeController.Enqueue(vmi.Status.MigrationState.SourceNode)
+	}
+
+	return vmi, nil
+}
+
+// inUseVIDs returns the VLAN IDs of the NADs the VMIs running on the node are attached to per cluster network
+func (h Handler) inUseVIDs(node string) (map[string][]uint16, error) {
+	vmis, err := h.vmiCache.GetByIndex(vmiByNodeIndex, node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vmis via %s %s, error: %w", vmiByNodeIndex, node, err)
+	}
+
+	nads := map[string]map[string]*cniv1.NetworkAttachmentDefinition{}
+	for _, vmi := range vmis {
+		for _, network := range vmi.Spec.Networks {
+			if network.Multus == nil {
+				continue
+			}
+			// multus network name can be <networkName> or <namespace>/<networkName>
+			namespace, name := vmi.Namespace, network.Multus.NetworkName
+			if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+				namespace, name = parts[0], parts[1]
+			}
+			nad, err := h.nadCache.Get(namespace, name)
+			if apierrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			cnName := utils.GetNadLabel(nad, utils.KeyClusterNetworkLabel)
+			if cnName == "" {
+				continue
+			}
+			if nads[cnName] == nil {
+				nads[cnName] = map[string]*cniv1.NetworkAttachmentDefinition{}
+			}
+			nads[cnName][namespace+"/"+name] = nad
+		}
+	}
+
+	vids := make(map[string][]uint16, len(nads))
+	for cnName, cnNads := range nads {
+		list := make([]*cniv1.NetworkAttachmentDefinition, 0, len(cnNads))
+		for _, nad := range cnNads {
+			list = append(list, nad)
+		}
+		vidSet, err := utils.NewVlanIDSetFromNadList(list)
+		if err != nil {
+			return nil, err
+		}
+		if err := vidSet.WalkVIDs(cnName, func(vid uint16) error {
+			vids[cnName] = append(vids[cnName], vid)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return vids, nil
+}
+
+// exportState saves the state of the node in a configmap owned by the node, so that the network team can verify the
+// switch trunks against it, e.g. `kubectl get cm -l network.harvesterhci.io/node=<node>`
+func (h Handler) exportState(node *corev1.Node, body []byte) error {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		return err
+	}
+	name := utils.Name(RequiredVlansConfigMapPrefix, node.Name)
+	data := map[string]string{RequiredVlansKey: indented.String()}
+
+	cm, err := h.cmClient.Get(h.namespace, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = h.cmClient.Create(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: h.namespace,
+				Labels:    map[string]string{utils.KeyNodeLabel: utils.LabelValue(node.Name)},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "v1",
+					Kind:       "Node",
+					Name:       node.Name,
+					UID:        node.UID,
+				}},
+			},
+			Data: data,
+		})
+		return err
+	} else if err != nil {
+		return err
+	}
+	if cm.Data[RequiredVlansKey] == data[RequiredVlansKey] {
+		return nil
+	}
+
+	cmCopy := cm.DeepCopy()
+	cmCopy.Data = data
+	_, err = h.cmClient.Update(cmCopy)
+	return err
+}
@@ -0,0 +1,103 @@
+package ttl
+
+import (
+	"context"
+	"time"
+
+	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	controllerName = "harvester-network-manager-ttl-controller"
+
+	// recheck an expired object whose deletion is rejected, e.g. VMs are still attached to the network
+	recheckInterval = 5 * time.Minute
+)
+
+// Handler deletes the NADs and the vlanconfigs annotated with a TTL once they expire, e.g. the test networks created by
+// CI which are often leaked. The deletion goes through the webhook, so an expired network still in use is kept and
+// retried later.
+type Handler struct {
+	nadController ctlcniv1.NetworkAttachmentDefinitionController
+	vcController  ctlnetworkv1.VlanConfigController
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	vcs := management.HarvesterNetworkFactory.Network().V1beta1().VlanConfig()
+
+	handler := Handler{
+		nadController: nads,
+		vcController:  vcs,
+	}
+
+	nads.OnChange(ctx, controllerName, handler.OnNadChange)
+	vcs.OnChange(ctx, controllerName, handler.OnVlanConfigChange)
+
+	return nil
+}
+
+func (h Handler) OnNadChange(_ string, nad *cniv1.NetworkAttachmentDefinition) (*cniv1.NetworkAttachmentDefinition, error) {
+	if nad == nil || nad.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	name := nad.Namespace + "/" + nad.Name
+	after := h.expire(nad, "nad", name, func() error {
+		return h.nadController.Delete(nad.Namespace, nad.Name, &metav1.DeleteOptions{})
+	})
+	if after > 0 {
+		h.nadController.EnqueueAfter(nad.Namespace, nad.Name, after)
+	}
+
+	return nad, nil
+}
+
+func (h Handler) OnVlanConfigChange(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanConfig, error) {
+	if vc == nil || vc.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	after := h.expire(vc, "vlanconfig", vc.Name, func() error {
+		return h.vcController.Delete(vc.Name, &metav1.DeleteOptions{})
+	})
+	if after > 0 {
+		h.vcController.EnqueueAfter(vc.Name, after)
+	}
+
+	return vc, nil
+}
+
+// expire deletes the object if it's expired and returns when to check the object again, which is zero if the object has
+// no TTL or is deleted
+func (h Handler) expire(obj metav1.Object, kind, name string, deleteFunc func() error) time.Duration {
+	expiry, ok, err := utils.ExpiresAt(obj)
+	if err != nil {
+		// the webhook rejects an invalid TTL, an object created before the webhook is left as it is
+		logrus.Warnf("ignore the TTL of %s %s, error: %v", kind, name, err)
+		return 0
+	}
+	if !ok {
+		return 0
+	}
+	if remaining := time.Until(expiry); remaining > 0 {
+		return remaining
+	}
+
+	logrus.Infof("delete %s %s expired at %s", kind, name, expiry.UTC().Format(time.RFC3339))
+	if err := deleteFunc(); err != nil && !apierrors.IsNotFound(err) {
+		logrus.Warnf("failed to delete the expired %s %s, recheck in %s, error: %v", kind, name, recheckInterval, err)
+		return recheckInterval
+	}
+
+	return 0
+}
@@ -0,0 +1,151 @@
+package vidcheck
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	controllerName = "harvester-network-manager-vidcheck-controller"
+
+	// how long the VLAN IDs may be missing before they are reported, the agent needs a moment to follow a NAD change
+	gracePeriod = 2 * time.Minute
+)
+
+// Handler cross-checks the VLAN IDs reported in the vlanstatuses against the ones required by the NADs of their cluster
+// networks and sets the condition VIDsMissing on the vlanstatus of a node whose uplink lacks any of them, e.g. the
+// agent missed an event. Only the agents reporting their VLAN IDs are checked.
+type Handler struct {
+	vsController ctlnetworkv1.VlanStatusController
+	vsCache      ctlnetworkv1.VlanStatusCache
+	vsClient     ctlnetworkv1.VlanStatusClient
+	nadCache     ctlcniv1.NetworkAttachmentDefinitionCache
+
+	// the time since when the VLAN IDs are missing, by the name of the vlanstatus
+	mutex        sync.Mutex
+	missingSince map[string]time.Time
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	vss := management.HarvesterNetworkFactory.Network().V1beta1().VlanStatus()
+	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+
+	handler := &Handler{
+		vsController: vss,
+		vsCache:      vss.Cache(),
+		vsClient:     vss,
+		nadCache:     nads.Cache(),
+		missingSince: make(map[string]time.Time),
+	}
+
+	vss.OnChange(ctx, controllerName, handler.OnVlanStatusChange)
+	nads.OnChange(ctx, controllerName, handler.OnNadChange)
+
+	return nil
+}
+
+func (h *Handler) OnVlanStatusChange(name string, vs *networkv1.VlanStatus) (*networkv1.VlanStatus, error) {
+	if vs == nil || vs.DeletionTimestamp != nil {
+		h.forget(name)
+		return nil, nil
+	}
+	// the VLAN IDs of an unready uplink are expected to be incomplete, the ready condition tells why
+	if !slices.Contains(vs.Status.Features, utils.FeatureVIDStatus) || !networkv1.Ready.IsTrue(vs) {
+		h.forget(name)
+		return vs, nil
+	}
+
+	nads, err := utils.NewNadGetter(h.nadCache).ListNadsOnClusterNetwork(vs.Status.ClusterNetwork)
+	if err != nil {
+		return nil, err
+	}
+	required, err := utils.NewVlanIDSetFromNadList(nads)
+	if err != nil {
+		return nil, err
+	}
+	missing := required.MissingVIDs(vs.Status.VIDs)
+	if len(missing) == 0 {
+		h.forget(name)
+		return h.setCondition(vs, false, "")
+	}
+
+	if remaining := time.Until(h.since(name).Add(gracePeriod)); remaining > 0 {
+		h.vsController.EnqueueAfter(name, remaining)
+		return vs, nil
+	}
+
+	msg := fmt.Sprintf("VLAN IDs %s required by the NADs of cluster network %s are missing on the uplink",
+		utils.FormatVIDRanges(missing), vs.Status.ClusterNetwork)
+	return h.setCondition(vs, true, msg)
+}
+
+// OnNadChange rechecks the vlanstatuses of the cluster network of the NAD
+func (h *Handler) OnNadChange(_ string, nad *cniv1.NetworkAttachmentDefinition) (*cniv1.NetworkAttachmentDefinition, error) {
+	if nad == nil {
+		return nil, nil
+	}
+	cnName := nad.Labels[utils.KeyClusterNetworkLabel]
+	if cnName == "" {
+		return nad, nil
+	}
+
+	vss, err := h.vsCache.List(labels.Set{utils.KeyClusterNetworkLabel: cnName}.AsSelector())
+	if err != nil {
+		return nil, err
+	}
+	for _, vs := range vss {
+		h.vsController.Enqueue(vs.Name)
+	}
+
+	return nad, nil
+}
+
+func (h *Handler) setCondition(vs *networkv1.VlanStatus, missing bool, msg string) (*networkv1.VlanStatus, error) {
+	if networkv1.VIDsMissing.IsTrue(vs) == missing && networkv1.VIDsMissing.GetMessage(vs) == msg {
+		return vs, nil
+	}
+	// don't add the condition to the vlanstatuses which never miss any VLAN ID
+	if !missing && networkv1.VIDsMissing.GetStatus(vs) == "" {
+		return vs, nil
+	}
+
+	if missing {
+		logrus.Warnf("vlanstatus %s: %s", vs.Name, msg)
+	}
+	return utils.UpdateVlanStatus(h.vsClient, vs, func(vs *networkv1.VlanStatus) {
+		networkv1.VIDsMissing.SetStatusBool(vs, missing)
+		networkv1.VIDsMissing.Message(vs, msg)
+	})
+}
+
+func (h *Handler) since(name string) time.Time {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	since, ok := h.missingSince[name]
+	if !ok {
+		since = time.Now()
+		h.missingSince[name] = since
+	}
+	return since
+}
+
+func (h *Handler) forget(name string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delete(h.missingSince, name)
+}
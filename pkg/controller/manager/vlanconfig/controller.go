@@ -3,12 +3,15 @@ package vlanconfig
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 
 	"github.com/sirupsen/logrus"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
@@ -20,10 +23,12 @@ const (
 )
 
 type Handler struct {
-	cnClient ctlnetworkv1.ClusterNetworkClient
-	cnCache  ctlnetworkv1.ClusterNetworkCache
-	vsCache  ctlnetworkv1.VlanStatusCache
-	vcCache  ctlnetworkv1.VlanConfigCache
+	cnClient     ctlnetworkv1.ClusterNetworkClient
+	cnCache      ctlnetworkv1.ClusterNetworkCache
+	vsCache      ctlnetworkv1.VlanStatusCache
+	vcClient     ctlnetworkv1.VlanConfigClient
+	vcCache      ctlnetworkv1.VlanConfigCache
+	vcController ctlnetworkv1.VlanConfigController
 }
 
 func Register(ctx context.Context, management *config.Management) error {
@@ -32,16 +37,22 @@ func Register(ctx context.Context, management *config.Management) error {
 	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
 
 	handler := &Handler{
-		cnClient: cns,
-		cnCache:  cns.Cache(),
-		vsCache:  vss.Cache(),
-		vcCache:  vcs.Cache(),
+		cnClient:     cns,
+		cnCache:      cns.Cache(),
+		vsCache:      vss.Cache(),
+		vcClient:     vcs,
+		vcCache:      vcs.Cache(),
+		vcController: vcs,
 	}
 
 	vcs.OnChange(ctx, ControllerName, handler.EnsureClusterNetwork)
+	vcs.OnChange(ctx, ControllerName, handler.CloneVlanConfig)
+	vcs.OnChange(ctx, ControllerName, handler.TrackTeardown)
 	vcs.OnRemove(ctx, ControllerName, handler.OnVlanConfigRemove)
 	vss.OnChange(ctx, ControllerName, handler.SetClusterNetworkReady)
 	vss.OnRemove(ctx, ControllerName, handler.SetClusterNetworkUnready)
+	vss.OnChange(ctx, ControllerName, handler.EnqueueDeletingVlanConfig)
+	cns.OnChange(ctx, ControllerName, handler.RestoreVlanConfig)
 
 	return nil
 }
@@ -59,6 +70,94 @@ func (h Handler) EnsureClusterNetwork(_ string, vc *networkv1.VlanConfig) (*netw
 	return vc, nil
 }
 
+// CloneVlanConfig handles the clone request annotated on the vlanconfig.
+// The request is removed once it's handled, and the result is recorded in another annotation.
+func (h Handler) CloneVlanConfig(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanConfig, error) {
+	if vc == nil || vc.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	if _, ok := vc.Annotations[utils.KeyCloneRequest]; !ok {
+		return vc, nil
+	}
+
+	result := ""
+	req, err := utils.GetCloneRequest(vc)
+	if err == nil {
+		clone := utils.CloneVlanConfig(vc, req)
+		if _, err = h.vcClient.Create(clone); err == nil {
+			result = fmt.Sprintf("cloned to vlanconfig %s", clone.Name)
+			logrus.Infof("vlanconfig %s has been cloned to %s", vc.Name, clone.Name)
+		} else if !apierrors.IsAlreadyExists(err) && !apierrors.IsInvalid(err) && !apierrors.IsBadRequest(err) &&
+			!apierrors.IsForbidden(err) {
+			// retry on the transient errors, the others, e.g. denied by the webhook, are recorded as the result
+			return nil, fmt.Errorf("clone vlanconfig %s failed, error: %w", vc.Name, err)
+		}
+	}
+	if err != nil {
+		result = fmt.Sprintf("failed to clone: %s", err.Error())
+		logrus.Warnf("vlanconfig %s %s", vc.Name, result)
+	}
+
+	vcCopy := vc.DeepCopy()
+	delete(vcCopy.Annotations, utils.KeyCloneRequest)
+	vcCopy.Annotations[utils.KeyCloneResult] = result
+
+	return h.vcClient.Update(vcCopy)
+}
+
+// TrackTeardown records the nodes which still hold the interfaces of the vlanconfig being deleted
+func (h Handler) TrackTeardown(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanConfig, error) {
+	if vc == nil || vc.DeletionTimestamp == nil {
+		return vc, nil
+	}
+
+	vss, err := h.vsCache.List(labels.Set(map[string]string{
+		utils.KeyVlanConfigLabel: utils.LabelValue(vc.Name),
+	}).AsSelector())
+	if err != nil {
+		return nil, err
+	}
+
+	// the vlanstatus is deleted by the agent after the VLAN is torn down
+	pendingNodes := make([]string, 0, len(vss))
+	for _, vs := range vss {
+		if vs.DeletionTimestamp == nil && vs.Status.VlanConfig == vc.Name {
+			pendingNodes = append(pendingNodes, vs.Status.Node)
+		}
+	}
+	sort.Strings(pendingNodes)
+
+	if slices.Equal(pendingNodes, vc.Status.TeardownPendingNodes) {
+		return vc, nil
+	}
+	if len(pendingNodes) > 0 {
+		logrus.Infof("vlanconfig %s is being deleted, waiting for the teardown on node(s) %v", vc.Name, pendingNodes)
+	}
+
+	vcCopy := vc.DeepCopy()
+	vcCopy.Status.TeardownPendingNodes = pendingNodes
+	return h.vcClient.Update(vcCopy)
+}
+
+// EnqueueDeletingVlanConfig refreshes the teardown progress of the vlanconfig being deleted when its vlanstatus changes
+func (h Handler) EnqueueDeletingVlanConfig(_ string, vs *networkv1.VlanStatus) (*networkv1.VlanStatus, error) {
+	if vs == nil {
+		return nil, nil
+	}
+
+	vc, err := h.vcCache.Get(vs.Status.VlanConfig)
+	if apierrors.IsNotFound(err) {
+		return vs, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if vc.DeletionTimestamp != nil {
+		h.vcController.Enqueue(vc.Name)
+	}
+
+	return vs, nil
+}
+
 func (h Handler) SetClusterNetworkReady(_ string, vs *networkv1.VlanStatus) (*networkv1.VlanStatus, error) {
 	if vs == nil || vs.DeletionTimestamp != nil {
 		return nil, nil
@@ -102,7 +201,7 @@ func (h Handler) ensureClusterNetwork(vc *networkv1.VlanConfig) error {
 
 	// check if the configured VC MTU value is updated to ClusterNetwork annotations
 	if curCn != nil {
-		curMTU := curCn.Annotations[utils.KeyUplinkMTU]
+		curMTU := annotations.UplinkMTU.Value(curCn)
 		if curMTU == targetMTU {
 			// do not compare KeyMTUSourceVlanConfig, which is only used for reference
 			return nil
@@ -113,8 +212,8 @@ func (h Handler) ensureClusterNetwork(vc *networkv1.VlanConfig) error {
 		if cnCopy.Annotations == nil {
 			cnCopy.Annotations = make(map[string]string, 2)
 		}
-		cnCopy.Annotations[utils.KeyUplinkMTU] = targetMTU
-		cnCopy.Annotations[utils.KeyMTUSourceVlanConfig] = vc.Name
+		annotations.UplinkMTU.Set(cnCopy, targetMTU)
+		annotations.MTUSourceVlanConfig.Set(cnCopy, vc.Name)
 		if _, err := h.cnClient.Update(cnCopy); err != nil {
 			return fmt.Errorf("failed to update cluster network %s annotation %s with MTU %s: %w", name, utils.KeyUplinkMTU, targetMTU, err)
 		}
@@ -203,15 +302,17 @@ func (h Handler) OnVlanConfigRemove(_ string, vc *networkv1.VlanConfig) (*networ
 		return nil, err
 	}
 
+	if cn, err = h.recordDeletedVlanConfig(cn, vc); err != nil {
+		return nil, err
+	}
+
 	// Abort if the deleted `VlanConfig` is not matching the annotated one.
-	if cn.Annotations[utils.KeyMTUSourceVlanConfig] != vc.Name {
+	if annotations.MTUSourceVlanConfig.Value(cn) != vc.Name {
 		return nil, nil
 	}
 
 	// Try to find another `VlanConfig` for this cluster network.
-	vcs, err := h.vcCache.List(labels.Set(map[string]string{
-		utils.KeyClusterNetworkLabel: cnName,
-	}).AsSelector())
+	vcs, err := h.vcCache.GetByIndex(utils.VlanConfigByClusterNetworkIndex, cnName)
 	if err != nil {
 		return nil, err
 	}
@@ -262,8 +363,8 @@ func (h Handler) OnVlanConfigRemove(_ string, vc *networkv1.VlanConfig) (*networ
 		// as all `VlanConfig`s should have the same MTU value. However, it is
 		// safer to update them in case the boundary conditions change in the
 		// future.
-		cnCopy.Annotations[utils.KeyUplinkMTU] = fmt.Sprintf("%v", mtu)
-		cnCopy.Annotations[utils.KeyMTUSourceVlanConfig] = vcCandidate.Name
+		annotations.UplinkMTU.Set(cnCopy, fmt.Sprintf("%v", mtu))
+		annotations.MTUSourceVlanConfig.Set(cnCopy, vcCandidate.Name)
 		if _, err := h.cnClient.Update(cnCopy); err != nil {
 			return nil, fmt.Errorf("failed to update cluster network %s after deleting source vlan config %s: %w", cnName, vc.Name, err)
 		}
@@ -273,8 +374,8 @@ func (h Handler) OnVlanConfigRemove(_ string, vc *networkv1.VlanConfig) (*networ
 	}
 
 	// No candidate found, remove the annotations.
-	delete(cnCopy.Annotations, utils.KeyMTUSourceVlanConfig)
-	delete(cnCopy.Annotations, utils.KeyUplinkMTU)
+	annotations.MTUSourceVlanConfig.Delete(cnCopy)
+	annotations.UplinkMTU.Delete(cnCopy)
 	if _, err := h.cnClient.Update(cnCopy); err != nil {
 		return nil, fmt.Errorf("failed to clear cluster network %s MTU annotations after deleting source vlan config %s: %w", cnName, vc.Name, err)
 	}
@@ -0,0 +1,77 @@
+package vlanconfig
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// recordDeletedVlanConfig keeps the snapshot of the deleted vlanconfig on its cluster network so that an accidental
+// deletion can be restored, it's skipped if the cluster network is being deleted as well
+func (h Handler) recordDeletedVlanConfig(cn *networkv1.ClusterNetwork, vc *networkv1.VlanConfig) (*networkv1.ClusterNetwork, error) {
+	if cn.DeletionTimestamp != nil {
+		return cn, nil
+	}
+
+	cnCopy := cn.DeepCopy()
+	if err := utils.RecordDeletedVlanConfig(cnCopy, vc, time.Now()); err != nil {
+		logrus.Warnf("failed to keep the snapshot of the deleted vlanconfig %s on cluster network %s, error: %v",
+			vc.Name, cn.Name, err)
+		return cn, nil
+	}
+
+	updated, err := h.cnClient.Update(cnCopy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to keep the snapshot of the deleted vlanconfig %s on cluster network %s, error: %w",
+			vc.Name, cn.Name, err)
+	}
+
+	return updated, nil
+}
+
+// RestoreVlanConfig handles the restore request annotated on the cluster network, which recreates the deleted
+// vlanconfig from its snapshot. The request is removed once it's handled, and the result is recorded in another
+// annotation.
+func (h Handler) RestoreVlanConfig(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn == nil || cn.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	name, ok := cn.Annotations[utils.KeyRestoreVlanConfig]
+	if !ok {
+		return cn, nil
+	}
+
+	cnCopy := cn.DeepCopy()
+	result := ""
+	snapshot, err := utils.TakeDeletedVlanConfig(cnCopy, name, time.Now())
+	if err == nil && snapshot == nil {
+		err = fmt.Errorf("there is no snapshot of the deleted vlanconfig %s, or it has expired", name)
+	}
+	if err == nil {
+		vc := utils.RestoredVlanConfig(snapshot)
+		if _, err = h.vcClient.Create(vc); err == nil {
+			result = fmt.Sprintf("restored vlanconfig %s deleted at %s", name, snapshot.DeletedAt.UTC().Format(time.RFC3339))
+			logrus.Infof("vlanconfig %s has been restored on cluster network %s", name, cn.Name)
+		} else if !apierrors.IsAlreadyExists(err) && !apierrors.IsInvalid(err) && !apierrors.IsBadRequest(err) &&
+			!apierrors.IsForbidden(err) {
+			// retry on the transient errors, the others, e.g. denied by the webhook, are recorded as the result
+			return nil, fmt.Errorf("restore vlanconfig %s failed, error: %w", name, err)
+		}
+	}
+	if err != nil {
+		// keep the snapshot to fix the cause and retry
+		cnCopy = cn.DeepCopy()
+		result = fmt.Sprintf("failed to restore: %s", err.Error())
+		logrus.Warnf("cluster network %s %s", cn.Name, result)
+	}
+
+	delete(cnCopy.Annotations, utils.KeyRestoreVlanConfig)
+	cnCopy.Annotations[utils.KeyRestoreResult] = result
+
+	return h.cnClient.Update(cnCopy)
+}
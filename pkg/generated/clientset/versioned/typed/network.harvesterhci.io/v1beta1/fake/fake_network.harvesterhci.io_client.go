@@ -40,6 +40,22 @@ func (c *FakeNetworkV1beta1) LinkMonitors() v1beta1.LinkMonitorInterface {
 	return newFakeLinkMonitors(c)
 }
 
+func (c *FakeNetworkV1beta1) NetworkChangeLogs() v1beta1.NetworkChangeLogInterface {
+	return newFakeNetworkChangeLogs(c)
+}
+
+func (c *FakeNetworkV1beta1) NetworkInterfaceClaims() v1beta1.NetworkInterfaceClaimInterface {
+	return newFakeNetworkInterfaceClaims(c)
+}
+
+func (c *FakeNetworkV1beta1) NodeNetworkStates() v1beta1.NodeNetworkStateInterface {
+	return newFakeNodeNetworkStates(c)
+}
+
+func (c *FakeNetworkV1beta1) PacketCaptures() v1beta1.PacketCaptureInterface {
+	return newFakePacketCaptures(c)
+}
+
 func (c *FakeNetworkV1beta1) VlanConfigs() v1beta1.VlanConfigInterface {
 	return newFakeVlanConfigs(c)
 }
@@ -0,0 +1,52 @@
+/*
+Copyright 2025 Harvester Network Controller Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package fake
+
+import (
+	v1beta1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	networkharvesterhciiov1beta1 "github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/typed/network.harvesterhci.io/v1beta1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeNetworkChangeLogs implements NetworkChangeLogInterface
+type fakeNetworkChangeLogs struct {
+	*gentype.FakeClientWithList[*v1beta1.NetworkChangeLog, *v1beta1.NetworkChangeLogList]
+	Fake *FakeNetworkV1beta1
+}
+
+func newFakeNetworkChangeLogs(fake *FakeNetworkV1beta1) networkharvesterhciiov1beta1.NetworkChangeLogInterface {
+	return &fakeNetworkChangeLogs{
+		gentype.NewFakeClientWithList[*v1beta1.NetworkChangeLog, *v1beta1.NetworkChangeLogList](
+			fake.Fake,
+			"",
+			v1beta1.SchemeGroupVersion.WithResource("networkchangelogs"),
+			v1beta1.SchemeGroupVersion.WithKind("NetworkChangeLog"),
+			func() *v1beta1.NetworkChangeLog { return &v1beta1.NetworkChangeLog{} },
+			func() *v1beta1.NetworkChangeLogList { return &v1beta1.NetworkChangeLogList{} },
+			func(dst, src *v1beta1.NetworkChangeLogList) { dst.ListMeta = src.ListMeta },
+			func(list *v1beta1.NetworkChangeLogList) []*v1beta1.NetworkChangeLog {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1beta1.NetworkChangeLogList, items []*v1beta1.NetworkChangeLog) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
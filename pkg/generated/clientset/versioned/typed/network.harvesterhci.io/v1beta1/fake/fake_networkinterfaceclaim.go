@@ -0,0 +1,52 @@
+/*
+Copyright 2025 Harvester Network Controller Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package fake
+
+import (
+	v1beta1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	networkharvesterhciiov1beta1 "github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/typed/network.harvesterhci.io/v1beta1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeNetworkInterfaceClaims implements NetworkInterfaceClaimInterface
+type fakeNetworkInterfaceClaims struct {
+	*gentype.FakeClientWithList[*v1beta1.NetworkInterfaceClaim, *v1beta1.NetworkInterfaceClaimList]
+	Fake *FakeNetworkV1beta1
+}
+
+func newFakeNetworkInterfaceClaims(fake *FakeNetworkV1beta1) networkharvesterhciiov1beta1.NetworkInterfaceClaimInterface {
+	return &fakeNetworkInterfaceClaims{
+		gentype.NewFakeClientWithList[*v1beta1.NetworkInterfaceClaim, *v1beta1.NetworkInterfaceClaimList](
+			fake.Fake,
+			"",
+			v1beta1.SchemeGroupVersion.WithResource("networkinterfaceclaims"),
+			v1beta1.SchemeGroupVersion.WithKind("NetworkInterfaceClaim"),
+			func() *v1beta1.NetworkInterfaceClaim { return &v1beta1.NetworkInterfaceClaim{} },
+			func() *v1beta1.NetworkInterfaceClaimList { return &v1beta1.NetworkInterfaceClaimList{} },
+			func(dst, src *v1beta1.NetworkInterfaceClaimList) { dst.ListMeta = src.ListMeta },
+			func(list *v1beta1.NetworkInterfaceClaimList) []*v1beta1.NetworkInterfaceClaim {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1beta1.NetworkInterfaceClaimList, items []*v1beta1.NetworkInterfaceClaim) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
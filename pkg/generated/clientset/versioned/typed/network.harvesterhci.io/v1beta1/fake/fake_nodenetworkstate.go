@@ -0,0 +1,52 @@
+/*
+Copyright 2025 Harvester Network Controller Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package fake
+
+import (
+	v1beta1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	networkharvesterhciiov1beta1 "github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/typed/network.harvesterhci.io/v1beta1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeNodeNetworkStates implements NodeNetworkStateInterface
+type fakeNodeNetworkStates struct {
+	*gentype.FakeClientWithList[*v1beta1.NodeNetworkState, *v1beta1.NodeNetworkStateList]
+	Fake *FakeNetworkV1beta1
+}
+
+func newFakeNodeNetworkStates(fake *FakeNetworkV1beta1) networkharvesterhciiov1beta1.NodeNetworkStateInterface {
+	return &fakeNodeNetworkStates{
+		gentype.NewFakeClientWithList[*v1beta1.NodeNetworkState, *v1beta1.NodeNetworkStateList](
+			fake.Fake,
+			"",
+			v1beta1.SchemeGroupVersion.WithResource("nodenetworkstates"),
+			v1beta1.SchemeGroupVersion.WithKind("NodeNetworkState"),
+			func() *v1beta1.NodeNetworkState { return &v1beta1.NodeNetworkState{} },
+			func() *v1beta1.NodeNetworkStateList { return &v1beta1.NodeNetworkStateList{} },
+			func(dst, src *v1beta1.NodeNetworkStateList) { dst.ListMeta = src.ListMeta },
+			func(list *v1beta1.NodeNetworkStateList) []*v1beta1.NodeNetworkState {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1beta1.NodeNetworkStateList, items []*v1beta1.NodeNetworkState) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
@@ -24,6 +24,14 @@ type HostNetworkConfigExpansion interface{}
 
 type LinkMonitorExpansion interface{}
 
+type NetworkChangeLogExpansion interface{}
+
+type NetworkInterfaceClaimExpansion interface{}
+
+type NodeNetworkStateExpansion interface{}
+
+type PacketCaptureExpansion interface{}
+
 type VlanConfigExpansion interface{}
 
 type VlanStatusExpansion interface{}
@@ -31,6 +31,10 @@ type NetworkV1beta1Interface interface {
 	ClusterNetworksGetter
 	HostNetworkConfigsGetter
 	LinkMonitorsGetter
+	NetworkChangeLogsGetter
+	NetworkInterfaceClaimsGetter
+	NodeNetworkStatesGetter
+	PacketCapturesGetter
 	VlanConfigsGetter
 	VlanStatusesGetter
 }
@@ -52,6 +56,22 @@ func (c *NetworkV1beta1Client) LinkMonitors() LinkMonitorInterface {
 	return newLinkMonitors(c)
 }
 
+func (c *NetworkV1beta1Client) NetworkChangeLogs() NetworkChangeLogInterface {
+	return newNetworkChangeLogs(c)
+}
+
+func (c *NetworkV1beta1Client) NetworkInterfaceClaims() NetworkInterfaceClaimInterface {
+	return newNetworkInterfaceClaims(c)
+}
+
+func (c *NetworkV1beta1Client) NodeNetworkStates() NodeNetworkStateInterface {
+	return newNodeNetworkStates(c)
+}
+
+func (c *NetworkV1beta1Client) PacketCaptures() PacketCaptureInterface {
+	return newPacketCaptures(c)
+}
+
 func (c *NetworkV1beta1Client) VlanConfigs() VlanConfigInterface {
 	return newVlanConfigs(c)
 }
@@ -0,0 +1,74 @@
+/*
+Copyright 2025 Harvester Network Controller Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	context "context"
+
+	networkharvesterhciiov1beta1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	scheme "github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// PacketCapturesGetter has a method to return a PacketCaptureInterface.
+// A group's client should implement this interface.
+type PacketCapturesGetter interface {
+	PacketCaptures() PacketCaptureInterface
+}
+
+// PacketCaptureInterface has methods to work with PacketCapture resources.
+type PacketCaptureInterface interface {
+	Create(ctx context.Context, packetCapture *networkharvesterhciiov1beta1.PacketCapture, opts v1.CreateOptions) (*networkharvesterhciiov1beta1.PacketCapture, error)
+	Update(ctx context.Context, packetCapture *networkharvesterhciiov1beta1.PacketCapture, opts v1.UpdateOptions) (*networkharvesterhciiov1beta1.PacketCapture, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, packetCapture *networkharvesterhciiov1beta1.PacketCapture, opts v1.UpdateOptions) (*networkharvesterhciiov1beta1.PacketCapture, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*networkharvesterhciiov1beta1.PacketCapture, error)
+	List(ctx context.Context, opts v1.ListOptions) (*networkharvesterhciiov1beta1.PacketCaptureList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *networkharvesterhciiov1beta1.PacketCapture, err error)
+	PacketCaptureExpansion
+}
+
+// packetCaptures implements PacketCaptureInterface
+type packetCaptures struct {
+	*gentype.ClientWithList[*networkharvesterhciiov1beta1.PacketCapture, *networkharvesterhciiov1beta1.PacketCaptureList]
+}
+
+// newPacketCaptures returns a PacketCaptures
+func newPacketCaptures(c *NetworkV1beta1Client) *packetCaptures {
+	return &packetCaptures{
+		gentype.NewClientWithList[*networkharvesterhciiov1beta1.PacketCapture, *networkharvesterhciiov1beta1.PacketCaptureList](
+			"packetcaptures",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			"",
+			func() *networkharvesterhciiov1beta1.PacketCapture {
+				return &networkharvesterhciiov1beta1.PacketCapture{}
+			},
+			func() *networkharvesterhciiov1beta1.PacketCaptureList {
+				return &networkharvesterhciiov1beta1.PacketCaptureList{}
+			},
+		),
+	}
+}
@@ -33,6 +33,7 @@ func init() {
 type Interface interface {
 	VirtualMachine() VirtualMachineController
 	VirtualMachineInstance() VirtualMachineInstanceController
+	VirtualMachineInstanceMigration() VirtualMachineInstanceMigrationController
 }
 
 func New(controllerFactory controller.SharedControllerFactory) Interface {
@@ -52,3 +53,7 @@ func (v *version) VirtualMachine() VirtualMachineController {
 func (v *version) VirtualMachineInstance() VirtualMachineInstanceController {
 	return generic.NewController[*v1.VirtualMachineInstance, *v1.VirtualMachineInstanceList](schema.GroupVersionKind{Group: "kubevirt.io", Version: "v1", Kind: "VirtualMachineInstance"}, "virtualmachineinstances", true, v.controllerFactory)
 }
+
+func (v *version) VirtualMachineInstanceMigration() VirtualMachineInstanceMigrationController {
+	return generic.NewController[*v1.VirtualMachineInstanceMigration, *v1.VirtualMachineInstanceMigrationList](schema.GroupVersionKind{Group: "kubevirt.io", Version: "v1", Kind: "VirtualMachineInstanceMigration"}, "virtualmachineinstancemigrations", true, v.controllerFactory)
+}
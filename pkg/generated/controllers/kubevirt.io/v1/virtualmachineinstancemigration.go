@@ -0,0 +1,208 @@
+/*
+Copyright 2025 Harvester Network Controller Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rancher/wrangler/v3/pkg/apply"
+	"github.com/rancher/wrangler/v3/pkg/condition"
+	"github.com/rancher/wrangler/v3/pkg/generic"
+	"github.com/rancher/wrangler/v3/pkg/kv"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	v1 "kubevirt.io/api/core/v1"
+)
+
+// VirtualMachineInstanceMigrationController interface for managing VirtualMachineInstanceMigration resources.
+type VirtualMachineInstanceMigrationController interface {
+	generic.ControllerInterface[*v1.VirtualMachineInstanceMigration, *v1.VirtualMachineInstanceMigrationList]
+}
+
+// VirtualMachineInstanceMigrationClient interface for managing VirtualMachineInstanceMigration resources in Kubernetes.
+type VirtualMachineInstanceMigrationClient interface {
+	generic.ClientInterface[*v1.VirtualMachineInstanceMigration, *v1.VirtualMachineInstanceMigrationList]
+}
+
+// VirtualMachineInstanceMigrationCache interface for retrieving VirtualMachineInstanceMigration resources in memory.
+type VirtualMachineInstanceMigrationCache interface {
+	generic.CacheInterface[*v1.VirtualMachineInstanceMigration]
+}
+
+// VirtualMachineInstanceMigrationStatusHandler is executed for every added or modified VirtualMachineInstanceMigration. Should return the new status to be updated
+type VirtualMachineInstanceMigrationStatusHandler func(obj *v1.VirtualMachineInstanceMigration, status v1.VirtualMachineInstanceMigrationStatus) (v1.VirtualMachineInstanceMigrationStatus, error)
+
+// VirtualMachineInstanceMigrationGeneratingHandler is the top-level handler that is executed for every VirtualMachineInstanceMigration event. It extends VirtualMachineInstanceMigrationStatusHandler by a returning a slice of child objects to be passed to apply.Apply
+type VirtualMachineInstanceMigrationGeneratingHandler func(obj *v1.VirtualMachineInstanceMigration, status v1.VirtualMachineInstanceMigrationStatus) ([]runtime.Object, v1.VirtualMachineInstanceMigrationStatus, error)
+
+// RegisterVirtualMachineInstanceMigrationStatusHandler configures a VirtualMachineInstanceMigrationController to execute a VirtualMachineInstanceMigrationStatusHandler for every events observed.
+// If a non-empty condition is provided, it will be updated in the status conditions for every handler execution
+func RegisterVirtualMachineInstanceMigrationStatusHandler(ctx context.Context, controller VirtualMachineInstanceMigrationController, condition condition.Cond, name string, handler VirtualMachineInstanceMigrationStatusHandler) {
+	statusHandler := &virtualMachineInstanceMigrationStatusHandler{
+		client:    controller,
+		condition: condition,
+		handler:   handler,
+	}
+	controller.AddGenericHandler(ctx, name, generic.FromObjectHandlerToHandler(statusHandler.sync))
+}
+
+// RegisterVirtualMachineInstanceMigrationGeneratingHandler configures a VirtualMachineInstanceMigrationController to execute a VirtualMachineInstanceMigrationGeneratingHandler for every events observed, passing the returned objects to the provided apply.Apply.
+// If a non-empty condition is provided, it will be updated in the status conditions for every handler execution
+func RegisterVirtualMachineInstanceMigrationGeneratingHandler(ctx context.Context, controller VirtualMachineInstanceMigrationController, apply apply.Apply,
+	condition condition.Cond, name string, handler VirtualMachineInstanceMigrationGeneratingHandler, opts *generic.GeneratingHandlerOptions) {
+	statusHandler := &virtualMachineInstanceMigrationGeneratingHandler{
+		VirtualMachineInstanceMigrationGeneratingHandler: handler,
+		apply: apply,
+		name:  name,
+		gvk:   controller.GroupVersionKind(),
+	}
+	if opts != nil {
+		statusHandler.opts = *opts
+	}
+	controller.OnChange(ctx, name, statusHandler.Remove)
+	RegisterVirtualMachineInstanceMigrationStatusHandler(ctx, controller, condition, name, statusHandler.Handle)
+}
+
+type virtualMachineInstanceMigrationStatusHandler struct {
+	client    VirtualMachineInstanceMigrationClient
+	condition condition.Cond
+	handler   VirtualMachineInstanceMigrationStatusHandler
+}
+
+// sync is executed on every resource addition or modification. Executes the configured handlers and sends the updated status to the Kubernetes API
+func (a *virtualMachineInstanceMigrationStatusHandler) sync(key string, obj *v1.VirtualMachineInstanceMigration) (*v1.VirtualMachineInstanceMigration, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	origStatus := obj.Status.DeepCopy()
+	obj = obj.DeepCopy()
+	newStatus, err := a.handler(obj, obj.Status)
+	if err != nil {
+		// Revert to old status on error
+		newStatus = *origStatus.DeepCopy()
+	}
+
+	if a.condition != "" {
+		if errors.IsConflict(err) {
+			a.condition.SetError(&newStatus, "", nil)
+		} else {
+			a.condition.SetError(&newStatus, "", err)
+		}
+	}
+	if !equality.Semantic.DeepEqual(origStatus, &newStatus) {
+		if a.condition != "" {
+			// Since status has changed, update the lastUpdatedTime
+			a.condition.LastUpdated(&newStatus, time.Now().UTC().Format(time.RFC3339))
+		}
+
+		var newErr error
+		obj.Status = newStatus
+		newObj, newErr := a.client.UpdateStatus(obj)
+		if err == nil {
+			err = newErr
+		}
+		if newErr == nil {
+			obj = newObj
+		}
+	}
+	return obj, err
+}
+
+type virtualMachineInstanceMigrationGeneratingHandler struct {
+	VirtualMachineInstanceMigrationGeneratingHandler
+	apply apply.Apply
+	opts  generic.GeneratingHandlerOptions
+	gvk   schema.GroupVersionKind
+	name  string
+	seen  sync.Map
+}
+
+// Remove handles the observed deletion of a resource, cascade deleting every associated resource previously applied
+func (a *virtualMachineInstanceMigrationGeneratingHandler) Remove(key string, obj *v1.VirtualMachineInstanceMigration) (*v1.VirtualMachineInstanceMigration, error) {
+	if obj != nil {
+		return obj, nil
+	}
+
+	obj = &v1.VirtualMachineInstanceMigration{}
+	obj.Namespace, obj.Name = kv.RSplit(key, "/")
+	obj.SetGroupVersionKind(a.gvk)
+
+	if a.opts.UniqueApplyForResourceVersion {
+		a.seen.Delete(key)
+	}
+
+	return nil, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects()
+}
+
+// Handle executes the configured VirtualMachineInstanceMigrationGeneratingHandler and pass the resulting objects to apply.Apply, finally returning the new status of the resource
+func (a *virtualMachineInstanceMigrationGeneratingHandler) Handle(obj *v1.VirtualMachineInstanceMigration, status v1.VirtualMachineInstanceMigrationStatus) (v1.VirtualMachineInstanceMigrationStatus, error) {
+	if !obj.DeletionTimestamp.IsZero() {
+		return status, nil
+	}
+
+	objs, newStatus, err := a.VirtualMachineInstanceMigrationGeneratingHandler(obj, status)
+	if err != nil {
+		return newStatus, err
+	}
+	if !a.isNewResourceVersion(obj) {
+		return newStatus, nil
+	}
+
+	err = generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects(objs...)
+	if err != nil {
+		return newStatus, err
+	}
+	a.storeResourceVersion(obj)
+	return newStatus, nil
+}
+
+// isNewResourceVersion detects if a specific resource version was already successfully processed.
+// Only used if UniqueApplyForResourceVersion is set in generic.GeneratingHandlerOptions
+func (a *virtualMachineInstanceMigrationGeneratingHandler) isNewResourceVersion(obj *v1.VirtualMachineInstanceMigration) bool {
+	if !a.opts.UniqueApplyForResourceVersion {
+		return true
+	}
+
+	// Apply once per resource version
+	key := obj.Namespace + "/" + obj.Name
+	previous, ok := a.seen.Load(key)
+	return !ok || previous != obj.ResourceVersion
+}
+
+// storeResourceVersion keeps track of the latest resource version of an object for which Apply was executed
+// Only used if UniqueApplyForResourceVersion is set in generic.GeneratingHandlerOptions
+func (a *virtualMachineInstanceMigrationGeneratingHandler) storeResourceVersion(obj *v1.VirtualMachineInstanceMigration) {
+	if !a.opts.UniqueApplyForResourceVersion {
+		return
+	}
+
+	key := obj.Namespace + "/" + obj.Name
+	a.seen.Store(key, obj.ResourceVersion)
+}
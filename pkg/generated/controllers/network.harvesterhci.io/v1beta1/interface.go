@@ -34,6 +34,10 @@ type Interface interface {
 	ClusterNetwork() ClusterNetworkController
 	HostNetworkConfig() HostNetworkConfigController
 	LinkMonitor() LinkMonitorController
+	NetworkChangeLog() NetworkChangeLogController
+	NetworkInterfaceClaim() NetworkInterfaceClaimController
+	NodeNetworkState() NodeNetworkStateController
+	PacketCapture() PacketCaptureController
 	VlanConfig() VlanConfigController
 	VlanStatus() VlanStatusController
 }
@@ -60,6 +64,22 @@ func (v *version) LinkMonitor() LinkMonitorController {
 	return generic.NewNonNamespacedController[*v1beta1.LinkMonitor, *v1beta1.LinkMonitorList](schema.GroupVersionKind{Group: "network.harvesterhci.io", Version: "v1beta1", Kind: "LinkMonitor"}, "linkmonitors", v.controllerFactory)
 }
 
+func (v *version) NetworkChangeLog() NetworkChangeLogController {
+	return generic.NewNonNamespacedController[*v1beta1.NetworkChangeLog, *v1beta1.NetworkChangeLogList](schema.GroupVersionKind{Group: "network.harvesterhci.io", Version: "v1beta1", Kind: "NetworkChangeLog"}, "networkchangelogs", v.controllerFactory)
+}
+
+func (v *version) NetworkInterfaceClaim() NetworkInterfaceClaimController {
+	return generic.NewNonNamespacedController[*v1beta1.NetworkInterfaceClaim, *v1beta1.NetworkInterfaceClaimList](schema.GroupVersionKind{Group: "network.harvesterhci.io", Version: "v1beta1", Kind: "NetworkInterfaceClaim"}, "networkinterfaceclaims", v.controllerFactory)
+}
+
+func (v *version) NodeNetworkState() NodeNetworkStateController {
+	return generic.NewNonNamespacedController[*v1beta1.NodeNetworkState, *v1beta1.NodeNetworkStateList](schema.GroupVersionKind{Group: "network.harvesterhci.io", Version: "v1beta1", Kind: "NodeNetworkState"}, "nodenetworkstates", v.controllerFactory)
+}
+
+func (v *version) PacketCapture() PacketCaptureController {
+	return generic.NewNonNamespacedController[*v1beta1.PacketCapture, *v1beta1.PacketCaptureList](schema.GroupVersionKind{Group: "network.harvesterhci.io", Version: "v1beta1", Kind: "PacketCapture"}, "packetcaptures", v.controllerFactory)
+}
+
 func (v *version) VlanConfig() VlanConfigController {
 	return generic.NewNonNamespacedController[*v1beta1.VlanConfig, *v1beta1.VlanConfigList](schema.GroupVersionKind{Group: "network.harvesterhci.io", Version: "v1beta1", Kind: "VlanConfig"}, "vlanconfigs", v.controllerFactory)
 }
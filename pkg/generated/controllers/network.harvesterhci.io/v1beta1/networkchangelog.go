@@ -0,0 +1,39 @@
+/*
+Copyright 2025 Harvester Network Controller Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/rancher/wrangler/v3/pkg/generic"
+)
+
+// NetworkChangeLogController interface for managing NetworkChangeLog resources.
+type NetworkChangeLogController interface {
+	generic.NonNamespacedControllerInterface[*v1beta1.NetworkChangeLog, *v1beta1.NetworkChangeLogList]
+}
+
+// NetworkChangeLogClient interface for managing NetworkChangeLog resources in Kubernetes.
+type NetworkChangeLogClient interface {
+	generic.NonNamespacedClientInterface[*v1beta1.NetworkChangeLog, *v1beta1.NetworkChangeLogList]
+}
+
+// NetworkChangeLogCache interface for retrieving NetworkChangeLog resources in memory.
+type NetworkChangeLogCache interface {
+	generic.NonNamespacedCacheInterface[*v1beta1.NetworkChangeLog]
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2025 Harvester Network Controller Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1beta1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/rancher/wrangler/v3/pkg/apply"
+	"github.com/rancher/wrangler/v3/pkg/condition"
+	"github.com/rancher/wrangler/v3/pkg/generic"
+	"github.com/rancher/wrangler/v3/pkg/kv"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PacketCaptureController interface for managing PacketCapture resources.
+type PacketCaptureController interface {
+	generic.NonNamespacedControllerInterface[*v1beta1.PacketCapture, *v1beta1.PacketCaptureList]
+}
+
+// PacketCaptureClient interface for managing PacketCapture resources in Kubernetes.
+type PacketCaptureClient interface {
+	generic.NonNamespacedClientInterface[*v1beta1.PacketCapture, *v1beta1.PacketCaptureList]
+}
+
+// PacketCaptureCache interface for retrieving PacketCapture resources in memory.
+type PacketCaptureCache interface {
+	generic.NonNamespacedCacheInterface[*v1beta1.PacketCapture]
+}
+
+// PacketCaptureStatusHandler is executed for every added or modified PacketCapture. Should return the new status to be updated
+type PacketCaptureStatusHandler func(obj *v1beta1.PacketCapture, status v1beta1.PacketCaptureStatus) (v1beta1.PacketCaptureStatus, error)
+
+// PacketCaptureGeneratingHandler is the top-level handler that is executed for every PacketCapture event. It extends PacketCaptureStatusHandler by a returning a slice of child objects to be passed to apply.Apply
+type PacketCaptureGeneratingHandler func(obj *v1beta1.PacketCapture, status v1beta1.PacketCaptureStatus) ([]runtime.Object, v1beta1.PacketCaptureStatus, error)
+
+// RegisterPacketCaptureStatusHandler configures a PacketCaptureController to execute a PacketCaptureStatusHandler for every events observed.
+// If a non-empty condition is provided, it will be updated in the status conditions for every handler execution
+func RegisterPacketCaptureStatusHandler(ctx context.Context, controller PacketCaptureController, condition condition.Cond, name string, handler PacketCaptureStatusHandler) {
+	statusHandler := &packetCaptureStatusHandler{
+		client:    controller,
+		condition: condition,
+		handler:   handler,
+	}
+	controller.AddGenericHandler(ctx, name, generic.FromObjectHandlerToHandler(statusHandler.sync))
+}
+
+// RegisterPacketCaptureGeneratingHandler configures a PacketCaptureController to execute a PacketCaptureGeneratingHandler for every events observed, passing the returned objects to the provided apply.Apply.
+// If a non-empty condition is provided, it will be updated in the status conditions for every handler execution
+func RegisterPacketCaptureGeneratingHandler(ctx context.Context, controller PacketCaptureController, apply apply.Apply,
+	condition condition.Cond, name string, handler PacketCaptureGeneratingHandler, opts *generic.GeneratingHandlerOptions) {
+	statusHandler := &packetCaptureGeneratingHandler{
+		PacketCaptureGeneratingHandler: handler,
+		apply:                          apply,
+		name:                           name,
+		gvk:                            controller.GroupVersionKind(),
+	}
+	if opts != nil {
+		statusHandler.opts = *opts
+	}
+	controller.OnChange(ctx, name, statusHandler.Remove)
+	RegisterPacketCaptureStatusHandler(ctx, controller, condition, name, statusHandler.Handle)
+}
+
+type packetCaptureStatusHandler struct {
+	client    PacketCaptureClient
+	condition condition.Cond
+	handler   PacketCaptureStatusHandler
+}
+
+// sync is executed on every resource addition or modification. Executes the configured handlers and sends the updated status to the Kubernetes API
+func (a *packetCaptureStatusHandler) sync(key string, obj *v1beta1.PacketCapture) (*v1beta1.PacketCapture, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	origStatus := obj.Status.DeepCopy()
+	obj = obj.DeepCopy()
+	newStatus, err := a.handler(obj, obj.Status)
+	if err != nil {
+		// Revert to old status on error
+		newStatus = *origStatus.DeepCopy()
+	}
+
+	if a.condition != "" {
+		if errors.IsConflict(err) {
+			a.condition.SetError(&newStatus, "", nil)
+		} else {
+			a.condition.SetError(&newStatus, "", err)
+		}
+	}
+	if !equality.Semantic.DeepEqual(origStatus, &newStatus) {
+		if a.condition != "" {
+			// Since status has changed, update the lastUpdatedTime
+			a.condition.LastUpdated(&newStatus, time.Now().UTC().Format(time.RFC3339))
+		}
+
+		var newErr error
+		obj.Status = newStatus
+		newObj, newErr := a.client.UpdateStatus(obj)
+		if err == nil {
+			err = newErr
+		}
+		if newErr == nil {
+			obj = newObj
+		}
+	}
+	return obj, err
+}
+
+type packetCaptureGeneratingHandler struct {
+	PacketCaptureGeneratingHandler
+	apply apply.Apply
+	opts  generic.GeneratingHandlerOptions
+	gvk   schema.GroupVersionKind
+	name  string
+	seen  sync.Map
+}
+
+// Remove handles the observed deletion of a resource, cascade deleting every associated resource previously applied
+func (a *packetCaptureGeneratingHandler) Remove(key string, obj *v1beta1.PacketCapture) (*v1beta1.PacketCapture, error) {
+	if obj != nil {
+		return obj, nil
+	}
+
+	obj = &v1beta1.PacketCapture{}
+	obj.Namespace, obj.Name = kv.RSplit(key, "/")
+	obj.SetGroupVersionKind(a.gvk)
+
+	if a.opts.UniqueApplyForResourceVersion {
+		a.seen.Delete(key)
+	}
+
+	return nil, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects()
+}
+
+// Handle executes the configured PacketCaptureGeneratingHandler and pass the resulting objects to apply.Apply, finally returning the new status of the resource
+func (a *packetCaptureGeneratingHandler) Handle(obj *v1beta1.PacketCapture, status v1beta1.PacketCaptureStatus) (v1beta1.PacketCaptureStatus, error) {
+	if !obj.DeletionTimestamp.IsZero() {
+		return status, nil
+	}
+
+	objs, newStatus, err := a.PacketCaptureGeneratingHandler(obj, status)
+	if err != nil {
+		return newStatus, err
+	}
+	if !a.isNewResourceVersion(obj) {
+		return newStatus, nil
+	}
+
+	err = generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects(objs...)
+	if err != nil {
+		return newStatus, err
+	}
+	a.storeResourceVersion(obj)
+	return newStatus, nil
+}
+
+// isNewResourceVersion detects if a specific resource version was already successfully processed.
+// Only used if UniqueApplyForResourceVersion is set in generic.GeneratingHandlerOptions
+func (a *packetCaptureGeneratingHandler) isNewResourceVersion(obj *v1beta1.PacketCapture) bool {
+	if !a.opts.UniqueApplyForResourceVersion {
+		return true
+	}
+
+	// Apply once per resource version
+	key := obj.Namespace + "/" + obj.Name
+	previous, ok := a.seen.Load(key)
+	return !ok || previous != obj.ResourceVersion
+}
+
+// storeResourceVersion keeps track of the latest resource version of an object for which Apply was executed
+// Only used if UniqueApplyForResourceVersion is set in generic.GeneratingHandlerOptions
+func (a *packetCaptureGeneratingHandler) storeResourceVersion(obj *v1beta1.PacketCapture) {
+	if !a.opts.UniqueApplyForResourceVersion {
+		return
+	}
+
+	key := obj.Namespace + "/" + obj.Name
+	a.seen.Store(key, obj.ResourceVersion)
+}
@@ -8,9 +8,12 @@ import (
 	"github.com/insomniacslk/dhcp/dhcpv4"
 	"github.com/insomniacslk/dhcp/dhcpv4/nclient4"
 	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
 )
 
-func obtainCIDRAndGw(iface string, serverAddr net.IP) (*net.IPNet, net.IP, error) {
+// obtainCIDRAndGw returns the CIDR and the gateway the DHCP server offers and the IP of the DHCP server
+func obtainCIDRAndGw(iface string, serverAddr net.IP) (*net.IPNet, net.IP, net.IP, error) {
 	var ack *dhcpv4.DHCPv4
 	var err error
 	if serverAddr != nil {
@@ -20,7 +23,13 @@ func obtainCIDRAndGw(iface string, serverAddr net.IP) (*net.IPNet, net.IP, error
 	}
 
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+
+	// the server identifier is the address the server answers on, the siaddr might be the next server to boot from
+	server := ack.ServerIdentifier()
+	if server == nil {
+		server = ack.ServerIPAddr
 	}
 
 	defaultGateway := net.IP(ack.Options.Get(dhcpv4.OptionRouter))
@@ -28,7 +37,7 @@ func obtainCIDRAndGw(iface string, serverAddr net.IP) (*net.IPNet, net.IP, error
 	cidr.Mask = ack.Options.Get(dhcpv4.OptionSubnetMask)
 	cidr.IP = defaultGateway.Mask(cidr.Mask)
 
-	return cidr, defaultGateway, nil
+	return cidr, defaultGateway, server, nil
 }
 
 func sendDiscoverMessage(iface string) (*dhcpv4.DHCPv4, error) {
@@ -41,11 +50,11 @@ func sendDiscoverMessage(iface string) (*dhcpv4.DHCPv4, error) {
 }
 
 func sendInformMessage(iface string, siaddr net.IP) (*dhcpv4.DHCPv4, error) {
-	l, err := netlink.LinkByName(iface)
+	l, err := network.Handle().LinkByName(iface)
 	if err != nil {
 		return nil, err
 	}
-	addresses, err := netlink.AddrList(l, netlink.FAMILY_V4)
+	addresses, err := network.Handle().AddrList(l, netlink.FAMILY_V4)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package helper
 
 import (
 	"net"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -23,23 +24,66 @@ func New(cniFactory *ctlcni.Factory) *NetHelper {
 	}
 }
 
-func (n *NetHelper) GetVLANLayer3Network(selectedNetwork *nadv1.NetworkSelectionElement, serverIPAddr string) *utils.Layer3NetworkConf {
+func (n *NetHelper) GetVLANLayer3Network(selectedNetwork *nadv1.NetworkSelectionElement, serverIPAddr string,
+	family utils.IPFamily) *utils.Layer3NetworkConf {
 	networkConf := &utils.Layer3NetworkConf{
 		Mode:         utils.Auto,
 		ServerIPAddr: serverIPAddr,
 	}
-	cidr, gw, err := obtainCIDRAndGw(selectedNetwork.InterfaceRequest, net.ParseIP(serverIPAddr))
-	if err == nil {
-		networkConf.CIDR = cidr.String()
-		networkConf.Gateway = gw.String()
-	} else {
-		logrus.Errorf("obtain CIDR and gw using DHCP protocol failed, error: %v", err)
-		networkConf.Connectivity = utils.DHCPFailed
+	if family != utils.IPv4 {
+		networkConf.IPFamily = family
+	}
+
+	if family == utils.IPv4 || family == utils.DualStack {
+		start := time.Now()
+		cidr, gw, server, err := obtainCIDRAndGw(selectedNetwork.InterfaceRequest, net.ParseIP(serverIPAddr))
+		networkConf.DHCPProbe = newDHCPProbe(start, server, err)
+		if err == nil {
+			networkConf.CIDR = cidr.String()
+			networkConf.Gateway = gw.String()
+		} else {
+			logrus.Errorf("obtain CIDR and gw using DHCP protocol failed, error: %v", err)
+			networkConf.Connectivity = utils.DHCPFailed
+		}
+	}
+
+	if family == utils.IPv6 || family == utils.DualStack {
+		cidr, gw, err := obtainIPv6CIDRAndGw(selectedNetwork.InterfaceRequest)
+		connectivity := utils.Connectable
+		if err != nil {
+			logrus.Errorf("obtain IPv6 CIDR and gw using router advertisement failed, error: %v", err)
+			connectivity = utils.RAFailed
+		}
+		// the link-local router can't be pinged from outside the network, it's reachable as it has just advertised
+		if family == utils.IPv6 {
+			if err == nil {
+				networkConf.CIDR, networkConf.Gateway = cidr.String(), gw.String()
+			}
+			networkConf.Connectivity = connectivity
+		} else {
+			if err == nil {
+				networkConf.IPv6CIDR, networkConf.IPv6Gateway = cidr.String(), gw.String()
+			}
+			networkConf.IPv6Connectivity = connectivity
+		}
 	}
 
 	return networkConf
 }
 
+func newDHCPProbe(start time.Time, server net.IP, err error) *utils.DHCPProbe {
+	probe := &utils.DHCPProbe{
+		LatencyMs: time.Since(start).Milliseconds(),
+		Time:      start.UTC().Format(time.RFC3339),
+	}
+	if err != nil {
+		probe.Error = err.Error()
+	} else if server != nil {
+		probe.ServerIP = server.String()
+	}
+	return probe
+}
+
 func (n *NetHelper) RecordToNad(selectedNetwork *nadv1.NetworkSelectionElement, networkConf *utils.Layer3NetworkConf) error {
 	nad, err := n.nadClient.Get(selectedNetwork.Namespace, selectedNetwork.Name, metav1.GetOptions{})
 	if err != nil {
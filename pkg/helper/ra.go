@@ -0,0 +1,131 @@
+package helper
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	raTimeout       = 15 * time.Second
+	raSolicitations = 3
+
+	// the hop limit of the neighbor discovery messages, a message with a lower one has been forwarded by a router
+	ndHopLimit = 255
+
+	raHeaderLen             = 16
+	optionPrefixInformation = 3
+	prefixInformationLen    = 32
+	prefixFlagOnLink        = 0x80
+)
+
+var allRouters = net.ParseIP("ff02::2")
+
+// obtainIPv6CIDRAndGw solicits a router advertisement on the interface. The CIDR is the first on-link prefix advertised
+// and the gateway is the router, whose address is always a link-local one. DHCPv6 carries neither the prefix length nor
+// the routes, so the router advertisement is the source of both.
+func obtainIPv6CIDRAndGw(iface string) (*net.IPNet, net.IP, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	pc := conn.IPv6PacketConn()
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeRouterAdvertisement)
+	if err := pc.SetICMPFilter(&filter); err != nil {
+		return nil, nil, err
+	}
+	if err := pc.SetControlMessage(ipv6.FlagInterface|ipv6.FlagHopLimit, true); err != nil {
+		return nil, nil, err
+	}
+	if err := pc.SetMulticastInterface(ifi); err != nil {
+		return nil, nil, err
+	}
+	if err := pc.SetMulticastHopLimit(ndHopLimit); err != nil {
+		return nil, nil, err
+	}
+
+	// the kernel fills in the checksum of the ICMPv6 messages
+	rs, err := (&icmp.Message{Type: ipv6.ICMPTypeRouterSolicitation, Body: &icmp.RawBody{Data: make([]byte, 4)}}).Marshal(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buf := make([]byte, 1500)
+	for i := 0; i < raSolicitations; i++ {
+		if _, err := pc.WriteTo(rs, nil, &net.IPAddr{IP: allRouters, Zone: iface}); err != nil {
+			return nil, nil, fmt.Errorf("send router solicitation on %s failed, error: %w", iface, err)
+		}
+		if err := pc.SetReadDeadline(time.Now().Add(raTimeout / raSolicitations)); err != nil {
+			return nil, nil, err
+		}
+
+		for {
+			n, cm, src, err := pc.ReadFrom(buf)
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			} else if err != nil {
+				return nil, nil, err
+			}
+			if cm == nil || cm.IfIndex != ifi.Index || cm.HopLimit != ndHopLimit {
+				continue
+			}
+			cidr, err := parseRouterAdvertisement(buf[:n])
+			if err != nil {
+				logrus.Infof("skip router advertisement from %s, error: %v", src, err)
+				continue
+			}
+			addr, ok := src.(*net.IPAddr)
+			if !ok {
+				continue
+			}
+			return cidr, addr.IP, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("no router advertisement received on %s in %s", iface, raTimeout)
+}
+
+// parseRouterAdvertisement returns the first on-link global prefix of the router advertisement, the router has to be a
+// default router to be the gateway of the network
+func parseRouterAdvertisement(b []byte) (*net.IPNet, error) {
+	if len(b) < raHeaderLen || b[0] != byte(ipv6.ICMPTypeRouterAdvertisement) {
+		return nil, fmt.Errorf("not a router advertisement")
+	}
+	if binary.BigEndian.Uint16(b[6:8]) == 0 {
+		return nil, fmt.Errorf("not a default router")
+	}
+
+	for i := raHeaderLen; i+2 <= len(b); {
+		length := int(b[i+1]) * 8
+		if length == 0 || i+length > len(b) {
+			return nil, fmt.Errorf("invalid option at %d", i)
+		}
+		if b[i] == optionPrefixInformation && length == prefixInformationLen && b[i+3]&prefixFlagOnLink != 0 {
+			ones := int(b[i+2])
+			ip := net.IP(b[i+16 : i+32])
+			if ones <= 128 && !ip.IsLinkLocalUnicast() {
+				mask := net.CIDRMask(ones, 128)
+				return &net.IPNet{IP: ip.Mask(mask), Mask: mask}, nil
+			}
+		}
+		i += length
+	}
+
+	return nil, fmt.Errorf("no on-link prefix")
+}
@@ -0,0 +1,48 @@
+package helper
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// routerAdvertisement builds a router advertisement with the router lifetime and the options
+func routerAdvertisement(lifetime uint16, options ...[]byte) []byte {
+	b := []byte{134, 0, 0, 0, 64, 0, byte(lifetime >> 8), byte(lifetime), 0, 0, 0, 0, 0, 0, 0, 0}
+	for _, o := range options {
+		b = append(b, o...)
+	}
+	return b
+}
+
+func prefixInformation(prefix string, ones int, flags byte) []byte {
+	o := make([]byte, prefixInformationLen)
+	o[0], o[1], o[2], o[3] = optionPrefixInformation, prefixInformationLen/8, byte(ones), flags
+	copy(o[16:], net.ParseIP(prefix).To16())
+	return o
+}
+
+func TestParseRouterAdvertisement(t *testing.T) {
+	// the source link-layer address option
+	sourceLinkLayer := []byte{1, 1, 0x52, 0x54, 0, 0x12, 0x34, 0x56}
+
+	cidr, err := parseRouterAdvertisement(routerAdvertisement(1800, sourceLinkLayer,
+		prefixInformation("fe80::", 64, prefixFlagOnLink),
+		prefixInformation("2001:db8:1::", 64, 0),
+		prefixInformation("2001:db8:2::1", 64, prefixFlagOnLink|0x40)))
+	assert.NoError(t, err)
+	assert.Equal(t, "2001:db8:2::/64", cidr.String())
+
+	_, err = parseRouterAdvertisement(routerAdvertisement(0, prefixInformation("2001:db8::", 64, prefixFlagOnLink)))
+	assert.ErrorContains(t, err, "not a default router")
+
+	_, err = parseRouterAdvertisement(routerAdvertisement(1800, sourceLinkLayer))
+	assert.ErrorContains(t, err, "no on-link prefix")
+
+	_, err = parseRouterAdvertisement(routerAdvertisement(1800, []byte{3, 0, 0, 0}))
+	assert.ErrorContains(t, err, "invalid option")
+
+	_, err = parseRouterAdvertisement([]byte{133, 0, 0, 0})
+	assert.Error(t, err)
+}
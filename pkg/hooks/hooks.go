@@ -0,0 +1,258 @@
+// Package hooks runs the external automation around the setup and teardown of the VLAN networks on the node, e.g. to
+// integrate with the external IPAM/DCIM or the switch automation when the uplink membership of the node changes.
+// The hooks are configured in the Harvester setting network-controller-hooks.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Event string
+
+const (
+	EventPreSetup     Event = "preSetup"
+	EventPostSetup    Event = "postSetup"
+	EventPreTeardown  Event = "preTeardown"
+	EventPostTeardown Event = "postTeardown"
+)
+
+var events = []Event{EventPreSetup, EventPostSetup, EventPreTeardown, EventPostTeardown}
+
+type FailurePolicy string
+
+const (
+	// FailurePolicyIgnore logs the failure of the hook and goes on, it's the default
+	FailurePolicyIgnore FailurePolicy = "Ignore"
+	// FailurePolicyFail fails the setup or teardown, which is retried with the hook later
+	FailurePolicyFail FailurePolicy = "Fail"
+)
+
+const (
+	DefaultTimeout = 30 * time.Second
+
+	maxOutputLen = 512
+)
+
+type Config struct {
+	Hooks []Hook `json:"hooks"`
+}
+
+// Hook either executes a command in the agent container or posts the payload to a URL
+type Hook struct {
+	Name    string    `json:"name"`
+	Events  []Event   `json:"events"`
+	Exec    *ExecHook `json:"exec,omitempty"`
+	Webhook *WebHook  `json:"webhook,omitempty"`
+	// 0 means DefaultTimeout
+	TimeoutSeconds int           `json:"timeoutSeconds,omitempty"`
+	FailurePolicy  FailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// ExecHook gets the payload on the stdin and in the environment variables HARVESTER_NETWORK_*, a script on the host
+// can be run with nsenter, e.g. ["nsenter", "-t", "1", "-m", "--", "/opt/hooks/uplink.sh"]
+type ExecHook struct {
+	Command []string `json:"command"`
+}
+
+type WebHook struct {
+	URL                   string `json:"url"`
+	InsecureSkipTLSVerify bool   `json:"insecureSkipTLSVerify,omitempty"`
+}
+
+// Payload describes the uplink membership change of the node
+type Payload struct {
+	Event          Event    `json:"event"`
+	Node           string   `json:"node"`
+	ClusterNetwork string   `json:"clusterNetwork"`
+	VlanConfig     string   `json:"vlanConfig"`
+	NICs           []string `json:"nics,omitempty"`
+}
+
+// Parse parses and validates the hook configuration, an empty value means no hook
+func Parse(value string) (*Config, error) {
+	cfg := &Config{}
+	if strings.TrimSpace(value) == "" {
+		return cfg, nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid hook configuration, error: %w", err)
+	}
+	for i := range cfg.Hooks {
+		if err := cfg.Hooks[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid hook %q, error: %w", cfg.Hooks[i].Name, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func (h *Hook) validate() error {
+	if h.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(h.Events) == 0 {
+		return fmt.Errorf("events are required")
+	}
+	for _, e := range h.Events {
+		if !slices.Contains(events, e) {
+			return fmt.Errorf("unknown event %q, must be one of %v", e, events)
+		}
+	}
+	if (h.Exec == nil) == (h.Webhook == nil) {
+		return fmt.Errorf("exactly one of exec and webhook is required")
+	}
+	if h.Exec != nil && len(h.Exec.Command) == 0 {
+		return fmt.Errorf("exec command is required")
+	}
+	if h.Webhook != nil {
+		u, err := url.Parse(h.Webhook.URL)
+		if err != nil {
+			return fmt.Errorf("invalid webhook URL, error: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("webhook URL %s must be http or https", h.Webhook.URL)
+		}
+	}
+	if h.TimeoutSeconds < 0 {
+		return fmt.Errorf("timeoutSeconds %d can't be negative", h.TimeoutSeconds)
+	}
+	switch h.FailurePolicy {
+	case "", FailurePolicyIgnore, FailurePolicyFail:
+	default:
+		return fmt.Errorf("unknown failure policy %q", h.FailurePolicy)
+	}
+
+	return nil
+}
+
+func (h *Hook) timeout() time.Duration {
+	if h.TimeoutSeconds == 0 {
+		return DefaultTimeout
+	}
+	return time.Duration(h.TimeoutSeconds) * time.Second
+}
+
+// Loader loads the latest hook configuration
+type Loader func(ctx context.Context) (*Config, error)
+
+type Runner struct {
+	load Loader
+}
+
+// NewRunner returns a runner loading the configuration on every run, so that the changes take effect immediately.
+// A nil runner runs nothing.
+func NewRunner(load Loader) *Runner {
+	return &Runner{load: load}
+}
+
+// Run runs the hooks of the event in order and stops at the first failed hook whose failure policy is Fail
+func (r *Runner) Run(ctx context.Context, payload *Payload) error {
+	if r == nil || r.load == nil {
+		return nil
+	}
+	cfg, err := r.load(ctx)
+	if err != nil {
+		return fmt.Errorf("load hooks failed, error: %w", err)
+	}
+
+	for i := range cfg.Hooks {
+		hook := &cfg.Hooks[i]
+		if !slices.Contains(hook.Events, payload.Event) {
+			continue
+		}
+		logrus.Infof("run %s hook %s for cluster network %s on node %s", payload.Event, hook.Name,
+			payload.ClusterNetwork, payload.Node)
+		if err := run(ctx, hook, payload); err != nil {
+			if hook.FailurePolicy == FailurePolicyFail {
+				return fmt.Errorf("%s hook %s failed, error: %w", payload.Event, hook.Name, err)
+			}
+			logrus.Warnf("ignore the failure of %s hook %s, error: %s", payload.Event, hook.Name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func run(ctx context.Context, hook *Hook, payload *Payload) error {
+	ctx, cancel := context.WithTimeout(ctx, hook.timeout())
+	defer cancel()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if hook.Exec != nil {
+		return runExec(ctx, hook.Exec, payload, body)
+	}
+	return hook.Webhook.Post(ctx, body)
+}
+
+func runExec(ctx context.Context, e *ExecHook, payload *Payload, body []byte) error {
+	cmd := exec.CommandContext(ctx, e.Command[0], e.Command[1:]...) //nolint:gosec
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"HARVESTER_NETWORK_EVENT="+string(payload.Event),
+		"HARVESTER_NETWORK_NODE="+payload.Node,
+		"HARVESTER_NETWORK_CLUSTER_NETWORK="+payload.ClusterNetwork,
+		"HARVESTER_NETWORK_VLANCONFIG="+payload.VlanConfig,
+		"HARVESTER_NETWORK_NICS="+strings.Join(payload.NICs, ","),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w, output: %s", err, truncate(output))
+	}
+
+	return nil
+}
+
+// Post posts the JSON body to the URL, any status other than 2xx is an error
+func (w *WebHook) Post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := http.DefaultClient
+	if w.InsecureSkipTLSVerify {
+		client = &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+		}}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		output, _ := io.ReadAll(io.LimitReader(resp.Body, maxOutputLen))
+		return fmt.Errorf("unexpected status %s, response: %s", resp.Status, truncate(output))
+	}
+
+	return nil
+}
+
+func truncate(output []byte) string {
+	s := strings.TrimSpace(string(output))
+	if len(s) > maxOutputLen {
+		return s[:maxOutputLen] + "..."
+	}
+	return s
+}
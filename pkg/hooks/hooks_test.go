@@ -0,0 +1,184 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		errKey string
+	}{
+		{
+			name:  "empty value means no hook",
+			value: "",
+		},
+		{
+			name:  "exec and webhook hooks",
+			value: `{"hooks":[{"name":"dcim","events":["postSetup","postTeardown"],"webhook":{"url":"https://dcim.example.com/hook"}},{"name":"switch","events":["preSetup"],"exec":{"command":["/bin/true"]},"failurePolicy":"Fail"}]}`,
+		},
+		{
+			name:   "unknown field",
+			value:  `{"hooks":[{"name":"dcim","event":["postSetup"]}]}`,
+			errKey: "unknown field",
+		},
+		{
+			name:   "unknown event",
+			value:  `{"hooks":[{"name":"dcim","events":["setup"],"exec":{"command":["/bin/true"]}}]}`,
+			errKey: "unknown event",
+		},
+		{
+			name:   "both exec and webhook",
+			value:  `{"hooks":[{"name":"dcim","events":["preSetup"],"exec":{"command":["/bin/true"]},"webhook":{"url":"http://a"}}]}`,
+			errKey: "exactly one of exec and webhook",
+		},
+		{
+			name:   "webhook URL without scheme",
+			value:  `{"hooks":[{"name":"dcim","events":["preSetup"],"webhook":{"url":"dcim.example.com"}}]}`,
+			errKey: "must be http or https",
+		},
+		{
+			name:   "unknown failure policy",
+			value:  `{"hooks":[{"name":"dcim","events":["preSetup"],"exec":{"command":["/bin/true"]},"failurePolicy":"Retry"}]}`,
+			errKey: "unknown failure policy",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.value)
+			if tc.errKey == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.errKey)
+			}
+		})
+	}
+}
+
+func staticLoader(cfg *Config) Loader {
+	return func(context.Context) (*Config, error) {
+		return cfg, nil
+	}
+}
+
+func TestRunExec(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	payload := &Payload{
+		Event:          EventPreSetup,
+		Node:           "node1",
+		ClusterNetwork: "cn",
+		VlanConfig:     "vc",
+		NICs:           []string{"eth1", "eth2"},
+	}
+	runner := NewRunner(staticLoader(&Config{Hooks: []Hook{
+		{
+			Name:   "record",
+			Events: []Event{EventPreSetup},
+			Exec:   &ExecHook{Command: []string{"sh", "-c", `echo "$HARVESTER_NETWORK_EVENT $HARVESTER_NETWORK_NICS" > ` + out}},
+		},
+		{
+			Name:   "not run",
+			Events: []Event{EventPostSetup},
+			Exec:   &ExecHook{Command: []string{"false"}},
+			// the hook of another event fails the run if it's run
+			FailurePolicy: FailurePolicyFail,
+		},
+	}}))
+
+	assert.NoError(t, runner.Run(context.Background(), payload))
+	content, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "preSetup eth1,eth2\n", string(content))
+}
+
+func TestRunFailurePolicy(t *testing.T) {
+	payload := &Payload{Event: EventPreTeardown}
+	failed := Hook{Name: "failed", Events: []Event{EventPreTeardown}, Exec: &ExecHook{Command: []string{"sh", "-c", "echo oops; exit 1"}}}
+
+	assert.NoError(t, NewRunner(staticLoader(&Config{Hooks: []Hook{failed}})).Run(context.Background(), payload))
+
+	failed.FailurePolicy = FailurePolicyFail
+	err := NewRunner(staticLoader(&Config{Hooks: []Hook{failed}})).Run(context.Background(), payload)
+	assert.ErrorContains(t, err, "preTeardown hook failed failed")
+	assert.ErrorContains(t, err, "output: oops")
+
+	// a nil runner runs nothing
+	var runner *Runner
+	assert.NoError(t, runner.Run(context.Background(), payload))
+}
+
+func TestRunWebhook(t *testing.T) {
+	var received Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if received.Event == EventPostTeardown {
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	hook := Hook{
+		Name:          "dcim",
+		Events:        []Event{EventPostSetup, EventPostTeardown},
+		Webhook:       &WebHook{URL: server.URL},
+		FailurePolicy: FailurePolicyFail,
+	}
+	runner := NewRunner(staticLoader(&Config{Hooks: []Hook{hook}}))
+
+	payload := &Payload{Event: EventPostSetup, Node: "node1", ClusterNetwork: "cn", VlanConfig: "vc", NICs: []string{"eth1"}}
+	assert.NoError(t, runner.Run(context.Background(), payload))
+	assert.Equal(t, *payload, received)
+
+	err := runner.Run(context.Background(), &Payload{Event: EventPostTeardown})
+	assert.ErrorContains(t, err, "unexpected status 404")
+}
+
+func TestSettingInformer(t *testing.T) {
+	setting := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "harvesterhci.io/v1beta1",
+		"kind":       "Setting",
+		"metadata":   map[string]interface{}{"name": SettingName},
+		"default":    `{"hooks":[]}`,
+	}}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{settingResource: "SettingList"}, setting)
+
+	informer := NewSettingInformer(client, SettingName)
+	changed := make(chan struct{}, 10)
+	assert.NoError(t, informer.OnChange(func() { changed <- struct{}{} }))
+	value, err := informer.Get()
+	assert.NoError(t, err)
+	assert.Empty(t, value, "the value is empty before the cache is synced")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	informer.Run(ctx)
+	<-changed
+	value, err = informer.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"hooks":[]}`, value, "the default is taken if the value is empty")
+
+	setting.Object["value"] = `{"hooks":[{"name":"dcim"}]}`
+	_, err = client.Resource(settingResource).Update(ctx, setting, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	<-changed
+	value, err = informer.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"hooks":[{"name":"dcim"}]}`, value)
+}
@@ -0,0 +1,116 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// SettingName is the Harvester setting holding the hook configuration in JSON
+const SettingName = "network-controller-hooks"
+
+// the Harvester settings are read as unstructured objects to avoid depending on the Harvester API
+var settingResource = schema.GroupVersionResource{
+	Group:    "harvesterhci.io",
+	Version:  "v1beta1",
+	Resource: "settings",
+}
+
+// SettingLoader loads the hook configuration from the value of the Harvester setting, or its default if the value is
+// empty. There is no hook if the setting doesn't exist, e.g. the cluster isn't a Harvester cluster.
+func SettingLoader(client dynamic.Interface, name string) Loader {
+	return func(ctx context.Context) (*Config, error) {
+		value, err := GetSetting(ctx, client, name)
+		if err != nil {
+			return nil, err
+		}
+
+		return Parse(value)
+	}
+}
+
+// GetSetting returns the value of the Harvester setting, or its default if the value is empty. It's empty if the
+// setting doesn't exist.
+func GetSetting(ctx context.Context, client dynamic.Interface, name string) (string, error) {
+	setting, err := client.Resource(settingResource).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return settingValue(setting), nil
+}
+
+func settingValue(setting *unstructured.Unstructured) string {
+	if value, _, _ := unstructured.NestedString(setting.Object, "value"); value != "" {
+		return value
+	}
+	value, _, _ := unstructured.NestedString(setting.Object, "default")
+	return value
+}
+
+// SettingInformer watches the Harvester setting, so that its value is read from the cache rather than from the API
+// server every time it's needed
+type SettingInformer struct {
+	name     string
+	informer cache.SharedIndexInformer
+}
+
+func NewSettingInformer(client dynamic.Interface, name string) *SettingInformer {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, 0, metav1.NamespaceAll,
+		func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		})
+	informer := factory.ForResource(settingResource).Informer()
+	// the settings don't exist if the cluster isn't a Harvester cluster, which isn't worth a warning every retry
+	_ = informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			logrus.Debugf("failed to watch setting %s, error: %s", name, err.Error())
+			return
+		}
+		logrus.Warnf("failed to watch setting %s, error: %s", name, err.Error())
+	})
+
+	return &SettingInformer{name: name, informer: informer}
+}
+
+// OnChange calls the handler whenever the setting is created, updated or deleted. It must be called before Run.
+func (s *SettingInformer) OnChange(handler func()) error {
+	_, err := s.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { handler() },
+		UpdateFunc: func(interface{}, interface{}) { handler() },
+		DeleteFunc: func(interface{}) { handler() },
+	})
+	return err
+}
+
+// Run watches the setting in the background until the context is done
+func (s *SettingInformer) Run(ctx context.Context) {
+	go s.informer.Run(ctx.Done())
+}
+
+// Get returns the value of the setting as GetSetting does, but from the cache. It's empty before the cache is synced,
+// the handler passed to OnChange is called once it is.
+func (s *SettingInformer) Get() (string, error) {
+	obj, exists, err := s.informer.GetStore().GetByKey(s.name)
+	if err != nil || !exists {
+		return "", err
+	}
+	setting, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return "", fmt.Errorf("unexpected object %T of setting %s", obj, s.name)
+	}
+
+	return settingValue(setting), nil
+}
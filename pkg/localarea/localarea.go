@@ -0,0 +1,87 @@
+// Package localarea collects the VLAN IDs, the local areas, the agent programs on the uplinks of a cluster network.
+// The NADs of the cluster network are one source of them, the others, e.g. the trunk CRDs or an external IPAM, are
+// added as another Source without changing the controllers programming the uplinks.
+package localarea
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// Source contributes the VLAN IDs required on the uplinks of the cluster networks
+type Source interface {
+	// Name identifies the source in the errors
+	Name() string
+	// VIDs returns the VLAN IDs the source requires on the uplinks of the cluster network
+	VIDs(cn *networkv1.ClusterNetwork) ([]uint16, error)
+}
+
+// Watcher is implemented by the sources whose VLAN IDs change without the cluster network changing, e.g. the
+// allocations of an external IPAM. Watch runs until the context is done and calls enqueue with the name of the
+// cluster network whose VLAN IDs changed.
+type Watcher interface {
+	Watch(ctx context.Context, enqueue func(cnName string))
+}
+
+// Sources is the union of the sources, it's safe for concurrent use
+type Sources struct {
+	mutex   sync.RWMutex
+	sources []Source
+	// set once the watchers are started, the watchers of the sources added afterwards are started right away
+	ctx     context.Context
+	enqueue func(cnName string)
+}
+
+func NewSources(sources ...Source) *Sources {
+	return &Sources{sources: sources}
+}
+
+// Add adds the source, the cluster networks are only reconciled with it on their next change unless it's a Watcher
+func (s *Sources) Add(source Source) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sources = append(s.sources, source)
+	if w, ok := source.(Watcher); ok && s.ctx != nil {
+		go w.Watch(s.ctx, s.enqueue)
+	}
+}
+
+// Watch starts the watchers among the sources, it's called once
+func (s *Sources) Watch(ctx context.Context, enqueue func(cnName string)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ctx, s.enqueue = ctx, enqueue
+	for _, source := range s.sources {
+		if w, ok := source.(Watcher); ok {
+			go w.Watch(ctx, enqueue)
+		}
+	}
+}
+
+// VlanIDSetOf returns the VLAN IDs all the sources require on the uplinks of the cluster network. It fails if any
+// source fails, as the VLAN IDs missing from a failed source would be removed from the uplinks.
+func (s *Sources) VlanIDSetOf(cn *networkv1.ClusterNetwork) (*utils.VlanIDSet, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	vis := utils.NewVlanIDSet()
+	for _, source := range s.sources {
+		vids, err := source.VIDs(cn)
+		if err != nil {
+			return nil, fmt.Errorf("source %s failed to get the vids of cluster network %s, error: %w", source.Name(), cn.Name, err)
+		}
+		for _, vid := range vids {
+			if err := vis.SetUint16VID(vid); err != nil {
+				return nil, fmt.Errorf("source %s has invalid vid %d of cluster network %s, error: %w", source.Name(), vid, cn.Name, err)
+			}
+		}
+	}
+
+	return vis, nil
+}
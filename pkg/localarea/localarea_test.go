@@ -0,0 +1,118 @@
+package localarea
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+type fakeSource struct {
+	vids []uint16
+	err  error
+	// the cluster network the watcher enqueues
+	changed string
+}
+
+func (f *fakeSource) Name() string {
+	return "fake"
+}
+
+func (f *fakeSource) VIDs(_ *networkv1.ClusterNetwork) ([]uint16, error) {
+	return f.vids, f.err
+}
+
+func (f *fakeSource) Watch(_ context.Context, enqueue func(cnName string)) {
+	enqueue(f.changed)
+}
+
+func TestVlanIDSetOf(t *testing.T) {
+	cn := &networkv1.ClusterNetwork{
+		ObjectMeta: metav1.ObjectMeta{Name: "vlan"},
+		Spec:       networkv1.ClusterNetworkSpec{StaticVIDs: []uint16{100, 10}},
+	}
+
+	tests := []struct {
+		name      string
+		sources   []Source
+		cn        *networkv1.ClusterNetwork
+		expected  []uint16
+		returnErr bool
+	}{
+		{
+			name:     "no source",
+			expected: []uint16{},
+		},
+		{
+			name:     "the static VIDs",
+			sources:  []Source{StaticSource},
+			expected: []uint16{10, 100},
+		},
+		{
+			name:     "the untagged VID",
+			sources:  []Source{StaticSource},
+			cn:       &networkv1.ClusterNetwork{Spec: networkv1.ClusterNetworkSpec{StaticVIDs: []uint16{10}, UntaggedVID: 5}},
+			expected: []uint16{5, 10},
+		},
+		{
+			name:     "the union of the sources",
+			sources:  []Source{StaticSource, &fakeSource{vids: []uint16{10, 20}}},
+			expected: []uint16{10, 20, 100},
+		},
+		{
+			name:      "a failed source fails the union",
+			sources:   []Source{StaticSource, &fakeSource{err: errors.New("unreachable")}},
+			returnErr: true,
+		},
+		{
+			name:      "a source with an invalid VID",
+			sources:   []Source{&fakeSource{vids: []uint16{4095}}},
+			returnErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.cn == nil {
+				tc.cn = cn
+			}
+			vis, err := NewSources(tc.sources...).VlanIDSetOf(tc.cn)
+			assert.Equal(t, tc.returnErr, err != nil)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.expected, append([]uint16{}, vis.VIDs()...))
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	enqueued := make(chan string, 2)
+	enqueue := func(cnName string) { enqueued <- cnName }
+
+	sources := NewSources(StaticSource, &fakeSource{changed: "a"})
+	sources.Watch(ctx, enqueue)
+	assert.Equal(t, "a", receive(t, enqueued))
+
+	// the watcher of a source added afterwards starts right away
+	sources.Add(&fakeSource{changed: "b"})
+	assert.Equal(t, "b", receive(t, enqueued))
+}
+
+func receive(t *testing.T, enqueued chan string) string {
+	select {
+	case name := <-enqueued:
+		return name
+	case <-time.After(time.Second):
+		t.Fatal("no cluster network is enqueued")
+		return ""
+	}
+}
@@ -0,0 +1,81 @@
+package localarea
+
+import (
+	"slices"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	nadSourceName    = "nad"
+	staticSourceName = "static"
+)
+
+// NewNadSource returns the source of the VLAN IDs of the bridge NADs of the cluster network, including the ones kept
+// for the VMs still using them
+func NewNadSource(nadCache ctlcniv1.NetworkAttachmentDefinitionCache) Source {
+	return &nadSource{nadCache: nadCache}
+}
+
+type nadSource struct {
+	nadCache ctlcniv1.NetworkAttachmentDefinitionCache
+}
+
+func (n *nadSource) Name() string {
+	return nadSourceName
+}
+
+func (n *nadSource) VIDs(cn *networkv1.ClusterNetwork) ([]uint16, error) {
+	vis, err := utils.GetVlanIDSetOfClusterNetwork(cn, n.nadCache)
+	if err != nil {
+		return nil, err
+	}
+	return vis.VIDs(), nil
+}
+
+// NewNadListSource is NewNadSource with the NADs of the cluster network listed by the caller, e.g. from the manifests
+// rendered offline
+func NewNadListSource(nads []*nadv1.NetworkAttachmentDefinition) Source {
+	return &nadListSource{nads: nads}
+}
+
+type nadListSource struct {
+	nads []*nadv1.NetworkAttachmentDefinition
+}
+
+func (n *nadListSource) Name() string {
+	return nadSourceName
+}
+
+func (n *nadListSource) VIDs(cn *networkv1.ClusterNetwork) ([]uint16, error) {
+	vis, err := utils.VlanIDSetOfClusterNetwork(cn, n.nads)
+	if err != nil {
+		return nil, err
+	}
+	return vis.VIDs(), nil
+}
+
+// StaticSource is the source of the static VLAN IDs and the untagged VLAN ID in the spec of the cluster network
+var StaticSource Source = staticSource{}
+
+type staticSource struct{}
+
+func (staticSource) Name() string {
+	return staticSourceName
+}
+
+func (staticSource) VIDs(cn *networkv1.ClusterNetwork) ([]uint16, error) {
+	if cn.Spec.UntaggedVID > utils.DefaultVlanID {
+		return append(slices.Clone(cn.Spec.StaticVIDs), cn.Spec.UntaggedVID), nil
+	}
+	return cn.Spec.StaticVIDs, nil
+}
+
+// NewDefaultSources returns the sources the controllers start with, further sources are added with Sources.Add
+func NewDefaultSources(nadCache ctlcniv1.NetworkAttachmentDefinitionCache) *Sources {
+	return NewSources(NewNadSource(nadCache), StaticSource)
+}
@@ -0,0 +1,177 @@
+// Package matcher computes which nodes a vlanconfig matches, which vlanconfigs overlap on a node and which one of
+// the overlapping vlanconfigs takes effect. It's shared by the webhook, the manager and the agent so that they
+// always agree on the matched nodes.
+package matcher
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// Selector returns the label selector of the node selector, the witness nodes are always excluded because they
+// don't run workloads
+func Selector(nodeSelector map[string]string) (labels.Selector, error) {
+	requirements := make([]labels.Requirement, 0, len(nodeSelector)+1)
+	for key, value := range nodeSelector {
+		req, err := labels.NewRequirement(key, selection.Equals, []string{value})
+		if err != nil {
+			return nil, fmt.Errorf("invalid node selector %s=%s, error: %w", key, value, err)
+		}
+		requirements = append(requirements, *req)
+	}
+	witnessFilter, err := labels.NewRequirement(utils.HarvesterWitnessNodeLabelKey, selection.DoesNotExist, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return labels.NewSelector().Add(append(requirements, *witnessFilter)...), nil
+}
+
+// MatchesNode returns true if the node with the labels is matched by the node selector.
+// An empty node selector matches all nodes except the witness nodes.
+func MatchesNode(nodeSelector, nodeLabels map[string]string) bool {
+	if _, ok := nodeLabels[utils.HarvesterWitnessNodeLabelKey]; ok {
+		return false
+	}
+	for key, value := range nodeSelector {
+		if v, ok := nodeLabels[key]; !ok || v != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatchedNodes returns the sorted names of the nodes matched by the node selector
+func MatchedNodes(nodeSelector map[string]string, nodes map[string]map[string]string) []string {
+	matched := make([]string, 0, len(nodes))
+	for name, nodeLabels := range nodes {
+		if MatchesNode(nodeSelector, nodeLabels) {
+			matched = append(matched, name)
+		}
+	}
+	sort.Strings(matched)
+
+	return matched
+}
+
+// DecodeMatchedNodes parses the value of the annotation KeyMatchedNodes, an empty value means no node is matched
+func DecodeMatchedNodes(value string) ([]string, error) {
+	return annotations.DecodeMatchedNodes(value)
+}
+
+// EncodeMatchedNodes returns the value of the annotation KeyMatchedNodes, the nodes are sorted and deduplicated so
+// that the value is stable
+func EncodeMatchedNodes(nodes []string) (string, error) {
+	return annotations.EncodeMatchedNodes(nodes)
+}
+
+// MatchedNodesOf returns the matched nodes recorded in the annotation of the vlanconfig
+func MatchedNodesOf(vc *networkv1.VlanConfig) ([]string, error) {
+	if vc == nil {
+		return nil, nil
+	}
+
+	return annotations.GetMatchedNodes(vc)
+}
+
+// IsMatched returns true if the vlanconfig records the node as matched
+func IsMatched(vc *networkv1.VlanConfig, node string) (bool, error) {
+	nodes, err := MatchedNodesOf(vc)
+	if err != nil {
+		return false, err
+	}
+	for _, n := range nodes {
+		if n == node {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Overlap is a node matched by more than one vlanconfig of the same cluster network
+type Overlap struct {
+	ClusterNetwork string
+	Node           string
+	// sorted names of the vlanconfigs
+	VlanConfigs []string
+}
+
+// Overlaps returns the overlaps of the vlanconfigs sorted by the cluster network and the node.
+// The vlanconfigs being deleted are ignored.
+func Overlaps(vcs []*networkv1.VlanConfig) ([]Overlap, error) {
+	// cluster network -> node -> vlanconfigs
+	users := make(map[string]map[string][]string)
+	for _, vc := range vcs {
+		if vc.DeletionTimestamp != nil {
+			continue
+		}
+		nodes, err := MatchedNodesOf(vc)
+		if err != nil {
+			return nil, fmt.Errorf("vlanconfig %s: %w", vc.Name, err)
+		}
+		cn := vc.Spec.ClusterNetwork
+		if users[cn] == nil {
+			users[cn] = make(map[string][]string)
+		}
+		for _, node := range nodes {
+			users[cn][node] = append(users[cn][node], vc.Name)
+		}
+	}
+
+	overlaps := make([]Overlap, 0)
+	for cn, nodes := range users {
+		for node, names := range nodes {
+			if len(names) < 2 {
+				continue
+			}
+			sort.Strings(names)
+			overlaps = append(overlaps, Overlap{ClusterNetwork: cn, Node: node, VlanConfigs: names})
+		}
+	}
+	sort.Slice(overlaps, func(i, j int) bool {
+		if overlaps[i].ClusterNetwork != overlaps[j].ClusterNetwork {
+			return overlaps[i].ClusterNetwork < overlaps[j].ClusterNetwork
+		}
+		return overlaps[i].Node < overlaps[j].Node
+	})
+
+	return overlaps, nil
+}
+
+// Resolve returns the vlanconfig which takes effect on a node among the candidates matching the node with the same
+// cluster network, it's nil if there is no candidate. The active vlanconfig, i.e. the one having taken effect,
+// keeps taking effect, otherwise the oldest one wins and the name breaks the tie.
+// The vlanconfigs being deleted never win.
+func Resolve(candidates []*networkv1.VlanConfig, active string) *networkv1.VlanConfig {
+	var winner *networkv1.VlanConfig
+	for _, vc := range candidates {
+		if vc == nil || vc.DeletionTimestamp != nil {
+			continue
+		}
+		if vc.Name == active {
+			return vc
+		}
+		if winner == nil || precedes(vc, winner) {
+			winner = vc
+		}
+	}
+
+	return winner
+}
+
+func precedes(a, b *networkv1.VlanConfig) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	return a.Name < b.Name
+}
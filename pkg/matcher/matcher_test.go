@@ -0,0 +1,387 @@
+package matcher
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	testZoneKey = "topology.kubernetes.io/zone"
+	testRackKey = "rack"
+)
+
+var testNodes = map[string]map[string]string{
+	"node1":   {testZoneKey: "zone1", testRackKey: "rack1"},
+	"node2":   {testZoneKey: "zone1", testRackKey: "rack2"},
+	"node3":   {testZoneKey: "zone2"},
+	"node4":   {},
+	"witness": {testZoneKey: "zone1", utils.HarvesterWitnessNodeLabelKey: utils.ValueTrue},
+	// the witness node is excluded no matter what the label value is
+	"witness-false": {testZoneKey: "zone1", utils.HarvesterWitnessNodeLabelKey: "false"},
+}
+
+func TestMatchedNodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodeSelector map[string]string
+		expected     []string
+	}{
+		{
+			name:     "nil selector matches all nodes except the witness nodes",
+			expected: []string{"node1", "node2", "node3", "node4"},
+		},
+		{
+			name:         "empty selector matches all nodes except the witness nodes",
+			nodeSelector: map[string]string{},
+			expected:     []string{"node1", "node2", "node3", "node4"},
+		},
+		{
+			name:         "single label",
+			nodeSelector: map[string]string{testZoneKey: "zone1"},
+			expected:     []string{"node1", "node2"},
+		},
+		{
+			name:         "all labels are required",
+			nodeSelector: map[string]string{testZoneKey: "zone1", testRackKey: "rack2"},
+			expected:     []string{"node2"},
+		},
+		{
+			name:         "empty value doesn't match a missing label",
+			nodeSelector: map[string]string{testRackKey: ""},
+			expected:     []string{},
+		},
+		{
+			name:         "no node is matched",
+			nodeSelector: map[string]string{testZoneKey: "zone3"},
+			expected:     []string{},
+		},
+		{
+			name:         "witness node can't be selected explicitly",
+			nodeSelector: map[string]string{utils.HarvesterWitnessNodeLabelKey: utils.ValueTrue},
+			expected:     []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, MatchedNodes(tc.nodeSelector, testNodes))
+
+			// the label selector used to list the nodes from the cache agrees with MatchesNode
+			selector, err := Selector(tc.nodeSelector)
+			assert.Nil(t, err)
+			for name, nodeLabels := range testNodes {
+				assert.Equal(t, MatchesNode(tc.nodeSelector, nodeLabels), selector.Matches(labels.Set(nodeLabels)), name)
+			}
+		})
+	}
+}
+
+func TestSelectorInvalid(t *testing.T) {
+	_, err := Selector(map[string]string{"invalid key!": "value"})
+	assert.NotNil(t, err)
+	_, err = Selector(map[string]string{testZoneKey: "invalid value!"})
+	assert.NotNil(t, err)
+}
+
+func TestMatchedNodesCodec(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		returnErr bool
+		expected  []string
+	}{
+		{
+			name:  "empty value",
+			value: "",
+		},
+		{
+			name:     "empty list",
+			value:    "[]",
+			expected: []string{},
+		},
+		{
+			name:     "nodes",
+			value:    "[\"node2\",\"node1\"]",
+			expected: []string{"node2", "node1"},
+		},
+		{
+			name:      "invalid value",
+			value:     "node1",
+			returnErr: true,
+		},
+		{
+			name:      "not a list of strings",
+			value:     "[1]",
+			returnErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes, err := DecodeMatchedNodes(tc.value)
+			assert.Equal(t, tc.returnErr, err != nil)
+			if !tc.returnErr {
+				assert.Equal(t, tc.expected, nodes)
+			}
+		})
+	}
+
+	value, err := EncodeMatchedNodes([]string{"node2", "node1", "node2"})
+	assert.Nil(t, err)
+	assert.Equal(t, "[\"node1\",\"node2\"]", value)
+
+	value, err = EncodeMatchedNodes(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "[]", value)
+}
+
+func newVlanConfig(name, cn string, created int, nodes ...string) *networkv1.VlanConfig {
+	value, _ := EncodeMatchedNodes(nodes)
+	return &networkv1.VlanConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(time.Unix(int64(created), 0)),
+			Annotations:       map[string]string{utils.KeyMatchedNodes: value},
+		},
+		Spec: networkv1.VlanConfigSpec{
+			ClusterNetwork: cn,
+		},
+	}
+}
+
+func TestIsMatched(t *testing.T) {
+	vc := newVlanConfig("vc1", "cn1", 0, "node1", "node2")
+
+	isMatched, err := IsMatched(vc, "node1")
+	assert.Nil(t, err)
+	assert.True(t, isMatched)
+
+	isMatched, err = IsMatched(vc, "node3")
+	assert.Nil(t, err)
+	assert.False(t, isMatched)
+
+	isMatched, err = IsMatched(&networkv1.VlanConfig{}, "node1")
+	assert.Nil(t, err)
+	assert.False(t, isMatched)
+
+	isMatched, err = IsMatched(nil, "node1")
+	assert.Nil(t, err)
+	assert.False(t, isMatched)
+
+	vc.Annotations[utils.KeyMatchedNodes] = "node1"
+	_, err = IsMatched(vc, "node1")
+	assert.NotNil(t, err)
+}
+
+func TestOverlaps(t *testing.T) {
+	deleting := newVlanConfig("vc5", "cn1", 0, "node1", "node2", "node3")
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Unix(10, 0)}
+
+	tests := []struct {
+		name      string
+		vcs       []*networkv1.VlanConfig
+		returnErr bool
+		expected  []Overlap
+	}{
+		{
+			name:     "no vlanconfig",
+			expected: []Overlap{},
+		},
+		{
+			name: "disjoint nodes",
+			vcs: []*networkv1.VlanConfig{
+				newVlanConfig("vc1", "cn1", 0, "node1"),
+				newVlanConfig("vc2", "cn1", 0, "node2"),
+			},
+			expected: []Overlap{},
+		},
+		{
+			name: "different cluster networks never overlap",
+			vcs: []*networkv1.VlanConfig{
+				newVlanConfig("vc1", "cn1", 0, "node1"),
+				newVlanConfig("vc2", "cn2", 0, "node1"),
+			},
+			expected: []Overlap{},
+		},
+		{
+			name: "overlaps are sorted by cluster network and node",
+			vcs: []*networkv1.VlanConfig{
+				newVlanConfig("vc4", "cn2", 0, "node1"),
+				newVlanConfig("vc3", "cn2", 0, "node1"),
+				newVlanConfig("vc2", "cn1", 0, "node2", "node3"),
+				newVlanConfig("vc1", "cn1", 0, "node1", "node2", "node3"),
+				newVlanConfig("vc6", "cn1", 0, "node3"),
+			},
+			expected: []Overlap{
+				{ClusterNetwork: "cn1", Node: "node2", VlanConfigs: []string{"vc1", "vc2"}},
+				{ClusterNetwork: "cn1", Node: "node3", VlanConfigs: []string{"vc1", "vc2", "vc6"}},
+				{ClusterNetwork: "cn2", Node: "node1", VlanConfigs: []string{"vc3", "vc4"}},
+			},
+		},
+		{
+			name: "vlanconfig being deleted is ignored",
+			vcs: []*networkv1.VlanConfig{
+				newVlanConfig("vc1", "cn1", 0, "node1"),
+				deleting,
+			},
+			expected: []Overlap{},
+		},
+		{
+			name: "invalid matched nodes",
+			vcs: []*networkv1.VlanConfig{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "vc1",
+						Annotations: map[string]string{utils.KeyMatchedNodes: "node1"},
+					},
+				},
+			},
+			returnErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			overlaps, err := Overlaps(tc.vcs)
+			assert.Equal(t, tc.returnErr, err != nil)
+			if !tc.returnErr {
+				assert.Equal(t, tc.expected, overlaps)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	oldest := newVlanConfig("vc-b", "cn1", 1, "node1")
+	sameAge := newVlanConfig("vc-a", "cn1", 1, "node1")
+	newest := newVlanConfig("vc-c", "cn1", 2, "node1")
+	deleting := newVlanConfig("vc-0", "cn1", 0, "node1")
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Unix(10, 0)}
+
+	tests := []struct {
+		name       string
+		candidates []*networkv1.VlanConfig
+		active     string
+		expected   *networkv1.VlanConfig
+	}{
+		{
+			name: "no candidate",
+		},
+		{
+			name:       "single candidate",
+			candidates: []*networkv1.VlanConfig{newest},
+			expected:   newest,
+		},
+		{
+			name:       "the oldest wins",
+			candidates: []*networkv1.VlanConfig{newest, oldest},
+			expected:   oldest,
+		},
+		{
+			name:       "the name breaks the tie",
+			candidates: []*networkv1.VlanConfig{newest, oldest, sameAge},
+			expected:   sameAge,
+		},
+		{
+			name:       "the active one keeps taking effect",
+			candidates: []*networkv1.VlanConfig{oldest, sameAge, newest},
+			active:     newest.Name,
+			expected:   newest,
+		},
+		{
+			name:       "the active one not in the candidates is ignored",
+			candidates: []*networkv1.VlanConfig{newest, oldest},
+			active:     "vc-x",
+			expected:   oldest,
+		},
+		{
+			name:       "the vlanconfig being deleted never wins",
+			candidates: []*networkv1.VlanConfig{deleting, newest, nil},
+			active:     deleting.Name,
+			expected:   newest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Resolve(tc.candidates, tc.active))
+		})
+	}
+}
+
+// FuzzMatchesNode checks MatchesNode against the label selector which the webhook uses to list the nodes
+func FuzzMatchesNode(f *testing.F) {
+	f.Add("topology.kubernetes.io/zone=zone1", "topology.kubernetes.io/zone=zone1,rack=rack1")
+	f.Add("rack=", "rack=rack1")
+	f.Add("", utils.HarvesterWitnessNodeLabelKey+"=true")
+	f.Add("a=b,c=d", "a=b")
+
+	f.Fuzz(func(t *testing.T, selectorStr, nodeStr string) {
+		nodeSelector, nodeLabels := parseLabels(selectorStr), parseLabels(nodeStr)
+		selector, err := Selector(nodeSelector)
+		if err != nil {
+			// the node selector is rejected by the API server
+			return
+		}
+		if selector.Matches(labels.Set(nodeLabels)) != MatchesNode(nodeSelector, nodeLabels) {
+			t.Errorf("selector %v and node labels %v are matched inconsistently", nodeSelector, nodeLabels)
+		}
+	})
+}
+
+// FuzzMatchedNodesCodec checks the encoded matched nodes are decoded to the sorted and deduplicated nodes
+func FuzzMatchedNodesCodec(f *testing.F) {
+	f.Add("node1,node2,node1")
+	f.Add("")
+	f.Add("\"quoted\",\\")
+
+	f.Fuzz(func(t *testing.T, nodesStr string) {
+		// node names are DNS subdomains, json replaces the invalid UTF-8 anyway
+		if !utf8.ValidString(nodesStr) {
+			return
+		}
+		nodes := strings.Split(nodesStr, ",")
+		value, err := EncodeMatchedNodes(nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeMatchedNodes(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := EncodeMatchedNodes(decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again != value {
+			t.Errorf("%s is encoded to %s again", value, again)
+		}
+		for _, node := range nodes {
+			if isMatched, _ := IsMatched(&networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{utils.KeyMatchedNodes: value}},
+			}, node); !isMatched {
+				t.Errorf("node %s is lost in %s", node, value)
+			}
+		}
+	})
+}
+
+func parseLabels(s string) map[string]string {
+	m := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			m[k] = v
+		}
+	}
+
+	return m
+}
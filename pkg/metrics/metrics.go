@@ -0,0 +1,284 @@
+// Package metrics exposes the metrics of the network controller in the Prometheus format
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	namespace = "harvester_network"
+
+	metricsPath       = "/metrics"
+	readHeaderTimeout = 10 * time.Second
+)
+
+// NetlinkOp is the kind of the netlink operations changing the links
+type NetlinkOp string
+
+const (
+	OpLinkAdd       NetlinkOp = "link_add"
+	OpLinkDel       NetlinkOp = "link_del"
+	OpLinkSet       NetlinkOp = "link_set"
+	OpBridgeVlanAdd NetlinkOp = "bridge_vlan_add"
+	OpBridgeVlanDel NetlinkOp = "bridge_vlan_del"
+	OpFdbAdd        NetlinkOp = "fdb_add"
+	OpFdbDel        NetlinkOp = "fdb_del"
+)
+
+var netlinkOps = []NetlinkOp{OpLinkAdd, OpLinkDel, OpLinkSet, OpBridgeVlanAdd, OpBridgeVlanDel, OpFdbAdd, OpFdbDel}
+
+var (
+	netlinkOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "netlink_operations_total",
+		Help:      "The number of netlink operations changing the links",
+	}, []string{"operation"})
+
+	// a reconcile of a network in the steady state changes nothing, a regression re-programming the network on every
+	// reconcile shows up as the operations moving out of the bucket 0
+	reconcileNetlinkOperations = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reconcile_netlink_operations",
+		Help:      "The number of netlink operations changing the links per reconcile",
+		Buckets:   []float64{0, 1, 2, 4, 8, 16, 32, 64},
+	}, []string{"controller", "operation"})
+
+	// the series of a network is removed once it's ready again, so an alert rule like
+	// `time() - harvester_network_unready_since_timestamp_seconds > 300` fires on the networks unready for more than
+	// 5 minutes while ignoring the short blips
+	networkUnreadySince = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "unready_since_timestamp_seconds",
+		Help:      "The unix time since when the network of the cluster network on the node is unready",
+	}, []string{"node", "clusternetwork"})
+
+	uplinkThroughput = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "uplink_throughput_bits_per_second",
+		Help:      "The average throughput of the uplink of the cluster network on the node over the last sampling interval",
+	}, []string{"node", "clusternetwork", "direction"})
+
+	dhcpProbeLabels = []string{"namespace", "nad", "clusternetwork", "vlan"}
+
+	dhcpProbeSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dhcp_probe_success",
+		Help:      "Whether the DHCP server of the VLAN of the NAD answered the last probe, 1 if it did, 0 otherwise",
+	}, dhcpProbeLabels)
+
+	// the value is always 1, the answering server and what it offers are in the labels to be joined to the other series
+	dhcpProbeInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dhcp_probe_info",
+		Help:      "The DHCP server which answered the last probe of the VLAN of the NAD and the subnet and gateway it offers",
+	}, append([]string{"server", "subnet", "gateway"}, dhcpProbeLabels...))
+
+	dhcpProbeLatency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dhcp_probe_latency_seconds",
+		Help:      "The time the DHCP server of the VLAN of the NAD took to answer the last probe, or the probe took to fail",
+	}, dhcpProbeLabels)
+
+	dhcpProbeTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dhcp_probe_timestamp_seconds",
+		Help:      "The unix time of the last DHCP probe of the VLAN of the NAD",
+	}, dhcpProbeLabels)
+
+	dhcpProbeFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dhcp_probe_failures_total",
+		Help:      "The number of the DHCP probes of the VLAN of the NAD not answered",
+	}, dhcpProbeLabels)
+
+	// the last observed probe of the NADs, a probe is recorded once while the NAD is reconciled many times
+	dhcpProbes = struct {
+		sync.Mutex
+		last map[[2]string]DHCPProbe
+	}{last: map[[2]string]DHCPProbe{}}
+
+	// the networks whose unready time is observed, the time is kept until they are ready again
+	unreadyNetworks = struct {
+		sync.Mutex
+		set map[[2]string]bool
+	}{set: map[[2]string]bool{}}
+
+	// the running totals to count the operations of a reconcile
+	totals = func() map[NetlinkOp]*atomic.Uint64 {
+		m := make(map[NetlinkOp]*atomic.Uint64, len(netlinkOps))
+		for _, op := range netlinkOps {
+			m[op] = &atomic.Uint64{}
+		}
+		return m
+	}()
+)
+
+func init() {
+	prometheus.MustRegister(netlinkOperations, reconcileNetlinkOperations, networkUnreadySince, uplinkThroughput,
+		dhcpProbeSuccess, dhcpProbeInfo, dhcpProbeLatency, dhcpProbeTimestamp, dhcpProbeFailures)
+}
+
+// CountNetlinkOp counts a netlink operation, it's called before the operation is done whether it succeeds or not
+func CountNetlinkOp(op NetlinkOp) {
+	netlinkOperations.WithLabelValues(string(op)).Inc()
+	if total, ok := totals[op]; ok {
+		total.Add(1)
+	}
+}
+
+// ObserveReconcile starts observing the netlink operations of a reconcile of the controller and returns the function
+// to call when the reconcile completes, e.g. defer metrics.ObserveReconcile(ControllerName)().
+// The operations of the reconciles running at the same time are observed by each of them, so the numbers are exact
+// only if the reconciles don't overlap, which is the case in the steady state.
+func ObserveReconcile(controller string) func() {
+	start := snapshot()
+	return func() {
+		end := snapshot()
+		for _, op := range netlinkOps {
+			reconcileNetlinkOperations.WithLabelValues(controller, string(op)).Observe(float64(end[op] - start[op]))
+		}
+	}
+}
+
+func snapshot() map[NetlinkOp]uint64 {
+	s := make(map[NetlinkOp]uint64, len(totals))
+	for op, total := range totals {
+		s[op] = total.Load()
+	}
+	return s
+}
+
+// ObserveNetworkReady observes the readiness of the network of the cluster network on the node, since is the time
+// the network turned unready, now if it's zero. It's only recorded when the network turns unready so that the time
+// survives the reconciles keeping the network unready
+func ObserveNetworkReady(node, clusterNetwork string, ready bool, since time.Time) {
+	key := [2]string{node, clusterNetwork}
+	unreadyNetworks.Lock()
+	defer unreadyNetworks.Unlock()
+
+	if ready {
+		delete(unreadyNetworks.set, key)
+		networkUnreadySince.DeleteLabelValues(node, clusterNetwork)
+		return
+	}
+	if unreadyNetworks.set[key] {
+		return
+	}
+	if since.IsZero() {
+		since = time.Now()
+	}
+	unreadyNetworks.set[key] = true
+	networkUnreadySince.WithLabelValues(node, clusterNetwork).Set(float64(since.Unix()))
+}
+
+// ObserveUplinkThroughput observes the average received and transmitted bits per second of the uplink of the cluster
+// network on the node
+func ObserveUplinkThroughput(node, clusterNetwork string, rx, tx uint64) {
+	uplinkThroughput.WithLabelValues(node, clusterNetwork, "rx").Set(float64(rx))
+	uplinkThroughput.WithLabelValues(node, clusterNetwork, "tx").Set(float64(tx))
+}
+
+// ForgetNetwork removes the series of the network of the cluster network on the node after it's torn down
+func ForgetNetwork(node, clusterNetwork string) {
+	ObserveNetworkReady(node, clusterNetwork, true, time.Time{})
+	uplinkThroughput.DeletePartialMatch(prometheus.Labels{"node": node, "clusternetwork": clusterNetwork})
+}
+
+// DHCPProbe is the result of the DHCP probe of the VLAN of a NAD by its helper job
+type DHCPProbe struct {
+	Namespace      string
+	NAD            string
+	ClusterNetwork string
+	VID            uint16
+	// Server is the IP of the DHCP server which answered, Subnet and Gateway are what it offers
+	Server    string
+	Subnet    string
+	Gateway   string
+	Succeeded bool
+	Latency   time.Duration
+	Time      time.Time
+}
+
+// ObserveDHCPProbe observes the result of the DHCP probe of the VLAN of the NAD. A failed probe is counted once,
+// observing the same probe again changes nothing. The series of the previous probe are replaced, e.g. those of the
+// previous VLAN of the NAD.
+func ObserveDHCPProbe(p DHCPProbe) {
+	key := [2]string{p.Namespace, p.NAD}
+	dhcpProbes.Lock()
+	defer dhcpProbes.Unlock()
+
+	last, ok := dhcpProbes.last[key]
+	if ok && last == p {
+		return
+	}
+	deleteDHCPProbe(p.Namespace, p.NAD, ok && (last.ClusterNetwork != p.ClusterNetwork || last.VID != p.VID))
+	dhcpProbes.last[key] = p
+
+	labels := []string{p.Namespace, p.NAD, p.ClusterNetwork, strconv.Itoa(int(p.VID))}
+	success := 0.0
+	if p.Succeeded {
+		success = 1
+		dhcpProbeInfo.WithLabelValues(append([]string{p.Server, p.Subnet, p.Gateway}, labels...)...).Set(1)
+	}
+	dhcpProbeSuccess.WithLabelValues(labels...).Set(success)
+	dhcpProbeLatency.WithLabelValues(labels...).Set(p.Latency.Seconds())
+	dhcpProbeTimestamp.WithLabelValues(labels...).Set(float64(p.Time.Unix()))
+	failures := dhcpProbeFailures.WithLabelValues(labels...)
+	// the counter restarts with the manager, which counts the last probe again if it failed
+	if !p.Succeeded && (!ok || !last.Time.Equal(p.Time)) {
+		failures.Inc()
+	}
+}
+
+// ForgetDHCPProbe removes the series of the DHCP probe of the NAD after it's removed or no longer probed
+func ForgetDHCPProbe(namespace, nad string) {
+	dhcpProbes.Lock()
+	defer dhcpProbes.Unlock()
+
+	delete(dhcpProbes.last, [2]string{namespace, nad})
+	deleteDHCPProbe(namespace, nad, true)
+}
+
+func deleteDHCPProbe(namespace, nad string, failures bool) {
+	labels := prometheus.Labels{"namespace": namespace, "nad": nad}
+	for _, gauge := range []*prometheus.GaugeVec{dhcpProbeSuccess, dhcpProbeInfo, dhcpProbeLatency, dhcpProbeTimestamp} {
+		gauge.DeletePartialMatch(labels)
+	}
+	if failures {
+		dhcpProbeFailures.DeletePartialMatch(labels)
+	}
+}
+
+// Serve serves the metrics on the address in the background until the context is done
+func Serve(ctx context.Context, address string) {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.Handler())
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			logrus.Warnf("shut down metrics server failed, error: %s", err.Error())
+		}
+	}()
+	go func() {
+		logrus.Infof("serve metrics on %s%s", address, metricsPath)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Errorf("serve metrics on %s failed, error: %s", address, err.Error())
+		}
+	}()
+}
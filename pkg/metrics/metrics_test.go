@@ -0,0 +1,176 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func histogramOf(t *testing.T, controller string, op NetlinkOp) *dto.Histogram {
+	m := &dto.Metric{}
+	observer := reconcileNetlinkOperations.WithLabelValues(controller, string(op))
+	assert.NoError(t, observer.(prometheus.Metric).Write(m))
+	return m.GetHistogram()
+}
+
+func counterOf(t *testing.T, op NetlinkOp) float64 {
+	m := &dto.Metric{}
+	assert.NoError(t, netlinkOperations.WithLabelValues(string(op)).Write(m))
+	return m.GetCounter().GetValue()
+}
+
+func TestObserveReconcile(t *testing.T) {
+	before := counterOf(t, OpBridgeVlanAdd)
+
+	done := ObserveReconcile("test")
+	CountNetlinkOp(OpBridgeVlanAdd)
+	CountNetlinkOp(OpBridgeVlanAdd)
+	CountNetlinkOp(OpLinkSet)
+	done()
+
+	assert.Equal(t, before+2, counterOf(t, OpBridgeVlanAdd))
+
+	h := histogramOf(t, "test", OpBridgeVlanAdd)
+	assert.Equal(t, uint64(1), h.GetSampleCount())
+	assert.Equal(t, float64(2), h.GetSampleSum())
+	h = histogramOf(t, "test", OpLinkSet)
+	assert.Equal(t, float64(1), h.GetSampleSum())
+	// the operations not done are observed as 0
+	h = histogramOf(t, "test", OpLinkDel)
+	assert.Equal(t, uint64(1), h.GetSampleCount())
+	assert.Equal(t, float64(0), h.GetSampleSum())
+
+	// a reconcile in the steady state
+	ObserveReconcile("test")()
+	h = histogramOf(t, "test", OpBridgeVlanAdd)
+	assert.Equal(t, uint64(2), h.GetSampleCount())
+	assert.Equal(t, float64(2), h.GetSampleSum())
+}
+
+func unreadySinceOf(t *testing.T, node, clusterNetwork string) (float64, bool) {
+	ch := make(chan prometheus.Metric, 16)
+	networkUnreadySince.Collect(ch)
+	close(ch)
+	for metric := range ch {
+		m := &dto.Metric{}
+		assert.NoError(t, metric.Write(m))
+		labels := map[string]string{}
+		for _, l := range m.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		if labels["node"] == node && labels["clusternetwork"] == clusterNetwork {
+			return m.GetGauge().GetValue(), true
+		}
+	}
+	return 0, false
+}
+
+func TestObserveNetworkReady(t *testing.T) {
+	first := time.Unix(1000, 0)
+
+	ObserveNetworkReady("node1", "cn1", true, first)
+	_, ok := unreadySinceOf(t, "node1", "cn1")
+	assert.False(t, ok)
+
+	ObserveNetworkReady("node1", "cn1", false, first)
+	// the network keeps unready, the first time is kept
+	ObserveNetworkReady("node1", "cn1", false, first.Add(time.Minute))
+	value, ok := unreadySinceOf(t, "node1", "cn1")
+	assert.True(t, ok)
+	assert.Equal(t, float64(1000), value)
+
+	ObserveNetworkReady("node1", "cn1", true, time.Time{})
+	_, ok = unreadySinceOf(t, "node1", "cn1")
+	assert.False(t, ok)
+
+	ObserveNetworkReady("node1", "cn1", false, first.Add(time.Hour))
+	value, _ = unreadySinceOf(t, "node1", "cn1")
+	assert.Equal(t, float64(4600), value)
+	ForgetNetwork("node1", "cn1")
+	_, ok = unreadySinceOf(t, "node1", "cn1")
+	assert.False(t, ok)
+}
+
+func seriesCount(c prometheus.Collector) int {
+	ch := make(chan prometheus.Metric, 16)
+	c.Collect(ch)
+	close(ch)
+	return len(ch)
+}
+
+func TestObserveUplinkThroughput(t *testing.T) {
+	ObserveUplinkThroughput("node1", "cn1", 1000, 2000)
+	ObserveUplinkThroughput("node1", "cn2", 3000, 4000)
+
+	m := &dto.Metric{}
+	assert.NoError(t, uplinkThroughput.WithLabelValues("node1", "cn1", "tx").Write(m))
+	assert.Equal(t, float64(2000), m.GetGauge().GetValue())
+	assert.Equal(t, 4, seriesCount(uplinkThroughput))
+
+	ForgetNetwork("node1", "cn1")
+	assert.Equal(t, 2, seriesCount(uplinkThroughput))
+	ForgetNetwork("node1", "cn2")
+}
+
+func gaugeOf(t *testing.T, gauge *prometheus.GaugeVec, labels ...string) float64 {
+	m := &dto.Metric{}
+	assert.NoError(t, gauge.WithLabelValues(labels...).Write(m))
+	return m.GetGauge().GetValue()
+}
+
+func failuresOf(t *testing.T, labels ...string) float64 {
+	m := &dto.Metric{}
+	assert.NoError(t, dhcpProbeFailures.WithLabelValues(labels...).Write(m))
+	return m.GetCounter().GetValue()
+}
+
+func TestObserveDHCPProbe(t *testing.T) {
+	labels := []string{"default", "vlan100", "cn1", "100"}
+	probe := DHCPProbe{
+		Namespace:      "default",
+		NAD:            "vlan100",
+		ClusterNetwork: "cn1",
+		VID:            100,
+		Server:         "192.168.100.2",
+		Subnet:         "192.168.100.0/24",
+		Gateway:        "192.168.100.1",
+		Succeeded:      true,
+		Latency:        20 * time.Millisecond,
+		Time:           time.Unix(1000, 0),
+	}
+
+	ObserveDHCPProbe(probe)
+	assert.Equal(t, float64(1), gaugeOf(t, dhcpProbeSuccess, labels...))
+	assert.Equal(t, 0.02, gaugeOf(t, dhcpProbeLatency, labels...))
+	assert.Equal(t, float64(1000), gaugeOf(t, dhcpProbeTimestamp, labels...))
+	assert.Equal(t, 1, seriesCount(dhcpProbeInfo))
+
+	// the failed probe replaces the info of the server, it's counted once however many times it's observed
+	failed := probe
+	failed.Server, failed.Subnet, failed.Gateway = "", "", ""
+	failed.Succeeded = false
+	failed.Time = time.Unix(2000, 0)
+	ObserveDHCPProbe(failed)
+	ObserveDHCPProbe(failed)
+	assert.Equal(t, float64(0), gaugeOf(t, dhcpProbeSuccess, labels...))
+	assert.Equal(t, 0, seriesCount(dhcpProbeInfo))
+	assert.Equal(t, float64(1), failuresOf(t, labels...))
+	failed.Time = time.Unix(3000, 0)
+	ObserveDHCPProbe(failed)
+	assert.Equal(t, float64(2), failuresOf(t, labels...))
+
+	// the probe of another VLAN replaces all the series of the previous one
+	failed.VID = 200
+	ObserveDHCPProbe(failed)
+	assert.Equal(t, 1, seriesCount(dhcpProbeSuccess))
+	assert.Equal(t, 1, seriesCount(dhcpProbeFailures))
+
+	ForgetDHCPProbe("default", "vlan100")
+	for _, c := range []prometheus.Collector{dhcpProbeSuccess, dhcpProbeInfo, dhcpProbeLatency, dhcpProbeTimestamp,
+		dhcpProbeFailures} {
+		assert.Equal(t, 0, seriesCount(c))
+	}
+}
@@ -0,0 +1,84 @@
+// Package announce sends RARP announcements of the MAC addresses behind a link out of it, like the announce-self of
+// QEMU after a live migration, so that the switches relearn at once which port the MAC addresses are behind, e.g.
+// after the traffic of the VMs has moved to another NIC or fabric.
+package announce
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/packet"
+)
+
+const (
+	rarpEtherType = 0x8035
+	// the reverse request of RFC 903, which QEMU sends as well
+	rarpOpRequestReverse = 3
+
+	// DefaultRounds is how many times the announcements are sent by default, a single frame may be lost while the
+	// switch port is still coming up
+	DefaultRounds = 3
+	// DefaultInterval is the time between the rounds by default
+	DefaultInterval = 100 * time.Millisecond
+)
+
+// Station is a MAC address to be announced on the VID, 0 means untagged
+type Station struct {
+	MAC net.HardwareAddr
+	VID uint16
+}
+
+// Send sends a round of the announcements of the stations out of the link every interval
+func Send(name string, stations []Station, rounds int, interval time.Duration) error {
+	if len(stations) == 0 {
+		return nil
+	}
+	if rounds <= 0 {
+		rounds = DefaultRounds
+	}
+
+	frames := make([][]byte, 0, len(stations))
+	for _, s := range stations {
+		frames = append(frames, buildRARP(s.MAC, s.VID))
+	}
+
+	conn, err := packet.Open(name, rarpEtherType)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for i := 0; i < rounds; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+		for _, frame := range frames {
+			if err := conn.Send(frame); err != nil {
+				return fmt.Errorf("send announcement on %s failed, error: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildRARP returns the broadcast RARP frame announcing the MAC address, the addresses of both the sender and the
+// target are the MAC address and their IPs are left zero
+func buildRARP(mac net.HardwareAddr, vid uint16) []byte {
+	frame := packet.AppendEthHeader(make([]byte, 0, packet.MinFrameLen), packet.Broadcast, mac, vid, rarpEtherType)
+	// Ethernet, IPv4, the lengths of their addresses and the operation
+	frame = binary.BigEndian.AppendUint16(frame, 1)
+	frame = binary.BigEndian.AppendUint16(frame, unix.ETH_P_IP)
+	frame = append(frame, 6, 4)
+	frame = binary.BigEndian.AppendUint16(frame, rarpOpRequestReverse)
+	frame = append(frame, mac...)
+	frame = append(frame, 0, 0, 0, 0)
+	frame = append(frame, mac...)
+	frame = append(frame, 0, 0, 0, 0)
+
+	return packet.Pad(frame)
+}
@@ -0,0 +1,29 @@
+package announce
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/packet"
+)
+
+func TestRARP(t *testing.T) {
+	mac := net.HardwareAddr{0x52, 0x54, 0, 0x12, 0x34, 0x56}
+
+	untagged := buildRARP(mac, 0)
+	assert.Len(t, untagged, packet.MinFrameLen)
+	assert.Equal(t, net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, net.HardwareAddr(untagged[:6]))
+	assert.Equal(t, mac, net.HardwareAddr(untagged[6:12]))
+	assert.Equal(t, []byte{0x80, 0x35}, untagged[12:14])
+	// Ethernet, IPv4, 6, 4, the reverse request
+	assert.Equal(t, []byte{0, 1, 0x08, 0, 6, 4, 0, 3}, untagged[14:22])
+	assert.Equal(t, mac, net.HardwareAddr(untagged[22:28]))
+	assert.Equal(t, mac, net.HardwareAddr(untagged[32:38]))
+
+	tagged := buildRARP(mac, 100)
+	assert.Len(t, tagged, packet.MinFrameLen)
+	assert.Equal(t, []byte{0x81, 0x00, 0x00, 0x64, 0x80, 0x35}, tagged[12:18])
+	assert.Equal(t, untagged[14:42], tagged[18:46])
+}
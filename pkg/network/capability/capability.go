@@ -0,0 +1,117 @@
+// Package capability discovers what the NICs and the kernel of the node can do, e.g. the largest MTU of each NIC, so
+// that the webhook can reject a config some matched nodes can't implement instead of letting their agents fail.
+package capability
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/kernel"
+	"github.com/harvester/harvester-network-controller/pkg/network/ovs"
+)
+
+// Env is where the capabilities are queried from, the directory and the probes are replaced in the tests
+type Env struct {
+	// /sys/class/net, the NICs having a device are the physical ones
+	SysClassNetDir string
+	MTURange       func(name string) (minMTU, maxMTU int, err error)
+	Features       func(name string) (map[string]bool, error)
+	// whether the kernel can stack VLAN tags
+	QinQ func() bool
+	// whether the ovsdb-server of the node answers
+	OVS func() bool
+}
+
+// HostEnv returns the environment of the running node
+func HostEnv() Env {
+	return Env{
+		SysClassNetDir: "/sys/class/net",
+		MTURange:       iface.MTURange,
+		Features:       iface.Features,
+		QinQ: func() bool {
+			return kernel.Require(kernel.Module8021Q) == nil
+		},
+		OVS: ovs.Available,
+	}
+}
+
+// Discover returns the capabilities of the physical NICs and the node. A capability a NIC fails to report is left
+// unset rather than failing the discovery, the webhook doesn't check the unknown capabilities.
+func (e Env) Discover() (*networkv1.NodeNetworkStateStatus, error) {
+	entries, err := os.ReadDir(e.SysClassNetDir)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &networkv1.NodeNetworkStateStatus{
+		Capabilities: networkv1.NodeCapabilities{QinQ: e.QinQ(), OVS: e.OVS()},
+		NICs:         make([]networkv1.NICCapabilities, 0),
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if _, err := os.Stat(filepath.Join(e.SysClassNetDir, name, "device")); err != nil {
+			continue
+		}
+
+		nic := networkv1.NICCapabilities{Name: name, SriovTotalVFs: e.sriovTotalVFs(name)}
+		if _, maxMTU, err := e.MTURange(name); err != nil {
+			logrus.Debugf("skip the max MTU of %s, error: %s", name, err.Error())
+		} else {
+			nic.MaxMTU = maxMTU
+		}
+		if features, err := e.Features(name); err != nil {
+			logrus.Debugf("skip the offload features of %s, error: %s", name, err.Error())
+		} else {
+			nic.VxlanOffload = features[iface.FeatureVxlanOffload]
+		}
+
+		status.NICs = append(status.NICs, nic)
+		caps := &status.Capabilities
+		caps.SRIOV = caps.SRIOV || nic.SriovTotalVFs > 0
+		caps.VxlanOffload = caps.VxlanOffload || nic.VxlanOffload
+		caps.MaxMTU = max(caps.MaxMTU, nic.MaxMTU)
+	}
+	sort.Slice(status.NICs, func(i, j int) bool { return status.NICs[i].Name < status.NICs[j].Name })
+
+	return status, nil
+}
+
+// sriovTotalVFs returns 0 if the NIC doesn't support SR-IOV
+func (e Env) sriovTotalVFs(name string) int {
+	content, err := os.ReadFile(filepath.Join(e.SysClassNetDir, name, "device", "sriov_totalvfs"))
+	if err != nil {
+		return 0
+	}
+	vfs, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0
+	}
+
+	return vfs
+}
+
+// discovered is the result of the discovery on the host, the NICs and the kernel don't change while the agent runs
+var discovered = sync.OnceValues(func() (*networkv1.NodeNetworkStateStatus, error) {
+	return HostEnv().Discover()
+})
+
+// DiscoverOnHost returns the capabilities of the running node, a copy is returned as the result is cached. Open vSwitch
+// is probed again every time, it may be installed or started on the node after the agent.
+func DiscoverOnHost() (*networkv1.NodeNetworkStateStatus, error) {
+	status, err := discovered()
+	if err != nil {
+		return nil, err
+	}
+
+	status = status.DeepCopy()
+	status.Capabilities.OVS = ovs.Available()
+	return status, nil
+}
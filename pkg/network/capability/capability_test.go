@@ -0,0 +1,53 @@
+package capability
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+)
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	// eno1 supports SR-IOV, eno2 reports nothing, lo and br0 aren't physical NICs
+	for _, path := range []string{"eno1/device", "eno2/device", "lo", "br0/bridge"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, path), 0755))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "eno1/device/sriov_totalvfs"), []byte("64\n"), 0644))
+
+	env := Env{
+		SysClassNetDir: dir,
+		MTURange: func(name string) (int, int, error) {
+			if name == "eno1" {
+				return 68, 9216, nil
+			}
+			return 0, 0, errors.New("not supported")
+		},
+		Features: func(name string) (map[string]bool, error) {
+			if name == "eno1" {
+				return map[string]bool{iface.FeatureVxlanOffload: true}, nil
+			}
+			return nil, errors.New("not supported")
+		},
+		QinQ: func() bool { return true },
+		OVS:  func() bool { return false },
+	}
+
+	status, err := env.Discover()
+	assert.NoError(t, err)
+	assert.Equal(t, networkv1.NodeCapabilities{QinQ: true, SRIOV: true, VxlanOffload: true, MaxMTU: 9216},
+		status.Capabilities)
+	assert.Equal(t, []networkv1.NICCapabilities{
+		{Name: "eno1", MaxMTU: 9216, SriovTotalVFs: 64, VxlanOffload: true},
+		{Name: "eno2"},
+	}, status.NICs)
+
+	env.SysClassNetDir = filepath.Join(dir, "none")
+	_, err = env.Discover()
+	assert.Error(t, err)
+}
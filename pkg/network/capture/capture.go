@@ -0,0 +1,163 @@
+// Package capture captures the frames of a link into a pcap file over a packet socket. The VLAN tags stripped by the
+// NIC or the kernel are restored from the auxiliary data of the socket, so that the frames are filtered and saved as
+// they are on the wire.
+package capture
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/packet"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	qinqEtherType = 0x88a8
+
+	// the layout of struct tpacket_auxdata
+	auxdataLen        = 20
+	auxdataTCIOffset  = 16
+	auxdataTPIDOffset = 18
+
+	// check the context every 100ms while no frame arrives
+	pollInterval = 100 * time.Millisecond
+	maxFrameLen  = 65535 + 4
+)
+
+type Options struct {
+	Link string
+	// VID keeps the frames tagged with it only, 0 keeps all the frames. The untagged frames are taken as the default
+	// VLAN ID 1, which leaves the uplink untagged.
+	VID uint16
+	// SnapLen is the bytes kept of each frame, 0 keeps the whole frame
+	SnapLen int
+	// MaxBytes stops the capture before the pcap exceeds it
+	MaxBytes int64
+	Duration time.Duration
+}
+
+type Result struct {
+	Frames int64
+	// Bytes is the size of the pcap
+	Bytes int64
+	// Truncated means the capture is stopped by MaxBytes before the duration elapses
+	Truncated bool
+}
+
+// Run captures the frames received and sent by the link into w until the duration elapses, the pcap reaches the size
+// limit or the context is done. The result is returned together with the error, so that the frames captured before a
+// failure are still accounted.
+func Run(ctx context.Context, opts Options, w io.Writer) (Result, error) {
+	var res Result
+
+	conn, err := packet.Listen(opts.Link, pollInterval)
+	if err != nil {
+		return res, err
+	}
+	defer conn.Close()
+
+	if err := unix.SetsockoptInt(conn.FD, unix.SOL_PACKET, unix.PACKET_AUXDATA, 1); err != nil {
+		return res, fmt.Errorf("enable the auxiliary data on %s failed, error: %w", opts.Link, err)
+	}
+
+	pw, err := newPcapWriter(w, opts.SnapLen)
+	if err != nil {
+		return res, err
+	}
+	res.Bytes = pw.written
+
+	deadline := time.Now().Add(opts.Duration)
+	buf := make([]byte, maxFrameLen)
+	oob := make([]byte, unix.CmsgSpace(auxdataLen))
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		n, oobn, _, _, err := unix.Recvmsg(conn.FD, buf, oob, 0)
+		if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+			continue
+		} else if err != nil {
+			return res, fmt.Errorf("receive on %s failed, error: %w", opts.Link, err)
+		}
+
+		frame := restoreVlanTag(buf[:n], parseAuxdata(oob[:oobn]))
+		if !matchVID(frame, opts.VID) {
+			continue
+		}
+		if opts.MaxBytes > 0 && pw.written+pw.recordLen(frame) > opts.MaxBytes {
+			res.Truncated = true
+			break
+		}
+		if err := pw.writeFrame(time.Now(), frame); err != nil {
+			return res, fmt.Errorf("write the pcap failed, error: %w", err)
+		}
+		res.Frames++
+		res.Bytes = pw.written
+	}
+
+	return res, nil
+}
+
+// auxdata is the VLAN tag of a frame reported by the kernel in the struct tpacket_auxdata
+type auxdata struct {
+	status uint32
+	tci    uint16
+	tpid   uint16
+}
+
+func parseAuxdata(oob []byte) *auxdata {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil
+	}
+	for _, m := range msgs {
+		if m.Header.Level != unix.SOL_PACKET || m.Header.Type != unix.PACKET_AUXDATA || len(m.Data) < auxdataLen {
+			continue
+		}
+		return &auxdata{
+			status: binary.NativeEndian.Uint32(m.Data),
+			tci:    binary.NativeEndian.Uint16(m.Data[auxdataTCIOffset:]),
+			tpid:   binary.NativeEndian.Uint16(m.Data[auxdataTPIDOffset:]),
+		}
+	}
+
+	return nil
+}
+
+// restoreVlanTag inserts the VLAN tag stripped from the frame back behind the MAC addresses
+func restoreVlanTag(frame []byte, aux *auxdata) []byte {
+	if aux == nil || aux.status&unix.TP_STATUS_VLAN_VALID == 0 || len(frame) < 12 {
+		return frame
+	}
+	tpid := uint16(packet.VlanEtherType)
+	if aux.status&unix.TP_STATUS_VLAN_TPID_VALID != 0 && aux.tpid != 0 {
+		tpid = aux.tpid
+	}
+
+	tagged := make([]byte, 0, len(frame)+4)
+	tagged = append(tagged, frame[:12]...)
+	tagged = binary.BigEndian.AppendUint16(tagged, tpid)
+	tagged = binary.BigEndian.AppendUint16(tagged, aux.tci)
+	return append(tagged, frame[12:]...)
+}
+
+// matchVID returns true if the outer VLAN tag of the frame carries the VID, or the frame is untagged and the VID is the
+// default VLAN ID
+func matchVID(frame []byte, vid uint16) bool {
+	if vid == 0 {
+		return true
+	}
+	if len(frame) < 16 {
+		return false
+	}
+
+	switch binary.BigEndian.Uint16(frame[12:]) {
+	case packet.VlanEtherType, qinqEtherType:
+		return binary.BigEndian.Uint16(frame[14:])&0x0fff == vid
+	default:
+		return vid == utils.DefaultVlanID
+	}
+}
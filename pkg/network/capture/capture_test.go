@@ -0,0 +1,75 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func TestVlanTag(t *testing.T) {
+	macs := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02, 0, 0, 0, 0, 1}
+	untagged := append(append([]byte{}, macs...), 0x08, 0x06, 0, 1)
+
+	assert.Equal(t, untagged, restoreVlanTag(untagged, nil))
+	assert.Equal(t, untagged, restoreVlanTag(untagged, &auxdata{tci: 100}), "the tag is not valid")
+
+	tagged := restoreVlanTag(untagged, &auxdata{status: unix.TP_STATUS_VLAN_VALID, tci: 100})
+	assert.Equal(t, []byte{0x81, 0x00, 0x00, 0x64, 0x08, 0x06}, tagged[12:18])
+	assert.Equal(t, macs, tagged[:12])
+
+	outer := restoreVlanTag(untagged, &auxdata{
+		status: unix.TP_STATUS_VLAN_VALID | unix.TP_STATUS_VLAN_TPID_VALID,
+		tci:    200,
+		tpid:   qinqEtherType,
+	})
+	assert.Equal(t, []byte{0x88, 0xa8, 0x00, 0xc8}, outer[12:16])
+
+	tests := []struct {
+		name  string
+		frame []byte
+		vid   uint16
+		want  bool
+	}{
+		{name: "all VIDs", frame: untagged, vid: 0, want: true},
+		{name: "tagged", frame: tagged, vid: 100, want: true},
+		{name: "tagged with another VID", frame: tagged, vid: 200, want: false},
+		{name: "outer tag of QinQ", frame: outer, vid: 200, want: true},
+		{name: "untagged on the default VID", frame: untagged, vid: 1, want: true},
+		{name: "untagged on another VID", frame: untagged, vid: 100, want: false},
+		{name: "short frame", frame: untagged[:13], vid: 1, want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, matchVID(tc.frame, tc.vid))
+		})
+	}
+}
+
+func TestPcapWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	pw, err := newPcapWriter(buf, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xd4, 0xc3, 0xb2, 0xa1, 2, 0, 4, 0}, buf.Bytes()[:8])
+	assert.Equal(t, uint32(4), binary.LittleEndian.Uint32(buf.Bytes()[16:]))
+
+	frame := []byte{1, 2, 3, 4, 5, 6}
+	assert.Equal(t, int64(pcapRecordHdrLen+4), pw.recordLen(frame))
+	ts := time.Unix(1700000000, 123456000)
+	assert.NoError(t, pw.writeFrame(ts, frame))
+
+	record := buf.Bytes()[pcapHeaderLen:]
+	assert.Equal(t, uint32(1700000000), binary.LittleEndian.Uint32(record[0:]))
+	assert.Equal(t, uint32(123456), binary.LittleEndian.Uint32(record[4:]))
+	assert.Equal(t, uint32(4), binary.LittleEndian.Uint32(record[8:]), "the frame is cut to the snap length")
+	assert.Equal(t, uint32(6), binary.LittleEndian.Uint32(record[12:]))
+	assert.Equal(t, frame[:4], record[pcapRecordHdrLen:])
+	assert.Equal(t, int64(buf.Len()), pw.written)
+
+	_, err = newPcapWriter(&bytes.Buffer{}, 0)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,71 @@
+package capture
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	pcapMagic         = 0xa1b2c3d4
+	pcapVersionMajor  = 2
+	pcapVersionMinor  = 4
+	linkTypeEthernet  = 1
+	pcapHeaderLen     = 24
+	pcapRecordHdrLen  = 16
+	defaultSnapLen    = 65535
+	microsecondsInSec = int64(time.Second / time.Microsecond)
+)
+
+// pcapWriter writes the frames in the classic pcap format with the microsecond timestamps, which is read by tcpdump
+// and Wireshark alike
+type pcapWriter struct {
+	w       io.Writer
+	snapLen int
+	// written is the bytes written so far including the file header
+	written int64
+}
+
+func newPcapWriter(w io.Writer, snapLen int) (*pcapWriter, error) {
+	if snapLen <= 0 || snapLen > defaultSnapLen {
+		snapLen = defaultSnapLen
+	}
+
+	hdr := make([]byte, pcapHeaderLen)
+	binary.LittleEndian.PutUint32(hdr[0:], pcapMagic)
+	binary.LittleEndian.PutUint16(hdr[4:], pcapVersionMajor)
+	binary.LittleEndian.PutUint16(hdr[6:], pcapVersionMinor)
+	// the time zone offset and the accuracy of the timestamps are always 0
+	binary.LittleEndian.PutUint32(hdr[16:], uint32(snapLen))
+	binary.LittleEndian.PutUint32(hdr[20:], linkTypeEthernet)
+	if _, err := w.Write(hdr); err != nil {
+		return nil, err
+	}
+
+	return &pcapWriter{w: w, snapLen: snapLen, written: pcapHeaderLen}, nil
+}
+
+// recordLen returns the bytes the frame takes in the file once cut to the snap length
+func (p *pcapWriter) recordLen(frame []byte) int64 {
+	return int64(pcapRecordHdrLen + min(len(frame), p.snapLen))
+}
+
+func (p *pcapWriter) writeFrame(ts time.Time, frame []byte) error {
+	data := frame[:min(len(frame), p.snapLen)]
+
+	hdr := make([]byte, pcapRecordHdrLen)
+	usec := ts.UnixMicro()
+	binary.LittleEndian.PutUint32(hdr[0:], uint32(usec/microsecondsInSec))
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(usec%microsecondsInSec))
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(hdr[12:], uint32(len(frame)))
+	if _, err := p.w.Write(hdr); err != nil {
+		return err
+	}
+	if _, err := p.w.Write(data); err != nil {
+		return err
+	}
+	p.written += int64(len(hdr) + len(data))
+
+	return nil
+}
@@ -0,0 +1,91 @@
+// Package network defines the error classes of the network operations in the iface and vlan packages.
+// The controllers branch on the class with errors.Is, e.g. retry on ErrLinkBusy, skip on ErrLinkNotFound and report
+// the configuration as invalid on ErrUnsupportedByDriver or ErrMTUOutOfRange.
+package network
+
+import (
+	"errors"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	// ErrLinkBusy means the link is being changed by the kernel or another program, the operation can be retried
+	ErrLinkBusy = errors.New("link is busy")
+	// ErrLinkNotFound means the link doesn't exist
+	ErrLinkNotFound = errors.New("link not found")
+	// ErrUnsupportedByDriver means the driver of the link doesn't support the operation, retrying never helps
+	ErrUnsupportedByDriver = errors.New("operation not supported by the driver")
+	// ErrMTUOutOfRange means the MTU is out of the range supported by the link
+	ErrMTUOutOfRange = errors.New("MTU out of range")
+	// ErrLinkExcluded means the link is in the exclusion list, the agent must never enslave or delete it
+	ErrLinkExcluded = errors.New("link is excluded")
+)
+
+// classifiedError is an error attached with its class, both are matched by errors.Is and errors.As
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.class, e.err}
+}
+
+// Classify attaches the class to the error returned by netlink or the kernel. The error is returned as it is if it's
+// nil, already classified or of no known class.
+func Classify(err error) error {
+	if err == nil || classOf(err) != nil {
+		return err
+	}
+
+	var class error
+	var errno unix.Errno
+	switch {
+	case errors.As(err, &netlink.LinkNotFoundError{}):
+		class = ErrLinkNotFound
+	case !errors.As(err, &errno):
+		return err
+	case errno == unix.ENODEV:
+		class = ErrLinkNotFound
+	case errno == unix.EBUSY || errno == unix.EAGAIN || errno == unix.EALREADY || errno == unix.EEXIST:
+		class = ErrLinkBusy
+	case errno == unix.EOPNOTSUPP:
+		class = ErrUnsupportedByDriver
+	default:
+		return err
+	}
+
+	return &classifiedError{class: class, err: err}
+}
+
+// ClassifyMTU classifies the error of setting the MTU, the kernel rejects an MTU out of the range of the link with
+// EINVAL or ERANGE
+func ClassifyMTU(err error) error {
+	var errno unix.Errno
+	if errors.As(err, &errno) && (errno == unix.EINVAL || errno == unix.ERANGE) {
+		return &classifiedError{class: ErrMTUOutOfRange, err: err}
+	}
+
+	return Classify(err)
+}
+
+// IsRetryable returns true if the operation may succeed when retried
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrLinkBusy)
+}
+
+func classOf(err error) error {
+	for _, class := range []error{ErrLinkBusy, ErrLinkNotFound, ErrUnsupportedByDriver, ErrMTUOutOfRange, ErrLinkExcluded} {
+		if errors.Is(err, class) {
+			return class
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		classify func(error) error
+		expected error
+	}{
+		{
+			name:     "nil",
+			classify: Classify,
+		},
+		{
+			name:     "link not found by netlink",
+			err:      linkNotFoundError(),
+			classify: Classify,
+			expected: ErrLinkNotFound,
+		},
+		{
+			name:     "wrapped ENODEV",
+			err:      fmt.Errorf("get link failed, error: %w", unix.ENODEV),
+			classify: Classify,
+			expected: ErrLinkNotFound,
+		},
+		{
+			name:     "EBUSY",
+			err:      unix.EBUSY,
+			classify: Classify,
+			expected: ErrLinkBusy,
+		},
+		{
+			name:     "EEXIST",
+			err:      fmt.Errorf("add link failed, error: %w", unix.EEXIST),
+			classify: Classify,
+			expected: ErrLinkBusy,
+		},
+		{
+			name:     "EOPNOTSUPP",
+			err:      unix.EOPNOTSUPP,
+			classify: Classify,
+			expected: ErrUnsupportedByDriver,
+		},
+		{
+			name:     "EINVAL is of no class",
+			err:      unix.EINVAL,
+			classify: Classify,
+		},
+		{
+			name:     "EINVAL of setting MTU",
+			err:      unix.EINVAL,
+			classify: ClassifyMTU,
+			expected: ErrMTUOutOfRange,
+		},
+		{
+			name:     "EBUSY of setting MTU",
+			err:      unix.EBUSY,
+			classify: ClassifyMTU,
+			expected: ErrLinkBusy,
+		},
+		{
+			name:     "unknown error",
+			err:      errors.New("unknown"),
+			classify: Classify,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.classify(tc.err)
+			if tc.expected == nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, tc.expected)
+			assert.Equal(t, tc.err.Error(), err.Error())
+			// the original error is still matched
+			assert.ErrorIs(t, err, tc.err)
+			// the class is kept after being wrapped and classified again
+			wrapped := Classify(fmt.Errorf("wrapped: %w", err))
+			assert.ErrorIs(t, wrapped, tc.expected)
+			assert.Equal(t, tc.expected == ErrLinkBusy, IsRetryable(wrapped))
+		})
+	}
+}
+
+func linkNotFoundError() error {
+	_, err := netlink.LinkByName("nch-test-absent")
+	return err
+}
@@ -0,0 +1,62 @@
+package network
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultExcludedInterfaces are the interfaces of the CNI and kube-proxy, which are excluded even if the exclusion list
+// doesn't mention them
+var DefaultExcludedInterfaces = []string{"cali*", "flannel*", "cni*", "vxlan.calico", "kube-ipvs*"}
+
+var exclusion = struct {
+	sync.RWMutex
+	patterns []string
+}{patterns: DefaultExcludedInterfaces}
+
+// ParseExcludedInterfaces parses the comma or whitespace separated glob patterns of the interface names, e.g.
+// "mgmt*, eno1"
+func ParseExcludedInterfaces(value string) ([]string, error) {
+	patterns := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid interface pattern %q, error: %w", pattern, err)
+		}
+	}
+
+	return patterns, nil
+}
+
+// SetExcludedInterfaces replaces the glob patterns of the interfaces the agent must never enslave or delete, the
+// DefaultExcludedInterfaces are always kept
+func SetExcludedInterfaces(patterns []string) {
+	merged := slices.Clone(DefaultExcludedInterfaces)
+	for _, pattern := range patterns {
+		if !slices.Contains(merged, pattern) {
+			merged = append(merged, pattern)
+		}
+	}
+
+	exclusion.Lock()
+	defer exclusion.Unlock()
+	exclusion.patterns = merged
+}
+
+// CheckExcluded returns an error of ErrLinkExcluded if the interface matches any of the excluded patterns
+func CheckExcluded(name string) error {
+	exclusion.RLock()
+	defer exclusion.RUnlock()
+
+	for _, pattern := range exclusion.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return fmt.Errorf("%w: %s matches the pattern %q", ErrLinkExcluded, name, pattern)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package network
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExcludedInterfaces(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  []string
+		expectErr bool
+	}{
+		{
+			name:     "empty",
+			expected: []string{},
+		},
+		{
+			name:     "comma and whitespace separated",
+			value:    "mgmt*, eno1\teno[23]",
+			expected: []string{"mgmt*", "eno1", "eno[23]"},
+		},
+		{
+			name:      "invalid pattern",
+			value:     "eno[",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			patterns, err := ParseExcludedInterfaces(tc.value)
+			assert.Equal(t, tc.expectErr, err != nil)
+			assert.Equal(t, tc.expected, patterns)
+		})
+	}
+}
+
+func TestCheckExcluded(t *testing.T) {
+	defer SetExcludedInterfaces(nil)
+	SetExcludedInterfaces([]string{"mgmt*", "eno1"})
+
+	tests := []struct {
+		name     string
+		excluded bool
+	}{
+		{name: "mgmt-bo", excluded: true},
+		{name: "eno1", excluded: true},
+		{name: "eno12", excluded: false},
+		{name: "cali1234", excluded: true},
+		{name: "flannel.1", excluded: true},
+		{name: "cn1-bo", excluded: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckExcluded(tc.name)
+			assert.Equal(t, tc.excluded, errors.Is(err, ErrLinkExcluded))
+			assert.Equal(t, tc.excluded, classOf(err) == ErrLinkExcluded)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Package fault injects failures and delays into the netlink operations changing the links, so that the retry,
+// rollback and status paths of the agent can be exercised by chaos tests. It's enabled with the hidden flag
+// --fault-injection of the agent, which names a JSON file of the rules. The file is reloaded when it changes, so a test
+// can switch the faults on and off while the agent runs. It must never be enabled in production.
+package fault
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Wildcard matches all the operations
+const Wildcard = "*"
+
+// Rule makes the matched netlink operations fail or delay
+type Rule struct {
+	// Op is the netlink operation, e.g. linkAdd, linkSetMaster or bridgeVlanAdd, * matches all
+	Op string `json:"op"`
+	// Link is the name of the link, empty matches all, the operations on a link known by the index only have no name
+	Link string `json:"link,omitempty"`
+	// Error fails the operation with the message, the operation is done after the delay if it's empty
+	Error string `json:"error,omitempty"`
+	// DelayMilliseconds delays the operation
+	DelayMilliseconds int `json:"delayMilliseconds,omitempty"`
+	// Probability is the chance in (0, 1] the rule applies to a matched operation, 0 means always
+	Probability float64 `json:"probability,omitempty"`
+	// Times limits how many times the rule applies, 0 means unlimited
+	Times int `json:"times,omitempty"`
+}
+
+type injector struct {
+	mutex   sync.Mutex
+	path    string
+	modTime time.Time
+	rules   []Rule
+	applied []int
+}
+
+var (
+	active *injector
+	// random is replaced by the tests
+	random = rand.Float64
+)
+
+// Enable loads the rules from the file and starts injecting the faults
+func Enable(path string) error {
+	i := &injector{path: path}
+	if err := i.reload(); err != nil {
+		return err
+	}
+	logrus.Warnf("netlink fault injection is enabled with %s, it must not be used in production", path)
+	active = i
+	return nil
+}
+
+// Inject is called before the netlink operation on the link, it returns the injected error, if any, after the
+// injected delay. It returns nil immediately if the fault injection isn't enabled.
+func Inject(op, link string) error {
+	if active == nil {
+		return nil
+	}
+	return active.inject(op, link)
+}
+
+func (i *injector) inject(op, link string) error {
+	i.mutex.Lock()
+	if err := i.reload(); err != nil {
+		logrus.Warnf("failed to reload the fault injection rules, keep the previous ones, error: %s", err.Error())
+	}
+	rule, ok := i.match(op, link)
+	i.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+
+	if rule.DelayMilliseconds > 0 {
+		time.Sleep(time.Duration(rule.DelayMilliseconds) * time.Millisecond)
+	}
+	if rule.Error == "" {
+		return nil
+	}
+	logrus.Infof("inject fault into %s of link %s: %s", op, link, rule.Error)
+	return fmt.Errorf("injected fault: %s", rule.Error)
+}
+
+// match returns the first rule applying to the operation and counts it
+func (i *injector) match(op, link string) (Rule, bool) {
+	for n, rule := range i.rules {
+		if rule.Op != Wildcard && rule.Op != op {
+			continue
+		}
+		if rule.Link != "" && rule.Link != link {
+			continue
+		}
+		if rule.Times > 0 && i.applied[n] >= rule.Times {
+			continue
+		}
+		if rule.Probability > 0 && random() >= rule.Probability {
+			continue
+		}
+		i.applied[n]++
+		return rule, true
+	}
+	return Rule{}, false
+}
+
+// reload reads the rules again if the file is modified, the counts of the applied rules are reset
+func (i *injector) reload() error {
+	info, err := os.Stat(i.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(i.modTime) {
+		return nil
+	}
+
+	content, err := os.ReadFile(i.path)
+	if err != nil {
+		return err
+	}
+	var rules []Rule
+	if err := json.Unmarshal(content, &rules); err != nil {
+		return fmt.Errorf("invalid fault injection rules in %s, error: %w", i.path, err)
+	}
+
+	i.modTime = info.ModTime()
+	i.rules = rules
+	i.applied = make([]int, len(rules))
+	logrus.Infof("load %d fault injection rules from %s", len(rules), i.path)
+	return nil
+}
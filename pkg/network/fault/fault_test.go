@@ -0,0 +1,54 @@
+package fault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeRules(t *testing.T, path, rules string, modTime time.Time) {
+	assert.NoError(t, os.WriteFile(path, []byte(rules), 0600))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestInject(t *testing.T) {
+	defer func() { active = nil }()
+	assert.NoError(t, Inject("linkAdd", "cn1-br"))
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	writeRules(t, path, `[{"op":"linkSetMaster","link":"eth1","error":"device busy","times":2},
+		{"op":"*","link":"cn1-bo","error":"no buffer space"}]`, time.Unix(1000, 0))
+	assert.NoError(t, Enable(path))
+
+	assert.NoError(t, Inject("linkSetMaster", "eth2"))
+	assert.ErrorContains(t, Inject("linkSetMaster", "eth1"), "device busy")
+	assert.Error(t, Inject("linkSetMaster", "eth1"))
+	// the rule applies twice only
+	assert.NoError(t, Inject("linkSetMaster", "eth1"))
+	assert.ErrorContains(t, Inject("bridgeVlanAdd", "cn1-bo"), "no buffer space")
+
+	// the modified rules are reloaded
+	writeRules(t, path, `[]`, time.Unix(2000, 0))
+	assert.NoError(t, Inject("bridgeVlanAdd", "cn1-bo"))
+
+	// an invalid file keeps the previous rules
+	writeRules(t, path, `[{"op":`, time.Unix(3000, 0))
+	assert.NoError(t, Inject("bridgeVlanAdd", "cn1-bo"))
+}
+
+func TestInjectProbability(t *testing.T) {
+	defaultRandom := random
+	defer func() { active, random = nil, defaultRandom }()
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	writeRules(t, path, `[{"op":"linkAdd","error":"flaky","probability":0.5}]`, time.Unix(1000, 0))
+	assert.NoError(t, Enable(path))
+
+	random = func() float64 { return 0.7 }
+	assert.NoError(t, Inject("linkAdd", "cn1-br"))
+	random = func() float64 { return 0.3 }
+	assert.Error(t, Inject("linkAdd", "cn1-br"))
+}
@@ -0,0 +1,106 @@
+package iface
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// bondSlave is the name and the carrier of a slave of a bond
+type bondSlave struct {
+	name       string
+	index      int
+	hasCarrier bool
+}
+
+// preferredSlave returns the slave to carry the traffic instead of the active one, nil if the active one is kept.
+// A backup slave is only kept active while none of the other slaves has carrier. If the carrier is tracked by the agent,
+// the active slave without carrier is replaced as well, the kernel doesn't fail over a bond without link monitoring.
+func preferredSlave(active string, slaves []bondSlave, backup []string, trackCarrier bool) *bondSlave {
+	activeLost := trackCarrier && slices.ContainsFunc(slaves, func(s bondSlave) bool {
+		return s.name == active && !s.hasCarrier
+	})
+	if active != "" && !slices.Contains(backup, active) && !activeLost {
+		return nil
+	}
+	for i := range slaves {
+		if slaves[i].hasCarrier && !slices.Contains(backup, slaves[i].name) {
+			return &slaves[i]
+		}
+	}
+	if activeLost {
+		for i := range slaves {
+			if slaves[i].hasCarrier {
+				return &slaves[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// EnsureActiveSlave moves the traffic of the active-backup bond off the backup slaves if any other slave has carrier,
+// and returns the name of the active slave, which is empty if the bond has none. The kernel fails over to any slave
+// with carrier and doesn't fail back on its own, the primary option only covers a single preferred slave. It doesn't
+// fail over at all without link monitoring, the agent fails over on the carrier of the slaves then.
+func EnsureActiveSlave(name string, backup []string) (string, error) {
+	l, err := network.Handle().LinkByName(name)
+	if err != nil {
+		return "", fmt.Errorf("get bond %s failed, error: %w", name, network.Classify(err))
+	}
+	bond, ok := l.(*netlink.Bond)
+	if !ok {
+		return "", fmt.Errorf("%s is not a bond", name)
+	}
+	if bond.Mode != netlink.BOND_MODE_ACTIVE_BACKUP {
+		return "", nil
+	}
+
+	links, err := getSlaves(bond.Index)
+	if err != nil {
+		return "", err
+	}
+	active := ""
+	slaves := make([]bondSlave, 0, len(links))
+	for _, s := range links {
+		if s.Attrs().Index == bond.ActiveSlave {
+			active = s.Attrs().Name
+		}
+		slaves = append(slaves, bondSlave{
+			name:       s.Attrs().Name,
+			index:      s.Attrs().Index,
+			hasCarrier: s.Attrs().OperState == netlink.OperUp,
+		})
+	}
+
+	preferred := preferredSlave(active, slaves, backup, !BondLinkMonitored(bond))
+	if preferred == nil {
+		return active, nil
+	}
+
+	logrus.Infof("move the traffic of bond %s from the slave %s to %s", name, active, preferred.name)
+	change := newBondChange(bond)
+	change.ActiveSlave = preferred.index
+	if err := linkModify(change); err != nil {
+		return "", fmt.Errorf("set active slave of bond %s to %s failed, error: %w", name, preferred.name, err)
+	}
+
+	return preferred.name, nil
+}
+
+// ActiveSlaveName returns the name of the active slave of the bond, empty if the bond has none
+func ActiveSlaveName(bond *netlink.Bond) string {
+	if bond.ActiveSlave <= 0 {
+		return ""
+	}
+	l, err := network.Handle().LinkByIndex(bond.ActiveSlave)
+	if err != nil {
+		return ""
+	}
+
+	return l.Attrs().Name
+}
@@ -0,0 +1,100 @@
+package iface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferredSlave(t *testing.T) {
+	slaves := []bondSlave{
+		{name: "eth0", index: 2, hasCarrier: false},
+		{name: "eth1", index: 3, hasCarrier: true},
+		{name: "usb0", index: 4, hasCarrier: true},
+	}
+	backup := []string{"usb0"}
+
+	tests := []struct {
+		name         string
+		active       string
+		slaves       []bondSlave
+		trackCarrier bool
+		expected     string
+	}{
+		{
+			name:     "a primary-capable slave is active",
+			active:   "eth1",
+			slaves:   slaves,
+			expected: "",
+		},
+		{
+			name:     "fail back from the backup slave",
+			active:   "usb0",
+			slaves:   slaves,
+			expected: "eth1",
+		},
+		{
+			name:     "no active slave",
+			active:   "",
+			slaves:   slaves,
+			expected: "eth1",
+		},
+		{
+			name:   "keep the backup slave while the others have no carrier",
+			active: "usb0",
+			slaves: []bondSlave{
+				{name: "eth0", index: 2},
+				{name: "eth1", index: 3},
+				{name: "usb0", index: 4, hasCarrier: true},
+			},
+			expected: "",
+		},
+		{
+			name:     "the kernel fails over the active slave without carrier on its own",
+			active:   "eth0",
+			slaves:   slaves,
+			expected: "",
+		},
+		{
+			name:         "fail over the active slave without carrier if the carrier is tracked",
+			active:       "eth0",
+			slaves:       slaves,
+			trackCarrier: true,
+			expected:     "eth1",
+		},
+		{
+			name:   "fail over to the backup slave if the carrier is tracked and the others have no carrier",
+			active: "eth0",
+			slaves: []bondSlave{
+				{name: "eth0", index: 2},
+				{name: "eth1", index: 3},
+				{name: "usb0", index: 4, hasCarrier: true},
+			},
+			trackCarrier: true,
+			expected:     "usb0",
+		},
+		{
+			name:   "keep the active slave if the carrier is tracked and no slave has carrier",
+			active: "eth0",
+			slaves: []bondSlave{
+				{name: "eth0", index: 2},
+				{name: "eth1", index: 3},
+			},
+			trackCarrier: true,
+			expected:     "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			preferred := preferredSlave(tc.active, tc.slaves, backup, tc.trackCarrier)
+			if tc.expected == "" {
+				assert.Nil(t, preferred)
+				return
+			}
+			if assert.NotNil(t, preferred) {
+				assert.Equal(t, tc.expected, preferred.name)
+			}
+		})
+	}
+}
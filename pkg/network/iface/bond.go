@@ -4,9 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"net"
-	"strings"
+	"slices"
 	"time"
 
+	"github.com/harvester/harvester-network-controller/pkg/network"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
@@ -32,25 +33,7 @@ const (
 
 // isKernelConflictError checks for retryable kernel conflicts
 func isKernelConflictError(err error) bool {
-    if err == nil {
-        return false
-    }
-
-    errorMsg := strings.ToLower(err.Error())
-    conflictIndicators := []string{
-        "device is busy",
-        "resource temporarily unavailable",
-        "operation already in progress",
-        "file exists",
-    }
-
-    for _, indicator := range conflictIndicators {
-        if strings.Contains(errorMsg, indicator) {
-            return true
-        }
-    }
-
-    return false
+	return network.IsRetryable(network.Classify(err))
 }
 
 // retryOnKernelConflict retries the given function up to maxRetryAttempts if it returns a kernel conflict error.
@@ -88,7 +71,7 @@ func retryOnKernelConflict(operation func() error, operationName string) error {
 func setLinkUp(ifName string) error {
 	operation := func() error {
 		// Always refetch the link to get current state
-		link, err := netlink.LinkByName(ifName)
+		link, err := network.Handle().LinkByName(ifName)
 		if err != nil {
 			return fmt.Errorf("get link %s failed: %w", ifName, err)
 		}
@@ -102,7 +85,7 @@ func setLinkUp(ifName string) error {
 		}
 
 		logrus.Infof("Setting NIC %s to UP state (current: %s)", ifName, currentState)
-		return netlink.LinkSetUp(link)
+		return linkSetUp(link)
 	}
 
 	return retryOnKernelConflict(operation, fmt.Sprintf("setLinkUp(%s)", ifName))
@@ -119,22 +102,22 @@ func (b *Bond) EnsureBond() error {
 
 func (b *Bond) ensureBond() error {
 	// add or update bond
-	if oldBond, err := netlink.LinkByName(b.Name); errors.As(err, &netlink.LinkNotFoundError{}) {
-		if err := netlink.LinkAdd(b.Bond); err != nil {
+	if oldBond, err := network.Handle().LinkByName(b.Name); errors.Is(network.Classify(err), network.ErrLinkNotFound) {
+		if err := linkAdd(b.Bond); err != nil {
 			return fmt.Errorf("add bond %s failed, error: %w", b.Name, err)
 		}
 	} else if err != nil {
-		return fmt.Errorf("get bond %s failed, error: %w", b.Name, err)
+		return fmt.Errorf("get bond %s failed, error: %w", b.Name, network.Classify(err))
 	} else {
 		if err := b.modifyBond(oldBond.(*netlink.Bond)); err != nil {
 			return fmt.Errorf("modify bond %s failed, error: %w", b.Name, err)
 		}
 	}
-	if err := netlink.LinkSetUp(b); err != nil {
+	if err := linkSetUp(b); err != nil {
 		return fmt.Errorf("set %s up failed, error: %w", b.Name, err)
 	}
 	// fetch bond
-	l, err := netlink.LinkByName(b.Name)
+	l, err := network.Handle().LinkByName(b.Name)
 	if err != nil {
 		return fmt.Errorf("fetch bond %s failed, error: %w", b.Name, err)
 	}
@@ -161,7 +144,10 @@ func (b *Bond) ensureBondSlaves() error {
 	for _, slave := range b.slaves {
 		l := slaveMap[slave]
 		if l == nil {
-			l, err = netlink.LinkByName(slave)
+			if err := network.CheckExcluded(slave); err != nil {
+				return fmt.Errorf("add slave %s to bond %s failed, error: %w", slave, b.Name, err)
+			}
+			l, err = network.Handle().LinkByName(slave)
 			if err != nil {
 				return fmt.Errorf("get link %s failed, error: %w", slave, err)
 			}
@@ -171,11 +157,11 @@ func (b *Bond) ensureBondSlaves() error {
 			}
 
 			// The slave link should be down before enslaved
-			if err := netlink.LinkSetDown(l); err != nil {
+			if err := linkSetDown(l); err != nil {
 				return fmt.Errorf("set slave %s down failed, error: %w", slave, err)
 			}
 
-			if err := netlink.LinkSetBondSlave(l, b.Bond); err != nil {
+			if err := linkSetBondSlave(l, b.Bond); err != nil {
 				if upErr := setLinkUp(slave); upErr != nil {
 					logrus.Warnf("Failed to set NIC %s up after bond operation failed: %v", slave, upErr)
 				}
@@ -197,7 +183,7 @@ func (b *Bond) ensureBondSlaves() error {
 	var removalErrors []error
 	for name, l := range slaveMap {
 		// First remove from bond
-		if err := netlink.LinkSetNoMaster(l); err != nil {
+		if err := linkSetNoMaster(l); err != nil {
 			removalErrors = append(removalErrors,
 				fmt.Errorf("delete slave %s from %s failed: %w", name, b.Name, err))
 			continue
@@ -225,7 +211,7 @@ func (b *Bond) remove() error {
 		return err
 	}
 
-	if err := netlink.LinkDel(b); err != nil {
+	if err := linkDel(b); err != nil {
 		return err
 	}
 
@@ -248,16 +234,9 @@ func (b *Bond) remove() error {
 	return nil
 }
 
-// modifyBond deletes the original bond and creates a new one
-func (b *Bond) modifyBond(oldBond *netlink.Bond) error {
-	if compareBond(oldBond, b.Bond) {
-		return nil
-	}
-
-	if err := netlink.LinkDel(oldBond); err != nil {
-		return err
-	}
-	return netlink.LinkAdd(b.Bond)
+// Matches returns true if the existing bond has the same attributes as desired, the slaves are not compared
+func (b *Bond) Matches(existing *netlink.Bond) bool {
+	return compareBond(existing, b.Bond)
 }
 
 func getSlaves(index int) ([]netlink.Link, error) {
@@ -265,7 +244,7 @@ func getSlaves(index int) ([]netlink.Link, error) {
 		return nil, fmt.Errorf("invalid master index %d", index)
 	}
 
-	all, err := netlink.LinkList()
+	all, err := network.Handle().LinkList()
 	if err != nil {
 		return nil, fmt.Errorf("list links failed, error: %w", err)
 	}
@@ -280,6 +259,22 @@ func getSlaves(index int) ([]netlink.Link, error) {
 	return links, nil
 }
 
+// arpMonitorDrifts returns true if the ARP monitoring of the existing bond differs from the desired one, the omitted
+// options with the value -1 or nil targets are kept as they are
+func arpMonitorDrifts(old, desired *netlink.Bond) bool {
+	if desired.ArpInterval != -1 && old.ArpInterval != desired.ArpInterval {
+		return true
+	}
+	if desired.ArpValidate != -1 && old.ArpValidate != desired.ArpValidate {
+		return true
+	}
+	if desired.ArpIpTargets != nil && !slices.EqualFunc(old.ArpIpTargets, desired.ArpIpTargets, net.IP.Equal) {
+		return true
+	}
+
+	return false
+}
+
 func compareBond(old, new *netlink.Bond) bool { //nolint
 	if old.Name != new.Name {
 		return false
@@ -305,6 +300,14 @@ func compareBond(old, new *netlink.Bond) bool { //nolint
 		return false
 	}
 
+	// skip if the number of queues is omitted, the kernel default is used
+	if new.NumTxQueues != 0 && old.NumTxQueues != new.NumTxQueues {
+		return false
+	}
+	if new.NumRxQueues != 0 && old.NumRxQueues != new.NumRxQueues {
+		return false
+	}
+
 	//handle change for any value of miimon including default (-1)
 	newMiimon := new.Miimon
 	if newMiimon == -1 {
@@ -315,5 +318,95 @@ func compareBond(old, new *netlink.Bond) bool { //nolint
 		return false
 	}
 
+	// skip if the delays are omitted, default value -1
+	if new.UpDelay != -1 && old.UpDelay != new.UpDelay {
+		return false
+	}
+	if new.DownDelay != -1 && old.DownDelay != new.DownDelay {
+		return false
+	}
+
+	// skip if the hash policy is omitted, default value -1
+	if new.XmitHashPolicy != -1 && old.XmitHashPolicy != new.XmitHashPolicy {
+		return false
+	}
+
+	// skip if the LACP rate is omitted, default value -1
+	if new.LacpRate != -1 && old.LacpRate != new.LacpRate {
+		return false
+	}
+
+	if arpMonitorDrifts(old, new) {
+		return false
+	}
+
 	return true
 }
+
+// getVlanSubInterfaces returns the VLAN sub-interfaces whose parent is the link with the given index
+func getVlanSubInterfaces(index int) ([]netlink.Link, error) {
+	all, err := network.Handle().LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("list links failed, error: %w", err)
+	}
+
+	links := make([]netlink.Link, 0, len(all))
+	for _, l := range all {
+		if l.Type() == TypeVlan && l.Attrs().ParentIndex == index {
+			links = append(links, l)
+		}
+	}
+
+	return links, nil
+}
+
+// RemoveSharedBondIfUnused removes a bond shared by multiple cluster networks.
+// The VLAN sub-interfaces of the bond are the references, the bond is kept as long as any of them exists.
+func RemoveSharedBondIfUnused(index int) error {
+	l, err := network.Handle().LinkByIndex(index)
+	if errors.Is(network.Classify(err), network.ErrLinkNotFound) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("get shared bond with index %d failed, error: %w", index, network.Classify(err))
+	}
+	// the parent is not a bond managed by us
+	if l.Type() != TypeBond {
+		return nil
+	}
+	if err := network.CheckExcluded(l.Attrs().Name); err != nil {
+		logrus.Infof("skip removing shared bond %s, %s", l.Attrs().Name, err.Error())
+		return nil
+	}
+
+	refs, err := getVlanSubInterfaces(index)
+	if err != nil {
+		return err
+	}
+	if len(refs) > 0 {
+		logrus.Infof("shared bond %s is still referenced by %d VLAN sub-interface(s), skip removing it", l.Attrs().Name, len(refs))
+		return nil
+	}
+
+	logrus.Infof("remove shared bond %s as it is no longer referenced", l.Attrs().Name)
+	return NewBond(netlink.NewLinkBond(*l.Attrs()), nil).remove()
+}
+
+// ReleaseSlaves detaches the slaves of the bond which are not in keep, the bond itself is kept
+func ReleaseSlaves(index int, keep []string) error {
+	slaves, err := getSlaves(index)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range slaves {
+		if slices.Contains(keep, s.Attrs().Name) {
+			continue
+		}
+		logrus.Infof("release slave %s from bond with index %d", s.Attrs().Name, index)
+		if err := linkSetNoMaster(s); err != nil {
+			return fmt.Errorf("release slave %s failed, error: %w", s.Attrs().Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package iface
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+func TestBondMatches(t *testing.T) {
+	existing := newTestBond(func(b *netlink.Bond) {
+		b.Mode = netlink.BOND_MODE_802_3AD
+		b.MTU = utils.DefaultMTU
+		b.TxQLen = 1000
+		b.Miimon = utils.DefaultValueMiimon
+		b.UpDelay = 0
+		b.DownDelay = 0
+		b.XmitHashPolicy = netlink.BOND_XMIT_HASH_POLICY_LAYER3_4
+	})
+
+	tests := []struct {
+		name    string
+		desired *netlink.Bond
+		matches bool
+	}{
+		{
+			name:    "the omitted attributes are kept",
+			desired: newTestBond(func(b *netlink.Bond) { b.Mode = netlink.BOND_MODE_802_3AD }),
+			matches: true,
+		},
+		{
+			name:    "mode drifts",
+			desired: newTestBond(nil),
+		},
+		{
+			name: "miimon drifts",
+			desired: newTestBond(func(b *netlink.Bond) {
+				b.Mode = netlink.BOND_MODE_802_3AD
+				b.Miimon = 200
+			}),
+		},
+		{
+			name: "hash policy drifts",
+			desired: newTestBond(func(b *netlink.Bond) {
+				b.Mode = netlink.BOND_MODE_802_3AD
+				b.XmitHashPolicy = netlink.BOND_XMIT_HASH_POLICY_LAYER2
+			}),
+		},
+		{
+			name: "ARP targets drift",
+			desired: newTestBond(func(b *netlink.Bond) {
+				b.Mode = netlink.BOND_MODE_802_3AD
+				b.ArpIpTargets = []net.IP{net.ParseIP("192.168.1.1").To4()}
+			}),
+		},
+		{
+			name: "LACP rate drifts",
+			desired: newTestBond(func(b *netlink.Bond) {
+				b.Mode = netlink.BOND_MODE_802_3AD
+				b.LacpRate = netlink.BOND_LACP_RATE_FAST
+			}),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.matches, NewBond(tc.desired, nil).Matches(existing))
+		})
+	}
+}
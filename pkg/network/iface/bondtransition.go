@@ -0,0 +1,235 @@
+package iface
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// BondTransitionError is returned if the bond can't be transitioned to the desired attributes on the fly.
+// The bond is left as it is, it's up to the user to remove the links depending on the bond first.
+type BondTransitionError struct {
+	Bond   string
+	Reason string
+}
+
+func (e *BondTransitionError) Error() string {
+	return fmt.Sprintf("unsupported transition of bond %s: %s", e.Bond, e.Reason)
+}
+
+// IsBondTransitionError returns true if the error is or wraps a BondTransitionError
+func IsBondTransitionError(err error) bool {
+	var e *BondTransitionError
+	return errors.As(err, &e)
+}
+
+// bondTransition is the plan to transition the existing bond to the desired one
+type bondTransition struct {
+	// the attributes can only be set when the bond is created, e.g. the number of queues
+	recreate bool
+	// the mode can only be changed when the bond is down and has no slave
+	mode bool
+	// the attributes which can be changed on the fly
+	mtu, hardwareAddr, txQLen, miimon, delays, xmitHashPolicy, lacpRate, arpMonitor bool
+}
+
+// planBondTransition returns how to transition the existing bond to the desired one.
+// Recreating the bond drops the VLAN sub-interfaces on it, which belong to other cluster networks sharing the bond,
+// so it's refused if there is any.
+func planBondTransition(old, desired *netlink.Bond, vlanSubInterfaces int) (bondTransition, error) {
+	t := bondTransition{
+		recreate: (desired.NumTxQueues != 0 && old.NumTxQueues != desired.NumTxQueues) ||
+			(desired.NumRxQueues != 0 && old.NumRxQueues != desired.NumRxQueues),
+		mode:         old.Mode != desired.Mode,
+		mtu:          desired.MTU != 0 && old.MTU != desired.MTU,
+		hardwareAddr: desired.HardwareAddr.String() != "" && old.HardwareAddr.String() != desired.HardwareAddr.String(),
+		txQLen:       desired.TxQLen != -1 && old.TxQLen != desired.TxQLen,
+		miimon:       old.Miimon != desiredMiimon(desired),
+		// skip if the delays are omitted, the default value -1 keeps them as they are
+		delays: (desired.UpDelay != -1 && old.UpDelay != desired.UpDelay) ||
+			(desired.DownDelay != -1 && old.DownDelay != desired.DownDelay),
+		// skip if the hash policy is unset, the value -1 keeps it as it is
+		xmitHashPolicy: desired.XmitHashPolicy != -1 && old.XmitHashPolicy != desired.XmitHashPolicy,
+		// skip if the LACP rate is unset, the value -1 keeps it as it is
+		lacpRate:   desired.LacpRate != -1 && old.LacpRate != desired.LacpRate,
+		arpMonitor: arpMonitorDrifts(old, desired),
+	}
+
+	if t.recreate && vlanSubInterfaces > 0 {
+		return t, &BondTransitionError{
+			Bond: desired.Name,
+			Reason: fmt.Sprintf("the number of queues can't be changed while %d VLAN sub-interface(s) depend on it",
+				vlanSubInterfaces),
+		}
+	}
+
+	return t, nil
+}
+
+func desiredMiimon(b *netlink.Bond) int {
+	if b.Miimon == -1 {
+		return utils.DefaultValueMiimon
+	}
+	return b.Miimon
+}
+
+// modifyBond transitions the existing bond to the desired attributes. The bond is modified in place when possible
+// to keep the bridge port and the VLAN sub-interfaces on it, and it's only recreated for the attributes which can't
+// be modified.
+func (b *Bond) modifyBond(oldBond *netlink.Bond) error {
+	refs, err := getVlanSubInterfaces(oldBond.Index)
+	if err != nil {
+		return err
+	}
+	t, err := planBondTransition(oldBond, b.Bond, len(refs))
+	if err != nil {
+		return err
+	}
+
+	if t.recreate {
+		logrus.Infof("recreate bond %s to change the number of queues", b.Name)
+		if err := linkDel(oldBond); err != nil {
+			return err
+		}
+		return linkAdd(b.Bond)
+	}
+
+	if t.mode {
+		if err := b.changeMode(oldBond); err != nil {
+			return err
+		}
+	}
+	if t.mtu {
+		if err := linkSetMTU(oldBond, b.MTU); err != nil {
+			return fmt.Errorf("set MTU of %s to %d failed, error: %w", b.Name, b.MTU, network.ClassifyMTU(err))
+		}
+	}
+	if t.hardwareAddr {
+		if err := linkSetHardwareAddr(oldBond, b.HardwareAddr); err != nil {
+			return fmt.Errorf("set hardware address of %s to %s failed, error: %w", b.Name, b.HardwareAddr, err)
+		}
+	}
+	if t.txQLen {
+		if err := linkSetTxQLen(oldBond, b.TxQLen); err != nil {
+			return fmt.Errorf("set txqueuelen of %s to %d failed, error: %w", b.Name, b.TxQLen, err)
+		}
+	}
+	// the delays are rounded down to multiples of miimon by the kernel, so they're changed together with miimon
+	if t.miimon || t.delays {
+		change := newBondChange(oldBond)
+		change.Miimon = desiredMiimon(b.Bond)
+		change.UpDelay = b.UpDelay
+		change.DownDelay = b.DownDelay
+		if err := linkModify(change); err != nil {
+			return fmt.Errorf("set miimon/updelay/downdelay of %s to %d/%d/%d failed, error: %w", b.Name,
+				change.Miimon, change.UpDelay, change.DownDelay, err)
+		}
+	}
+	// the hash policy is changed after the mode, some modes ignore it
+	if t.xmitHashPolicy {
+		change := newBondChange(oldBond)
+		change.XmitHashPolicy = b.XmitHashPolicy
+		if err := linkModify(change); err != nil {
+			return fmt.Errorf("set xmit hash policy of %s to %s failed, error: %w", b.Name, b.XmitHashPolicy, err)
+		}
+	}
+	// the LACP rate is only accepted in the mode 802.3ad, so it's changed after the mode as well
+	if t.lacpRate {
+		change := newBondChange(oldBond)
+		change.LacpRate = b.LacpRate
+		if err := linkModify(change); err != nil {
+			return fmt.Errorf("set LACP rate of %s to %s failed, error: %w", b.Name, b.LacpRate, err)
+		}
+	}
+	// the ARP monitoring is changed after miimon, the kernel refuses to turn it on while miimon is on
+	if t.arpMonitor {
+		change := newBondChange(oldBond)
+		change.ArpInterval = b.ArpInterval
+		change.ArpIpTargets = b.ArpIpTargets
+		change.ArpValidate = b.ArpValidate
+		if err := linkModify(change); err != nil {
+			return fmt.Errorf("set ARP interval/targets of %s to %d/%v failed, error: %w", b.Name, b.ArpInterval,
+				b.ArpIpTargets, err)
+		}
+	}
+
+	return nil
+}
+
+// newBondChange returns a bond carrying no attribute, to modify the given attributes of the existing bond only
+func newBondChange(oldBond *netlink.Bond) *netlink.Bond {
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = oldBond.Name
+	attrs.Index = oldBond.Index
+	return netlink.NewLinkBond(attrs)
+}
+
+// changeMode changes the bond mode in the order required by the kernel:
+// set the bond down, release the slaves, change the mode, enslave the slaves again.
+// The mode is kept if it can't be changed, and the slaves are always enslaved again,
+// so that the bond is never left down without slaves.
+func (b *Bond) changeMode(oldBond *netlink.Bond) error {
+	slaves, err := getSlaves(oldBond.Index)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("change mode of bond %s from %s to %s", b.Name, oldBond.Mode, b.Mode)
+
+	if err := linkSetDown(oldBond); err != nil {
+		return fmt.Errorf("set bond %s down failed, error: %w", b.Name, err)
+	}
+
+	released := make([]netlink.Link, 0, len(slaves))
+	var modeErr error
+	for _, s := range slaves {
+		if err := linkSetNoMaster(s); err != nil {
+			modeErr = fmt.Errorf("release slave %s failed, error: %w", s.Attrs().Name, err)
+			break
+		}
+		released = append(released, s)
+	}
+
+	if modeErr == nil {
+		change := newBondChange(oldBond)
+		change.Mode = b.Mode
+		if err := linkModify(change); err != nil {
+			modeErr = fmt.Errorf("set mode to %s failed, error: %w", b.Mode, err)
+		}
+	}
+
+	// enslave the released slaves again whether the mode is changed or not
+	var enslaveErrors []error
+	for _, s := range released {
+		if err := linkSetDown(s); err != nil {
+			enslaveErrors = append(enslaveErrors, fmt.Errorf("set slave %s down failed: %w", s.Attrs().Name, err))
+			continue
+		}
+		if err := linkSetBondSlave(s, oldBond); err != nil {
+			enslaveErrors = append(enslaveErrors, fmt.Errorf("add slave %s failed: %w", s.Attrs().Name, err))
+		}
+		if err := setLinkUp(s.Attrs().Name); err != nil {
+			enslaveErrors = append(enslaveErrors, err)
+		}
+	}
+
+	if err := linkSetUp(oldBond); err != nil {
+		enslaveErrors = append(enslaveErrors, fmt.Errorf("set bond up failed: %w", err))
+	}
+
+	switch {
+	case modeErr != nil && len(enslaveErrors) > 0:
+		return fmt.Errorf("change mode of bond %s failed, error: %w, and restore failed: %v", b.Name, modeErr, enslaveErrors)
+	case modeErr != nil:
+		return fmt.Errorf("change mode of bond %s failed and the slaves are restored, error: %w", b.Name, modeErr)
+	case len(enslaveErrors) > 0:
+		return fmt.Errorf("bond %s is changed to mode %s but %d slave(s) failed to recover: %v", b.Name, b.Mode,
+			len(enslaveErrors), enslaveErrors)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package iface
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+func newTestBond(mutate func(b *netlink.Bond)) *netlink.Bond {
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = "test-bo"
+	b := netlink.NewLinkBond(attrs)
+	b.Mode = netlink.BOND_MODE_ACTIVE_BACKUP
+	b.Miimon = -1
+	if mutate != nil {
+		mutate(b)
+	}
+	return b
+}
+
+func Test_planBondTransition(t *testing.T) {
+	existing := newTestBond(func(b *netlink.Bond) {
+		b.MTU = utils.DefaultMTU
+		b.TxQLen = 1000
+		b.Miimon = utils.DefaultValueMiimon
+		b.NumTxQueues = 16
+		b.NumRxQueues = 16
+		b.HardwareAddr = net.HardwareAddr{0x02, 0, 0, 0, 0, 1}
+		b.UpDelay = 0
+		b.DownDelay = 0
+	})
+
+	tests := []struct {
+		name              string
+		desired           *netlink.Bond
+		vlanSubInterfaces int
+		returnErr         bool
+		expected          bondTransition
+	}{
+		{
+			name:     "nothing to change with the omitted attributes",
+			desired:  newTestBond(nil),
+			expected: bondTransition{},
+		},
+		{
+			name:     "mode change is done in place",
+			desired:  newTestBond(func(b *netlink.Bond) { b.Mode = netlink.BOND_MODE_802_3AD }),
+			expected: bondTransition{mode: true},
+		},
+		{
+			name:              "mode change is done in place with VLAN sub-interfaces",
+			desired:           newTestBond(func(b *netlink.Bond) { b.Mode = netlink.BOND_MODE_BALANCE_ALB }),
+			vlanSubInterfaces: 2,
+			expected:          bondTransition{mode: true},
+		},
+		{
+			name: "attributes changed on the fly",
+			desired: newTestBond(func(b *netlink.Bond) {
+				b.MTU = 9000
+				b.TxQLen = 2000
+				b.Miimon = 200
+				b.HardwareAddr = net.HardwareAddr{0x02, 0, 0, 0, 0, 2}
+			}),
+			expected: bondTransition{mtu: true, txQLen: true, miimon: true, hardwareAddr: true},
+		},
+		{
+			name: "delays changed on the fly",
+			desired: newTestBond(func(b *netlink.Bond) {
+				b.UpDelay = 2000
+				b.DownDelay = 200
+			}),
+			expected: bondTransition{delays: true},
+		},
+		{
+			name: "zero delays are the kernel default",
+			desired: newTestBond(func(b *netlink.Bond) {
+				b.UpDelay = 0
+				b.DownDelay = 0
+			}),
+			expected: bondTransition{},
+		},
+		{
+			name:     "hash policy changed on the fly",
+			desired:  newTestBond(func(b *netlink.Bond) { b.XmitHashPolicy = netlink.BOND_XMIT_HASH_POLICY_LAYER3_4 }),
+			expected: bondTransition{xmitHashPolicy: true},
+		},
+		{
+			name:     "LACP rate changed on the fly",
+			desired:  newTestBond(func(b *netlink.Bond) { b.LacpRate = netlink.BOND_LACP_RATE_FAST }),
+			expected: bondTransition{lacpRate: true},
+		},
+		{
+			name: "ARP monitoring replaces miimon on the fly",
+			desired: newTestBond(func(b *netlink.Bond) {
+				b.Miimon = 0
+				b.ArpInterval = 1000
+				b.ArpIpTargets = []net.IP{net.ParseIP("192.168.1.1").To4()}
+			}),
+			expected: bondTransition{miimon: true, arpMonitor: true},
+		},
+		{
+			name:     "queue change recreates the bond",
+			desired:  newTestBond(func(b *netlink.Bond) { b.NumTxQueues = 8 }),
+			expected: bondTransition{recreate: true},
+		},
+		{
+			name:              "queue change is refused with VLAN sub-interfaces",
+			desired:           newTestBond(func(b *netlink.Bond) { b.NumRxQueues = 8 }),
+			vlanSubInterfaces: 1,
+			returnErr:         true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			transition, err := planBondTransition(existing, tc.desired, tc.vlanSubInterfaces)
+			assert.Equal(t, tc.returnErr, err != nil)
+			if tc.returnErr {
+				assert.True(t, IsBondTransitionError(err))
+				return
+			}
+			assert.Equal(t, tc.expected, transition)
+		})
+	}
+}
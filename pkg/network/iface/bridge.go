@@ -2,15 +2,20 @@ package iface
 
 import (
 	"fmt"
+	"os"
+	"strings"
 	"syscall"
 
+	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
 
+	"github.com/harvester/harvester-network-controller/pkg/network"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
 
 const (
 	bridgeNFCallIptables = "net/bridge/bridge-nf-call-iptables"
+	bridgeSTPStateFmt    = "/sys/class/net/%s/bridge/stp_state"
 )
 
 type Bridge struct {
@@ -27,10 +32,24 @@ func NewBridge(name string) *Bridge {
 	}
 }
 
+// NewBridgeWithoutVlanFiltering returns a bridge forwarding the frames regardless of their VLAN, e.g. the bridges of a
+// cluster network with the datapath BridgePerVID
+func NewBridgeWithoutVlanFiltering(name string) *Bridge {
+	vlanFiltering := false
+	return &Bridge{
+		&netlink.Bridge{
+			LinkAttrs:     netlink.LinkAttrs{Name: name},
+			VlanFiltering: &vlanFiltering,
+		},
+	}
+}
+
 // Ensure bridge
-// set promiscuous mod default
+// The promiscuous mode follows the NADs requesting it, see the cluster network controller of the agent.
+// The options changed outside the agent, e.g. by a debugging session, are corrected as well, not only the existence.
 func (br *Bridge) Ensure() error {
-	if err := netlink.LinkAdd(br); err != nil && err != syscall.EEXIST {
+	vlanFiltering := br.VlanFiltering == nil || *br.VlanFiltering
+	if err := linkAdd(br); err != nil && err != syscall.EEXIST {
 		return fmt.Errorf("add iface failed, error: %w, iface: %v", err, br)
 	}
 
@@ -40,20 +59,24 @@ func (br *Bridge) Ensure() error {
 		return err
 	}
 
-	if br.Promisc != 1 {
-		if err := netlink.SetPromiscOn(br); err != nil {
-			return fmt.Errorf("set promiscuous mode failed, error: %w, iface: %v", err, br)
+	if br.VlanFiltering != nil && *br.VlanFiltering != vlanFiltering {
+		logrus.Infof("set the vlan filtering of bridge %s changed outside the agent to %t", br.Name, vlanFiltering)
+		if err := network.Handle().BridgeSetVlanFiltering(br.Bridge, vlanFiltering); err != nil {
+			return fmt.Errorf("set vlan filtering failed, error: %w, iface: %v", err, br)
 		}
 	}
 
-	if br.VlanFiltering != nil && !*br.VlanFiltering {
-		if err := netlink.BridgeSetVlanFiltering(br.Bridge, true); err != nil {
-			return fmt.Errorf("set vlan filtering failed, error: %w, iface: %v", err, br)
-		}
+	if err := br.ensureSTPDisabled(); err != nil {
+		return err
+	}
+
+	// the sysctl is reset if the br_netfilter module is reloaded
+	if err := DisableBridgeNF(); err != nil {
+		return fmt.Errorf("disable %s failed, error: %w", bridgeNFCallIptables, err)
 	}
 
 	if br.OperState != netlink.OperUp {
-		if err := netlink.LinkSetUp(br); err != nil {
+		if err := linkSetUp(br); err != nil {
 			return err
 		}
 	}
@@ -62,14 +85,35 @@ func (br *Bridge) Ensure() error {
 	return br.Fetch()
 }
 
+// ensureSTPDisabled turns off the STP enabled outside the agent, the uplink would block the traffic for the forwarding
+// delay after every change of the bridge ports otherwise. The bridges are created without STP, the loops are detected
+// by the loop detection instead.
+func (br *Bridge) ensureSTPDisabled() error {
+	path := fmt.Sprintf(bridgeSTPStateFmt, br.Name)
+	state, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read STP state of %s failed, error: %w", br.Name, err)
+	}
+	if strings.TrimSpace(string(state)) == "0" {
+		return nil
+	}
+
+	logrus.Infof("disable the STP of bridge %s enabled outside the agent", br.Name)
+	if err := os.WriteFile(path, []byte("0"), 0644); err != nil {
+		return fmt.Errorf("disable STP of %s failed, error: %w", br.Name, err)
+	}
+
+	return nil
+}
+
 func DisableBridgeNF() error {
 	return utils.EnsureSysctlValue(bridgeNFCallIptables, "0")
 }
 
 func (br *Bridge) Fetch() error {
-	l, err := netlink.LinkByName(br.Name)
+	l, err := network.Handle().LinkByName(br.Name)
 	if err != nil {
-		return fmt.Errorf("could not lookup link %s, error: %w", br.Name, err)
+		return fmt.Errorf("could not lookup link %s, error: %w", br.Name, network.Classify(err))
 	}
 
 	b, ok := l.(*netlink.Bridge)
@@ -0,0 +1,39 @@
+package iface
+
+import (
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// ListPerVIDUplinks returns the VLAN sub-interfaces of the cluster network on the parent by their VID, they are the
+// masters of the macvlan or ipvlan links of a bridge-less cluster network
+func ListPerVIDUplinks(cnName string, parentIndex int) (map[uint16]netlink.Link, error) {
+	links, err := network.Handle().LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("list links failed, error: %w", err)
+	}
+
+	subs := make(map[uint16]netlink.Link)
+	for _, l := range links {
+		sub, ok := l.(*netlink.Vlan)
+		if !ok || sub.ParentIndex != parentIndex || sub.VlanId < 1 || sub.VlanId > utils.MaxVlanID {
+			continue
+		}
+		vid := uint16(sub.VlanId) //nolint:gosec
+		if sub.Name == utils.GeneratePerVIDUplinkName(cnName, vid) {
+			subs[vid] = sub
+		}
+	}
+
+	return subs, nil
+}
+
+// RemovePerVIDUplink removes the VLAN sub-interface of the VID of the cluster network, the macvlan or ipvlan links on
+// it are removed with it by the kernel
+func RemovePerVIDUplink(cnName string, vid uint16) error {
+	return removeLinks(utils.GeneratePerVIDUplinkName(cnName, vid))
+}
@@ -0,0 +1,108 @@
+package iface
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/fault"
+)
+
+// maxVlanRangesPerRequest bounds the size of a batched request, the ranges beyond it are sent in the next request
+const maxVlanRangesPerRequest = 256
+
+type vlanRange struct {
+	begin, end uint16
+}
+
+// AddBridgeVlans adds the vlan filter entries of the VIDs with as few requests as possible, the contiguous VIDs are
+// sent as ranges. If the kernel rejects a batched request, e.g. it doesn't support the ranges, the VIDs of the request
+// are added one by one.
+// Equivalent to: `bridge vlan add dev DEV vid BEGIN-END master` for each range
+func (l *Link) AddBridgeVlans(vids []uint16) error {
+	return l.modifyBridgeVlans(unix.RTM_SETLINK, vids, l.AddBridgeVlan)
+}
+
+// DelBridgeVlans deletes the vlan filter entries of the VIDs with as few requests as possible
+// Equivalent to: `bridge vlan del dev DEV vid BEGIN-END master` for each range
+func (l *Link) DelBridgeVlans(vids []uint16) error {
+	return l.modifyBridgeVlans(unix.RTM_DELLINK, vids, l.DelBridgeVlan)
+}
+
+func (l *Link) modifyBridgeVlans(cmd int, vids []uint16, modifyOne func(uint16) error) error {
+	var errs []error
+	ranges := toVlanRanges(vids)
+	for len(ranges) > 0 {
+		n := min(len(ranges), maxVlanRangesPerRequest)
+		if err := bridgeVlanModifyRanges(cmd, l.Attrs().Index, ranges[:n]); err != nil {
+			logrus.Debugf("batched bridge vlan request on %s failed, fall back to a request per vid, error: %s",
+				l.Attrs().Name, err.Error())
+			for _, r := range ranges[:n] {
+				for vid := uint32(r.begin); vid <= uint32(r.end); vid++ {
+					if err := modifyOne(uint16(vid)); err != nil {
+						errs = append(errs, err)
+					}
+				}
+			}
+		}
+		ranges = ranges[n:]
+	}
+
+	return errors.Join(errs...)
+}
+
+// toVlanRanges merges the VIDs in ascending order into ranges, the default PVID is skipped as AddBridgeVlan does
+func toVlanRanges(vids []uint16) []vlanRange {
+	var ranges []vlanRange
+	for _, vid := range vids {
+		if vid == defaultPVID || vid == minVlanID {
+			continue
+		}
+		if last := len(ranges) - 1; last >= 0 && ranges[last].end+1 == vid {
+			ranges[last].end = vid
+			continue
+		}
+		ranges = append(ranges, vlanRange{begin: vid, end: vid})
+	}
+
+	return ranges
+}
+
+func bridgeVlanModifyRanges(cmd, index int, ranges []vlanRange) error {
+	op := "bridgeVlanAdd"
+	if cmd == unix.RTM_SETLINK {
+		metrics.CountNetlinkOp(metrics.OpBridgeVlanAdd)
+	} else {
+		op = "bridgeVlanDel"
+		metrics.CountNetlinkOp(metrics.OpBridgeVlanDel)
+	}
+	if err := fault.Inject(op, ""); err != nil {
+		return err
+	}
+
+	req := network.NewRouteRequest(cmd, unix.NLM_F_ACK)
+	msg := nl.NewIfInfomsg(unix.AF_BRIDGE)
+	msg.Index = int32(index) //nolint:gosec
+	req.AddData(msg)
+
+	br := nl.NewRtAttr(unix.IFLA_AF_SPEC, nil)
+	br.AddRtAttr(nl.IFLA_BRIDGE_FLAGS, nl.Uint16Attr(nl.BRIDGE_FLAGS_MASTER))
+	for _, r := range ranges {
+		if r.begin == r.end {
+			br.AddRtAttr(nl.IFLA_BRIDGE_VLAN_INFO, (&nl.BridgeVlanInfo{Vid: r.begin}).Serialize())
+			continue
+		}
+		br.AddRtAttr(nl.IFLA_BRIDGE_VLAN_INFO,
+			(&nl.BridgeVlanInfo{Vid: r.begin, Flags: nl.BRIDGE_VLAN_INFO_RANGE_BEGIN}).Serialize())
+		br.AddRtAttr(nl.IFLA_BRIDGE_VLAN_INFO,
+			(&nl.BridgeVlanInfo{Vid: r.end, Flags: nl.BRIDGE_VLAN_INFO_RANGE_END}).Serialize())
+	}
+	req.AddData(br)
+
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return network.Classify(err)
+}
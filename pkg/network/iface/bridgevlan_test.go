@@ -0,0 +1,37 @@
+package iface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_toVlanRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		vids     []uint16
+		expected []vlanRange
+	}{
+		{
+			name:     "no vids",
+			vids:     nil,
+			expected: nil,
+		},
+		{
+			name:     "default pvid is skipped",
+			vids:     []uint16{1, 100},
+			expected: []vlanRange{{begin: 100, end: 100}},
+		},
+		{
+			name:     "contiguous vids are merged",
+			vids:     []uint16{100, 101, 102, 200, 300, 301},
+			expected: []vlanRange{{begin: 100, end: 102}, {begin: 200, end: 200}, {begin: 300, end: 301}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, toVlanRanges(tc.vids))
+		})
+	}
+}
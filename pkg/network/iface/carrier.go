@@ -0,0 +1,69 @@
+package iface
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// BondLinkMonitored returns true if the bond detects the link failures of its slaves by miimon or the ARP monitoring.
+// The kernel takes all the slaves of a bond without link monitoring as up, and so the bond itself keeps carrier.
+func BondLinkMonitored(b *netlink.Bond) bool {
+	return b.Miimon > 0 || b.ArpInterval > 0
+}
+
+// CarrierOf returns true if the link has carrier. The carrier of a bond without link monitoring, or of a VLAN
+// sub-interface of it, is tracked on the slaves of the bond instead, it has carrier if any of its slaves has carrier.
+func CarrierOf(l netlink.Link) (bool, error) {
+	if link, ok := l.(*Link); ok {
+		l = link.Link
+	}
+	if l.Attrs().OperState != netlink.OperUp {
+		return false, nil
+	}
+
+	if vlan, ok := l.(*netlink.Vlan); ok && vlan.ParentIndex != 0 {
+		parent, err := network.Handle().LinkByIndex(vlan.ParentIndex)
+		if err != nil {
+			return false, network.Classify(err)
+		}
+		l = parent
+	}
+	bond, ok := l.(*netlink.Bond)
+	if !ok || BondLinkMonitored(bond) {
+		return true, nil
+	}
+
+	slaves, err := getSlaves(bond.Index)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.ContainsFunc(slaves, hasCarrier), nil
+}
+
+// NICsWithoutCarrier returns the NICs which are not operationally up, a missing NIC has no carrier either
+func NICsWithoutCarrier(nics []string) ([]string, error) {
+	down := make([]string, 0)
+	for _, nic := range nics {
+		l, err := network.Handle().LinkByName(nic)
+		if err = network.Classify(err); errors.Is(err, network.ErrLinkNotFound) {
+			down = append(down, nic)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if !hasCarrier(l) {
+			down = append(down, nic)
+		}
+	}
+
+	return down, nil
+}
+
+func hasCarrier(l netlink.Link) bool {
+	return l.Attrs().OperState == netlink.OperUp
+}
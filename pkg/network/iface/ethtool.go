@@ -0,0 +1,261 @@
+package iface
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// ethtoolRingParam is struct ethtool_ringparam in include/uapi/linux/ethtool.h
+type ethtoolRingParam struct {
+	cmd               uint32
+	rxMaxPending      uint32
+	rxMiniMaxPending  uint32
+	rxJumboMaxPending uint32
+	txMaxPending      uint32
+	rxPending         uint32
+	rxMiniPending     uint32
+	rxJumboPending    uint32
+	txPending         uint32
+}
+
+// ethtoolCoalesce is struct ethtool_coalesce in include/uapi/linux/ethtool.h
+type ethtoolCoalesce struct {
+	cmd                      uint32
+	rxCoalesceUsecs          uint32
+	rxMaxCoalescedFrames     uint32
+	rxCoalesceUsecsIrq       uint32
+	rxMaxCoalescedFramesIrq  uint32
+	txCoalesceUsecs          uint32
+	txMaxCoalescedFrames     uint32
+	txCoalesceUsecsIrq       uint32
+	txMaxCoalescedFramesIrq  uint32
+	statsBlockCoalesceUsecs  uint32
+	useAdaptiveRxCoalesce    uint32
+	useAdaptiveTxCoalesce    uint32
+	pktRateLow               uint32
+	rxCoalesceUsecsLow       uint32
+	rxMaxCoalescedFramesLow  uint32
+	txCoalesceUsecsLow       uint32
+	txMaxCoalescedFramesLow  uint32
+	pktRateHigh              uint32
+	rxCoalesceUsecsHigh      uint32
+	rxMaxCoalescedFramesHigh uint32
+	txCoalesceUsecsHigh      uint32
+	txMaxCoalescedFramesHigh uint32
+	rateSampleInterval       uint32
+}
+
+// ethtoolCmd is the legacy struct ethtool_cmd in include/uapi/linux/ethtool.h
+type ethtoolCmd struct {
+	cmd           uint32
+	supported     uint32
+	advertising   uint32
+	speed         uint16
+	duplex        uint8
+	port          uint8
+	phyAddress    uint8
+	transceiver   uint8
+	autoneg       uint8
+	mdioSupport   uint8
+	maxtxpkt      uint32
+	maxrxpkt      uint32
+	speedHi       uint16
+	ethTpMdix     uint8
+	ethTpMdixCtrl uint8
+	lpAdvertising uint32
+	reserved      [2]uint32
+}
+
+// the speed in Mb/s of the SUPPORTED_* bits of the legacy link mode mask
+var supportedSpeeds = []struct {
+	bit   uint32
+	speed uint32
+}{
+	{1 << 0, 10},     // 10baseT_Half
+	{1 << 1, 10},     // 10baseT_Full
+	{1 << 2, 100},    // 100baseT_Half
+	{1 << 3, 100},    // 100baseT_Full
+	{1 << 4, 1000},   // 1000baseT_Half
+	{1 << 5, 1000},   // 1000baseT_Full
+	{1 << 12, 10000}, // 10000baseT_Full
+	{1 << 15, 2500},  // 2500baseX_Full
+	{1 << 17, 1000},  // 1000baseKX_Full
+	{1 << 18, 10000}, // 10000baseKX4_Full
+	{1 << 19, 10000}, // 10000baseKR_Full
+	{1 << 20, 10000}, // 10000baseR_FEC
+	{1 << 21, 20000}, // 20000baseMLD2_Full
+	{1 << 22, 20000}, // 20000baseKR2_Full
+	{1 << 23, 40000}, // 40000baseKR4_Full
+	{1 << 24, 40000}, // 40000baseCR4_Full
+	{1 << 25, 40000}, // 40000baseSR4_Full
+	{1 << 26, 40000}, // 40000baseLR4_Full
+	{1 << 27, 56000}, // 56000baseKR4_Full
+	{1 << 28, 56000}, // 56000baseCR4_Full
+	{1 << 29, 56000}, // 56000baseSR4_Full
+	{1 << 30, 56000}, // 56000baseLR4_Full
+}
+
+// ifreqData is struct ifreq with the ifr_data member of the union
+type ifreqData struct {
+	name [unix.IFNAMSIZ]byte
+	data uintptr
+	_    [16]byte
+}
+
+// RingConfig is the desired ring buffer size, 0 means to keep the current value
+type RingConfig struct {
+	RX uint32
+	TX uint32
+}
+
+// CoalesceConfig is the desired interrupt coalescing, nil means to keep the current value
+type CoalesceConfig struct {
+	AdaptiveRX *bool
+	AdaptiveTX *bool
+	RXUsecs    *uint32
+	RXFrames   *uint32
+	TXUsecs    *uint32
+	TXFrames   *uint32
+}
+
+func ethtoolIoctl(name string, data unsafe.Pointer) error {
+	if len(name) >= unix.IFNAMSIZ {
+		return fmt.Errorf("invalid interface name %s", name)
+	}
+
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	ifr := ifreqData{data: uintptr(data)}
+	copy(ifr.name[:], name)
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.SIOCETHTOOL, uintptr(unsafe.Pointer(&ifr))) // #nosec G103
+	runtime.KeepAlive(data)
+	if errno != 0 {
+		return network.Classify(errno)
+	}
+
+	return nil
+}
+
+// EnsureRing is equivalent to `ethtool -G <nic> rx <rx> tx <tx>` and does nothing if the ring size is as desired
+func EnsureRing(nic string, cfg *RingConfig) error {
+	if cfg == nil || (cfg.RX == 0 && cfg.TX == 0) {
+		return nil
+	}
+
+	ring := ethtoolRingParam{cmd: unix.ETHTOOL_GRINGPARAM}
+	if err := ethtoolIoctl(nic, unsafe.Pointer(&ring)); err != nil { // #nosec G103
+		return fmt.Errorf("get ring parameters of %s failed, error: %w", nic, err)
+	}
+
+	changed := false
+	if cfg.RX != 0 && cfg.RX != ring.rxPending {
+		if cfg.RX > ring.rxMaxPending {
+			return fmt.Errorf("rx ring size %d of %s exceeds the maximum %d", cfg.RX, nic, ring.rxMaxPending)
+		}
+		ring.rxPending, changed = cfg.RX, true
+	}
+	if cfg.TX != 0 && cfg.TX != ring.txPending {
+		if cfg.TX > ring.txMaxPending {
+			return fmt.Errorf("tx ring size %d of %s exceeds the maximum %d", cfg.TX, nic, ring.txMaxPending)
+		}
+		ring.txPending, changed = cfg.TX, true
+	}
+	if !changed {
+		return nil
+	}
+
+	logrus.Infof("set ring parameters of %s, rx: %d, tx: %d", nic, ring.rxPending, ring.txPending)
+	ring.cmd = unix.ETHTOOL_SRINGPARAM
+	if err := ethtoolIoctl(nic, unsafe.Pointer(&ring)); err != nil { // #nosec G103
+		return fmt.Errorf("set ring parameters of %s failed, error: %w", nic, err)
+	}
+
+	return nil
+}
+
+// EnsureCoalesce is equivalent to `ethtool -C <nic> ...` and does nothing if the coalescing is as desired
+func EnsureCoalesce(nic string, cfg *CoalesceConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	c := ethtoolCoalesce{cmd: unix.ETHTOOL_GCOALESCE}
+	if err := ethtoolIoctl(nic, unsafe.Pointer(&c)); err != nil { // #nosec G103
+		return fmt.Errorf("get coalesce parameters of %s failed, error: %w", nic, err)
+	}
+
+	changed := false
+	setBool := func(field *uint32, v *bool) {
+		if v == nil {
+			return
+		}
+		value := uint32(0)
+		if *v {
+			value = 1
+		}
+		if *field != value {
+			*field, changed = value, true
+		}
+	}
+	setUint32 := func(field *uint32, v *uint32) {
+		if v != nil && *field != *v {
+			*field, changed = *v, true
+		}
+	}
+	setBool(&c.useAdaptiveRxCoalesce, cfg.AdaptiveRX)
+	setBool(&c.useAdaptiveTxCoalesce, cfg.AdaptiveTX)
+	setUint32(&c.rxCoalesceUsecs, cfg.RXUsecs)
+	setUint32(&c.rxMaxCoalescedFrames, cfg.RXFrames)
+	setUint32(&c.txCoalesceUsecs, cfg.TXUsecs)
+	setUint32(&c.txMaxCoalescedFrames, cfg.TXFrames)
+	if !changed {
+		return nil
+	}
+
+	logrus.Infof("set coalesce parameters of %s", nic)
+	c.cmd = unix.ETHTOOL_SCOALESCE
+	if err := ethtoolIoctl(nic, unsafe.Pointer(&c)); err != nil { // #nosec G103
+		return fmt.Errorf("set coalesce parameters of %s failed, error: %w", nic, err)
+	}
+
+	return nil
+}
+
+// LinkSpeed returns the negotiated speed and the highest supported speed of the NIC in Mb/s.
+// The speed is 0 if it is unknown, e.g. the NIC has no carrier, and so is the highest supported speed if the
+// driver does not report the supported link modes. Link modes faster than 56G are not in the legacy link mode mask.
+func LinkSpeed(nic string) (speed, maxSpeed uint32, err error) {
+	c := ethtoolCmd{cmd: unix.ETHTOOL_GSET}
+	if err := ethtoolIoctl(nic, unsafe.Pointer(&c)); err != nil { // #nosec G103
+		return 0, 0, fmt.Errorf("get link settings of %s failed, error: %w", nic, err)
+	}
+
+	speed = uint32(c.speedHi)<<16 | uint32(c.speed)
+	// SPEED_UNKNOWN
+	if speed == 0xffff || speed == 0xffffffff {
+		speed = 0
+	}
+
+	return speed, maxSupportedSpeed(c.supported), nil
+}
+
+func maxSupportedSpeed(supported uint32) uint32 {
+	var maxSpeed uint32
+	for _, s := range supportedSpeeds {
+		if supported&s.bit != 0 && s.speed > maxSpeed {
+			maxSpeed = s.speed
+		}
+	}
+
+	return maxSpeed
+}
@@ -0,0 +1,68 @@
+package iface
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_maxSupportedSpeed(t *testing.T) {
+	tests := []struct {
+		name      string
+		supported uint32
+		maxSpeed  uint32
+	}{
+		{
+			name:      "no link modes reported",
+			supported: 0,
+			maxSpeed:  0,
+		},
+		{
+			name:      "1G copper NIC",
+			supported: 1<<0 | 1<<1 | 1<<2 | 1<<3 | 1<<5,
+			maxSpeed:  1000,
+		},
+		{
+			name:      "10G NIC also supporting 1G",
+			supported: 1<<5 | 1<<12,
+			maxSpeed:  10000,
+		},
+		{
+			name:      "2.5G NIC",
+			supported: 1<<5 | 1<<15,
+			maxSpeed:  2500,
+		},
+		{
+			name:      "40G NIC",
+			supported: 1<<17 | 1<<19 | 1<<23,
+			maxSpeed:  40000,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.maxSpeed, maxSupportedSpeed(tc.supported))
+		})
+	}
+}
+
+func Test_parseFeatures(t *testing.T) {
+	featureNames := []string{"rx-checksumming", "tx-checksumming", FeatureVxlanOffload}
+	names := make([]byte, len(featureNames)*ethGStringLen)
+	for i, name := range featureNames {
+		copy(names[i*ethGStringLen:], name)
+	}
+	blocks := make([]byte, featuresBlockLen)
+	// all available, rx-checksumming and the VXLAN offload active
+	binary.NativeEndian.PutUint32(blocks[0:], 0b111)
+	binary.NativeEndian.PutUint32(blocks[8:], 0b101)
+
+	assert.Equal(t, map[string]bool{
+		"rx-checksumming":   true,
+		"tx-checksumming":   false,
+		FeatureVxlanOffload: true,
+	}, parseFeatures(names, blocks, len(featureNames)))
+	// a count beyond the buffers is cut short
+	assert.Len(t, parseFeatures(names, blocks, 40), len(featureNames))
+}
@@ -0,0 +1,70 @@
+package iface
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// LearnedMAC is a MAC address a bridge has learned on one of its ports, the VID is 0 without VLAN filtering
+type LearnedMAC struct {
+	MAC       net.HardwareAddr
+	VID       uint16
+	PortIndex int
+}
+
+// ListLearnedMACs returns the MAC addresses the bridges have learned on their ports
+// Equivalent to: `bridge fdb show br BRIDGE dynamic`
+func ListLearnedMACs(bridgeIndexes ...int) ([]LearnedMAC, error) {
+	neighs, err := network.Handle().NeighList(0, unix.AF_BRIDGE)
+	if err != nil {
+		return nil, fmt.Errorf("list FDB entries failed, error: %w", network.Classify(err))
+	}
+
+	bridges := make(map[int]bool, len(bridgeIndexes))
+	for _, index := range bridgeIndexes {
+		bridges[index] = true
+	}
+
+	return learnedMACs(neighs, bridges), nil
+}
+
+// learnedMACs returns the unicast entries of the bridges other than the local and the static ones
+func learnedMACs(neighs []netlink.Neigh, bridges map[int]bool) []LearnedMAC {
+	macs := make([]LearnedMAC, 0)
+	for _, n := range neighs {
+		if !bridges[n.MasterIndex] || n.State&(netlink.NUD_PERMANENT|netlink.NUD_NOARP) != 0 {
+			continue
+		}
+		if len(n.HardwareAddr) != 6 || n.HardwareAddr[0]&0x01 != 0 {
+			continue
+		}
+		macs = append(macs, LearnedMAC{
+			MAC:       n.HardwareAddr,
+			VID:       uint16(n.Vlan), //nolint:gosec
+			PortIndex: n.LinkIndex,
+		})
+	}
+
+	return macs
+}
+
+// EgressVlans returns the VIDs the bridge port carries, true if the VID leaves the port untagged
+// Equivalent to: `bridge vlan show dev DEV`
+func (l *Link) EgressVlans() (map[uint16]bool, error) {
+	vlans, err := network.Handle().BridgeVlanList()
+	if err != nil {
+		return nil, fmt.Errorf("list bridge vlans failed, error: %w", err)
+	}
+
+	egress := make(map[uint16]bool)
+	for _, info := range vlans[int32(l.Attrs().Index)] { //nolint:gosec
+		egress[info.Vid] = info.EngressUntag()
+	}
+
+	return egress, nil
+}
@@ -0,0 +1,28 @@
+package iface
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+func Test_learnedMACs(t *testing.T) {
+	vmMAC, _ := net.ParseMAC("52:54:00:12:34:56")
+	otherMAC, _ := net.ParseMAC("52:54:00:12:34:57")
+	bridgeMAC, _ := net.ParseMAC("52:54:00:00:00:01")
+	multicastMAC, _ := net.ParseMAC("01:00:5e:00:00:01")
+	neighs := []netlink.Neigh{
+		{MasterIndex: 10, LinkIndex: 11, Vlan: 100, State: netlink.NUD_REACHABLE, HardwareAddr: vmMAC},
+		// the entry of a bridge not asked for
+		{MasterIndex: 20, LinkIndex: 21, Vlan: 100, State: netlink.NUD_REACHABLE, HardwareAddr: otherMAC},
+		// the local and the static entries
+		{MasterIndex: 10, LinkIndex: 12, Vlan: 1, State: netlink.NUD_PERMANENT, HardwareAddr: bridgeMAC},
+		{MasterIndex: 10, LinkIndex: 12, Vlan: 1, State: netlink.NUD_NOARP, HardwareAddr: otherMAC},
+		{MasterIndex: 10, LinkIndex: 12, State: netlink.NUD_STALE, HardwareAddr: multicastMAC},
+	}
+
+	assert.Equal(t, []LearnedMAC{{MAC: vmMAC, VID: 100, PortIndex: 11}}, learnedMACs(neighs, map[int]bool{10: true}))
+	assert.Empty(t, learnedMACs(neighs, map[int]bool{}))
+}
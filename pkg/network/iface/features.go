@@ -0,0 +1,79 @@
+package iface
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ETH_SS_FEATURES in include/uapi/linux/ethtool.h
+	ethSSFeatures = 4
+	// ETH_GSTRING_LEN in include/uapi/linux/ethtool.h
+	ethGStringLen = 32
+	// the size of struct ethtool_get_features_block
+	featuresBlockLen = 16
+
+	// FeatureVxlanOffload is the offload of the segmentation of the UDP tunnels like VXLAN
+	FeatureVxlanOffload = "tx-udp_tnl-segmentation"
+)
+
+// Features is equivalent to `ethtool -k <nic>` and returns the active offload features of the NIC by name
+func Features(nic string) (map[string]bool, error) {
+	// struct ethtool_sset_info with a single data entry
+	info := make([]byte, 20)
+	binary.NativeEndian.PutUint32(info[0:], unix.ETHTOOL_GSSET_INFO)
+	binary.NativeEndian.PutUint64(info[8:], 1<<ethSSFeatures)
+	if err := ethtoolIoctl(nic, unsafe.Pointer(&info[0])); err != nil { // #nosec G103
+		return nil, fmt.Errorf("get feature count of %s failed, error: %w", nic, err)
+	}
+	if binary.NativeEndian.Uint64(info[8:])&(1<<ethSSFeatures) == 0 {
+		return map[string]bool{}, nil
+	}
+	count := int(binary.NativeEndian.Uint32(info[16:]))
+
+	// struct ethtool_gstrings
+	names := make([]byte, 12+count*ethGStringLen)
+	binary.NativeEndian.PutUint32(names[0:], unix.ETHTOOL_GSTRINGS)
+	binary.NativeEndian.PutUint32(names[4:], ethSSFeatures)
+	binary.NativeEndian.PutUint32(names[8:], uint32(count)) // #nosec G115
+
+	if err := ethtoolIoctl(nic, unsafe.Pointer(&names[0])); err != nil { // #nosec G103
+		return nil, fmt.Errorf("get feature names of %s failed, error: %w", nic, err)
+	}
+
+	// struct ethtool_gfeatures
+	size := (count + 31) / 32
+	blocks := make([]byte, 8+size*featuresBlockLen)
+	binary.NativeEndian.PutUint32(blocks[0:], unix.ETHTOOL_GFEATURES)
+	binary.NativeEndian.PutUint32(blocks[4:], uint32(size)) // #nosec G115
+
+	if err := ethtoolIoctl(nic, unsafe.Pointer(&blocks[0])); err != nil { // #nosec G103
+		return nil, fmt.Errorf("get features of %s failed, error: %w", nic, err)
+	}
+
+	return parseFeatures(names[12:], blocks[8:], count), nil
+}
+
+// parseFeatures maps the names of the features to whether they're active, the names are NUL padded strings of
+// ETH_GSTRING_LEN bytes and the blocks hold the bits of 32 features each
+func parseFeatures(names, blocks []byte, count int) map[string]bool {
+	features := make(map[string]bool, count)
+	for i := 0; i < count; i++ {
+		if (i+1)*ethGStringLen > len(names) || (i/32+1)*featuresBlockLen > len(blocks) {
+			break
+		}
+		name := string(bytes.TrimRight(names[i*ethGStringLen:(i+1)*ethGStringLen], "\x00"))
+		if name == "" {
+			continue
+		}
+		// the active bits follow the available and requested ones in the block
+		active := binary.NativeEndian.Uint32(blocks[(i/32)*featuresBlockLen+8:])
+		features[name] = active&(1<<(i%32)) != 0
+	}
+
+	return features
+}
@@ -0,0 +1,80 @@
+package iface
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// the bits of the actor operational port state in IEEE 802.1AX
+const (
+	lacpStateSync         = 0x08
+	lacpStateCollecting   = 0x10
+	lacpStateDistributing = 0x20
+)
+
+// LACPPort is the negotiation of a slave of the 802.3ad bond
+type LACPPort struct {
+	Name         string
+	AggregatorID int
+	// the slave is in the active aggregator and the partner collects and distributes on it
+	Bundled bool
+}
+
+// LACPState is the negotiation of the 802.3ad bond with its partner, the switch
+type LACPState struct {
+	AggregatorID int
+	NumPorts     int
+	PartnerMAC   net.HardwareAddr
+	Ports        []LACPPort
+}
+
+// BondLACPState is equivalent to reading the 802.3ad section of /proc/net/bonding/<bond>, it returns nil if the bond
+// is not in the mode 802.3ad
+func BondLACPState(name string) (*LACPState, error) {
+	l, err := network.Handle().LinkByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("get bond %s failed, error: %w", name, network.Classify(err))
+	}
+	bond, ok := l.(*netlink.Bond)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a bond", name)
+	}
+	if bond.Mode != netlink.BOND_MODE_802_3AD {
+		return nil, nil
+	}
+
+	links, err := getSlaves(bond.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &LACPState{Ports: make([]LACPPort, 0, len(links))}
+	// the bond has no active aggregator until a slave has carrier
+	if info := bond.AdInfo; info != nil {
+		state.AggregatorID = info.AggregatorId
+		state.NumPorts = info.NumPorts
+		state.PartnerMAC = info.PartnerMac
+	}
+	for _, s := range links {
+		port := LACPPort{Name: s.Attrs().Name}
+		if slave, ok := s.Attrs().Slave.(*netlink.BondSlave); ok {
+			port.AggregatorID = int(slave.AggregatorId)
+			port.Bundled = lacpBundled(slave, state.AggregatorID)
+		}
+		state.Ports = append(state.Ports, port)
+	}
+
+	return state, nil
+}
+
+// lacpBundled tells whether the slave carries the traffic of the bond, a slave in another aggregator or not yet in sync
+// with the partner is left out by the kernel
+func lacpBundled(slave *netlink.BondSlave, aggregatorID int) bool {
+	const bundled = lacpStateSync | lacpStateCollecting | lacpStateDistributing
+
+	return aggregatorID != 0 && int(slave.AggregatorId) == aggregatorID && slave.AdActorOperPortState&bundled == bundled
+}
@@ -0,0 +1,20 @@
+package iface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+func Test_lacpBundled(t *testing.T) {
+	const negotiated = lacpStateSync | lacpStateCollecting | lacpStateDistributing
+
+	assert.True(t, lacpBundled(&netlink.BondSlave{AggregatorId: 1, AdActorOperPortState: negotiated | 0x05}, 1))
+	// the slave is in another aggregator, e.g. cabled to another switch
+	assert.False(t, lacpBundled(&netlink.BondSlave{AggregatorId: 2, AdActorOperPortState: negotiated}, 1))
+	// the partner doesn't distribute on the slave yet
+	assert.False(t, lacpBundled(&netlink.BondSlave{AggregatorId: 1, AdActorOperPortState: lacpStateSync}, 1))
+	// the bond has no active aggregator
+	assert.False(t, lacpBundled(&netlink.BondSlave{AdActorOperPortState: negotiated}, 0))
+}
@@ -5,11 +5,11 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/containernetworking/plugins/pkg/netlinksafe"
 	"github.com/coreos/go-iptables/iptables"
 	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
 
+	"github.com/harvester/harvester-network-controller/pkg/network"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
 
@@ -17,6 +17,9 @@ const (
 	TypeLoopback = "loopback"
 	TypeDevice   = "device"
 	TypeBond     = "bond"
+	TypeVlan     = "vlan"
+	TypeBridge   = "bridge"
+	TypeVxlan    = "vxlan"
 
 	ipv4Forward = "net/ipv4/ip_forward"
 
@@ -45,7 +48,7 @@ func (l *Link) AddBridgeVlan(vid uint16) error {
 		return nil
 	}
 
-	if err := netlink.BridgeVlanAdd(l, vid, false, false, false, true); err != nil {
+	if err := bridgeVlanAdd(l, vid, false, false, false, true); err != nil {
 		return fmt.Errorf("add iface vlan failed, error: %v, link: %s, vid: %d", err, l.Attrs().Name, vid)
 	}
 
@@ -59,7 +62,7 @@ func (l *Link) DelBridgeVlan(vid uint16) error {
 		return nil
 	}
 
-	if err := netlink.BridgeVlanDel(l, vid, false, false, false, true); err != nil {
+	if err := bridgeVlanDel(l, vid, false, false, false, true); err != nil {
 		return fmt.Errorf("delete iface vlan failed, error: %v, link: %s, vid: %d", err, l.Attrs().Name, vid)
 	}
 
@@ -74,7 +77,7 @@ func (l *Link) AddBridgeVlanSelf(vid uint16) error {
 		return nil
 	}
 
-	if err := netlink.BridgeVlanAdd(l, vid, false, false, true, false); err != nil {
+	if err := bridgeVlanAdd(l, vid, false, false, true, false); err != nil {
 		return fmt.Errorf("add iface vlan failed, error: %v, link: %s, vid: %d", err, l.Attrs().Name, vid)
 	}
 
@@ -88,7 +91,7 @@ func (l *Link) DelBridgeVlanSelf(vid uint16) error {
 		return nil
 	}
 
-	if err := netlink.BridgeVlanDel(l, vid, false, false, true, false); err != nil {
+	if err := bridgeVlanDel(l, vid, false, false, true, false); err != nil {
 		return fmt.Errorf("delete iface vlan failed, error: %v, link: %s, vid: %d", err, l.Attrs().Name, vid)
 	}
 
@@ -96,7 +99,7 @@ func (l *Link) DelBridgeVlanSelf(vid uint16) error {
 }
 
 func (l *Link) ListBridgeVlan() ([]uint16, error) {
-	m, err := netlink.BridgeVlanList()
+	m, err := network.Handle().BridgeVlanList()
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +118,7 @@ func (l *Link) ListBridgeVlan() ([]uint16, error) {
 }
 
 func (l *Link) ToVlanIDSet() (*utils.VlanIDSet, error) {
-	m, err := netlink.BridgeVlanList()
+	m, err := network.Handle().BridgeVlanList()
 	if err != nil {
 		return nil, err
 	}
@@ -138,13 +141,13 @@ func (l *Link) ToVlanIDSet() (*utils.VlanIDSet, error) {
 
 // clearMacvlan to delete all the macvlan interfaces whose parent index equals l.Index()
 func (l *Link) clearMacVlan() error {
-	links, err := netlinksafe.LinkList()
+	links, err := network.Handle().LinkList()
 	if err != nil {
 		return err
 	}
 	for _, link := range links {
 		if link.Attrs().ParentIndex == l.Attrs().Index && link.Type() == "macvlan" {
-			if err := netlink.LinkDel(link); err != nil {
+			if err := linkDel(link); err != nil {
 				return err
 			}
 			logrus.Infof("delete macvlan interface %s", link.Attrs().Name)
@@ -158,11 +161,14 @@ func (l *Link) SetMaster(br *Bridge) error {
 	if l.Attrs().MasterIndex == br.Index {
 		return nil
 	}
+	if err := network.CheckExcluded(l.Attrs().Name); err != nil {
+		return fmt.Errorf("%s set %s as master failed, error: %w", l.Attrs().Name, br.Name, err)
+	}
 
 	if err := l.clearMacVlan(); err != nil {
 		return err
 	}
-	if err := netlink.LinkSetMaster(l, br); err != nil {
+	if err := linkSetMaster(l, br); err != nil {
 		return fmt.Errorf("%s set %s as master failed, error: %w", l.Attrs().Name, br.Name, err)
 	}
 
@@ -176,7 +182,7 @@ func (l *Link) SetNoMaster() error {
 
 	logrus.Infof("%s set no master", l.Attrs().Name)
 
-	return netlink.LinkSetNoMaster(l)
+	return linkSetNoMaster(l)
 }
 
 func (l *Link) EnsureIptForward() error {
@@ -223,9 +229,9 @@ func (l *Link) DeleteIptForward() error {
 }
 
 func (l *Link) Fetch() error {
-	link, err := netlink.LinkByName(l.Attrs().Name)
+	link, err := network.Handle().LinkByName(l.Attrs().Name)
 	if err != nil {
-		return fmt.Errorf("refresh link %s failed, error: %w", l.Attrs().Name, err)
+		return fmt.Errorf("refresh link %s failed, error: %w", l.Attrs().Name, network.Classify(err))
 	}
 
 	l.Link = link
@@ -233,8 +239,29 @@ func (l *Link) Fetch() error {
 	return nil
 }
 
+// EnsurePromisc turns the promiscuous mode of the link on or off, and returns whether it's changed
+func EnsurePromisc(l netlink.Link, on bool) (bool, error) {
+	if (l.Attrs().Promisc != 0) == on {
+		return false, nil
+	}
+	if err := linkSetPromisc(l, on); err != nil {
+		return false, err
+	}
+	if on {
+		l.Attrs().Promisc = 1
+	} else {
+		l.Attrs().Promisc = 0
+	}
+	return true, nil
+}
+
+// HasCarrier returns true if the link is operationally up, a bond has carrier if any of its slaves has carrier
+func (l *Link) HasCarrier() bool {
+	return l.Attrs().OperState == netlink.OperUp
+}
+
 func ListLinks(typeSelector map[string]bool) ([]*Link, error) {
-	links, err := netlinksafe.LinkList()
+	links, err := network.Handle().LinkList()
 	if err != nil {
 		return nil, err
 	}
@@ -255,11 +282,47 @@ func ListLinks(typeSelector map[string]bool) ([]*Link, error) {
 }
 
 func (l *Link) Remove() error {
+	if err := network.CheckExcluded(l.Attrs().Name); err != nil {
+		return fmt.Errorf("delete link %s failed, error: %w", l.Attrs().Name, err)
+	}
+
 	if l.Type() == TypeBond {
 		return NewBond(netlink.NewLinkBond(*l.Attrs()), nil).remove()
 	}
 
-	return netlink.LinkDel(l)
+	// the uplink is a VLAN sub-interface of a shared bond, remove the bond only if it's not referenced any more
+	if l.Type() == TypeVlan && l.Attrs().ParentIndex != 0 {
+		if err := linkDel(l); err != nil {
+			return err
+		}
+		return RemoveSharedBondIfUnused(l.Attrs().ParentIndex)
+	}
+
+	return linkDel(l)
+}
+
+// EnsureVlanSubInterface creates the VLAN sub-interface <link>.<vid> if not existing and returns it
+func (l *Link) EnsureVlanSubInterface(vid uint16) (*Link, error) {
+	if err := l.CreateVlanSubInterface(vid); err != nil {
+		return nil, err
+	}
+
+	name := utils.GetClusterNetworkBrVlanDevice(l.Attrs().Name, vid)
+	sub, err := network.Handle().LinkByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("get vlan subinterface %s failed, error: %w", name, err)
+	}
+	// the VLAN sub-interface only takes the MTU of its parent when it's created
+	if mtu := l.Attrs().MTU; mtu != 0 && sub.Attrs().MTU != mtu {
+		if err := linkSetMTU(sub, mtu); err != nil {
+			return nil, fmt.Errorf("set MTU of %s to %d failed, error: %w", name, mtu, network.ClassifyMTU(err))
+		}
+		if sub, err = network.Handle().LinkByName(name); err != nil {
+			return nil, fmt.Errorf("get vlan subinterface %s failed, error: %w", name, err)
+		}
+	}
+
+	return NewLink(sub), nil
 }
 
 // for the convenience of unit test
@@ -291,7 +354,7 @@ func getManuallyConfiguredVlans(cnName string, links []netlink.Link) []uint16 {
 // user might configure sub vlan interface on a bridge directly, should always keep them
 func GetManuallyConfiguredVlans(cnName string) ([]uint16, error) {
 	// similar to `ip link`
-	links, err := netlinksafe.LinkList()
+	links, err := network.Handle().LinkList()
 	if err != nil {
 		return nil, err
 	}
@@ -300,7 +363,7 @@ func GetManuallyConfiguredVlans(cnName string) ([]uint16, error) {
 }
 
 func addrExists(link netlink.Link) (bool, error) {
-	addrs, err := netlink.AddrList(link, netlink.FAMILY_V4)
+	addrs, err := network.Handle().AddrList(link, netlink.FAMILY_V4)
 	if err != nil {
 		return false, err
 	}
@@ -312,7 +375,7 @@ func addrExists(link netlink.Link) (bool, error) {
 }
 
 func GetMgmtInterface() (vlanSubInterface string, err error) {
-	links, err := netlink.LinkList()
+	links, err := network.Handle().LinkList()
 	if err != nil {
 		return "", err
 	}
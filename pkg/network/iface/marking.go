@@ -0,0 +1,220 @@
+package iface
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+const (
+	// the preferences of the marking filters, they are lower than the one of the egress priority filter so that the
+	// packets pass them first, and they continue the classification after their actions
+	markingPrefIPv4 = managedFilterPref - 3
+	markingPrefIPv6 = managedFilterPref - 2
+	markingPrefPCP  = managedFilterPref - 1
+
+	tcaVlanActModify = 3
+	maxPCP           = 7
+	maxDSCP          = 63
+)
+
+// Marking is the QoS marking of the frames of a VLAN leaving the uplink, a nil field is left as it is
+type Marking struct {
+	// the 802.1p priority code point of the VLAN tag
+	PCP *uint8
+	// the differentiated services code point of the IPv4 and IPv6 headers
+	DSCP *uint8
+}
+
+// markingFilter identifies a marking filter, the handle is the VID in the upper 16 bits and the PCP or the DSCP in
+// the lower ones, so that a changed marking is a different filter
+type markingFilter struct {
+	pref   uint16
+	handle uint32
+}
+
+func (f markingFilter) vid() uint16 {
+	return uint16(f.handle >> 16) // #nosec G115 -- the upper 16 bits
+}
+
+func (f markingFilter) value() uint8 {
+	return uint8(f.handle & 0xff) // #nosec G115 -- the lowest 8 bits
+}
+
+// desiredMarkingFilters returns the filters marking the VLANs, sorted so that they are added in a stable order
+func desiredMarkingFilters(markings map[uint16]Marking) []markingFilter {
+	filters := make([]markingFilter, 0, len(markings)*3)
+	for vid, marking := range markings {
+		if vid == 0 {
+			continue
+		}
+		if marking.PCP != nil && *marking.PCP <= maxPCP {
+			filters = append(filters, markingFilter{pref: markingPrefPCP, handle: uint32(vid)<<16 | uint32(*marking.PCP)})
+		}
+		if marking.DSCP != nil && *marking.DSCP <= maxDSCP {
+			handle := uint32(vid)<<16 | uint32(*marking.DSCP)
+			filters = append(filters, markingFilter{pref: markingPrefIPv4, handle: handle},
+				markingFilter{pref: markingPrefIPv6, handle: handle})
+		}
+	}
+	sort.Slice(filters, func(i, j int) bool {
+		if filters[i].pref != filters[j].pref {
+			return filters[i].pref < filters[j].pref
+		}
+		return filters[i].handle < filters[j].handle
+	})
+
+	return filters
+}
+
+// EnsureEgressMarkings marks the frames of the VLANs leaving the link with flower filters on the clsact egress hook,
+// the PCP of the VLAN tag is rewritten by the vlan action and the DSCP of the IP header by the pedit action. The
+// filters of the VLANs without a marking are removed.
+func EnsureEgressMarkings(l netlink.Link, markings map[uint16]Marking) error {
+	name := l.Attrs().Name
+	desired := desiredMarkingFilters(markings)
+	existing, err := listMarkingFilters(l)
+	if err != nil {
+		return err
+	}
+	if len(desired) == 0 && len(existing) == 0 {
+		return nil
+	}
+
+	wanted := make(map[markingFilter]bool, len(desired))
+	for _, f := range desired {
+		wanted[f] = true
+	}
+	for key, f := range existing {
+		if wanted[key] {
+			continue
+		}
+		logrus.Infof("remove the marking filter %d:%x of %s", key.pref, key.handle, name)
+		if err := network.Handle().FilterDel(f); err != nil {
+			return fmt.Errorf("remove marking filter of VID %d of %s failed, error: %w", key.vid(), name, err)
+		}
+	}
+
+	if len(desired) > 0 {
+		if err := ensureClsact(l); err != nil {
+			return err
+		}
+	}
+	for _, f := range desired {
+		if _, ok := existing[f]; ok {
+			continue
+		}
+		logrus.Infof("add the marking filter %d:%x of %s", f.pref, f.handle, name)
+		if err := addMarkingFilter(l, f); err != nil {
+			return fmt.Errorf("add marking filter of VID %d of %s failed, error: %w", f.vid(), name, err)
+		}
+	}
+
+	return nil
+}
+
+func listMarkingFilters(l netlink.Link) (map[markingFilter]netlink.Filter, error) {
+	filters, err := network.Handle().FilterList(l, netlink.HANDLE_MIN_EGRESS)
+	if err != nil {
+		if isNoEgressHook(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list egress filters of %s failed, error: %w", l.Attrs().Name, err)
+	}
+
+	result := make(map[markingFilter]netlink.Filter)
+	for _, f := range filters {
+		switch pref := f.Attrs().Priority; pref {
+		case markingPrefIPv4, markingPrefIPv6, markingPrefPCP:
+			result[markingFilter{pref: pref, handle: f.Attrs().Handle}] = f
+		}
+	}
+
+	return result, nil
+}
+
+// addMarkingFilter sends the flower filter in a raw request, the netlink package can neither set the PCP with the
+// vlan action nor the DSCP with the pedit action
+func addMarkingFilter(l netlink.Link, f markingFilter) error {
+	req := network.NewRouteRequest(unix.RTM_NEWTFILTER, unix.NLM_F_CREATE|unix.NLM_F_EXCL|unix.NLM_F_ACK)
+	req.AddData(&nl.TcMsg{
+		Family:  nl.FAMILY_ALL,
+		Ifindex: int32(l.Attrs().Index), //nolint:gosec
+		Handle:  f.handle,
+		Parent:  netlink.HANDLE_MIN_EGRESS,
+		Info:    netlink.MakeHandle(f.pref, nl.Swap16(unix.ETH_P_8021Q)),
+	})
+	req.AddData(nl.NewRtAttr(nl.TCA_KIND, nl.ZeroTerminated("flower")))
+
+	options := nl.NewRtAttr(nl.TCA_OPTIONS, nil)
+	// the VLAN keys are only parsed with a VLAN ethernet type
+	options.AddRtAttr(nl.TCA_FLOWER_KEY_ETH_TYPE, htons(unix.ETH_P_8021Q))
+	options.AddRtAttr(nl.TCA_FLOWER_KEY_VLAN_ID, nl.Uint16Attr(f.vid()))
+	actions := options.AddRtAttr(nl.TCA_FLOWER_ACT, nil)
+	switch f.pref {
+	case markingPrefIPv4:
+		options.AddRtAttr(nl.TCA_FLOWER_KEY_VLAN_ETH_TYPE, htons(unix.ETH_P_IP))
+		addPeditAction(actions, 1, nl.TCA_PEDIT_KEY_EX_HDR_TYPE_IP4, dscpKeyIPv4(f.value()), netlink.TC_ACT_PIPE)
+		csum := nl.TcCsum{
+			TcGen:       nl.TcGen{Action: int32(netlink.TC_ACT_UNSPEC)},
+			UpdateFlags: uint32(netlink.TCA_CSUM_UPDATE_FLAG_IPV4HDR),
+		}
+		table := actions.AddRtAttr(2, nil)
+		table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("csum"))
+		table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil).AddRtAttr(nl.TCA_CSUM_PARMS, csum.Serialize())
+	case markingPrefIPv6:
+		options.AddRtAttr(nl.TCA_FLOWER_KEY_VLAN_ETH_TYPE, htons(unix.ETH_P_IPV6))
+		addPeditAction(actions, 1, nl.TCA_PEDIT_KEY_EX_HDR_TYPE_IP6, dscpKeyIPv6(f.value()), netlink.TC_ACT_UNSPEC)
+	case markingPrefPCP:
+		vlan := nl.TcVlan{TcGen: nl.TcGen{Action: int32(netlink.TC_ACT_UNSPEC)}, Action: tcaVlanActModify}
+		table := actions.AddRtAttr(1, nil)
+		table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("vlan"))
+		vlanOptions := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
+		vlanOptions.AddRtAttr(nl.TCA_VLAN_PARMS, vlan.Serialize())
+		vlanOptions.AddRtAttr(nl.TCA_VLAN_PUSH_VLAN_ID, nl.Uint16Attr(f.vid()))
+		vlanOptions.AddRtAttr(nl.TCA_VLAN_PUSH_VLAN_PRIORITY, nl.Uint8Attr(f.value()))
+	}
+	req.AddData(options)
+
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return network.Classify(err)
+}
+
+func addPeditAction(actions *nl.RtAttr, index int, header nl.PeditHeaderType, key nl.TcPeditKey, control netlink.TcAct) {
+	pedit := nl.TcPedit{
+		Sel:    nl.TcPeditSel{TcGen: nl.TcGen{Action: int32(control)}, NKeys: 1},
+		Keys:   []nl.TcPeditKey{key},
+		KeysEx: []nl.TcPeditKeyEx{{HeaderType: header, Cmd: nl.TCA_PEDIT_KEY_EX_CMD_SET}},
+	}
+	pedit.Encode(actions.AddRtAttr(index, nil))
+}
+
+// dscpKeyIPv4 rewrites the upper 6 bits of the second byte of the IPv4 header, the TOS, and keeps the ECN bits. The
+// kernel applies the key to the first 32 bits of the header in the host byte order as (word & mask) ^ val.
+func dscpKeyIPv4(dscp uint8) nl.TcPeditKey {
+	return nl.TcPeditKey{
+		Mask: nl.NativeEndian().Uint32([]byte{0xff, 0x03, 0xff, 0xff}),
+		Val:  nl.NativeEndian().Uint32([]byte{0x00, dscp << 2, 0x00, 0x00}),
+	}
+}
+
+// dscpKeyIPv6 rewrites the upper 6 bits of the traffic class of the IPv6 header, which spans the lower 4 bits of the
+// first byte and the upper 4 bits of the second one, and keeps the version, the ECN bits and the flow label
+func dscpKeyIPv6(dscp uint8) nl.TcPeditKey {
+	return nl.TcPeditKey{
+		Mask: nl.NativeEndian().Uint32([]byte{0xf0, 0x3f, 0xff, 0xff}),
+		Val:  nl.NativeEndian().Uint32([]byte{dscp >> 2, (dscp & 0x03) << 6, 0x00, 0x00}),
+	}
+}
+
+func htons(v uint16) []byte {
+	b := make([]byte, 2)
+	b[0], b[1] = byte(v>>8), byte(v)
+	return b
+}
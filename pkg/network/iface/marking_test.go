@@ -0,0 +1,47 @@
+package iface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink/nl"
+)
+
+func applyPeditKey(header []byte, key nl.TcPeditKey) []byte {
+	word := nl.NativeEndian().Uint32(header[:4])
+	result := make([]byte, len(header))
+	copy(result, header)
+	nl.NativeEndian().PutUint32(result[:4], (word&key.Mask)^key.Val)
+	return result
+}
+
+func TestDSCPKeys(t *testing.T) {
+	// version 4, IHL 5, ECN 1 and length 0x0054
+	ipv4 := []byte{0x45, 0x01, 0x00, 0x54}
+	assert.Equal(t, []byte{0x45, 46<<2 | 0x01, 0x00, 0x54}, applyPeditKey(ipv4, dscpKeyIPv4(46)))
+
+	// version 6, traffic class 0xff and flow label 0xabcde
+	ipv6 := []byte{0x6f, 0xfa, 0xbc, 0xde}
+	// DSCP 46 is 0b101110, the traffic class turns 0b10111011 with the ECN bits kept
+	assert.Equal(t, []byte{0x6b, 0xba, 0xbc, 0xde}, applyPeditKey(ipv6, dscpKeyIPv6(46)))
+	assert.Equal(t, []byte{0x60, 0x3a, 0xbc, 0xde}, applyPeditKey(ipv6, dscpKeyIPv6(0)))
+}
+
+func TestDesiredMarkingFilters(t *testing.T) {
+	pcp, dscp := uint8(5), uint8(46)
+	filters := desiredMarkingFilters(map[uint16]Marking{
+		0:   {PCP: &pcp},
+		100: {PCP: &pcp, DSCP: &dscp},
+		200: {PCP: &pcp},
+	})
+
+	assert.Equal(t, []markingFilter{
+		{pref: markingPrefIPv4, handle: 100<<16 | 46},
+		{pref: markingPrefIPv6, handle: 100<<16 | 46},
+		{pref: markingPrefPCP, handle: 100<<16 | 5},
+		{pref: markingPrefPCP, handle: 200<<16 | 5},
+	}, filters, "the untagged frames can't be marked")
+	assert.Equal(t, uint16(200), filters[3].vid())
+	assert.Equal(t, uint8(5), filters[3].value())
+	assert.Empty(t, desiredMarkingFilters(nil))
+}
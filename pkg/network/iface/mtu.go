@@ -0,0 +1,44 @@
+package iface
+
+import (
+	"fmt"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// MTURange returns the minimum and maximum MTU supported by the link. They're 0 if the kernel doesn't report them,
+// which is the case for the kernels before 4.19 and some virtual links.
+func MTURange(name string) (minMTU, maxMTU int, err error) {
+	req := nl.NewNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_ACK)
+	req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(name)))
+
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get link %s failed, error: %w", name, network.Classify(err))
+	}
+	if len(msgs) == 0 || len(msgs[0]) < unix.SizeofIfInfomsg {
+		return 0, 0, fmt.Errorf("get link %s failed, error: empty response", name)
+	}
+
+	attrs, err := nl.ParseRouteAttr(msgs[0][unix.SizeofIfInfomsg:])
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse attributes of link %s failed, error: %w", name, err)
+	}
+	for _, attr := range attrs {
+		if len(attr.Value) < 4 {
+			continue
+		}
+		switch attr.Attr.Type {
+		case unix.IFLA_MIN_MTU:
+			minMTU = int(nl.NativeEndian().Uint32(attr.Value[:4]))
+		case unix.IFLA_MAX_MTU:
+			maxMTU = int(nl.NativeEndian().Uint32(attr.Value[:4]))
+		}
+	}
+
+	return minMTU, maxMTU, nil
+}
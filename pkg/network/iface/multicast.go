@@ -0,0 +1,312 @@
+package iface
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/fault"
+)
+
+// the attributes of the bridge VLAN database in include/uapi/linux/if_bridge.h, which are not defined by the
+// netlink and unix packages
+const (
+	bridgeVlanDBDumpFlags     = 1
+	bridgeVlanDBGlobalOptions = 2
+	bridgeVlanDBDumpGlobal    = 1 << 1
+
+	bridgeVlanDBGoptsID            = 1
+	bridgeVlanDBGoptsRange         = 2
+	bridgeVlanDBGoptsMcastSnooping = 3
+	bridgeVlanDBGoptsMcastQuerier  = 15
+
+	// BR_BOOLOPT_MCAST_VLAN_SNOOPING
+	boolOptMcastVlanSnooping = 1
+
+	sizeofBrVlanMsg = 8
+)
+
+// MulticastConfig is the IGMP/MLD snooping and querier settings of the bridge
+type MulticastConfig struct {
+	Snooping bool
+	Querier  bool
+	// the settings of the VLANs which differ from the bridge, the per-VLAN multicast context of the bridge is enabled
+	// if there is any, and the VLANs omitted follow the settings of the bridge
+	Vlans map[uint16]VlanMulticastConfig
+}
+
+type VlanMulticastConfig struct {
+	Snooping bool
+	Querier  bool
+}
+
+// DefaultMulticastConfig is the kernel default, i.e. snooping without querier
+var DefaultMulticastConfig = MulticastConfig{Snooping: true}
+
+// MulticastState is the multicast settings in effect on the bridge
+type MulticastState struct {
+	Snooping     bool
+	Querier      bool
+	VlanSnooping bool
+	// only reported if the per-VLAN multicast context is enabled
+	Vlans map[uint16]VlanMulticastConfig
+}
+
+// EnsureMulticast applies the multicast settings to the bridge and its VLANs
+func (br *Bridge) EnsureMulticast(cfg *MulticastConfig, vids []uint16) error {
+	if cfg == nil {
+		cfg = &DefaultMulticastConfig
+	}
+	state, err := br.Multicast()
+	if err != nil {
+		return err
+	}
+
+	vlanSnooping := len(cfg.Vlans) > 0
+	if state.Snooping != cfg.Snooping || state.Querier != cfg.Querier || state.VlanSnooping != vlanSnooping {
+		if err := setBridgeMulticast(br.Attrs().Index, cfg.Snooping, cfg.Querier, vlanSnooping); err != nil {
+			return fmt.Errorf("set multicast options of bridge %s failed, error: %w", br.Name, network.Classify(err))
+		}
+	}
+	if !vlanSnooping {
+		return nil
+	}
+
+	// the VLANs have their own multicast context once it's enabled, the VLANs without settings follow the bridge
+	if !state.VlanSnooping {
+		if state, err = br.Multicast(); err != nil {
+			return err
+		}
+	}
+	for _, vid := range vids {
+		desired, ok := cfg.Vlans[vid]
+		if !ok {
+			desired = VlanMulticastConfig{Snooping: cfg.Snooping, Querier: cfg.Querier}
+		}
+		if current, ok := state.Vlans[vid]; ok && current == desired {
+			continue
+		}
+		if err := setVlanMulticast(br.Attrs().Index, vid, desired); err != nil {
+			return fmt.Errorf("set multicast options of VLAN %d on bridge %s failed, error: %w", vid, br.Name,
+				network.Classify(err))
+		}
+	}
+
+	return nil
+}
+
+// Multicast returns the multicast settings in effect on the bridge
+func (br *Bridge) Multicast() (*MulticastState, error) {
+	req := nl.NewNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_ACK)
+	req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(br.Name)))
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+	if err != nil {
+		return nil, fmt.Errorf("get bridge %s failed, error: %w", br.Name, network.Classify(err))
+	}
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("get bridge %s failed, error: empty response", br.Name)
+	}
+	state, err := parseBridgeMulticast(msgs[0])
+	if err != nil {
+		return nil, fmt.Errorf("parse bridge %s failed, error: %w", br.Name, err)
+	}
+	if !state.VlanSnooping {
+		return state, nil
+	}
+
+	req = nl.NewNetlinkRequest(unix.RTM_GETVLAN, unix.NLM_F_DUMP)
+	req.AddData(&brVlanMsg{ifindex: uint32(br.Attrs().Index)}) //nolint:gosec
+	req.AddData(nl.NewRtAttr(bridgeVlanDBDumpFlags, nl.Uint32Attr(bridgeVlanDBDumpGlobal)))
+	msgs, err = req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWVLAN)
+	if err != nil {
+		return nil, fmt.Errorf("dump VLAN options of bridge %s failed, error: %w", br.Name, network.Classify(err))
+	}
+	if state.Vlans, err = parseVlanMulticast(msgs); err != nil {
+		return nil, fmt.Errorf("parse VLAN options of bridge %s failed, error: %w", br.Name, err)
+	}
+
+	return state, nil
+}
+
+// SortedVIDs returns the VIDs having the per-VLAN settings in order
+func (s *MulticastState) SortedVIDs() []uint16 {
+	vids := make([]uint16, 0, len(s.Vlans))
+	for vid := range s.Vlans {
+		vids = append(vids, vid)
+	}
+	sort.Slice(vids, func(i, j int) bool { return vids[i] < vids[j] })
+	return vids
+}
+
+func setBridgeMulticast(index int, snooping, querier, vlanSnooping bool) error {
+	metrics.CountNetlinkOp(metrics.OpLinkSet)
+	if err := fault.Inject("setBridgeMulticast", ""); err != nil {
+		return err
+	}
+
+	req := nl.NewNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = int32(index) //nolint:gosec
+	req.AddData(msg)
+	req.AddData(bridgeMulticastAttr(snooping, querier, vlanSnooping))
+
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
+func bridgeMulticastAttr(snooping, querier, vlanSnooping bool) *nl.RtAttr {
+	linkInfo := nl.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	linkInfo.AddRtAttr(nl.IFLA_INFO_KIND, nl.NonZeroTerminated(TypeBridge))
+	data := linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil)
+	data.AddRtAttr(unix.IFLA_BR_MCAST_SNOOPING, boolAttr(snooping))
+	data.AddRtAttr(unix.IFLA_BR_MCAST_QUERIER, boolAttr(querier))
+	// struct br_boolopt_multi
+	var optval uint32
+	if vlanSnooping {
+		optval = 1 << boolOptMcastVlanSnooping
+	}
+	boolopt := make([]byte, 8)
+	nl.NativeEndian().PutUint32(boolopt[0:4], optval)
+	nl.NativeEndian().PutUint32(boolopt[4:8], 1<<boolOptMcastVlanSnooping)
+	data.AddRtAttr(unix.IFLA_BR_MULTI_BOOLOPT, boolopt)
+
+	return linkInfo
+}
+
+func parseBridgeMulticast(msg []byte) (*MulticastState, error) {
+	if len(msg) < unix.SizeofIfInfomsg {
+		return nil, fmt.Errorf("message too short")
+	}
+	attrs, err := nl.ParseRouteAttr(msg[unix.SizeofIfInfomsg:])
+	if err != nil {
+		return nil, err
+	}
+
+	state := &MulticastState{}
+	for _, attr := range attrs {
+		if attr.Attr.Type&nl.NLA_TYPE_MASK != unix.IFLA_LINKINFO {
+			continue
+		}
+		infos, err := nl.ParseRouteAttr(attr.Value)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if info.Attr.Type&nl.NLA_TYPE_MASK != nl.IFLA_INFO_DATA {
+				continue
+			}
+			data, err := nl.ParseRouteAttr(info.Value)
+			if err != nil {
+				return nil, err
+			}
+			for _, d := range data {
+				switch d.Attr.Type & nl.NLA_TYPE_MASK {
+				case unix.IFLA_BR_MCAST_SNOOPING:
+					state.Snooping = len(d.Value) > 0 && d.Value[0] == 1
+				case unix.IFLA_BR_MCAST_QUERIER:
+					state.Querier = len(d.Value) > 0 && d.Value[0] == 1
+				case unix.IFLA_BR_MULTI_BOOLOPT:
+					if len(d.Value) >= 4 {
+						state.VlanSnooping = nl.NativeEndian().Uint32(d.Value[0:4])&(1<<boolOptMcastVlanSnooping) != 0
+					}
+				}
+			}
+		}
+	}
+
+	return state, nil
+}
+
+func setVlanMulticast(index int, vid uint16, cfg VlanMulticastConfig) error {
+	metrics.CountNetlinkOp(metrics.OpLinkSet)
+	if err := fault.Inject("setVlanMulticast", ""); err != nil {
+		return err
+	}
+
+	req := nl.NewNetlinkRequest(unix.RTM_NEWVLAN, unix.NLM_F_ACK)
+	req.AddData(&brVlanMsg{ifindex: uint32(index)}) //nolint:gosec
+	req.AddData(vlanMulticastAttr(vid, cfg))
+
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
+func vlanMulticastAttr(vid uint16, cfg VlanMulticastConfig) *nl.RtAttr {
+	opts := nl.NewRtAttr(unix.NLA_F_NESTED|bridgeVlanDBGlobalOptions, nil)
+	opts.AddRtAttr(bridgeVlanDBGoptsID, nl.Uint16Attr(vid))
+	opts.AddRtAttr(bridgeVlanDBGoptsMcastSnooping, boolAttr(cfg.Snooping))
+	opts.AddRtAttr(bridgeVlanDBGoptsMcastQuerier, boolAttr(cfg.Querier))
+	return opts
+}
+
+// parseVlanMulticast parses the global VLAN options dumped by the kernel, the VLANs with the same options may be
+// compressed into a range
+func parseVlanMulticast(msgs [][]byte) (map[uint16]VlanMulticastConfig, error) {
+	vlans := make(map[uint16]VlanMulticastConfig)
+	for _, msg := range msgs {
+		if len(msg) < sizeofBrVlanMsg {
+			return nil, fmt.Errorf("message too short")
+		}
+		attrs, err := nl.ParseRouteAttr(msg[sizeofBrVlanMsg:])
+		if err != nil {
+			return nil, err
+		}
+		for _, attr := range attrs {
+			if attr.Attr.Type&nl.NLA_TYPE_MASK != bridgeVlanDBGlobalOptions {
+				continue
+			}
+			opts, err := nl.ParseRouteAttr(attr.Value)
+			if err != nil {
+				return nil, err
+			}
+			var first, last uint16
+			cfg := VlanMulticastConfig{}
+			for _, o := range opts {
+				switch o.Attr.Type & nl.NLA_TYPE_MASK {
+				case bridgeVlanDBGoptsID:
+					first = nl.NativeEndian().Uint16(o.Value)
+				case bridgeVlanDBGoptsRange:
+					last = nl.NativeEndian().Uint16(o.Value)
+				case bridgeVlanDBGoptsMcastSnooping:
+					cfg.Snooping = len(o.Value) > 0 && o.Value[0] == 1
+				case bridgeVlanDBGoptsMcastQuerier:
+					cfg.Querier = len(o.Value) > 0 && o.Value[0] == 1
+				}
+			}
+			last = max(first, last)
+			for vid := uint32(first); vid <= uint32(last); vid++ {
+				vlans[uint16(vid)] = cfg //nolint:gosec
+			}
+		}
+	}
+
+	return vlans, nil
+}
+
+func boolAttr(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// brVlanMsg is struct br_vlan_msg in include/uapi/linux/if_bridge.h
+type brVlanMsg struct {
+	ifindex uint32
+}
+
+func (m *brVlanMsg) Len() int {
+	return sizeofBrVlanMsg
+}
+
+func (m *brVlanMsg) Serialize() []byte {
+	b := make([]byte, sizeofBrVlanMsg)
+	b[0] = unix.AF_BRIDGE
+	nl.NativeEndian().PutUint32(b[4:8], m.ifindex)
+	return b
+}
@@ -0,0 +1,52 @@
+package iface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+func TestParseBridgeMulticast(t *testing.T) {
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC).Serialize()
+	msg = append(msg, nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated("cn-br")).Serialize()...)
+	msg = append(msg, bridgeMulticastAttr(false, true, true).Serialize()...)
+
+	state, err := parseBridgeMulticast(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, &MulticastState{Snooping: false, Querier: true, VlanSnooping: true}, state)
+
+	msg = append(nl.NewIfInfomsg(unix.AF_UNSPEC).Serialize(), bridgeMulticastAttr(true, false, false).Serialize()...)
+	state, err = parseBridgeMulticast(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, &MulticastState{Snooping: true}, state)
+
+	_, err = parseBridgeMulticast(msg[:4])
+	assert.Error(t, err)
+}
+
+func TestParseVlanMulticast(t *testing.T) {
+	header := (&brVlanMsg{ifindex: 3}).Serialize()
+	assert.Equal(t, byte(unix.AF_BRIDGE), header[0])
+
+	single := append(append([]byte{}, header...),
+		vlanMulticastAttr(10, VlanMulticastConfig{Snooping: true, Querier: true}).Serialize()...)
+	// the kernel compresses the consecutive VLANs with the same options into a range
+	ranged := vlanMulticastAttr(20, VlanMulticastConfig{Snooping: true})
+	ranged.AddRtAttr(bridgeVlanDBGoptsRange, nl.Uint16Attr(22))
+	multi := append(append([]byte{}, header...), ranged.Serialize()...)
+
+	vlans, err := parseVlanMulticast([][]byte{single, multi})
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint16]VlanMulticastConfig{
+		10: {Snooping: true, Querier: true},
+		20: {Snooping: true},
+		21: {Snooping: true},
+		22: {Snooping: true},
+	}, vlans)
+	assert.Equal(t, []uint16{10, 20, 21, 22}, (&MulticastState{Vlans: vlans}).SortedVIDs())
+
+	_, err = parseVlanMulticast([][]byte{header[:4]})
+	assert.Error(t, err)
+}
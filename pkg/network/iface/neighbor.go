@@ -0,0 +1,71 @@
+package iface
+
+import (
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// NeighborConfig is the ARP/ND settings of the bridge ports
+type NeighborConfig struct {
+	ProxyARP bool
+	Suppress bool
+}
+
+// Ports returns the links attached to the bridge
+func (br *Bridge) Ports() ([]netlink.Link, error) {
+	links, err := network.Handle().LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("list links failed, error: %w", err)
+	}
+
+	ports := make([]netlink.Link, 0, len(links))
+	for _, l := range links {
+		if l.Attrs().MasterIndex == br.Index {
+			ports = append(ports, l)
+		}
+	}
+
+	return ports, nil
+}
+
+// EnsureNeighbor applies the ARP/ND settings to all ports of the bridge
+func (br *Bridge) EnsureNeighbor(cfg NeighborConfig) error {
+	ports, err := br.Ports()
+	if err != nil {
+		return err
+	}
+
+	for _, port := range ports {
+		if err := EnsurePortNeighbor(port, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EnsurePortNeighbor applies the ARP/ND settings to the bridge port
+func EnsurePortNeighbor(port netlink.Link, cfg NeighborConfig) error {
+	name := port.Attrs().Name
+	info, err := network.Handle().LinkGetProtinfo(port)
+	if err != nil {
+		return fmt.Errorf("get bridge port info of %s failed, error: %w", name, network.Classify(err))
+	}
+
+	if info.ProxyArp != cfg.ProxyARP {
+		if err := linkSetBrProxyArp(port, cfg.ProxyARP); err != nil {
+			return fmt.Errorf("set proxy_arp of %s to %t failed, error: %w", name, cfg.ProxyARP, network.Classify(err))
+		}
+	}
+	if info.NeighSuppress != cfg.Suppress {
+		if err := linkSetBrNeighSuppress(port, cfg.Suppress); err != nil {
+			return fmt.Errorf("set neigh_suppress of %s to %t failed, error: %w", name, cfg.Suppress,
+				network.Classify(err))
+		}
+	}
+
+	return nil
+}
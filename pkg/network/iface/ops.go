@@ -0,0 +1,158 @@
+package iface
+
+import (
+	"net"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/fault"
+)
+
+// The netlink operations changing the links are counted by the wrappers below, so that a reconcile re-programming
+// the network rather than doing nothing shows up in the metrics. They are sent over the shared netlink handle.
+
+// netlinkOp counts the netlink operation on the link and returns the fault injected into it, if any
+func netlinkOp(kind metrics.NetlinkOp, op string, l netlink.Link) error {
+	metrics.CountNetlinkOp(kind)
+	return fault.Inject(op, l.Attrs().Name)
+}
+
+func linkAdd(l netlink.Link) error {
+	if err := netlinkOp(metrics.OpLinkAdd, "linkAdd", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkAdd(l)
+}
+
+func linkDel(l netlink.Link) error {
+	if err := netlinkOp(metrics.OpLinkDel, "linkDel", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkDel(l)
+}
+
+func linkModify(l netlink.Link) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkModify", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkModify(l)
+}
+
+func linkSetUp(l netlink.Link) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkSetUp", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkSetUp(l)
+}
+
+func linkSetDown(l netlink.Link) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkSetDown", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkSetDown(l)
+}
+
+func linkSetMTU(l netlink.Link, mtu int) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkSetMTU", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkSetMTU(l, mtu)
+}
+
+func linkSetHardwareAddr(l netlink.Link, hwaddr net.HardwareAddr) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkSetHardwareAddr", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkSetHardwareAddr(l, hwaddr)
+}
+
+func linkSetTxQLen(l netlink.Link, qlen int) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkSetTxQLen", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkSetTxQLen(l, qlen)
+}
+
+func linkSetMaster(l, master netlink.Link) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkSetMaster", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkSetMaster(l, master)
+}
+
+func linkSetNoMaster(l netlink.Link) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkSetNoMaster", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkSetNoMaster(l)
+}
+
+// linkSetBondSlave enslaves the link which is down to the bond over the shared handle, the kernel adds the slave the
+// same way as the bonding ioctl netlink.LinkSetBondSlave uses
+func linkSetBondSlave(l netlink.Link, master *netlink.Bond) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkSetBondSlave", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkSetMaster(l, master)
+}
+
+func linkSetPromisc(l netlink.Link, on bool) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkSetPromisc", l); err != nil {
+		return err
+	}
+	if on {
+		return network.Handle().SetPromiscOn(l)
+	}
+	return network.Handle().SetPromiscOff(l)
+}
+
+func linkSetBrProxyArp(l netlink.Link, mode bool) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkSetBrProxyArp", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkSetBrProxyArp(l, mode)
+}
+
+func linkSetBrNeighSuppress(l netlink.Link, mode bool) error {
+	if err := netlinkOp(metrics.OpLinkSet, "linkSetBrNeighSuppress", l); err != nil {
+		return err
+	}
+	return network.Handle().LinkSetBrNeighSuppress(l, mode)
+}
+
+func bridgeVlanAdd(l netlink.Link, vid uint16, pvid, untagged, self, master bool) error {
+	if err := netlinkOp(metrics.OpBridgeVlanAdd, "bridgeVlanAdd", l); err != nil {
+		return err
+	}
+	return network.Handle().BridgeVlanAdd(l, vid, pvid, untagged, self, master)
+}
+
+func bridgeVlanDel(l netlink.Link, vid uint16, pvid, untagged, self, master bool) error {
+	if err := netlinkOp(metrics.OpBridgeVlanDel, "bridgeVlanDel", l); err != nil {
+		return err
+	}
+	return network.Handle().BridgeVlanDel(l, vid, pvid, untagged, self, master)
+}
+
+func fdbAppend(l netlink.Link, neigh *netlink.Neigh) error {
+	if err := netlinkOp(metrics.OpFdbAdd, "fdbAppend", l); err != nil {
+		return err
+	}
+	return network.Handle().NeighAppend(neigh)
+}
+
+func fdbDel(l netlink.Link, neigh *netlink.Neigh) error {
+	if err := netlinkOp(metrics.OpFdbDel, "fdbDel", l); err != nil {
+		return err
+	}
+	return network.Handle().NeighDel(neigh)
+}
+
+func bridgeSetVlanDefaultPVID(br *netlink.Bridge, pvid uint16) error {
+	if err := netlinkOp(metrics.OpLinkSet, "bridgeSetVlanDefaultPVID", br); err != nil {
+		return err
+	}
+	return network.Handle().BridgeSetVlanDefaultPVID(br, pvid)
+}
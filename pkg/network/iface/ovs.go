@@ -0,0 +1,27 @@
+package iface
+
+import (
+	"errors"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/ovs"
+)
+
+// IsOVSBridge returns true if the link is the internal interface of an OVS bridge, false if it's another link or not
+// found
+func IsOVSBridge(name string) (bool, error) {
+	l, err := network.Handle().LinkByName(name)
+	if err != nil {
+		if err = network.Classify(err); errors.Is(err, network.ErrLinkNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return l.Type() == ovs.LinkType, nil
+}
+
+// EnsureOVSBridgeUp sets the internal interface of the OVS bridge up, Open vSwitch creates it down
+func EnsureOVSBridgeUp(name string) error {
+	return setLinkUp(name)
+}
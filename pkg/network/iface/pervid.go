@@ -0,0 +1,132 @@
+package iface
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// EnsurePerVIDUplink creates the VLAN sub-interface of the VID on the link, which is the uplink of the bridge per VID
+// of the cluster network, and sets it up. The sub-interface left on another parent, e.g. the bond of fabric A after the
+// failover to fabric B, is replaced.
+func (l *Link) EnsurePerVIDUplink(cnName string, vid uint16) (*Link, error) {
+	return l.ensureSubInterface(utils.GeneratePerVIDUplinkName(cnName, vid), vid, netlink.VLAN_PROTOCOL_8021Q)
+}
+
+func (l *Link) ensureSubInterface(name string, vid uint16, protocol netlink.VlanProtocol) (*Link, error) {
+	existing, err := network.Handle().LinkByName(name)
+	if err == nil {
+		sub, ok := existing.(*netlink.Vlan)
+		if ok && sub.ParentIndex == l.Attrs().Index && sub.VlanId == int(vid) && sameVlanProtocol(sub.VlanProtocol, protocol) {
+			if sub.OperState != netlink.OperUp {
+				if err := linkSetUp(sub); err != nil {
+					return nil, fmt.Errorf("set %s up failed, error: %w", name, err)
+				}
+			}
+			return NewLink(sub), nil
+		}
+		logrus.Infof("replace the stale VLAN sub-interface %s", name)
+		if err := linkDel(existing); err != nil {
+			return nil, fmt.Errorf("delete stale VLAN sub-interface %s failed, error: %w", name, err)
+		}
+	} else if err = network.Classify(err); !errors.Is(err, network.ErrLinkNotFound) {
+		return nil, err
+	}
+
+	sub := &netlink.Vlan{
+		LinkAttrs:    netlink.LinkAttrs{Name: name, ParentIndex: l.Attrs().Index},
+		VlanId:       int(vid),
+		VlanProtocol: protocol,
+	}
+	if err := linkAdd(sub); err != nil && !errors.Is(err, unix.EEXIST) {
+		return nil, fmt.Errorf("add VLAN sub-interface %s failed, error: %w", name, err)
+	}
+	if err := linkSetUp(sub); err != nil {
+		return nil, fmt.Errorf("set %s up failed, error: %w", name, err)
+	}
+	created, err := network.Handle().LinkByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("get VLAN sub-interface %s failed, error: %w", name, network.Classify(err))
+	}
+
+	return NewLink(created), nil
+}
+
+// sameVlanProtocol treats the protocol unknown to an older kernel as 802.1Q, the protocol a VLAN sub-interface defaults
+// to
+func sameVlanProtocol(a, b netlink.VlanProtocol) bool {
+	if a == netlink.VLAN_PROTOCOL_UNKNOWN {
+		a = netlink.VLAN_PROTOCOL_8021Q
+	}
+	if b == netlink.VLAN_PROTOCOL_UNKNOWN {
+		b = netlink.VLAN_PROTOCOL_8021Q
+	}
+	return a == b
+}
+
+// RemovePerVID removes the bridge of the VID of the cluster network and its uplink. Unlike Remove, the parent of the
+// uplink is never removed with it, it's the uplink of the cluster network.
+func RemovePerVID(cnName string, vid uint16) error {
+	return removeLinks(utils.GeneratePerVIDUplinkName(cnName, vid), utils.GeneratePerVIDBridgeName(cnName, vid))
+}
+
+func removeLinks(names ...string) error {
+	for _, name := range names {
+		l, err := network.Handle().LinkByName(name)
+		if errors.Is(network.Classify(err), network.ErrLinkNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := linkDel(l); err != nil {
+			return fmt.Errorf("delete %s failed, error: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// PerVIDBridge is a bridge per VID of a cluster network together with its ports, i.e. its uplink and the VM ports
+type PerVIDBridge struct {
+	*Bridge
+	Members []netlink.Link
+}
+
+// ListPerVIDBridges returns the bridges per VID of the cluster network by their VID
+func ListPerVIDBridges(cnName string) (map[uint16]*PerVIDBridge, error) {
+	return listBridgesByVID(cnName, utils.ParsePerVIDBridgeName)
+}
+
+// listBridgesByVID returns the bridges of the cluster network by the VID the parse function tells from their names
+func listBridgesByVID(cnName string, parse func(string) (string, uint16, bool)) (map[uint16]*PerVIDBridge, error) {
+	links, err := network.Handle().LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("list links failed, error: %w", err)
+	}
+
+	bridges := make(map[uint16]*PerVIDBridge)
+	byIndex := make(map[int]*PerVIDBridge)
+	for _, l := range links {
+		br, ok := l.(*netlink.Bridge)
+		if !ok {
+			continue
+		}
+		if name, vid, ok := parse(br.Name); ok && name == cnName {
+			bridges[vid] = &PerVIDBridge{Bridge: &Bridge{br}}
+			byIndex[br.Index] = bridges[vid]
+		}
+	}
+	for _, l := range links {
+		if br, ok := byIndex[l.Attrs().MasterIndex]; ok && l.Attrs().MasterIndex != 0 {
+			br.Members = append(br.Members, l)
+		}
+	}
+
+	return bridges, nil
+}
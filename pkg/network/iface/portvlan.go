@@ -0,0 +1,32 @@
+package iface
+
+import (
+	"fmt"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// PortVlanUsers returns the names of the bridge ports per VID, except the port with the index excluded, e.g. the
+// uplink. The ports of the VMs keep their VIDs until the VMs are torn down, so they count the VMs using a VID.
+func (br *Bridge) PortVlanUsers(excluded int) (map[uint16][]string, error) {
+	ports, err := br.Ports()
+	if err != nil {
+		return nil, err
+	}
+	vlans, err := network.Handle().BridgeVlanList()
+	if err != nil {
+		return nil, fmt.Errorf("list bridge vlans failed, error: %w", err)
+	}
+
+	users := map[uint16][]string{}
+	for _, port := range ports {
+		if port.Attrs().Index == excluded {
+			continue
+		}
+		for _, info := range vlans[int32(port.Attrs().Index)] { //nolint:gosec
+			users[info.Vid] = append(users[info.Vid], port.Attrs().Name)
+		}
+	}
+
+	return users, nil
+}
@@ -0,0 +1,135 @@
+package iface
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+const (
+	// PriorityHigh is the skb priority of the prioritized traffic, TC_PRIO_INTERACTIVE, which the pfifo_fast and prio
+	// qdiscs put in the first band and the VLAN devices map to the PCP with their egress-qos-map
+	PriorityHigh = uint32(6)
+
+	// the preference of the egress filters managed by the controller, it tells them apart from the ones configured
+	// by the administrator
+	managedFilterPref = uint16(0x4e43)
+	qdiscClsact       = "clsact"
+)
+
+// EnsureEgressPriority sets the skb priority of every packet transmitted by the link with a matchall filter on the
+// clsact egress hook, so that the qdiscs of the physical NICs underneath prioritize them.
+// If priority is 0, the filter is removed and the priority the packets come with is kept.
+func EnsureEgressPriority(l netlink.Link, priority uint32) error {
+	name := l.Attrs().Name
+	filter, err := managedEgressFilter(l)
+	if err != nil {
+		return err
+	}
+
+	if priority == 0 {
+		if filter == nil {
+			return nil
+		}
+		logrus.Infof("remove the egress priority of %s", name)
+		if err := network.Handle().FilterDel(filter); err != nil {
+			return fmt.Errorf("remove egress priority filter of %s failed, error: %w", name, err)
+		}
+		return nil
+	}
+
+	if filter != nil && egressPriorityOf(filter) == priority {
+		return nil
+	}
+	if err := ensureClsact(l); err != nil {
+		return err
+	}
+
+	skbedit := netlink.NewSkbEditAction()
+	skbedit.Priority = &priority
+	desired := &netlink.MatchAll{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: l.Attrs().Index,
+			Parent:    netlink.HANDLE_MIN_EGRESS,
+			Priority:  managedFilterPref,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		Actions: []netlink.Action{skbedit},
+	}
+	logrus.Infof("set the egress priority of %s to %d", name, priority)
+	if err := network.Handle().FilterReplace(desired); err != nil {
+		return fmt.Errorf("set egress priority of %s to %d failed, error: %w", name, priority, err)
+	}
+
+	return nil
+}
+
+// ensureClsact adds the clsact qdisc to the link if it's missing, it's shared with the filters of the administrator
+// and never removed
+func ensureClsact(l netlink.Link) error {
+	qdiscs, err := network.Handle().QdiscList(l)
+	if err != nil {
+		return fmt.Errorf("list qdiscs of %s failed, error: %w", l.Attrs().Name, err)
+	}
+	for _, q := range qdiscs {
+		if q.Type() == qdiscClsact {
+			return nil
+		}
+	}
+
+	clsact := &netlink.GenericQdisc{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: l.Attrs().Index,
+			Handle:    netlink.MakeHandle(0xffff, 0),
+			Parent:    netlink.HANDLE_CLSACT,
+		},
+		QdiscType: qdiscClsact,
+	}
+	if err := network.Handle().QdiscAdd(clsact); err != nil && !errors.Is(err, unix.EEXIST) {
+		return fmt.Errorf("add clsact qdisc to %s failed, error: %w", l.Attrs().Name, err)
+	}
+
+	return nil
+}
+
+// managedEgressFilter returns the egress filter of the link managed by the controller, nil if there is none
+func managedEgressFilter(l netlink.Link) (netlink.Filter, error) {
+	filters, err := network.Handle().FilterList(l, netlink.HANDLE_MIN_EGRESS)
+	if err != nil {
+		if isNoEgressHook(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list egress filters of %s failed, error: %w", l.Attrs().Name, err)
+	}
+	for _, f := range filters {
+		if f.Attrs().Priority == managedFilterPref {
+			return f, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// isNoEgressHook returns true if the filters can't be listed as there is no egress hook without the clsact qdisc
+func isNoEgressHook(err error) bool {
+	return errors.Is(err, unix.EINVAL) || errors.Is(err, unix.ENOENT)
+}
+
+func egressPriorityOf(filter netlink.Filter) uint32 {
+	matchAll, ok := filter.(*netlink.MatchAll)
+	if !ok {
+		return 0
+	}
+	for _, action := range matchAll.Actions {
+		if skbedit, ok := action.(*netlink.SkbEditAction); ok && skbedit.Priority != nil {
+			return *skbedit.Priority
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,172 @@
+package iface
+
+import (
+	"fmt"
+
+	"github.com/achanda/go-sysctl"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+const (
+	QdiscFq      = "fq"
+	QdiscFqCodel = "fq_codel"
+	QdiscMq      = "mq"
+
+	// the major handle of the root qdisc managed by the controller, it tells the qdisc apart from the ones
+	// configured by the kernel or the administrator
+	managedQdiscMajor = uint16(0x4e43)
+
+	// the qdisc the kernel attaches to the TX queues, pfifo_fast if the sysctl can't be read, e.g. in a network
+	// namespace other than the initial one
+	defaultQdiscSysctl = "net.core.default_qdisc"
+	qdiscPfifoFast     = "pfifo_fast"
+)
+
+// the packet limits the kernel creates the fq and fq_codel qdiscs with
+var defaultQdiscLimits = map[string]uint32{QdiscFq: 10000, QdiscFqCodel: 10240}
+
+// QueueConfig is the desired queue discipline of a link
+type QueueConfig struct {
+	// root qdisc
+	Qdisc string
+	// qdisc attached to every TX queue when the root qdisc is mq
+	PerQueueQdisc string
+	// packet limit of the fq/fq_codel qdiscs
+	Limit uint32
+}
+
+// EnsureQdisc makes the root qdisc of the link as desired.
+// If cfg is nil, the root qdisc managed by the controller is removed and the kernel default is restored.
+// If the root qdisc is mq without a per-queue qdisc, the TX queues are reset to the kernel default.
+func (l *Link) EnsureQdisc(cfg *QueueConfig) error {
+	qdiscs, err := network.Handle().QdiscList(l)
+	if err != nil {
+		return fmt.Errorf("list qdiscs of %s failed, error: %w", l.Attrs().Name, err)
+	}
+
+	var root netlink.Qdisc
+	children := make(map[uint32]netlink.Qdisc)
+	for _, q := range qdiscs {
+		if q.Attrs().Parent == netlink.HANDLE_ROOT {
+			root = q
+		} else if major, _ := netlink.MajorMinor(q.Attrs().Parent); major == managedQdiscMajor {
+			children[q.Attrs().Parent] = q
+		}
+	}
+
+	if cfg == nil || cfg.Qdisc == "" {
+		if root != nil && isManagedQdisc(root) {
+			logrus.Infof("restore the default qdisc of %s", l.Attrs().Name)
+			return network.Handle().QdiscDel(root)
+		}
+		return nil
+	}
+
+	if root == nil || !isManagedQdisc(root) || !matchQdisc(root, cfg.Qdisc, cfg.Limit) {
+		desired := newQdisc(cfg.Qdisc, cfg.Limit, netlink.QdiscAttrs{
+			LinkIndex: l.Attrs().Index,
+			Handle:    netlink.MakeHandle(managedQdiscMajor, 0),
+			Parent:    netlink.HANDLE_ROOT,
+		})
+		logrus.Infof("replace the root qdisc of %s with %s", l.Attrs().Name, cfg.Qdisc)
+		if err := network.Handle().QdiscReplace(desired); err != nil {
+			return fmt.Errorf("replace root qdisc of %s with %s failed, error: %w", l.Attrs().Name, cfg.Qdisc, err)
+		}
+		children = nil
+	}
+
+	if cfg.Qdisc != QdiscMq {
+		return nil
+	}
+	if cfg.PerQueueQdisc == "" {
+		return l.resetQueueQdiscs(children)
+	}
+
+	// the mq qdisc has a class per TX queue, minor numbers start from 1
+	for i := 1; i <= l.Attrs().NumTxQueues; i++ {
+		parent := netlink.MakeHandle(managedQdiscMajor, uint16(i)) // #nosec G115 -- the number of TX queues is within uint16
+		if child, ok := children[parent]; ok && matchQdisc(child, cfg.PerQueueQdisc, cfg.Limit) {
+			continue
+		}
+		desired := newQdisc(cfg.PerQueueQdisc, cfg.Limit, netlink.QdiscAttrs{
+			LinkIndex: l.Attrs().Index,
+			Parent:    parent,
+		})
+		if err := network.Handle().QdiscReplace(desired); err != nil {
+			return fmt.Errorf("replace qdisc of %s queue %d with %s failed, error: %w", l.Attrs().Name, i, cfg.PerQueueQdisc, err)
+		}
+	}
+
+	return nil
+}
+
+// resetQueueQdiscs replaces the qdiscs of the TX queues of the managed mq qdisc with the kernel default, e.g. once the
+// per-queue qdisc is removed from the config while the root stays mq
+func (l *Link) resetQueueQdiscs(children map[uint32]netlink.Qdisc) error {
+	qdiscType := defaultQdisc()
+	limit := defaultQdiscLimits[qdiscType]
+	for parent, child := range children {
+		if matchQdisc(child, qdiscType, limit) {
+			continue
+		}
+		desired := newQdisc(qdiscType, limit, netlink.QdiscAttrs{
+			LinkIndex: l.Attrs().Index,
+			Parent:    parent,
+		})
+		_, queue := netlink.MajorMinor(parent)
+		logrus.Infof("restore the default qdisc %s of %s queue %d", qdiscType, l.Attrs().Name, queue)
+		if err := network.Handle().QdiscReplace(desired); err != nil {
+			return fmt.Errorf("replace qdisc of %s queue %d with %s failed, error: %w", l.Attrs().Name, queue, qdiscType, err)
+		}
+	}
+
+	return nil
+}
+
+func defaultQdisc() string {
+	if qdiscType, err := sysctl.Get(defaultQdiscSysctl); err == nil && qdiscType != "" {
+		return qdiscType
+	}
+	return qdiscPfifoFast
+}
+
+func isManagedQdisc(q netlink.Qdisc) bool {
+	major, _ := netlink.MajorMinor(q.Attrs().Handle)
+	return major == managedQdiscMajor
+}
+
+func matchQdisc(q netlink.Qdisc, qdiscType string, limit uint32) bool {
+	if q.Type() != qdiscType {
+		return false
+	}
+	if limit == 0 {
+		return true
+	}
+
+	switch q := q.(type) {
+	case *netlink.Fq:
+		return q.PacketLimit == limit
+	case *netlink.FqCodel:
+		return q.Limit == limit
+	default:
+		return true
+	}
+}
+
+func newQdisc(qdiscType string, limit uint32, attrs netlink.QdiscAttrs) netlink.Qdisc {
+	switch qdiscType {
+	case QdiscFq:
+		q := netlink.NewFq(attrs)
+		q.PacketLimit = limit
+		return q
+	case QdiscFqCodel:
+		q := netlink.NewFqCodel(attrs)
+		q.Limit = limit
+		return q
+	default:
+		return &netlink.GenericQdisc{QdiscAttrs: attrs, QdiscType: qdiscType}
+	}
+}
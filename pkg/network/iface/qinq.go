@@ -0,0 +1,26 @@
+package iface
+
+import (
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// EnsureQinQUplink creates the 802.1ad VLAN sub-interface of the S-VLAN on the link, which is the uplink of the QinQ
+// bridge of the cluster network, and sets it up. The frames of the C-VLANs tagged by the QinQ bridge leave the link
+// with the S-tag pushed over their C-tag.
+// Equivalent to: `ip link add link DEV name CN.qSVID type vlan proto 802.1ad id SVID`
+func (l *Link) EnsureQinQUplink(cnName string, svid uint16) (*Link, error) {
+	return l.ensureSubInterface(utils.GenerateQinQUplinkName(cnName, svid), svid, netlink.VLAN_PROTOCOL_8021AD)
+}
+
+// RemoveQinQ removes the QinQ bridge of the S-VLAN of the cluster network and its uplink, the parent of the uplink is
+// kept
+func RemoveQinQ(cnName string, svid uint16) error {
+	return removeLinks(utils.GenerateQinQUplinkName(cnName, svid), utils.GenerateQinQBridgeName(cnName, svid))
+}
+
+// ListQinQBridges returns the QinQ bridges of the cluster network by their S-VLAN
+func ListQinQBridges(cnName string) (map[uint16]*PerVIDBridge, error) {
+	return listBridgesByVID(cnName, utils.ParseQinQBridgeName)
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
 )
 
 const defaultIPv4Route = "0.0.0.0/0"
@@ -34,7 +36,7 @@ func String2Route(route string) (*netlink.Route, error) {
 
 func getGateway() (gateway net.IP, linkIndex int, err error) {
 	var routes []netlink.Route
-	routes, err = netlink.RouteList(nil, netlink.FAMILY_V4)
+	routes, err = network.Handle().RouteList(nil, netlink.FAMILY_V4)
 	if err != nil {
 		return
 	}
@@ -59,7 +61,7 @@ func EnsureRouteViaGateway(cidr string) error {
 
 	logrus.Infof("ensure route for cidr %s", cidr)
 
-	ip, network, err := net.ParseCIDR(cidr)
+	ip, dst, err := net.ParseCIDR(cidr)
 	if err != nil {
 		return err
 	}
@@ -69,7 +71,7 @@ func EnsureRouteViaGateway(cidr string) error {
 		return err
 	}
 
-	routes, err := netlink.RouteGet(ip)
+	routes, err := network.Handle().RouteGet(ip)
 	if err != nil {
 		return err
 	}
@@ -83,11 +85,11 @@ func EnsureRouteViaGateway(cidr string) error {
 
 	route := &netlink.Route{
 		LinkIndex: linkIndex,
-		Dst:       network,
+		Dst:       dst,
 		Gw:        gateway,
 		Protocol:  netlink.FAMILY_V4,
 	}
-	if err := netlink.RouteAdd(route); err != nil && err != syscall.EEXIST {
+	if err := network.Handle().RouteAdd(route); err != nil && err != syscall.EEXIST {
 		return err
 	} else if err == nil {
 		logrus.Infof("add route: %+v", route)
@@ -101,7 +103,7 @@ func DeleteRouteViaGateway(cidr string) error {
 		return nil
 	}
 
-	_, network, err := net.ParseCIDR(cidr)
+	_, dst, err := net.ParseCIDR(cidr)
 	if err != nil {
 		return err
 	}
@@ -111,11 +113,11 @@ func DeleteRouteViaGateway(cidr string) error {
 	}
 
 	route := &netlink.Route{
-		Dst:       network,
+		Dst:       dst,
 		Gw:        gateway,
 		LinkIndex: linkIndex,
 	}
-	if err := netlink.RouteDel(route); err != nil && !errors.Is(err, syscall.ESRCH) {
+	if err := network.Handle().RouteDel(route); err != nil && !errors.Is(err, syscall.ESRCH) {
 		return err
 	} else if err == nil {
 		logrus.Infof("delete route: %+v", route)
@@ -0,0 +1,48 @@
+package iface
+
+import (
+	"fmt"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// DefaultPVID returns the PVID the ports get when they are attached to the bridge, i.e. the VID of the frames of the
+// ports attached without any VID, e.g. the VMs of the untagged NADs
+func (br *Bridge) DefaultPVID() uint16 {
+	if br.VlanDefaultPVID == nil {
+		return defaultPVID
+	}
+	return *br.VlanDefaultPVID
+}
+
+// SetDefaultPVID changes the PVID the ports get when they are attached to the bridge. The kernel moves the bridge and
+// the ports still untagged on the previous default PVID to the new one, the ports of the VMs running already included.
+// Equivalent to: `ip link set dev BRIDGE type bridge vlan_default_pvid PVID`
+func (br *Bridge) SetDefaultPVID(pvid uint16) error {
+	if err := bridgeSetVlanDefaultPVID(br.Bridge, pvid); err != nil {
+		return fmt.Errorf("set default pvid of bridge %s to %d failed, error: %w", br.Name, pvid, err)
+	}
+
+	return br.Fetch()
+}
+
+// EnsurePortVID makes the port carry the VID as its untagged PVID if untagged is true, or tagged and not as its PVID
+// otherwise. The port is left alone if it carries the VID that way already.
+// Equivalent to: `bridge vlan add dev DEV vid VID [pvid untagged] master`
+func (l *Link) EnsurePortVID(vid uint16, untagged bool) error {
+	vlans, err := network.Handle().BridgeVlanList()
+	if err != nil {
+		return fmt.Errorf("list bridge vlans failed, error: %w", err)
+	}
+	for _, info := range vlans[int32(l.Attrs().Index)] { //nolint:gosec
+		if info.Vid == vid && info.PortVID() == untagged && info.EngressUntag() == untagged {
+			return nil
+		}
+	}
+
+	if err := bridgeVlanAdd(l, vid, untagged, untagged, false, true); err != nil {
+		return fmt.Errorf("set vid %d of port %s failed, error: %w", vid, l.Attrs().Name, err)
+	}
+
+	return nil
+}
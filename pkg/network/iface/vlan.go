@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/harvester/harvester-network-controller/pkg/network"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
@@ -12,7 +13,7 @@ import (
 func (l *Link) GetVlanSubInterfaceAndOperState(vid uint16) (netlink.Link, bool, error) {
 	linkName := utils.GetClusterNetworkBrVlanDevice(l.Attrs().Name, vid)
 	// Check if link exists
-	if vlanLink, err := netlink.LinkByName(linkName); err == nil {
+	if vlanLink, err := network.Handle().LinkByName(linkName); err == nil {
 		// Check if already UP
 		if vlanLink.Attrs().OperState == netlink.OperUp {
 			return vlanLink, true, nil
@@ -33,7 +34,7 @@ func (l *Link) CreateVlanSubInterface(vid uint16) error {
 		if operUp {
 			return nil
 		} else {
-			if err := netlink.LinkSetUp(link); err != nil {
+			if err := linkSetUp(link); err != nil {
 				return fmt.Errorf("set vlan subinterface up failed, error: %v, link: %s, vid: %d", err, link.Attrs().Name, vid)
 			}
 		}
@@ -49,11 +50,11 @@ func (l *Link) CreateVlanSubInterface(vid uint16) error {
 		VlanId: int(vid),
 	}
 
-	if err := netlink.LinkAdd(vlan); err != nil && !errors.Is(err, unix.EEXIST) {
+	if err := linkAdd(vlan); err != nil && !errors.Is(err, unix.EEXIST) {
 		return fmt.Errorf("add vlan subinterface failed, error: %v, link: %s, vid: %d", err, vlan.Name, vid)
 	}
 
-	if err := netlink.LinkSetUp(vlan); err != nil {
+	if err := linkSetUp(vlan); err != nil {
 		return fmt.Errorf("set vlan subinterface up failed, error: %v, link: %s, vid: %d", err, vlan.Attrs().Name, vid)
 	}
 
@@ -64,16 +65,16 @@ func (l *Link) CreateVlanSubInterface(vid uint16) error {
 // Equivalent to: `ip link del dev <vlansubintf-name>`
 func (l *Link) DelVlanSubInterface(vid uint16) error {
 	linkName := utils.GetClusterNetworkBrVlanDevice(l.Attrs().Name, vid)
-	vlanLink, err := netlink.LinkByName(linkName)
+	vlanLink, err := network.Handle().LinkByName(linkName)
 	if err != nil {
-		if errors.As(err, &netlink.LinkNotFoundError{}) {
+		if errors.Is(network.Classify(err), network.ErrLinkNotFound) {
 			return nil
 		} else {
 			return fmt.Errorf("finding the vlan subinterface failed, error: %v, link: %s, vid: %d", err, linkName, vid)
 		}
 	}
 
-	if err := netlink.LinkDel(vlanLink); err != nil {
+	if err := linkDel(vlanLink); err != nil {
 		return fmt.Errorf("delete vlan subinterface failed, error: %v, link: %s, vid: %d", err, linkName, vid)
 	}
 
@@ -82,7 +83,7 @@ func (l *Link) DelVlanSubInterface(vid uint16) error {
 
 func (l *Link) SetIPAddress(cidr string, vid uint16) error {
 	linkName := utils.GetClusterNetworkBrVlanDevice(l.Attrs().Name, vid)
-	vlanLink, err := netlink.LinkByName(linkName)
+	vlanLink, err := network.Handle().LinkByName(linkName)
 	if err != nil {
 		return fmt.Errorf("finding vlan subinterface failed, error: %v, link: %s, vid: %d", err, linkName, vid)
 	}
@@ -92,19 +93,19 @@ func (l *Link) SetIPAddress(cidr string, vid uint16) error {
 		return err
 	}
 
-	if err := netlink.AddrReplace(vlanLink, ipAddr); err != nil {
+	if err := network.Handle().AddrReplace(vlanLink, ipAddr); err != nil {
 		return fmt.Errorf("set ip address failed, error: %v, link: %s, ipNet: %v", err, l.Attrs().Name, ipAddr)
 	}
 
 	//delete other ip addresses (configured by previous DHCP lease or other static IPs)
-	addresses, err := netlink.AddrList(vlanLink, netlink.FAMILY_V4)
+	addresses, err := network.Handle().AddrList(vlanLink, netlink.FAMILY_V4)
 	if err != nil {
 		return err
 	}
 
 	for _, address := range addresses {
 		if !ipAddr.IP.Equal(address.IP) {
-			if err := netlink.AddrDel(vlanLink, &address); err != nil {
+			if err := network.Handle().AddrDel(vlanLink, &address); err != nil {
 				return fmt.Errorf("delete ip address failed, error: %v, link: %s, ipNet: %v", err, l.Attrs().Name, address)
 			}
 		}
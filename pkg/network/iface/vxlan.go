@@ -0,0 +1,142 @@
+package iface
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// zeroMAC is the destination of the FDB entries the broadcast, unknown unicast and multicast traffic is flooded by
+var zeroMAC = net.HardwareAddr{0, 0, 0, 0, 0, 0}
+
+// VtepConfig is the VXLAN tunnel endpoint of an overlay cluster network on the node
+type VtepConfig struct {
+	Name    string
+	VNI     int
+	Port    int
+	LocalIP net.IP
+	MTU     int
+}
+
+// EnsureVtep creates the VXLAN interface and brings it up, it's recreated if the VNI, the port or the local IP is
+// changed. The learning of the remote MACs stays on, only the flooding to the peers is programmed.
+// Equivalent to: `ip link add NAME type vxlan id VNI local IP dstport PORT` if not existing
+func EnsureVtep(cfg *VtepConfig) (*Link, error) {
+	l, err := network.Handle().LinkByName(cfg.Name)
+	if err == nil {
+		if vx, ok := l.(*netlink.Vxlan); ok && vx.VxlanId == cfg.VNI && vx.Port == cfg.Port && vx.SrcAddr.Equal(cfg.LocalIP) {
+			return ensureVtepUp(NewLink(l), cfg.MTU)
+		}
+		logrus.Infof("recreate the VTEP %s of VNI %d on %s", cfg.Name, cfg.VNI, cfg.LocalIP)
+		if err := linkDel(l); err != nil {
+			return nil, fmt.Errorf("delete VTEP %s failed, error: %w", cfg.Name, network.Classify(err))
+		}
+	} else if err = network.Classify(err); !errors.Is(err, network.ErrLinkNotFound) {
+		return nil, err
+	}
+
+	vx := &netlink.Vxlan{
+		LinkAttrs: netlink.LinkAttrs{Name: cfg.Name, MTU: cfg.MTU},
+		VxlanId:   cfg.VNI,
+		SrcAddr:   cfg.LocalIP,
+		Port:      cfg.Port,
+		Learning:  true,
+	}
+	if err := linkAdd(vx); err != nil {
+		return nil, fmt.Errorf("add VTEP %s failed, error: %w", cfg.Name, network.Classify(err))
+	}
+	if l, err = network.Handle().LinkByName(cfg.Name); err != nil {
+		return nil, fmt.Errorf("get VTEP %s failed, error: %w", cfg.Name, network.Classify(err))
+	}
+
+	return ensureVtepUp(NewLink(l), cfg.MTU)
+}
+
+func ensureVtepUp(l *Link, mtu int) (*Link, error) {
+	if mtu != 0 && l.Attrs().MTU != mtu {
+		if err := linkSetMTU(l, mtu); err != nil {
+			return nil, fmt.Errorf("set MTU of %s to %d failed, error: %w", l.Attrs().Name, mtu, network.ClassifyMTU(err))
+		}
+	}
+	if l.Attrs().Flags&net.FlagUp == 0 {
+		if err := linkSetUp(l); err != nil {
+			return nil, fmt.Errorf("set %s up failed, error: %w", l.Attrs().Name, network.Classify(err))
+		}
+	}
+
+	return l, l.Fetch()
+}
+
+// EnsureFloodPeers programs a flooding FDB entry per peer on the VTEP, so that the broadcast, unknown unicast and
+// multicast traffic reaches every other node of the overlay, and removes the entries of the peers gone.
+// Equivalent to: `bridge fdb append 00:00:00:00:00:00 dev VTEP dst PEER` for each peer
+func (l *Link) EnsureFloodPeers(peers []net.IP) error {
+	neighs, err := network.Handle().NeighList(l.Attrs().Index, unix.AF_BRIDGE)
+	if err != nil {
+		return fmt.Errorf("list FDB entries of %s failed, error: %w", l.Attrs().Name, network.Classify(err))
+	}
+
+	toAdd, toDel := floodPeerChanges(neighs, peers)
+	for i := range toDel {
+		if err := fdbDel(l, &toDel[i]); err != nil {
+			return fmt.Errorf("delete the flooding to %s from %s failed, error: %w", toDel[i].IP, l.Attrs().Name,
+				network.Classify(err))
+		}
+	}
+	for _, ip := range toAdd {
+		neigh := &netlink.Neigh{
+			LinkIndex:    l.Attrs().Index,
+			Family:       unix.AF_BRIDGE,
+			State:        netlink.NUD_PERMANENT | netlink.NUD_NOARP,
+			Flags:        netlink.NTF_SELF,
+			IP:           ip,
+			HardwareAddr: zeroMAC,
+		}
+		if err := fdbAppend(l, neigh); err != nil {
+			return fmt.Errorf("add the flooding to %s to %s failed, error: %w", ip, l.Attrs().Name, network.Classify(err))
+		}
+	}
+	if len(toAdd) > 0 || len(toDel) > 0 {
+		logrus.Infof("the VTEP %s floods to %v", l.Attrs().Name, peers)
+	}
+
+	return nil
+}
+
+// floodPeerChanges returns the peers without a flooding entry, and the flooding entries of the peers gone
+func floodPeerChanges(neighs []netlink.Neigh, peers []net.IP) (toAdd []net.IP, toDel []netlink.Neigh) {
+	existing := make([]net.IP, 0, len(neighs))
+	for _, n := range neighs {
+		if !bytes.Equal(n.HardwareAddr, zeroMAC) || n.IP == nil {
+			continue
+		}
+		if containsIP(peers, n.IP) {
+			existing = append(existing, n.IP)
+		} else {
+			toDel = append(toDel, n)
+		}
+	}
+	for _, ip := range peers {
+		if !containsIP(existing, ip) {
+			toAdd = append(toAdd, ip)
+		}
+	}
+
+	return toAdd, toDel
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, i := range ips {
+		if i.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
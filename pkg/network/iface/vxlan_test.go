@@ -0,0 +1,31 @@
+package iface
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+func Test_floodPeerChanges(t *testing.T) {
+	vmMAC, _ := net.ParseMAC("52:54:00:12:34:56")
+	neighs := []netlink.Neigh{
+		{IP: net.ParseIP("10.0.0.2"), HardwareAddr: zeroMAC},
+		{IP: net.ParseIP("10.0.0.3"), HardwareAddr: zeroMAC},
+		// a learned remote MAC is kept
+		{IP: net.ParseIP("10.0.0.4"), HardwareAddr: vmMAC},
+		// a local entry without destination
+		{HardwareAddr: zeroMAC},
+	}
+	peers := []net.IP{net.ParseIP("10.0.0.2"), net.ParseIP("10.0.0.5")}
+
+	toAdd, toDel := floodPeerChanges(neighs, peers)
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.0.5")}, toAdd)
+	assert.Len(t, toDel, 1)
+	assert.True(t, toDel[0].IP.Equal(net.ParseIP("10.0.0.3")))
+
+	toAdd, toDel = floodPeerChanges(nil, nil)
+	assert.Empty(t, toAdd)
+	assert.Empty(t, toDel)
+}
@@ -0,0 +1,191 @@
+// Package inspect dumps the network state managed by the agent on the local node, i.e. the bridges of the cluster
+// networks, their uplinks and VLAN tables, and maps them to the vlanstatuses, which helps to find out where the
+// API view and the reality disagree.
+package inspect
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// State is the network state of a node
+type State struct {
+	Node            string                `json:"node"`
+	ClusterNetworks []ClusterNetworkState `json:"clusterNetworks"`
+}
+
+// ClusterNetworkState is the bridge of a cluster network, its ports, and the vlanstatus of the node
+type ClusterNetworkState struct {
+	// the name derived from the bridge, it may be truncated for a long cluster network name
+	Name   string      `json:"name"`
+	Bridge LinkState   `json:"bridge"`
+	Ports  []LinkState `json:"ports,omitempty"`
+	// the bond of fabric B which is not attached to the bridge
+	Standby *LinkState `json:"standby,omitempty"`
+
+	VlanStatus *VlanStatusRef `json:"vlanStatus,omitempty"`
+}
+
+// VlanStatusRef is the vlanstatus of the cluster network on the node
+type VlanStatusRef struct {
+	Name           string              `json:"name"`
+	ClusterNetwork string              `json:"clusterNetwork"`
+	VlanConfig     string              `json:"vlanConfig"`
+	Phase          networkv1.VlanPhase `json:"phase,omitempty"`
+	Ready          bool                `json:"ready"`
+}
+
+// LinkState is a link as the agent sees it
+type LinkState struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Index        int      `json:"index"`
+	MTU          int      `json:"mtu"`
+	OperState    string   `json:"operState"`
+	HardwareAddr string   `json:"hardwareAddr,omitempty"`
+	Alias        string   `json:"alias,omitempty"`
+	BondMode     string   `json:"bondMode,omitempty"`
+	Slaves       []string `json:"slaves,omitempty"`
+	// the parent of a VLAN sub-interface, e.g. a shared bond
+	Parent string `json:"parent,omitempty"`
+	// the VLAN table of the bridge port
+	VIDs []uint16 `json:"vids,omitempty"`
+	PVID uint16   `json:"pvid,omitempty"`
+}
+
+// Collect returns the network state of the local node
+func Collect(node string) (*State, error) {
+	links, err := network.Handle().LinkList()
+	if err != nil {
+		return nil, err
+	}
+	vlans, err := network.Handle().BridgeVlanList()
+	if err != nil {
+		return nil, err
+	}
+
+	return buildState(node, links, vlans), nil
+}
+
+func buildState(node string, links []netlink.Link, vlans map[int32][]*nl.BridgeVlanInfo) *State {
+	byIndex := make(map[int]netlink.Link, len(links))
+	byName := make(map[string]netlink.Link, len(links))
+	for _, l := range links {
+		byIndex[l.Attrs().Index] = l
+		byName[l.Attrs().Name] = l
+	}
+	newLinkState := func(l netlink.Link) LinkState {
+		return toLinkState(l, links, byIndex, vlans)
+	}
+
+	state := &State{Node: node, ClusterNetworks: make([]ClusterNetworkState, 0)}
+	for _, l := range links {
+		if l.Type() != iface.TypeBridge {
+			continue
+		}
+		name, err := utils.GetClusterNetworkFromBridgeName(l.Attrs().Name)
+		if err != nil {
+			// not a bridge of a cluster network
+			continue
+		}
+
+		cn := ClusterNetworkState{Name: name, Bridge: newLinkState(l)}
+		attached := make(map[string]bool)
+		for _, port := range links {
+			if port.Attrs().MasterIndex == l.Attrs().Index {
+				cn.Ports = append(cn.Ports, newLinkState(port))
+				attached[port.Attrs().Name] = true
+			}
+		}
+		for _, standby := range []string{utils.GenerateBondName(name), utils.GenerateFabricBBondName(name)} {
+			if b, ok := byName[standby]; ok && !attached[standby] {
+				s := newLinkState(b)
+				cn.Standby = &s
+			}
+		}
+		state.ClusterNetworks = append(state.ClusterNetworks, cn)
+	}
+	sort.Slice(state.ClusterNetworks, func(i, j int) bool {
+		return state.ClusterNetworks[i].Name < state.ClusterNetworks[j].Name
+	})
+
+	return state
+}
+
+func toLinkState(l netlink.Link, links []netlink.Link, byIndex map[int]netlink.Link, vlans map[int32][]*nl.BridgeVlanInfo) LinkState {
+	attrs := l.Attrs()
+	s := LinkState{
+		Name:         attrs.Name,
+		Type:         l.Type(),
+		Index:        attrs.Index,
+		MTU:          attrs.MTU,
+		OperState:    attrs.OperState.String(),
+		HardwareAddr: attrs.HardwareAddr.String(),
+		Alias:        attrs.Alias,
+	}
+
+	if bond, ok := l.(*netlink.Bond); ok {
+		s.BondMode = bond.Mode.String()
+		for _, slave := range links {
+			if slave.Attrs().MasterIndex == attrs.Index {
+				s.Slaves = append(s.Slaves, slave.Attrs().Name)
+			}
+		}
+		sort.Strings(s.Slaves)
+	}
+	if l.Type() == iface.TypeVlan && attrs.ParentIndex != 0 {
+		if parent, ok := byIndex[attrs.ParentIndex]; ok {
+			s.Parent = parent.Attrs().Name
+		}
+	}
+	for _, info := range vlans[int32(attrs.Index)] {
+		if info.PortVID() {
+			s.PVID = info.Vid
+		}
+		s.VIDs = append(s.VIDs, info.Vid)
+	}
+
+	return s
+}
+
+// AttachVlanStatuses maps the vlanstatuses of the node to the cluster networks by the bridge name.
+// A vlanstatus without the bridge is reported as a cluster network without the bridge.
+func (s *State) AttachVlanStatuses(vss []*networkv1.VlanStatus) {
+	for _, vs := range vss {
+		ref := &VlanStatusRef{
+			Name:           vs.Name,
+			ClusterNetwork: vs.Status.ClusterNetwork,
+			VlanConfig:     vs.Status.VlanConfig,
+			Phase:          vs.Status.Phase,
+			Ready:          networkv1.Ready.IsTrue(vs.Status),
+		}
+
+		bridge := utils.GenerateBridgeName(vs.Status.ClusterNetwork)
+		found := false
+		for i := range s.ClusterNetworks {
+			if s.ClusterNetworks[i].Bridge.Name == bridge {
+				s.ClusterNetworks[i].VlanStatus = ref
+				found = true
+				break
+			}
+		}
+		if !found {
+			s.ClusterNetworks = append(s.ClusterNetworks, ClusterNetworkState{
+				Name:       vs.Status.ClusterNetwork,
+				Bridge:     LinkState{Name: bridge, OperState: "missing"},
+				VlanStatus: ref,
+			})
+		}
+	}
+	sort.Slice(s.ClusterNetworks, func(i, j int) bool {
+		return strings.Compare(s.ClusterNetworks[i].Name, s.ClusterNetworks[j].Name) < 0
+	})
+}
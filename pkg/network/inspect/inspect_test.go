@@ -0,0 +1,90 @@
+package inspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func newBond(name string, index, master int) *netlink.Bond {
+	b := netlink.NewLinkBond(netlink.LinkAttrs{Name: name, Index: index, MasterIndex: master, MTU: 1500})
+	b.Mode = netlink.BOND_MODE_ACTIVE_BACKUP
+	return b
+}
+
+func TestBuildState(t *testing.T) {
+	links := []netlink.Link{
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth1", Index: 2, MasterIndex: 11}},
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0", Index: 3, MasterIndex: 11}},
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth2", Index: 4, MasterIndex: 12}},
+		&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: "cn2-br", Index: 20, MTU: 1500}},
+		&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: "cn1-br", Index: 10, MTU: 1500}},
+		newBond("cn1-bo", 11, 10),
+		newBond("cn1-fb", 12, 0),
+		newBond("shared-bo", 30, 0),
+		&netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: "shared-bo.100", Index: 31, MasterIndex: 20, ParentIndex: 30}, VlanId: 100},
+		// not a bridge of a cluster network
+		&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: "docker0", Index: 40}},
+	}
+	vlans := map[int32][]*nl.BridgeVlanInfo{
+		11: {{Vid: 1, Flags: nl.BRIDGE_VLAN_INFO_PVID | nl.BRIDGE_VLAN_INFO_UNTAGGED}, {Vid: 100}, {Vid: 200}},
+	}
+
+	state := buildState("node1", links, vlans)
+	assert.Equal(t, "node1", state.Node)
+	assert.Len(t, state.ClusterNetworks, 2)
+
+	cn1 := state.ClusterNetworks[0]
+	assert.Equal(t, "cn1", cn1.Name)
+	assert.Equal(t, "cn1-br", cn1.Bridge.Name)
+	assert.Len(t, cn1.Ports, 1)
+	assert.Equal(t, "cn1-bo", cn1.Ports[0].Name)
+	assert.Equal(t, "active-backup", cn1.Ports[0].BondMode)
+	assert.Equal(t, []string{"eth0", "eth1"}, cn1.Ports[0].Slaves)
+	assert.Equal(t, []uint16{1, 100, 200}, cn1.Ports[0].VIDs)
+	assert.Equal(t, uint16(1), cn1.Ports[0].PVID)
+	assert.NotNil(t, cn1.Standby)
+	assert.Equal(t, "cn1-fb", cn1.Standby.Name)
+	assert.Equal(t, []string{"eth2"}, cn1.Standby.Slaves)
+
+	cn2 := state.ClusterNetworks[1]
+	assert.Equal(t, "cn2", cn2.Name)
+	assert.Len(t, cn2.Ports, 1)
+	assert.Equal(t, "shared-bo", cn2.Ports[0].Parent)
+	assert.Nil(t, cn2.Standby)
+
+	state.AttachVlanStatuses([]*networkv1.VlanStatus{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "cn1-node1"},
+			Status: networkv1.VlStatus{
+				ClusterNetwork: "cn1",
+				VlanConfig:     "vc1",
+				Phase:          networkv1.VlanPhaseActive,
+				Conditions: []networkv1.Condition{
+					{Type: networkv1.Ready, Status: "True"},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "cn0-node1"},
+			Status: networkv1.VlStatus{
+				ClusterNetwork: "cn0",
+				VlanConfig:     "vc0",
+			},
+		},
+	})
+	assert.Len(t, state.ClusterNetworks, 3)
+	// the vlanstatus whose bridge is missing
+	assert.Equal(t, "cn0", state.ClusterNetworks[0].Name)
+	assert.Equal(t, "missing", state.ClusterNetworks[0].Bridge.OperState)
+	assert.Equal(t, "vc0", state.ClusterNetworks[0].VlanStatus.VlanConfig)
+	assert.False(t, state.ClusterNetworks[0].VlanStatus.Ready)
+	assert.Equal(t, "vc1", state.ClusterNetworks[1].VlanStatus.VlanConfig)
+	assert.True(t, state.ClusterNetworks[1].VlanStatus.Ready)
+	assert.Nil(t, state.ClusterNetworks[2].VlanStatus)
+}
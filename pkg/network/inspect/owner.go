@@ -0,0 +1,94 @@
+package inspect
+
+import (
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const mgmtPrefix = utils.ManagementClusterNetworkName + "-"
+
+// the name prefixes of the links created by the CNI plugins and KubeVirt in the host network namespace
+var ownerPrefixes = []struct {
+	prefix string
+	owner  networkv1.LinkOwner
+}{
+	{prefix: mgmtPrefix, owner: networkv1.LinkOwnerSystem},
+	{prefix: "cali", owner: networkv1.LinkOwnerCanal},
+	{prefix: "flannel", owner: networkv1.LinkOwnerCanal},
+	{prefix: "vxlan.calico", owner: networkv1.LinkOwnerCanal},
+	{prefix: "tunl", owner: networkv1.LinkOwnerCanal},
+	{prefix: "cni", owner: networkv1.LinkOwnerCanal},
+	{prefix: "cilium_", owner: networkv1.LinkOwnerCilium},
+	{prefix: "lxc", owner: networkv1.LinkOwnerCilium},
+	{prefix: "tap", owner: networkv1.LinkOwnerKubeVirt},
+	{prefix: "k6t-", owner: networkv1.LinkOwnerKubeVirt},
+}
+
+// ClassifyOwners returns the owners of the links by their index, e.g. to tell the free NICs from the links of the
+// CNI plugins
+func ClassifyOwners(links []netlink.Link) map[int]networkv1.LinkOwner {
+	byIndex := make(map[int]netlink.Link, len(links))
+	for _, l := range links {
+		byIndex[l.Attrs().Index] = l
+	}
+
+	owners := make(map[int]networkv1.LinkOwner, len(links))
+	for _, l := range links {
+		owners[l.Attrs().Index] = classifyOwner(l, byIndex, 0)
+	}
+
+	return owners
+}
+
+func classifyOwner(l netlink.Link, byIndex map[int]netlink.Link, depth int) networkv1.LinkOwner {
+	attrs := l.Attrs()
+	if attrs.Name == "lo" {
+		return networkv1.LinkOwnerSystem
+	}
+	for _, p := range ownerPrefixes {
+		if strings.HasPrefix(attrs.Name, p.prefix) {
+			return p.owner
+		}
+	}
+	if isControllerLink(attrs.Name) {
+		return networkv1.LinkOwnerController
+	}
+	if l.Type() == "tuntap" {
+		return networkv1.LinkOwnerKubeVirt
+	}
+
+	// the owner of the master or the parent owns the link, e.g. the NICs enslaved by a bond or a VLAN sub-interface of
+	// a bond, and the VM ports are the veths attached to the bridges of the cluster networks
+	related := attrs.MasterIndex
+	if related == 0 && l.Type() == iface.TypeVlan {
+		related = attrs.ParentIndex
+	}
+	if r, ok := byIndex[related]; ok && related != 0 && depth < 3 {
+		if l.Type() == "veth" && strings.HasSuffix(r.Attrs().Name, utils.BridgeSuffix) {
+			return networkv1.LinkOwnerKubeVirt
+		}
+		return classifyOwner(r, byIndex, depth+1)
+	}
+
+	if l.Type() == iface.TypeDevice {
+		return networkv1.LinkOwnerNone
+	}
+
+	return networkv1.LinkOwnerUnknown
+}
+
+func isControllerLink(name string) bool {
+	base, _, _ := strings.Cut(name, ".")
+	for _, suffix := range []string{utils.BridgeSuffix, utils.BondSuffix, utils.FabricBBondSuffix} {
+		if strings.HasSuffix(base, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
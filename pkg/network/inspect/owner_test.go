@@ -0,0 +1,53 @@
+package inspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestClassifyOwners(t *testing.T) {
+	links := []netlink.Link{
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "lo", Index: 1}},
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0", Index: 2, MasterIndex: 5}},
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth1", Index: 3, MasterIndex: 11}},
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth2", Index: 4}},
+		newBond("mgmt-bo", 5, 6),
+		&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: "mgmt-br", Index: 6}},
+		&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: "cn1-br", Index: 10}},
+		newBond("cn1-bo", 11, 10),
+		&netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: "cn1-br.100", Index: 12, ParentIndex: 10}, VlanId: 100},
+		&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "veth1234", Index: 20, MasterIndex: 10}},
+		&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "veth5678", Index: 21, MasterIndex: 6}},
+		&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "cali1234", Index: 30}},
+		&netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: "flannel.1", Index: 31}},
+		&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "lxc1234", Index: 32}},
+		&netlink.Tuntap{LinkAttrs: netlink.LinkAttrs{Name: "vnet0", Index: 40}},
+		newBond("bond0", 50, 0),
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth3", Index: 51, MasterIndex: 50}},
+	}
+
+	expected := map[int]networkv1.LinkOwner{
+		1:  networkv1.LinkOwnerSystem,
+		2:  networkv1.LinkOwnerSystem,
+		3:  networkv1.LinkOwnerController,
+		4:  networkv1.LinkOwnerNone,
+		5:  networkv1.LinkOwnerSystem,
+		6:  networkv1.LinkOwnerSystem,
+		10: networkv1.LinkOwnerController,
+		11: networkv1.LinkOwnerController,
+		12: networkv1.LinkOwnerController,
+		20: networkv1.LinkOwnerKubeVirt,
+		21: networkv1.LinkOwnerKubeVirt,
+		30: networkv1.LinkOwnerCanal,
+		31: networkv1.LinkOwnerCanal,
+		32: networkv1.LinkOwnerCilium,
+		40: networkv1.LinkOwnerKubeVirt,
+		50: networkv1.LinkOwnerUnknown,
+		51: networkv1.LinkOwnerUnknown,
+	}
+	assert.Equal(t, expected, ClassifyOwners(links))
+}
@@ -0,0 +1,294 @@
+// Package jumbo verifies the jumbo frames end to end by pinging an IPv4 target on the VLAN out of a link with the
+// packets of the full MTU and the do-not-fragment flag, e.g. a switch drops them silently despite the MTU configured
+// on the node. The pings are sent from a spare address of the subnet by a packet socket, so that the link needs no IP
+// address, and the ARP requests for the spare address are answered by the socket as well.
+package jumbo
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/packet"
+)
+
+const (
+	ipv4EtherType = 0x0800
+	arpEtherType  = 0x0806
+
+	ethHeaderLen  = 14
+	ipv4HeaderLen = 20
+	icmpHeaderLen = 8
+	arpLen        = 28
+	nonceLen      = 16
+
+	arpRequest = 1
+	arpReply   = 2
+
+	icmpEchoReply   = 0
+	icmpUnreachable = 3
+	icmpEchoRequest = 8
+	// the code of the ICMP destination unreachable telling the packet is too big to forward without fragmentation
+	icmpFragNeeded = 4
+
+	// DefaultTimeout is how long to wait for the target to answer by default
+	DefaultTimeout = time.Second
+	probeCount     = 3
+)
+
+var (
+	// ErrTargetUnreachable means the target doesn't answer the ARP requests
+	ErrTargetUnreachable = errors.New("jumbo verification target unreachable")
+	// ErrJumboDropped means the target is reachable but the pings of the full MTU are not answered
+	ErrJumboDropped = errors.New("jumbo frames dropped")
+)
+
+// Probe is the ping of the full MTU sent out of a link
+type Probe struct {
+	// the link the pings are sent out of and received by
+	Link string
+	// the VLAN ID the frames are tagged with, 0 means untagged
+	VID    uint16
+	Source net.IP
+	Target net.IP
+	// the size of the IPv4 packets
+	MTU     int
+	Timeout time.Duration
+}
+
+// Verify resolves the hardware address of the target and pings it with the packets of the full MTU. It returns
+// ErrTargetUnreachable if the target doesn't answer the ARP requests, and ErrJumboDropped if it doesn't answer the
+// pings, which are small enough to be answered without the jumbo frames.
+func (p *Probe) Verify() error {
+	source, target := p.Source.To4(), p.Target.To4()
+	if source == nil || target == nil {
+		return fmt.Errorf("the source %s and the target %s must be IPv4 addresses", p.Source, p.Target)
+	}
+	if p.MTU < ipv4HeaderLen+icmpHeaderLen+nonceLen {
+		return fmt.Errorf("the MTU %d is too small to probe", p.MTU)
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultTimeout
+	}
+
+	// check the received frames every 100ms to send the probes in between
+	conn, err := packet.Listen(p.Link, 100*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	targetMAC, err := p.resolve(conn, source, target)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, nonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	id := binary.BigEndian.Uint16(nonce)
+	echo := buildEcho(conn.MAC, targetMAC, p.VID, source, target, id, p.MTU, nonce)
+
+	var result error
+	err = p.exchange(conn, source, echo, func(payload []byte) bool {
+		answered, fragNeeded := parseEchoReply(payload, source, target, id, nonce)
+		if fragNeeded != 0 {
+			result = fmt.Errorf("%w: %s needs fragmentation to forward the packets of %d bytes, the path MTU is %d",
+				ErrJumboDropped, p.Target, p.MTU, fragNeeded)
+			return true
+		}
+		return answered
+	})
+	if err != nil {
+		return err
+	}
+	if result != nil {
+		return result
+	}
+
+	return nil
+}
+
+// resolve returns the hardware address of the target
+func (p *Probe) resolve(conn *packet.Conn, source, target net.IP) (net.HardwareAddr, error) {
+	var mac net.HardwareAddr
+	request := buildARP(conn.MAC, packet.Broadcast, p.VID, arpRequest, source, target)
+	err := p.exchange(conn, source, request, func(payload []byte) bool {
+		mac = parseARPReply(payload, source, target)
+		return mac != nil
+	})
+	if errors.Is(err, ErrJumboDropped) {
+		return nil, fmt.Errorf("%w: %s doesn't answer the ARP requests on %s", ErrTargetUnreachable, target, p.Link)
+	}
+
+	return mac, err
+}
+
+// exchange sends the frame a few times within the timeout until a received frame is answered, the ARP requests for
+// the source address are answered in between. It returns ErrJumboDropped if nothing is answered.
+func (p *Probe) exchange(conn *packet.Conn, source net.IP, frame []byte, answered func(payload []byte) bool) error {
+	deadline := time.Now().Add(p.Timeout)
+	interval := p.Timeout / probeCount
+	nextProbe, sent := time.Now(), 0
+	buf := make([]byte, p.MTU+ethHeaderLen+4)
+	for time.Now().Before(deadline) {
+		if sent < probeCount && !time.Now().Before(nextProbe) {
+			if err := conn.Send(frame); err != nil {
+				return fmt.Errorf("send probe on %s failed, error: %w", p.Link, err)
+			}
+			sent++
+			nextProbe = nextProbe.Add(interval)
+		}
+
+		n, err := conn.Receive(buf)
+		if err != nil {
+			return fmt.Errorf("receive on %s failed, error: %w", p.Link, err)
+		}
+		if n == 0 {
+			continue
+		}
+		etherType, payload := packet.ParseFrame(buf[:n])
+		switch etherType {
+		case arpEtherType:
+			// the target asks for the hardware address of the source to answer the pings
+			if mac, ip := parseARPRequest(payload, source); mac != nil {
+				reply := buildARP(conn.MAC, mac, p.VID, arpReply, source, ip)
+				if err := conn.Send(reply); err != nil {
+					return fmt.Errorf("send ARP reply on %s failed, error: %w", p.Link, err)
+				}
+				continue
+			}
+		case ipv4EtherType:
+		default:
+			continue
+		}
+		if answered(payload) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: the pings of %d bytes to %s on %s are not answered within %s", ErrJumboDropped, p.MTU,
+		p.Target, p.Link, p.Timeout)
+}
+
+func buildARP(src, dst net.HardwareAddr, vid uint16, op uint16, senderIP, targetIP net.IP) []byte {
+	frame := packet.AppendEthHeader(make([]byte, 0, packet.MinFrameLen), dst, src, vid, arpEtherType)
+	frame = binary.BigEndian.AppendUint16(frame, 1) // Ethernet
+	frame = binary.BigEndian.AppendUint16(frame, ipv4EtherType)
+	frame = append(frame, 6, 4)
+	frame = binary.BigEndian.AppendUint16(frame, op)
+	frame = append(frame, src...)
+	frame = append(frame, senderIP.To4()...)
+	if op == arpRequest {
+		frame = append(frame, make([]byte, 6)...)
+	} else {
+		frame = append(frame, dst...)
+	}
+	frame = append(frame, targetIP.To4()...)
+
+	return packet.Pad(frame)
+}
+
+// parseARP returns the operation, the sender hardware and IP addresses and the target IP address of the ARP packet
+func parseARP(payload []byte) (uint16, net.HardwareAddr, net.IP, net.IP, bool) {
+	if len(payload) < arpLen || binary.BigEndian.Uint16(payload[2:]) != ipv4EtherType || payload[4] != 6 || payload[5] != 4 {
+		return 0, nil, nil, nil, false
+	}
+	return binary.BigEndian.Uint16(payload[6:]), net.HardwareAddr(payload[8:14]), net.IP(payload[14:18]),
+		net.IP(payload[24:28]), true
+}
+
+// parseARPReply returns the hardware address of the target if the payload is its reply to the source
+func parseARPReply(payload []byte, source, target net.IP) net.HardwareAddr {
+	op, mac, senderIP, targetIP, ok := parseARP(payload)
+	if !ok || op != arpReply || !senderIP.Equal(target) || !targetIP.Equal(source) {
+		return nil
+	}
+	return append(net.HardwareAddr{}, mac...)
+}
+
+// parseARPRequest returns the hardware and IP addresses of the sender if the payload asks for the source
+func parseARPRequest(payload []byte, source net.IP) (net.HardwareAddr, net.IP) {
+	op, mac, senderIP, targetIP, ok := parseARP(payload)
+	if !ok || op != arpRequest || !targetIP.Equal(source) {
+		return nil, nil
+	}
+	return append(net.HardwareAddr{}, mac...), append(net.IP{}, senderIP...)
+}
+
+// buildEcho returns the ICMP echo request of the full MTU with the do-not-fragment flag, carrying the nonce
+func buildEcho(src, dst net.HardwareAddr, vid uint16, source, target net.IP, id uint16, mtu int, nonce []byte) []byte {
+	frame := packet.AppendEthHeader(make([]byte, 0, ethHeaderLen+4+mtu), dst, src, vid, ipv4EtherType)
+
+	ip := make([]byte, ipv4HeaderLen)
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:], uint16(mtu))
+	binary.BigEndian.PutUint16(ip[4:], id)
+	binary.BigEndian.PutUint16(ip[6:], 0x4000) // don't fragment
+	ip[8] = 64
+	ip[9] = unix.IPPROTO_ICMP
+	copy(ip[12:16], source.To4())
+	copy(ip[16:20], target.To4())
+	binary.BigEndian.PutUint16(ip[10:], checksum(ip))
+
+	icmp := make([]byte, mtu-ipv4HeaderLen)
+	icmp[0] = icmpEchoRequest
+	binary.BigEndian.PutUint16(icmp[4:], id)
+	binary.BigEndian.PutUint16(icmp[6:], 1)
+	copy(icmp[icmpHeaderLen:], nonce)
+	binary.BigEndian.PutUint16(icmp[2:], checksum(icmp))
+
+	frame = append(frame, ip...)
+	return append(frame, icmp...)
+}
+
+// parseEchoReply returns true if the IPv4 packet is the full size echo reply of the target carrying the nonce, or
+// the next-hop MTU if a router on the path tells the echo request needs fragmentation
+func parseEchoReply(packet []byte, source, target net.IP, id uint16, nonce []byte) (bool, int) {
+	if len(packet) < ipv4HeaderLen || packet[0]>>4 != 4 || packet[9] != unix.IPPROTO_ICMP {
+		return false, 0
+	}
+	headerLen := int(packet[0]&0x0f) * 4
+	if len(packet) < headerLen+icmpHeaderLen || !net.IP(packet[16:20]).Equal(source) {
+		return false, 0
+	}
+	icmp := packet[headerLen:]
+
+	switch icmp[0] {
+	case icmpEchoReply:
+		return net.IP(packet[12:16]).Equal(target) && binary.BigEndian.Uint16(icmp[4:]) == id &&
+			bytes.HasPrefix(icmp[icmpHeaderLen:], nonce), 0
+	case icmpUnreachable:
+		// the original header and the first 8 bytes of the echo request follow the ICMP header
+		original := icmp[icmpHeaderLen:]
+		if icmp[1] != icmpFragNeeded || len(original) < ipv4HeaderLen+icmpHeaderLen ||
+			!net.IP(original[16:20]).Equal(target) || binary.BigEndian.Uint16(original[ipv4HeaderLen+4:]) != id {
+			return false, 0
+		}
+		return false, int(binary.BigEndian.Uint16(icmp[6:]))
+	}
+
+	return false, 0
+}
+
+// checksum is the internet checksum of RFC 1071
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,82 @@
+package jumbo
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/packet"
+)
+
+var (
+	localMAC  = net.HardwareAddr{0x02, 0, 0, 0, 0, 1}
+	targetMAC = net.HardwareAddr{0x02, 0, 0, 0, 0, 2}
+	source    = net.IPv4(10, 0, 100, 250).To4()
+	target    = net.IPv4(10, 0, 100, 1).To4()
+)
+
+func TestARP(t *testing.T) {
+	request := buildARP(localMAC, packet.Broadcast, 100, arpRequest, source, target)
+	assert.Len(t, request, packet.MinFrameLen)
+	assert.Equal(t, []byte{0x81, 0x00, 0x00, 0x64}, request[12:16])
+
+	etherType, payload := packet.ParseFrame(request)
+	assert.Equal(t, uint16(arpEtherType), etherType)
+	mac, ip := parseARPRequest(payload, source)
+	assert.Nil(t, mac, "the request of the source itself doesn't ask for the source")
+	assert.Nil(t, ip)
+
+	// the target asks for the source to answer the pings, the NIC strips the VLAN tag
+	asking := buildARP(targetMAC, packet.Broadcast, 100, arpRequest, target, source)
+	stripped := append(append([]byte{}, asking[:12]...), asking[16:]...)
+	_, payload = packet.ParseFrame(stripped)
+	mac, ip = parseARPRequest(payload, source)
+	assert.Equal(t, targetMAC, mac)
+	assert.True(t, target.Equal(ip))
+
+	reply := buildARP(targetMAC, localMAC, 100, arpReply, target, source)
+	_, payload = packet.ParseFrame(reply)
+	assert.Equal(t, targetMAC, parseARPReply(payload, source, target))
+	assert.Nil(t, parseARPReply(payload, source, net.IPv4(10, 0, 100, 2)), "the reply of another address")
+}
+
+func TestEcho(t *testing.T) {
+	nonce := []byte("0123456789abcdef")
+	echo := buildEcho(localMAC, targetMAC, 0, source, target, 0x3031, 9000, nonce)
+	assert.Len(t, echo, ethHeaderLen+9000)
+
+	etherType, packet := packet.ParseFrame(echo)
+	assert.Equal(t, uint16(ipv4EtherType), etherType)
+	assert.Equal(t, uint16(9000), binary.BigEndian.Uint16(packet[2:]))
+	assert.Equal(t, uint16(0x4000), binary.BigEndian.Uint16(packet[6:]), "don't fragment")
+	assert.Zero(t, checksum(packet[:ipv4HeaderLen]), "the checksum of a valid header is 0")
+	assert.Zero(t, checksum(packet[ipv4HeaderLen:]))
+
+	// the target answers with the source and destination swapped
+	reply := append([]byte{}, packet...)
+	copy(reply[12:16], target)
+	copy(reply[16:20], source)
+	reply[ipv4HeaderLen] = icmpEchoReply
+	answered, fragNeeded := parseEchoReply(reply, source, target, 0x3031, nonce)
+	assert.True(t, answered)
+	assert.Zero(t, fragNeeded)
+	answered, _ = parseEchoReply(reply, source, target, 0x3031, []byte("fedcba9876543210"))
+	assert.False(t, answered, "the reply to another probe")
+
+	// a router on the path can't forward the packet without fragmentation
+	unreachable := make([]byte, ipv4HeaderLen, ipv4HeaderLen+icmpHeaderLen+ipv4HeaderLen+icmpHeaderLen)
+	unreachable[0] = 0x45
+	unreachable[9] = 1
+	copy(unreachable[12:16], net.IPv4(10, 0, 100, 254).To4())
+	copy(unreachable[16:20], source)
+	icmp := make([]byte, icmpHeaderLen)
+	icmp[0], icmp[1] = icmpUnreachable, icmpFragNeeded
+	binary.BigEndian.PutUint16(icmp[6:], 1500)
+	unreachable = append(unreachable, icmp...)
+	unreachable = append(unreachable, packet[:ipv4HeaderLen+icmpHeaderLen]...)
+	answered, fragNeeded = parseEchoReply(unreachable, source, target, 0x3031, nonce)
+	assert.False(t, answered)
+	assert.Equal(t, 1500, fragNeeded)
+}
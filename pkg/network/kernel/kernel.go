@@ -0,0 +1,212 @@
+// Package kernel checks the kernel modules and options the agent relies on, so that a node lacking them reports what to
+// install instead of failing with an obscure netlink error when the first bond or VLAN sub-interface is set up.
+package kernel
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ModuleBonding = "bonding"
+	Module8021Q   = "8021q"
+	ModuleBridge  = "bridge"
+	// OptionBridgeVlanFiltering is the kernel option the VLAN filtering of the bridges requires
+	OptionBridgeVlanFiltering = "CONFIG_BRIDGE_VLAN_FILTERING"
+)
+
+// Missing is a kernel module or option the node lacks
+type Missing struct {
+	Name string
+	// what the agent needs it for
+	Usage string
+	// how to get it
+	Hint string
+}
+
+func (m Missing) String() string {
+	return fmt.Sprintf("%s is missing, it's required by %s; %s", m.Name, m.Usage, m.Hint)
+}
+
+var (
+	usages = map[string]string{
+		ModuleBonding:             "the uplink bonds",
+		Module8021Q:               "the VLAN sub-interfaces of the shared bonds and the service VLANs",
+		ModuleBridge:              "the bridges of the cluster networks",
+		OptionBridgeVlanFiltering: "the VLAN IDs of the NADs on the bridges",
+	}
+	// the prerequisites are checked in this order
+	prerequisites = []string{ModuleBonding, Module8021Q, ModuleBridge, OptionBridgeVlanFiltering}
+)
+
+// Env is where the kernel exposes its modules and config, the directories are replaced in the tests
+type Env struct {
+	// /sys/module, the loaded modules and the built-in ones having parameters
+	SysModuleDir string
+	// /sys/class/net, the existing bridges tell whether the VLAN filtering is supported
+	SysClassNetDir string
+	// /lib/modules/<release>, the modules which can be loaded on demand
+	ModulesDir string
+	// the kernel config, either plain or gzipped
+	ConfigPaths []string
+}
+
+// HostEnv returns the environment of the running kernel
+func HostEnv() Env {
+	release := ""
+	var uts unix.Utsname
+	if err := unix.Uname(&uts); err == nil {
+		release = unix.ByteSliceToString(uts.Release[:])
+	}
+
+	return Env{
+		SysModuleDir:   "/sys/module",
+		SysClassNetDir: "/sys/class/net",
+		ModulesDir:     filepath.Join("/lib/modules", release),
+		ConfigPaths:    []string{filepath.Join("/boot", "config-"+release), "/proc/config.gz"},
+	}
+}
+
+// Check returns the prerequisites the kernel lacks. A prerequisite is only reported if its absence is certain, e.g.
+// a module not loaded is not reported if the modules of the kernel can't be read from the agent.
+func (e Env) Check() []Missing {
+	missing := make([]Missing, 0)
+	for _, name := range prerequisites {
+		var present bool
+		var hint string
+		if name == OptionBridgeVlanFiltering {
+			present = e.hasBridgeVlanFiltering()
+			hint = fmt.Sprintf("the kernel has to be built with %s=y", name)
+		} else {
+			present = e.hasModule(name)
+			hint = fmt.Sprintf("load it with `modprobe %s` or install the kernel modules package of the node", name)
+		}
+		if !present {
+			missing = append(missing, Missing{Name: name, Usage: usages[name], Hint: hint})
+		}
+	}
+
+	return missing
+}
+
+// hasModule returns false only if the module is neither loaded, built in, nor available to be loaded on demand
+func (e Env) hasModule(name string) bool {
+	if _, err := os.Stat(filepath.Join(e.SysModuleDir, name)); err == nil {
+		return true
+	}
+
+	known := false
+	for _, index := range []string{"modules.builtin", "modules.dep"} {
+		found, err := listsModule(filepath.Join(e.ModulesDir, index), name)
+		if err != nil {
+			continue
+		}
+		if found {
+			return true
+		}
+		known = true
+	}
+
+	return !known
+}
+
+// listsModule tells whether the module index, i.e. modules.builtin or modules.dep, lists the module
+func listsModule(path, name string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		file, _, _ := strings.Cut(scanner.Text(), ":")
+		base := filepath.Base(file)
+		// the modules may be compressed, e.g. bonding.ko.zst
+		if base == name+".ko" || strings.HasPrefix(base, name+".ko.") {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// hasBridgeVlanFiltering looks at the existing bridges first, the kernel only exposes their vlan_filtering if it's
+// supported, and falls back to the kernel config. It returns false only if the option is certainly not supported.
+func (e Env) hasBridgeVlanFiltering() bool {
+	if bridges, err := filepath.Glob(filepath.Join(e.SysClassNetDir, "*", "bridge")); err == nil && len(bridges) > 0 {
+		_, err := os.Stat(filepath.Join(bridges[0], "vlan_filtering"))
+		return err == nil
+	}
+
+	for _, path := range e.ConfigPaths {
+		value, err := configValue(path, OptionBridgeVlanFiltering)
+		if err != nil {
+			continue
+		}
+		return value == "y"
+	}
+
+	return true
+}
+
+// configValue returns the value of the option in the kernel config, empty if it's not set
+func configValue(path, option string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return "", err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), option+"="); ok {
+			return value, nil
+		}
+	}
+
+	return "", scanner.Err()
+}
+
+// checked is the result of the check on the host, the kernel doesn't change while the agent runs
+var checked = sync.OnceValue(func() []Missing {
+	return HostEnv().Check()
+})
+
+// MissingOnHost returns the prerequisites the running kernel lacks
+func MissingOnHost() []Missing {
+	return checked()
+}
+
+// Require returns an error telling how to get the required prerequisites the running kernel lacks
+func Require(names ...string) error {
+	var errs []error
+	for _, m := range MissingOnHost() {
+		for _, name := range names {
+			if m.Name == name {
+				errs = append(errs, errors.New(m.String()))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,57 @@
+package kernel
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func missingNames(missing []Missing) []string {
+	names := make([]string, 0, len(missing))
+	for _, m := range missing {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	env := Env{
+		SysModuleDir:   filepath.Join(dir, "sys/module"),
+		SysClassNetDir: filepath.Join(dir, "sys/class/net"),
+		ModulesDir:     filepath.Join(dir, "lib/modules"),
+		ConfigPaths:    []string{filepath.Join(dir, "proc/config.gz")},
+	}
+
+	assert.Empty(t, env.Check(), "nothing is reported if the kernel can't be inspected")
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(env.SysModuleDir, ModuleBonding), 0755))
+	writeFile(t, filepath.Join(env.ModulesDir, "modules.builtin"), "kernel/net/bridge/bridge.ko\n")
+	writeFile(t, filepath.Join(env.ModulesDir, "modules.dep"), "kernel/drivers/net/dummy.ko.zst:\n")
+	assert.Equal(t, []string{Module8021Q}, missingNames(env.Check()))
+
+	writeFile(t, filepath.Join(env.ModulesDir, "modules.dep"), "kernel/net/8021q/8021q.ko.zst: kernel/net/802/garp.ko.zst\n")
+	assert.Empty(t, env.Check(), "the module can be loaded on demand")
+
+	assert.NoError(t, os.MkdirAll(filepath.Dir(env.ConfigPaths[0]), 0755))
+	f, err := os.Create(env.ConfigPaths[0])
+	assert.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write([]byte("CONFIG_BRIDGE=m\n# CONFIG_BRIDGE_VLAN_FILTERING is not set\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, f.Close())
+	assert.Equal(t, []string{OptionBridgeVlanFiltering}, missingNames(env.Check()))
+
+	// an existing bridge tells the truth over the config
+	writeFile(t, filepath.Join(env.SysClassNetDir, "mgmt-br/bridge/vlan_filtering"), "1\n")
+	assert.Empty(t, env.Check())
+}
@@ -0,0 +1,91 @@
+// Package loop detects a layer 2 loop behind a link by sending broadcast probe frames out of the link and checking
+// whether any of them is received by the link again, e.g. two NICs of a bond are cabled to switch ports which are
+// bridged without STP.
+package loop
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/packet"
+)
+
+const (
+	// the IEEE 802 local experimental ethertype
+	probeEtherType = 0x88b5
+
+	probeMagic = "harvester-loop"
+	nonceLen   = 16
+
+	// DefaultTimeout is how long to wait for the probes to come back by default
+	DefaultTimeout = 500 * time.Millisecond
+	probeCount     = 3
+)
+
+// ErrLoopDetected means a probe sent out of the link is received by the link again
+var ErrLoopDetected = errors.New("layer 2 loop detected")
+
+// Detect sends the probes tagged with the VID out of the link, 0 means untagged, and returns ErrLoopDetected if any
+// of them comes back within the timeout. A link without carrier never detects a loop.
+func Detect(name string, vid uint16, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	nonce := make([]byte, nonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	// check the received frames every 100ms to send the probes in between
+	conn, err := packet.Listen(name, 100*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	frame := buildProbe(conn.MAC, vid, nonce)
+
+	deadline := time.Now().Add(timeout)
+	interval := timeout / probeCount
+	nextProbe, sent := time.Now(), 0
+	buf := make([]byte, 2048)
+	for time.Now().Before(deadline) {
+		if sent < probeCount && !time.Now().Before(nextProbe) {
+			if err := conn.Send(frame); err != nil {
+				return fmt.Errorf("send probe on %s failed, error: %w", name, err)
+			}
+			sent++
+			nextProbe = nextProbe.Add(interval)
+		}
+
+		n, err := conn.Receive(buf)
+		if err != nil {
+			return fmt.Errorf("receive on %s failed, error: %w", name, err)
+		}
+		if n > 0 && isProbe(buf[:n], nonce) {
+			return fmt.Errorf("%w: the probe sent out of %s is received by it again", ErrLoopDetected, name)
+		}
+	}
+
+	return nil
+}
+
+// buildProbe returns a broadcast frame carrying the nonce, which is flooded by the switches and comes back if there
+// is a loop
+func buildProbe(src net.HardwareAddr, vid uint16, nonce []byte) []byte {
+	frame := packet.AppendEthHeader(make([]byte, 0, packet.MinFrameLen), packet.Broadcast, src, vid, probeEtherType)
+	frame = append(frame, probeMagic...)
+	frame = append(frame, nonce...)
+	return packet.Pad(frame)
+}
+
+// isProbe returns true if the frame is a probe carrying the nonce, the VLAN tag may have been stripped by the NIC
+func isProbe(frame, nonce []byte) bool {
+	etherType, payload := packet.ParseFrame(frame)
+	return etherType == probeEtherType && bytes.HasPrefix(payload, []byte(probeMagic)) &&
+		bytes.HasPrefix(payload[len(probeMagic):], nonce)
+}
@@ -0,0 +1,34 @@
+package loop
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/packet"
+)
+
+func TestProbe(t *testing.T) {
+	src := net.HardwareAddr{0x02, 0, 0, 0, 0, 1}
+	nonce := []byte("0123456789abcdef")
+	other := []byte("fedcba9876543210")
+
+	untagged := buildProbe(src, 0, nonce)
+	assert.Len(t, untagged, packet.MinFrameLen)
+	assert.Equal(t, src, net.HardwareAddr(untagged[6:12]))
+	assert.True(t, isProbe(untagged, nonce))
+	assert.False(t, isProbe(untagged, other), "a probe of another agent is not taken as a loop")
+
+	tagged := buildProbe(src, 100, nonce)
+	assert.Equal(t, []byte{0x81, 0x00, 0x00, 0x64}, tagged[12:16])
+	assert.True(t, isProbe(tagged, nonce))
+
+	// the NIC strips the VLAN tag of the received frame
+	stripped := append(append([]byte{}, tagged[:12]...), tagged[16:]...)
+	assert.True(t, isProbe(stripped, nonce))
+
+	assert.False(t, isProbe(untagged[:13], nonce))
+	arp := append(append([]byte{}, untagged[:12]...), 0x08, 0x06)
+	assert.False(t, isProbe(append(arp, untagged[14:]...), nonce))
+}
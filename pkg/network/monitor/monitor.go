@@ -9,6 +9,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
 
+	"github.com/harvester/harvester-network-controller/pkg/network"
 	"github.com/harvester/harvester-network-controller/pkg/network/iface"
 )
 
@@ -133,7 +134,7 @@ func (m *Monitor) AddPattern(key string, pattern *Pattern) {
 }
 
 func (m *Monitor) ScanLinks(pattern *Pattern) ([]netlink.Link, error) {
-	links, err := netlink.LinkList()
+	links, err := network.Handle().LinkList()
 	if err != nil {
 		return nil, err
 	}
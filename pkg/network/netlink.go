@@ -0,0 +1,97 @@
+package network
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/netlinksafe"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+const socketTimeout = 10 * time.Second
+
+// sockets are the netlink handle and the rtnetlink socket shared by the process, they are swapped together
+type sockets struct {
+	handle      *SafeHandle
+	routeSocket *nl.SocketHandle
+}
+
+var (
+	sharedOnce    sync.Once
+	sharedSockets atomic.Pointer[sockets]
+)
+
+// Handle returns the netlink handle shared by the network operations of the process. Its sockets are opened once and
+// reused by the requests, rather than one socket per request as the package functions of netlink do. It's safe for
+// concurrent use, the requests over the same socket are serialized. Its dumps are retried if they are interrupted, so
+// the links are listed by it rather than by netlinksafe's package functions.
+func Handle() *SafeHandle {
+	sharedOnce.Do(openSharedSockets)
+	return sharedSockets.Load().handle
+}
+
+// NewRouteRequest returns a rtnetlink request sent over the shared socket, for the requests which the netlink handle
+// doesn't provide, e.g. several bridge VLAN changes in one request
+func NewRouteRequest(proto, flags int) *nl.NetlinkRequest {
+	sharedOnce.Do(openSharedSockets)
+	req := nl.NewNetlinkRequest(proto, flags)
+	if s := sharedSockets.Load().routeSocket; s != nil {
+		req.Sockets = map[int]*nl.SocketHandle{unix.NETLINK_ROUTE: s}
+	}
+	return req
+}
+
+// openSharedSockets falls back to one socket per request if the sockets can't be opened
+func openSharedSockets() {
+	sharedSockets.Store(openSocketsAt(netns.None()))
+}
+
+// UseNamespace makes the shared netlink handle and socket send the requests into the network namespace and returns
+// the function to restore the previous ones. It's for the tests running the network code in a network namespace. The
+// handle and socket are swapped atomically, the requests running on the other goroutines meanwhile are sent over
+// either the previous ones or the new ones.
+func UseNamespace(ns netns.NsHandle) func() {
+	sharedOnce.Do(openSharedSockets)
+	prev := sharedSockets.Swap(openSocketsAt(ns))
+
+	return func() {
+		s := sharedSockets.Swap(prev)
+		if s.routeSocket != nil {
+			s.routeSocket.Close()
+		}
+		s.handle.Close()
+	}
+}
+
+// openSocketsAt opens the netlink handle and the rtnetlink socket in the network namespace, netns.None() means the
+// current one
+func openSocketsAt(ns netns.NsHandle) *sockets {
+	nh, err := netlinksafe.NewHandleAt(ns, unix.NETLINK_ROUTE)
+	if err == nil {
+		err = nh.SetSocketTimeout(socketTimeout)
+	}
+	if err != nil {
+		logrus.Warnf("failed to open the shared netlink handle, fall back to a socket per request, error: %s", err.Error())
+		nh = netlinksafe.Handle{Handle: &netlink.Handle{}}
+	}
+	h := &SafeHandle{Handle: nh}
+
+	s, err := nl.GetNetlinkSocketAt(ns, netns.None(), unix.NETLINK_ROUTE)
+	if err != nil {
+		logrus.Warnf("failed to open the shared netlink socket, fall back to a socket per request, error: %s", err.Error())
+		return &sockets{handle: h}
+	}
+	timeout := unix.NsecToTimeval(socketTimeout.Nanoseconds())
+	if err := s.SetSendTimeout(&timeout); err != nil {
+		logrus.Warnf("failed to set the send timeout of the shared netlink socket, error: %s", err.Error())
+	}
+	if err := s.SetReceiveTimeout(&timeout); err != nil {
+		logrus.Warnf("failed to set the receive timeout of the shared netlink socket, error: %s", err.Error())
+	}
+	return &sockets{handle: h, routeSocket: &nl.SocketHandle{Socket: s}}
+}
@@ -0,0 +1,44 @@
+package network
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netns"
+)
+
+func TestUseNamespaceConcurrently(t *testing.T) {
+	shared := Handle()
+
+	var wg, started sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					assert.NotNil(t, Handle())
+					assert.NotNil(t, NewRouteRequest(0, 0))
+				}
+			}
+		}()
+	}
+
+	started.Wait()
+	for i := 0; i < 100; i++ {
+		restore := UseNamespace(netns.None())
+		assert.NotSame(t, shared, Handle())
+		restore()
+	}
+	close(stop)
+	wg.Wait()
+
+	assert.Same(t, shared, Handle())
+}
@@ -0,0 +1,183 @@
+// Package ovs drives the Open vSwitch bridges of the cluster networks with the datapath OVS. The bridges and ports
+// are configured through ovs-vsctl, which talks to the ovsdb-server of the node over its socket, e.g. the
+// /var/run/openvswitch of the host mounted into the agent.
+package ovs
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	// LinkType is the type of the internal interface of an OVS bridge on the node
+	LinkType = "openvswitch"
+	// DatapathName is the kernel datapath of Open vSwitch, the master of the ports of all the OVS bridges
+	DatapathName = "ovs-system"
+
+	defaultTimeout = 10 * time.Second
+	maxOutputLen   = 512
+)
+
+// vsctl runs ovs-vsctl with the arguments and returns its output, it's replaced in the unit tests
+var vsctl = func(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ovs-vsctl", args...) //nolint:gosec
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		s := strings.TrimSpace(string(output))
+		if len(s) > maxOutputLen {
+			s = s[:maxOutputLen] + "..."
+		}
+		return "", fmt.Errorf("ovs-vsctl %s failed, error: %w, output: %s", strings.Join(args, " "), err, s)
+	}
+
+	return string(output), nil
+}
+
+// Available returns true if ovs-vsctl is installed and the ovsdb-server of the node answers it
+func Available() bool {
+	// ovs-vsctl waits for the ovsdb-server forever by default
+	_, err := vsctl("--timeout=5", "show")
+	return err == nil
+}
+
+// EnsureBridge creates the OVS bridge if not existing
+func EnsureBridge(name string) error {
+	_, err := vsctl("--may-exist", "add-br", name)
+	return err
+}
+
+// DeleteBridge removes the OVS bridge with all its ports, it's not an error if the bridge doesn't exist
+func DeleteBridge(name string) error {
+	_, err := vsctl("--if-exists", "del-br", name)
+	return err
+}
+
+// EnsureTrunkPort adds the port to the OVS bridge if not existing. The untagged frames of the port are taken as the
+// default VLAN ID 1, which is always trunked, an empty trunk set would trunk all the VLAN IDs otherwise.
+func EnsureTrunkPort(bridge, port string) error {
+	_, err := vsctl("--may-exist", "add-port", bridge, port,
+		"--", "set", "port", port, "vlan_mode=native-untagged", fmt.Sprintf("tag=%d", utils.DefaultVlanID))
+	if err != nil {
+		return err
+	}
+
+	trunks, err := Trunks(port)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(trunks, utils.DefaultVlanID) {
+		return SetTrunks(port, trunks)
+	}
+
+	return nil
+}
+
+// DeletePort removes the port from the OVS bridge, it's not an error if the port doesn't exist
+func DeletePort(bridge, port string) error {
+	_, err := vsctl("--if-exists", "del-port", bridge, port)
+	return err
+}
+
+// Trunks returns the VLAN IDs trunked on the port
+func Trunks(port string) ([]uint16, error) {
+	output, err := vsctl("get", "port", port, "trunks")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseVIDs(strings.Trim(strings.TrimSpace(output), "[]"), ",")
+}
+
+// SetTrunks sets the VLAN IDs trunked on the port, the default VLAN ID 1 is always kept
+func SetTrunks(port string, vids []uint16) error {
+	set := []string{strconv.Itoa(utils.DefaultVlanID)}
+	for _, vid := range vids {
+		if vid != utils.DefaultVlanID {
+			set = append(set, strconv.Itoa(int(vid)))
+		}
+	}
+
+	_, err := vsctl("set", "port", port, "trunks="+strings.Join(set, ","))
+	return err
+}
+
+// VlanUsers returns the ports of the OVS bridge other than the excluded one using each VID, e.g. the VM ports added by
+// the OVS CNI. A port without any tag and trunks is taken as a user of the default VLAN ID 1.
+func VlanUsers(bridge, excluded string) (map[uint16][]string, error) {
+	output, err := vsctl("list-ports", bridge)
+	if err != nil {
+		return nil, err
+	}
+	ports := make(map[string]bool)
+	for _, p := range strings.Fields(output) {
+		if p != excluded {
+			ports[p] = true
+		}
+	}
+	if len(ports) == 0 {
+		return map[uint16][]string{}, nil
+	}
+
+	output, err = vsctl("--format=csv", "--data=bare", "--no-headings", "--columns=name,tag,trunks", "list", "port")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseVlanUsers(output, ports)
+}
+
+// parseVlanUsers parses the CSV rows of the name, tag and trunks of the ports, only the rows of the ports are taken
+func parseVlanUsers(output string, ports map[string]bool) (map[uint16][]string, error) {
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse ports failed, error: %w", err)
+	}
+
+	users := make(map[uint16][]string)
+	for _, r := range records {
+		if len(r) != 3 || !ports[r[0]] {
+			continue
+		}
+		vids, err := parseVIDs(r[1]+" "+r[2], " ")
+		if err != nil {
+			return nil, fmt.Errorf("parse VIDs of port %s failed, error: %w", r[0], err)
+		}
+		if len(vids) == 0 {
+			vids = []uint16{utils.DefaultVlanID}
+		}
+		for _, vid := range vids {
+			if !slices.Contains(users[vid], r[0]) {
+				users[vid] = append(users[vid], r[0])
+			}
+		}
+	}
+
+	return users, nil
+}
+
+func parseVIDs(s, sep string) ([]uint16, error) {
+	var vids []uint16
+	for _, f := range strings.Split(s, sep) {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		vid, err := strconv.ParseUint(f, 10, 16)
+		if err != nil || vid == 0 || vid > utils.MaxVlanID {
+			return nil, fmt.Errorf("invalid VID %q", f)
+		}
+		vids = append(vids, uint16(vid))
+	}
+
+	return vids, nil
+}
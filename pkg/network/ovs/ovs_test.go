@@ -0,0 +1,99 @@
+package ovs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVlanUsers(t *testing.T) {
+	output := `br-uplink,1,"1 100 200"
+cn-bo,1,"1 100"
+vm1-port,100,
+vm2-port,200,
+vm3-port,,
+vm4-port,,"100 300"
+`
+	tests := []struct {
+		name      string
+		ports     map[string]bool
+		want      map[uint16][]string
+		returnErr bool
+	}{
+		{
+			name:  "tagged, trunk and untagged ports",
+			ports: map[string]bool{"vm1-port": true, "vm2-port": true, "vm3-port": true, "vm4-port": true},
+			want: map[uint16][]string{
+				1:   {"vm3-port"},
+				100: {"vm1-port", "vm4-port"},
+				200: {"vm2-port"},
+				300: {"vm4-port"},
+			},
+		},
+		{
+			name:  "the ports of other bridges are skipped",
+			ports: map[string]bool{"vm2-port": true},
+			want:  map[uint16][]string{200: {"vm2-port"}},
+		},
+		{
+			name:      "invalid VID",
+			ports:     map[string]bool{"vm5-port": true},
+			returnErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseVlanUsers(output+"vm5-port,4095,\n", tc.ports)
+			if tc.returnErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSetTrunks(t *testing.T) {
+	var args []string
+	origin := vsctl
+	defer func() { vsctl = origin }()
+	vsctl = func(a ...string) (string, error) {
+		args = a
+		return "", nil
+	}
+
+	tests := []struct {
+		name string
+		vids []uint16
+		want string
+	}{
+		{
+			name: "the default VID is kept with no VID",
+			want: "trunks=1",
+		},
+		{
+			name: "the default VID is not duplicated",
+			vids: []uint16{1, 100, 200},
+			want: "trunks=1,100,200",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := SetTrunks("cn-bo", tc.vids); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.Join(args, " "); got != "set port cn-bo "+tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
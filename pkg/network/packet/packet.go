@@ -0,0 +1,144 @@
+// Package packet sends and receives the raw Ethernet frames of a link over a packet socket, for the probes and
+// announcements which are built frame by frame rather than sent through the network stack of the node.
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	VlanEtherType = 0x8100
+	// MinFrameLen is the minimum length of an Ethernet frame without the FCS
+	MinFrameLen = 60
+	// the offset of the ethertype, behind the MAC addresses
+	etherTypeOffset = 12
+)
+
+var Broadcast = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// Conn is a packet socket of a link
+type Conn struct {
+	FD   int
+	Addr *unix.SockaddrLinklayer
+	// MAC is the hardware address of the link
+	MAC net.HardwareAddr
+}
+
+// Open returns the packet socket sending the frames of the ethertype out of the link, it receives nothing
+func Open(name string, etherType uint16) (*Conn, error) {
+	link, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("get link %s failed, error: %w", name, err)
+	}
+
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open packet socket on %s failed, error: %w", name, err)
+	}
+
+	return &Conn{
+		FD:   fd,
+		Addr: &unix.SockaddrLinklayer{Protocol: Htons(etherType), Ifindex: link.Index},
+		MAC:  link.HardwareAddr,
+	}, nil
+}
+
+// Listen returns the packet socket bound to the link, which receives the frames of all the protocols received and
+// sent by the link. The receiving returns after the timeout if nothing arrives, so that the caller can send or check
+// something in between.
+func Listen(name string, timeout time.Duration) (*Conn, error) {
+	link, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("get link %s failed, error: %w", name, err)
+	}
+
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(Htons(unix.ETH_P_ALL)))
+	if err != nil {
+		return nil, fmt.Errorf("open packet socket on %s failed, error: %w", name, err)
+	}
+	addr := &unix.SockaddrLinklayer{Protocol: Htons(unix.ETH_P_ALL), Ifindex: link.Index}
+	if err := unix.Bind(fd, addr); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("bind packet socket to %s failed, error: %w", name, err)
+	}
+	tv := unix.NsecToTimeval(timeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("set the receive timeout on %s failed, error: %w", name, err)
+	}
+
+	return &Conn{FD: fd, Addr: addr, MAC: link.HardwareAddr}, nil
+}
+
+// Send sends the frame out of the link
+func (c *Conn) Send(frame []byte) error {
+	return unix.Sendto(c.FD, frame, 0, c.Addr)
+}
+
+// Receive reads a frame received by the link into the buffer and returns its length. It returns 0 if no frame is
+// received before the timeout. The frames sent by the link are skipped, including the ones sent by this socket.
+func (c *Conn) Receive(buf []byte) (int, error) {
+	n, from, err := unix.Recvfrom(c.FD, buf, 0)
+	if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if ll, ok := from.(*unix.SockaddrLinklayer); ok && ll.Pkttype == unix.PACKET_OUTGOING {
+		return 0, nil
+	}
+
+	return n, nil
+}
+
+func (c *Conn) Close() error {
+	return unix.Close(c.FD)
+}
+
+// AppendEthHeader appends the Ethernet header to the frame, with the 802.1Q tag of the VID unless it's 0
+func AppendEthHeader(frame []byte, dst, src net.HardwareAddr, vid, etherType uint16) []byte {
+	frame = append(frame, dst...)
+	frame = append(frame, src...)
+	if vid != 0 {
+		frame = binary.BigEndian.AppendUint16(frame, VlanEtherType)
+		frame = binary.BigEndian.AppendUint16(frame, vid&0x0fff)
+	}
+	return binary.BigEndian.AppendUint16(frame, etherType)
+}
+
+// ParseFrame returns the ethertype and the payload of the frame, the VLAN tag may have been stripped by the NIC
+func ParseFrame(frame []byte) (uint16, []byte) {
+	offset := etherTypeOffset
+	if len(frame) < offset+2 {
+		return 0, nil
+	}
+	if binary.BigEndian.Uint16(frame[offset:]) == VlanEtherType {
+		offset += 4
+		if len(frame) < offset+2 {
+			return 0, nil
+		}
+	}
+
+	return binary.BigEndian.Uint16(frame[offset:]), frame[offset+2:]
+}
+
+// Pad pads the frame with zeros to the minimum length
+func Pad(frame []byte) []byte {
+	for len(frame) < MinFrameLen {
+		frame = append(frame, 0)
+	}
+	return frame
+}
+
+// Htons converts the value to the network byte order as the packet socket expects
+func Htons(v uint16) uint16 {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return binary.NativeEndian.Uint16(b)
+}
@@ -0,0 +1,40 @@
+package packet
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthHeader(t *testing.T) {
+	src := net.HardwareAddr{0x02, 0, 0, 0, 0, 1}
+	payload := []byte("payload")
+
+	untagged := Pad(append(AppendEthHeader(nil, Broadcast, src, 0, 0x88b5), payload...))
+	assert.Len(t, untagged, MinFrameLen)
+	assert.Equal(t, Broadcast, net.HardwareAddr(untagged[:6]))
+	assert.Equal(t, src, net.HardwareAddr(untagged[6:12]))
+	etherType, got := ParseFrame(untagged)
+	assert.Equal(t, uint16(0x88b5), etherType)
+	assert.Equal(t, payload, got[:len(payload)])
+
+	tagged := append(AppendEthHeader(nil, Broadcast, src, 100, 0x88b5), payload...)
+	assert.Equal(t, uint16(VlanEtherType), binary.BigEndian.Uint16(tagged[12:]))
+	assert.Equal(t, uint16(100), binary.BigEndian.Uint16(tagged[14:]))
+	etherType, got = ParseFrame(tagged)
+	assert.Equal(t, uint16(0x88b5), etherType)
+	assert.Equal(t, payload, got)
+
+	etherType, got = ParseFrame(tagged[:15])
+	assert.Zero(t, etherType, "a truncated tagged frame has no ethertype")
+	assert.Nil(t, got)
+}
+
+func TestHtons(t *testing.T) {
+	v := Htons(0x0003)
+	b := make([]byte, 2)
+	binary.NativeEndian.PutUint16(b, v)
+	assert.Equal(t, []byte{0x00, 0x03}, b, "the value is laid out in the network byte order in memory")
+}
@@ -0,0 +1,263 @@
+// Package preflight validates a vlanconfig against the NICs of the local node before it's applied, i.e. whether the
+// NICs exist, have carrier, negotiate the expected speed, support the MTU and are not used by something else.
+package preflight
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vishvananda/netlink"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// Result is the result of a check
+type Result string
+
+const (
+	Pass Result = "pass"
+	// Warn means the vlanconfig can be applied but the uplink may not work as expected
+	Warn Result = "warn"
+	Fail Result = "fail"
+)
+
+const (
+	CheckExistence = "existence"
+	CheckCarrier   = "carrier"
+	CheckSpeed     = "speed"
+	CheckMTU       = "mtu"
+	CheckConflict  = "conflict"
+
+	// the target of the checks on the whole uplink rather than a NIC
+	targetUplink = "uplink"
+)
+
+// Check is the result of a check on a NIC or the uplink
+type Check struct {
+	Name    string `json:"name"`
+	Target  string `json:"target"`
+	Result  Result `json:"result"`
+	Message string `json:"message,omitempty"`
+}
+
+// Report is the result of all checks of a vlanconfig on a node
+type Report struct {
+	Node           string  `json:"node"`
+	VlanConfig     string  `json:"vlanConfig"`
+	ClusterNetwork string  `json:"clusterNetwork"`
+	Checks         []Check `json:"checks"`
+	// false if any check fails, the warnings don't fail the report
+	Passed bool `json:"passed"`
+}
+
+// NIC is what the checks need to know about a NIC of the node
+type NIC struct {
+	Name  string
+	Found bool
+	Type  string
+	Up    bool
+	// 0 if unknown
+	MinMTU, MaxMTU int
+	// in Mb/s, 0 if unknown
+	Speed, MaxSpeed uint32
+	// the name of the master link, empty if the NIC is not enslaved
+	Master     string
+	HasAddress bool
+}
+
+// Run checks the vlanconfig against the NICs of the local node
+func Run(node string, vc *networkv1.VlanConfig) (*Report, error) {
+	if vc.Spec.ClusterNetwork == "" {
+		return nil, errors.New("cluster network of the vlanconfig is not specified")
+	}
+	if len(vc.Spec.Uplink.NICs) == 0 {
+		return nil, errors.New("NICs of the vlanconfig are not specified")
+	}
+
+	names := uplinkNICs(vc)
+	nics := make([]NIC, 0, len(names))
+	for _, name := range names {
+		nic, err := collectNIC(name)
+		if err != nil {
+			return nil, err
+		}
+		nics = append(nics, nic)
+	}
+
+	return Evaluate(node, vc, nics), nil
+}
+
+func collectNIC(name string) (NIC, error) {
+	nic := NIC{Name: name}
+	l, err := network.Handle().LinkByName(name)
+	if errors.Is(network.Classify(err), network.ErrLinkNotFound) {
+		return nic, nil
+	} else if err != nil {
+		return nic, fmt.Errorf("get link %s failed, error: %w", name, err)
+	}
+
+	nic.Found = true
+	nic.Type = l.Type()
+	nic.Up = l.Attrs().OperState == netlink.OperUp
+	if masterIndex := l.Attrs().MasterIndex; masterIndex != 0 {
+		master, err := network.Handle().LinkByIndex(masterIndex)
+		if err != nil {
+			return nic, fmt.Errorf("get master of link %s failed, error: %w", name, network.Classify(err))
+		}
+		nic.Master = master.Attrs().Name
+	}
+	addrs, err := network.Handle().AddrList(l, netlink.FAMILY_ALL)
+	if err != nil {
+		return nic, fmt.Errorf("list addresses of link %s failed, error: %w", name, err)
+	}
+	for _, addr := range addrs {
+		// the IPv6 link-local address is assigned automatically
+		if !addr.IP.IsLinkLocalUnicast() {
+			nic.HasAddress = true
+			break
+		}
+	}
+	// the MTU range and the speed are optional, some drivers don't report them
+	if nic.MinMTU, nic.MaxMTU, err = iface.MTURange(name); err != nil {
+		nic.MinMTU, nic.MaxMTU = 0, 0
+	}
+	if nic.Speed, nic.MaxSpeed, err = iface.LinkSpeed(name); err != nil {
+		nic.Speed, nic.MaxSpeed = 0, 0
+	}
+
+	return nic, nil
+}
+
+// Evaluate checks the vlanconfig against the NICs
+func Evaluate(node string, vc *networkv1.VlanConfig, nics []NIC) *Report {
+	r := &Report{
+		Node:           node,
+		VlanConfig:     vc.Name,
+		ClusterNetwork: vc.Spec.ClusterNetwork,
+		Checks:         make([]Check, 0),
+	}
+	mtu := utils.MTUDefaultTo(utils.GetMTUFromVlanConfig(vc))
+	masters := allowedMasters(vc)
+
+	up := 0
+	speeds := make(map[uint32]bool)
+	for _, nic := range nics {
+		if !nic.Found {
+			r.add(CheckExistence, nic.Name, Fail, "NIC not found")
+			continue
+		}
+		if nic.Type != iface.TypeDevice {
+			r.add(CheckExistence, nic.Name, Warn, fmt.Sprintf("%s is a %s link rather than a physical NIC", nic.Name, nic.Type))
+		} else {
+			r.add(CheckExistence, nic.Name, Pass, "")
+		}
+
+		if nic.Up {
+			up++
+			r.add(CheckCarrier, nic.Name, Pass, "")
+		} else {
+			r.add(CheckCarrier, nic.Name, Warn, "no carrier")
+		}
+
+		switch {
+		case nic.Speed == 0:
+			r.add(CheckSpeed, nic.Name, Warn, "speed unknown")
+		case nic.Speed < nic.MaxSpeed:
+			speeds[nic.Speed] = true
+			r.add(CheckSpeed, nic.Name, Warn, fmt.Sprintf("links at %dMb/s below %dMb/s", nic.Speed, nic.MaxSpeed))
+		default:
+			speeds[nic.Speed] = true
+			r.add(CheckSpeed, nic.Name, Pass, fmt.Sprintf("%dMb/s", nic.Speed))
+		}
+
+		switch {
+		case nic.MaxMTU == 0:
+			r.add(CheckMTU, nic.Name, Warn, fmt.Sprintf("MTU range unknown, MTU %d may not be supported", mtu))
+		case mtu < nic.MinMTU || mtu > nic.MaxMTU:
+			r.add(CheckMTU, nic.Name, Fail, fmt.Sprintf("MTU %d out of range [%d, %d]", mtu, nic.MinMTU, nic.MaxMTU))
+		default:
+			r.add(CheckMTU, nic.Name, Pass, fmt.Sprintf("MTU %d in range [%d, %d]", mtu, nic.MinMTU, nic.MaxMTU))
+		}
+
+		switch {
+		case nic.Master != "" && !slices.Contains(masters, nic.Master):
+			r.add(CheckConflict, nic.Name, Fail, fmt.Sprintf("enslaved by %s", nic.Master))
+		case nic.HasAddress:
+			r.add(CheckConflict, nic.Name, Fail, "has IP addresses, it may be used by the management network")
+		default:
+			r.add(CheckConflict, nic.Name, Pass, "")
+		}
+	}
+
+	// the NICs not found are already reported
+	if found := countFound(nics); found > 0 && up == 0 {
+		r.add(CheckCarrier, targetUplink, Fail, "none of the NICs has carrier")
+	}
+	if len(speeds) > 1 {
+		r.add(CheckSpeed, targetUplink, Warn, "the NICs negotiate different speeds")
+	}
+
+	r.Passed = !slices.ContainsFunc(r.Checks, func(c Check) bool { return c.Result == Fail })
+
+	return r
+}
+
+// String renders the report as a table for humans
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "vlanconfig %s of cluster network %s on node %s\n", r.VlanConfig, r.ClusterNetwork, r.Node)
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RESULT\tCHECK\tTARGET\tMESSAGE")
+	for _, c := range r.Checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", strings.ToUpper(string(c.Result)), c.Name, c.Target, c.Message)
+	}
+	_ = w.Flush()
+	if r.Passed {
+		b.WriteString("preflight passed")
+	} else {
+		b.WriteString("preflight failed")
+	}
+
+	return b.String()
+}
+
+func (r *Report) add(name, target string, result Result, message string) {
+	r.Checks = append(r.Checks, Check{Name: name, Target: target, Result: result, Message: message})
+}
+
+// allowedMasters returns the links the NICs may be enslaved by, i.e. the NICs are applied with the vlanconfig already
+func allowedMasters(vc *networkv1.VlanConfig) []string {
+	masters := []string{
+		utils.GenerateBondName(vc.Spec.ClusterNetwork),
+		utils.GenerateFabricBBondName(vc.Spec.ClusterNetwork),
+	}
+	if vc.Spec.Uplink.SharedBond != nil {
+		masters = append(masters, vc.Spec.Uplink.SharedBond.Name)
+	}
+
+	return masters
+}
+
+func uplinkNICs(vc *networkv1.VlanConfig) []string {
+	if vc.Spec.Uplink.FabricB == nil {
+		return vc.Spec.Uplink.NICs
+	}
+
+	return append(slices.Clone(vc.Spec.Uplink.NICs), vc.Spec.Uplink.FabricB.NICs...)
+}
+
+func countFound(nics []NIC) int {
+	n := 0
+	for _, nic := range nics {
+		if nic.Found {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,165 @@
+package preflight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func newVlanConfig(mtu int, nics ...string) *networkv1.VlanConfig {
+	return &networkv1.VlanConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc1"},
+		Spec: networkv1.VlanConfigSpec{
+			ClusterNetwork: "cn1",
+			Uplink: networkv1.Uplink{
+				NICs:      nics,
+				LinkAttrs: &networkv1.LinkAttrs{MTU: mtu},
+			},
+		},
+	}
+}
+
+func healthyNIC(name string) NIC {
+	return NIC{Name: name, Found: true, Type: "device", Up: true, MinMTU: 68, MaxMTU: 9216, Speed: 10000, MaxSpeed: 10000}
+}
+
+func resultOf(r *Report, name, target string) Result {
+	for _, c := range r.Checks {
+		if c.Name == name && c.Target == target {
+			return c.Result
+		}
+	}
+	return ""
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		vc       *networkv1.VlanConfig
+		nics     []NIC
+		passed   bool
+		expected map[[2]string]Result
+	}{
+		{
+			name:   "healthy NICs",
+			vc:     newVlanConfig(9000, "eth1", "eth2"),
+			nics:   []NIC{healthyNIC("eth1"), healthyNIC("eth2")},
+			passed: true,
+			expected: map[[2]string]Result{
+				{CheckExistence, "eth1"}: Pass,
+				{CheckCarrier, "eth2"}:   Pass,
+				{CheckMTU, "eth1"}:       Pass,
+				{CheckConflict, "eth2"}:  Pass,
+			},
+		},
+		{
+			name:     "NIC not found",
+			vc:       newVlanConfig(0, "eth1", "eth9"),
+			nics:     []NIC{healthyNIC("eth1"), {Name: "eth9"}},
+			passed:   false,
+			expected: map[[2]string]Result{{CheckExistence, "eth9"}: Fail, {CheckCarrier, "eth9"}: ""},
+		},
+		{
+			name: "one NIC without carrier is a warning",
+			vc:   newVlanConfig(0, "eth1", "eth2"),
+			nics: func() []NIC {
+				down := healthyNIC("eth2")
+				down.Up = false
+				return []NIC{healthyNIC("eth1"), down}
+			}(),
+			passed:   true,
+			expected: map[[2]string]Result{{CheckCarrier, "eth2"}: Warn, {CheckCarrier, targetUplink}: ""},
+		},
+		{
+			name: "no NIC has carrier",
+			vc:   newVlanConfig(0, "eth1"),
+			nics: func() []NIC {
+				down := healthyNIC("eth1")
+				down.Up = false
+				return []NIC{down}
+			}(),
+			passed:   false,
+			expected: map[[2]string]Result{{CheckCarrier, targetUplink}: Fail},
+		},
+		{
+			name: "MTU out of range",
+			vc:   newVlanConfig(9000, "eth1"),
+			nics: func() []NIC {
+				nic := healthyNIC("eth1")
+				nic.MaxMTU = 1500
+				return []NIC{nic}
+			}(),
+			passed:   false,
+			expected: map[[2]string]Result{{CheckMTU, "eth1"}: Fail},
+		},
+		{
+			name: "unknown MTU range is a warning",
+			vc:   newVlanConfig(9000, "eth1"),
+			nics: func() []NIC {
+				nic := healthyNIC("eth1")
+				nic.MinMTU, nic.MaxMTU = 0, 0
+				return []NIC{nic}
+			}(),
+			passed:   true,
+			expected: map[[2]string]Result{{CheckMTU, "eth1"}: Warn},
+		},
+		{
+			name: "degraded and mismatched speeds",
+			vc:   newVlanConfig(0, "eth1", "eth2"),
+			nics: func() []NIC {
+				slow := healthyNIC("eth2")
+				slow.Speed = 1000
+				return []NIC{healthyNIC("eth1"), slow}
+			}(),
+			passed:   true,
+			expected: map[[2]string]Result{{CheckSpeed, "eth1"}: Pass, {CheckSpeed, "eth2"}: Warn, {CheckSpeed, targetUplink}: Warn},
+		},
+		{
+			name: "enslaved by the bond of the cluster network",
+			vc:   newVlanConfig(0, "eth1"),
+			nics: func() []NIC {
+				nic := healthyNIC("eth1")
+				nic.Master = "cn1-bo"
+				return []NIC{nic}
+			}(),
+			passed:   true,
+			expected: map[[2]string]Result{{CheckConflict, "eth1"}: Pass},
+		},
+		{
+			name: "enslaved by another cluster network",
+			vc:   newVlanConfig(0, "eth1"),
+			nics: func() []NIC {
+				nic := healthyNIC("eth1")
+				nic.Master = "cn2-bo"
+				return []NIC{nic}
+			}(),
+			passed:   false,
+			expected: map[[2]string]Result{{CheckConflict, "eth1"}: Fail},
+		},
+		{
+			name: "NIC with IP addresses",
+			vc:   newVlanConfig(0, "eth1"),
+			nics: func() []NIC {
+				nic := healthyNIC("eth1")
+				nic.HasAddress = true
+				return []NIC{nic}
+			}(),
+			passed:   false,
+			expected: map[[2]string]Result{{CheckConflict, "eth1"}: Fail},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := Evaluate("node1", tc.vc, tc.nics)
+			assert.Equal(t, tc.passed, r.Passed)
+			assert.Equal(t, "cn1", r.ClusterNetwork)
+			for key, result := range tc.expected {
+				assert.Equal(t, result, resultOf(r, key[0], key[1]), "check %s of %s", key[0], key[1])
+			}
+		})
+	}
+}
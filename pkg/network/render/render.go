@@ -0,0 +1,313 @@
+// Package render computes the host interfaces the agent is expected to set up on every node from the manifests of
+// the cluster networks, vlanconfigs and NADs without touching the system, e.g. to review a change in a PR or to
+// analyze the manifests of a support bundle. It relies on the same matching and VLAN ID rules as the agent.
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/localarea"
+	"github.com/harvester/harvester-network-controller/pkg/matcher"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// Manifests are the objects the layout is rendered from, the other kinds are ignored
+type Manifests struct {
+	ClusterNetworks []*networkv1.ClusterNetwork
+	VlanConfigs     []*networkv1.VlanConfig
+	NADs            []*nadv1.NetworkAttachmentDefinition
+	// the nodes the vlanconfigs without the matched nodes annotation are matched against by their node selectors
+	Nodes []*corev1.Node
+}
+
+// Layout is the host interfaces expected on the nodes
+type Layout struct {
+	Nodes []NodeLayout `json:"nodes"`
+	// the vlanconfigs matching no node
+	Unmatched []string `json:"unmatched,omitempty"`
+}
+
+// NodeLayout is the host interfaces expected on a node
+type NodeLayout struct {
+	Node            string                 `json:"node"`
+	ClusterNetworks []ClusterNetworkLayout `json:"clusterNetworks"`
+}
+
+// ClusterNetworkLayout is the bridge of a cluster network and its uplink on a node
+type ClusterNetworkLayout struct {
+	Name       string `json:"name"`
+	VlanConfig string `json:"vlanConfig"`
+	Bridge     string `json:"bridge"`
+	// the bonds of fabric A and B
+	Bonds []BondLayout `json:"bonds"`
+	// the VLAN sub-interface of the shared bond or of the service VLAN the bridge is attached to
+	VlanSubInterface string `json:"vlanSubInterface,omitempty"`
+	MTU              int    `json:"mtu"`
+	// the VLAN IDs programmed on the uplink, e.g. "100-102,200"
+	VIDs string `json:"vids,omitempty"`
+	// the vlanconfigs of the cluster network overlapping on the node which don't take effect
+	Overridden []string `json:"overridden,omitempty"`
+	// the cluster network doesn't exist, the vlanconfig is kept but the VLAN IDs are unknown
+	MissingClusterNetwork bool `json:"missingClusterNetwork,omitempty"`
+}
+
+// BondLayout is a bond of the uplink
+type BondLayout struct {
+	Name         string   `json:"name"`
+	Mode         string   `json:"mode"`
+	Miimon       int      `json:"miimon"`
+	NICs         []string `json:"nics"`
+	BackupNICs   []string `json:"backupNICs,omitempty"`
+	HardwareAddr string   `json:"hardwareAddr,omitempty"`
+	Shared       bool     `json:"shared,omitempty"`
+	// the hash policy in the modes balancing by it
+	XmitHashPolicy string `json:"xmitHashPolicy,omitempty"`
+	// the LACP rate in the mode 802.3ad
+	LacpRate string `json:"lacpRate,omitempty"`
+	// the ARP monitoring replacing miimon
+	ArpInterval  int      `json:"arpInterval,omitempty"`
+	ArpIPTargets []string `json:"arpIPTargets,omitempty"`
+	ArpValidate  string   `json:"arpValidate,omitempty"`
+}
+
+// Decode reads the objects from the YAML or JSON documents, the lists, e.g. the output of kubectl get -o yaml, are
+// flattened
+func Decode(r io.Reader) (*Manifests, error) {
+	m := &Manifests{}
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if err := m.add(obj); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Manifests) add(obj *unstructured.Unstructured) error {
+	if obj.IsList() {
+		return obj.EachListItem(func(item runtime.Object) error {
+			u, ok := item.(*unstructured.Unstructured)
+			if !ok {
+				return fmt.Errorf("unexpected list item %T", item)
+			}
+			return m.add(u)
+		})
+	}
+
+	var err error
+	switch obj.GetKind() {
+	case "ClusterNetwork":
+		cn := &networkv1.ClusterNetwork{}
+		err = convert(obj, cn)
+		m.ClusterNetworks = append(m.ClusterNetworks, cn)
+	case "VlanConfig":
+		vc := &networkv1.VlanConfig{}
+		err = convert(obj, vc)
+		m.VlanConfigs = append(m.VlanConfigs, vc)
+	case "NetworkAttachmentDefinition":
+		nad := &nadv1.NetworkAttachmentDefinition{}
+		err = convert(obj, nad)
+		m.NADs = append(m.NADs, nad)
+	case "Node":
+		node := &corev1.Node{}
+		err = convert(obj, node)
+		m.Nodes = append(m.Nodes, node)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %s, error: %w", obj.GetKind(), obj.GetName(), err)
+	}
+
+	return nil
+}
+
+func convert(obj *unstructured.Unstructured, out interface{}) error {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(out)
+}
+
+// Render returns the layout of the nodes sorted by the name. The vlanconfigs inherit the uplink defaults of their
+// cluster networks as the webhook does, and the topology overrides matching the labels of the nodes are applied. Without the vlanstatuses, the oldest of the overlapping vlanconfigs takes
+// effect on a node, while the agent keeps the one having taken effect.
+func Render(m *Manifests) (*Layout, error) {
+	cns := make(map[string]*networkv1.ClusterNetwork, len(m.ClusterNetworks))
+	for _, cn := range m.ClusterNetworks {
+		cns[cn.Name] = cn
+	}
+	nodeLabels := make(map[string]map[string]string, len(m.Nodes))
+	for _, node := range m.Nodes {
+		nodeLabels[node.Name] = node.Labels
+	}
+
+	layout := &Layout{Nodes: []NodeLayout{}}
+	// node -> cluster network -> candidates
+	candidates := make(map[string]map[string][]*networkv1.VlanConfig)
+	for _, vc := range m.VlanConfigs {
+		if vc.DeletionTimestamp != nil {
+			continue
+		}
+		nodes, err := matchedNodes(vc, nodeLabels)
+		if err != nil {
+			return nil, fmt.Errorf("vlanconfig %s: %w", vc.Name, err)
+		}
+		if len(nodes) == 0 {
+			layout.Unmatched = append(layout.Unmatched, vc.Name)
+		}
+		for _, node := range nodes {
+			if candidates[node] == nil {
+				candidates[node] = make(map[string][]*networkv1.VlanConfig)
+			}
+			candidates[node][vc.Spec.ClusterNetwork] = append(candidates[node][vc.Spec.ClusterNetwork], vc)
+		}
+	}
+	sort.Strings(layout.Unmatched)
+
+	for node, byCN := range candidates {
+		nl := NodeLayout{Node: node, ClusterNetworks: make([]ClusterNetworkLayout, 0, len(byCN))}
+		for cnName, vcs := range byCN {
+			cnl, err := renderClusterNetwork(cns[cnName], vcs, m.NADs, nodeLabels[node])
+			if err != nil {
+				return nil, fmt.Errorf("cluster network %s on node %s: %w", cnName, node, err)
+			}
+			nl.ClusterNetworks = append(nl.ClusterNetworks, *cnl)
+		}
+		sort.Slice(nl.ClusterNetworks, func(i, j int) bool { return nl.ClusterNetworks[i].Name < nl.ClusterNetworks[j].Name })
+		layout.Nodes = append(layout.Nodes, nl)
+	}
+	sort.Slice(layout.Nodes, func(i, j int) bool { return layout.Nodes[i].Node < layout.Nodes[j].Node })
+
+	return layout, nil
+}
+
+// matchedNodes prefers the matched nodes recorded by the webhook, which the agent follows, and falls back to the node
+// selector if the vlanconfig isn't admitted yet
+func matchedNodes(vc *networkv1.VlanConfig, nodeLabels map[string]map[string]string) ([]string, error) {
+	if _, ok := vc.Annotations[utils.KeyMatchedNodes]; ok {
+		return matcher.MatchedNodesOf(vc)
+	}
+	return matcher.MatchedNodes(vc.Spec.NodeSelector, nodeLabels), nil
+}
+
+func renderClusterNetwork(cn *networkv1.ClusterNetwork, candidates []*networkv1.VlanConfig,
+	nads []*nadv1.NetworkAttachmentDefinition, labels map[string]string) (*ClusterNetworkLayout, error) {
+	vc := utils.ApplyTopologyOverride(matcher.Resolve(candidates, ""), labels).DeepCopy()
+	cnName := vc.Spec.ClusterNetwork
+	cnl := &ClusterNetworkLayout{
+		Name:       cnName,
+		VlanConfig: vc.Name,
+		Bridge:     utils.GetBridgeNameOfClusterNetwork(cnName),
+	}
+	for _, other := range candidates {
+		if other.Name != vc.Name {
+			cnl.Overridden = append(cnl.Overridden, other.Name)
+		}
+	}
+	sort.Strings(cnl.Overridden)
+
+	if cn == nil {
+		cnl.MissingClusterNetwork = true
+	} else {
+		vc.Spec.Uplink, _ = utils.InheritUplinkDefaults(nil, vc, cn.Spec.UplinkDefaults)
+		onCN := make([]*nadv1.NetworkAttachmentDefinition, 0)
+		for _, nad := range nads {
+			if nad.Labels[utils.KeyClusterNetworkLabel] == cnName && nad.DeletionTimestamp == nil {
+				onCN = append(onCN, nad)
+			}
+		}
+		vids, err := localarea.NewSources(localarea.NewNadListSource(onCN), localarea.StaticSource).VlanIDSetOf(cn)
+		if err != nil {
+			return nil, err
+		}
+		cnl.VIDs = utils.FormatVIDRanges(vids.VIDs())
+	}
+	cnl.MTU = utils.MTUDefaultTo(utils.GetMTUFromVlanConfig(vc))
+
+	uplink := vc.Spec.Uplink
+	if sharedBond := uplink.SharedBond; sharedBond != nil {
+		bond := renderBond(vc, sharedBond.Name, uplink.NICs, uplink.BackupNICs)
+		bond.Shared = true
+		cnl.Bonds = []BondLayout{bond}
+		cnl.VlanSubInterface = utils.GetClusterNetworkBrVlanDevice(sharedBond.Name, sharedBond.VID)
+		return cnl, nil
+	}
+	cnl.Bonds = []BondLayout{renderBond(vc, cnName+utils.BondSuffix, uplink.NICs, uplink.BackupNICs)}
+	if uplink.ServiceVLAN != 0 {
+		cnl.VlanSubInterface = utils.GetClusterNetworkBrVlanDevice(cnName+utils.BondSuffix, uplink.ServiceVLAN)
+	}
+	if uplink.FabricB != nil {
+		cnl.Bonds = append(cnl.Bonds, renderBond(vc, utils.GenerateFabricBBondName(cnName), uplink.FabricB.NICs, nil))
+	}
+
+	return cnl, nil
+}
+
+// renderBond follows the defaults of the bond the agent creates
+func renderBond(vc *networkv1.VlanConfig, name string, nics, backupNICs []string) BondLayout {
+	bond := BondLayout{
+		Name:       name,
+		Mode:       string(networkv1.BondMoDeActiveBackup),
+		Miimon:     utils.DefaultValueMiimon,
+		NICs:       nics,
+		BackupNICs: backupNICs,
+	}
+	if opts := vc.Spec.Uplink.BondOptions; opts != nil {
+		if opts.Mode != "" {
+			bond.Mode = string(opts.Mode)
+		}
+		if opts.Miimon != -1 {
+			bond.Miimon = opts.Miimon
+		}
+	}
+	if utils.IsHashingBondMode(networkv1.BondMode(bond.Mode)) {
+		bond.XmitHashPolicy = string(networkv1.XmitHashPolicyLayer2)
+		if opts := vc.Spec.Uplink.BondOptions; opts != nil && opts.XmitHashPolicy != "" {
+			bond.XmitHashPolicy = string(opts.XmitHashPolicy)
+		}
+	}
+	if networkv1.BondMode(bond.Mode) == networkv1.BondMode8023AD {
+		bond.LacpRate = string(networkv1.LacpRateSlow)
+		if opts := vc.Spec.Uplink.BondOptions; opts != nil && opts.LacpRate != "" {
+			bond.LacpRate = string(opts.LacpRate)
+		}
+	}
+	if opts := vc.Spec.Uplink.BondOptions; opts != nil && opts.ArpInterval > 0 {
+		bond.Miimon = 0
+		bond.ArpInterval = opts.ArpInterval
+		bond.ArpIPTargets = opts.ArpIPTargets
+		bond.ArpValidate = string(networkv1.ArpValidateNone)
+		if opts.ArpValidate != "" {
+			bond.ArpValidate = string(opts.ArpValidate)
+		}
+	}
+	if attrs := vc.Spec.Uplink.LinkAttrs; attrs != nil && len(attrs.HardwareAddr) != 0 {
+		bond.HardwareAddr = attrs.HardwareAddr.String()
+	}
+
+	return bond
+}
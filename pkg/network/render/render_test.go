@@ -0,0 +1,133 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testManifests = `
+apiVersion: network.harvesterhci.io/v1beta1
+kind: ClusterNetwork
+metadata:
+  name: data
+spec:
+  uplinkDefaults:
+    linkAttributes:
+      mtu: 9000
+    bondOptions:
+      mode: 802.3ad
+      miimon: -1
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: network.harvesterhci.io/v1beta1
+  kind: VlanConfig
+  metadata:
+    name: data-all
+    creationTimestamp: "2024-01-01T00:00:00Z"
+  spec:
+    clusterNetwork: data
+    uplink:
+      nics: [eth1, eth2]
+    topologyOverrides:
+    - values: [zone2]
+      nics: [ens1f0, ens1f1]
+- apiVersion: network.harvesterhci.io/v1beta1
+  kind: VlanConfig
+  metadata:
+    name: data-rack2
+    creationTimestamp: "2024-02-01T00:00:00Z"
+    annotations:
+      network.harvesterhci.io/matched-nodes: '["node2"]'
+  spec:
+    clusterNetwork: data
+    uplink:
+      nics: [eth3]
+      linkAttributes:
+        mtu: 1500
+- apiVersion: network.harvesterhci.io/v1beta1
+  kind: VlanConfig
+  metadata:
+    name: orphan
+  spec:
+    clusterNetwork: data
+    nodeSelector:
+      rack: "9"
+---
+apiVersion: k8s.cni.cncf.io/v1
+kind: NetworkAttachmentDefinition
+metadata:
+  name: vlan100
+  namespace: default
+  labels:
+    network.harvesterhci.io/clusternetwork: data
+spec:
+  config: '{"cniVersion":"0.3.1","name":"vlan100","type":"bridge","bridge":"data-br","promiscMode":true,"vlan":100,"ipam":{}}'
+---
+apiVersion: k8s.cni.cncf.io/v1
+kind: NetworkAttachmentDefinition
+metadata:
+  name: vlan101
+  namespace: default
+  labels:
+    network.harvesterhci.io/clusternetwork: data
+spec:
+  config: '{"cniVersion":"0.3.1","name":"vlan101","type":"bridge","bridge":"data-br","promiscMode":true,"vlan":101,"ipam":{}}'
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: node1
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: node2
+  labels:
+    topology.kubernetes.io/zone: zone2
+`
+
+func TestRender(t *testing.T) {
+	m, err := Decode(strings.NewReader(testManifests))
+	assert.NoError(t, err)
+	assert.Len(t, m.ClusterNetworks, 1)
+	assert.Len(t, m.VlanConfigs, 3)
+	assert.Len(t, m.NADs, 2)
+	assert.Len(t, m.Nodes, 2)
+
+	layout, err := Render(m)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"orphan"}, layout.Unmatched)
+	assert.Len(t, layout.Nodes, 2)
+
+	node1 := layout.Nodes[0].ClusterNetworks
+	assert.Equal(t, "node1", layout.Nodes[0].Node)
+	assert.Len(t, node1, 1)
+	assert.Equal(t, "data-all", node1[0].VlanConfig)
+	assert.Equal(t, "data-br", node1[0].Bridge)
+	assert.Equal(t, 9000, node1[0].MTU, "the default MTU of the cluster network is inherited")
+	assert.Equal(t, "100-101", node1[0].VIDs)
+	assert.Equal(t, []BondLayout{{Name: "data-bo", Mode: "802.3ad", Miimon: 100, NICs: []string{"eth1", "eth2"}, XmitHashPolicy: "layer2", LacpRate: "slow"}}, node1[0].Bonds)
+
+	// the older vlanconfig takes effect on the node both match
+	node2 := layout.Nodes[1].ClusterNetworks
+	assert.Equal(t, "node2", layout.Nodes[1].Node)
+	assert.Equal(t, "data-all", node2[0].VlanConfig)
+	assert.Equal(t, []string{"data-rack2"}, node2[0].Overridden)
+	assert.Equal(t, []string{"ens1f0", "ens1f1"}, node2[0].Bonds[0].NICs, "the NICs are overridden in zone2")
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	_, err := Decode(strings.NewReader(`
+apiVersion: network.harvesterhci.io/v1beta1
+kind: VlanConfig
+metadata:
+  name: typo
+spec:
+  clusterNetwrok: data
+`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,53 @@
+package network
+
+import (
+	"errors"
+
+	"github.com/containernetworking/plugins/pkg/netlinksafe"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+)
+
+// maxDumpAttempts is the same limit of the attempts as netlinksafe's
+const maxDumpAttempts = 5
+
+// SafeHandle is the netlink handle whose dumps are retried if they are interrupted, netlink returns ErrDumpInterrupted
+// if the dumped objects change during the dump. netlinksafe.Handle retries the dumps of the links, routes, qdiscs,
+// filters and rules, SafeHandle adds the ones netlinksafe only retries through the package functions or not at all.
+// As netlinksafe does, the possibly inconsistent result of the last attempt is returned rather than the error.
+type SafeHandle struct {
+	netlinksafe.Handle
+}
+
+// AddrList calls netlink.Handle.AddrList, retrying if necessary
+func (h *SafeHandle) AddrList(link netlink.Link, family int) ([]netlink.Addr, error) {
+	return retryDump("addresses", func() ([]netlink.Addr, error) {
+		return h.Handle.Handle.AddrList(link, family)
+	})
+}
+
+// BridgeVlanList calls netlink.Handle.BridgeVlanList, retrying if necessary
+func (h *SafeHandle) BridgeVlanList() (map[int32][]*nl.BridgeVlanInfo, error) {
+	return retryDump("bridge VLANs", h.Handle.Handle.BridgeVlanList)
+}
+
+// NeighList calls netlink.Handle.NeighList, retrying if necessary
+func (h *SafeHandle) NeighList(linkIndex, family int) ([]netlink.Neigh, error) {
+	return retryDump("neighbors", func() ([]netlink.Neigh, error) {
+		return h.Handle.Handle.NeighList(linkIndex, family)
+	})
+}
+
+func retryDump[T any](what string, dump func() (T, error)) (T, error) {
+	var result T
+	var err error
+	for attempt := 0; attempt < maxDumpAttempts; attempt++ {
+		if result, err = dump(); !errors.Is(err, netlink.ErrDumpInterrupted) {
+			return result, err
+		}
+	}
+	logrus.Warnf("the dump of the %s was interrupted after %d attempts, use the possibly inconsistent result", what,
+		maxDumpAttempts)
+	return result, nil
+}
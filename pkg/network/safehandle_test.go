@@ -0,0 +1,63 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestRetryDump(t *testing.T) {
+	tests := []struct {
+		name             string
+		interruptions    int
+		err              error
+		expectedAttempts int
+		expectErr        bool
+	}{
+		{
+			name:             "not interrupted",
+			expectedAttempts: 1,
+		},
+		{
+			name:             "interrupted once",
+			interruptions:    1,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "interrupted in every attempt",
+			interruptions:    maxDumpAttempts,
+			expectedAttempts: maxDumpAttempts,
+		},
+		{
+			name:             "failed",
+			err:              unix.ENOBUFS,
+			expectedAttempts: 1,
+			expectErr:        true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			result, err := retryDump("tests", func() (int, error) {
+				attempts++
+				if attempts <= tc.interruptions {
+					return attempts, fmt.Errorf("dump failed, error: %w", netlink.ErrDumpInterrupted)
+				}
+				return attempts, tc.err
+			})
+
+			assert.Equal(t, tc.expectedAttempts, attempts)
+			assert.Equal(t, tc.expectedAttempts, result)
+			if tc.expectErr {
+				assert.True(t, errors.Is(err, tc.err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package vlan
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/announce"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// NewBridgelessVlan is NewVlan of a cluster network with the datapath Macvlan or Ipvlan. The cluster network has no
+// bridge, the macvlan or ipvlan links of the VMs are created by the CNI on the uplink bond for the untagged traffic and
+// on a VLAN sub-interface of it for each VID.
+func NewBridgelessVlan(name string) *Vlan {
+	v := &Vlan{name: name}
+	v.datapath = subInterfaces{v: v}
+	return v
+}
+
+// getBridgelessVlan returns the VLAN of the bridge-less cluster network set up on the node, the uplink is its bond
+// attached to no bridge
+func getBridgelessVlan(name string) (*Vlan, error) {
+	v := NewBridgelessVlan(name)
+
+	l, err := network.Handle().LinkByName(utils.GenerateBondName(name))
+	if err != nil {
+		return nil, network.Classify(err)
+	}
+	if l.Attrs().MasterIndex != 0 {
+		return nil, fmt.Errorf("uplink %s of the bridge-less cluster network %s is attached to a bridge: %w",
+			l.Attrs().Name, name, network.ErrLinkNotFound)
+	}
+	v.uplink = iface.NewLink(l)
+
+	return v, nil
+}
+
+// IsBridgeless returns true if the VLANs are separated by the VLAN sub-interfaces of the uplink without a bridge
+func (v *Vlan) IsBridgeless() bool {
+	_, ok := v.datapath.(subInterfaces)
+	return ok
+}
+
+// subInterfaces separates the VLAN IDs by a VLAN sub-interface of the uplink per VID, the VID 1 is the untagged traffic
+// on the uplink itself
+type subInterfaces struct {
+	v *Vlan
+}
+
+func (d subInterfaces) setup(l *iface.Link) error {
+	// the bond may be left on the bridge of a previous datapath
+	if l.Attrs().MasterIndex != 0 {
+		if err := l.SetNoMaster(); err != nil {
+			return fmt.Errorf("set %s no master failed, error: %w", l.Attrs().Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (d subInterfaces) addLocalAreas(vids []uint16) error {
+	for _, vid := range vids {
+		if vid == utils.DefaultVlanID {
+			continue
+		}
+		if _, err := d.v.uplink.EnsurePerVIDUplink(d.v.name, vid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d subInterfaces) removeLocalAreas(vids []uint16) error {
+	for _, vid := range vids {
+		if err := iface.RemovePerVIDUplink(d.v.name, vid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d subInterfaces) localAreas() (*utils.VlanIDSet, error) {
+	subs, err := iface.ListPerVIDUplinks(d.v.name, d.v.uplink.Attrs().Index)
+	if err != nil {
+		return nil, err
+	}
+
+	vis := utils.NewVlanIDSet()
+	for vid := range subs {
+		if err := vis.SetUint16VID(vid); err != nil {
+			return nil, err
+		}
+	}
+
+	return vis, nil
+}
+
+// vlanUsers returns no users, the macvlan and ipvlan links of the VMs are moved into the network namespaces of their
+// pods and are invisible on the node
+func (d subInterfaces) vlanUsers() (map[uint16][]string, error) {
+	return map[uint16][]string{}, nil
+}
+
+func (d subInterfaces) teardown() error {
+	return RemoveBridgeless(d.v.name)
+}
+
+// stations returns no stations, the MAC addresses of the macvlan and ipvlan links are not learned on the node
+func (d subInterfaces) stations() ([]announce.Station, error) {
+	return nil, nil
+}
+
+// RemoveBridgeless removes the VLAN sub-interfaces of the bridge-less cluster network left on its uplink bond
+func RemoveBridgeless(name string) error {
+	l, err := network.Handle().LinkByName(utils.GenerateBondName(name))
+	if err != nil {
+		if err = network.Classify(err); errors.Is(err, network.ErrLinkNotFound) {
+			return nil
+		}
+		return err
+	}
+	subs, err := iface.ListPerVIDUplinks(name, l.Attrs().Index)
+	if err != nil {
+		return err
+	}
+	for vid := range subs {
+		logrus.Infof("remove the VLAN sub-interface of VID %d of cluster network %s", vid, name)
+		if err := iface.RemovePerVIDUplink(name, vid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
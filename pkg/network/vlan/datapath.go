@@ -0,0 +1,140 @@
+package vlan
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/announce"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// datapath separates the VLANs of the cluster network below its uplink on the node. The Vlan drives the local areas of
+// the VLAN IDs through it, so that the controllers work alike whichever backend the cluster network has chosen.
+type datapath interface {
+	// setup attaches the uplink to the datapath
+	setup(l *iface.Link) error
+	addLocalAreas(vids []uint16) error
+	removeLocalAreas(vids []uint16) error
+	// localAreas returns the VLAN IDs set up on the node
+	localAreas() (*utils.VlanIDSet, error)
+	// vlanUsers returns the ports other than the uplink using each VID, e.g. the VM ports
+	vlanUsers() (map[uint16][]string, error)
+	// teardown removes the links of the datapath other than the uplink and the bridge
+	teardown() error
+	// stations returns the MAC addresses learned on the ports other than the uplink, e.g. the VMs, with the VID they
+	// leave the uplink on
+	stations() ([]announce.Station, error)
+}
+
+// NewVlanWithDatapath returns the Vlan of the cluster network with the datapath
+func NewVlanWithDatapath(name string, dp networkv1.Datapath) *Vlan {
+	switch dp {
+	case networkv1.DatapathBridgePerVID:
+		return NewBridgePerVIDVlan(name)
+	case networkv1.DatapathMacvlan, networkv1.DatapathIpvlan:
+		return NewBridgelessVlan(name)
+	case networkv1.DatapathOVS:
+		return NewOVSVlan(name)
+	default:
+		return NewVlan(name)
+	}
+}
+
+// GetVlanWithDatapath is GetVlan of a cluster network whose datapath is known. A bridge-less or OVS cluster network has
+// no Linux bridge to tell its datapath on the node.
+func GetVlanWithDatapath(name string, dp networkv1.Datapath) (*Vlan, error) {
+	switch dp {
+	case networkv1.DatapathMacvlan, networkv1.DatapathIpvlan:
+		return getBridgelessVlan(name)
+	case networkv1.DatapathOVS:
+		return getOVSVlan(name)
+	default:
+		return GetVlan(name)
+	}
+}
+
+// LookupVlan is GetVlan of a cluster network whose datapath may be unknown, e.g. it's removed. The cluster network is
+// taken as OVS if its bridge is an OVS bridge, and as bridge-less if it has no bridge but its bond.
+func LookupVlan(name string) (*Vlan, error) {
+	if ok, err := iface.IsOVSBridge(utils.GenerateBridgeName(name)); err != nil {
+		return nil, err
+	} else if ok {
+		return getOVSVlan(name)
+	}
+
+	v, err := GetVlan(name)
+	if errors.Is(err, network.ErrLinkNotFound) {
+		return getBridgelessVlan(name)
+	}
+	return v, err
+}
+
+// vlanFiltering trunks the VLAN IDs on the uplink port of one VLAN aware bridge
+type vlanFiltering struct {
+	v *Vlan
+}
+
+func (d vlanFiltering) setup(l *iface.Link) error {
+	return d.v.setupBridge(l)
+}
+
+func (d vlanFiltering) addLocalAreas(vids []uint16) error {
+	if err := d.v.uplink.AddBridgeVlans(vids); err != nil {
+		logrus.Warnf("failed to add vids to uplink %s, error: %s", d.v.uplink.Attrs().Name, err.Error())
+	}
+	return nil
+}
+
+func (d vlanFiltering) removeLocalAreas(vids []uint16) error {
+	if err := d.v.uplink.DelBridgeVlans(vids); err != nil {
+		logrus.Warnf("failed to remove vids from uplink %s, error: %s", d.v.uplink.Attrs().Name, err.Error())
+	}
+	return nil
+}
+
+func (d vlanFiltering) localAreas() (*utils.VlanIDSet, error) {
+	return d.v.uplink.ToVlanIDSet()
+}
+
+func (d vlanFiltering) vlanUsers() (map[uint16][]string, error) {
+	excluded := 0
+	if d.v.uplink != nil {
+		excluded = d.v.uplink.Attrs().Index
+	}
+	return d.v.bridge.PortVlanUsers(excluded)
+}
+
+func (d vlanFiltering) teardown() error {
+	return nil
+}
+
+// stations skips the VIDs the uplink doesn't carry, e.g. the ones of the local-only NADs
+func (d vlanFiltering) stations() ([]announce.Station, error) {
+	macs, err := iface.ListLearnedMACs(d.v.bridge.Index)
+	if err != nil {
+		return nil, err
+	}
+	egress, err := d.v.uplink.EgressVlans()
+	if err != nil {
+		return nil, err
+	}
+
+	stations := make([]announce.Station, 0, len(macs))
+	for _, m := range macs {
+		untagged, ok := egress[m.VID]
+		if !ok || m.PortIndex == d.v.uplink.Attrs().Index {
+			continue
+		}
+		vid := m.VID
+		if untagged {
+			vid = 0
+		}
+		stations = append(stations, announce.Station{MAC: m.MAC, VID: vid})
+	}
+
+	return stations, nil
+}
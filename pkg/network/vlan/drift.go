@@ -0,0 +1,107 @@
+package vlan
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+)
+
+// DesiredUplink is the uplink of a cluster network as the agent sets it up on the node
+type DesiredUplink struct {
+	Bonds []DesiredBond
+	// Bridge is the Linux bridge of the cluster network, empty if the datapath has none
+	Bridge string
+	// Ports are the links one of which is attached to the bridge, e.g. the bonds of fabric A and B, or the VLAN
+	// sub-interface of the bond
+	Ports []string
+}
+
+type DesiredBond struct {
+	Bond *netlink.Bond
+	NICs []string
+}
+
+// Drift returns how the links of the node differ from the desired uplink, e.g. the bridge is removed or a NIC is
+// released from the bond outside the agent, nil if they don't. The bonds are compared the way AdoptUplink does, so
+// that any drift found is modified back by the next setup.
+func Drift(d *DesiredUplink) ([]string, error) {
+	links, err := network.Handle().LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("list links failed, error: %w", err)
+	}
+
+	return d.drift(links), nil
+}
+
+func (d *DesiredUplink) drift(links []netlink.Link) []string {
+	byName := make(map[string]netlink.Link, len(links))
+	for _, l := range links {
+		byName[l.Attrs().Name] = l
+	}
+
+	var drifts []string
+	for _, b := range d.Bonds {
+		drifts = append(drifts, bondDrift(b, byName[b.Bond.Name], links)...)
+	}
+
+	if d.Bridge == "" {
+		return drifts
+	}
+	br, ok := byName[d.Bridge]
+	if !ok {
+		return append(drifts, fmt.Sprintf("bridge %s is missing", d.Bridge))
+	}
+	for _, port := range d.Ports {
+		if l, ok := byName[port]; ok && l.Attrs().MasterIndex == br.Attrs().Index {
+			return drifts
+		}
+	}
+
+	return append(drifts, fmt.Sprintf("%s is not attached to bridge %s", strings.Join(d.Ports, " or "), d.Bridge))
+}
+
+// bondDrift compares the existing bond with the desired one, the NICs which are down are taken as released like
+// listSlaves does
+func bondDrift(b DesiredBond, l netlink.Link, links []netlink.Link) []string {
+	name := b.Bond.Name
+	if l == nil {
+		return []string{fmt.Sprintf("bond %s is missing", name)}
+	}
+	existing, ok := l.(*netlink.Bond)
+	if !ok {
+		return []string{fmt.Sprintf("%s is a %s rather than a bond", name, l.Type())}
+	}
+
+	var drifts []string
+	if existing.Flags&net.FlagUp == 0 {
+		drifts = append(drifts, fmt.Sprintf("bond %s is down", name))
+	}
+	if !iface.NewBond(b.Bond, b.NICs).Matches(existing) {
+		drifts = append(drifts, fmt.Sprintf("the attributes of bond %s are changed", name))
+	}
+
+	slaves := make(map[string]bool)
+	for _, l := range links {
+		if l.Attrs().MasterIndex == existing.Index && l.Attrs().Flags&net.FlagUp != 0 {
+			slaves[l.Attrs().Name] = true
+		}
+	}
+	for _, nic := range b.NICs {
+		if !slaves[nic] {
+			drifts = append(drifts, fmt.Sprintf("NIC %s is released from bond %s", nic, name))
+		}
+		delete(slaves, nic)
+	}
+	for _, l := range links {
+		if slaves[l.Attrs().Name] {
+			drifts = append(drifts, fmt.Sprintf("%s is enslaved to bond %s", l.Attrs().Name, name))
+		}
+	}
+
+	return drifts
+}
@@ -0,0 +1,94 @@
+package vlan
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+func newTestLink(name string, index, masterIndex int) *netlink.Device {
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = name
+	attrs.Index = index
+	attrs.MasterIndex = masterIndex
+	attrs.Flags = net.FlagUp
+	return &netlink.Device{LinkAttrs: attrs}
+}
+
+func newTestBridge(name string, index int) *netlink.Bridge {
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = name
+	attrs.Index = index
+	return &netlink.Bridge{LinkAttrs: attrs}
+}
+
+func Test_Drift(t *testing.T) {
+	desired := &DesiredUplink{
+		Bonds:  []DesiredBond{{Bond: newTestBond(nil), NICs: []string{"eth0", "eth1"}}},
+		Bridge: "test-br",
+		Ports:  []string{"test-bo"},
+	}
+	existingBond := func(mutate func(b *netlink.Bond)) *netlink.Bond {
+		return newTestBond(func(b *netlink.Bond) {
+			b.Index, b.MasterIndex, b.Flags = 2, 1, net.FlagUp
+			if mutate != nil {
+				mutate(b)
+			}
+		})
+	}
+
+	tests := []struct {
+		name   string
+		links  []netlink.Link
+		drifts []string
+	}{
+		{
+			name: "no drift",
+			links: []netlink.Link{newTestBridge("test-br", 1), existingBond(nil), newTestLink("eth0", 3, 2),
+				newTestLink("eth1", 4, 2), newTestLink("eth2", 5, 0)},
+		},
+		{
+			name:   "the bridge is removed",
+			links:  []netlink.Link{existingBond(nil), newTestLink("eth0", 3, 2), newTestLink("eth1", 4, 2)},
+			drifts: []string{"bridge test-br is missing"},
+		},
+		{
+			name:   "the bond is removed",
+			links:  []netlink.Link{newTestBridge("test-br", 1), newTestLink("eth0", 3, 0), newTestLink("eth1", 4, 0)},
+			drifts: []string{"bond test-bo is missing", "test-bo is not attached to bridge test-br"},
+		},
+		{
+			name: "the NICs are swapped",
+			links: []netlink.Link{newTestBridge("test-br", 1), existingBond(nil), newTestLink("eth0", 3, 2),
+				newTestLink("eth1", 4, 0), newTestLink("eth2", 5, 2)},
+			drifts: []string{"NIC eth1 is released from bond test-bo", "eth2 is enslaved to bond test-bo"},
+		},
+		{
+			name: "the mode of the bond is changed",
+			links: []netlink.Link{newTestBridge("test-br", 1),
+				existingBond(func(b *netlink.Bond) { b.Mode = netlink.BOND_MODE_BALANCE_RR }),
+				newTestLink("eth0", 3, 2), newTestLink("eth1", 4, 2)},
+			drifts: []string{"the attributes of bond test-bo are changed"},
+		},
+		{
+			name: "the bond is detached from the bridge",
+			links: []netlink.Link{newTestBridge("test-br", 1),
+				existingBond(func(b *netlink.Bond) { b.MasterIndex = 0 }),
+				newTestLink("eth0", 3, 2), newTestLink("eth1", 4, 2)},
+			drifts: []string{"test-bo is not attached to bridge test-br"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.drifts, desired.drift(tc.links))
+		})
+	}
+
+	// a cluster network without a Linux bridge has only its bonds checked
+	bridgeless := &DesiredUplink{Bonds: desired.Bonds}
+	assert.Empty(t, bridgeless.drift([]netlink.Link{existingBond(nil), newTestLink("eth0", 3, 2),
+		newTestLink("eth1", 4, 2)}))
+}
@@ -0,0 +1,204 @@
+package vlan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/metrics"
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/fault"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// The fingerprint of the desired uplink is stamped into the alias of the bond, e.g. `harvester-network/v1:0123456789abcdef`.
+// It is computed only from the attributes which differ from the defaults, so that a new agent version introducing
+// more attributes still gets the same fingerprint for an uplink set up by an old agent version, and adopts it
+// without recreating the bond.
+const (
+	fingerprintPrefix  = "harvester-network/"
+	fingerprintVersion = "v1"
+	fingerprintLen     = 16
+)
+
+// UplinkFingerprint computes the fingerprint of the desired bond and its slaves
+func UplinkFingerprint(bond *netlink.Bond, slaves []string) string {
+	attrs := make([]string, 0)
+	attrs = append(attrs, "name="+bond.Name)
+
+	if bond.Mode != netlink.BOND_MODE_ACTIVE_BACKUP {
+		attrs = append(attrs, "mode="+bond.Mode.String())
+	}
+	if miimon := bond.Miimon; miimon != -1 && miimon != utils.DefaultValueMiimon {
+		attrs = append(attrs, fmt.Sprintf("miimon=%d", miimon))
+	}
+	if bond.UpDelay > 0 {
+		attrs = append(attrs, fmt.Sprintf("updelay=%d", bond.UpDelay))
+	}
+	if bond.DownDelay > 0 {
+		attrs = append(attrs, fmt.Sprintf("downdelay=%d", bond.DownDelay))
+	}
+	if policy := bond.XmitHashPolicy; policy != -1 && policy != netlink.BOND_XMIT_HASH_POLICY_LAYER2 {
+		attrs = append(attrs, "xmit_hash_policy="+policy.String())
+	}
+	if rate := bond.LacpRate; rate != -1 && rate != netlink.BOND_LACP_RATE_SLOW {
+		attrs = append(attrs, "lacp_rate="+rate.String())
+	}
+	if bond.ArpInterval > 0 {
+		targets := make([]string, 0, len(bond.ArpIpTargets))
+		for _, ip := range bond.ArpIpTargets {
+			targets = append(targets, ip.String())
+		}
+		attrs = append(attrs, fmt.Sprintf("arp_interval=%d", bond.ArpInterval),
+			"arp_ip_target="+strings.Join(targets, ","), fmt.Sprintf("arp_validate=%d", bond.ArpValidate))
+	}
+	if bond.MTU != 0 && bond.MTU != utils.DefaultMTU {
+		attrs = append(attrs, fmt.Sprintf("mtu=%d", bond.MTU))
+	}
+	if bond.TxQLen != -1 {
+		attrs = append(attrs, fmt.Sprintf("txqlen=%d", bond.TxQLen))
+	}
+	if bond.NumTxQueues != 0 {
+		attrs = append(attrs, fmt.Sprintf("txqueues=%d", bond.NumTxQueues))
+	}
+	if bond.NumRxQueues != 0 {
+		attrs = append(attrs, fmt.Sprintf("rxqueues=%d", bond.NumRxQueues))
+	}
+	if len(bond.HardwareAddr) != 0 {
+		attrs = append(attrs, "hwaddr="+bond.HardwareAddr.String())
+	}
+
+	sortedSlaves := append([]string{}, slaves...)
+	sort.Strings(sortedSlaves)
+	attrs = append(attrs, "slaves="+strings.Join(sortedSlaves, ","))
+
+	sum := sha256.Sum256([]byte(strings.Join(attrs, ";")))
+	return fingerprintPrefix + fingerprintVersion + ":" + hex.EncodeToString(sum[:])[:fingerprintLen]
+}
+
+// parseFingerprint returns the version and the hash of a fingerprint, ok is false if the alias is not a fingerprint
+func parseFingerprint(alias string) (version, hash string, ok bool) {
+	if !strings.HasPrefix(alias, fingerprintPrefix) {
+		return "", "", false
+	}
+
+	version, hash, ok = strings.Cut(strings.TrimPrefix(alias, fingerprintPrefix), ":")
+	if !ok || version == "" || hash == "" {
+		return "", "", false
+	}
+
+	return version, hash, true
+}
+
+// AdoptUplink checks whether the existing bond already matches the desired one and can be adopted as it is.
+// It returns the existing bond if so, and nil if the bond has to be (re-)created or modified, including the bond
+// stamped with the desired fingerprint whose attributes drift.
+// A bond without a fingerprint or with a fingerprint of an unknown version, e.g. set up by another agent version,
+// is adopted if its attributes and slaves are the same as desired, and the fingerprint is re-stamped.
+func AdoptUplink(bond *netlink.Bond, slaves []string) (*iface.Link, error) {
+	l, err := network.Handle().LinkByName(bond.Name)
+	if errors.Is(network.Classify(err), network.ErrLinkNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	existing, ok := l.(*netlink.Bond)
+	if !ok || existing.Flags&net.FlagUp == 0 {
+		return nil, nil
+	}
+
+	existingSlaves, err := listSlaves(existing.Index)
+	if err != nil {
+		return nil, err
+	}
+	if !equalSlaves(existingSlaves, slaves) {
+		return nil, nil
+	}
+
+	fingerprint := UplinkFingerprint(bond, slaves)
+
+	// the attributes may be changed outside the agent after the bond is stamped, modify them back
+	if existing.Alias == fingerprint {
+		if !iface.NewBond(bond, slaves).Matches(existing) {
+			logrus.Infof("the attributes of bond %s drift from its fingerprint, modify them", bond.Name)
+			return nil, nil
+		}
+		return iface.NewLink(existing), nil
+	}
+
+	if version, _, ok := parseFingerprint(existing.Alias); ok && version == fingerprintVersion {
+		// the fingerprint of the current version is different, the desired state has been changed
+		return nil, nil
+	}
+
+	if !iface.NewBond(bond, slaves).Matches(existing) {
+		return nil, nil
+	}
+
+	logrus.Infof("adopt the existing bond %s, stamp fingerprint %s", bond.Name, fingerprint)
+	if err := StampUplink(existing, fingerprint); err != nil {
+		return nil, err
+	}
+
+	return iface.NewLink(existing), nil
+}
+
+// StampUplink records the fingerprint into the alias of the uplink
+func StampUplink(l netlink.Link, fingerprint string) error {
+	if l.Attrs().Alias == fingerprint {
+		return nil
+	}
+
+	metrics.CountNetlinkOp(metrics.OpLinkSet)
+	if err := fault.Inject("linkSetAlias", l.Attrs().Name); err != nil {
+		return err
+	}
+	if err := network.Handle().LinkSetAlias(l, fingerprint); err != nil {
+		return fmt.Errorf("set alias of %s failed, error: %w", l.Attrs().Name, err)
+	}
+
+	return nil
+}
+
+// listSlaves lists the slaves which are up, a slave which is down is not taken as adopted
+func listSlaves(index int) ([]string, error) {
+	links, err := network.Handle().LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("list links failed, error: %w", err)
+	}
+
+	slaves := make([]string, 0)
+	for _, l := range links {
+		if l.Attrs().MasterIndex == index && l.Attrs().Flags&net.FlagUp != 0 {
+			slaves = append(slaves, l.Attrs().Name)
+		}
+	}
+
+	return slaves, nil
+}
+
+func equalSlaves(existing, desired []string) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+
+	m := make(map[string]bool, len(existing))
+	for _, s := range existing {
+		m[s] = true
+	}
+	for _, s := range desired {
+		if !m[s] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,101 @@
+package vlan
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+func newTestBond(mutate func(b *netlink.Bond)) *netlink.Bond {
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = "test-bo"
+	b := netlink.NewLinkBond(attrs)
+	b.Mode = netlink.BOND_MODE_ACTIVE_BACKUP
+	b.Miimon = utils.DefaultValueMiimon
+	if mutate != nil {
+		mutate(b)
+	}
+	return b
+}
+
+func Test_UplinkFingerprint(t *testing.T) {
+	base := UplinkFingerprint(newTestBond(nil), []string{"eth0", "eth1"})
+
+	tests := []struct {
+		name   string
+		bond   *netlink.Bond
+		slaves []string
+		same   bool
+	}{
+		{
+			name:   "slaves order doesn't matter",
+			bond:   newTestBond(nil),
+			slaves: []string{"eth1", "eth0"},
+			same:   true,
+		},
+		{
+			name:   "default values are equal to omitted values",
+			bond:   newTestBond(func(b *netlink.Bond) { b.Miimon = -1; b.MTU = utils.DefaultMTU }),
+			slaves: []string{"eth0", "eth1"},
+			same:   true,
+		},
+		{
+			name:   "zero delays are equal to omitted delays",
+			bond:   newTestBond(func(b *netlink.Bond) { b.UpDelay = 0; b.DownDelay = 0 }),
+			slaves: []string{"eth0", "eth1"},
+			same:   true,
+		},
+		{
+			name:   "delays are set",
+			bond:   newTestBond(func(b *netlink.Bond) { b.UpDelay = 2000 }),
+			slaves: []string{"eth0", "eth1"},
+			same:   false,
+		},
+		{
+			name:   "mode is changed",
+			bond:   newTestBond(func(b *netlink.Bond) { b.Mode = netlink.BOND_MODE_802_3AD }),
+			slaves: []string{"eth0", "eth1"},
+			same:   false,
+		},
+		{
+			name:   "mtu is changed",
+			bond:   newTestBond(func(b *netlink.Bond) { b.MTU = 9000 }),
+			slaves: []string{"eth0", "eth1"},
+			same:   false,
+		},
+		{
+			name:   "hardware address is set",
+			bond:   newTestBond(func(b *netlink.Bond) { b.HardwareAddr = net.HardwareAddr{0x02, 0, 0, 0, 0, 1} }),
+			slaves: []string{"eth0", "eth1"},
+			same:   false,
+		},
+		{
+			name:   "slave is removed",
+			bond:   newTestBond(nil),
+			slaves: []string{"eth0"},
+			same:   false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.same, UplinkFingerprint(tc.bond, tc.slaves) == base)
+		})
+	}
+}
+
+func Test_parseFingerprint(t *testing.T) {
+	version, hash, ok := parseFingerprint(UplinkFingerprint(newTestBond(nil), []string{"eth0"}))
+	assert.True(t, ok)
+	assert.Equal(t, fingerprintVersion, version)
+	assert.Len(t, hash, fingerprintLen)
+
+	for _, alias := range []string{"", "uplink to switch", "harvester-network/", "harvester-network/v1:"} {
+		_, _, ok := parseFingerprint(alias)
+		assert.False(t, ok, alias)
+	}
+}
@@ -0,0 +1,151 @@
+package vlan
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/announce"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/ovs"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// NewOVSVlan is NewVlan of a cluster network with the datapath OVS. The bridge <cluster network>-br is an Open vSwitch
+// bridge managed through the OVS database rather than netlink, the bond is added to it as a port trunking the VIDs.
+func NewOVSVlan(name string) *Vlan {
+	v := &Vlan{name: name}
+	v.datapath = ovsTrunks{v: v}
+	return v
+}
+
+// getOVSVlan returns the VLAN of the OVS cluster network set up on the node, the uplink is its bond
+func getOVSVlan(name string) (*Vlan, error) {
+	v := NewOVSVlan(name)
+
+	brName := utils.GenerateBridgeName(name)
+	if ok, err := iface.IsOVSBridge(brName); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, fmt.Errorf("OVS bridge %s of cluster network %s: %w", brName, name, network.ErrLinkNotFound)
+	}
+
+	l, err := network.Handle().LinkByName(utils.GenerateBondName(name))
+	if err != nil {
+		return nil, network.Classify(err)
+	}
+	v.uplink = iface.NewLink(l)
+
+	return v, nil
+}
+
+// IsOVS returns true if the VLANs are trunked through an Open vSwitch bridge
+func (v *Vlan) IsOVS() bool {
+	_, ok := v.datapath.(ovsTrunks)
+	return ok
+}
+
+// RemoveOVSBridge removes the OVS bridge of the cluster network left on the node, if any. Nothing is asked of Open
+// vSwitch on the nodes without the bridge.
+func RemoveOVSBridge(name string) error {
+	brName := utils.GenerateBridgeName(name)
+	if ok, err := iface.IsOVSBridge(brName); err != nil || !ok {
+		return err
+	}
+
+	return ovs.DeleteBridge(brName)
+}
+
+// ovsTrunks separates the VLAN IDs by the trunks of the uplink port of an Open vSwitch bridge, the VMs are attached by
+// the OVS CNI with the VID as their tag
+type ovsTrunks struct {
+	v *Vlan
+}
+
+func (d ovsTrunks) bridgeName() string {
+	return utils.GenerateBridgeName(d.v.name)
+}
+
+func (d ovsTrunks) setup(l *iface.Link) error {
+	// the bond may be left on the Linux bridge of a previous datapath
+	if l.Attrs().MasterIndex != 0 {
+		master, err := network.Handle().LinkByIndex(l.Attrs().MasterIndex)
+		if err != nil {
+			return network.Classify(err)
+		}
+		if master.Attrs().Name != ovs.DatapathName {
+			if err := l.SetNoMaster(); err != nil {
+				return fmt.Errorf("set %s no master failed, error: %w", l.Attrs().Name, err)
+			}
+		}
+	}
+
+	if err := ovs.EnsureBridge(d.bridgeName()); err != nil {
+		return fmt.Errorf("ensure OVS bridge %s failed, error: %w", d.bridgeName(), err)
+	}
+	if err := iface.EnsureOVSBridgeUp(d.bridgeName()); err != nil {
+		return err
+	}
+
+	return ovs.EnsureTrunkPort(d.bridgeName(), l.Attrs().Name)
+}
+
+func (d ovsTrunks) addLocalAreas(vids []uint16) error {
+	trunks, err := ovs.Trunks(d.v.uplink.Attrs().Name)
+	if err != nil {
+		return err
+	}
+	for _, vid := range vids {
+		if !slices.Contains(trunks, vid) {
+			trunks = append(trunks, vid)
+		}
+	}
+
+	return ovs.SetTrunks(d.v.uplink.Attrs().Name, trunks)
+}
+
+func (d ovsTrunks) removeLocalAreas(vids []uint16) error {
+	trunks, err := ovs.Trunks(d.v.uplink.Attrs().Name)
+	if err != nil {
+		return err
+	}
+	trunks = slices.DeleteFunc(trunks, func(vid uint16) bool {
+		return slices.Contains(vids, vid)
+	})
+
+	return ovs.SetTrunks(d.v.uplink.Attrs().Name, trunks)
+}
+
+func (d ovsTrunks) localAreas() (*utils.VlanIDSet, error) {
+	trunks, err := ovs.Trunks(d.v.uplink.Attrs().Name)
+	if err != nil {
+		return nil, err
+	}
+
+	vis := utils.NewVlanIDSet()
+	for _, vid := range trunks {
+		if err := vis.SetUint16VID(vid); err != nil {
+			return nil, err
+		}
+	}
+
+	return vis, nil
+}
+
+func (d ovsTrunks) vlanUsers() (map[uint16][]string, error) {
+	excluded := ""
+	if d.v.uplink != nil {
+		excluded = d.v.uplink.Attrs().Name
+	}
+	return ovs.VlanUsers(d.bridgeName(), excluded)
+}
+
+// teardown removes the OVS bridge, the records of its ports are removed with it
+func (d ovsTrunks) teardown() error {
+	return ovs.DeleteBridge(d.bridgeName())
+}
+
+// stations returns no stations, the MAC addresses are learned by Open vSwitch rather than a Linux bridge
+func (d ovsTrunks) stations() ([]announce.Station, error) {
+	return nil, nil
+}
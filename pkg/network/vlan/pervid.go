@@ -0,0 +1,198 @@
+package vlan
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/announce"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// NewBridgePerVIDVlan is NewVlan of a cluster network with the datapath BridgePerVID. The bridge of the cluster network
+// carries the untagged traffic only, each VID has a bridge of its own with a VLAN sub-interface of the uplink.
+func NewBridgePerVIDVlan(name string) *Vlan {
+	v := &Vlan{
+		name:   name,
+		bridge: iface.NewBridgeWithoutVlanFiltering(utils.GenerateBridgeName(name)),
+	}
+	v.datapath = bridgePerVID{v: v}
+	return v
+}
+
+// IsBridgePerVID returns true if the VLANs are separated by a bridge per VID
+func (v *Vlan) IsBridgePerVID() bool {
+	_, ok := v.datapath.(bridgePerVID)
+	return ok
+}
+
+// bridgePerVID separates the VLAN IDs by a bridge per VID attached to a VLAN sub-interface of the uplink
+type bridgePerVID struct {
+	v *Vlan
+}
+
+func (d bridgePerVID) setup(l *iface.Link) error {
+	return d.v.setupBridge(l)
+}
+
+func (d bridgePerVID) addLocalAreas(vids []uint16) error {
+	return d.v.addBridgesPerVID(vids)
+}
+
+func (d bridgePerVID) removeLocalAreas(vids []uint16) error {
+	return d.v.removeBridgesPerVID(vids)
+}
+
+func (d bridgePerVID) localAreas() (*utils.VlanIDSet, error) {
+	return d.v.bridgesPerVIDSet()
+}
+
+func (d bridgePerVID) vlanUsers() (map[uint16][]string, error) {
+	return d.v.vlanUsersPerVID()
+}
+
+func (d bridgePerVID) teardown() error {
+	return RemoveBridgesPerVID(d.v.name)
+}
+
+// stations returns the MAC addresses of the bridge of the cluster network untagged, and the ones of the bridges per
+// VID tagged with their VID
+func (d bridgePerVID) stations() ([]announce.Station, error) {
+	stations, err := learnedStations(d.v.bridge.Index, d.v.uplink.Attrs().Index, 0)
+	if err != nil {
+		return nil, err
+	}
+	bridges, err := iface.ListPerVIDBridges(d.v.name)
+	if err != nil {
+		return nil, err
+	}
+	for vid, br := range bridges {
+		for _, port := range br.Members {
+			if port.Attrs().Name != utils.GeneratePerVIDUplinkName(d.v.name, vid) {
+				continue
+			}
+			learned, err := learnedStations(br.Index, port.Attrs().Index, vid)
+			if err != nil {
+				return nil, err
+			}
+			stations = append(stations, learned...)
+		}
+	}
+
+	return stations, nil
+}
+
+// learnedStations returns the MAC addresses the bridge learned on the ports other than the uplink on the VID
+func learnedStations(brIndex, uplinkIndex int, vid uint16) ([]announce.Station, error) {
+	macs, err := iface.ListLearnedMACs(brIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	stations := make([]announce.Station, 0, len(macs))
+	for _, m := range macs {
+		if m.PortIndex != uplinkIndex {
+			stations = append(stations, announce.Station{MAC: m.MAC, VID: vid})
+		}
+	}
+
+	return stations, nil
+}
+
+func (v *Vlan) addBridgesPerVID(vids []uint16) error {
+	for _, vid := range vids {
+		sub, err := v.uplink.EnsurePerVIDUplink(v.name, vid)
+		if err != nil {
+			return err
+		}
+		br := iface.NewBridgeWithoutVlanFiltering(utils.GeneratePerVIDBridgeName(v.name, vid))
+		if err := br.Ensure(); err != nil {
+			return fmt.Errorf("ensure bridge %s failed, error: %w", br.Name, err)
+		}
+		if err := sub.SetMaster(br); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *Vlan) removeBridgesPerVID(vids []uint16) error {
+	for _, vid := range vids {
+		if err := iface.RemovePerVID(v.name, vid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bridgesPerVIDSet returns the VIDs whose bridge is attached to a VLAN sub-interface of the current uplink, the VIDs
+// left on the previous uplink are added again
+func (v *Vlan) bridgesPerVIDSet() (*utils.VlanIDSet, error) {
+	bridges, err := iface.ListPerVIDBridges(v.name)
+	if err != nil {
+		return nil, err
+	}
+
+	vis := utils.NewVlanIDSet()
+	for vid, br := range bridges {
+		for _, port := range br.Members {
+			attrs := port.Attrs()
+			if attrs.Name == utils.GeneratePerVIDUplinkName(v.name, vid) && attrs.ParentIndex == v.uplink.Attrs().Index {
+				if err := vis.SetUint16VID(vid); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+
+	return vis, nil
+}
+
+// vlanUsersPerVID returns the ports of the bridges per VID other than their uplinks. The ports of the bridge of the
+// cluster network other than the uplink use the VID 1, like the untagged ports of a VLAN filtering bridge.
+func (v *Vlan) vlanUsersPerVID() (map[uint16][]string, error) {
+	bridges, err := iface.ListPerVIDBridges(v.name)
+	if err != nil {
+		return nil, err
+	}
+	ports, err := v.bridge.Ports()
+	if err != nil {
+		return nil, err
+	}
+
+	users := map[uint16][]string{}
+	for _, port := range ports {
+		if v.uplink == nil || port.Attrs().Index != v.uplink.Attrs().Index {
+			users[utils.DefaultVlanID] = append(users[utils.DefaultVlanID], port.Attrs().Name)
+		}
+	}
+	for vid, br := range bridges {
+		for _, port := range br.Members {
+			if port.Attrs().Name != utils.GeneratePerVIDUplinkName(v.name, vid) {
+				users[vid] = append(users[vid], port.Attrs().Name)
+			}
+		}
+	}
+
+	return users, nil
+}
+
+// RemoveBridgesPerVID removes the bridges per VID of the cluster network, their uplinks are removed with them
+func RemoveBridgesPerVID(name string) error {
+	bridges, err := iface.ListPerVIDBridges(name)
+	if err != nil {
+		return err
+	}
+	for vid := range bridges {
+		logrus.Infof("remove the bridge per VID %d of cluster network %s", vid, name)
+		if err := iface.RemovePerVID(name, vid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package vlan
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// EnsureQinQ builds a QinQ bridge per S-VLAN of the sets next to the bridge of the cluster network. Its uplink is the
+// 802.1ad VLAN sub-interface of the S-VLAN on the uplink of the cluster network, which carries the C-VLANs of the
+// S-VLAN tagged. The QinQ bridges of the S-VLANs no longer in the sets are removed once no VM is attached to them.
+func (v *Vlan) EnsureQinQ(sets map[uint16]*utils.VlanIDSet) error {
+	bridges, err := iface.ListQinQBridges(v.name)
+	if err != nil {
+		return err
+	}
+	if len(sets) != 0 && v.uplink == nil {
+		return v.errNoUplink()
+	}
+
+	for svid, cvids := range sets {
+		if err := v.ensureQinQBridge(svid, cvids); err != nil {
+			return err
+		}
+	}
+
+	for svid, br := range bridges {
+		if _, ok := sets[svid]; ok {
+			continue
+		}
+		if users := qinqUsers(v.name, svid, br); len(users) != 0 {
+			logrus.Infof("keep the QinQ bridge %s of cluster network %s still used by %v", br.Name, v.name, users)
+			continue
+		}
+		logrus.Infof("remove the QinQ bridge %s of cluster network %s", br.Name, v.name)
+		if err := iface.RemoveQinQ(v.name, svid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *Vlan) ensureQinQBridge(svid uint16, cvids *utils.VlanIDSet) error {
+	sub, err := v.uplink.EnsureQinQUplink(v.name, svid)
+	if err != nil {
+		return err
+	}
+	br := iface.NewBridge(utils.GenerateQinQBridgeName(v.name, svid))
+	if err := br.Ensure(); err != nil {
+		return fmt.Errorf("ensure bridge %s failed, error: %w", br.Name, err)
+	}
+	if err := sub.SetMaster(br); err != nil {
+		return err
+	}
+
+	existing, err := sub.ToVlanIDSet()
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		existing = utils.NewVlanIDSet()
+	}
+	added, removed, err := cvids.Diff(existing)
+	if err != nil {
+		return err
+	}
+	if err := sub.AddBridgeVlans(added.VIDs()); err != nil {
+		return fmt.Errorf("add C-VLANs %v to %s failed, error: %w", added.VIDs(), sub.Attrs().Name, err)
+	}
+	if err := sub.DelBridgeVlans(removed.VIDs()); err != nil {
+		return fmt.Errorf("remove C-VLANs %v from %s failed, error: %w", removed.VIDs(), sub.Attrs().Name, err)
+	}
+
+	return nil
+}
+
+// qinqUsers returns the ports of the QinQ bridge other than its uplink, e.g. the VM ports
+func qinqUsers(name string, svid uint16, br *iface.PerVIDBridge) []string {
+	var users []string
+	for _, port := range br.Members {
+		if port.Attrs().Name != utils.GenerateQinQUplinkName(name, svid) {
+			users = append(users, port.Attrs().Name)
+		}
+	}
+	return users
+}
+
+// RemoveQinQBridges removes the QinQ bridges of the cluster network, their uplinks are removed with them
+func RemoveQinQBridges(name string) error {
+	bridges, err := iface.ListQinQBridges(name)
+	if err != nil {
+		return err
+	}
+	for svid := range bridges {
+		logrus.Infof("remove the QinQ bridge of S-VLAN %d of cluster network %s", svid, name)
+		if err := iface.RemoveQinQ(name, svid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
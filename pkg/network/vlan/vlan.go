@@ -1,11 +1,13 @@
 package vlan
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
-	"github.com/vishvananda/netlink"
 
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/announce"
 	"github.com/harvester/harvester-network-controller/pkg/network/iface"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
@@ -14,6 +16,8 @@ type Vlan struct {
 	name   string
 	bridge *iface.Bridge
 	uplink *iface.Link
+	// how the VLANs are separated below the uplink, the VLAN filtering of the bridge by default
+	datapath datapath
 }
 
 func (v *Vlan) Type() string {
@@ -25,35 +29,97 @@ func (v *Vlan) Type() string {
 func NewVlan(name string) *Vlan {
 	br := iface.NewBridge(utils.GenerateBridgeName(name))
 
-	return &Vlan{
+	v := &Vlan{
 		name:   name,
 		bridge: br,
 	}
+	v.datapath = vlanFiltering{v: v}
+	return v
 }
 
 func (v *Vlan) getUplink() (*iface.Link, error) {
-	l, err := netlink.LinkByName(utils.GenerateBondName(v.name))
+	l, err := network.Handle().LinkByName(utils.GenerateBondName(v.name))
+	if err == nil {
+		// the bond of fabric B is attached to the bridge after failover
+		if l.Attrs().MasterIndex != v.bridge.Index {
+			if standby, standbyErr := network.Handle().LinkByName(utils.GenerateFabricBBondName(v.name)); standbyErr == nil &&
+				standby.Attrs().MasterIndex == v.bridge.Index {
+				return iface.NewLink(standby), nil
+			}
+			// the VLAN sub-interface of the service VLAN is attached to the bridge rather than the bond
+			if sub, subErr := v.getVlanSubInterfaceUplink(); subErr != nil {
+				return nil, subErr
+			} else if sub != nil {
+				return sub, nil
+			}
+		}
+		return iface.NewLink(l), nil
+	} else if err = network.Classify(err); !errors.Is(err, network.ErrLinkNotFound) {
+		return nil, err
+	}
+
+	// the uplink of an overlay cluster network is the VTEP
+	if vtep, vtepErr := network.Handle().LinkByName(utils.GenerateVtepName(v.name)); vtepErr == nil {
+		return iface.NewLink(vtep), nil
+	} else if vtepErr = network.Classify(vtepErr); !errors.Is(vtepErr, network.ErrLinkNotFound) {
+		return nil, vtepErr
+	}
+
+	// the uplink may be a VLAN sub-interface of a shared bond
+	shared, sharedErr := v.getVlanSubInterfaceUplink()
+	if sharedErr != nil {
+		return nil, sharedErr
+	}
+	if shared == nil {
+		return nil, err
+	}
+
+	return shared, nil
+}
+
+// getVlanSubInterfaceUplink returns the VLAN sub-interface of a bond which is attached to the bridge, or nil if not
+// found. The bond is either shared or the bond of the cluster network with a service VLAN.
+func (v *Vlan) getVlanSubInterfaceUplink() (*iface.Link, error) {
+	links, err := network.Handle().LinkList()
 	if err != nil {
 		return nil, err
 	}
 
-	return iface.NewLink(l), nil
+	for _, l := range links {
+		if l.Type() != iface.TypeVlan || l.Attrs().MasterIndex == 0 || l.Attrs().MasterIndex != v.bridge.Index {
+			continue
+		}
+		parent, err := network.Handle().LinkByIndex(l.Attrs().ParentIndex)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Type() == iface.TypeBond {
+			return iface.NewLink(l), nil
+		}
+	}
+
+	return nil, nil
 }
 
 func (v *Vlan) GetBridgelink() (*iface.Link, error) {
-	l, err := netlink.LinkByName(utils.GenerateBridgeName(v.name))
+	l, err := network.Handle().LinkByName(utils.GenerateBridgeName(v.name))
 	if err != nil {
-		return nil, err
+		return nil, network.Classify(err)
 	}
 
 	return iface.NewLink(l), nil
 }
 
+// GetVlan returns the VLAN of the cluster network set up on the node, the datapath is told by the VLAN filtering of
+// its bridge
 func GetVlan(name string) (*Vlan, error) {
 	v := NewVlan(name)
 	if err := v.bridge.Fetch(); err != nil {
 		return nil, err
 	}
+	if v.bridge.VlanFiltering != nil && !*v.bridge.VlanFiltering {
+		v.datapath = bridgePerVID{v: v}
+	}
 
 	uplink, err := v.getUplink()
 	if err != nil {
@@ -65,35 +131,57 @@ func GetVlan(name string) (*Vlan, error) {
 }
 
 func (v *Vlan) Setup(l *iface.Link) error {
+	if err := v.datapath.setup(l); err != nil {
+		return err
+	}
+	v.uplink = l
+
+	return nil
+}
+
+// setupBridge attaches the uplink to the Linux bridge of the cluster network
+func (v *Vlan) setupBridge(l *iface.Link) error {
 	// ensure bridge and get NIC
 	if err := v.bridge.Ensure(); err != nil {
 		return fmt.Errorf("ensure bridge %s failed, error: %w", v.bridge.Name, err)
 	}
 
 	// set master
-	if err := l.SetMaster(v.bridge); err != nil {
-		return err
-	}
-	v.uplink = l
-
-	return nil
+	return l.SetMaster(v.bridge)
 }
 
 func (v *Vlan) Teardown() error {
 	logrus.Info("start to tear down VLAN network")
 	if v.uplink == nil {
-		return fmt.Errorf("bridge %s hasn't attached an uplink", v.bridge.Name)
+		return v.errNoUplink()
 	}
 
 	// set no master, VIDs will be auto-removed
-	if err := v.uplink.SetNoMaster(); err != nil {
-		return fmt.Errorf("set %s no master failed, error: %w", v.uplink.Attrs().Name, err)
+	if v.bridge != nil {
+		if err := v.uplink.SetNoMaster(); err != nil {
+			return fmt.Errorf("set %s no master failed, error: %w", v.uplink.Attrs().Name, err)
+		}
 	}
 
 	if err := v.uplink.Remove(); err != nil {
 		return fmt.Errorf("delete uplink %s failed, error: %w", v.uplink.Attrs().Name, err)
 	}
 
+	if err := v.removeStandbyUplink(); err != nil {
+		return err
+	}
+
+	if err := v.datapath.teardown(); err != nil {
+		return err
+	}
+	if err := RemoveQinQBridges(v.name); err != nil {
+		return err
+	}
+
+	if v.bridge == nil {
+		logrus.Info("tear down VLAN network successfully")
+		return nil
+	}
 	if err := iface.NewLink(v.bridge).Remove(); err != nil {
 		return fmt.Errorf("delete bridge %s failed, error: %w", v.bridge.Name, err)
 	}
@@ -102,17 +190,31 @@ func (v *Vlan) Teardown() error {
 	return nil
 }
 
+// removeStandbyUplink removes the bond of the fabric which is not attached to the bridge
+func (v *Vlan) removeStandbyUplink() error {
+	for _, name := range []string{utils.GenerateBondName(v.name), utils.GenerateFabricBBondName(v.name)} {
+		l, err := network.Handle().LinkByName(name)
+		if errors.Is(network.Classify(err), network.ErrLinkNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := iface.NewLink(l).Remove(); err != nil {
+			return fmt.Errorf("delete standby uplink %s failed, error: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
 func (v *Vlan) AddLocalAreas(vis *utils.VlanIDSet) error {
 	if vis == nil {
 		return nil
 	}
 	if v.uplink == nil {
-		return fmt.Errorf("bridge %s hasn't attached with an uplink", v.bridge.Name)
-	}
-	if err := vis.WalkVIDs("add bridge vlanconfig", v.uplink.AddBridgeVlan); err != nil {
-		return nil
+		return v.errNoUplink()
 	}
-	return nil
+	return v.datapath.addLocalAreas(vis.VIDs())
 }
 
 func (v *Vlan) RemoveLocalAreas(vis *utils.VlanIDSet) error {
@@ -120,24 +222,106 @@ func (v *Vlan) RemoveLocalAreas(vis *utils.VlanIDSet) error {
 		return nil
 	}
 	if v.uplink == nil {
-		return fmt.Errorf("bridge %s hasn't attached with an uplink", v.bridge.Name)
+		return v.errNoUplink()
 	}
+	return v.datapath.removeLocalAreas(vis.VIDs())
+}
+
+func (v *Vlan) ToVlanIDSet() (*utils.VlanIDSet, error) {
+	// the NewVlan returned vlan never has an empty uplink, skip check it
+	return v.datapath.localAreas()
+}
+
+// VlanUsers returns the ports other than the uplink using each VID, e.g. the VM ports
+func (v *Vlan) VlanUsers() (map[uint16][]string, error) {
+	return v.datapath.vlanUsers()
+}
 
-	if err := vis.WalkVIDs("remove bridge vlanconfig", v.uplink.DelBridgeVlan); err != nil {
+// EnsureUntaggedVID puts the ports of the bridge attached without any VID, i.e. the VMs of the untagged NADs, on the
+// VID. The VID leaves the uplink tagged so that they land on the VLAN at the switch, except the default VID 1, which
+// leaves it untagged to the native VLAN of the switch port. Only a VLAN filtering bridge has a VLAN for the untagged
+// ports.
+func (v *Vlan) EnsureUntaggedVID(vid uint16) error {
+	if _, ok := v.datapath.(vlanFiltering); !ok {
 		return nil
 	}
-	return nil
+	if v.uplink == nil {
+		return v.errNoUplink()
+	}
+
+	if current := v.bridge.DefaultPVID(); current != vid {
+		logrus.Infof("move the untagged ports of bridge %s from vid %d to %d", v.bridge.Name, current, vid)
+		if err := v.bridge.SetDefaultPVID(vid); err != nil {
+			return err
+		}
+	}
+
+	return v.uplink.EnsurePortVID(vid, vid == utils.DefaultVlanID)
 }
 
-func (v *Vlan) ToVlanIDSet() (*utils.VlanIDSet, error) {
-	// the NewVlan returned vlan never has an empty uplink, skip check it
-	return v.uplink.ToVlanIDSet()
+// Stations returns the MAC addresses behind the uplink, e.g. the VMs, with the VID they leave the uplink on, to be
+// announced to the switches after the uplink fails over
+func (v *Vlan) Stations() ([]announce.Station, error) {
+	if v.uplink == nil {
+		return nil, v.errNoUplink()
+	}
+	return v.datapath.stations()
 }
 
+// Bridge returns the Linux bridge of the cluster network, nil if the datapath is bridge-less or OVS
 func (v *Vlan) Bridge() *iface.Bridge {
 	return v.bridge
 }
 
+func (v *Vlan) errNoUplink() error {
+	if v.bridge == nil {
+		return fmt.Errorf("cluster network %s hasn't attached with an uplink", v.name)
+	}
+	return fmt.Errorf("bridge %s hasn't attached with an uplink", v.bridge.Name)
+}
+
 func (v *Vlan) Uplink() *iface.Link {
 	return v.uplink
 }
+
+// RemoveStaleUplink removes the uplink of the cluster network if it's not the expected one, e.g. a vlanconfig is
+// switched between a dedicated bond and a shared bond, or the service VLAN is changed. The vlanUplink is the expected
+// VLAN sub-interface, empty if the bond is expected. The NICs have to be released before enslaved by the new bond.
+func RemoveStaleUplink(name string, vlanUplink string) error {
+	v, err := LookupVlan(name)
+	if errors.Is(err, network.ErrLinkNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	isVlan := v.uplink.Type() == iface.TypeVlan
+	if (vlanUplink == "" && !isVlan) || (vlanUplink != "" && v.uplink.Attrs().Name == vlanUplink) {
+		return nil
+	}
+
+	logrus.Infof("remove stale uplink %s of cluster network %s", v.uplink.Attrs().Name, name)
+	if err := v.uplink.SetNoMaster(); err != nil {
+		return fmt.Errorf("set %s no master failed, error: %w", v.uplink.Attrs().Name, err)
+	}
+
+	return v.uplink.Remove()
+}
+
+// ReleaseUplinkSlaves keeps the bridge and the uplink, and only releases the slaves of the uplink bonds which are
+// not in keep. It's used instead of Teardown when the bridge is still used by others.
+func (v *Vlan) ReleaseUplinkSlaves(keep []string) error {
+	for _, name := range []string{utils.GenerateBondName(v.name), utils.GenerateFabricBBondName(v.name)} {
+		l, err := network.Handle().LinkByName(name)
+		if errors.Is(network.Classify(err), network.ErrLinkNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := iface.ReleaseSlaves(l.Attrs().Index, keep); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
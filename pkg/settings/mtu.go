@@ -0,0 +1,93 @@
+// Package settings applies the cluster-wide Harvester settings of the network controller to the manager and the
+// webhook.
+package settings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	"github.com/harvester/harvester-network-controller/pkg/hooks"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	// MTUBoundsSettingName is the Harvester setting overriding the bounds of the valid MTUs in JSON, e.g.
+	// {"min":1280,"max":9216}
+	MTUBoundsSettingName = "network-controller-mtu-bounds"
+
+	mtuBoundsSyncInterval = time.Minute
+)
+
+type MTUBounds struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// ParseMTUBounds parses the value of the setting, an omitted bound keeps its default, and an empty value restores
+// both defaults
+func ParseMTUBounds(value string) (*MTUBounds, error) {
+	bounds := &MTUBounds{Min: utils.MinMTU, Max: utils.MaxMTU}
+	if value == "" {
+		return bounds, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewBufferString(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(bounds); err != nil {
+		return nil, fmt.Errorf("invalid %s %s, error: %w", MTUBoundsSettingName, value, err)
+	}
+	if bounds.Min > bounds.Max {
+		return nil, fmt.Errorf("invalid %s %s, the min MTU %d is larger than the max MTU %d", MTUBoundsSettingName,
+			value, bounds.Min, bounds.Max)
+	}
+
+	return bounds, nil
+}
+
+// Register syncs the settings applying to the controllers of the manager
+func Register(ctx context.Context, management *config.Management) error {
+	SyncMTUBounds(ctx, management.DynamicClient)
+	return nil
+}
+
+// SyncMTUBounds applies the setting to the MTU bounds now and every minute in the background until the context is
+// done. An invalid value is logged and the bounds in effect are kept, so that a typo doesn't loosen the validation.
+func SyncMTUBounds(ctx context.Context, client dynamic.Interface) {
+	sync := func(ctx context.Context) {
+		if err := syncMTUBounds(ctx, client); err != nil {
+			logrus.Warnf("sync the MTU bounds failed, error: %s", err.Error())
+		}
+	}
+
+	sync(ctx)
+	go wait.UntilWithContext(ctx, sync, mtuBoundsSyncInterval)
+}
+
+func syncMTUBounds(ctx context.Context, client dynamic.Interface) error {
+	value, err := hooks.GetSetting(ctx, client, MTUBoundsSettingName)
+	if err != nil {
+		return err
+	}
+	bounds, err := ParseMTUBounds(value)
+	if err != nil {
+		return err
+	}
+
+	if minMTU, maxMTU := utils.MTUBounds(); minMTU == bounds.Min && maxMTU == bounds.Max {
+		return nil
+	}
+	if err := utils.SetMTUBounds(bounds.Min, bounds.Max); err != nil {
+		return err
+	}
+	logrus.Infof("the MTU bounds are set to [%d..%d]", bounds.Min, bounds.Max)
+
+	return nil
+}
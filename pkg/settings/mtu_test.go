@@ -0,0 +1,56 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+func TestParseMTUBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected *MTUBounds
+		errKey   string
+	}{
+		{
+			name:     "empty value restores the defaults",
+			value:    "",
+			expected: &MTUBounds{Min: utils.MinMTU, Max: utils.MaxMTU},
+		},
+		{
+			name:     "both bounds",
+			value:    `{"min":1280,"max":9216}`,
+			expected: &MTUBounds{Min: 1280, Max: 9216},
+		},
+		{
+			name:     "omitted min keeps the default",
+			value:    `{"max":4000}`,
+			expected: &MTUBounds{Min: utils.MinMTU, Max: 4000},
+		},
+		{
+			name:   "unknown field",
+			value:  `{"maximum":9216}`,
+			errKey: "unknown field",
+		},
+		{
+			name:   "min larger than max",
+			value:  `{"min":9000,"max":1500}`,
+			errKey: "is larger than the max MTU",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bounds, err := ParseMTUBounds(tc.value)
+			if tc.errKey != "" {
+				assert.ErrorContains(t, err, tc.errKey)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, bounds)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package switchport describes the switch-side state the VLAN networks of a node depend on, i.e. which VLANs the
+// switch ports connected to the uplink NICs have to trunk, so that an external automation (e.g. NetBox or Ansible)
+// can provision the physical trunks. The NICs are mapped to the switch ports by their LLDP neighbors, which an LLDP
+// agent on the node annotates on the node.
+package switchport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/hooks"
+)
+
+// SettingName is the Harvester setting holding the configuration of the external API in JSON
+const SettingName = "network-controller-switchport-automation"
+
+const fabricB = "B"
+
+// Config is the external API the desired state of the node is posted to
+type Config struct {
+	hooks.WebHook
+	// 0 means hooks.DefaultTimeout
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// Parse parses and validates the configuration, an empty value means the automation is disabled and nil is returned
+func Parse(value string) (*Config, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	cfg := &Config{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid switch port automation configuration, error: %w", err)
+	}
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, fmt.Errorf("URL %q must be http or https", cfg.URL)
+	}
+	if cfg.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("timeoutSeconds %d can't be negative", cfg.TimeoutSeconds)
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) Timeout() time.Duration {
+	if c.TimeoutSeconds == 0 {
+		return hooks.DefaultTimeout
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// Neighbor is the LLDP neighbor of a NIC, i.e. the switch port it's connected to
+type Neighbor struct {
+	ChassisID       string `json:"chassisID,omitempty"`
+	SystemName      string `json:"systemName,omitempty"`
+	PortID          string `json:"portID,omitempty"`
+	PortDescription string `json:"portDescription,omitempty"`
+}
+
+// ParseNeighbors parses the value of the node annotation KeyLLDPNeighbors, a map from the NIC name to its neighbor
+func ParseNeighbors(value string) (map[string]Neighbor, error) {
+	neighbors := map[string]Neighbor{}
+	if value == "" {
+		return neighbors, nil
+	}
+	if err := json.Unmarshal([]byte(value), &neighbors); err != nil {
+		return nil, fmt.Errorf("invalid LLDP neighbors %q, error: %w", value, err)
+	}
+
+	return neighbors, nil
+}
+
+// State is the desired switch-side state of a node
+type State struct {
+	Node    string   `json:"node"`
+	Uplinks []Uplink `json:"uplinks"`
+}
+
+// Uplink is the desired state of the switch ports connected to the uplink of a cluster network
+type Uplink struct {
+	ClusterNetwork string `json:"clusterNetwork"`
+	VlanConfig     string `json:"vlanConfig"`
+	// the bond mode, e.g. 802.3ad requires the switch ports to be aggregated
+	BondMode networkv1.BondMode `json:"bondMode,omitempty"`
+	MTU      int                `json:"mtu,omitempty"`
+	// the VLAN ID of the shared bond sub-interface the cluster network is attached to, which the ports have to trunk
+	SharedBondVID uint16 `json:"sharedBondVID,omitempty"`
+	// the VLAN IDs of the networks on the cluster network, in ascending order
+	VIDs []uint16 `json:"vids"`
+	// the VLAN IDs of the networks the VMs on the node are attached to, in ascending order, a subset of VIDs
+	InUseVIDs []uint16 `json:"inUseVIDs"`
+	Ports     []Port   `json:"ports"`
+}
+
+type Port struct {
+	NIC string `json:"nic"`
+	// B if the NIC is in the uplink group connected to the second fabric
+	Fabric string `json:"fabric,omitempty"`
+	// nil if the LLDP neighbor of the NIC is unknown
+	Switch *Neighbor `json:"switch,omitempty"`
+}
+
+// NewUplink returns the desired state of the switch ports connected to the uplink of the vlanconfig
+func NewUplink(vc *networkv1.VlanConfig, vids, inUseVIDs []uint16, neighbors map[string]Neighbor) Uplink {
+	uplink := Uplink{
+		ClusterNetwork: vc.Spec.ClusterNetwork,
+		VlanConfig:     vc.Name,
+		VIDs:           sortedVIDs(vids),
+		InUseVIDs:      sortedVIDs(inUseVIDs),
+		Ports:          []Port{},
+	}
+	if vc.Spec.Uplink.BondOptions != nil {
+		uplink.BondMode = vc.Spec.Uplink.BondOptions.Mode
+	}
+	if vc.Spec.Uplink.LinkAttrs != nil {
+		uplink.MTU = vc.Spec.Uplink.LinkAttrs.MTU
+	}
+	if vc.Spec.Uplink.SharedBond != nil {
+		uplink.SharedBondVID = vc.Spec.Uplink.SharedBond.VID
+	}
+
+	addPort := func(nic, fabric string) {
+		port := Port{NIC: nic, Fabric: fabric}
+		if neighbor, ok := neighbors[nic]; ok {
+			port.Switch = &neighbor
+		}
+		uplink.Ports = append(uplink.Ports, port)
+	}
+	for _, nic := range vc.Spec.Uplink.NICs {
+		addPort(nic, "")
+	}
+	if vc.Spec.Uplink.FabricB != nil {
+		for _, nic := range vc.Spec.Uplink.FabricB.NICs {
+			addPort(nic, fabricB)
+		}
+	}
+
+	return uplink
+}
+
+func sortedVIDs(vids []uint16) []uint16 {
+	sorted := append([]uint16{}, vids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// Sort sorts the uplinks by the cluster network so that the same state is always encoded the same
+func (s *State) Sort() {
+	sort.Slice(s.Uplinks, func(i, j int) bool { return s.Uplinks[i].ClusterNetwork < s.Uplinks[j].ClusterNetwork })
+}
+
+// Encode returns the state in JSON and its hash
+func (s *State) Encode() (body []byte, hash string, err error) {
+	body, err = json.Marshal(s)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(body)
+
+	return body, hex.EncodeToString(sum[:]), nil
+}
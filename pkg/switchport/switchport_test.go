@@ -0,0 +1,83 @@
+package switchport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse("")
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	cfg, err = Parse(`{"url":"https://netbox.example.com/api/trunks","insecureSkipTLSVerify":true,"timeoutSeconds":5}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://netbox.example.com/api/trunks", cfg.URL)
+	assert.True(t, cfg.InsecureSkipTLSVerify)
+	assert.Equal(t, 5*time.Second, cfg.Timeout())
+
+	_, err = Parse(`{"url":"netbox.example.com"}`)
+	assert.ErrorContains(t, err, "must be http or https")
+
+	_, err = Parse(`{"url":"http://netbox","timeout":5}`)
+	assert.ErrorContains(t, err, "unknown field")
+}
+
+func TestParseNeighbors(t *testing.T) {
+	neighbors, err := ParseNeighbors(`{"eth1":{"systemName":"leaf1","portID":"Ethernet1/1"}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Neighbor{"eth1": {SystemName: "leaf1", PortID: "Ethernet1/1"}}, neighbors)
+
+	neighbors, err = ParseNeighbors("")
+	assert.NoError(t, err)
+	assert.Empty(t, neighbors)
+
+	_, err = ParseNeighbors("eth1=leaf1")
+	assert.Error(t, err)
+}
+
+func TestState(t *testing.T) {
+	vc := &networkv1.VlanConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc1"},
+		Spec: networkv1.VlanConfigSpec{
+			ClusterNetwork: "cn1",
+			Uplink: networkv1.Uplink{
+				NICs:        []string{"eth1"},
+				FabricB:     &networkv1.FabricUplink{NICs: []string{"eth2"}},
+				LinkAttrs:   &networkv1.LinkAttrs{MTU: 9000},
+				BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD},
+			},
+		},
+	}
+	neighbors := map[string]Neighbor{"eth1": {SystemName: "leaf1", PortID: "Ethernet1/1"}}
+
+	uplink := NewUplink(vc, []uint16{200, 100}, []uint16{200}, neighbors)
+	assert.Equal(t, Uplink{
+		ClusterNetwork: "cn1",
+		VlanConfig:     "vc1",
+		BondMode:       networkv1.BondMode8023AD,
+		MTU:            9000,
+		VIDs:           []uint16{100, 200},
+		InUseVIDs:      []uint16{200},
+		Ports: []Port{
+			{NIC: "eth1", Switch: &Neighbor{SystemName: "leaf1", PortID: "Ethernet1/1"}},
+			{NIC: "eth2", Fabric: fabricB},
+		},
+	}, uplink)
+
+	other := Uplink{ClusterNetwork: "cn0", VlanConfig: "vc0", VIDs: []uint16{}, InUseVIDs: []uint16{}, Ports: []Port{}}
+	a := &State{Node: "node1", Uplinks: []Uplink{uplink, other}}
+	b := &State{Node: "node1", Uplinks: []Uplink{other, uplink}}
+	a.Sort()
+	b.Sort()
+	_, hashA, err := a.Encode()
+	assert.NoError(t, err)
+	_, hashB, err := b.Encode()
+	assert.NoError(t, err)
+	assert.Equal(t, hashA, hashB)
+}
@@ -0,0 +1,71 @@
+package testing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	"github.com/harvester/harvester-network-controller/pkg/config"
+)
+
+// StartAPIServer starts an etcd and a kube-apiserver with the CRDs of the repository and the dependencies installed,
+// the test is skipped if the binaries aren't installed, see `setup-envtest use -p path`
+func StartAPIServer(t testing.TB) *rest.Config {
+	t.Helper()
+
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("skip the test which requires KUBEBUILDER_ASSETS to locate the etcd and kube-apiserver binaries")
+	}
+
+	manifests := filepath.Join(repositoryRoot(), "manifests")
+	env := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join(manifests, "crds"),
+			filepath.Join(manifests, "dependency_crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := env.Start()
+	if err != nil {
+		t.Fatalf("failed to start the API server, error: %s", err.Error())
+	}
+	t.Cleanup(func() {
+		if err := env.Stop(); err != nil {
+			t.Errorf("failed to stop the API server, error: %s", err.Error())
+		}
+	})
+
+	return cfg
+}
+
+// StartManagement registers the controllers of the funcs and starts them against the API server until the test
+// finishes
+func StartManagement(t testing.TB, cfg *rest.Config, options *config.Options, funcs ...config.RegisterFunc) *config.Management {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	management, err := config.SetupManagement(ctx, cfg, options)
+	if err != nil {
+		t.Fatalf("failed to set up the management, error: %s", err.Error())
+	}
+	if err := management.Register(ctx, cfg, funcs); err != nil {
+		t.Fatalf("failed to register the controllers, error: %s", err.Error())
+	}
+	if err := management.Start(1); err != nil {
+		t.Fatalf("failed to start the controllers, error: %s", err.Error())
+	}
+
+	return management
+}
+
+func repositoryRoot() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..")
+}
@@ -0,0 +1,25 @@
+package testing_test
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned"
+	nettesting "github.com/harvester/harvester-network-controller/pkg/testing"
+)
+
+func TestAPIServer(t *testing.T) {
+	cfg := nettesting.StartAPIServer(t)
+
+	client, err := versioned.NewForConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cn := &networkv1.ClusterNetwork{ObjectMeta: metav1.ObjectMeta{Name: "cn1"}}
+	if _, err := client.NetworkV1beta1().ClusterNetworks().Create(context.Background(), cn, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("expected the CRD of the cluster network to be installed, error: %s", err.Error())
+	}
+}
@@ -0,0 +1,147 @@
+// Package testing is the harness of the regression tests running the real code of the controllers against an API
+// server started by envtest and the netlink code of the agent in network namespaces with fake physical NICs.
+package testing
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+)
+
+// PeerSuffix is appended to the name of a fake NIC to name its peer, which plays the switch port
+const PeerSuffix = "-sw"
+
+// NetNS is a network namespace with fake physical NICs, i.e. veth pairs whose peers stay in the namespace as the
+// switch ports. The namespace is released when the test finishes.
+type NetNS struct {
+	handle netns.NsHandle
+}
+
+// NewNetNS creates a network namespace with the fake NICs which are set up, the test is skipped if the process can't
+// create a network namespace, e.g. without CAP_NET_ADMIN
+func NewNetNS(t testing.TB, nics ...string) *NetNS {
+	t.Helper()
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	origin, err := netns.Get()
+	if err != nil {
+		t.Fatalf("failed to get the current network namespace, error: %s", err.Error())
+	}
+	defer origin.Close()
+
+	handle, err := netns.New()
+	if err != nil {
+		t.Skipf("skip the test which can't create a network namespace, error: %s", err.Error())
+	}
+	// switch back before the test fails, the locked thread must not stay in the namespace
+	setupErr := addFakeNICs(nics)
+	if err := netns.Set(origin); err != nil {
+		t.Fatalf("failed to switch back to the network namespace, error: %s", err.Error())
+	}
+	if setupErr != nil {
+		handle.Close()
+		t.Fatalf("failed to add the fake NICs, error: %s", setupErr.Error())
+	}
+
+	n := &NetNS{handle: handle}
+	t.Cleanup(func() { n.handle.Close() })
+	return n
+}
+
+func addFakeNICs(nics []string) error {
+	lo, err := netlink.LinkByName("lo")
+	if err != nil {
+		return err
+	}
+	if err := netlink.LinkSetUp(lo); err != nil {
+		return err
+	}
+
+	for _, nic := range nics {
+		veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: nic}, PeerName: nic + PeerSuffix}
+		if err := netlink.LinkAdd(veth); err != nil {
+			return fmt.Errorf("add %s failed, error: %w", nic, err)
+		}
+		for _, name := range []string{nic, nic + PeerSuffix} {
+			l, err := netlink.LinkByName(name)
+			if err != nil {
+				return err
+			}
+			if err := netlink.LinkSetUp(l); err != nil {
+				return fmt.Errorf("set %s up failed, error: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Do runs the function in the network namespace. The network code called by the function, including the shared
+// netlink handle, works on the namespace, but only on the goroutine of the function, so the function must call the
+// network code directly rather than through the goroutines of the controllers.
+func (n *NetNS) Do(fn func() error) error {
+	// the shared handle of the process is opened in the current namespace, it would be opened in the namespace of the
+	// test and restored to it if the first network code of the process ran here
+	network.Handle()
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	origin, err := netns.Get()
+	if err != nil {
+		return err
+	}
+	defer origin.Close()
+
+	if err := netns.Set(n.handle); err != nil {
+		return err
+	}
+	restore := network.UseNamespace(n.handle)
+	fnErr := fn()
+	restore()
+
+	return errors.Join(fnErr, netns.Set(origin))
+}
+
+// VlanFilteringBridge is the link type of the VLAN aware bridges the cluster networks are built on
+const VlanFilteringBridge = "bridge-vlan-filtering"
+
+// RequireLinkType skips the test if the kernel can't create the links of the type, e.g. bond without the bonding
+// module loaded
+func (n *NetNS) RequireLinkType(t testing.TB, linkType string) {
+	t.Helper()
+
+	var link netlink.Link
+	attrs := netlink.LinkAttrs{Name: "probe0"}
+	vlanFiltering := true
+	switch linkType {
+	case "bond":
+		link = netlink.NewLinkBond(attrs)
+	case "bridge":
+		link = &netlink.Bridge{LinkAttrs: attrs}
+	case VlanFilteringBridge:
+		link = &netlink.Bridge{LinkAttrs: attrs, VlanFiltering: &vlanFiltering}
+	case "vxlan":
+		link = &netlink.Vxlan{LinkAttrs: attrs, VxlanId: 1}
+	default:
+		t.Fatalf("unknown link type %s", linkType)
+	}
+
+	err := n.Do(func() error {
+		if err := netlink.LinkAdd(link); err != nil {
+			return err
+		}
+		return netlink.LinkDel(link)
+	})
+	if err != nil {
+		t.Skipf("skip the test which requires the link type %s, error: %s", linkType, err.Error())
+	}
+}
@@ -0,0 +1,143 @@
+package testing_test
+
+import (
+	"testing"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	nettesting "github.com/harvester/harvester-network-controller/pkg/testing"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+func TestVlanLifecycle(t *testing.T) {
+	const cnName = "cn1"
+	// the fake NIC plays the bond of the cluster network, the bond itself is tested only where the kernel has bonding
+	ns := nettesting.NewNetNS(t, utils.GenerateBondName(cnName))
+	ns.RequireLinkType(t, nettesting.VlanFilteringBridge)
+
+	err := ns.Do(func() error {
+		uplink, err := network.Handle().LinkByName(utils.GenerateBondName(cnName))
+		if err != nil {
+			return err
+		}
+		if err := vlan.NewVlan(cnName).Setup(iface.NewLink(uplink)); err != nil {
+			return err
+		}
+
+		v, err := vlan.GetVlan(cnName)
+		if err != nil {
+			return err
+		}
+		vids, err := utils.NewVlanIDSetFromSingleVID(100)
+		if err != nil {
+			return err
+		}
+		if err := v.AddLocalAreas(vids); err != nil {
+			return err
+		}
+
+		programmed, err := v.ToVlanIDSet()
+		if err != nil {
+			return err
+		}
+		if got := programmed.VIDs(); len(got) != 1 || got[0] != 100 {
+			t.Errorf("expected the VID 100 on the uplink, got %v", got)
+		}
+
+		if err := v.Teardown(); err != nil {
+			return err
+		}
+		if _, err := netlink.LinkByName(utils.GenerateBridgeName(cnName)); err == nil {
+			t.Errorf("expected the bridge of %s to be removed", cnName)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNetNSIsolation(t *testing.T) {
+	const nic = "fake0"
+	ns := nettesting.NewNetNS(t, nic)
+
+	err := ns.Do(func() error {
+		l, err := network.Handle().LinkByName(nic)
+		if err != nil {
+			return err
+		}
+		if l.Attrs().OperState != netlink.OperUp {
+			t.Errorf("expected %s to be up, got %s", nic, l.Attrs().OperState)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := network.Handle().LinkByName(nic); err == nil {
+		t.Errorf("expected %s not to be visible outside of the namespace", nic)
+	}
+}
+
+func TestResetQueueQdiscs(t *testing.T) {
+	const perQueueQdisc = "pfifo"
+	ns := nettesting.NewNetNS(t)
+
+	queueQdiscs := func(l *iface.Link) ([]string, error) {
+		qdiscs, err := network.Handle().QdiscList(l)
+		if err != nil {
+			return nil, err
+		}
+		types := make([]string, 0)
+		for _, q := range qdiscs {
+			if q.Attrs().Parent != netlink.HANDLE_ROOT {
+				types = append(types, q.Type())
+			}
+		}
+		return types, nil
+	}
+
+	err := ns.Do(func() error {
+		veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "mq0", NumTxQueues: 2},
+			PeerName: "mq0" + nettesting.PeerSuffix}
+		if err := network.Handle().LinkAdd(veth); err != nil {
+			return err
+		}
+		l, err := network.Handle().LinkByName("mq0")
+		if err != nil {
+			return err
+		}
+		link := iface.NewLink(l)
+
+		// pfifo plays the per-queue qdisc, fq and fq_codel are modules missing from some kernels
+		if err := link.EnsureQdisc(&iface.QueueConfig{Qdisc: iface.QdiscMq, PerQueueQdisc: perQueueQdisc}); err != nil {
+			return err
+		}
+		types, err := queueQdiscs(link)
+		if err != nil {
+			return err
+		}
+		if len(types) != 2 || types[0] != perQueueQdisc || types[1] != perQueueQdisc {
+			t.Errorf("expected %s on both TX queues, got %v", perQueueQdisc, types)
+		}
+
+		// the per-queue qdisc is removed while the root stays mq
+		if err := link.EnsureQdisc(&iface.QueueConfig{Qdisc: iface.QdiscMq}); err != nil {
+			return err
+		}
+		if types, err = queueQdiscs(link); err != nil {
+			return err
+		}
+		if len(types) != 2 || types[0] == perQueueQdisc || types[1] == perQueueQdisc {
+			t.Errorf("expected the default qdisc on both TX queues, got %v", types)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// BondModeOf returns the mode of the bond options, active-backup if it's omitted
+func BondModeOf(opts *networkv1.BondOptions) networkv1.BondMode {
+	if opts == nil || opts.Mode == "" {
+		return networkv1.BondMoDeActiveBackup
+	}
+	return opts.Mode
+}
+
+// IsHashingBondMode returns true if the bond mode picks the slave to transmit on by the xmit hash policy
+func IsHashingBondMode(mode networkv1.BondMode) bool {
+	return mode == networkv1.BondModeBalanceXor || mode == networkv1.BondMode8023AD || mode == networkv1.BondModeBalanceTlb
+}
+
+// CheckXmitHashPolicy rejects the xmit hash policy in the bond modes which ignore it, the kernel accepts it silently
+// and the traffic is not balanced as expected
+func CheckXmitHashPolicy(opts *networkv1.BondOptions) error {
+	if opts == nil || opts.XmitHashPolicy == "" {
+		return nil
+	}
+
+	if mode := BondModeOf(opts); !IsHashingBondMode(mode) {
+		return fmt.Errorf("the xmit hash policy %s is meaningless in the bond mode %s, it only applies to the modes %s, %s and %s",
+			opts.XmitHashPolicy, mode, networkv1.BondModeBalanceXor, networkv1.BondMode8023AD, networkv1.BondModeBalanceTlb)
+	}
+
+	return nil
+}
+
+// IsArpMonitorBondMode returns true if the kernel supports the ARP monitoring in the bond mode
+func IsArpMonitorBondMode(mode networkv1.BondMode) bool {
+	return mode != networkv1.BondMode8023AD && mode != networkv1.BondModeBalanceTlb && mode != networkv1.BondModeBalanceAlb
+}
+
+// ArpIPTargetsOf parses the ARP targets of the bond options, the invalid ones are left out
+func ArpIPTargetsOf(opts *networkv1.BondOptions) []net.IP {
+	if opts == nil {
+		return nil
+	}
+
+	targets := make([]net.IP, 0, len(opts.ArpIPTargets))
+	for _, target := range opts.ArpIPTargets {
+		if ip := net.ParseIP(target).To4(); ip != nil {
+			targets = append(targets, ip)
+		}
+	}
+	return targets
+}
+
+// IsCarrierTracked returns true if the bond has no link monitoring, miimon and the ARP monitoring are both off, e.g.
+// for the virtual NICs of the nested clusters which don't support miimon. The agent tracks the carrier of the NICs
+// instead, the kernel would take them as up forever.
+func IsCarrierTracked(opts *networkv1.BondOptions) bool {
+	return opts != nil && opts.Miimon == 0 && opts.ArpInterval == 0
+}
+
+// CheckArpMonitor makes sure the ARP monitoring has an interval and targets, the kernel accepts an interval without
+// targets and takes all the slaves down. It replaces miimon, the kernel turns one off once the other is set.
+func CheckArpMonitor(opts *networkv1.BondOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.ArpInterval == 0 {
+		if len(opts.ArpIPTargets) != 0 || opts.ArpValidate != "" {
+			return fmt.Errorf("the ARP targets and validation require the ARP interval")
+		}
+		return nil
+	}
+
+	if mode := BondModeOf(opts); !IsArpMonitorBondMode(mode) {
+		return fmt.Errorf("the ARP monitoring is not supported in the bond mode %s", mode)
+	}
+	if opts.Miimon > 0 {
+		return fmt.Errorf("miimon %d and the ARP monitoring are exclusive, omit miimon or set it to 0", opts.Miimon)
+	}
+	if len(opts.ArpIPTargets) == 0 {
+		return fmt.Errorf("the ARP monitoring requires at least one ARP target")
+	}
+	seen := make(map[string]bool, len(opts.ArpIPTargets))
+	for _, target := range opts.ArpIPTargets {
+		ip := net.ParseIP(target).To4()
+		if ip == nil || !ip.IsGlobalUnicast() {
+			return fmt.Errorf("the ARP target %s is not an IPv4 unicast address", target)
+		}
+		if seen[ip.String()] {
+			return fmt.Errorf("the ARP target %s is duplicated", target)
+		}
+		seen[ip.String()] = true
+	}
+
+	return nil
+}
+
+// CheckLacpRate rejects the LACP rate in the bond modes other than 802.3ad, the kernel refuses to set it
+func CheckLacpRate(opts *networkv1.BondOptions) error {
+	if opts == nil || opts.LacpRate == "" {
+		return nil
+	}
+
+	if mode := BondModeOf(opts); mode != networkv1.BondMode8023AD {
+		return fmt.Errorf("the LACP rate %s is meaningless in the bond mode %s, it only applies to the mode %s",
+			opts.LacpRate, mode, networkv1.BondMode8023AD)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestCheckXmitHashPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   *networkv1.BondOptions
+		errKey string
+	}{
+		{
+			name: "no bond options",
+		},
+		{
+			name: "802.3ad without hash policy",
+			opts: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD},
+		},
+		{
+			name: "802.3ad with layer3+4",
+			opts: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, XmitHashPolicy: networkv1.XmitHashPolicyLayer34},
+		},
+		{
+			name: "balance-xor with vlan+srcmac",
+			opts: &networkv1.BondOptions{Mode: networkv1.BondModeBalanceXor, XmitHashPolicy: networkv1.XmitHashPolicyVlanSrcMAC},
+		},
+		{
+			name:   "the omitted mode is active-backup",
+			opts:   &networkv1.BondOptions{XmitHashPolicy: networkv1.XmitHashPolicyLayer23},
+			errKey: "meaningless in the bond mode active-backup",
+		},
+		{
+			name:   "balance-alb ignores the hash policy",
+			opts:   &networkv1.BondOptions{Mode: networkv1.BondModeBalanceAlb, XmitHashPolicy: networkv1.XmitHashPolicyLayer34},
+			errKey: "meaningless in the bond mode balance-alb",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckXmitHashPolicy(tc.opts)
+			if tc.errKey == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.errKey)
+		})
+	}
+}
+
+func TestCheckArpMonitor(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   *networkv1.BondOptions
+		errKey string
+	}{
+		{
+			name: "no bond options",
+		},
+		{
+			name: "miimon only",
+			opts: &networkv1.BondOptions{Miimon: 100},
+		},
+		{
+			name: "ARP monitoring in the default mode",
+			opts: &networkv1.BondOptions{Miimon: -1, ArpInterval: 1000, ArpIPTargets: []string{"192.168.1.1", "192.168.1.2"},
+				ArpValidate: networkv1.ArpValidateAll},
+		},
+		{
+			name:   "targets without interval",
+			opts:   &networkv1.BondOptions{ArpIPTargets: []string{"192.168.1.1"}},
+			errKey: "require the ARP interval",
+		},
+		{
+			name:   "interval without targets",
+			opts:   &networkv1.BondOptions{ArpInterval: 1000},
+			errKey: "at least one ARP target",
+		},
+		{
+			name:   "miimon together with ARP",
+			opts:   &networkv1.BondOptions{Miimon: 100, ArpInterval: 1000, ArpIPTargets: []string{"192.168.1.1"}},
+			errKey: "are exclusive",
+		},
+		{
+			name: "802.3ad doesn't support ARP",
+			opts: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, ArpInterval: 1000,
+				ArpIPTargets: []string{"192.168.1.1"}},
+			errKey: "not supported in the bond mode 802.3ad",
+		},
+		{
+			name:   "IPv6 target",
+			opts:   &networkv1.BondOptions{ArpInterval: 1000, ArpIPTargets: []string{"fd00::1"}},
+			errKey: "not an IPv4 unicast address",
+		},
+		{
+			name:   "broadcast target",
+			opts:   &networkv1.BondOptions{ArpInterval: 1000, ArpIPTargets: []string{"255.255.255.255"}},
+			errKey: "not an IPv4 unicast address",
+		},
+		{
+			name:   "duplicated target",
+			opts:   &networkv1.BondOptions{ArpInterval: 1000, ArpIPTargets: []string{"192.168.1.1", "192.168.1.1"}},
+			errKey: "is duplicated",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckArpMonitor(tc.opts)
+			if tc.errKey == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.errKey)
+		})
+	}
+}
+
+func TestCheckLacpRate(t *testing.T) {
+	assert.NoError(t, CheckLacpRate(nil))
+	assert.NoError(t, CheckLacpRate(&networkv1.BondOptions{Mode: networkv1.BondMode8023AD}))
+	assert.NoError(t, CheckLacpRate(&networkv1.BondOptions{Mode: networkv1.BondMode8023AD, LacpRate: networkv1.LacpRateFast}))
+	assert.ErrorContains(t, CheckLacpRate(&networkv1.BondOptions{LacpRate: networkv1.LacpRateSlow}),
+		"meaningless in the bond mode active-backup")
+	assert.ErrorContains(t, CheckLacpRate(&networkv1.BondOptions{Mode: networkv1.BondModeBalanceXor, LacpRate: networkv1.LacpRateFast}),
+		"meaningless in the bond mode balance-xor")
+}
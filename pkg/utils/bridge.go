@@ -2,18 +2,27 @@ package utils
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
 const (
 	BridgeSuffix       = "-br"
 	BondSuffix         = "-bo"
+	FabricBBondSuffix  = "-fb"
+	VtepSuffix         = "-vx"
 	DefaultValueMiimon = 100
 
-	LenOfBridgeSuffix = 3 // length of BridgeSuffix
-	LenOfBondSuffix   = 3 // length of BondSuffix
+	LenOfBridgeSuffix      = 3 // length of BridgeSuffix
+	LenOfBondSuffix        = 3 // length of BondSuffix
+	LenOfFabricBBondSuffix = 3 // length of FabricBBondSuffix
+	LenOfVtepSuffix        = 3 // length of VtepSuffix
 
 	MaxDeviceNameLen = 15
+	// the longest suffix of the links per VID, e.g. -4094 of the bridge per VID
+	LenOfPerVIDSuffix = 5
+	// the longest suffix of the links per S-VLAN of QinQ, e.g. -q4094 of the QinQ bridge
+	LenOfQinQSuffix = 6
 
 	VlanSubInterfaceSpliter = "."
 
@@ -99,6 +108,16 @@ func GenerateBondName(prefix string) string {
 	return generateName(prefix, BondSuffix, LenOfBondSuffix)
 }
 
+// the bond of the uplink group connected to fabric B
+func GenerateFabricBBondName(prefix string) string {
+	return generateName(prefix, FabricBBondSuffix, LenOfFabricBBondSuffix)
+}
+
+// the VXLAN tunnel endpoint of an overlay cluster network
+func GenerateVtepName(prefix string) string {
+	return generateName(prefix, VtepSuffix, LenOfVtepSuffix)
+}
+
 func IsHostNetworkIntfNameValid(cn string, vlanid uint16) error {
 	vlanIntfName := GetClusterNetworkVlanDevice(cn, vlanid)
 
@@ -108,3 +127,84 @@ func IsHostNetworkIntfNameValid(cn string, vlanid uint16) error {
 
 	return nil
 }
+
+// GeneratePerVIDBridgeName returns the bridge of the VID of a cluster network with the datapath BridgePerVID, e.g.
+// cn2-2025. It never ends with BridgeSuffix, so it's told apart from the bridge of a cluster network.
+func GeneratePerVIDBridgeName(cnName string, vid uint16) string {
+	return fmt.Sprintf("%s-%d", cnName, vid)
+}
+
+// GeneratePerVIDUplinkName returns the VLAN sub-interface of the uplink attached to the bridge of the VID, e.g.
+// cn2.2025
+func GeneratePerVIDUplinkName(cnName string, vid uint16) string {
+	return fmt.Sprintf("%s%s%d", cnName, VlanSubInterfaceSpliter, vid)
+}
+
+// ParsePerVIDBridgeName returns the cluster network and the VID of a bridge per VID, ok is false if the name is not
+// the one of a bridge per VID
+func ParsePerVIDBridgeName(brName string) (cnName string, vid uint16, ok bool) {
+	i := strings.LastIndex(brName, "-")
+	if i <= 0 || strings.HasSuffix(brName, BridgeSuffix) {
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(brName[i+1:], 10, 16)
+	if err != nil || id < 1 || id > MaxVlanID || brName[i+1] == '0' {
+		return "", 0, false
+	}
+
+	return brName[:i], uint16(id), true
+}
+
+// GenerateQinQBridgeName returns the VLAN filtering bridge of the QinQ NADs of a cluster network whose outer S-VLAN is
+// the VID, e.g. cn2-q100. The C-VLANs of the NADs are filtered by the bridge.
+func GenerateQinQBridgeName(cnName string, svid uint16) string {
+	return fmt.Sprintf("%s-q%d", cnName, svid)
+}
+
+// GenerateQinQUplinkName returns the 802.1ad VLAN sub-interface of the uplink attached to the QinQ bridge of the
+// S-VLAN, e.g. cn2.q100
+func GenerateQinQUplinkName(cnName string, svid uint16) string {
+	return fmt.Sprintf("%s%sq%d", cnName, VlanSubInterfaceSpliter, svid)
+}
+
+// ParseQinQBridgeName returns the cluster network and the S-VLAN of a QinQ bridge, ok is false if the name is not the
+// one of a QinQ bridge
+func ParseQinQBridgeName(brName string) (cnName string, svid uint16, ok bool) {
+	i := strings.LastIndex(brName, "-q")
+	if i <= 0 || i+2 >= len(brName) || brName[i+2] == '0' {
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(brName[i+2:], 10, 16)
+	if err != nil || id < 1 || id > MaxVlanID {
+		return "", 0, false
+	}
+
+	return brName[:i], uint16(id), true
+}
+
+// GenerateBridgelessMaster returns the master of the macvlan or ipvlan links of the VID of a bridge-less cluster
+// network, the bond for the untagged VID and the VLAN sub-interface of the bond for the others, e.g. cn2.2025
+func GenerateBridgelessMaster(cnName string, vid uint16) string {
+	if vid <= DefaultVlanID {
+		return GenerateBondName(cnName)
+	}
+	return GeneratePerVIDUplinkName(cnName, vid)
+}
+
+// ParseBridgelessMaster returns the cluster network and the VID of the master of the macvlan or ipvlan links of a
+// bridge-less cluster network, the VID is 0 for the bond. ok is false if the name is not the one of such a master.
+func ParseBridgelessMaster(master string) (cnName string, vid uint16, ok bool) {
+	if strings.HasSuffix(master, BondSuffix) && len(master) > LenOfBondSuffix {
+		return strings.TrimSuffix(master, BondSuffix), 0, true
+	}
+	i := strings.LastIndex(master, VlanSubInterfaceSpliter)
+	if i <= 0 || i+1 >= len(master) || master[i+1] == '0' || strings.HasSuffix(master[:i], BridgeSuffix) {
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(master[i+1:], 10, 16)
+	if err != nil || id <= DefaultVlanID || id > MaxVlanID {
+		return "", 0, false
+	}
+
+	return master[:i], uint16(id), true
+}
@@ -40,3 +40,96 @@ func TestBridgeRelatedFunctions(t *testing.T) {
 		})
 	}
 }
+
+func TestPerVIDBridgeName(t *testing.T) {
+	assert.Equal(t, "cn2-100", GeneratePerVIDBridgeName(cn2, 100))
+	assert.Equal(t, "cn2.100", GeneratePerVIDUplinkName(cn2, 100))
+
+	tests := []struct {
+		brName string
+		cnName string
+		vid    uint16
+		ok     bool
+	}{
+		{brName: "cn2-100", cnName: cn2, vid: 100, ok: true},
+		{brName: "a-b-4094", cnName: "a-b", vid: 4094, ok: true},
+		{brName: "cn2-br"},
+		{brName: "cn2-0100"},
+		{brName: "cn2-0"},
+		{brName: "cn2-4095"},
+		{brName: "-100"},
+		{brName: "cn2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.brName, func(t *testing.T) {
+			cnName, vid, ok := ParsePerVIDBridgeName(tc.brName)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.cnName, cnName)
+			assert.Equal(t, tc.vid, vid)
+		})
+	}
+}
+
+func TestQinQBridgeName(t *testing.T) {
+	assert.Equal(t, "cn2-q100", GenerateQinQBridgeName(cn2, 100))
+	assert.Equal(t, "cn2.q100", GenerateQinQUplinkName(cn2, 100))
+
+	tests := []struct {
+		brName string
+		cnName string
+		svid   uint16
+		ok     bool
+	}{
+		{brName: "cn2-q100", cnName: cn2, svid: 100, ok: true},
+		{brName: "a-q1-q4094", cnName: "a-q1", svid: 4094, ok: true},
+		{brName: "a-q1-br"},
+		{brName: "cn2-100"},
+		{brName: "cn2-q0100"},
+		{brName: "cn2-q4095"},
+		{brName: "cn2-q"},
+		{brName: "-q100"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.brName, func(t *testing.T) {
+			cnName, svid, ok := ParseQinQBridgeName(tc.brName)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.cnName, cnName)
+			assert.Equal(t, tc.svid, svid)
+		})
+	}
+}
+
+func TestBridgelessMaster(t *testing.T) {
+	assert.Equal(t, "cn2-bo", GenerateBridgelessMaster(cn2, 0))
+	assert.Equal(t, "cn2-bo", GenerateBridgelessMaster(cn2, DefaultVlanID))
+	assert.Equal(t, "cn2.100", GenerateBridgelessMaster(cn2, 100))
+
+	tests := []struct {
+		master string
+		cnName string
+		vid    uint16
+		ok     bool
+	}{
+		{master: "cn2-bo", cnName: cn2, ok: true},
+		{master: "cn2.100", cnName: cn2, vid: 100, ok: true},
+		{master: "a.b.4094", cnName: "a.b", vid: 4094, ok: true},
+		{master: "cn2-br.100"},
+		{master: "cn2.1"},
+		{master: "cn2.0100"},
+		{master: "cn2.4095"},
+		{master: "cn2.q100"},
+		{master: "-bo"},
+		{master: "eth0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.master, func(t *testing.T) {
+			cnName, vid, ok := ParseBridgelessMaster(tc.master)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.cnName, cnName)
+			assert.Equal(t, tc.vid, vid)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+)
+
+// BridgeConsumers are what still uses the bridge of a cluster network on a node, the agent defers the teardown of the
+// bridge while there are any instead of cutting the VMs off
+type BridgeConsumers struct {
+	// Ports are the ports of the VMs and the pods on the bridge, the uplink excluded
+	Ports []string
+	// NADs are the <namespace>/<name> of the NADs of the cluster network whose VIDs the ports use
+	NADs []string
+}
+
+// NewBridgeConsumers returns the consumers of the bridge from the ports per VID, see vlan.Vlan.VlanUsers, and the NADs
+// of the cluster network. The ports of the untagged NADs have the untagged VID of the bridge.
+func NewBridgeConsumers(nads []*nadv1.NetworkAttachmentDefinition, users map[uint16][]string,
+	untaggedVID uint16) (*BridgeConsumers, error) {
+	c := &BridgeConsumers{}
+	for _, ports := range users {
+		for _, port := range ports {
+			if !slices.Contains(c.Ports, port) {
+				c.Ports = append(c.Ports, port)
+			}
+		}
+	}
+	if len(c.Ports) == 0 {
+		return c, nil
+	}
+
+	for _, nad := range nads {
+		nc, err := DecodeNadConfigToNetConf(nad)
+		if err != nil {
+			return nil, err
+		}
+		if !nc.IsBridgeCNI() {
+			continue
+		}
+		vis, err := nc.dumpVlanIDSet()
+		if err != nil {
+			return nil, err
+		}
+		vids := vis.VIDs()
+		if nc.IsVlanAccessMode() && nc.Vlan == MinVlanID {
+			vids = []uint16{untaggedVID}
+		}
+		for _, vid := range vids {
+			if len(users[vid]) != 0 {
+				c.NADs = append(c.NADs, nad.Namespace+"/"+nad.Name)
+				break
+			}
+		}
+	}
+	slices.Sort(c.Ports)
+	slices.Sort(c.NADs)
+
+	return c, nil
+}
+
+// IsEmpty returns true if nothing uses the bridge, it can be torn down
+func (c *BridgeConsumers) IsEmpty() bool {
+	return len(c.Ports) == 0
+}
+
+func (c *BridgeConsumers) String() string {
+	return fmt.Sprintf("%d port(s) %v of NAD(s) %v", len(c.Ports), c.Ports, c.NADs)
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"testing"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewBridgeConsumers(t *testing.T) {
+	newNad := func(name, config string) *nadv1.NetworkAttachmentDefinition {
+		return &nadv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec:       nadv1.NetworkAttachmentDefinitionSpec{Config: config},
+		}
+	}
+	nads := []*nadv1.NetworkAttachmentDefinition{
+		newNad("vlan300", testNadConfigVlan300),
+		newNad("vlan350", testNadConfigVlan350),
+		newNad("untagged", testNadConfigVlanUntag),
+		newNad("trunk", testNadConfigVlanTrunk),
+		newNad("ovn", testNadConfigOVN),
+	}
+
+	tests := []struct {
+		name  string
+		users map[uint16][]string
+		ports []string
+		nads  []string
+	}{
+		{
+			name: "no port",
+		},
+		{
+			name:  "the ports of an access NAD",
+			users: map[uint16][]string{300: {"tap2", "tap1"}},
+			ports: []string{"tap1", "tap2"},
+			nads:  []string{"test/trunk", "test/vlan300"},
+		},
+		{
+			name:  "the port of an untagged NAD has the default VID",
+			users: map[uint16][]string{1: {"tap1"}},
+			ports: []string{"tap1"},
+			nads:  []string{"test/untagged"},
+		},
+		{
+			name:  "a trunk port is counted once",
+			users: map[uint16][]string{310: {"tap1"}, 311: {"tap1"}},
+			ports: []string{"tap1"},
+			nads:  []string{"test/trunk"},
+		},
+		{
+			name:  "the port of a deleted NAD",
+			users: map[uint16][]string{400: {"tap1"}},
+			ports: []string{"tap1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			consumers, err := NewBridgeConsumers(nads, tc.users, DefaultVlanID)
+			assert.NoError(t, err)
+			assert.Equal(t, len(tc.ports) == 0, consumers.IsEmpty())
+			assert.Equal(t, tc.ports, consumers.Ports)
+			assert.Equal(t, tc.nads, consumers.NADs)
+		})
+	}
+
+	// the ports of the untagged NADs are on the untagged VID of the bridge
+	consumers, err := NewBridgeConsumers(nads, map[uint16][]string{100: {"tap1"}}, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test/untagged"}, consumers.NADs)
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// CloneRequest asks the controller to clone a vlanconfig, it's set to the annotation KeyCloneRequest of the source
+// vlanconfig in json format, e.g. `{"name":"rack2","nodeSelector":{"topology.kubernetes.io/zone":"rack2"}}`.
+// The omitted fields are copied from the source vlanconfig.
+type CloneRequest struct {
+	Name           string            `json:"name"`
+	Description    string            `json:"description,omitempty"`
+	ClusterNetwork string            `json:"clusterNetwork,omitempty"`
+	NodeSelector   map[string]string `json:"nodeSelector,omitempty"`
+	NICs           []string          `json:"nics,omitempty"`
+}
+
+// GetCloneRequest returns the clone request of the vlanconfig, it's nil if there is no request
+func GetCloneRequest(vc *networkv1.VlanConfig) (*CloneRequest, error) {
+	value, ok := vc.Annotations[KeyCloneRequest]
+	if !ok {
+		return nil, nil
+	}
+
+	req := &CloneRequest{}
+	if err := json.Unmarshal([]byte(value), req); err != nil {
+		return nil, fmt.Errorf("invalid clone request %s, error: %w", value, err)
+	}
+	if req.Name == "" {
+		return nil, fmt.Errorf("invalid clone request %s, the name is empty", value)
+	}
+	if req.Name == vc.Name {
+		return nil, fmt.Errorf("invalid clone request %s, the name is the same as the source", value)
+	}
+
+	return req, nil
+}
+
+// CloneVlanConfig returns a new vlanconfig copied from the source with the overrides of the request.
+// The uplink sections inherited from the cluster network are left empty for the clone to inherit again,
+// the cluster network of the clone may be a different one.
+func CloneVlanConfig(src *networkv1.VlanConfig, req *CloneRequest) *networkv1.VlanConfig {
+	spec := specWithoutInheritedUplink(src)
+
+	if req.Description != "" {
+		spec.Description = req.Description
+	}
+	if req.ClusterNetwork != "" {
+		spec.ClusterNetwork = req.ClusterNetwork
+	}
+	if req.NodeSelector != nil {
+		spec.NodeSelector = req.NodeSelector
+	}
+	if len(req.NICs) != 0 {
+		spec.Uplink.NICs = req.NICs
+		// the tuning refers to the NICs of the source
+		spec.Uplink.NICTuning = nil
+	}
+
+	return &networkv1.VlanConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: req.Name,
+			Labels: map[string]string{
+				KeyClusterNetworkLabel: spec.ClusterNetwork,
+			},
+			Annotations: map[string]string{
+				KeyClonedFrom: src.Name,
+			},
+		},
+		Spec: spec,
+	}
+}
+
+// specWithoutInheritedUplink returns a copy of the spec of the vlanconfig without the uplink sections inherited from the
+// cluster network, so that a vlanconfig created from it inherits them again
+func specWithoutInheritedUplink(vc *networkv1.VlanConfig) networkv1.VlanConfigSpec {
+	spec := *vc.Spec.DeepCopy()
+	for _, field := range InheritedUplinkFields(vc) {
+		switch field {
+		case UplinkFieldLinkAttrs:
+			spec.Uplink.LinkAttrs = nil
+		case UplinkFieldBondOptions:
+			spec.Uplink.BondOptions = nil
+		case UplinkFieldQueueOptions:
+			spec.Uplink.QueueOptions = nil
+		}
+	}
+
+	return spec
+}
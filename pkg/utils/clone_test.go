@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestGetCloneRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation *string
+		expected   *CloneRequest
+		returnErr  bool
+	}{
+		{
+			name:       "no request",
+			annotation: nil,
+			expected:   nil,
+		},
+		{
+			name:       "invalid json",
+			annotation: ptrString(`{"name":`),
+			returnErr:  true,
+		},
+		{
+			name:       "empty name",
+			annotation: ptrString(`{"clusterNetwork":"rack2"}`),
+			returnErr:  true,
+		},
+		{
+			name:       "same name as the source",
+			annotation: ptrString(`{"name":"rack1"}`),
+			returnErr:  true,
+		},
+		{
+			name:       "valid request",
+			annotation: ptrString(`{"name":"rack2","nodeSelector":{"zone":"rack2"}}`),
+			expected:   &CloneRequest{Name: "rack2", NodeSelector: map[string]string{"zone": "rack2"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vc := &networkv1.VlanConfig{ObjectMeta: metav1.ObjectMeta{Name: "rack1"}}
+			if tc.annotation != nil {
+				vc.Annotations = map[string]string{KeyCloneRequest: *tc.annotation}
+			}
+			req, err := GetCloneRequest(vc)
+			assert.Equal(t, tc.returnErr, err != nil)
+			assert.Equal(t, tc.expected, req)
+		})
+	}
+}
+
+func TestCloneVlanConfig(t *testing.T) {
+	src := &networkv1.VlanConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "rack1",
+			Annotations: map[string]string{
+				KeyMatchedNodes:          `["node1"]`,
+				KeyInheritedUplinkFields: UplinkFieldBondOptions,
+				KeyCloneRequest:          `{"name":"rack2"}`,
+			},
+		},
+		Spec: networkv1.VlanConfigSpec{
+			ClusterNetwork: "data",
+			NodeSelector:   map[string]string{"zone": "rack1"},
+			Uplink: networkv1.Uplink{
+				NICs:        []string{"eth1", "eth2"},
+				LinkAttrs:   &networkv1.LinkAttrs{MTU: 9000, TxQLen: -1},
+				BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: 100},
+				NICTuning:   []networkv1.NICTuning{{Name: "eth1", Ring: &networkv1.RingOptions{RX: 4096}}},
+			},
+		},
+	}
+
+	clone := CloneVlanConfig(src, &CloneRequest{Name: "rack2", NodeSelector: map[string]string{"zone": "rack2"}})
+	assert.Equal(t, "rack2", clone.Name)
+	assert.Equal(t, map[string]string{KeyClonedFrom: "rack1"}, clone.Annotations)
+	assert.Equal(t, map[string]string{KeyClusterNetworkLabel: "data"}, clone.Labels)
+	assert.Equal(t, map[string]string{"zone": "rack2"}, clone.Spec.NodeSelector)
+	assert.Equal(t, src.Spec.Uplink.LinkAttrs, clone.Spec.Uplink.LinkAttrs)
+	assert.Nil(t, clone.Spec.Uplink.BondOptions, "the inherited section is left to inherit again")
+	assert.Equal(t, src.Spec.Uplink.NICTuning, clone.Spec.Uplink.NICTuning)
+
+	clone = CloneVlanConfig(src, &CloneRequest{Name: "rack3", ClusterNetwork: "storage", NICs: []string{"eth3"}})
+	assert.Equal(t, "storage", clone.Spec.ClusterNetwork)
+	assert.Equal(t, map[string]string{"zone": "rack1"}, clone.Spec.NodeSelector)
+	assert.Equal(t, []string{"eth3"}, clone.Spec.Uplink.NICs)
+	assert.Nil(t, clone.Spec.Uplink.NICTuning)
+	// the source is not modified
+	assert.Equal(t, []string{"eth1", "eth2"}, src.Spec.Uplink.NICs)
+}
+
+func ptrString(s string) *string {
+	return &s
+}
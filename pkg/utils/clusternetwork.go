@@ -3,11 +3,24 @@ package utils
 import (
 	"fmt"
 
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 )
 
 const (
 	MaxClusterNetworkNameLen = MaxDeviceNameLen - LenOfBridgeSuffix
+	// MaxBridgePerVIDClusterNetworkNameLen leaves room for the VID in the names of the bridges per VID
+	MaxBridgePerVIDClusterNetworkNameLen = MaxDeviceNameLen - LenOfPerVIDSuffix
+	// MaxQinQClusterNetworkNameLen leaves room for the S-VLAN in the names of the QinQ bridges
+	MaxQinQClusterNetworkNameLen = MaxDeviceNameLen - LenOfQinQSuffix
+	// DefaultMaxVIDs is the cap of the VLAN IDs on the uplinks of a cluster network if not configured
+	DefaultMaxVIDs = 512
+	// DefaultVxlanPort is the IANA assigned UDP port of VXLAN
+	DefaultVxlanPort = 4789
+	// VxlanOverhead is the bytes the VXLAN encapsulation adds to the frames of the VMs, the MTU of the underlay must
+	// exceed the MTU of the overlay by it
+	VxlanOverhead = 50
 )
 
 func IsClusterNetworkNameValid(nm string) (bool, error) {
@@ -17,6 +30,77 @@ func IsClusterNetworkNameValid(nm string) (bool, error) {
 	return true, nil
 }
 
+// MaxVIDsOf returns the cap of the VLAN IDs on the uplinks of the cluster network
+func MaxVIDsOf(cn *networkv1.ClusterNetwork) int {
+	if cn == nil || cn.Spec.MaxVIDs == 0 {
+		return DefaultMaxVIDs
+	}
+	return cn.Spec.MaxVIDs
+}
+
+// CheckVIDCount returns an error if the VLAN IDs of the NADs, which are trunked on the uplinks of the cluster network,
+// exceed its cap
+func CheckVIDCount(cn *networkv1.ClusterNetwork, nads []*nadv1.NetworkAttachmentDefinition) error {
+	vis, err := NewVlanIDSetFromNadList(nads)
+	if err != nil {
+		return err
+	}
+
+	limit := MaxVIDsOf(cn)
+	if count := len(vis.VIDs()); count > limit {
+		return fmt.Errorf("the uplinks of cluster network %s would carry %d VLAN IDs, more than the cap %d; the switches "+
+			"learn the MAC addresses of the VMs per VLAN and flood per VLAN, so a large trunk overwhelms their MAC tables. "+
+			"Reuse the existing VLANs, keep node-local networks off the uplinks with the annotation %s=false, or raise "+
+			"spec.maxVIDs of the cluster network once the switches are verified to scale to it", cn.Name, count, limit, KeyUplink)
+	}
+
+	return nil
+}
+
+// IsPrioritized returns true if the egress traffic of the cluster network is prioritized over the other cluster networks
+func IsPrioritized(cn *networkv1.ClusterNetwork) bool {
+	return cn != nil && cn.Spec.QoS != nil && cn.Spec.QoS.Priority == networkv1.QoSPriorityHigh
+}
+
+// IsBridgePerVID returns true if the VLANs of the cluster network are separated by a bridge per VID instead of the
+// VLAN filtering of one bridge
+func IsBridgePerVID(cn *networkv1.ClusterNetwork) bool {
+	return cn != nil && cn.Spec.Datapath == networkv1.DatapathBridgePerVID
+}
+
+// IsBridgeless returns true if the cluster network has no bridge, the VMs are attached to its uplink by macvlan or
+// ipvlan links
+func IsBridgeless(cn *networkv1.ClusterNetwork) bool {
+	return cn != nil && (cn.Spec.Datapath == networkv1.DatapathMacvlan || cn.Spec.Datapath == networkv1.DatapathIpvlan)
+}
+
+// IsOVS returns true if the VLANs of the cluster network are trunked through an Open vSwitch bridge
+func IsOVS(cn *networkv1.ClusterNetwork) bool {
+	return cn != nil && cn.Spec.Datapath == networkv1.DatapathOVS
+}
+
+// UntaggedVIDOf returns the VLAN ID of the VMs of the untagged NADs of the cluster network
+func UntaggedVIDOf(cn *networkv1.ClusterNetwork) uint16 {
+	if cn.Spec.UntaggedVID == 0 {
+		return DefaultVlanID
+	}
+	return cn.Spec.UntaggedVID
+}
+
+// IsOverlay returns true if the VM networks of the cluster network are carried in VXLAN between the nodes instead of
+// VLANs on the uplinks
+func IsOverlay(cn *networkv1.ClusterNetwork) bool {
+	return cn != nil && cn.Spec.Type == networkv1.ClusterNetworkTypeVXLAN
+}
+
+// VxlanPortOf returns the UDP port of the VXLAN tunnels of the overlay cluster network
+func VxlanPortOf(cn *networkv1.ClusterNetwork) int {
+	if cn == nil || cn.Spec.VXLAN == nil || cn.Spec.VXLAN.Port == 0 {
+		return DefaultVxlanPort
+	}
+	return cn.Spec.VXLAN.Port
+}
+
 func AreClusterNetworkVlanAnnotationsUnchanged(cn *networkv1.ClusterNetwork, vidstr, vidhash string) bool {
 	if cn == nil || cn.Annotations == nil {
 		return false
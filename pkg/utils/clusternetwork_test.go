@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestCheckVIDCount(t *testing.T) {
+	trunk := func(name string, minID, maxID int, annotations map[string]string) *nadv1.NetworkAttachmentDefinition {
+		return &nadv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations},
+			Spec: nadv1.NetworkAttachmentDefinitionSpec{
+				Config: fmt.Sprintf(`{"cniVersion":"0.3.1","name":"%s","type":"bridge","bridge":"data-br","vlanTrunk":[{"minID":%d,"maxID":%d}],"ipam":{}}`,
+					name, minID, maxID),
+			},
+		}
+	}
+	cn := &networkv1.ClusterNetwork{ObjectMeta: metav1.ObjectMeta{Name: "data"}}
+	nads := []*nadv1.NetworkAttachmentDefinition{trunk("a", 100, 399, nil), trunk("b", 300, 611, nil)}
+
+	assert.Equal(t, DefaultMaxVIDs, MaxVIDsOf(cn))
+	assert.NoError(t, CheckVIDCount(cn, nads), "the overlapping VIDs count once")
+	assert.ErrorContains(t, CheckVIDCount(cn, append(nads, trunk("c", 612, 612, nil))), "513 VLAN IDs, more than the cap 512")
+	assert.NoError(t, CheckVIDCount(cn, append(nads, trunk("c", 612, 700, map[string]string{KeyUplink: "false"}))),
+		"the local-only NADs don't count")
+
+	cn.Spec.MaxVIDs = 100
+	assert.Equal(t, 100, MaxVIDsOf(cn))
+	assert.Error(t, CheckVIDCount(cn, nads))
+}
+
+func TestOverlay(t *testing.T) {
+	vlan := &networkv1.ClusterNetwork{ObjectMeta: metav1.ObjectMeta{Name: "data"}}
+	overlay := &networkv1.ClusterNetwork{
+		ObjectMeta: metav1.ObjectMeta{Name: "overlay"},
+		Spec: networkv1.ClusterNetworkSpec{
+			Type:  networkv1.ClusterNetworkTypeVXLAN,
+			VXLAN: &networkv1.VXLANOptions{VNI: 100},
+		},
+	}
+
+	assert.False(t, IsOverlay(nil))
+	assert.False(t, IsOverlay(vlan))
+	assert.True(t, IsOverlay(overlay))
+	assert.Equal(t, DefaultVxlanPort, VxlanPortOf(overlay))
+	overlay.Spec.VXLAN.Port = 8472
+	assert.Equal(t, 8472, VxlanPortOf(overlay))
+	assert.Equal(t, "overlay-vx", GenerateVtepName(overlay.Name))
+	assert.Equal(t, "abcdefghijkl-vx", GenerateVtepName("abcdefghijklmn"))
+}
@@ -2,9 +2,15 @@ package utils
 
 const (
 	DefaultMTU       = 1500
-	MaxMTU           = 9000
-	MinMTU           = 576 // IPv4 does not define this explicitly; IPv6 defines 1280; Some protocol requires 576; hence 576 is used
+	MaxMTU           = 9000 // the default upper bound of the MTUs, which the Harvester setting can override
+	MinMTU           = 576  // IPv4 does not define this explicitly; IPv6 defines 1280; Some protocol requires 576; hence 576 is used
+	JumboMTU         = 9000 // the jumbo frames are verified end to end from this MTU on if required
 	defaultNamespace = "default"
 
+	// the absolute bounds the overridden MTU bounds must stay in, the minimum MTU of IPv4 and the maximum one of
+	// the ethernet devices
+	AbsoluteMinMTU = 68
+	AbsoluteMaxMTU = 65535
+
 	HarvesterSystemNamespaceName = "harvester-system" // don't import harvester/pkg/util to avoid loop importing, define it directly
 )
@@ -0,0 +1,242 @@
+package crd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/harvester/harvester-network-controller/manifests/crds"
+)
+
+// BootstrapMode is how the manager treats the CRDs of the cluster at startup
+type BootstrapMode string
+
+const (
+	// BootstrapOff leaves the CRDs alone, e.g. they are managed by a chart
+	BootstrapOff BootstrapMode = "off"
+	// BootstrapVerify refuses to run against the CRDs which can't hold the objects of this release
+	BootstrapVerify BootstrapMode = "verify"
+	// BootstrapInstall installs or updates the CRDs built into the binary before verifying them
+	BootstrapInstall BootstrapMode = "install"
+
+	// KeyManifestHash is the annotation of the hash of the manifest a CRD is installed from, the CRD is only updated
+	// if the manifest changes
+	KeyManifestHash = "network.harvesterhci.io/manifest-hash"
+)
+
+func ParseBootstrapMode(s string) (BootstrapMode, error) {
+	switch mode := BootstrapMode(s); mode {
+	case "":
+		return BootstrapOff, nil
+	case BootstrapOff, BootstrapVerify, BootstrapInstall:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown CRD bootstrap mode %q, it must be one of %s, %s and %s", s, BootstrapOff,
+			BootstrapVerify, BootstrapInstall)
+	}
+}
+
+// Manifests returns the CRDs built into the binary, annotated with the hashes of their manifests
+func Manifests() ([]*apiext.CustomResourceDefinition, error) {
+	files, err := fs.Glob(crds.FS, "*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	result := make([]*apiext.CustomResourceDefinition, 0, len(files))
+	for _, file := range files {
+		data, err := fs.ReadFile(crds.FS, file)
+		if err != nil {
+			return nil, err
+		}
+		data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("---"))
+		crd := &apiext.CustomResourceDefinition{}
+		if err := yaml.UnmarshalStrict(data, crd); err != nil {
+			return nil, fmt.Errorf("decode CRD manifest %s failed, error: %w", file, err)
+		}
+		crd.Annotations = map[string]string{KeyManifestHash: fmt.Sprintf("%x", sha256.Sum256(data))}
+		result = append(result, crd)
+	}
+
+	return result, nil
+}
+
+// Bootstrap installs or updates the CRDs built into the binary in the install mode, and then refuses to run against
+// the CRDs of the cluster which can't hold the objects of this release, e.g. they lack the fields the binary writes
+// and the API server would silently prune them. It only warns if the CRDs can't be read with the permissions.
+func (f *Factory) Bootstrap(mode BootstrapMode) error {
+	if mode == BootstrapOff || mode == "" {
+		return nil
+	}
+
+	desired, err := Manifests()
+	if err != nil {
+		return err
+	}
+	if ok, err := f.ensureAccess(f.ctx); err != nil {
+		return err
+	} else if !ok {
+		logrus.Warnf("No access to list CRDs, the CRDs of the cluster can't be verified")
+		return nil
+	}
+
+	if mode == BootstrapInstall {
+		for _, crd := range desired {
+			if err := f.ensureCRD(crd); err != nil {
+				return fmt.Errorf("install CRD %s failed, error: %w", crd.Name, err)
+			}
+		}
+	}
+
+	problems := make([]string, 0)
+	for _, crd := range desired {
+		existing, err := f.client.ApiextensionsV1().CustomResourceDefinitions().Get(f.ctx, crd.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			existing = nil
+		} else if err != nil {
+			return err
+		}
+		for _, problem := range CheckCRD(existing, crd) {
+			problems = append(problems, fmt.Sprintf("%s %s", crd.Name, problem))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("refuse to run against the stale CRDs: %s; apply the CRDs of this release or start the "+
+			"manager with the CRD bootstrap mode %s", strings.Join(problems, "; "), BootstrapInstall)
+	}
+
+	return nil
+}
+
+// ensureCRD creates the CRD or updates it if it's installed from another manifest, and waits until it's established
+func (f *Factory) ensureCRD(crd *apiext.CustomResourceDefinition) error {
+	client := f.client.ApiextensionsV1().CustomResourceDefinitions()
+	existing, err := client.Get(f.ctx, crd.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		logrus.Infof("Creating CRD %s", crd.Name)
+		if _, err := client.Create(f.ctx, crd, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case existing.Annotations[KeyManifestHash] == crd.Annotations[KeyManifestHash]:
+		return nil
+	default:
+		logrus.Infof("Updating CRD %s", crd.Name)
+		updated := existing.DeepCopy()
+		updated.Spec = crd.Spec
+		if updated.Annotations == nil {
+			updated.Annotations = make(map[string]string, 1)
+		}
+		updated.Annotations[KeyManifestHash] = crd.Annotations[KeyManifestHash]
+		if _, err := client.Update(f.ctx, updated, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}
+	return f.waitCRD(f.ctx, crd.Name, gvk, map[schema.GroupVersionKind]*apiext.CustomResourceDefinition{})
+}
+
+// CheckCRD returns why the CRD of the cluster can't hold the objects of the desired CRD, empty if it can. A CRD newer
+// than the desired one, e.g. with more fields, is fine.
+func CheckCRD(existing, desired *apiext.CustomResourceDefinition) []string {
+	if existing == nil {
+		return []string{"is not installed"}
+	}
+
+	problems := make([]string, 0)
+	for i := range desired.Spec.Versions {
+		version := &desired.Spec.Versions[i]
+		if !version.Served {
+			continue
+		}
+		found := findVersion(existing, version.Name)
+		if found == nil || !found.Served {
+			problems = append(problems, fmt.Sprintf("doesn't serve the version %s", version.Name))
+			continue
+		}
+		if version.Storage && !found.Storage {
+			problems = append(problems, fmt.Sprintf("doesn't store the version %s", version.Name))
+		}
+		if version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		var existingSchema *apiext.JSONSchemaProps
+		if found.Schema != nil {
+			existingSchema = found.Schema.OpenAPIV3Schema
+		}
+		if missing := missingFields(existingSchema, version.Schema.OpenAPIV3Schema, ""); len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("lacks the fields %s of the version %s", strings.Join(missing, ", "),
+				version.Name))
+		}
+	}
+
+	return problems
+}
+
+func findVersion(crd *apiext.CustomResourceDefinition, name string) *apiext.CustomResourceDefinitionVersion {
+	for i := range crd.Spec.Versions {
+		if crd.Spec.Versions[i].Name == name {
+			return &crd.Spec.Versions[i]
+		}
+	}
+	return nil
+}
+
+// missingFields returns the sorted paths of the fields of the desired schema which the existing schema lacks, the
+// fields under an existing field preserving the unknown fields aren't missing
+func missingFields(existing, desired *apiext.JSONSchemaProps, path string) []string {
+	if desired == nil || (existing != nil && existing.XPreserveUnknownFields != nil && *existing.XPreserveUnknownFields) {
+		return nil
+	}
+
+	missing := make([]string, 0)
+	for name := range desired.Properties {
+		desiredField := desired.Properties[name]
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		var existingField *apiext.JSONSchemaProps
+		if existing != nil {
+			if field, ok := existing.Properties[name]; ok {
+				existingField = &field
+			}
+		}
+		if existingField == nil {
+			missing = append(missing, fieldPath)
+			continue
+		}
+		missing = append(missing, missingFields(existingField, &desiredField, fieldPath)...)
+	}
+	if desired.Items != nil && desired.Items.Schema != nil {
+		var existingItems *apiext.JSONSchemaProps
+		if existing != nil && existing.Items != nil {
+			existingItems = existing.Items.Schema
+		}
+		missing = append(missing, missingFields(existingItems, desired.Items.Schema, path+"[]")...)
+	}
+	if desired.AdditionalProperties != nil && desired.AdditionalProperties.Schema != nil {
+		var existingValues *apiext.JSONSchemaProps
+		if existing != nil && existing.AdditionalProperties != nil {
+			existingValues = existing.AdditionalProperties.Schema
+		}
+		missing = append(missing, missingFields(existingValues, desired.AdditionalProperties.Schema, path+"{}")...)
+	}
+	sort.Strings(missing)
+
+	return missing
+}
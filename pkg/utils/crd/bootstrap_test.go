@@ -0,0 +1,67 @@
+package crd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestManifests(t *testing.T) {
+	crds, err := Manifests()
+	assert.NoError(t, err)
+	names := make([]string, 0, len(crds))
+	for _, crd := range crds {
+		names = append(names, crd.Name)
+		assert.NotEmpty(t, crd.Annotations[KeyManifestHash])
+		assert.Empty(t, CheckCRD(crd, crd), "a CRD can hold its own objects")
+	}
+	assert.Contains(t, names, "vlanconfigs.network.harvesterhci.io")
+	assert.Contains(t, names, "clusternetworks.network.harvesterhci.io")
+}
+
+func TestCheckCRD(t *testing.T) {
+	crds, err := Manifests()
+	assert.NoError(t, err)
+	var desired *apiext.CustomResourceDefinition
+	for _, crd := range crds {
+		if crd.Name == "vlanconfigs.network.harvesterhci.io" {
+			desired = crd
+		}
+	}
+	if !assert.NotNil(t, desired) {
+		return
+	}
+
+	assert.Equal(t, []string{"is not installed"}, CheckCRD(nil, desired))
+
+	// the CRD of an older release lacks a field of the uplink
+	stale := desired.DeepCopy()
+	uplink := stale.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["uplink"]
+	delete(uplink.Properties, "serviceVLAN")
+	stale.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["uplink"] = uplink
+	problems := CheckCRD(stale, desired)
+	if assert.Len(t, problems, 1) {
+		assert.Contains(t, problems[0], "lacks the fields spec.uplink.serviceVLAN")
+	}
+	assert.Empty(t, CheckCRD(desired, stale), "a newer CRD holds the objects of an older release")
+
+	preserved := true
+	stale.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"] = apiext.JSONSchemaProps{
+		Type: "object", XPreserveUnknownFields: &preserved}
+	assert.Empty(t, CheckCRD(stale, desired), "the unknown fields are preserved")
+
+	stale.Spec.Versions[0].Served = false
+	assert.Contains(t, CheckCRD(stale, desired), "doesn't serve the version v1beta1")
+}
+
+func TestParseBootstrapMode(t *testing.T) {
+	mode, err := ParseBootstrapMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, BootstrapOff, mode)
+	mode, err = ParseBootstrapMode("install")
+	assert.NoError(t, err)
+	assert.Equal(t, BootstrapInstall, mode)
+	_, err = ParseBootstrapMode("upgrade")
+	assert.Error(t, err)
+}
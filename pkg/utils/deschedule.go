@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rancher/wrangler/pkg/condition"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// UnhealthySince returns true with the time since when the network reported in the vlanstatus is unhealthy, i.e. not
+// ready, or degraded as well if degraded is true. The time is the last transition of the condition, which the message
+// changing doesn't move, or the last update if an older agent hasn't stamped the transition. It's zero if unknown.
+func UnhealthySince(vs *networkv1.VlanStatus, degraded bool) (time.Time, bool) {
+	var cond condition.Cond
+	switch {
+	case networkv1.Ready.IsFalse(vs.Status):
+		cond = networkv1.Ready
+	case degraded && networkv1.Degraded.IsTrue(vs.Status):
+		cond = networkv1.Degraded
+	default:
+		return time.Time{}, false
+	}
+
+	ts := conditionTransitionTime(vs, cond)
+	if ts == "" {
+		ts = cond.GetLastUpdated(vs.Status)
+	}
+	since, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return time.Time{}, true
+	}
+	return since, true
+}
+
+// UnhealthyClock remembers when the networks whose vlanstatuses carry no unhealthy time were first seen unhealthy, e.g.
+// reported by an older agent, so that their delay starts from then rather than being skipped
+type UnhealthyClock struct {
+	mutex     sync.Mutex
+	firstSeen map[string]time.Time
+}
+
+func NewUnhealthyClock() *UnhealthyClock {
+	return &UnhealthyClock{firstSeen: make(map[string]time.Time)}
+}
+
+// Since returns since if it's known, otherwise the time the key was first seen unhealthy, which is now the first time
+func (c *UnhealthyClock) Since(key string, since, now time.Time) time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !since.IsZero() {
+		delete(c.firstSeen, key)
+		return since
+	}
+	if firstSeen, ok := c.firstSeen[key]; ok {
+		return firstSeen
+	}
+	c.firstSeen[key] = now
+	return now
+}
+
+// Forget drops the first-seen time of the key once its network is healthy or gone
+func (c *UnhealthyClock) Forget(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.firstSeen, key)
+}
+
+func conditionTransitionTime(vs *networkv1.VlanStatus, cond condition.Cond) string {
+	for _, c := range vs.Status.Conditions {
+		if c.Type == cond {
+			return c.LastTransitionTime
+		}
+	}
+	return ""
+}
+
+// AddUnhealthyNetwork adds the cluster network to the value of the annotation KeyUnhealthyNetworks
+func AddUnhealthyNetwork(value, cnName string) string {
+	networks := splitUnhealthyNetworks(value)
+	if !slices.Contains(networks, cnName) {
+		networks = append(networks, cnName)
+		slices.Sort(networks)
+	}
+	return strings.Join(networks, ",")
+}
+
+// RemoveUnhealthyNetwork removes the cluster network from the value of the annotation KeyUnhealthyNetworks
+func RemoveUnhealthyNetwork(value, cnName string) string {
+	networks := slices.DeleteFunc(splitUnhealthyNetworks(value), func(name string) bool {
+		return name == cnName
+	})
+	return strings.Join(networks, ",")
+}
+
+func splitUnhealthyNetworks(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestUnhealthySince(t *testing.T) {
+	vs := &networkv1.VlanStatus{}
+	networkv1.Ready.SetStatusBool(vs, true)
+	_, unhealthy := UnhealthySince(vs, true)
+	assert.False(t, unhealthy)
+
+	networkv1.Degraded.SetStatusBool(vs, true)
+	_, unhealthy = UnhealthySince(vs, false)
+	assert.False(t, unhealthy)
+	since, unhealthy := UnhealthySince(vs, true)
+	assert.True(t, unhealthy)
+	assert.WithinDuration(t, time.Now(), since, time.Minute)
+
+	networkv1.Degraded.SetStatusBool(vs, false)
+	networkv1.Ready.SetStatusBool(vs, false)
+	networkv1.Ready.LastUpdated(vs, "2026-01-02T03:04:05Z")
+	since, unhealthy = UnhealthySince(vs, false)
+	assert.True(t, unhealthy)
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), since)
+
+	// the transition is preferred to the last update, which moves with the message
+	transition := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	StampConditionTransition(vs, networkv1.Ready, string(corev1.ConditionTrue), transition)
+	networkv1.Ready.Message(vs, "no carrier")
+	since, _ = UnhealthySince(vs, false)
+	assert.Equal(t, transition, since)
+}
+
+func TestUnhealthyNetworks(t *testing.T) {
+	value := AddUnhealthyNetwork("", "cn2")
+	value = AddUnhealthyNetwork(value, "cn1")
+	value = AddUnhealthyNetwork(value, "cn2")
+	assert.Equal(t, "cn1,cn2", value)
+
+	value = RemoveUnhealthyNetwork(value, "cn2")
+	assert.Equal(t, "cn1", value)
+	assert.Equal(t, "", RemoveUnhealthyNetwork(value, "cn1"))
+}
+
+func TestUnhealthyClock(t *testing.T) {
+	clock := NewUnhealthyClock()
+	now := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	// an unknown unhealthy time starts the delay when it's first seen rather than skipping it
+	vs := &networkv1.VlanStatus{}
+	networkv1.Ready.SetStatusBool(vs, false)
+	networkv1.Ready.LastUpdated(vs, "")
+	since, unhealthy := UnhealthySince(vs, false)
+	assert.True(t, unhealthy)
+	assert.True(t, since.IsZero())
+	assert.Equal(t, now, clock.Since("vs1", since, now))
+	assert.Equal(t, now, clock.Since("vs1", since, now.Add(time.Minute)))
+
+	// a known time is taken as it is
+	known := now.Add(-time.Hour)
+	assert.Equal(t, known, clock.Since("vs1", known, now.Add(2*time.Minute)))
+
+	clock.Forget("vs2")
+	assert.Equal(t, now.Add(3*time.Minute), clock.Since("vs2", time.Time{}, now.Add(3*time.Minute)))
+	clock.Forget("vs2")
+	assert.Equal(t, now.Add(4*time.Minute), clock.Since("vs2", time.Time{}, now.Add(4*time.Minute)))
+}
@@ -0,0 +1,11 @@
+package utils
+
+import (
+	"github.com/harvester/webhook/pkg/server/admission"
+)
+
+// IsDryRun returns true if the admission request is a dry run, e.g. `kubectl apply --dry-run=server`.
+// The object of a dry run request is never persisted, so the admission handlers must not have side effects for it.
+func IsDryRun(req *admission.Request) bool {
+	return req != nil && req.Request != nil && req.DryRun != nil && *req.DryRun
+}
@@ -0,0 +1,38 @@
+package fakeclients
+
+import (
+	"context"
+
+	"github.com/rancher/wrangler/v3/pkg/generic"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	networktype "github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/typed/network.harvesterhci.io/v1beta1"
+)
+
+type LinkMonitorCache func() networktype.LinkMonitorInterface
+
+func (c LinkMonitorCache) Get(name string) (*v1beta1.LinkMonitor, error) {
+	return c().Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (c LinkMonitorCache) List(selector labels.Selector) ([]*v1beta1.LinkMonitor, error) {
+	list, err := c().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*v1beta1.LinkMonitor, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+
+func (c LinkMonitorCache) AddIndexer(_ string, _ generic.Indexer[*v1beta1.LinkMonitor]) {
+	panic("implement me")
+}
+
+func (c LinkMonitorCache) GetByIndex(_, _ string) ([]*v1beta1.LinkMonitor, error) {
+	panic("implement me")
+}
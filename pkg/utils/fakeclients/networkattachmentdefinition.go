@@ -5,10 +5,12 @@ import (
 
 	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	"github.com/rancher/wrangler/v3/pkg/generic"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
 	cnitype "github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/typed/k8s.cni.cncf.io/v1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
 
 type NetworkAttachmentDefinitionCache func(namespace string) cnitype.NetworkAttachmentDefinitionInterface
@@ -33,10 +35,32 @@ func (c NetworkAttachmentDefinitionCache) List(namespace string, selector labels
 	return nads, nil
 }
 
-func (c NetworkAttachmentDefinitionCache) AddIndexer(_ string, _ generic.Indexer[*cniv1.NetworkAttachmentDefinition]) {
-	panic("implement me")
+// support NadByBridgeIndex for test
+func (c NetworkAttachmentDefinitionCache) AddIndexer(index string, _ generic.Indexer[*cniv1.NetworkAttachmentDefinition]) {
+	if index != utils.NadByBridgeIndex {
+		panic("implement me")
+	}
 }
 
-func (c NetworkAttachmentDefinitionCache) GetByIndex(_, _ string) ([]*cniv1.NetworkAttachmentDefinition, error) {
-	panic("implement me")
+// support NadByBridgeIndex for test
+func (c NetworkAttachmentDefinitionCache) GetByIndex(index, key string) ([]*cniv1.NetworkAttachmentDefinition, error) {
+	if index != utils.NadByBridgeIndex {
+		panic("implement me")
+	}
+
+	nads, err := c.List(corev1.NamespaceAll, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*cniv1.NetworkAttachmentDefinition, 0)
+	for _, nad := range nads {
+		keys, err := utils.NadByBridge(nad)
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) > 0 && keys[0] == key {
+			result = append(result, nad)
+		}
+	}
+	return result, nil
 }
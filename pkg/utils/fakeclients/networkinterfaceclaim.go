@@ -0,0 +1,74 @@
+package fakeclients
+
+import (
+	"context"
+
+	"github.com/rancher/wrangler/v3/pkg/generic"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	networktype "github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/typed/network.harvesterhci.io/v1beta1"
+)
+
+type NetworkInterfaceClaimClient func() networktype.NetworkInterfaceClaimInterface
+
+func (c NetworkInterfaceClaimClient) Create(s *v1beta1.NetworkInterfaceClaim) (*v1beta1.NetworkInterfaceClaim, error) {
+	return c().Create(context.TODO(), s, metav1.CreateOptions{})
+}
+
+func (c NetworkInterfaceClaimClient) Update(s *v1beta1.NetworkInterfaceClaim) (*v1beta1.NetworkInterfaceClaim, error) {
+	return c().Update(context.TODO(), s, metav1.UpdateOptions{})
+}
+
+func (c NetworkInterfaceClaimClient) UpdateStatus(_ *v1beta1.NetworkInterfaceClaim) (*v1beta1.NetworkInterfaceClaim, error) {
+	panic("implement me")
+}
+
+func (c NetworkInterfaceClaimClient) Delete(name string, options *metav1.DeleteOptions) error {
+	return c().Delete(context.TODO(), name, *options)
+}
+
+func (c NetworkInterfaceClaimClient) Get(name string, options metav1.GetOptions) (*v1beta1.NetworkInterfaceClaim, error) {
+	return c().Get(context.TODO(), name, options)
+}
+
+func (c NetworkInterfaceClaimClient) List(opts metav1.ListOptions) (*v1beta1.NetworkInterfaceClaimList, error) {
+	return c().List(context.TODO(), opts)
+}
+
+func (c NetworkInterfaceClaimClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return c().Watch(context.TODO(), opts)
+}
+
+func (c NetworkInterfaceClaimClient) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta1.NetworkInterfaceClaim, err error) {
+	return c().Patch(context.TODO(), name, pt, data, metav1.PatchOptions{}, subresources...)
+}
+
+type NetworkInterfaceClaimCache func() networktype.NetworkInterfaceClaimInterface
+
+func (c NetworkInterfaceClaimCache) Get(name string) (*v1beta1.NetworkInterfaceClaim, error) {
+	return c().Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (c NetworkInterfaceClaimCache) List(selector labels.Selector) ([]*v1beta1.NetworkInterfaceClaim, error) {
+	list, err := c().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*v1beta1.NetworkInterfaceClaim, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+
+func (c NetworkInterfaceClaimCache) AddIndexer(_ string, _ generic.Indexer[*v1beta1.NetworkInterfaceClaim]) {
+	panic("implement me")
+}
+
+func (c NetworkInterfaceClaimCache) GetByIndex(_, _ string) ([]*v1beta1.NetworkInterfaceClaim, error) {
+	panic("implement me")
+}
@@ -0,0 +1,74 @@
+package fakeclients
+
+import (
+	"context"
+
+	"github.com/rancher/wrangler/v3/pkg/generic"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	networktype "github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/typed/network.harvesterhci.io/v1beta1"
+)
+
+type NodeNetworkStateClient func() networktype.NodeNetworkStateInterface
+
+func (c NodeNetworkStateClient) Create(s *v1beta1.NodeNetworkState) (*v1beta1.NodeNetworkState, error) {
+	return c().Create(context.TODO(), s, metav1.CreateOptions{})
+}
+
+func (c NodeNetworkStateClient) Update(s *v1beta1.NodeNetworkState) (*v1beta1.NodeNetworkState, error) {
+	return c().Update(context.TODO(), s, metav1.UpdateOptions{})
+}
+
+func (c NodeNetworkStateClient) UpdateStatus(_ *v1beta1.NodeNetworkState) (*v1beta1.NodeNetworkState, error) {
+	panic("implement me")
+}
+
+func (c NodeNetworkStateClient) Delete(name string, options *metav1.DeleteOptions) error {
+	return c().Delete(context.TODO(), name, *options)
+}
+
+func (c NodeNetworkStateClient) Get(name string, options metav1.GetOptions) (*v1beta1.NodeNetworkState, error) {
+	return c().Get(context.TODO(), name, options)
+}
+
+func (c NodeNetworkStateClient) List(opts metav1.ListOptions) (*v1beta1.NodeNetworkStateList, error) {
+	return c().List(context.TODO(), opts)
+}
+
+func (c NodeNetworkStateClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return c().Watch(context.TODO(), opts)
+}
+
+func (c NodeNetworkStateClient) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta1.NodeNetworkState, err error) {
+	return c().Patch(context.TODO(), name, pt, data, metav1.PatchOptions{}, subresources...)
+}
+
+type NodeNetworkStateCache func() networktype.NodeNetworkStateInterface
+
+func (c NodeNetworkStateCache) Get(name string) (*v1beta1.NodeNetworkState, error) {
+	return c().Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (c NodeNetworkStateCache) List(selector labels.Selector) ([]*v1beta1.NodeNetworkState, error) {
+	list, err := c().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*v1beta1.NodeNetworkState, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+
+func (c NodeNetworkStateCache) AddIndexer(_ string, _ generic.Indexer[*v1beta1.NodeNetworkState]) {
+	panic("implement me")
+}
+
+func (c NodeNetworkStateCache) GetByIndex(_, _ string) ([]*v1beta1.NodeNetworkState, error) {
+	panic("implement me")
+}
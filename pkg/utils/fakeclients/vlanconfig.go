@@ -11,6 +11,7 @@ import (
 
 	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	networktype "github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/typed/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
 
 type VlanConfigClient func() networktype.VlanConfigInterface
@@ -65,10 +66,28 @@ func (c VlanConfigCache) List(selector labels.Selector) ([]*v1beta1.VlanConfig,
 	return result, err
 }
 
-func (c VlanConfigCache) AddIndexer(_ string, _ generic.Indexer[*v1beta1.VlanConfig]) {
-	panic("implement me")
+// support VlanConfigByClusterNetworkIndex for test
+func (c VlanConfigCache) AddIndexer(index string, _ generic.Indexer[*v1beta1.VlanConfig]) {
+	if index != utils.VlanConfigByClusterNetworkIndex {
+		panic("implement me")
+	}
 }
 
-func (c VlanConfigCache) GetByIndex(_, _ string) ([]*v1beta1.VlanConfig, error) {
-	panic("implement me")
+// support VlanConfigByClusterNetworkIndex for test
+func (c VlanConfigCache) GetByIndex(index, key string) ([]*v1beta1.VlanConfig, error) {
+	if index != utils.VlanConfigByClusterNetworkIndex {
+		panic("implement me")
+	}
+
+	vcs, err := c.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*v1beta1.VlanConfig, 0)
+	for _, vc := range vcs {
+		if vc.Spec.ClusterNetwork == key {
+			result = append(result, vc)
+		}
+	}
+	return result, nil
 }
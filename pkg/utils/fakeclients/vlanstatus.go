@@ -2,6 +2,7 @@ package fakeclients
 
 import (
 	"context"
+	"slices"
 
 	"github.com/rancher/wrangler/v3/pkg/generic"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	networktype "github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/typed/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
 
 type VlanStatusClient func() networktype.VlanStatusInterface
@@ -65,10 +67,38 @@ func (c VlanStatusCache) List(selector labels.Selector) ([]*v1beta1.VlanStatus,
 	return result, err
 }
 
-func (c VlanStatusCache) AddIndexer(_ string, _ generic.Indexer[*v1beta1.VlanStatus]) {
-	panic("implement me")
+var vlanStatusIndexers = map[string]generic.Indexer[*v1beta1.VlanStatus]{
+	utils.VlanStatusByNodeIndex:                  utils.VlanStatusByNode,
+	utils.VlanStatusByClusterNetworkAndNodeIndex: utils.VlanStatusByClusterNetworkAndNode,
 }
 
-func (c VlanStatusCache) GetByIndex(_, _ string) ([]*v1beta1.VlanStatus, error) {
-	panic("implement me")
+// support VlanStatusByNodeIndex and VlanStatusByClusterNetworkAndNodeIndex for test
+func (c VlanStatusCache) AddIndexer(index string, _ generic.Indexer[*v1beta1.VlanStatus]) {
+	if _, ok := vlanStatusIndexers[index]; !ok {
+		panic("implement me")
+	}
+}
+
+// support VlanStatusByNodeIndex and VlanStatusByClusterNetworkAndNodeIndex for test
+func (c VlanStatusCache) GetByIndex(index, key string) ([]*v1beta1.VlanStatus, error) {
+	indexer, ok := vlanStatusIndexers[index]
+	if !ok {
+		panic("implement me")
+	}
+
+	vss, err := c.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*v1beta1.VlanStatus, 0)
+	for _, vs := range vss {
+		keys, err := indexer(vs)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(keys, key) {
+			result = append(result, vs)
+		}
+	}
+	return result, nil
 }
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"slices"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// The vlanconfig features an agent has to understand to apply them, an older agent silently ignores the fields of
+// a feature it doesn't know. The agents report the features they understand in their vlanstatuses.
+const (
+	FeatureSharedBond        = "sharedBond"
+	FeatureFabricB           = "fabricB"
+	FeatureQueueOptions      = "queueOptions"
+	FeatureNICTuning         = "nicTuning"
+	FeatureCarrierSettle     = "carrierSettle"
+	FeatureLoopDetection     = "loopDetection"
+	FeatureXmitHashPolicy    = "xmitHashPolicy"
+	FeatureLacpRate          = "lacpRate"
+	FeatureArpMonitor        = "arpMonitor"
+	FeatureCarrierTracking   = "carrierTracking"
+	FeatureJumboVerification = "jumboVerification"
+	FeatureServiceVLAN       = "serviceVLAN"
+	FeatureBackupNICs        = "backupNICs"
+	FeatureBondDelays        = "bondDelays"
+	// the topology overrides of the vlanconfig spec rather than the uplink
+	FeatureTopologyOverrides = "topologyOverrides"
+)
+
+// The cluster network features the agents of its vlanconfigs have to understand to apply them. The datapath and the
+// type are immutable, they are required when a vlanconfig joins the cluster network. The other cluster network
+// settings, e.g. the descheduling or the VLAN ID removal, are handled by the manager rather than the agents.
+const (
+	FeatureBridgePerVID = "bridgePerVID"
+	FeatureMacvlan      = "macvlan"
+	FeatureIpvlan       = "ipvlan"
+	FeatureOVS          = "ovs"
+	FeatureVXLAN        = "vxlan"
+	FeatureUntaggedVID  = "untaggedVID"
+	FeatureMulticast    = "multicast"
+	// the proxy ARP and the ARP/ND suppression of the bridge ports
+	FeatureNeighbor    = "neighbor"
+	FeatureQoSPriority = "qosPriority"
+)
+
+// FeatureVIDStatus is not a vlanconfig feature but tells that the agent reports the VLAN IDs programmed on the uplink
+// in the vlanstatus, the VLAN IDs are only checked against the vlanstatuses of such agents
+const FeatureVIDStatus = "vidStatus"
+
+// AgentFeatures are the vlanconfig features this agent understands, a new feature must be added here once the agent
+// implements it
+var AgentFeatures = []string{
+	FeatureArpMonitor,
+	FeatureBackupNICs,
+	FeatureBondDelays,
+	FeatureBridgePerVID,
+	FeatureCarrierSettle,
+	FeatureCarrierTracking,
+	FeatureFabricB,
+	FeatureIpvlan,
+	FeatureJumboVerification,
+	FeatureLacpRate,
+	FeatureLoopDetection,
+	FeatureMacvlan,
+	FeatureMulticast,
+	FeatureNICTuning,
+	FeatureNeighbor,
+	FeatureOVS,
+	FeatureQoSPriority,
+	FeatureQueueOptions,
+	FeatureServiceVLAN,
+	FeatureSharedBond,
+	FeatureTopologyOverrides,
+	FeatureUntaggedVID,
+	FeatureVIDStatus,
+	FeatureVXLAN,
+	FeatureXmitHashPolicy,
+}
+
+// VlanConfigFeatures returns the features the vlanconfig uses
+func VlanConfigFeatures(vc *networkv1.VlanConfig) []string {
+	if vc == nil {
+		return nil
+	}
+
+	uplink := &vc.Spec.Uplink
+	features := make([]string, 0)
+	if uplink.BondOptions != nil && uplink.BondOptions.ArpInterval > 0 {
+		features = append(features, FeatureArpMonitor)
+	}
+	if hasBackupNICs(vc) {
+		features = append(features, FeatureBackupNICs)
+	}
+	if uplink.BondOptions != nil && (uplink.BondOptions.UpDelay > 0 || uplink.BondOptions.DownDelay > 0) {
+		features = append(features, FeatureBondDelays)
+	}
+	if uplink.CarrierSettleSeconds > 0 {
+		features = append(features, FeatureCarrierSettle)
+	}
+	if IsCarrierTracked(uplink.BondOptions) {
+		features = append(features, FeatureCarrierTracking)
+	}
+	if uplink.FabricB != nil {
+		features = append(features, FeatureFabricB)
+	}
+	if uplink.JumboVerification != nil {
+		features = append(features, FeatureJumboVerification)
+	}
+	if uplink.BondOptions != nil && uplink.BondOptions.LacpRate != "" {
+		features = append(features, FeatureLacpRate)
+	}
+	if uplink.LoopDetection != nil {
+		features = append(features, FeatureLoopDetection)
+	}
+	if len(uplink.NICTuning) > 0 {
+		features = append(features, FeatureNICTuning)
+	}
+	if uplink.QueueOptions != nil {
+		features = append(features, FeatureQueueOptions)
+	}
+	if uplink.ServiceVLAN != 0 {
+		features = append(features, FeatureServiceVLAN)
+	}
+	if uplink.SharedBond != nil {
+		features = append(features, FeatureSharedBond)
+	}
+	if len(vc.Spec.TopologyOverrides) > 0 {
+		features = append(features, FeatureTopologyOverrides)
+	}
+	if uplink.BondOptions != nil && uplink.BondOptions.XmitHashPolicy != "" {
+		features = append(features, FeatureXmitHashPolicy)
+	}
+
+	return features
+}
+
+// ClusterNetworkFeatures returns the features the cluster network uses
+func ClusterNetworkFeatures(cn *networkv1.ClusterNetwork) []string {
+	if cn == nil {
+		return nil
+	}
+
+	features := make([]string, 0)
+	switch cn.Spec.Datapath {
+	case networkv1.DatapathBridgePerVID:
+		features = append(features, FeatureBridgePerVID)
+	case networkv1.DatapathIpvlan:
+		features = append(features, FeatureIpvlan)
+	case networkv1.DatapathMacvlan:
+		features = append(features, FeatureMacvlan)
+	}
+	if cn.Spec.Multicast != nil {
+		features = append(features, FeatureMulticast)
+	}
+	if neighbor := cn.Spec.Neighbor; neighbor != nil && (neighbor.ProxyARP || neighbor.Suppression) {
+		features = append(features, FeatureNeighbor)
+	}
+	if IsOVS(cn) {
+		features = append(features, FeatureOVS)
+	}
+	if cn.Spec.QoS != nil && cn.Spec.QoS.Priority == networkv1.QoSPriorityHigh {
+		features = append(features, FeatureQoSPriority)
+	}
+	if UntaggedVIDOf(cn) != DefaultVlanID {
+		features = append(features, FeatureUntaggedVID)
+	}
+	if IsOverlay(cn) {
+		features = append(features, FeatureVXLAN)
+	}
+
+	return features
+}
+
+// hasBackupNICs tells whether the uplink or any of its topology overrides has backup NICs
+func hasBackupNICs(vc *networkv1.VlanConfig) bool {
+	if len(vc.Spec.Uplink.BackupNICs) > 0 {
+		return true
+	}
+	for _, override := range vc.Spec.TopologyOverrides {
+		if len(override.BackupNICs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingFeatures returns the required features which are not supported
+func MissingFeatures(required, supported []string) []string {
+	missing := make([]string, 0)
+	for _, feature := range required {
+		if !slices.Contains(supported, feature) {
+			missing = append(missing, feature)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestVlanConfigFeatures(t *testing.T) {
+	vc := &networkv1.VlanConfig{
+		Spec: networkv1.VlanConfigSpec{
+			Uplink: networkv1.Uplink{
+				NICs:                 []string{"eth1"},
+				CarrierSettleSeconds: 10,
+				FabricB:              &networkv1.FabricUplink{NICs: []string{"eth2"}},
+				BondOptions: &networkv1.BondOptions{
+					Mode:           networkv1.BondMode8023AD,
+					Miimon:         -1,
+					XmitHashPolicy: networkv1.XmitHashPolicyLayer34,
+					LacpRate:       networkv1.LacpRateFast,
+				},
+			},
+			TopologyOverrides: []networkv1.TopologyOverride{{Values: []string{"zone1"}, MTU: 9000}},
+		},
+	}
+
+	assert.Empty(t, VlanConfigFeatures(nil))
+	assert.Empty(t, VlanConfigFeatures(&networkv1.VlanConfig{}))
+	assert.Equal(t, []string{FeatureCarrierSettle, FeatureFabricB, FeatureLacpRate, FeatureTopologyOverrides,
+		FeatureXmitHashPolicy}, VlanConfigFeatures(vc))
+	assert.Equal(t, []string{FeatureServiceVLAN}, VlanConfigFeatures(&networkv1.VlanConfig{
+		Spec: networkv1.VlanConfigSpec{Uplink: networkv1.Uplink{NICs: []string{"eth1"}, ServiceVLAN: 4000}},
+	}))
+	assert.Equal(t, []string{FeatureArpMonitor}, VlanConfigFeatures(&networkv1.VlanConfig{
+		Spec: networkv1.VlanConfigSpec{Uplink: networkv1.Uplink{NICs: []string{"eth1"},
+			BondOptions: &networkv1.BondOptions{ArpInterval: 1000, ArpIPTargets: []string{"192.168.1.1"}}}},
+	}))
+	assert.Equal(t, []string{FeatureBackupNICs}, VlanConfigFeatures(&networkv1.VlanConfig{
+		Spec: networkv1.VlanConfigSpec{Uplink: networkv1.Uplink{NICs: []string{"eth1", "usb0"}, BackupNICs: []string{"usb0"},
+			BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMoDeActiveBackup, Miimon: 100}}},
+	}))
+	// the backup NICs of a topology override need the feature as well
+	assert.Equal(t, []string{FeatureBackupNICs, FeatureTopologyOverrides}, VlanConfigFeatures(&networkv1.VlanConfig{
+		Spec: networkv1.VlanConfigSpec{Uplink: networkv1.Uplink{NICs: []string{"eth1"}},
+			TopologyOverrides: []networkv1.TopologyOverride{{Values: []string{"zone1"}, NICs: []string{"eth1", "usb0"},
+				BackupNICs: []string{"usb0"}}}},
+	}))
+	assert.Equal(t, []string{FeatureBondDelays}, VlanConfigFeatures(&networkv1.VlanConfig{
+		Spec: networkv1.VlanConfigSpec{Uplink: networkv1.Uplink{NICs: []string{"eth1"},
+			BondOptions: &networkv1.BondOptions{Miimon: 100, UpDelay: 200}}},
+	}))
+	assert.Equal(t, []string{FeatureCarrierTracking}, VlanConfigFeatures(&networkv1.VlanConfig{
+		Spec: networkv1.VlanConfigSpec{Uplink: networkv1.Uplink{NICs: []string{"eth1"},
+			BondOptions: &networkv1.BondOptions{Miimon: 0}}},
+	}))
+	// this agent understands every feature it detects
+	assert.Empty(t, MissingFeatures(VlanConfigFeatures(vc), AgentFeatures))
+}
+
+func TestClusterNetworkFeatures(t *testing.T) {
+	assert.Empty(t, ClusterNetworkFeatures(nil))
+	assert.Empty(t, ClusterNetworkFeatures(&networkv1.ClusterNetwork{}))
+	assert.Empty(t, ClusterNetworkFeatures(&networkv1.ClusterNetwork{Spec: networkv1.ClusterNetworkSpec{
+		Datapath:    networkv1.DatapathVLANFiltering,
+		UntaggedVID: DefaultVlanID,
+		QoS:         &networkv1.QoSOptions{Priority: networkv1.QoSPriorityNormal},
+		Neighbor:    &networkv1.NeighborOptions{},
+	}}))
+	assert.Equal(t, []string{FeatureMacvlan, FeatureNeighbor, FeatureQoSPriority, FeatureUntaggedVID, FeatureVXLAN},
+		ClusterNetworkFeatures(&networkv1.ClusterNetwork{Spec: networkv1.ClusterNetworkSpec{
+			Type:        networkv1.ClusterNetworkTypeVXLAN,
+			Datapath:    networkv1.DatapathMacvlan,
+			UntaggedVID: 100,
+			QoS:         &networkv1.QoSOptions{Priority: networkv1.QoSPriorityHigh},
+			Neighbor:    &networkv1.NeighborOptions{ProxyARP: true},
+		}}))
+	assert.Equal(t, []string{FeatureMulticast, FeatureOVS}, ClusterNetworkFeatures(&networkv1.ClusterNetwork{
+		Spec: networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathOVS, Multicast: &networkv1.MulticastOptions{}},
+	}))
+	// this agent understands every feature of a cluster network
+	assert.Empty(t, MissingFeatures([]string{FeatureBridgePerVID, FeatureIpvlan, FeatureMacvlan, FeatureMulticast,
+		FeatureNeighbor, FeatureOVS, FeatureQoSPriority, FeatureUntaggedVID, FeatureVXLAN}, AgentFeatures))
+}
+
+func TestMissingFeatures(t *testing.T) {
+	assert.Empty(t, MissingFeatures(nil, nil))
+	assert.Empty(t, MissingFeatures([]string{FeatureFabricB}, []string{FeatureSharedBond, FeatureFabricB}))
+	assert.Equal(t, []string{FeatureNICTuning}, MissingFeatures([]string{FeatureFabricB, FeatureNICTuning},
+		[]string{FeatureFabricB}))
+}
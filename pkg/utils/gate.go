@@ -0,0 +1,32 @@
+package utils
+
+import "sync"
+
+// Gate blocks the callers of Wait until it's opened, e.g. to hold the controllers back until the agent has converged
+// the node network at startup. A gate can't be closed again once it's opened.
+type Gate struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+func NewGate() *Gate {
+	return &Gate{ch: make(chan struct{})}
+}
+
+// Open releases the waiters, it's safe to call it more than once
+func (g *Gate) Open() {
+	g.once.Do(func() { close(g.ch) })
+}
+
+func (g *Gate) Wait() {
+	<-g.ch
+}
+
+func (g *Gate) IsOpen() bool {
+	select {
+	case <-g.ch:
+		return true
+	default:
+		return false
+	}
+}
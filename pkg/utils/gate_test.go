@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGate(t *testing.T) {
+	g := NewGate()
+	if g.IsOpen() {
+		t.Fatal("gate is open before it's opened")
+	}
+
+	released := make(chan struct{})
+	go func() {
+		g.Wait()
+		close(released)
+	}()
+
+	select {
+	case <-released:
+		t.Fatal("waiter is released before the gate is opened")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	g.Open()
+	g.Open()
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("waiter isn't released after the gate is opened")
+	}
+	if !g.IsOpen() {
+		t.Fatal("gate isn't open after it's opened")
+	}
+}
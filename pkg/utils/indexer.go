@@ -0,0 +1,60 @@
+package utils
+
+import (
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/sirupsen/logrus"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// The indexes are added to the caches of the controllers in config.SetupManagement
+const (
+	VlanStatusByNodeIndex                  = "network.harvesterhci.io/vlanstatus-by-node"
+	VlanStatusByClusterNetworkAndNodeIndex = "network.harvesterhci.io/vlanstatus-by-clusternetwork-and-node"
+	VlanConfigByClusterNetworkIndex        = "network.harvesterhci.io/vlanconfig-by-clusternetwork"
+	NadByBridgeIndex                       = "network.harvesterhci.io/nad-by-bridge"
+)
+
+// VlanStatusByNode indexes the vlanstatuses by the node they are reported from
+func VlanStatusByNode(vs *networkv1.VlanStatus) ([]string, error) {
+	if vs.Status.Node == "" {
+		return nil, nil
+	}
+	return []string{vs.Status.Node}, nil
+}
+
+// VlanStatusByClusterNetworkAndNode indexes the vlanstatuses by the VlanStatusKey of the cluster network and the node
+// they are reported for
+func VlanStatusByClusterNetworkAndNode(vs *networkv1.VlanStatus) ([]string, error) {
+	if vs.Status.ClusterNetwork == "" || vs.Status.Node == "" {
+		return nil, nil
+	}
+	return []string{VlanStatusKey(vs.Status.ClusterNetwork, vs.Status.Node)}, nil
+}
+
+// VlanConfigByClusterNetwork indexes the vlanconfigs by their cluster network
+func VlanConfigByClusterNetwork(vc *networkv1.VlanConfig) ([]string, error) {
+	if vc.Spec.ClusterNetwork == "" {
+		return nil, nil
+	}
+	return []string{vc.Spec.ClusterNetwork}, nil
+}
+
+// NadByBridge indexes the bridge NADs by their bridge name, e.g. cn1-br
+func NadByBridge(nad *nadv1.NetworkAttachmentDefinition) ([]string, error) {
+	netconf, err := DecodeNadConfigToNetConf(nad)
+	if err != nil {
+		// don't fail the informer because of an invalid config
+		logrus.Warnf("skip indexing nad %s/%s, error: %s", nad.Namespace, nad.Name, err.Error())
+		return nil, nil
+	}
+	if !netconf.IsBridgeCNI() || netconf.BrName == "" {
+		return nil, nil
+	}
+	return []string{netconf.BrName}, nil
+}
+
+// GetBridgeNameOfClusterNetwork returns the name of the bridge of the cluster network, e.g. cn1-br
+func GetBridgeNameOfClusterNetwork(cnName string) string {
+	return cnName + BridgeSuffix
+}
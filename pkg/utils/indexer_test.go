@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNadByBridge(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected []string
+	}{
+		{
+			name:     "bridge NAD",
+			config:   testNadConfigVlan300,
+			expected: []string{"test-cn-br"},
+		},
+		{
+			name:     "not a bridge NAD",
+			config:   testNadConfigOVN,
+			expected: nil,
+		},
+		{
+			name:     "invalid config",
+			config:   "{",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nad := &nadv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "net1", Namespace: "default"},
+				Spec:       nadv1.NetworkAttachmentDefinitionSpec{Config: tc.config},
+			}
+			keys, err := NadByBridge(nad)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, keys)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package utils
+
+import "sync"
+
+// KeyMutex serializes the operations on the same key among the parallel workers, while the operations on different
+// keys run concurrently. The lock of a key is released from the map once it has no waiters.
+type KeyMutex struct {
+	mutex sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+func NewKeyMutex() *KeyMutex {
+	return &KeyMutex{locks: make(map[string]*keyLock)}
+}
+
+// Lock locks the key and returns the function unlocking it, e.g. defer m.Lock(key)()
+func (m *KeyMutex) Lock(key string) func() {
+	m.mutex.Lock()
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mutex.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, key)
+		}
+	}
+}
+
+// The keys of the resources shared by the controllers of the agent on the node. When several keys are locked, the
+// cluster network is locked first, followed by the link and then the node, to avoid deadlocks.
+
+// LockKeyOfClusterNetwork is the key of the bridge and the uplink of the cluster network on the node
+func LockKeyOfClusterNetwork(cnName string) string {
+	return "clusternetwork/" + cnName
+}
+
+// LockKeyOfLink is the key of a link shared by several cluster networks, e.g. a shared bond
+func LockKeyOfLink(name string) string {
+	return "link/" + name
+}
+
+// LockKeyOfNode is the key of the node object, whose labels and annotations are updated by several controllers
+func LockKeyOfNode(nodeName string) string {
+	return "node/" + nodeName
+}
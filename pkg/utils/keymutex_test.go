@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyMutex(t *testing.T) {
+	m := NewKeyMutex()
+	keys := []string{LockKeyOfClusterNetwork("cn1"), LockKeyOfClusterNetwork("cn2")}
+	counters := map[string]*int{keys[0]: new(int), keys[1]: new(int)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		for _, key := range keys {
+			wg.Add(1)
+			go func(key string) {
+				defer wg.Done()
+				// the read-modify-write of a counter is only serialized by the lock of its key
+				defer m.Lock(key)()
+				value := *counters[key]
+				runtime.Gosched()
+				*counters[key] = value + 1
+			}(key)
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, 100, *counters[keys[0]])
+	assert.Equal(t, 100, *counters[keys[1]])
+	assert.Empty(t, m.locks)
+}
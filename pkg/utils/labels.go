@@ -1,7 +1,11 @@
 package utils
 
-import "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io"
+import (
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io"
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
+)
 
+// the annotation keys alias the keys of the documented contract in the package annotations
 const (
 	KeyVlanLabel             = network.GroupName + "/vlan-id"
 	KeyVlanConfigLabel       = network.GroupName + "/vlanconfig"
@@ -10,23 +14,59 @@ const (
 	KeyNetworkType           = network.GroupName + "/type"
 	KeyLastNetworkType       = network.GroupName + "/last-type"
 	KeyNetworkReady          = network.GroupName + "/ready"
-	KeyNetworkRoute          = network.GroupName + "/route"
+	KeyIPFamilyLabel         = network.GroupName + "/ip-family" // the IP family the helper job probes
+	KeyNetworkRoute          = annotations.KeyNetworkRoute
 	KeyNetworkRouteSourceVID = network.GroupName + "/route-source-vid" // the source vid of this route
-	KeyMTUSourceVlanConfig   = network.GroupName + "/mtu-source-vc"    // the VC which syncs MTU to CN
-	KeyUplinkMTU             = network.GroupName + "/uplink-mtu"       // configured MTU on the VC'uplink
+	KeyMTUSourceVlanConfig   = annotations.KeyMTUSourceVlanConfig      // the VC which syncs MTU to CN
+	KeyUplinkMTU             = annotations.KeyUplinkMTU                // configured MTU on the VC'uplink
+
+	KeyMatchedNodes = annotations.KeyMatchedNodes
+
+	KeyInheritedUplinkFields = annotations.KeyInheritedUplinkFields // uplink sections inherited from the CN, format "bondOptions,linkAttributes"
+
+	KeyCloneRequest = annotations.KeyCloneRequest // request to clone the VC, see CloneRequest
+	KeyCloneResult  = annotations.KeyCloneResult  // result of the last clone request
+	KeyClonedFrom   = annotations.KeyClonedFrom   // the source VC of a cloned VC
+
+	KeyForceTeardown = annotations.KeyForceTeardown // "true" tears down the bridge of the VC even if VM ports remain
+
+	KeyDeletedVlanConfigs = annotations.KeyDeletedVlanConfigs // snapshots of the recently deleted VCs of a CN, see DeletedVlanConfig
+	KeyRestoreVlanConfig  = annotations.KeyRestoreVlanConfig  // request to restore the deleted VC of the name on a CN
+	KeyRestoreResult      = annotations.KeyRestoreResult      // result of the last restore request
+
+	KeyVlanIDSetStr     = annotations.KeyVlanIDSetStr     // all vlan ids under current cluster network, format "1,2,3..."
+	KeyVlanIDSetStrHash = annotations.KeyVlanIDSetStrHash // hash value of above string
 
-	KeyMatchedNodes = network.GroupName + "/matched-nodes"
+	KeyVlanDHCPServerIP = annotations.KeyVlanDHCPServerIP
 
-	KeyVlanIDSetStr     = network.GroupName + "/vlan-id-set-str"      // all vlan ids under current cluster network, format "1,2,3..."
-	KeyVlanIDSetStrHash = network.GroupName + "/vlan-id-set-str-hash" // hash value of above string
+	KeyUplink = annotations.KeyUplink // "false" keeps the VIDs of the NAD off the uplinks
+	KeyTTL    = annotations.KeyTTL    // time to live of the NAD or the VC, e.g. "2h"
 
-	KeyVlanDHCPServerIP = network.GroupName + "/vlan-dhcp-server-ip"
+	KeyQoSMarking = annotations.KeyQoSMarking // PCP and DSCP marked on the frames of the NAD, see annotations.Marking
+
+	KeyLLDPNeighbors = annotations.KeyLLDPNeighbors // LLDP neighbors of the NICs annotated on the node, see switchport.Neighbor
+
+	KeyOwner     = network.GroupName + "/owner"      // owner or team of the physical network segment, see networkv1.Ownership
+	KeyTicketRef = network.GroupName + "/ticket-ref" // external ticket reference of the physical network segment
+
+	KeyDeletionConfirmation = annotations.KeyDeletionConfirmation // confirm or abort the deletion of a CN in its grace period
+
+	KeyChangedKind = network.GroupName + "/changed-kind" // the kind of the resource a NetworkChangeLog records the change of
+
+	KeyDeschedule        = network.GroupName + "/deschedule" // labels the virt-launcher pods to deschedule for unhealthy networks
+	KeyUnhealthyNetworks = annotations.KeyUnhealthyNetworks  // the unhealthy CNs a virt-launcher pod is descheduled for
 
 	ValueTrue  = "true"
 	ValueFalse = "false"
 
+	ValueConfirm = "confirm"
+	ValueAbort   = "abort"
+
 	HarvesterWitnessNodeLabelKey = "node-role.harvesterhci.io/witness"
 
+	// KeyRancherProjectID is the label of the namespaces in a Rancher project, the value is the project ID
+	KeyRancherProjectID = "field.cattle.io/projectId"
+
 	HarvesterMgmtClusterNetworkLabeyKey = network.GroupName + "/" + ManagementClusterNetworkName
 
 	// defined in Harvester pkg/controller/master/storagenetwork/storage_network.go
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+)
+
+// LinkOwnersOnNode returns the owners of the links on the node reported by the link monitors, link name -> owner.
+// Only the links matched by any link monitor are known.
+func LinkOwnersOnNode(cache ctlnetworkv1.LinkMonitorCache, node string) (map[string]networkv1.LinkOwner, error) {
+	lms, err := cache.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list link monitors, error: %w", err)
+	}
+
+	owners := make(map[string]networkv1.LinkOwner)
+	for _, lm := range lms {
+		for _, link := range lm.Status.LinkStatus[node] {
+			owners[link.Name] = link.Owner
+		}
+	}
+
+	return owners, nil
+}
+
+// IsFreeNIC returns true if the NIC can be enslaved by a cluster network, i.e. it's owned by nothing or by the
+// controller itself, e.g. when it's moved between the cluster networks
+func IsFreeNIC(owner networkv1.LinkOwner) bool {
+	return owner == networkv1.LinkOwnerNone || owner == networkv1.LinkOwnerController
+}
+
+// CheckNICsNotOwned returns an error listing the NICs owned by the other components on the node, e.g. the CNI plugins
+func CheckNICsNotOwned(cache ctlnetworkv1.LinkMonitorCache, node string, nics []string) error {
+	owners, err := LinkOwnersOnNode(cache, node)
+	if err != nil {
+		return err
+	}
+
+	conflicts := make([]string, 0)
+	for _, nic := range nics {
+		if owner, ok := owners[nic]; ok && !IsFreeNIC(owner) {
+			conflicts = append(conflicts, fmt.Sprintf("NIC %s is owned by %s", nic, owner))
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("%s on node %s", strings.Join(conflicts, "; "), node)
+	}
+
+	return nil
+}
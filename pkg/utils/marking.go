@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
+)
+
+// QoSMarkingsOfNads returns the QoS markings of the VIDs the NADs carry on the uplinks. The VIDs of several NADs
+// can only be marked in one way, the NAD first in the order of the namespaces and names wins and the error tells
+// the conflicts. The local-only NADs and the untagged networks aren't marked.
+func QoSMarkingsOfNads(nads []*nadv1.NetworkAttachmentDefinition) (map[uint16]annotations.Marking, error) {
+	sorted := make([]*nadv1.NetworkAttachmentDefinition, 0, len(nads))
+	for _, nad := range nads {
+		if _, ok := annotations.QoSMarking.Get(nad); ok && nad.DeletionTimestamp == nil && !IsLocalOnlyNad(nad) {
+			sorted = append(sorted, nad)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	markings := make(map[uint16]annotations.Marking)
+	owners := make(map[uint16]string)
+	var conflict error
+	for _, nad := range sorted {
+		marking, err := annotations.GetQoSMarking(nad)
+		if err != nil {
+			return nil, fmt.Errorf("nad %s/%s has %w", nad.Namespace, nad.Name, err)
+		}
+		vis, err := NewVlanIDSetFromNadList([]*nadv1.NetworkAttachmentDefinition{nad})
+		if err != nil {
+			return nil, err
+		}
+		name := nad.Namespace + "/" + nad.Name
+		for _, vid := range vis.VIDs() {
+			if vid == 0 {
+				continue
+			}
+			if existing, ok := markings[vid]; ok {
+				if !existing.Equal(marking) && conflict == nil {
+					conflict = fmt.Errorf("the VID %d of nad %s is marked differently by nad %s", vid, name, owners[vid])
+				}
+				continue
+			}
+			markings[vid] = *marking
+			owners[vid] = name
+		}
+	}
+
+	return markings, conflict
+}
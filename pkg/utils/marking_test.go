@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQoSMarkingsOfNads(t *testing.T) {
+	nad := func(name string, vlan int, marking string) *nadv1.NetworkAttachmentDefinition {
+		return &nadv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: map[string]string{KeyQoSMarking: marking}},
+			Spec: nadv1.NetworkAttachmentDefinitionSpec{
+				Config: fmt.Sprintf(`{"cniVersion":"0.3.1","name":"%s","type":"bridge","bridge":"data-br","vlan":%d,"ipam":{}}`,
+					name, vlan),
+			},
+		}
+	}
+
+	markings, err := QoSMarkingsOfNads([]*nadv1.NetworkAttachmentDefinition{
+		nad("voice", 100, `{"pcp":5,"dscp":46}`), nad("voice-2", 100, `{"pcp":5,"dscp":46}`), nad("video", 200, `{"pcp":4}`),
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, markings, 2) {
+		assert.Equal(t, uint8(46), *markings[100].DSCP)
+		assert.Nil(t, markings[200].DSCP)
+	}
+
+	markings, err = QoSMarkingsOfNads([]*nadv1.NetworkAttachmentDefinition{
+		nad("voice-b", 100, `{"pcp":3}`), nad("voice-a", 100, `{"pcp":5}`),
+	})
+	assert.ErrorContains(t, err, "the VID 100 of nad default/voice-b is marked differently by nad default/voice-a")
+	assert.Equal(t, uint8(5), *markings[100].PCP, "the first NAD wins")
+
+	_, err = QoSMarkingsOfNads([]*nadv1.NetworkAttachmentDefinition{nad("voice", 100, `{"pcp":9}`)})
+	assert.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// CheckVlanStatusCoversNad checks that the network of the node reported in the vlanstatus can serve the NAD, i.e.
+// the cluster network is ready, the VLAN IDs of the NAD are programmed on the uplink and the uplink MTU is not less
+// than the MTU of the NAD. The VLAN IDs are only checked if the agent reports them.
+func CheckVlanStatusCoversNad(vs *networkv1.VlanStatus, nad *nadv1.NetworkAttachmentDefinition) error {
+	if !networkv1.Ready.IsTrue(vs.Status) {
+		return fmt.Errorf("cluster network %s is not ready on node %s: %s", vs.Status.ClusterNetwork, vs.Status.Node,
+			networkv1.Ready.GetMessage(vs.Status))
+	}
+
+	nc, err := DecodeNadConfigToNetConf(nad)
+	if err != nil {
+		return err
+	}
+	if !nc.IsBridgeCNI() {
+		return nil
+	}
+
+	if slices.Contains(vs.Status.Features, FeatureVIDStatus) {
+		vids, err := VIDsOfNad(nad)
+		if err != nil {
+			return err
+		}
+		missing := make([]uint16, 0)
+		for _, vid := range vids {
+			if !slices.Contains(vs.Status.VIDs, vid) {
+				missing = append(missing, vid)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("VLAN IDs %v of nad %s/%s are not programmed on cluster network %s of node %s", missing,
+				nad.Namespace, nad.Name, vs.Status.ClusterNetwork, vs.Status.Node)
+		}
+	}
+
+	if nc.MTU != 0 && vs.Status.MTU != 0 && nc.MTU > vs.Status.MTU {
+		return fmt.Errorf("MTU %d of nad %s/%s exceeds the uplink MTU %d of cluster network %s on node %s", nc.MTU,
+			nad.Namespace, nad.Name, vs.Status.MTU, vs.Status.ClusterNetwork, vs.Status.Node)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"testing"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestCheckVlanStatusCoversNad(t *testing.T) {
+	nad := &nadv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "net1", Namespace: "default"},
+		Spec: nadv1.NetworkAttachmentDefinitionSpec{
+			Config: `{"cniVersion":"0.3.1","name":"net1","type":"bridge","bridge":"cn1-br","vlan":300,"mtu":9000}`,
+		},
+	}
+	newVlanStatus := func(ready bool, vids []uint16, mtu int, features ...string) *networkv1.VlanStatus {
+		vs := &networkv1.VlanStatus{
+			Status: networkv1.VlStatus{
+				ClusterNetwork: "cn1",
+				Node:           "node1",
+				VIDs:           vids,
+				MTU:            mtu,
+				Features:       features,
+			},
+		}
+		networkv1.Ready.SetStatusBool(vs, ready)
+		return vs
+	}
+
+	tests := []struct {
+		name      string
+		vs        *networkv1.VlanStatus
+		returnErr bool
+	}{
+		{
+			name: "covered",
+			vs:   newVlanStatus(true, []uint16{100, 300}, 9000, FeatureVIDStatus),
+		},
+		{
+			name:      "not ready",
+			vs:        newVlanStatus(false, []uint16{300}, 9000, FeatureVIDStatus),
+			returnErr: true,
+		},
+		{
+			name:      "VLAN ID not programmed",
+			vs:        newVlanStatus(true, []uint16{100}, 9000, FeatureVIDStatus),
+			returnErr: true,
+		},
+		{
+			name: "the agent doesn't report the VLAN IDs",
+			vs:   newVlanStatus(true, nil, 9000),
+		},
+		{
+			name:      "uplink MTU too small",
+			vs:        newVlanStatus(true, []uint16{300}, 1500, FeatureVIDStatus),
+			returnErr: true,
+		},
+		{
+			name: "the agent doesn't report the MTU",
+			vs:   newVlanStatus(true, []uint16{300}, 0, FeatureVIDStatus),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckVlanStatusCoversNad(tc.vs, nad)
+			assert.Equal(t, tc.returnErr, err != nil, err)
+		})
+	}
+}
@@ -3,12 +3,46 @@ package utils
 import (
 	"fmt"
 	"strconv"
+	"sync"
 
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 )
 
+// the bounds of the valid MTUs, MinMTU and MaxMTU unless they're overridden cluster-wide
+var mtuBounds = struct {
+	mutex    sync.RWMutex
+	min, max int
+}{min: MinMTU, max: MaxMTU}
+
+// MTUBounds returns the lower and the upper bounds of the valid MTUs
+func MTUBounds() (int, int) {
+	mtuBounds.mutex.RLock()
+	defer mtuBounds.mutex.RUnlock()
+	return mtuBounds.min, mtuBounds.max
+}
+
+// SetMTUBounds overrides the bounds of the valid MTUs. They must include the default MTU, which the omitted MTUs fall
+// back to, and stay in [AbsoluteMinMTU..AbsoluteMaxMTU].
+func SetMTUBounds(minMTU, maxMTU int) error {
+	if minMTU < AbsoluteMinMTU || maxMTU > AbsoluteMaxMTU {
+		return fmt.Errorf("the MTU bounds [%d..%d] are not in range [%d..%d]", minMTU, maxMTU, AbsoluteMinMTU, AbsoluteMaxMTU)
+	}
+	if minMTU > DefaultMTU || maxMTU < DefaultMTU {
+		return fmt.Errorf("the MTU bounds [%d..%d] don't include the default MTU %d", minMTU, maxMTU, DefaultMTU)
+	}
+
+	mtuBounds.mutex.Lock()
+	defer mtuBounds.mutex.Unlock()
+	mtuBounds.min, mtuBounds.max = minMTU, maxMTU
+	return nil
+}
+
 func IsValidMTU(MTU int) bool {
-	return MTU == 0 || (MTU >= MinMTU && MTU <= MaxMTU)
+	minMTU, maxMTU := MTUBounds()
+	return MTU == 0 || (MTU >= minMTU && MTU <= maxMTU)
 }
 
 func IsDefaultMTU(MTU int) bool {
@@ -25,7 +59,8 @@ func GetMTUFromString(s string) (int, error) {
 		return 0, fmt.Errorf("value %v is not an integer: %w", s, err)
 	}
 	if !IsValidMTU(MTU) {
-		return 0, fmt.Errorf("value %v is not in range [0, %v..%v]", s, MinMTU, MaxMTU)
+		minMTU, maxMTU := MTUBounds()
+		return 0, fmt.Errorf("value %v is not in range [0, %v..%v]", s, minMTU, maxMTU)
 	}
 	return MTU, nil
 }
@@ -47,3 +82,56 @@ func MTUDefaultTo(MTU int) int {
 	}
 	return MTU
 }
+
+// GetMTUOfClusterNetwork returns the uplink MTU of the cluster network, the default MTU if it's not annotated
+func GetMTUOfClusterNetwork(cn *networkv1.ClusterNetwork) (int, error) {
+	mtu, _, err := annotations.GetUplinkMTU(cn)
+	if err != nil {
+		return 0, err
+	}
+	return MTUDefaultTo(mtu), nil
+}
+
+// CheckMTUAgainstMgmt returns why the MTU of the cluster network with the NADs is known to cause problems compared
+// with the mgmt network, or an empty string if there is none, e.g. the storage network has a smaller MTU than mgmt,
+// which is a frequent cause of slow or stalled volume replication.
+func CheckMTUAgainstMgmt(cn, mgmt *networkv1.ClusterNetwork, nads []*nadv1.NetworkAttachmentDefinition) (string, error) {
+	if cn.Name == ManagementClusterNetworkName {
+		return "", nil
+	}
+	storageNad := FilterFirstActiveStorageNetworkNad(nads)
+	if storageNad == nil {
+		return "", nil
+	}
+
+	mtu, err := GetMTUOfClusterNetwork(cn)
+	if err != nil {
+		return "", err
+	}
+	mgmtMTU, err := GetMTUOfClusterNetwork(mgmt)
+	if err != nil {
+		return "", err
+	}
+	if mtu < mgmtMTU {
+		return fmt.Sprintf("the MTU %d of the storage network nad %s/%s is less than the MTU %d of cluster network %s",
+			mtu, storageNad.Namespace, storageNad.Name, mgmtMTU, ManagementClusterNetworkName), nil
+	}
+
+	return "", nil
+}
+
+// CheckMaxMTU returns an error if the MTU of the object, 0 for the default MTU, exceeds the maximum MTU of the
+// cluster network
+func CheckMaxMTU(cn *networkv1.ClusterNetwork, mtu int, object string) error {
+	if cn == nil || cn.Spec.MaxMTU == 0 {
+		return nil
+	}
+
+	if mtu = MTUDefaultTo(mtu); mtu > cn.Spec.MaxMTU {
+		return fmt.Errorf("the MTU %d of %s exceeds the maximum MTU %d of cluster network %s; the fabric drops the "+
+			"larger frames silently, so lower the MTU or raise spec.maxMTU of the cluster network once the switches are "+
+			"verified to forward it", mtu, object, cn.Spec.MaxMTU, cn.Name)
+	}
+
+	return nil
+}
@@ -3,7 +3,9 @@ package utils
 import (
 	"testing"
 
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 )
@@ -190,3 +192,136 @@ func TestMTUDefaultTo(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckMTUAgainstMgmt(t *testing.T) {
+	cnWithMTU := func(name, mtu string) *networkv1.ClusterNetwork {
+		cn := &networkv1.ClusterNetwork{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if mtu != "" {
+			cn.Annotations = map[string]string{KeyUplinkMTU: mtu}
+		}
+		return cn
+	}
+	storageNad := &nadv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "harvester-system", Name: StorageNetworkNetAttachDefPrefix + "abc"},
+	}
+	vmNad := &nadv1.NetworkAttachmentDefinition{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm"}}
+
+	tests := []struct {
+		name       string
+		cn         *networkv1.ClusterNetwork
+		mgmt       *networkv1.ClusterNetwork
+		nads       []*nadv1.NetworkAttachmentDefinition
+		mismatched bool
+		expectErr  bool
+	}{
+		{
+			name:       "storage network has a smaller MTU than mgmt",
+			cn:         cnWithMTU("storage", "1500"),
+			mgmt:       cnWithMTU(ManagementClusterNetworkName, "9000"),
+			nads:       []*nadv1.NetworkAttachmentDefinition{vmNad, storageNad},
+			mismatched: true,
+		},
+		{
+			name:       "storage network defaults to a smaller MTU than mgmt",
+			cn:         cnWithMTU("storage", ""),
+			mgmt:       cnWithMTU(ManagementClusterNetworkName, "9000"),
+			nads:       []*nadv1.NetworkAttachmentDefinition{storageNad},
+			mismatched: true,
+		},
+		{
+			name: "storage network has a larger MTU than mgmt",
+			cn:   cnWithMTU("storage", "9000"),
+			mgmt: cnWithMTU(ManagementClusterNetworkName, ""),
+			nads: []*nadv1.NetworkAttachmentDefinition{storageNad},
+		},
+		{
+			name: "VM network has a smaller MTU than mgmt",
+			cn:   cnWithMTU("vm", "1500"),
+			mgmt: cnWithMTU(ManagementClusterNetworkName, "9000"),
+			nads: []*nadv1.NetworkAttachmentDefinition{vmNad},
+		},
+		{
+			name:      "invalid MTU annotation",
+			cn:        cnWithMTU("storage", "jumbo"),
+			mgmt:      cnWithMTU(ManagementClusterNetworkName, "9000"),
+			nads:      []*nadv1.NetworkAttachmentDefinition{storageNad},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := CheckMTUAgainstMgmt(tt.cn, tt.mgmt, tt.nads)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.mismatched, msg != "", msg)
+		})
+	}
+}
+
+func TestCheckMaxMTU(t *testing.T) {
+	cnWithMaxMTU := func(maxMTU int) *networkv1.ClusterNetwork {
+		return &networkv1.ClusterNetwork{
+			ObjectMeta: metav1.ObjectMeta{Name: "cn1"},
+			Spec:       networkv1.ClusterNetworkSpec{MaxMTU: maxMTU},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		cn        *networkv1.ClusterNetwork
+		mtu       int
+		expectErr bool
+	}{
+		{
+			name: "no limit",
+			cn:   cnWithMaxMTU(0),
+			mtu:  9000,
+		},
+		{
+			name: "MTU equal to the limit",
+			cn:   cnWithMaxMTU(1500),
+			mtu:  1500,
+		},
+		{
+			name:      "MTU exceeds the limit",
+			cn:        cnWithMaxMTU(1500),
+			mtu:       9000,
+			expectErr: true,
+		},
+		{
+			name:      "default MTU exceeds the limit",
+			cn:        cnWithMaxMTU(1400),
+			mtu:       0,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckMaxMTU(tt.cn, tt.mtu, "vlanconfig vc1")
+			assert.Equal(t, tt.expectErr, err != nil, err)
+		})
+	}
+}
+
+func TestSetMTUBounds(t *testing.T) {
+	defer func() {
+		assert.NoError(t, SetMTUBounds(MinMTU, MaxMTU))
+	}()
+
+	assert.Error(t, SetMTUBounds(AbsoluteMinMTU-1, MaxMTU), "below the absolute minimum")
+	assert.Error(t, SetMTUBounds(MinMTU, AbsoluteMaxMTU+1), "above the absolute maximum")
+	assert.Error(t, SetMTUBounds(MinMTU, 1400), "the default MTU is excluded")
+	assert.True(t, IsValidMTU(9000), "the bounds are kept after the errors")
+
+	assert.NoError(t, SetMTUBounds(1280, 9216))
+	assert.True(t, IsValidMTU(9216))
+	assert.True(t, IsValidMTU(0))
+	assert.False(t, IsValidMTU(1000))
+	_, err := GetMTUFromString("9217")
+	assert.ErrorContains(t, err, "[0, 1280..9216]")
+}
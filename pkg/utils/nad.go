@@ -7,6 +7,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -23,6 +24,9 @@ const (
 
 	CNITypeBridge       = "bridge"
 	CNITypeDefaultEmpty = "" // potential empty type, is treated as CNITypeBridge
+	CNITypeMacvlan      = "macvlan"
+	CNITypeIpvlan       = "ipvlan"
+	CNITypeOVS          = "ovs"
 )
 
 type Connectivity string
@@ -31,9 +35,19 @@ const (
 	Connectable   Connectivity = "true"
 	Unconnectable Connectivity = "false"
 	DHCPFailed    Connectivity = "DHCP failed"
+	RAFailed      Connectivity = "RA failed"
 	PingFailed    Connectivity = "ping failed"
 )
 
+// IPFamily is the address family of the layer 3 network, empty means IPv4
+type IPFamily string
+
+const (
+	IPv4      IPFamily = "ipv4"
+	IPv6      IPFamily = "ipv6"
+	DualStack IPFamily = "dual"
+)
+
 type Mode string
 
 const (
@@ -55,12 +69,46 @@ const (
 type NadSelectedNetworks []nadv1.NetworkSelectionElement
 
 type Layer3NetworkConf struct {
-	Mode         Mode         `json:"mode,omitempty"`
+	Mode Mode `json:"mode,omitempty"`
+	// the CIDR and the gateway are IPv6 for the IPv6 family, IPv4 for the others
+	IPFamily     IPFamily     `json:"ipFamily,omitempty"`
 	CIDR         string       `json:"cidr,omitempty"`
 	Gateway      string       `json:"gateway,omitempty"`
 	ServerIPAddr string       `json:"serverIPAddr,omitempty"`
 	Connectivity Connectivity `json:"connectivity,omitempty"`
-	Outdated     bool         `json:"outdated,omitempty"`
+	// the IPv6 CIDR and gateway of the dual stack family
+	IPv6CIDR         string       `json:"ipv6CIDR,omitempty"`
+	IPv6Gateway      string       `json:"ipv6Gateway,omitempty"`
+	IPv6Connectivity Connectivity `json:"ipv6Connectivity,omitempty"`
+	Outdated         bool         `json:"outdated,omitempty"`
+	// the result of the last DHCP probe of the helper job, only in the auto mode of the IPv4 and dual stack families
+	DHCPProbe *DHCPProbe `json:"dhcpProbe,omitempty"`
+}
+
+// DHCPProbe is how the DHCP server of the VLAN answered the helper job, the manager exports it as the metrics
+type DHCPProbe struct {
+	// ServerIP is the IP of the DHCP server which answered, empty if none did
+	ServerIP string `json:"serverIP,omitempty"`
+	// LatencyMs is the time in milliseconds the DHCP server took to answer or the probe took to fail
+	LatencyMs int64 `json:"latencyMs,omitempty"`
+	// Error is why the probe failed, empty if it succeeded
+	Error string `json:"error,omitempty"`
+	// Time is when the probe was done in RFC3339
+	Time string `json:"time,omitempty"`
+}
+
+// Succeeded returns true if the DHCP server answered the probe
+func (p *DHCPProbe) Succeeded() bool {
+	return p.Error == ""
+}
+
+// ProbedAt returns when the probe was done, the zero time if it's unknown
+func (p *DHCPProbe) ProbedAt() time.Time {
+	t, err := time.Parse(time.RFC3339, p.Time)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
 }
 
 func NewLayer3NetworkConf(conf string) (*Layer3NetworkConf, error) {
@@ -74,21 +122,8 @@ func NewLayer3NetworkConf(conf string) (*Layer3NetworkConf, error) {
 		return nil, fmt.Errorf("unmarshal %s faield, error: %w", conf, err)
 	}
 
-	// validate
-	if networkConf.Mode != "" && networkConf.Mode != Auto && networkConf.Mode != Manual {
-		return nil, fmt.Errorf("unknown mode %s", networkConf.Mode)
-	}
-
-	// validate cidr and gateway when the mode is manual
-	if networkConf.Mode == Manual {
-		_, ipnet, err := net.ParseCIDR(networkConf.CIDR)
-		if err != nil || (ipnet != nil && isMaskZero(ipnet)) {
-			return nil, fmt.Errorf("the CIDR %s is invalid", networkConf.CIDR)
-		}
-
-		if net.ParseIP(networkConf.Gateway) == nil {
-			return nil, fmt.Errorf("the gateway %s is invalid", networkConf.Gateway)
-		}
+	if err := networkConf.validate(); err != nil {
+		return nil, err
 	}
 
 	return networkConf, nil
@@ -207,21 +242,8 @@ func NewLayer3NetworkConfFromNad(nad *nadv1.NetworkAttachmentDefinition) (*Layer
 		return nil, fmt.Errorf("unmarshal nad %v/%v annotation %v %s faield, error: %w", nad.Namespace, nad.Name, KeyNetworkRoute, routeStr, err)
 	}
 
-	// validate
-	if networkConf.Mode != "" && networkConf.Mode != Auto && networkConf.Mode != Manual {
-		return nil, fmt.Errorf("unknown mode %s", networkConf.Mode)
-	}
-
-	// validate cidr and gateway when the mode is manual
-	if networkConf.Mode == Manual {
-		_, ipnet, err := net.ParseCIDR(networkConf.CIDR)
-		if err != nil || (ipnet != nil && isMaskZero(ipnet)) {
-			return nil, fmt.Errorf("the CIDR %s is invalid", networkConf.CIDR)
-		}
-
-		if net.ParseIP(networkConf.Gateway) == nil {
-			return nil, fmt.Errorf("the gateway %s is invalid", networkConf.Gateway)
-		}
+	if err := networkConf.validate(); err != nil {
+		return nil, err
 	}
 
 	return networkConf, nil
@@ -269,6 +291,52 @@ func (c *Layer3NetworkConf) ToString() (string, error) {
 	return string(bytes), nil
 }
 
+// GetIPFamily returns the address family of the network, IPv4 if it's not set
+func (c *Layer3NetworkConf) GetIPFamily() IPFamily {
+	if c == nil || c.IPFamily == "" {
+		return IPv4
+	}
+	return c.IPFamily
+}
+
+func (c *Layer3NetworkConf) validate() error {
+	if c.Mode != "" && c.Mode != Auto && c.Mode != Manual {
+		return fmt.Errorf("unknown mode %s", c.Mode)
+	}
+
+	family := c.GetIPFamily()
+	if family != IPv4 && family != IPv6 && family != DualStack {
+		return fmt.Errorf("unknown IP family %s", c.IPFamily)
+	}
+
+	// validate cidr and gateway when the mode is manual
+	if c.Mode != Manual {
+		return nil
+	}
+	if err := validateCIDRAndGateway(c.CIDR, c.Gateway, family == IPv6); err != nil {
+		return err
+	}
+	if family == DualStack {
+		return validateCIDRAndGateway(c.IPv6CIDR, c.IPv6Gateway, true)
+	}
+
+	return nil
+}
+
+func validateCIDRAndGateway(cidr, gateway string, ipv6 bool) error {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil || (ipnet != nil && isMaskZero(ipnet)) || (ipnet.IP.To4() == nil) != ipv6 {
+		return fmt.Errorf("the CIDR %s is invalid", cidr)
+	}
+
+	gw := net.ParseIP(gateway)
+	if gw == nil || (gw.To4() == nil) != ipv6 {
+		return fmt.Errorf("the gateway %s is invalid", gateway)
+	}
+
+	return nil
+}
+
 func (c *Layer3NetworkConf) GetDHCPServerIPAddr() string {
 	if c == nil {
 		return ""
@@ -285,6 +353,11 @@ func SetDHCPInfo2JobLabels(lb map[string]string, l2netconf *NetConf, l3netconf *
 	}
 	if l3netconf != nil {
 		lb[KeyVlanDHCPServerIP] = l3netconf.GetDHCPServerIPAddr()
+		if l3netconf.IPFamily != "" {
+			lb[KeyIPFamilyLabel] = string(l3netconf.IPFamily)
+		} else {
+			delete(lb, KeyIPFamilyLabel)
+		}
 	}
 }
 
@@ -293,7 +366,8 @@ func AreJobLabelsDHCPInfoUnchanged(lb map[string]string, l2netconf *NetConf, l3n
 		return false
 	}
 	if l2netconf != nil && l3netconf != nil {
-		return lb[KeyVlanLabel] == l2netconf.GetVlanString() && lb[KeyVlanDHCPServerIP] == l3netconf.GetDHCPServerIPAddr()
+		return lb[KeyVlanLabel] == l2netconf.GetVlanString() && lb[KeyVlanDHCPServerIP] == l3netconf.GetDHCPServerIPAddr() &&
+			lb[KeyIPFamilyLabel] == string(l3netconf.IPFamily)
 	}
 	return false
 }
@@ -330,6 +404,25 @@ type NetConf struct {
 	Vlan         int          `json:"vlan"`
 	Provider     string       `json:"provider"`
 	VlanTrunk    []*VlanTrunk `json:"vlanTrunk,omitempty"`
+	// PerVIDBridge is true if the NAD is attached to the bridge of its VID of a cluster network with the datapath
+	// BridgePerVID. BrName and Vlan are the bridge of the cluster network and the VID then, as if it's VLAN filtering
+	PerVIDBridge bool `json:"-"`
+	// OuterVlan is the S-VLAN of a QinQ NAD, whose frames leave the uplink tagged with the S-VLAN over the C-VLAN Vlan.
+	// The bridge CNI ignores it, the NAD is attached to the QinQ bridge of the S-VLAN filtering the C-VLAN.
+	OuterVlan int `json:"outerVlan,omitempty"`
+	// QinQBridge is true if the NAD is attached to a QinQ bridge, BrName is the bridge of the cluster network then
+	QinQBridge bool `json:"-"`
+	// Master and Mode are the uplink and the mode of the macvlan or ipvlan links of a NAD of a bridge-less cluster network
+	Master string `json:"master,omitempty"`
+	Mode   string `json:"mode,omitempty"`
+	// Bridgeless is true if the NAD is a macvlan or ipvlan NAD of a bridge-less cluster network. BrName and Vlan are the
+	// bridge the cluster network would have and the VID of the master then, as if it's a bridge NAD
+	Bridgeless bool `json:"-"`
+	// Trunk is the VIDs trunked by a NAD of the OVS CNI, in the format of VlanTrunk
+	Trunk []*VlanTrunk `json:"trunk,omitempty"`
+	// OVS is true if the NAD is attached to the OVS bridge of a cluster network with the datapath OVS by the OVS CNI.
+	// VlanTrunk is its trunk then, as if it's a bridge NAD
+	OVS bool `json:"-"`
 }
 
 type VlanTrunk struct {
@@ -400,6 +493,10 @@ func (nc *NetConf) IsVlanConfigValid() (bool, error) {
 }
 
 func (nc *NetConf) dumpVlanIDSet() (*VlanIDSet, error) {
+	// the C-VLAN of a QinQ NAD is carried inside the S-VLAN on the uplink of its QinQ bridge
+	if nc.IsQinQ() {
+		return NewVlanIDSet(), nil
+	}
 	// l2 tag mode or untag mode
 	if nc.IsVlanAccessMode() {
 		return NewVlanIDSetFromSingleVID(nc.Vlan)
@@ -425,7 +522,13 @@ func (nc *NetConf) dumpVlanIDSet() (*VlanIDSet, error) {
 	return vis, nil
 }
 
-// return vidsets from all bridge nads
+// IsLocalOnlyNad tells whether the NAD is annotated to keep its VIDs off the uplinks, its VMs only reach each other on
+// the same node through the bridge
+func IsLocalOnlyNad(nad *nadv1.NetworkAttachmentDefinition) bool {
+	return nad.Annotations[KeyUplink] == "false"
+}
+
+// return vidsets from all bridge nads, except the local-only ones
 func NewVlanIDSetFromNadList(nads []*nadv1.NetworkAttachmentDefinition) (*VlanIDSet, error) {
 	vis := NewVlanIDSet()
 	if len(nads) == 0 {
@@ -434,7 +537,7 @@ func NewVlanIDSetFromNadList(nads []*nadv1.NetworkAttachmentDefinition) (*VlanID
 	}
 
 	for _, nad := range nads {
-		if nad.DeletionTimestamp != nil {
+		if nad.DeletionTimestamp != nil || IsLocalOnlyNad(nad) {
 			continue
 		}
 		nc, err := DecodeNadConfigToNetConf(nad)
@@ -457,6 +560,38 @@ func NewVlanIDSetFromNadList(nads []*nadv1.NetworkAttachmentDefinition) (*VlanID
 	return vis, nil
 }
 
+// IsQinQ returns true if the frames of the NAD are double tagged with the S-VLAN OuterVlan over the C-VLAN Vlan
+func (nc *NetConf) IsQinQ() bool {
+	return nc.OuterVlan != 0
+}
+
+// QinQVlanIDSets returns the C-VLANs of the QinQ bridge NADs by their S-VLAN, the QinQ bridges of a cluster network are
+// built from them
+func QinQVlanIDSets(nads []*nadv1.NetworkAttachmentDefinition) (map[uint16]*VlanIDSet, error) {
+	sets := make(map[uint16]*VlanIDSet)
+	for _, nad := range nads {
+		if nad.DeletionTimestamp != nil {
+			continue
+		}
+		nc, err := DecodeNadConfigToNetConf(nad)
+		if err != nil {
+			return nil, err
+		}
+		if !nc.IsBridgeCNI() || !nc.IsQinQ() {
+			continue
+		}
+		svid := uint16(nc.OuterVlan) //nolint:gosec
+		if sets[svid] == nil {
+			sets[svid] = NewVlanIDSet()
+		}
+		if err := sets[svid].SetVID(nc.Vlan); err != nil {
+			return nil, err
+		}
+	}
+
+	return sets, nil
+}
+
 // if VlanTrunk is configured
 func (nc *NetConf) IsVlanTrunkMode() bool {
 	return len(nc.VlanTrunk) > 0
@@ -479,8 +614,10 @@ func (nc *NetConf) IsL2VlanTrunkNetwork() bool {
 	return nc.Vlan == 0 && len(nc.VlanTrunk) > 0
 }
 
+// IsBridgeCNI returns true if the NAD is attached to a cluster network by the bridge CNI, or by the macvlan or ipvlan
+// CNI in place of it on a bridge-less cluster network, or by the OVS CNI on an OVS cluster network
 func (nc *NetConf) IsBridgeCNI() bool {
-	return nc.Type == CNITypeBridge || nc.Type == CNITypeDefaultEmpty
+	return nc.Type == CNITypeBridge || nc.Type == CNITypeDefaultEmpty || nc.Bridgeless || nc.OVS
 }
 
 func (nc *NetConf) IsKubeOVNCNI() bool {
@@ -495,10 +632,10 @@ func (nc *NetConf) GetVlanID() int {
 }
 
 func (nc *NetConf) GetNetworkType() (NetworkType, error) {
-	switch nc.Type {
-	case CNITypeKubeOVN:
+	switch {
+	case nc.IsKubeOVNCNI():
 		return OverlayNetwork, nil
-	case CNITypeBridge, CNITypeDefaultEmpty:
+	case nc.IsBridgeCNI():
 		switch {
 		case nc.Vlan != 0:
 			return L2VlanNetwork, nil
@@ -615,6 +752,29 @@ func DecodeNadConfigToNetConf(nad *nadv1.NetworkAttachmentDefinition) (*NetConf,
 	if err := json.Unmarshal([]byte(nad.Spec.Config), conf); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal nad %v/%v config %s %w", nad.Namespace, nad.Name, nad.Spec.Config, err)
 	}
+	if cnName, vid, ok := ParsePerVIDBridgeName(conf.BrName); ok && conf.Vlan == 0 && len(conf.VlanTrunk) == 0 {
+		conf.BrName, conf.Vlan, conf.PerVIDBridge = GenerateBridgeName(cnName), int(vid), true
+	}
+	if cnName, _, ok := ParseQinQBridgeName(conf.BrName); ok {
+		conf.BrName, conf.QinQBridge = GenerateBridgeName(cnName), true
+	}
+	if cnName, vid, ok := ParseBridgelessMaster(conf.Master); ok && (conf.Type == CNITypeMacvlan || conf.Type == CNITypeIpvlan) {
+		conf.Bridgeless = true
+		// the bridge and the VID are left to a bridge NAD turned bridge-less
+		if conf.BrName == "" {
+			conf.BrName = GenerateBridgeName(cnName)
+		}
+		if conf.Vlan == 0 && len(conf.VlanTrunk) == 0 {
+			conf.Vlan = int(vid)
+		}
+	}
+
+	if _, err := GetClusterNetworkFromBridgeName(conf.BrName); err == nil && conf.Type == CNITypeOVS {
+		conf.OVS = true
+		if len(conf.VlanTrunk) == 0 {
+			conf.VlanTrunk = conf.Trunk
+		}
+	}
 
 	return conf, nil
 }
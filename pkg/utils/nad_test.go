@@ -2,6 +2,7 @@ package utils
 
 import (
 	"testing"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -149,3 +150,230 @@ func TestL2NetConf(t *testing.T) {
 		})
 	}
 }
+
+func TestNewVlanIDSetFromNadListSkipsLocalOnly(t *testing.T) {
+	nads := []*nadv1.NetworkAttachmentDefinition{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "net1", Namespace: testNamespace},
+			Spec:       nadv1.NetworkAttachmentDefinitionSpec{Config: testNadConfigVlan300},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "net2",
+				Namespace:   testNamespace,
+				Annotations: map[string]string{KeyUplink: "false"},
+			},
+			Spec: nadv1.NetworkAttachmentDefinitionSpec{
+				Config: `{"cniVersion":"0.3.1","name":"net2","type":"bridge","bridge":"test-cn-br","vlan":400}`,
+			},
+		},
+	}
+
+	vis, err := NewVlanIDSetFromNadList(nads)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{300}, vis.VIDs())
+}
+
+func TestNewLayer3NetworkConfIPFamily(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      string
+		returnErr bool
+		family    IPFamily
+	}{
+		{
+			name:   "IPv4 by default",
+			conf:   `{"mode":"manual","cidr":"192.168.1.0/24","gateway":"192.168.1.1"}`,
+			family: IPv4,
+		},
+		{
+			name:   "IPv6",
+			conf:   `{"mode":"manual","ipFamily":"ipv6","cidr":"2001:db8::/64","gateway":"fe80::1"}`,
+			family: IPv6,
+		},
+		{
+			name:      "IPv6 with IPv4 gateway",
+			conf:      `{"mode":"manual","ipFamily":"ipv6","cidr":"2001:db8::/64","gateway":"192.168.1.1"}`,
+			returnErr: true,
+		},
+		{
+			name:      "IPv4 with IPv6 CIDR",
+			conf:      `{"mode":"manual","cidr":"2001:db8::/64","gateway":"192.168.1.1"}`,
+			returnErr: true,
+		},
+		{
+			name:   "dual stack",
+			conf:   `{"mode":"manual","ipFamily":"dual","cidr":"192.168.1.0/24","gateway":"192.168.1.1","ipv6CIDR":"2001:db8::/64","ipv6Gateway":"2001:db8::1"}`,
+			family: DualStack,
+		},
+		{
+			name:      "dual stack without IPv6 CIDR",
+			conf:      `{"mode":"manual","ipFamily":"dual","cidr":"192.168.1.0/24","gateway":"192.168.1.1"}`,
+			returnErr: true,
+		},
+		{
+			name:   "auto mode needs no CIDR",
+			conf:   `{"mode":"auto","ipFamily":"dual"}`,
+			family: DualStack,
+		},
+		{
+			name:      "unknown family",
+			conf:      `{"mode":"auto","ipFamily":"ipx"}`,
+			returnErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			conf, err := NewLayer3NetworkConf(tc.conf)
+			if tc.returnErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.family, conf.GetIPFamily())
+		})
+	}
+}
+
+func TestDecodeBridgePerVIDNetConf(t *testing.T) {
+	nad := &nadv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "net1", Namespace: testNamespace},
+		Spec: nadv1.NetworkAttachmentDefinitionSpec{
+			Config: `{"cniVersion":"0.3.1","name":"net1","type":"bridge","bridge":"test-cn-300","vlan":0}`,
+		},
+	}
+
+	// the bridge per VID is decoded as the VID on the bridge of the cluster network
+	conf, err := DecodeNadConfigToNetConf(nad)
+	assert.NoError(t, err)
+	assert.True(t, conf.PerVIDBridge)
+	assert.Equal(t, "test-cn-br", conf.BrName)
+	assert.Equal(t, 300, conf.Vlan)
+
+	nad.Spec.Config = testNadConfigVlan300
+	conf, err = DecodeNadConfigToNetConf(nad)
+	assert.NoError(t, err)
+	assert.False(t, conf.PerVIDBridge)
+}
+
+func TestLayer3NetworkConfDHCPProbe(t *testing.T) {
+	conf, err := NewLayer3NetworkConf(`{"mode":"auto","cidr":"192.168.1.0/24","gateway":"192.168.1.1",` +
+		`"dhcpProbe":{"serverIP":"192.168.1.2","latencyMs":12,"time":"2026-10-17T08:00:00Z"}}`)
+	assert.NoError(t, err)
+	assert.True(t, conf.DHCPProbe.Succeeded())
+	assert.Equal(t, "192.168.1.2", conf.DHCPProbe.ServerIP)
+	assert.Equal(t, time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC), conf.DHCPProbe.ProbedAt())
+
+	conf, err = NewLayer3NetworkConf(`{"mode":"auto","connectivity":"dhcp-failed","dhcpProbe":{"error":"timeout"}}`)
+	assert.NoError(t, err)
+	assert.False(t, conf.DHCPProbe.Succeeded())
+	assert.True(t, conf.DHCPProbe.ProbedAt().IsZero())
+
+	// the probe is kept when the route is outdated
+	outdated, err := OutdateLayer3NetworkConfPerMode(`{"mode":"auto","dhcpProbe":{"error":"timeout"}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"mode":"auto","outdated":true,"dhcpProbe":{"error":"timeout"}}`, outdated)
+}
+
+func TestDecodeQinQNetConf(t *testing.T) {
+	newNad := func(name, config string) *nadv1.NetworkAttachmentDefinition {
+		return &nadv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec:       nadv1.NetworkAttachmentDefinitionSpec{Config: config},
+		}
+	}
+	qinq := newNad("qinq", `{"cniVersion":"0.3.1","name":"qinq","type":"bridge","bridge":"test-cn-q100","vlan":200,"outerVlan":100}`)
+
+	// the QinQ bridge is decoded as the bridge of the cluster network
+	conf, err := DecodeNadConfigToNetConf(qinq)
+	assert.NoError(t, err)
+	assert.True(t, conf.QinQBridge)
+	assert.True(t, conf.IsQinQ())
+	assert.Equal(t, "test-cn-br", conf.BrName)
+	assert.Equal(t, 200, conf.Vlan)
+
+	// the C-VLAN is carried in the S-VLAN rather than on the uplink of the cluster network
+	nads := []*nadv1.NetworkAttachmentDefinition{
+		qinq,
+		newNad("qinq2", `{"cniVersion":"0.3.1","name":"qinq2","type":"bridge","bridge":"test-cn-q100","vlan":201,"outerVlan":100}`),
+		newNad("vlan300", testNadConfigVlan300),
+	}
+	vis, err := NewVlanIDSetFromNadList(nads)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{300}, vis.VIDs())
+
+	sets, err := QinQVlanIDSets(nads)
+	assert.NoError(t, err)
+	assert.Len(t, sets, 1)
+	assert.Equal(t, []uint16{200, 201}, sets[100].VIDs())
+}
+
+func TestDecodeBridgelessNetConf(t *testing.T) {
+	newNad := func(name, config string) *nadv1.NetworkAttachmentDefinition {
+		return &nadv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec:       nadv1.NetworkAttachmentDefinitionSpec{Config: config},
+		}
+	}
+	tagged := newNad("macvlan", `{"cniVersion":"0.3.1","name":"macvlan","type":"macvlan","master":"test-cn.300","mode":"bridge"}`)
+	untagged := newNad("ipvlan", `{"cniVersion":"0.3.1","name":"ipvlan","type":"ipvlan","master":"test-cn-bo","mode":"l2"}`)
+
+	// the master is decoded as the bridge of the cluster network and the VID
+	conf, err := DecodeNadConfigToNetConf(tagged)
+	assert.NoError(t, err)
+	assert.True(t, conf.Bridgeless)
+	assert.True(t, conf.IsBridgeCNI())
+	assert.Equal(t, "test-cn-br", conf.BrName)
+	assert.Equal(t, 300, conf.Vlan)
+	networkType, err := conf.GetNetworkType()
+	assert.NoError(t, err)
+	assert.Equal(t, L2VlanNetwork, networkType)
+
+	conf, err = DecodeNadConfigToNetConf(untagged)
+	assert.NoError(t, err)
+	assert.True(t, conf.Bridgeless)
+	assert.True(t, conf.IsUntaggedNetwork())
+
+	// a macvlan NAD on any other master is not attached to a cluster network
+	conf, err = DecodeNadConfigToNetConf(newNad("eth0", `{"cniVersion":"0.3.1","name":"eth0","type":"macvlan","master":"eth0"}`))
+	assert.NoError(t, err)
+	assert.False(t, conf.IsBridgeCNI())
+
+	vis, err := NewVlanIDSetFromNadList([]*nadv1.NetworkAttachmentDefinition{tagged, untagged})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{300}, vis.VIDs())
+}
+
+func TestDecodeOVSNetConf(t *testing.T) {
+	newNad := func(name, config string) *nadv1.NetworkAttachmentDefinition {
+		return &nadv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec:       nadv1.NetworkAttachmentDefinitionSpec{Config: config},
+		}
+	}
+	access := newNad("ovs", `{"cniVersion":"0.3.1","name":"ovs","type":"ovs","bridge":"test-cn-br","vlan":300}`)
+	trunk := newNad("ovs-trunk", `{"cniVersion":"0.3.1","name":"ovs-trunk","type":"ovs","bridge":"test-cn-br",`+
+		`"trunk":[{"id":100},{"minID":200,"maxID":201}]}`)
+
+	conf, err := DecodeNadConfigToNetConf(access)
+	assert.NoError(t, err)
+	assert.True(t, conf.OVS)
+	assert.True(t, conf.IsBridgeCNI())
+	assert.Equal(t, 300, conf.Vlan)
+
+	// the trunk is decoded as the vlanTrunk of a bridge NAD
+	conf, err = DecodeNadConfigToNetConf(trunk)
+	assert.NoError(t, err)
+	assert.True(t, conf.OVS)
+	assert.True(t, conf.IsL2VlanTrunkNetwork())
+
+	// an OVS NAD on any other bridge is not attached to a cluster network
+	conf, err = DecodeNadConfigToNetConf(newNad("br-int", `{"cniVersion":"0.3.1","name":"br-int","type":"ovs","bridge":"br-int"}`))
+	assert.NoError(t, err)
+	assert.False(t, conf.IsBridgeCNI())
+
+	vis, err := NewVlanIDSetFromNadList([]*nadv1.NetworkAttachmentDefinition{access, trunk})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{100, 200, 201, 300}, vis.VIDs())
+}
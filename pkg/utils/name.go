@@ -6,13 +6,17 @@ import (
 	"strings"
 )
 
-const maxLengthOfName = 63
+const (
+	maxLengthOfName = 63
+	// the length of the crc32 checksum suffix Name appends
+	nameSuffixLength = 8
+)
 
 // Name function joints prefix with all other strings and crc32 checksum
 func Name(prefix string, s ...string) string {
 	name := prefix + strings.Join(s, "-")
 	digest := crc32.ChecksumIEEE([]byte(name))
-	suffix := fmt.Sprintf("%08x", digest)
+	suffix := fmt.Sprintf("%0*x", nameSuffixLength, digest)
 	// The name contains no more than 63 characters
 	maxLength := maxLengthOfName - 1 - len(suffix)
 	if len(name) > maxLength {
@@ -21,3 +25,23 @@ func Name(prefix string, s ...string) string {
 
 	return name + "-" + suffix
 }
+
+// NamePrefix returns the name generated by Name without its checksum suffix, i.e. with the trailing "-", e.g. as the
+// generateName of an object whose name is taken
+func NamePrefix(name string) string {
+	if len(name) < nameSuffixLength {
+		return name
+	}
+	return name[:len(name)-nameSuffixLength]
+}
+
+// LabelValue returns the value as it is if it's a valid label value, or truncates it with the crc32 checksum, e.g. a
+// node name longer than 63 characters. The labels are only used to select the objects, and the full value has to be
+// kept in the fields of the objects for the reverse lookup.
+func LabelValue(value string) string {
+	if len(value) <= maxLengthOfName {
+		return value
+	}
+
+	return Name("", value)
+}
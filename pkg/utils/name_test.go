@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamePrefix(t *testing.T) {
+	name := Name("", "cn1", "node1")
+	assert.Equal(t, "cn1-node1-", NamePrefix(name))
+
+	long := Name("", strings.Repeat("a", 80))
+	assert.Len(t, long, maxLengthOfName)
+	assert.True(t, strings.HasPrefix(long, NamePrefix(long)))
+	assert.True(t, strings.HasSuffix(NamePrefix(long), "-"))
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SupportedCNIVersions are the CNI spec versions of the NAD configs the bridge and kube-ovn plugins accept
+var SupportedCNIVersions = []string{"0.1.0", "0.2.0", "0.3.0", "0.3.1", "0.4.0", "1.0.0", "1.1.0"}
+
+// criticalNetConfFields decide the datapath of the network, a misspelled one is ignored by the CNI plugin and
+// results in a different network silently, e.g. `vlanId` instead of `vlan` makes the network untagged
+var criticalNetConfFields = []string{
+	"cniVersion", "type", "bridge", "vlan", "vlanTrunk", "mtu", "promiscMode", "ipam", "provider",
+}
+
+// knownNetConfFields are the fields of the CNI spec, the bridge plugin and the kube-ovn plugin, the other fields are
+// plugin specific and accepted unless they look like a misspelled critical field
+var knownNetConfFields = []string{
+	// CNI spec
+	"cniVersion", "name", "type", "capabilities", "ipam", "dns", "args", "runtimeConfig", "prevResult",
+	// bridge plugin
+	"bridge", "isGateway", "isDefaultGateway", "forceAddress", "ipMasq", "ipMasqBackend", "mtu", "hairpinMode",
+	"promiscMode", "vlan", "vlanTrunk", "preserveDefaultVlan", "macspoofchk", "enabledad", "disableContainerInterface",
+	"portIsolation", "mac",
+	// kube-ovn plugin
+	"provider", "server_socket",
+}
+
+// maxTypoDistance is the maximum edit distance between an unknown field and a critical field for the unknown field
+// to be taken as a typo
+const maxTypoDistance = 2
+
+// ValidateNetConfFields checks the NAD config of the bridge and kube-ovn plugins strictly, i.e. the CNI version is
+// supported, no unknown field looks like a misspelled critical field and the VLAN trunk entries have no unknown field
+func ValidateNetConfFields(config string) error {
+	if config == "" {
+		return nil
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(config), &fields); err != nil {
+		return fmt.Errorf("invalid config %s, error: %w", config, err)
+	}
+
+	if raw, ok := fields["cniVersion"]; ok {
+		version := ""
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return fmt.Errorf("invalid cniVersion %s, error: %w", string(raw), err)
+		}
+		if version != "" && !slices.Contains(SupportedCNIVersions, version) {
+			return fmt.Errorf("unsupported cniVersion %s, supported versions are %v", version, SupportedCNIVersions)
+		}
+	}
+
+	// the fields of the other plugins are unknown here
+	if raw, ok := fields["type"]; ok {
+		cniType := ""
+		if err := json.Unmarshal(raw, &cniType); err != nil {
+			return fmt.Errorf("invalid type %s, error: %w", string(raw), err)
+		}
+		if cniType != CNITypeBridge && cniType != CNITypeKubeOVN && cniType != CNITypeDefaultEmpty {
+			return nil
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// the plugins decode the fields case-insensitively
+		if slices.ContainsFunc(knownNetConfFields, func(known string) bool { return strings.EqualFold(known, name) }) {
+			continue
+		}
+		if critical := misspelledCriticalField(name); critical != "" {
+			return fmt.Errorf("unknown field %q in config, did you mean %q", name, critical)
+		}
+	}
+
+	if raw, ok := fields["vlanTrunk"]; ok {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		var trunk []*VlanTrunk
+		if err := decoder.Decode(&trunk); err != nil {
+			return fmt.Errorf("invalid vlanTrunk %s, error: %w", string(raw), err)
+		}
+	}
+
+	return nil
+}
+
+func misspelledCriticalField(name string) string {
+	for _, critical := range criticalNetConfFields {
+		if editDistance(strings.ToLower(name), strings.ToLower(critical)) <= maxTypoDistance {
+			return critical
+		}
+	}
+	return ""
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateNetConfFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		errKey string
+	}{
+		{
+			name:   "empty config",
+			config: "",
+		},
+		{
+			name:   "valid bridge config",
+			config: `{"cniVersion":"0.3.1","name":"nad1","type":"bridge","bridge":"cn1-br","promiscMode":true,"vlan":300,"ipam":{}}`,
+		},
+		{
+			name:   "plugin specific fields are accepted",
+			config: `{"cniVersion":"0.3.1","type":"bridge","bridge":"cn1-br","vlan":300,"customOption":"x"}`,
+		},
+		{
+			name:   "valid vlan trunk",
+			config: `{"cniVersion":"0.3.1","type":"bridge","bridge":"cn1-br","vlanTrunk":[{"minID":100,"maxID":200},{"id":300}]}`,
+		},
+		{
+			name:   "the fields of the other plugins are not checked",
+			config: `{"cniVersion":"0.3.1","type":"macvlan","master":"eth1","mode":"bridge"}`,
+		},
+		{
+			name:   "misspelled bridge",
+			config: `{"cniVersion":"0.3.1","type":"bridge","brdige":"cn1-br","vlan":300}`,
+			errKey: `did you mean "bridge"`,
+		},
+		{
+			name:   "misspelled vlan",
+			config: `{"cniVersion":"0.3.1","type":"bridge","bridge":"cn1-br","vlanId":300}`,
+			errKey: `did you mean "vlan"`,
+		},
+		{
+			name:   "unknown field of vlan trunk",
+			config: `{"cniVersion":"0.3.1","type":"bridge","bridge":"cn1-br","vlanTrunk":[{"min":100,"maxID":200}]}`,
+			errKey: "invalid vlanTrunk",
+		},
+		{
+			name:   "unsupported cni version",
+			config: `{"cniVersion":"2.0.0","type":"bridge","bridge":"cn1-br","vlan":300}`,
+			errKey: "unsupported cniVersion",
+		},
+		{
+			name:   "invalid json",
+			config: `{"type":`,
+			errKey: "invalid config",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateNetConfFields(tc.config)
+			if tc.errKey == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.errKey)
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("vlan", "vlan"))
+	assert.Equal(t, 2, editDistance("brdige", "bridge"))
+	assert.Equal(t, 2, editDistance("vlanid", "vlan"))
+	assert.Equal(t, 4, editDistance("", "ipam"))
+}
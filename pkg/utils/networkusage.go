@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+)
+
+// PodByNetworkIndex indexes the pods by the <namespace>/<name> of the NADs they are attached to
+const PodByNetworkIndex = "pod.harvesterhci.io/pod-by-network"
+
+// AttachedNetworks returns the <namespace>/<name> of the NADs the pod is attached to according to the multus network
+// status, the default network is skipped. The pods which have finished are attached to no network.
+func AttachedNetworks(pod *corev1.Pod) ([]string, error) {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return nil, nil
+	}
+	value := pod.Annotations[nadv1.NetworkStatusAnnot]
+	if value == "" {
+		return nil, nil
+	}
+
+	var statuses []nadv1.NetworkStatus
+	if err := json.Unmarshal([]byte(value), &statuses); err != nil {
+		return nil, fmt.Errorf("invalid network status of pod %s/%s, error: %w", pod.Namespace, pod.Name, err)
+	}
+	networks := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		if status.Default || status.Name == "" {
+			continue
+		}
+		name := status.Name
+		if !strings.Contains(name, "/") {
+			name = pod.Namespace + "/" + name
+		}
+		networks = append(networks, name)
+	}
+
+	return networks, nil
+}
+
+// VIDsOfNad returns the VLAN IDs of the bridge NAD required on the uplinks in ascending order, the untagged network and
+// the local-only network have none
+func VIDsOfNad(nad *nadv1.NetworkAttachmentDefinition) ([]uint16, error) {
+	if IsLocalOnlyNad(nad) {
+		return nil, nil
+	}
+	nc, err := DecodeNadConfigToNetConf(nad)
+	if err != nil {
+		return nil, err
+	}
+	if !nc.IsBridgeCNI() {
+		return nil, nil
+	}
+	vis, err := nc.dumpVlanIDSet()
+	if err != nil {
+		return nil, err
+	}
+
+	var vids []uint16
+	if err := vis.WalkVIDs(nad.Name, func(vid uint16) error {
+		vids = append(vids, vid)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return vids, nil
+}
+
+// GetVlanIDSetOfClusterNetwork returns the VLAN IDs of the NADs of the cluster network together with the ones of the
+// NADs in use, so that the VLAN IDs of a NAD being deleted, or the previous VLAN IDs of a NAD being changed, are kept on
+// the uplinks until the last consumer using them is gone
+func GetVlanIDSetOfClusterNetwork(cn *networkv1.ClusterNetwork, nadCache ctlcniv1.NetworkAttachmentDefinitionCache) (*VlanIDSet, error) {
+	nads, err := NewNadGetter(nadCache).ListNadsOnClusterNetwork(cn.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return VlanIDSetOfClusterNetwork(cn, nads)
+}
+
+// VlanIDSetOfClusterNetwork is GetVlanIDSetOfClusterNetwork with the NADs of the cluster network listed by the caller,
+// e.g. from the manifests rendered offline
+func VlanIDSetOfClusterNetwork(cn *networkv1.ClusterNetwork, nads []*nadv1.NetworkAttachmentDefinition) (*VlanIDSet, error) {
+	vis, err := NewVlanIDSetFromNadList(nads)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster network %v vlan id set, error: %w", cn.Name, err)
+	}
+
+	for _, usage := range cn.Status.InUse {
+		for _, vid := range usage.VIDs {
+			if err := vis.SetUint16VID(vid); err != nil {
+				return nil, fmt.Errorf("failed to keep vid %d of nad %s in use, error: %w", vid, usage.NAD, err)
+			}
+		}
+		for _, vid := range usage.PreviousVIDs {
+			if err := vis.SetUint16VID(vid); err != nil {
+				return nil, fmt.Errorf("failed to keep previous vid %d of nad %s in use, error: %w", vid, usage.NAD, err)
+			}
+		}
+	}
+
+	return vis, nil
+}
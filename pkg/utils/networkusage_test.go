@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"testing"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testNetworkStatus = `[{"name":"k8s-pod-network","interface":"eth0","default":true},` +
+	`{"name":"default/net1","interface":"pod1234"},{"name":"net2","interface":"pod5678"}]`
+
+func TestAttachedNetworks(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "virt-launcher-vm1",
+			Namespace:   "vms",
+			Annotations: map[string]string{nadv1.NetworkStatusAnnot: testNetworkStatus},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	networks, err := AttachedNetworks(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"default/net1", "vms/net2"}, networks)
+
+	pod.Status.Phase = corev1.PodSucceeded
+	networks, err = AttachedNetworks(pod)
+	assert.NoError(t, err)
+	assert.Empty(t, networks)
+
+	pod.Status.Phase = corev1.PodRunning
+	pod.Annotations[nadv1.NetworkStatusAnnot] = "net1"
+	_, err = AttachedNetworks(pod)
+	assert.Error(t, err)
+}
+
+func TestVIDsOfNad(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		annotations map[string]string
+		expected    []uint16
+	}{
+		{
+			name:     "access mode",
+			config:   testNadConfigVlan300,
+			expected: []uint16{300},
+		},
+		{
+			name:     "untagged",
+			config:   testNadConfigVlanUntag,
+			expected: nil,
+		},
+		{
+			name:     "trunk mode",
+			config:   `{"cniVersion":"0.3.1","name":"net1","type":"bridge","bridge":"test-cn-br","vlanTrunk":[{"id":100},{"minID":300,"maxID":302}]}`,
+			expected: []uint16{100, 300, 301, 302},
+		},
+		{
+			name:        "local-only",
+			config:      testNadConfigVlan300,
+			annotations: map[string]string{KeyUplink: "false"},
+			expected:    nil,
+		},
+		{
+			name:        "explicitly on the uplinks",
+			config:      testNadConfigVlan300,
+			annotations: map[string]string{KeyUplink: "true"},
+			expected:    []uint16{300},
+		},
+		{
+			name:     "not a bridge NAD",
+			config:   testNadConfigOVN,
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nad := &nadv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "net1", Namespace: "default", Annotations: tc.annotations},
+				Spec:       nadv1.NetworkAttachmentDefinitionSpec{Config: tc.config},
+			}
+			vids, err := VIDsOfNad(nad)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, vids)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+)
+
+// NICClaimsOnNode returns the NICs claimed on the node, NIC name -> claim
+func NICClaimsOnNode(cache ctlnetworkv1.NetworkInterfaceClaimCache, node string) (map[string]*networkv1.NetworkInterfaceClaim, error) {
+	claims, err := cache.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network interface claims, error: %w", err)
+	}
+
+	claimed := make(map[string]*networkv1.NetworkInterfaceClaim)
+	for _, claim := range claims {
+		if claim.Spec.Node != node || claim.DeletionTimestamp != nil {
+			continue
+		}
+		for _, nic := range claim.Spec.NICs {
+			claimed[nic] = claim
+		}
+	}
+
+	return claimed, nil
+}
+
+// CheckNICsNotClaimed returns an error listing the NICs claimed on the node
+func CheckNICsNotClaimed(cache ctlnetworkv1.NetworkInterfaceClaimCache, node string, nics []string) error {
+	claimed, err := NICClaimsOnNode(cache, node)
+	if err != nil {
+		return err
+	}
+
+	conflicts := make([]string, 0)
+	for _, nic := range nics {
+		if claim, ok := claimed[nic]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("NIC %s is claimed by %s with claim %s", nic, claim.Spec.Owner, claim.Name))
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("%s on node %s", strings.Join(conflicts, "; "), node)
+	}
+
+	return nil
+}
@@ -1,5 +1,13 @@
 package utils
 
+import corev1 "k8s.io/api/core/v1"
+
 const (
 	KeyUnderlayIntf = "ovn.kubernetes.io/tunnel_interface"
+
+	// NodeConditionVlanReady is the node condition mirroring whether all VLAN networks of the node are ready
+	NodeConditionVlanReady corev1.NodeConditionType = "NetworkHarvesterVlanReady"
+	// NodeConditionKernelReady is the node condition telling whether the kernel of the node has the modules and options
+	// the agent relies on
+	NodeConditionKernelReady corev1.NodeConditionType = "NetworkHarvesterKernelReady"
 )
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// NodeState is the desired network of a node cached on the node, so that the agent keeps enforcing it while the API
+// server is unreachable, e.g. on an edge node behind a flaky WAN link
+type NodeState struct {
+	Node            string                `json:"node"`
+	SavedAt         time.Time             `json:"savedAt"`
+	ClusterNetworks []ClusterNetworkState `json:"clusterNetworks"`
+}
+
+// ClusterNetworkState is the vlanconfig taking effect on the node and the VIDs of the cluster network
+type ClusterNetworkState struct {
+	Name       string                `json:"name"`
+	VlanConfig *networkv1.VlanConfig `json:"vlanConfig"`
+	VIDs       []uint16              `json:"vids,omitempty"`
+	// Datapath is the datapath of the cluster network, empty means VLAN filtering
+	Datapath networkv1.Datapath `json:"datapath,omitempty"`
+}
+
+// NewClusterNetworkState strips the vlanconfig down to its name and spec, which are all the agent needs to set up
+// the network
+func NewClusterNetworkState(vc *networkv1.VlanConfig, vids *VlanIDSet) ClusterNetworkState {
+	return ClusterNetworkState{
+		Name: vc.Spec.ClusterNetwork,
+		VlanConfig: &networkv1.VlanConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: vc.Name},
+			Spec:       *vc.Spec.DeepCopy(),
+		},
+		VIDs: vids.VIDs(),
+	}
+}
+
+// VlanIDSet returns the VIDs of the cluster network as a VlanIDSet
+func (s *ClusterNetworkState) VlanIDSet() (*VlanIDSet, error) {
+	vis := NewVlanIDSet()
+	for _, vid := range s.VIDs {
+		if err := vis.SetUint16VID(vid); err != nil {
+			return nil, err
+		}
+	}
+
+	return vis, nil
+}
+
+// SaveNodeState writes the state into the file if the cluster networks differ from the ones in the file and returns
+// whether it's written. An invalid file is overwritten. The file is replaced atomically, so that a crash never leaves
+// a partial state behind.
+func SaveNodeState(path string, state *NodeState) (bool, error) {
+	if previous, err := LoadNodeState(path); err == nil && previous != nil {
+		old, _ := json.Marshal(previous.ClusterNetworks)
+		cur, _ := json.Marshal(state.ClusterNetworks)
+		if bytes.Equal(old, cur) {
+			return false, nil
+		}
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return false, err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return false, err
+	}
+
+	return true, os.Rename(tmp, path)
+}
+
+// LoadNodeState reads the state from the file, it returns nil if the file doesn't exist
+func LoadNodeState(path string) (*NodeState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	state := &NodeState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid node state in %s, error: %w", path, err)
+	}
+
+	return state, nil
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestNodeState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent", "state.json")
+
+	state, err := LoadNodeState(path)
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+
+	vids := NewVlanIDSet()
+	assert.NoError(t, vids.SetUint16VID(100))
+	assert.NoError(t, vids.SetUint16VID(200))
+	vc := &networkv1.VlanConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc1", ResourceVersion: "10", Labels: map[string]string{"a": "b"}},
+		Spec: networkv1.VlanConfigSpec{
+			ClusterNetwork: "cn1",
+			Uplink:         networkv1.Uplink{NICs: []string{"eth1"}},
+		},
+	}
+	saved := &NodeState{
+		Node:            "node1",
+		SavedAt:         time.Unix(1000, 0).UTC(),
+		ClusterNetworks: []ClusterNetworkState{NewClusterNetworkState(vc, vids)},
+	}
+
+	written, err := SaveNodeState(path, saved)
+	assert.NoError(t, err)
+	assert.True(t, written)
+
+	state, err = LoadNodeState(path)
+	assert.NoError(t, err)
+	assert.Equal(t, saved, state)
+	assert.Equal(t, "vc1", state.ClusterNetworks[0].VlanConfig.Name)
+	assert.Empty(t, state.ClusterNetworks[0].VlanConfig.ResourceVersion, "only the name and spec are cached")
+	loaded, err := state.ClusterNetworks[0].VlanIDSet()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{100, 200}, loaded.VIDs())
+
+	// the file is kept if the cluster networks are unchanged
+	saved.SavedAt = time.Unix(2000, 0).UTC()
+	written, err = SaveNodeState(path, saved)
+	assert.NoError(t, err)
+	assert.False(t, written)
+
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+	_, err = LoadNodeState(path)
+	assert.Error(t, err)
+	written, err = SaveNodeState(path, saved)
+	assert.NoError(t, err)
+	assert.True(t, written, "the invalid file is overwritten")
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// EffectiveOwnership returns the ownership of the vlanconfig, the fields it omits are inherited from the cluster
+// network. The cluster network is nil if it's not found.
+func EffectiveOwnership(cn *networkv1.ClusterNetwork, vc *networkv1.VlanConfig) networkv1.Ownership {
+	var ownership networkv1.Ownership
+	if cn != nil && cn.Spec.Ownership != nil {
+		ownership = *cn.Spec.Ownership
+	}
+	if vc != nil && vc.Spec.Ownership != nil {
+		if vc.Spec.Ownership.Owner != "" {
+			ownership.Owner = vc.Spec.Ownership.Owner
+		}
+		if vc.Spec.Ownership.TicketRef != "" {
+			ownership.TicketRef = vc.Spec.Ownership.TicketRef
+		}
+	}
+
+	return ownership
+}
+
+// SetOwnershipLabels sets the ownership labels and removes the ones of the fields which are empty, it tells whether
+// the labels are changed
+func SetOwnershipLabels(lbs map[string]string, ownership networkv1.Ownership) bool {
+	changed := false
+	for key, value := range map[string]string{KeyOwner: ownership.Owner, KeyTicketRef: ownership.TicketRef} {
+		current, ok := lbs[key]
+		switch {
+		case value == "" && ok:
+			delete(lbs, key)
+			changed = true
+		case value != "" && current != value:
+			lbs[key] = value
+			changed = true
+		}
+	}
+
+	return changed
+}
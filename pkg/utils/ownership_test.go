@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestEffectiveOwnership(t *testing.T) {
+	cn := &networkv1.ClusterNetwork{
+		Spec: networkv1.ClusterNetworkSpec{
+			Ownership: &networkv1.Ownership{Owner: "network-team", TicketRef: "NET-100"},
+		},
+	}
+	vc := &networkv1.VlanConfig{
+		Spec: networkv1.VlanConfigSpec{
+			Ownership: &networkv1.Ownership{TicketRef: "NET-200"},
+		},
+	}
+
+	assert.Equal(t, networkv1.Ownership{Owner: "network-team", TicketRef: "NET-200"}, EffectiveOwnership(cn, vc))
+	assert.Equal(t, networkv1.Ownership{TicketRef: "NET-200"}, EffectiveOwnership(nil, vc))
+	assert.Equal(t, networkv1.Ownership{}, EffectiveOwnership(nil, &networkv1.VlanConfig{}))
+}
+
+func TestSetOwnershipLabels(t *testing.T) {
+	lbs := map[string]string{KeyNodeLabel: "node1", KeyTicketRef: "NET-100"}
+
+	assert.True(t, SetOwnershipLabels(lbs, networkv1.Ownership{Owner: "network-team"}))
+	assert.Equal(t, map[string]string{KeyNodeLabel: "node1", KeyOwner: "network-team"}, lbs)
+	assert.False(t, SetOwnershipLabels(lbs, networkv1.Ownership{Owner: "network-team"}))
+}
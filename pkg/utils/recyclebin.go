@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+const (
+	// DeletedVlanConfigRetention is how long the snapshot of a deleted vlanconfig is kept
+	DeletedVlanConfigRetention = 24 * time.Hour
+	// MaxDeletedVlanConfigs is the maximum number of the snapshots kept per cluster network, the oldest are dropped
+	MaxDeletedVlanConfigs = 5
+)
+
+// DeletedVlanConfig is the snapshot of a deleted vlanconfig, the snapshots of a cluster network are kept in its
+// annotation KeyDeletedVlanConfigs in json format
+type DeletedVlanConfig struct {
+	Name      string                   `json:"name"`
+	DeletedAt metav1.Time              `json:"deletedAt"`
+	Spec      networkv1.VlanConfigSpec `json:"spec"`
+}
+
+// GetDeletedVlanConfigs returns the snapshots of the deleted vlanconfigs of the cluster network, the newest first
+func GetDeletedVlanConfigs(cn *networkv1.ClusterNetwork) ([]DeletedVlanConfig, error) {
+	value, ok := cn.Annotations[KeyDeletedVlanConfigs]
+	if !ok {
+		return nil, nil
+	}
+
+	snapshots := []DeletedVlanConfig{}
+	if err := json.Unmarshal([]byte(value), &snapshots); err != nil {
+		return nil, fmt.Errorf("invalid deleted vlanconfigs %s, error: %w", value, err)
+	}
+
+	return snapshots, nil
+}
+
+// RecordDeletedVlanConfig keeps the snapshot of the deleted vlanconfig in the annotation of the cluster network and
+// drops the expired snapshots. The uplink sections inherited from the cluster network are left empty as in a clone.
+// The snapshot replaces the previous one of the same name.
+func RecordDeletedVlanConfig(cn *networkv1.ClusterNetwork, vc *networkv1.VlanConfig, now time.Time) error {
+	snapshots, err := GetDeletedVlanConfigs(cn)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := metav1.NewTime(now)
+	if vc.DeletionTimestamp != nil {
+		deletedAt = *vc.DeletionTimestamp
+	}
+	snapshots = append(removeDeletedVlanConfig(snapshots, vc.Name), DeletedVlanConfig{
+		Name:      vc.Name,
+		DeletedAt: deletedAt,
+		Spec:      specWithoutInheritedUplink(vc),
+	})
+
+	return setDeletedVlanConfigs(cn, snapshots, now)
+}
+
+// TakeDeletedVlanConfig removes the snapshot of the name from the annotation of the cluster network and returns it,
+// it's nil if there is no such snapshot or it has expired
+func TakeDeletedVlanConfig(cn *networkv1.ClusterNetwork, name string, now time.Time) (*DeletedVlanConfig, error) {
+	snapshots, err := GetDeletedVlanConfigs(cn)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot *DeletedVlanConfig
+	for i := range snapshots {
+		if snapshots[i].Name == name && !isExpired(&snapshots[i], now) {
+			snapshot = snapshots[i].DeepCopy()
+		}
+	}
+
+	return snapshot, setDeletedVlanConfigs(cn, removeDeletedVlanConfig(snapshots, name), now)
+}
+
+// RestoredVlanConfig returns the vlanconfig to be created from the snapshot
+func RestoredVlanConfig(snapshot *DeletedVlanConfig) *networkv1.VlanConfig {
+	return &networkv1.VlanConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: snapshot.Name,
+			Labels: map[string]string{
+				KeyClusterNetworkLabel: snapshot.Spec.ClusterNetwork,
+			},
+		},
+		Spec: *snapshot.Spec.DeepCopy(),
+	}
+}
+
+func (d *DeletedVlanConfig) DeepCopy() *DeletedVlanConfig {
+	return &DeletedVlanConfig{
+		Name:      d.Name,
+		DeletedAt: *d.DeletedAt.DeepCopy(),
+		Spec:      *d.Spec.DeepCopy(),
+	}
+}
+
+func isExpired(snapshot *DeletedVlanConfig, now time.Time) bool {
+	return now.Sub(snapshot.DeletedAt.Time) > DeletedVlanConfigRetention
+}
+
+func removeDeletedVlanConfig(snapshots []DeletedVlanConfig, name string) []DeletedVlanConfig {
+	kept := make([]DeletedVlanConfig, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if snapshot.Name != name {
+			kept = append(kept, snapshot)
+		}
+	}
+	return kept
+}
+
+// setDeletedVlanConfigs drops the expired snapshots and the oldest ones beyond MaxDeletedVlanConfigs, and removes the
+// annotation if there is no snapshot left
+func setDeletedVlanConfigs(cn *networkv1.ClusterNetwork, snapshots []DeletedVlanConfig, now time.Time) error {
+	kept := make([]DeletedVlanConfig, 0, len(snapshots))
+	for i := range snapshots {
+		if !isExpired(&snapshots[i], now) {
+			kept = append(kept, snapshots[i])
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].DeletedAt.After(kept[j].DeletedAt.Time)
+	})
+	if len(kept) > MaxDeletedVlanConfigs {
+		kept = kept[:MaxDeletedVlanConfigs]
+	}
+
+	if len(kept) == 0 {
+		delete(cn.Annotations, KeyDeletedVlanConfigs)
+		return nil
+	}
+
+	value, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+	if cn.Annotations == nil {
+		cn.Annotations = make(map[string]string)
+	}
+	cn.Annotations[KeyDeletedVlanConfigs] = string(value)
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func deletedVlanConfig(name string, deletedAt time.Time) *networkv1.VlanConfig {
+	ts := metav1.NewTime(deletedAt)
+	return &networkv1.VlanConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			DeletionTimestamp: &ts,
+			Annotations:       map[string]string{KeyInheritedUplinkFields: UplinkFieldBondOptions},
+		},
+		Spec: networkv1.VlanConfigSpec{
+			ClusterNetwork: "cn1",
+			Uplink: networkv1.Uplink{
+				NICs:        []string{"eth1", "eth2"},
+				BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMoDeActiveBackup},
+			},
+		},
+	}
+}
+
+func snapshotNames(t *testing.T, cn *networkv1.ClusterNetwork) []string {
+	snapshots, err := GetDeletedVlanConfigs(cn)
+	assert.NoError(t, err)
+	names := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		names = append(names, snapshot.Name)
+	}
+	return names
+}
+
+func TestRecordDeletedVlanConfig(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	cn := &networkv1.ClusterNetwork{ObjectMeta: metav1.ObjectMeta{Name: "cn1"}}
+
+	assert.NoError(t, RecordDeletedVlanConfig(cn, deletedVlanConfig("vc1", now.Add(-2*time.Minute)), now))
+	assert.NoError(t, RecordDeletedVlanConfig(cn, deletedVlanConfig("vc2", now.Add(-time.Minute)), now))
+	assert.Equal(t, []string{"vc2", "vc1"}, snapshotNames(t, cn))
+
+	// the snapshot of the same name is replaced
+	assert.NoError(t, RecordDeletedVlanConfig(cn, deletedVlanConfig("vc1", now), now))
+	assert.Equal(t, []string{"vc1", "vc2"}, snapshotNames(t, cn))
+
+	// the inherited uplink sections are left empty
+	snapshots, err := GetDeletedVlanConfigs(cn)
+	assert.NoError(t, err)
+	assert.Nil(t, snapshots[0].Spec.Uplink.BondOptions)
+	assert.Equal(t, []string{"eth1", "eth2"}, snapshots[0].Spec.Uplink.NICs)
+
+	// the oldest snapshots are dropped beyond the maximum
+	for i := 0; i < MaxDeletedVlanConfigs; i++ {
+		assert.NoError(t, RecordDeletedVlanConfig(cn, deletedVlanConfig(fmt.Sprintf("vc%d", i+3), now), now))
+	}
+	assert.Len(t, snapshotNames(t, cn), MaxDeletedVlanConfigs)
+	assert.NotContains(t, snapshotNames(t, cn), "vc2")
+
+	// the expired snapshots are dropped
+	later := now.Add(DeletedVlanConfigRetention + time.Minute)
+	assert.NoError(t, RecordDeletedVlanConfig(cn, deletedVlanConfig("vc9", later), later))
+	assert.Equal(t, []string{"vc9"}, snapshotNames(t, cn))
+}
+
+func TestTakeDeletedVlanConfig(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	cn := &networkv1.ClusterNetwork{ObjectMeta: metav1.ObjectMeta{Name: "cn1"}}
+	assert.NoError(t, RecordDeletedVlanConfig(cn, deletedVlanConfig("vc1", now), now))
+
+	snapshot, err := TakeDeletedVlanConfig(cn, "vc2", now)
+	assert.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	snapshot, err = TakeDeletedVlanConfig(cn, "vc1", now)
+	assert.NoError(t, err)
+	assert.NotNil(t, snapshot)
+	assert.NotContains(t, cn.Annotations, KeyDeletedVlanConfigs)
+
+	vc := RestoredVlanConfig(snapshot)
+	assert.Equal(t, "vc1", vc.Name)
+	assert.Equal(t, "cn1", vc.Labels[KeyClusterNetworkLabel])
+	assert.Equal(t, []string{"eth1", "eth2"}, vc.Spec.Uplink.NICs)
+
+	// an expired snapshot can't be restored
+	assert.NoError(t, RecordDeletedVlanConfig(cn, deletedVlanConfig("vc1", now), now))
+	snapshot, err = TakeDeletedVlanConfig(cn, "vc1", now.Add(DeletedVlanConfigRetention+time.Minute))
+	assert.NoError(t, err)
+	assert.Nil(t, snapshot)
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	statusWriteBaseDelay = 100 * time.Millisecond
+	statusWriteMaxDelay  = 30 * time.Second
+)
+
+// StatusWriter writes the statuses in the background, so that a slow or unreachable API server never holds back the
+// reconciles changing the links, and a failing reconcile still reports its error. The writes are coalesced by key:
+// a write submitted while an earlier one of the same key is pending replaces it, as only the latest status matters.
+// The writes of the same key run in the order they're submitted and never concurrently, a failed write is retried
+// with backoff until it succeeds or a newer write of the key replaces it.
+type StatusWriter struct {
+	queue   workqueue.TypedRateLimitingInterface[string]
+	mutex   sync.Mutex
+	pending map[string]func() error
+}
+
+func NewStatusWriter() *StatusWriter {
+	return &StatusWriter{
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[string](statusWriteBaseDelay, statusWriteMaxDelay),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: "status-writer"},
+		),
+		pending: make(map[string]func() error),
+	}
+}
+
+// Submit queues the write of the key, replacing the pending write of the key if any
+func (w *StatusWriter) Submit(key string, write func() error) {
+	w.mutex.Lock()
+	w.pending[key] = write
+	w.mutex.Unlock()
+
+	w.queue.Forget(key)
+	w.queue.Add(key)
+}
+
+// Pending returns the number of the keys having a write not done yet
+func (w *StatusWriter) Pending() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return len(w.pending)
+}
+
+// Run runs the writes with the workers until the context is done
+func (w *StatusWriter) Run(ctx context.Context, workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for w.processNext() {
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	w.queue.ShutDown()
+}
+
+func (w *StatusWriter) processNext() bool {
+	key, shutdown := w.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer w.queue.Done(key)
+
+	w.mutex.Lock()
+	write, ok := w.pending[key]
+	delete(w.pending, key)
+	w.mutex.Unlock()
+	if !ok {
+		return true
+	}
+
+	if err := write(); err != nil {
+		w.mutex.Lock()
+		// a newer write of the key supersedes the failed one
+		if _, superseded := w.pending[key]; !superseded {
+			w.pending[key] = write
+			w.mutex.Unlock()
+			logrus.Warnf("status write %s failed and will be retried, error: %s", key, err.Error())
+			w.queue.AddRateLimited(key)
+			return true
+		}
+		w.mutex.Unlock()
+	}
+	w.queue.Forget(key)
+
+	return true
+}
+
+// The keys of the status writes. The writers of different fields of the same object use different keys, so that they
+// don't replace each other's writes.
+
+// StatusKeyOfVlanStatus is the key of the writes of the agent setting up the VLAN of the cluster network on the node
+func StatusKeyOfVlanStatus(cnName, nodeName string) string {
+	return "vlanstatus/" + cnName + "/" + nodeName
+}
+
+// StatusKeyOfBridgeStatus is the key of the writes of the bridge settings in the vlanstatus of the node
+func StatusKeyOfBridgeStatus(cnName, nodeName string) string {
+	return "bridgestatus/" + cnName + "/" + nodeName
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusWriter(t *testing.T) {
+	w := NewStatusWriter()
+
+	// the writes submitted before the writer runs are coalesced into the latest one
+	var first, latest atomic.Int32
+	w.Submit("a", func() error { first.Add(1); return nil })
+	w.Submit("a", func() error { latest.Add(1); return nil })
+	assert.Equal(t, 1, w.Pending())
+
+	// a failed write is retried until it succeeds
+	var attempts atomic.Int32
+	w.Submit("b", func() error {
+		if attempts.Add(1) < 3 {
+			return errors.New("the API server is unreachable")
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx, 2)
+
+	assert.Eventually(t, func() bool {
+		return latest.Load() == 1 && attempts.Load() == 3 && w.Pending() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(0), first.Load())
+
+	// a newer write replaces a failing one
+	var replaced atomic.Int32
+	w.Submit("c", func() error { return errors.New("conflict") })
+	w.Submit("c", func() error { replaced.Add(1); return nil })
+	assert.Eventually(t, func() bool { return replaced.Load() == 1 && w.Pending() == 0 }, 5*time.Second,
+		10*time.Millisecond)
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// ErrSkipRemainingSteps is returned by a step to end the steps early without failing, the remaining steps are
+// skipped, e.g. the teardown of a bridge still used by another vlanconfig ends once the NICs are released
+var ErrSkipRemainingSteps = errors.New("skip the remaining steps")
+
+// stepRetryDelay is replaced by the tests
+var stepRetryDelay = 200 * time.Millisecond
+
+// Step is a step of the setup or the teardown of the VLAN on the node. The steps share their state through the
+// closures, a step only runs once the steps before it succeeded.
+type Step struct {
+	Name string
+	// Skip tells whether the step doesn't apply, it's evaluated right before the step runs so that it can depend on
+	// the state the earlier steps leave, nil runs the step always
+	Skip func() bool
+	// Retries is how many times the step is retried in place on a retryable error before the steps fail, so that a
+	// transient error doesn't repeat the steps already done
+	Retries int
+	Run     func() error
+}
+
+// RunSteps runs the steps in order until one fails, and returns the outcome of every step together with the error of
+// the failed step. The error is annotated with the name of the step.
+func RunSteps(steps []Step, retryable func(error) bool) ([]networkv1.SetupStep, error) {
+	results := make([]networkv1.SetupStep, 0, len(steps))
+	var stepErr error
+	skipRemaining := false
+
+	for _, step := range steps {
+		switch {
+		case stepErr != nil:
+			results = append(results, networkv1.SetupStep{Name: step.Name, Phase: networkv1.StepPending})
+			continue
+		case skipRemaining || (step.Skip != nil && step.Skip()):
+			results = append(results, networkv1.SetupStep{Name: step.Name, Phase: networkv1.StepSkipped})
+			continue
+		}
+
+		err := runStep(step, retryable)
+		switch {
+		case errors.Is(err, ErrSkipRemainingSteps):
+			skipRemaining = true
+		case err != nil:
+			stepErr = fmt.Errorf("step %s failed, error: %w", step.Name, err)
+			results = append(results, networkv1.SetupStep{Name: step.Name, Phase: networkv1.StepFailed, Message: err.Error()})
+			continue
+		}
+		results = append(results, networkv1.SetupStep{Name: step.Name, Phase: networkv1.StepSucceeded})
+	}
+
+	return results, stepErr
+}
+
+func runStep(step Step, retryable func(error) bool) error {
+	err := step.Run()
+	for attempt := 1; attempt <= step.Retries && err != nil && retryable != nil && retryable(err); attempt++ {
+		logrus.Warnf("step %s failed and is retried (%d/%d), error: %s", step.Name, attempt, step.Retries, err.Error())
+		time.Sleep(stepRetryDelay)
+		err = step.Run()
+	}
+
+	return err
+}
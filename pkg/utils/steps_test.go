@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+var errTransient = errors.New("transient")
+
+func TestRunSteps(t *testing.T) {
+	stepRetryDelay = 0
+	retryable := func(err error) bool { return errors.Is(err, errTransient) }
+
+	tests := []struct {
+		name      string
+		steps     func(ran *[]string) []Step
+		expected  []networkv1.StepPhase
+		ran       []string
+		returnErr bool
+	}{
+		{
+			name: "all steps succeed",
+			steps: func(ran *[]string) []Step {
+				return []Step{newTestStep("a", ran, nil), newTestStep("b", ran, nil)}
+			},
+			expected: []networkv1.StepPhase{networkv1.StepSucceeded, networkv1.StepSucceeded},
+			ran:      []string{"a", "b"},
+		},
+		{
+			name: "the steps after a failed one are pending",
+			steps: func(ran *[]string) []Step {
+				return []Step{newTestStep("a", ran, nil), newTestStep("b", ran, errors.New("boom")), newTestStep("c", ran, nil)}
+			},
+			expected:  []networkv1.StepPhase{networkv1.StepSucceeded, networkv1.StepFailed, networkv1.StepPending},
+			ran:       []string{"a", "b"},
+			returnErr: true,
+		},
+		{
+			name: "a skipped step doesn't run",
+			steps: func(ran *[]string) []Step {
+				skipped := newTestStep("b", ran, nil)
+				skipped.Skip = func() bool { return true }
+				return []Step{newTestStep("a", ran, nil), skipped, newTestStep("c", ran, nil)}
+			},
+			expected: []networkv1.StepPhase{networkv1.StepSucceeded, networkv1.StepSkipped, networkv1.StepSucceeded},
+			ran:      []string{"a", "c"},
+		},
+		{
+			name: "a step ends the steps early",
+			steps: func(ran *[]string) []Step {
+				return []Step{newTestStep("a", ran, ErrSkipRemainingSteps), newTestStep("b", ran, nil)}
+			},
+			expected: []networkv1.StepPhase{networkv1.StepSucceeded, networkv1.StepSkipped},
+			ran:      []string{"a"},
+		},
+		{
+			name: "the skip depends on the earlier steps",
+			steps: func(ran *[]string) []Step {
+				overlay := false
+				first := newTestStep("a", ran, nil)
+				run := first.Run
+				first.Run = func() error { overlay = true; return run() }
+				second := newTestStep("b", ran, nil)
+				second.Skip = func() bool { return overlay }
+				return []Step{first, second}
+			},
+			expected: []networkv1.StepPhase{networkv1.StepSucceeded, networkv1.StepSkipped},
+			ran:      []string{"a"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ran := make([]string, 0)
+			results, err := RunSteps(tc.steps(&ran), retryable)
+			phases := make([]networkv1.StepPhase, 0, len(results))
+			for _, r := range results {
+				phases = append(phases, r.Phase)
+			}
+			assert.Equal(t, tc.expected, phases)
+			assert.Equal(t, tc.ran, ran)
+			assert.Equal(t, tc.returnErr, err != nil)
+		})
+	}
+}
+
+func TestRunStepsRetries(t *testing.T) {
+	stepRetryDelay = 0
+	retryable := func(err error) bool { return errors.Is(err, errTransient) }
+
+	// a transient error is retried in place without running the earlier steps again
+	attempts, earlier := 0, 0
+	results, err := RunSteps([]Step{
+		{Name: "a", Run: func() error { earlier++; return nil }},
+		{Name: "b", Retries: 2, Run: func() error {
+			if attempts++; attempts < 3 {
+				return errTransient
+			}
+			return nil
+		}},
+	}, retryable)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 1, earlier)
+	assert.Equal(t, networkv1.StepSucceeded, results[1].Phase)
+
+	// the retries are limited
+	attempts = 0
+	results, err = RunSteps([]Step{{Name: "b", Retries: 1, Run: func() error { attempts++; return errTransient }}}, retryable)
+	assert.ErrorIs(t, err, errTransient)
+	assert.ErrorContains(t, err, "step b failed")
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, networkv1.SetupStep{Name: "b", Phase: networkv1.StepFailed, Message: "transient"}, results[0])
+
+	// the other errors are never retried
+	attempts = 0
+	_, err = RunSteps([]Step{{Name: "b", Retries: 3, Run: func() error { attempts++; return errors.New("invalid") }}}, retryable)
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func newTestStep(name string, ran *[]string, err error) Step {
+	return Step{Name: name, Run: func() error {
+		*ran = append(*ran, name)
+		return err
+	}}
+}
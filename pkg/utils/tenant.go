@@ -0,0 +1,35 @@
+package utils
+
+import "strings"
+
+// SplitLabelKeys returns the label keys in the comma separated string, the blank ones are skipped
+func SplitLabelKeys(value string) []string {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// SetTenantLabels copies the tenant labels of the keys from the source labels, e.g. the ones of the namespace, and
+// removes the ones the source doesn't have, it tells whether the labels are changed
+func SetTenantLabels(lbs map[string]string, keys []string, source map[string]string) bool {
+	changed := false
+	for _, key := range keys {
+		value, ok := source[key]
+		current, exists := lbs[key]
+		switch {
+		case !ok && exists:
+			delete(lbs, key)
+			changed = true
+		case ok && (!exists || current != value):
+			lbs[key] = value
+			changed = true
+		}
+	}
+
+	return changed
+}
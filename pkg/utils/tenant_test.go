@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitLabelKeys(t *testing.T) {
+	assert.Equal(t, []string{KeyRancherProjectID, "tenant"}, SplitLabelKeys(KeyRancherProjectID+", tenant,"))
+	assert.Empty(t, SplitLabelKeys(""))
+}
+
+func TestSetTenantLabels(t *testing.T) {
+	keys := []string{KeyRancherProjectID, "tenant"}
+	lbs := map[string]string{KeyClusterNetworkLabel: "cn1", "tenant": "team-a"}
+
+	assert.True(t, SetTenantLabels(lbs, keys, map[string]string{KeyRancherProjectID: "p-abcde", "other": "value"}))
+	assert.Equal(t, map[string]string{KeyClusterNetworkLabel: "cn1", KeyRancherProjectID: "p-abcde"}, lbs)
+	assert.False(t, SetTenantLabels(lbs, keys, map[string]string{KeyRancherProjectID: "p-abcde"}))
+}
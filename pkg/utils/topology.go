@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// TopologyKeyOf returns the node label the topology override matches, the zone label if it's omitted
+func TopologyKeyOf(override *networkv1.TopologyOverride) string {
+	if override.TopologyKey == "" {
+		return corev1.LabelTopologyZone
+	}
+	return override.TopologyKey
+}
+
+// MatchTopologyOverride returns the first topology override of the vlanconfig matching the node labels, nil if none
+func MatchTopologyOverride(vc *networkv1.VlanConfig, nodeLabels map[string]string) *networkv1.TopologyOverride {
+	for i := range vc.Spec.TopologyOverrides {
+		override := &vc.Spec.TopologyOverrides[i]
+		if value, ok := nodeLabels[TopologyKeyOf(override)]; ok && slices.Contains(override.Values, value) {
+			return override
+		}
+	}
+	return nil
+}
+
+// ApplyTopologyOverride returns a copy of the vlanconfig whose uplink is adjusted by the topology override matching
+// the node labels. The overrides are cleared in the copy, so that it's the effective vlanconfig on the node and
+// applying again changes nothing.
+func ApplyTopologyOverride(vc *networkv1.VlanConfig, nodeLabels map[string]string) *networkv1.VlanConfig {
+	if len(vc.Spec.TopologyOverrides) == 0 {
+		return vc
+	}
+
+	override := MatchTopologyOverride(vc, nodeLabels)
+	effective := vc.DeepCopy()
+	effective.Spec.TopologyOverrides = nil
+	if override == nil {
+		return effective
+	}
+	uplink := &effective.Spec.Uplink
+	if len(override.NICs) != 0 {
+		uplink.NICs = slices.Clone(override.NICs)
+		// the backup NICs of the default uplink may not exist in the zone
+		uplink.BackupNICs = slices.Clone(override.BackupNICs)
+	}
+	if override.MTU != 0 {
+		if uplink.LinkAttrs == nil {
+			uplink.LinkAttrs = &networkv1.LinkAttrs{TxQLen: -1}
+		}
+		uplink.LinkAttrs.MTU = override.MTU
+	}
+	if override.BondOptions != nil {
+		uplink.BondOptions = override.BondOptions.DeepCopy()
+	}
+
+	return effective
+}
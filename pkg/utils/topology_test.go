@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestApplyTopologyOverride(t *testing.T) {
+	vc := &networkv1.VlanConfig{
+		Spec: networkv1.VlanConfigSpec{
+			ClusterNetwork: "data",
+			Uplink: networkv1.Uplink{
+				NICs:        []string{"eth1", "eth2"},
+				BackupNICs:  []string{"eth2"},
+				BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMoDeActiveBackup, Miimon: 100},
+			},
+			TopologyOverrides: []networkv1.TopologyOverride{
+				{Values: []string{"zone2", "zone3"}, NICs: []string{"ens1f0", "ens1f1"}, MTU: 9000},
+				{TopologyKey: "rack", Values: []string{"r7"}, BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: 100}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		nics        []string
+		backupNICs  []string
+		mtu         int
+		bondOptions *networkv1.BondOptions
+	}{
+		{
+			name:        "no override matches",
+			labels:      map[string]string{corev1.LabelTopologyZone: "zone1"},
+			nics:        []string{"eth1", "eth2"},
+			backupNICs:  []string{"eth2"},
+			bondOptions: vc.Spec.Uplink.BondOptions,
+		},
+		{
+			name:        "zone override replaces the NICs and the MTU",
+			labels:      map[string]string{corev1.LabelTopologyZone: "zone3", "rack": "r7"},
+			nics:        []string{"ens1f0", "ens1f1"},
+			mtu:         9000,
+			bondOptions: vc.Spec.Uplink.BondOptions,
+		},
+		{
+			name:        "rack override replaces the bond options",
+			labels:      map[string]string{corev1.LabelTopologyZone: "zone1", "rack": "r7"},
+			nics:        []string{"eth1", "eth2"},
+			backupNICs:  []string{"eth2"},
+			bondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: 100},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			effective := ApplyTopologyOverride(vc, tc.labels)
+			assert.Empty(t, effective.Spec.TopologyOverrides)
+			assert.Equal(t, tc.nics, effective.Spec.Uplink.NICs)
+			assert.Equal(t, tc.backupNICs, effective.Spec.Uplink.BackupNICs)
+			assert.Equal(t, tc.mtu, GetMTUFromVlanConfig(effective))
+			assert.Equal(t, tc.bondOptions, effective.Spec.Uplink.BondOptions)
+			assert.Equal(t, effective, ApplyTopologyOverride(effective, tc.labels), "applying again changes nothing")
+		})
+	}
+	assert.Len(t, vc.Spec.TopologyOverrides, 2, "the vlanconfig itself is untouched")
+	assert.Nil(t, vc.Spec.Uplink.LinkAttrs)
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/rancher/wrangler/pkg/condition"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// MaxReadyTransitions is the number of the latest transitions of the condition Ready kept in a vlanstatus
+const MaxReadyTransitions = 16
+
+// RecordReadyTransition appends the transition to the history of the vlanstatus if its condition Ready has changed from
+// the previous status, and drops the oldest transitions beyond MaxReadyTransitions. It returns true if one is recorded.
+func RecordReadyTransition(vs *networkv1.VlanStatus, previous string, now time.Time) bool {
+	current := networkv1.Ready.GetStatus(vs)
+	if current == previous || current == "" {
+		return false
+	}
+
+	transition := networkv1.ReadyTransition{
+		Time:  metav1.Time{Time: now},
+		Ready: current == string(corev1.ConditionTrue),
+	}
+	if !transition.Ready {
+		transition.Reason = networkv1.Ready.GetMessage(vs)
+	}
+	transitions := append(vs.Status.ReadyTransitions, transition)
+	if len(transitions) > MaxReadyTransitions {
+		transitions = transitions[len(transitions)-MaxReadyTransitions:]
+	}
+	vs.Status.ReadyTransitions = transitions
+
+	return true
+}
+
+// StampConditionTransition stamps the LastTransitionTime of the condition of the vlanstatus if its status has changed
+// from the previous status or has never been stamped. wrangler only stamps the LastUpdateTime, which moves with the
+// message as well.
+func StampConditionTransition(vs *networkv1.VlanStatus, cond condition.Cond, previous string, now time.Time) {
+	for i := range vs.Status.Conditions {
+		c := &vs.Status.Conditions[i]
+		if c.Type != cond {
+			continue
+		}
+		if string(c.Status) != previous || c.LastTransitionTime == "" {
+			c.LastTransitionTime = now.UTC().Format(time.RFC3339)
+		}
+		return
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestRecordReadyTransition(t *testing.T) {
+	vs := &networkv1.VlanStatus{}
+	now := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+
+	networkv1.Ready.SetStatusBool(vs, true)
+	assert.True(t, RecordReadyTransition(vs, "", now), "the first status is recorded")
+	assert.False(t, RecordReadyTransition(vs, string(corev1.ConditionTrue), now), "no transition")
+
+	networkv1.Ready.SetStatusBool(vs, false)
+	networkv1.Ready.Message(vs, "no carrier")
+	assert.True(t, RecordReadyTransition(vs, string(corev1.ConditionTrue), now.Add(time.Minute)))
+	assert.Equal(t, []networkv1.ReadyTransition{
+		{Time: vs.Status.ReadyTransitions[0].Time, Ready: true},
+		{Time: vs.Status.ReadyTransitions[1].Time, Ready: false, Reason: "no carrier"},
+	}, vs.Status.ReadyTransitions)
+	assert.Equal(t, now.Add(time.Minute), vs.Status.ReadyTransitions[1].Time.Time)
+
+	// the history is bounded, the oldest are dropped
+	for i := 0; i < MaxReadyTransitions; i++ {
+		previous := networkv1.Ready.GetStatus(vs)
+		networkv1.Ready.SetStatusBool(vs, previous != string(corev1.ConditionTrue))
+		RecordReadyTransition(vs, previous, now.Add(time.Duration(i+2)*time.Minute))
+	}
+	assert.Len(t, vs.Status.ReadyTransitions, MaxReadyTransitions)
+	assert.Equal(t, now.Add(time.Duration(MaxReadyTransitions+1)*time.Minute), vs.Status.ReadyTransitions[MaxReadyTransitions-1].Time.Time)
+	assert.Equal(t, now.Add(2*time.Minute), vs.Status.ReadyTransitions[0].Time.Time)
+}
+
+func TestStampConditionTransition(t *testing.T) {
+	vs := &networkv1.VlanStatus{}
+	now := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+
+	networkv1.Ready.SetStatusBool(vs, false)
+	StampConditionTransition(vs, networkv1.Ready, "", now)
+	assert.Equal(t, "2024-01-01T02:00:00Z", vs.Status.Conditions[0].LastTransitionTime)
+
+	// a changing message doesn't move the transition
+	networkv1.Ready.Message(vs, "no carrier")
+	StampConditionTransition(vs, networkv1.Ready, string(corev1.ConditionFalse), now.Add(time.Minute))
+	assert.Equal(t, "2024-01-01T02:00:00Z", vs.Status.Conditions[0].LastTransitionTime)
+
+	networkv1.Ready.SetStatusBool(vs, true)
+	StampConditionTransition(vs, networkv1.Ready, string(corev1.ConditionFalse), now.Add(2*time.Minute))
+	assert.Equal(t, "2024-01-01T02:02:00Z", vs.Status.Conditions[0].LastTransitionTime)
+}
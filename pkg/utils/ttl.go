@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
+)
+
+// ExpiresAt returns when the object annotated with a TTL expires, counted from its creation, ok is false if the object
+// has no TTL
+func ExpiresAt(obj metav1.Object) (expiry time.Time, ok bool, err error) {
+	ttl, ok, err := annotations.GetTTL(obj)
+	if !ok || err != nil {
+		return time.Time{}, ok, err
+	}
+
+	return obj.GetCreationTimestamp().Add(ttl), true, nil
+}
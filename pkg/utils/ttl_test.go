@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExpiresAt(t *testing.T) {
+	created := time.Unix(1000, 0)
+	obj := &metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)}
+
+	_, ok, err := ExpiresAt(obj)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	obj.Annotations = map[string]string{KeyTTL: "2h"}
+	expiry, ok, err := ExpiresAt(obj)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, created.Add(2*time.Hour), expiry)
+
+	obj.Annotations[KeyTTL] = "forever"
+	_, ok, err = ExpiresAt(obj)
+	assert.Error(t, err)
+	assert.True(t, ok)
+}
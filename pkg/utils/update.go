@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+)
+
+// clusterScopedClient is the subset of the generated clients of the cluster-scoped resources the updates need
+type clusterScopedClient[T runtime.Object] interface {
+	Get(name string, options metav1.GetOptions) (T, error)
+	Update(T) (T, error)
+}
+
+// UpdateVlanStatus applies the change to a copy of the vlanstatus and updates it, see updateOnConflict
+func UpdateVlanStatus(client ctlnetworkv1.VlanStatusClient, vs *networkv1.VlanStatus,
+	change func(*networkv1.VlanStatus)) (*networkv1.VlanStatus, error) {
+	return updateOnConflict[*networkv1.VlanStatus](client, vs, vs.Name, change)
+}
+
+// UpdateClusterNetwork applies the change to a copy of the cluster network and updates it, see updateOnConflict
+func UpdateClusterNetwork(client ctlnetworkv1.ClusterNetworkClient, cn *networkv1.ClusterNetwork,
+	change func(*networkv1.ClusterNetwork)) (*networkv1.ClusterNetwork, error) {
+	return updateOnConflict[*networkv1.ClusterNetwork](client, cn, cn.Name, change)
+}
+
+// UpdatePacketCapture applies the change to a copy of the packet capture and updates it, see updateOnConflict
+func UpdatePacketCapture(client ctlnetworkv1.PacketCaptureClient, pc *networkv1.PacketCapture,
+	change func(*networkv1.PacketCapture)) (*networkv1.PacketCapture, error) {
+	return updateOnConflict[*networkv1.PacketCapture](client, pc, pc.Name, change)
+}
+
+// updateOnConflict applies the change to a copy of the object and updates it with the resourceVersion of the object
+// as the precondition. Nothing is written if the change is a no-op, e.g. an agent restarted reports the same status
+// again. On a conflict the change is applied again to the latest object read from the API server rather than the
+// cache, which may lag behind, so that the concurrent writers, e.g. the agent and the manager setting different
+// conditions of a vlanstatus, neither overwrite each other nor fail their reconciles.
+func updateOnConflict[T runtime.Object](client clusterScopedClient[T], obj T, name string, change func(T)) (T, error) {
+	current, updated := obj, obj
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		objCopy := current.DeepCopyObject().(T)
+		change(objCopy)
+		if reflect.DeepEqual(current, objCopy) {
+			updated = current
+			return nil
+		}
+
+		var err error
+		updated, err = client.Update(objCopy)
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+		latest, getErr := client.Get(name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		current = latest
+		return err
+	})
+
+	return updated, err
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// fakeVlanStatusClient stores a single vlanstatus and rejects the updates with a stale resourceVersion
+type fakeVlanStatusClient struct {
+	stored  *networkv1.VlanStatus
+	updates int
+}
+
+func (c *fakeVlanStatusClient) Get(string, metav1.GetOptions) (*networkv1.VlanStatus, error) {
+	return c.stored.DeepCopy(), nil
+}
+
+func (c *fakeVlanStatusClient) Update(vs *networkv1.VlanStatus) (*networkv1.VlanStatus, error) {
+	if vs.ResourceVersion != c.stored.ResourceVersion {
+		return nil, apierrors.NewConflict(schema.GroupResource{Resource: "vlanstatuses"}, vs.Name, nil)
+	}
+	c.updates++
+	c.stored = vs.DeepCopy()
+	c.stored.ResourceVersion += "1"
+	return c.stored.DeepCopy(), nil
+}
+
+func TestUpdateOnConflict(t *testing.T) {
+	const (
+		agentCond   condition.Cond = "agent"
+		managerCond condition.Cond = "manager"
+	)
+	stale := &networkv1.VlanStatus{ObjectMeta: metav1.ObjectMeta{Name: "vs1", ResourceVersion: "1"}}
+	client := &fakeVlanStatusClient{stored: stale.DeepCopy()}
+
+	// the manager wins the race
+	updated, err := updateOnConflict[*networkv1.VlanStatus](client, stale, stale.Name, func(vs *networkv1.VlanStatus) {
+		managerCond.True(vs)
+	})
+	assert.NoError(t, err)
+	assert.True(t, managerCond.IsTrue(updated))
+
+	// the agent updates the stale object, the change is applied again to the latest one
+	updated, err = updateOnConflict[*networkv1.VlanStatus](client, stale, stale.Name, func(vs *networkv1.VlanStatus) {
+		agentCond.True(vs)
+	})
+	assert.NoError(t, err)
+	assert.True(t, agentCond.IsTrue(updated))
+	assert.True(t, managerCond.IsTrue(updated), "the condition of the other writer is kept")
+	assert.Equal(t, 2, client.updates)
+
+	// nothing is written if the change is a no-op
+	unchanged, err := updateOnConflict[*networkv1.VlanStatus](client, updated, updated.Name, func(vs *networkv1.VlanStatus) {
+		agentCond.True(vs)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, updated, unchanged)
+	assert.Equal(t, 2, client.updates)
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// the uplink sections which can be inherited from the cluster network, named after their json tags
+const (
+	UplinkFieldLinkAttrs    = "linkAttributes"
+	UplinkFieldBondOptions  = "bondOptions"
+	UplinkFieldQueueOptions = "queueOptions"
+)
+
+// InheritedUplinkFields returns the uplink sections which the vlanconfig inherits from its cluster network
+func InheritedUplinkFields(vc *networkv1.VlanConfig) []string {
+	if vc == nil {
+		return nil
+	}
+
+	return annotations.GetInheritedUplinkFields(vc)
+}
+
+// SetInheritedUplinkFields records the inherited uplink sections into the annotations, the annotation is removed if
+// there is no inherited section
+func SetInheritedUplinkFields(annotations map[string]string, fields []string) {
+	if len(fields) == 0 {
+		delete(annotations, KeyInheritedUplinkFields)
+		return
+	}
+
+	sorted := slices.Clone(fields)
+	slices.Sort(sorted)
+	annotations[KeyInheritedUplinkFields] = strings.Join(sorted, ",")
+}
+
+// InheritUplinkDefaults returns the uplink of the vlanconfig with the defaults of the cluster network applied,
+// and the sections inherited from the defaults.
+//   - An omitted section inherits the default, so does a section newly set to the default.
+//   - An inherited section which is not modified since the old vlanconfig follows the default.
+//   - An inherited section which is modified becomes an override, unless it's modified to the default.
+func InheritUplinkDefaults(oldVc, vc *networkv1.VlanConfig, defaults *networkv1.UplinkDefaults) (networkv1.Uplink, []string) {
+	if defaults == nil {
+		defaults = &networkv1.UplinkDefaults{}
+	}
+	// the defaults may come from the cache, never share them
+	defaults = defaults.DeepCopy()
+	old := &networkv1.Uplink{}
+	if oldVc != nil {
+		old = &oldVc.Spec.Uplink
+	}
+
+	wasInherited := InheritedUplinkFields(vc)
+	inherited := make([]string, 0, 3)
+	uplink := *vc.Spec.Uplink.DeepCopy()
+
+	var ok bool
+	if uplink.LinkAttrs, ok = inheritField(uplink.LinkAttrs, old.LinkAttrs, defaults.LinkAttrs,
+		slices.Contains(wasInherited, UplinkFieldLinkAttrs)); ok {
+		inherited = append(inherited, UplinkFieldLinkAttrs)
+	}
+	if uplink.BondOptions, ok = inheritField(uplink.BondOptions, old.BondOptions, defaults.BondOptions,
+		slices.Contains(wasInherited, UplinkFieldBondOptions)); ok {
+		inherited = append(inherited, UplinkFieldBondOptions)
+	}
+	if uplink.QueueOptions, ok = inheritField(uplink.QueueOptions, old.QueueOptions, defaults.QueueOptions,
+		slices.Contains(wasInherited, UplinkFieldQueueOptions)); ok {
+		inherited = append(inherited, UplinkFieldQueueOptions)
+	}
+
+	return uplink, inherited
+}
+
+// inheritField returns the value of the section and whether it's inherited from the default
+func inheritField[T any](current, old, def *T, wasInherited bool) (*T, bool) {
+	switch {
+	case current == nil:
+		return def, def != nil
+	case reflect.DeepEqual(current, def):
+		// a section filled with the default is taken as inherited
+		return current, wasInherited || old == nil
+	case wasInherited && reflect.DeepEqual(current, old):
+		return def, def != nil
+	default:
+		return current, false
+	}
+}
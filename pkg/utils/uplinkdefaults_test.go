@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestInheritUplinkDefaults(t *testing.T) {
+	defaults := &networkv1.UplinkDefaults{
+		LinkAttrs:   &networkv1.LinkAttrs{MTU: 9000, TxQLen: -1},
+		BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: 100},
+	}
+	newVC := func(inherited string, linkAttrs *networkv1.LinkAttrs, bondOptions *networkv1.BondOptions) *networkv1.VlanConfig {
+		vc := &networkv1.VlanConfig{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+			Spec: networkv1.VlanConfigSpec{
+				Uplink: networkv1.Uplink{
+					NICs:        []string{"eth1"},
+					LinkAttrs:   linkAttrs,
+					BondOptions: bondOptions,
+				},
+			},
+		}
+		if inherited != "" {
+			vc.Annotations[KeyInheritedUplinkFields] = inherited
+		}
+		return vc
+	}
+
+	tests := []struct {
+		name              string
+		oldVC             *networkv1.VlanConfig
+		vc                *networkv1.VlanConfig
+		defaults          *networkv1.UplinkDefaults
+		expectedLinkAttrs *networkv1.LinkAttrs
+		expectedBond      *networkv1.BondOptions
+		expectedInherited []string
+	}{
+		{
+			name:              "omitted sections inherit the defaults",
+			vc:                newVC("", nil, nil),
+			defaults:          defaults,
+			expectedLinkAttrs: defaults.LinkAttrs,
+			expectedBond:      defaults.BondOptions,
+			expectedInherited: []string{UplinkFieldLinkAttrs, UplinkFieldBondOptions},
+		},
+		{
+			name:              "overridden section is kept",
+			vc:                newVC("", &networkv1.LinkAttrs{MTU: 1500, TxQLen: -1}, nil),
+			defaults:          defaults,
+			expectedLinkAttrs: &networkv1.LinkAttrs{MTU: 1500, TxQLen: -1},
+			expectedBond:      defaults.BondOptions,
+			expectedInherited: []string{UplinkFieldBondOptions},
+		},
+		{
+			name:              "no defaults",
+			vc:                newVC("", nil, nil),
+			defaults:          nil,
+			expectedLinkAttrs: nil,
+			expectedBond:      nil,
+			expectedInherited: []string{},
+		},
+		{
+			name:              "untouched inherited section follows the changed default",
+			oldVC:             newVC(UplinkFieldLinkAttrs, &networkv1.LinkAttrs{MTU: 1500, TxQLen: -1}, nil),
+			vc:                newVC(UplinkFieldLinkAttrs, &networkv1.LinkAttrs{MTU: 1500, TxQLen: -1}, nil),
+			defaults:          defaults,
+			expectedLinkAttrs: defaults.LinkAttrs,
+			expectedBond:      defaults.BondOptions,
+			expectedInherited: []string{UplinkFieldLinkAttrs, UplinkFieldBondOptions},
+		},
+		{
+			name:              "modified inherited section becomes an override",
+			oldVC:             newVC(UplinkFieldLinkAttrs+","+UplinkFieldBondOptions, defaults.LinkAttrs, defaults.BondOptions),
+			vc:                newVC(UplinkFieldLinkAttrs+","+UplinkFieldBondOptions, &networkv1.LinkAttrs{MTU: 1500, TxQLen: -1}, defaults.BondOptions),
+			defaults:          defaults,
+			expectedLinkAttrs: &networkv1.LinkAttrs{MTU: 1500, TxQLen: -1},
+			expectedBond:      defaults.BondOptions,
+			expectedInherited: []string{UplinkFieldBondOptions},
+		},
+		{
+			name:              "inherited section is removed with the default",
+			oldVC:             newVC(UplinkFieldBondOptions, nil, defaults.BondOptions),
+			vc:                newVC(UplinkFieldBondOptions, nil, defaults.BondOptions),
+			defaults:          &networkv1.UplinkDefaults{},
+			expectedLinkAttrs: nil,
+			expectedBond:      nil,
+			expectedInherited: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			uplink, inherited := InheritUplinkDefaults(tc.oldVC, tc.vc, tc.defaults)
+			assert.Equal(t, tc.expectedLinkAttrs, uplink.LinkAttrs)
+			assert.Equal(t, tc.expectedBond, uplink.BondOptions)
+			assert.Equal(t, tc.expectedInherited, inherited)
+			assert.Equal(t, tc.vc.Spec.Uplink.NICs, uplink.NICs)
+		})
+	}
+}
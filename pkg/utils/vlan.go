@@ -179,6 +179,16 @@ func (vis *VlanIDSet) WalkVIDs(name string, callback func(vid uint16) error) err
 	return nil
 }
 
+// VIDs returns the vids in range [2..4094] in ascending order
+func (vis *VlanIDSet) VIDs() []uint16 {
+	vids := make([]uint16, 0, vis.GetVlanCount())
+	_ = vis.WalkVIDs("", func(vid uint16) error {
+		vids = append(vids, vid)
+		return nil
+	})
+	return vids
+}
+
 // when run Append() or Diff(), if the vidset is in single mode, convert it to trunk mode first
 func (vis *VlanIDSet) ConvertToTrunkMode() {
 	// already in trunk mode
@@ -210,3 +220,37 @@ func NewVlanIDSetFromSingleVID(vid int) (*VlanIDSet, error) {
 	}
 	return vis, nil
 }
+
+// MissingVIDs returns the vids of the set in range [2..4094] which are not reported, in ascending order
+func (vis *VlanIDSet) MissingVIDs(reported []uint16) []uint16 {
+	present := make(map[uint16]bool, len(reported))
+	for _, vid := range reported {
+		present[vid] = true
+	}
+
+	var missing []uint16
+	for _, vid := range vis.VIDs() {
+		if !present[vid] {
+			missing = append(missing, vid)
+		}
+	}
+	return missing
+}
+
+// FormatVIDRanges joins the ascending vids with the consecutive ones collapsed into a range, e.g. "100-102,200"
+func FormatVIDRanges(vids []uint16) string {
+	ranges := make([]string, 0, len(vids))
+	for i := 0; i < len(vids); {
+		j := i
+		for j+1 < len(vids) && vids[j+1] == vids[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(int(vids[i])))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", vids[i], vids[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, VlanIDStringJoinChar)
+}
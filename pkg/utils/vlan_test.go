@@ -117,3 +117,30 @@ func TestNewVidSET(t *testing.T) {
 		})
 	}
 }
+
+func TestVIDs(t *testing.T) {
+	vis := NewVlanIDSet()
+	assert.Empty(t, vis.VIDs())
+	assert.Nil(t, vis.SetVID(300))
+	assert.Nil(t, vis.SetVID(100))
+	assert.Equal(t, []uint16{100, 300}, vis.VIDs())
+
+	single, err := NewVlanIDSetFromSingleVID(111)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint16{111}, single.VIDs())
+}
+
+func TestMissingVIDs(t *testing.T) {
+	vis := NewVlanIDSet()
+	for _, vid := range []uint16{100, 101, 102, 200, 300} {
+		assert.NoError(t, vis.SetUint16VID(vid))
+	}
+
+	missing := vis.MissingVIDs([]uint16{1, 101, 300, 400})
+	assert.Equal(t, []uint16{100, 102, 200}, missing)
+	assert.Nil(t, vis.MissingVIDs(vis.VIDs()))
+
+	assert.Equal(t, "", FormatVIDRanges(nil))
+	assert.Equal(t, "100,102,200", FormatVIDRanges(missing))
+	assert.Equal(t, "100-102,200,300", FormatVIDRanges(vis.VIDs()))
+}
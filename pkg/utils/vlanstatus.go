@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+)
+
+// VlanStatusKey is the key of the vlanstatus of the cluster network on the node in the
+// VlanStatusByClusterNetworkAndNodeIndex, it's unambiguous as neither names contain a slash
+func VlanStatusKey(clusterNetwork, node string) string {
+	return clusterNetwork + "/" + node
+}
+
+// VlanStatusName is the name a new vlanstatus of the cluster network on the node is created with,
+// <cluster network>-<node>-<crc32 checksum>. The name is ambiguous, e.g. the cluster network a-b on the node c and the
+// cluster network a on the node b-c share the name, and it's truncated for a long node name, so the vlanstatus has to be
+// looked up with GetVlanStatus rather than by the name.
+func VlanStatusName(clusterNetwork, node string) string {
+	return Name("", clusterNetwork, node)
+}
+
+// IsVlanStatusOf returns true if the vlanstatus is reported for the cluster network on the node
+func IsVlanStatusOf(vs *networkv1.VlanStatus, clusterNetwork, node string) bool {
+	return vs.Status.ClusterNetwork == clusterNetwork && vs.Status.Node == node
+}
+
+// GetVlanStatus returns the vlanstatus of the cluster network on the node by the VlanStatusByClusterNetworkAndNodeIndex,
+// or a not found error. The one named VlanStatusName, or else the oldest one, wins if there are more than one.
+func GetVlanStatus(cache ctlnetworkv1.VlanStatusCache, clusterNetwork, node string) (*networkv1.VlanStatus, error) {
+	vss, err := cache.GetByIndex(VlanStatusByClusterNetworkAndNodeIndex, VlanStatusKey(clusterNetwork, node))
+	if err != nil {
+		return nil, err
+	}
+	if len(vss) == 0 {
+		return nil, apierrors.NewNotFound(networkv1.Resource("vlanstatus"), VlanStatusName(clusterNetwork, node))
+	}
+
+	name := VlanStatusName(clusterNetwork, node)
+	sort.Slice(vss, func(i, j int) bool {
+		if (vss[i].Name == name) != (vss[j].Name == name) {
+			return vss[i].Name == name
+		}
+		if !vss[i].CreationTimestamp.Equal(&vss[j].CreationTimestamp) {
+			return vss[i].CreationTimestamp.Before(&vss[j].CreationTimestamp)
+		}
+		return vss[i].Name < vss[j].Name
+	})
+
+	return vss[0], nil
+}
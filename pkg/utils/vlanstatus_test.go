@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher/wrangler/v3/pkg/generic"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// vlanStatusCache only supports the VlanStatusByClusterNetworkAndNodeIndex
+type vlanStatusCache []*networkv1.VlanStatus
+
+func (c vlanStatusCache) Get(string) (*networkv1.VlanStatus, error) { panic("implement me") }
+
+func (c vlanStatusCache) List(labels.Selector) ([]*networkv1.VlanStatus, error) {
+	panic("implement me")
+}
+
+func (c vlanStatusCache) AddIndexer(string, generic.Indexer[*networkv1.VlanStatus]) {
+	panic("implement me")
+}
+
+func (c vlanStatusCache) GetByIndex(_, key string) ([]*networkv1.VlanStatus, error) {
+	result := make([]*networkv1.VlanStatus, 0)
+	for _, vs := range c {
+		if keys, _ := VlanStatusByClusterNetworkAndNode(vs); slices.Contains(keys, key) {
+			result = append(result, vs)
+		}
+	}
+	return result, nil
+}
+
+var testNow = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newVlanStatus(name, cn, node string, age time.Duration) *networkv1.VlanStatus {
+	return &networkv1.VlanStatus{
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(testNow.Add(-age))},
+		Status:     networkv1.VlStatus{ClusterNetwork: cn, Node: node},
+	}
+}
+
+func TestGetVlanStatus(t *testing.T) {
+	// the cluster network a-b on the node c and the cluster network a on the node b-c share the name
+	assert.Equal(t, VlanStatusName("a-b", "c"), VlanStatusName("a", "b-c"))
+
+	cache := vlanStatusCache{
+		newVlanStatus(VlanStatusName("a-b", "c"), "a-b", "c", time.Hour),
+		newVlanStatus("a-b-c-x7k2p", "a", "b-c", time.Minute),
+		newVlanStatus("cn1-node1-older", "cn1", "node1", time.Hour),
+		newVlanStatus(VlanStatusName("cn1", "node1"), "cn1", "node1", time.Minute),
+		newVlanStatus("cn2-node1-b", "cn2", "node1", time.Hour),
+		newVlanStatus("cn2-node1-a", "cn2", "node1", time.Hour),
+	}
+
+	tests := []struct {
+		cn       string
+		node     string
+		expected string
+	}{
+		{cn: "a-b", node: "c", expected: VlanStatusName("a-b", "c")},
+		{cn: "a", node: "b-c", expected: "a-b-c-x7k2p"},
+		{cn: "cn1", node: "node1", expected: VlanStatusName("cn1", "node1")},
+		{cn: "cn2", node: "node1", expected: "cn2-node1-a"},
+	}
+	for _, tc := range tests {
+		t.Run(VlanStatusKey(tc.cn, tc.node), func(t *testing.T) {
+			vs, err := GetVlanStatus(cache, tc.cn, tc.node)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, vs.Name)
+		})
+	}
+
+	_, err := GetVlanStatus(cache, "cn3", "node1")
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestLabelValue(t *testing.T) {
+	assert.Equal(t, "node1", LabelValue("node1"))
+
+	long := "node-" + strings.Repeat("a", 70) + ".example.com"
+	value := LabelValue(long)
+	assert.Len(t, value, maxLengthOfName)
+	assert.True(t, strings.HasPrefix(value, "node-aaa"))
+	assert.NotEqual(t, value, LabelValue(long+"x"))
+}
@@ -14,6 +14,20 @@ type VmiGetter struct {
 	VmiCache ctlkubevirtv1.VirtualMachineInstanceCache
 }
 
+// VMIByNetwork indexes the vmis by the multus network names, i.e. <networkName> or <namespace>/<networkName>, it's
+// the indexer VMByNetworkIndex of the vmi cache which WhoUseNad requires
+func VMIByNetwork(obj *kubevirtv1.VirtualMachineInstance) ([]string, error) {
+	networks := obj.Spec.Networks
+	networkNameList := make([]string, 0, len(networks))
+	for _, network := range networks {
+		if network.NetworkSource.Multus == nil {
+			continue
+		}
+		networkNameList = append(networkNameList, network.NetworkSource.Multus.NetworkName)
+	}
+	return networkNameList, nil
+}
+
 func NewVmiGetter(vmiCache ctlkubevirtv1.VirtualMachineInstanceCache) *VmiGetter {
 	return &VmiGetter{VmiCache: vmiCache}
 }
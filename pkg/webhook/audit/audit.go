@@ -0,0 +1,139 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/harvester/webhook/pkg/server/admission"
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const pruneInterval = time.Hour
+
+// Recorder records the changes admitted by the wrapped validators into NetworkChangeLogs. The records are written in
+// the background, a failed record is logged and doesn't fail the admission. They are admission-time records, a change
+// rejected after the validator, e.g. by another webhook or a conflict in the API server, is recorded as well.
+type Recorder struct {
+	client ctlnetworkv1.NetworkChangeLogClient
+}
+
+func NewRecorder(client ctlnetworkv1.NetworkChangeLogClient) *Recorder {
+	return &Recorder{client: client}
+}
+
+// Wrap returns the validator recording the changes it admits
+func (r *Recorder) Wrap(v admission.Validator) admission.Validator {
+	return &validator{Validator: v, recorder: r}
+}
+
+type validator struct {
+	admission.Validator
+	recorder *Recorder
+}
+
+func (v *validator) Create(request *admission.Request, newObj runtime.Object) error {
+	if err := v.Validator.Create(request, newObj); err != nil {
+		return err
+	}
+	v.recorder.record(request, nil, newObj)
+	return nil
+}
+
+func (v *validator) Update(request *admission.Request, oldObj, newObj runtime.Object) error {
+	if err := v.Validator.Update(request, oldObj, newObj); err != nil {
+		return err
+	}
+	v.recorder.record(request, oldObj, newObj)
+	return nil
+}
+
+func (v *validator) Delete(request *admission.Request, oldObj runtime.Object) error {
+	if err := v.Validator.Delete(request, oldObj); err != nil {
+		return err
+	}
+	v.recorder.record(request, oldObj, nil)
+	return nil
+}
+
+func (r *Recorder) record(request *admission.Request, oldObj, newObj runtime.Object) {
+	if utils.IsDryRun(request) {
+		return
+	}
+
+	log, err := newChangeLog(request, oldObj, newObj, time.Now())
+	if err != nil {
+		logrus.Warnf("failed to summarize the change of %s, error: %v", request, err)
+		return
+	}
+	if log == nil {
+		return
+	}
+
+	go func() {
+		if _, err := r.client.Create(log); err != nil {
+			logrus.Warnf("failed to record the change of %s, error: %v", request, err)
+		}
+	}()
+}
+
+// newChangeLog returns the record of the change, nil if an update changes none of the audited fields, e.g. only the
+// status or the finalizers
+func newChangeLog(request *admission.Request, oldObj, newObj runtime.Object, now time.Time) (*networkv1.NetworkChangeLog, error) {
+	changes, truncated, err := Summarize(oldObj, newObj)
+	if err != nil {
+		return nil, err
+	}
+	if request.Operation == admissionv1.Update && len(changes) == 0 {
+		return nil, nil
+	}
+
+	kind := request.Kind.Kind
+	return &networkv1.NetworkChangeLog{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: strings.ToLower(kind) + "-",
+			Labels:       map[string]string{utils.KeyChangedKind: kind},
+		},
+		Spec: networkv1.NetworkChangeLogSpec{
+			Kind:      kind,
+			Namespace: request.Namespace,
+			Name:      request.Name,
+			Operation: string(request.Operation),
+			User:      request.UserInfo.Username,
+			Groups:    request.UserInfo.Groups,
+			Timestamp: metav1.NewTime(now),
+			Changes:   changes,
+			Truncated: truncated,
+		},
+	}, nil
+}
+
+// Prune deletes the records older than the retention every hour until the context is done
+func (r *Recorder) Prune(ctx context.Context, cache ctlnetworkv1.NetworkChangeLogCache, retention time.Duration) {
+	wait.UntilWithContext(ctx, func(_ context.Context) {
+		logs, err := cache.List(labels.Everything())
+		if err != nil {
+			logrus.Warnf("failed to list the network change logs, error: %v", err)
+			return
+		}
+		deadline := time.Now().Add(-retention)
+		for _, log := range logs {
+			if !log.CreationTimestamp.Time.Before(deadline) {
+				continue
+			}
+			if err := r.client.Delete(log.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				logrus.Warnf("failed to prune the network change log %s, error: %v", log.Name, err)
+			}
+		}
+	}, pruneInterval)
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/harvester/webhook/pkg/config"
+	"github.com/harvester/webhook/pkg/server/admission"
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/rancher/wrangler/v3/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+func newRequest(kind string, operation admissionv1.Operation) *admission.Request {
+	return admission.NewRequest(&webhook.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Kind: kind},
+			Name:      "vc1",
+			Operation: operation,
+			UserInfo:  authenticationv1.UserInfo{Username: "alice", Groups: []string{"admins"}},
+		},
+	}, &config.Options{})
+}
+
+func newVlanConfig(nics ...string) *networkv1.VlanConfig {
+	return &networkv1.VlanConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc1", ResourceVersion: "1"},
+		Spec: networkv1.VlanConfigSpec{
+			ClusterNetwork: "cn1",
+			Uplink:         networkv1.Uplink{NICs: nics},
+		},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldObj   *networkv1.VlanConfig
+		newObj   *networkv1.VlanConfig
+		expected []string
+	}{
+		{
+			name:     "creation",
+			newObj:   newVlanConfig("eth1"),
+			expected: []string{`spec.clusterNetwork: <none> -> "cn1"`, `spec.uplink.nics: <none> -> ["eth1"]`},
+		},
+		{
+			name:     "update",
+			oldObj:   newVlanConfig("eth1"),
+			newObj:   newVlanConfig("eth1", "eth2"),
+			expected: []string{`spec.uplink.nics: ["eth1"] -> ["eth1","eth2"]`},
+		},
+		{
+			name:   "metadata only",
+			oldObj: newVlanConfig("eth1"),
+			newObj: func() *networkv1.VlanConfig {
+				vc := newVlanConfig("eth1")
+				vc.ResourceVersion = "2"
+				vc.Finalizers = []string{"wrangler"}
+				vc.Annotations = map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"}
+				return vc
+			}(),
+			expected: []string{},
+		},
+		{
+			name:     "deletion",
+			oldObj:   newVlanConfig("eth1"),
+			expected: []string{`spec.clusterNetwork: "cn1" -> <none>`, `spec.uplink.nics: ["eth1"] -> <none>`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			changes, truncated, err := Summarize(tc.oldObj, tc.newObj)
+			assert.NoError(t, err)
+			assert.False(t, truncated)
+			assert.Equal(t, tc.expected, changes)
+		})
+	}
+}
+
+func TestSummarizeNadConfig(t *testing.T) {
+	newNad := func(config string) *nadv1.NetworkAttachmentDefinition {
+		return &nadv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "nad1", Namespace: "default"},
+			Spec:       nadv1.NetworkAttachmentDefinitionSpec{Config: config},
+		}
+	}
+
+	changes, _, err := Summarize(newNad(`{"type":"bridge","vlan":100}`), newNad(`{"type":"bridge","vlan":200}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"spec.config.vlan: 100 -> 200"}, changes)
+}
+
+func TestNewChangeLog(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	log, err := newChangeLog(newRequest("VlanConfig", admissionv1.Update), newVlanConfig("eth1"), newVlanConfig("eth2"), now)
+	assert.NoError(t, err)
+	assert.Equal(t, "vlanconfig-", log.GenerateName)
+	assert.Equal(t, "VlanConfig", log.Labels[utils.KeyChangedKind])
+	assert.Equal(t, networkv1.NetworkChangeLogSpec{
+		Kind:      "VlanConfig",
+		Name:      "vc1",
+		Operation: "UPDATE",
+		User:      "alice",
+		Groups:    []string{"admins"},
+		Timestamp: metav1.NewTime(now),
+		Changes:   []string{`spec.uplink.nics: ["eth1"] -> ["eth2"]`},
+	}, log.Spec)
+
+	// an update changing none of the audited fields is not recorded
+	log, err = newChangeLog(newRequest("VlanConfig", admissionv1.Update), newVlanConfig("eth1"), newVlanConfig("eth1"), now)
+	assert.NoError(t, err)
+	assert.Nil(t, log)
+}
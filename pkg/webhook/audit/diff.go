@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	maxChanges     = 50
+	maxValueLength = 128
+	none           = "<none>"
+)
+
+// the annotations copying the whole object are left out of the summary
+var ignoredAnnotations = []string{"kubectl.kubernetes.io/last-applied-configuration"}
+
+// Summarize returns the changed fields of the spec, labels and annotations between the objects, one field per entry in
+// the form of "<path>: <old> -> <new>", and whether the summary is cut short. Either object can be nil for a creation
+// or a deletion. The JSON strings, e.g. the config of a NAD, are compared field by field.
+func Summarize(oldObj, newObj runtime.Object) ([]string, bool, error) {
+	oldFields, err := auditedFields(oldObj)
+	if err != nil {
+		return nil, false, err
+	}
+	newFields, err := auditedFields(newObj)
+	if err != nil {
+		return nil, false, err
+	}
+
+	changes := make([]string, 0)
+	diff("", oldFields, newFields, &changes)
+	if len(changes) > maxChanges {
+		return changes[:maxChanges], true, nil
+	}
+
+	return changes, false, nil
+}
+
+func auditedFields(obj runtime.Object) (map[string]interface{}, error) {
+	if obj == nil || reflect.ValueOf(obj).IsNil() {
+		return nil, nil
+	}
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]interface{}{}
+	if meta, ok := m["metadata"].(map[string]interface{}); ok {
+		metadata["labels"] = meta["labels"]
+		if annotations, ok := meta["annotations"].(map[string]interface{}); ok {
+			for _, key := range ignoredAnnotations {
+				delete(annotations, key)
+			}
+			metadata["annotations"] = annotations
+		}
+	}
+
+	return map[string]interface{}{"metadata": metadata, "spec": m["spec"]}, nil
+}
+
+func diff(path string, oldValue, newValue interface{}, changes *[]string) {
+	oldValue, newValue = expandJSON(oldValue), expandJSON(newValue)
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if (oldIsMap || oldValue == nil) && (newIsMap || newValue == nil) && (oldIsMap || newIsMap) {
+		keys := make([]string, 0, len(oldMap)+len(newMap))
+		for k := range oldMap {
+			keys = append(keys, k)
+		}
+		for k := range newMap {
+			if _, ok := oldMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diff(strings.TrimPrefix(path+"."+k, "."), oldMap[k], newMap[k], changes)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(oldValue, newValue) {
+		return
+	}
+	*changes = append(*changes, fmt.Sprintf("%s: %s -> %s", path, format(oldValue), format(newValue)))
+}
+
+// expandJSON returns the object encoded in the string, or the value as it is
+func expandJSON(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok || !strings.HasPrefix(strings.TrimSpace(s), "{") {
+		return v
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return v
+	}
+
+	return m
+}
+
+func format(v interface{}) string {
+	if v == nil {
+		return none
+	}
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	if len(bytes) > maxValueLength {
+		return string(bytes[:maxValueLength]) + "..."
+	}
+
+	return string(bytes)
+}
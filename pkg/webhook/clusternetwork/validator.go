@@ -2,13 +2,16 @@ package clusternetwork
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/harvester/webhook/pkg/server/admission"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	ctlkubevirtv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubevirt.io/v1"
@@ -28,15 +31,20 @@ type CnValidator struct {
 	nadCache ctlcniv1.NetworkAttachmentDefinitionCache
 	vmiCache ctlkubevirtv1.VirtualMachineInstanceCache
 	vcCache  ctlnetworkv1.VlanConfigCache
+	vsCache  ctlnetworkv1.VlanStatusCache
+	cnCache  ctlnetworkv1.ClusterNetworkCache
 }
 
 var _ admission.Validator = &CnValidator{}
 
-func NewCnValidator(nadCache ctlcniv1.NetworkAttachmentDefinitionCache, vmiCache ctlkubevirtv1.VirtualMachineInstanceCache, vcCache ctlnetworkv1.VlanConfigCache) *CnValidator {
+func NewCnValidator(nadCache ctlcniv1.NetworkAttachmentDefinitionCache, vmiCache ctlkubevirtv1.VirtualMachineInstanceCache, vcCache ctlnetworkv1.VlanConfigCache,
+	vsCache ctlnetworkv1.VlanStatusCache, cnCache ctlnetworkv1.ClusterNetworkCache) *CnValidator {
 	validator := &CnValidator{
 		nadCache: nadCache,
 		vmiCache: vmiCache,
 		vcCache:  vcCache,
+		vsCache:  vsCache,
+		cnCache:  cnCache,
 	}
 	return validator
 }
@@ -52,6 +60,38 @@ func (c *CnValidator) Create(_ *admission.Request, newObj runtime.Object) error
 		return fmt.Errorf(createErr, cn.Name, err)
 	}
 
+	if err := checkUplinkDefaults(cn); err != nil {
+		return fmt.Errorf(createErr, cn.Name, err)
+	}
+
+	if err := c.checkMaxMTU(nil, cn); err != nil {
+		return fmt.Errorf(createErr, cn.Name, err)
+	}
+
+	if err := checkMulticast(cn); err != nil {
+		return fmt.Errorf(createErr, cn.Name, err)
+	}
+
+	if err := checkStaticVIDs(cn); err != nil {
+		return fmt.Errorf(createErr, cn.Name, err)
+	}
+
+	if err := c.checkQoS(cn); err != nil {
+		return fmt.Errorf(createErr, cn.Name, err)
+	}
+
+	if err := c.checkOverlay(nil, cn); err != nil {
+		return fmt.Errorf(createErr, cn.Name, err)
+	}
+
+	if err := checkDatapath(nil, cn); err != nil {
+		return fmt.Errorf(createErr, cn.Name, err)
+	}
+
+	if err := checkUntaggedVID(cn); err != nil {
+		return fmt.Errorf(createErr, cn.Name, err)
+	}
+
 	return nil
 }
 
@@ -72,30 +112,267 @@ func (c *CnValidator) Update(_ *admission.Request, oldObj, newObj runtime.Object
 		return fmt.Errorf(updateErr, newCn.Name, err)
 	}
 
+	if err := checkUplinkDefaults(newCn); err != nil {
+		return fmt.Errorf(updateErr, newCn.Name, err)
+	}
+
+	if err := checkMulticast(newCn); err != nil {
+		return fmt.Errorf(updateErr, newCn.Name, err)
+	}
+
+	if err := checkStaticVIDs(newCn); err != nil {
+		return fmt.Errorf(updateErr, newCn.Name, err)
+	}
+
+	if err := c.checkMaxVIDs(oldCn, newCn); err != nil {
+		return fmt.Errorf(updateErr, newCn.Name, err)
+	}
+
+	if err := c.checkMaxMTU(oldCn, newCn); err != nil {
+		return fmt.Errorf(updateErr, newCn.Name, err)
+	}
+
+	if err := c.checkQoS(newCn); err != nil {
+		return fmt.Errorf(updateErr, newCn.Name, err)
+	}
+
+	if err := c.checkOverlay(oldCn, newCn); err != nil {
+		return fmt.Errorf(updateErr, newCn.Name, err)
+	}
+
+	if err := checkDatapath(oldCn, newCn); err != nil {
+		return fmt.Errorf(updateErr, newCn.Name, err)
+	}
+
+	if err := checkUntaggedVID(newCn); err != nil {
+		return fmt.Errorf(updateErr, newCn.Name, err)
+	}
+
+	if err := c.checkAgentFeatures(oldCn, newCn); err != nil {
+		return fmt.Errorf(updateErr, newCn.Name, err)
+	}
+
 	return nil
 }
 
-func (c *CnValidator) Delete(_ *admission.Request, oldObj runtime.Object) error {
-	cn := oldObj.(*networkv1.ClusterNetwork)
+// checkAgentFeatures rejects the features newly used by the cluster network if the agent of any node having the
+// cluster network doesn't understand them yet, instead of letting the agent ignore them silently. A new cluster network
+// has no node yet, its features are checked when its vlanconfigs are created.
+func (c *CnValidator) checkAgentFeatures(oldCn, newCn *networkv1.ClusterNetwork) error {
+	required := utils.MissingFeatures(utils.ClusterNetworkFeatures(newCn), utils.ClusterNetworkFeatures(oldCn))
+	if len(required) == 0 {
+		return nil
+	}
 
-	if cn.Name == utils.ManagementClusterNetworkName {
-		return fmt.Errorf(deleteErr, cn.Name, fmt.Errorf("it is not allowed"))
+	vss, err := c.vsCache.List(labels.Set{utils.KeyClusterNetworkLabel: newCn.Name}.AsSelector())
+	if err != nil {
+		return err
+	}
+	lagging := make([]string, 0)
+	missing := mapset.NewSet[string]()
+	for _, vs := range vss {
+		if m := utils.MissingFeatures(required, vs.Status.Features); len(m) > 0 {
+			lagging = append(lagging, vs.Status.Node)
+			missing.Append(m...)
+		}
+	}
+	if len(lagging) == 0 {
+		return nil
 	}
 
-	// all related vcs should be deleted/migrated to others
-	vcs, err := c.vcCache.List(labels.Set{
-		utils.KeyClusterNetworkLabel: cn.Name,
-	}.AsSelector())
+	sort.Strings(lagging)
+	features := missing.ToSlice()
+	sort.Strings(features)
+	return fmt.Errorf("the agents on nodes %v don't understand the features %v yet, upgrade them first", lagging, features)
+}
+
+// checkMaxVIDs rejects lowering the cap of the VLAN IDs below the VLAN IDs the NADs of the cluster network already have
+func (c *CnValidator) checkMaxVIDs(oldCn, newCn *networkv1.ClusterNetwork) error {
+	if utils.MaxVIDsOf(newCn) >= utils.MaxVIDsOf(oldCn) {
+		return nil
+	}
+
+	nads, err := utils.NewNadGetter(c.nadCache).ListNadsOnClusterNetwork(newCn.Name)
 	if err != nil {
-		return fmt.Errorf(deleteErr, cn.Name, fmt.Errorf("failed to list vlanconfig error %w", err))
+		return err
 	}
 
-	if len(vcs) > 0 {
-		vcNameList := make([]string, 0, len(vcs))
-		for _, vc := range vcs {
-			vcNameList = append(vcNameList, vc.Name)
+	return utils.CheckVIDCount(newCn, nads)
+}
+
+// checkMaxMTU rejects a maximum MTU below the default MTU of the uplinks, and, when it's set or lowered, below the MTU
+// the vlanconfigs of the cluster network already have
+func (c *CnValidator) checkMaxMTU(oldCn, newCn *networkv1.ClusterNetwork) error {
+	maxMTU := newCn.Spec.MaxMTU
+	if maxMTU == 0 {
+		return nil
+	}
+	if !utils.IsValidMTU(maxMTU) {
+		lower, upper := utils.MTUBounds()
+		return fmt.Errorf("the maximum MTU %d is not in range [0, %d..%d]", maxMTU, lower, upper)
+	}
+	if newCn.Name == utils.ManagementClusterNetworkName {
+		return fmt.Errorf("the maximum MTU is not allowed on the cluster network %s", utils.ManagementClusterNetworkName)
+	}
+	if defaults := newCn.Spec.UplinkDefaults; defaults != nil && defaults.LinkAttrs != nil && defaults.LinkAttrs.MTU != 0 {
+		if err := utils.CheckMaxMTU(newCn, defaults.LinkAttrs.MTU, "the uplink defaults"); err != nil {
+			return err
+		}
+	}
+	if oldCn == nil || (oldCn.Spec.MaxMTU != 0 && maxMTU >= oldCn.Spec.MaxMTU) {
+		return nil
+	}
+
+	vcs, err := c.vcCache.List(labels.Set(map[string]string{
+		utils.KeyClusterNetworkLabel: newCn.Name,
+	}).AsSelector())
+	if err != nil {
+		return err
+	}
+	for _, vc := range vcs {
+		if vc.DeletionTimestamp != nil {
+			continue
+		}
+		if err := utils.CheckMaxMTU(newCn, utils.GetMTUFromVlanConfig(vc), "vlanconfig "+vc.Name); err != nil {
+			return err
+		}
+		for i := range vc.Spec.TopologyOverrides {
+			override := &vc.Spec.TopologyOverrides[i]
+			if override.MTU == 0 {
+				continue
+			}
+			if err := utils.CheckMaxMTU(newCn, override.MTU, fmt.Sprintf("topology %s of vlanconfig %s",
+				utils.TopologyKeyOf(override), vc.Name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkQoS rejects prioritizing a second cluster network, the prioritized traffic is only protected while it's the
+// only one transmitted first
+func (c *CnValidator) checkQoS(cn *networkv1.ClusterNetwork) error {
+	if !utils.IsPrioritized(cn) {
+		return nil
+	}
+
+	cns, err := c.cnCache.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, other := range cns {
+		if other.Name != cn.Name && other.DeletionTimestamp == nil && utils.IsPrioritized(other) {
+			return fmt.Errorf("the cluster network %s is already prioritized, only one cluster network can have the "+
+				"QoS priority %s", other.Name, networkv1.QoSPriorityHigh)
 		}
-		return fmt.Errorf(deleteErr, cn.Name, fmt.Errorf("vlanconfig(s) %v under this clusternetwork are still existing", vcNameList))
+	}
+
+	return nil
+}
+
+// checkOverlay rejects changing the type of a cluster network, as the bridges of the nodes would lose their uplinks,
+// and a VNI taken by another overlay, the VTEPs of both would deliver the traffic of one to the other
+func (c *CnValidator) checkOverlay(oldCn, newCn *networkv1.ClusterNetwork) error {
+	if oldCn != nil && utils.IsOverlay(oldCn) != utils.IsOverlay(newCn) {
+		return fmt.Errorf("the type of the cluster network is immutable")
+	}
+	if !utils.IsOverlay(newCn) {
+		if newCn.Spec.VXLAN != nil {
+			return fmt.Errorf("the VXLAN options require the type %s", networkv1.ClusterNetworkTypeVXLAN)
+		}
+		return nil
+	}
+
+	if newCn.Name == utils.ManagementClusterNetworkName {
+		return fmt.Errorf("the cluster network %s can't be of type %s", utils.ManagementClusterNetworkName,
+			networkv1.ClusterNetworkTypeVXLAN)
+	}
+	if newCn.Spec.UplinkDefaults != nil {
+		return fmt.Errorf("the uplink defaults are not allowed on a cluster network of type %s, the VTEP is the uplink",
+			networkv1.ClusterNetworkTypeVXLAN)
+	}
+	opts := newCn.Spec.VXLAN
+	if opts == nil {
+		return fmt.Errorf("the type %s requires the VXLAN options", networkv1.ClusterNetworkTypeVXLAN)
+	}
+	if opts.VNI < 1 || opts.VNI > 16777215 {
+		return fmt.Errorf("the VNI %d is not in range [1, 16777215]", opts.VNI)
+	}
+	if opts.Port < 0 || opts.Port > 65535 {
+		return fmt.Errorf("the VXLAN port %d is not in range [0, 65535]", opts.Port)
+	}
+
+	cns, err := c.cnCache.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, other := range cns {
+		if other.Name != newCn.Name && utils.IsOverlay(other) && other.Spec.VXLAN != nil && other.Spec.VXLAN.VNI == opts.VNI {
+			return fmt.Errorf("the VNI %d is already used by cluster network %s", opts.VNI, other.Name)
+		}
+	}
+
+	return nil
+}
+
+// checkDatapath rejects changing the datapath, the bridges of the nodes would have to be rebuilt under the VMs, and
+// the datapaths other than the VLAN filtering where they don't apply. The names of the links per VID leave less room
+// for the cluster network.
+func checkDatapath(oldCn, newCn *networkv1.ClusterNetwork) error {
+	dp := datapathOf(newCn)
+	if oldCn != nil && datapathOf(oldCn) != dp {
+		return fmt.Errorf("the datapath of the cluster network is immutable")
+	}
+	if dp == networkv1.DatapathVLANFiltering {
+		return nil
+	}
+
+	if newCn.Name == utils.ManagementClusterNetworkName {
+		return fmt.Errorf("the cluster network %s can't have the datapath %s", utils.ManagementClusterNetworkName, dp)
+	}
+	if utils.IsOverlay(newCn) {
+		return fmt.Errorf("the datapath %s is not supported by a cluster network of type %s", dp,
+			networkv1.ClusterNetworkTypeVXLAN)
+	}
+	// the OVS bridge is named after the cluster network alone
+	if !utils.IsOVS(newCn) && len(newCn.Name) > utils.MaxBridgePerVIDClusterNetworkNameLen {
+		return fmt.Errorf("the length of the name is more than %d with the datapath %s",
+			utils.MaxBridgePerVIDClusterNetworkNameLen, dp)
+	}
+	if newCn.Spec.Multicast != nil && len(newCn.Spec.Multicast.VLANs) != 0 {
+		return fmt.Errorf("the multicast options per VLAN are not supported by the datapath %s", dp)
+	}
+	// the multicast and neighbor options are applied to the bridge
+	if utils.IsBridgeless(newCn) && (newCn.Spec.Multicast != nil || newCn.Spec.Neighbor != nil) {
+		return fmt.Errorf("the multicast and neighbor options are not supported by the datapath %s without a bridge", dp)
+	}
+	if utils.IsOVS(newCn) && (newCn.Spec.Multicast != nil || newCn.Spec.Neighbor != nil) {
+		return fmt.Errorf("the multicast and neighbor options of the Linux bridge are not supported by the datapath %s", dp)
+	}
+
+	return nil
+}
+
+// datapathOf returns the datapath of the cluster network, the VLAN filtering if it's omitted
+func datapathOf(cn *networkv1.ClusterNetwork) networkv1.Datapath {
+	if cn.Spec.Datapath == "" {
+		return networkv1.DatapathVLANFiltering
+	}
+	return cn.Spec.Datapath
+}
+
+func (c *CnValidator) Delete(_ *admission.Request, oldObj runtime.Object) error {
+	cn := oldObj.(*networkv1.ClusterNetwork)
+
+	if cn.Name == utils.ManagementClusterNetworkName {
+		return fmt.Errorf(deleteErr, cn.Name, fmt.Errorf("it is not allowed"))
+	}
+
+	// the vlanconfigs of a cluster network with a deletion grace period are kept in the period and deleted afterwards
+	if err := c.checkVlanConfigsOfDeletedClusterNetwork(cn); err != nil {
+		return fmt.Errorf(deleteErr, cn.Name, err)
 	}
 
 	// all related nads should be deleted
@@ -128,13 +405,111 @@ func (c *CnValidator) Resource() admission.Resource {
 	}
 }
 
+// checkUplinkDefaults validates the uplink defaults, the rest of the settings are validated on the vlanconfigs inheriting them
+func checkUplinkDefaults(cn *networkv1.ClusterNetwork) error {
+	defaults := cn.Spec.UplinkDefaults
+	if defaults == nil {
+		return nil
+	}
+
+	if cn.Name == utils.ManagementClusterNetworkName {
+		return fmt.Errorf("uplink defaults are not allowed on the cluster network %s", utils.ManagementClusterNetworkName)
+	}
+	if defaults.LinkAttrs != nil && !utils.IsValidMTU(defaults.LinkAttrs.MTU) {
+		minMTU, maxMTU := utils.MTUBounds()
+		return fmt.Errorf("the default MTU %d is not in range [0, %d..%d]", defaults.LinkAttrs.MTU, minMTU, maxMTU)
+	}
+	// every bond needs its own hardware address
+	if defaults.LinkAttrs != nil && len(defaults.LinkAttrs.HardwareAddr) != 0 {
+		return fmt.Errorf("the hardware address can't be a default of the uplinks")
+	}
+	if err := utils.CheckXmitHashPolicy(defaults.BondOptions); err != nil {
+		return fmt.Errorf("the default bond options are invalid: %w", err)
+	}
+	if err := utils.CheckLacpRate(defaults.BondOptions); err != nil {
+		return fmt.Errorf("the default bond options are invalid: %w", err)
+	}
+	if err := utils.CheckArpMonitor(defaults.BondOptions); err != nil {
+		return fmt.Errorf("the default bond options are invalid: %w", err)
+	}
+
+	return nil
+}
+
+// checkMulticast rejects the querier without snooping, which the bridge accepts but never sends any query
+func checkMulticast(cn *networkv1.ClusterNetwork) error {
+	multicast := cn.Spec.Multicast
+	if multicast == nil {
+		return nil
+	}
+
+	if multicast.Querier && !multicast.Snooping {
+		return fmt.Errorf("the multicast querier requires snooping")
+	}
+	vids := make(map[uint16]bool, len(multicast.VLANs))
+	for _, v := range multicast.VLANs {
+		if v.VID < 1 || v.VID > utils.MaxVlanID {
+			return fmt.Errorf("the multicast VID %d is not in range [1..%d]", v.VID, utils.MaxVlanID)
+		}
+		if vids[v.VID] {
+			return fmt.Errorf("the multicast VID %d is duplicated", v.VID)
+		}
+		vids[v.VID] = true
+		if v.Querier && !v.Snooping {
+			return fmt.Errorf("the multicast querier of VID %d requires snooping", v.VID)
+		}
+	}
+
+	return nil
+}
+
+// checkStaticVIDs rejects the static VLAN IDs out of range or duplicated
+func checkStaticVIDs(cn *networkv1.ClusterNetwork) error {
+	vids := make(map[uint16]bool, len(cn.Spec.StaticVIDs))
+	for _, vid := range cn.Spec.StaticVIDs {
+		if vid < 1 || vid > utils.MaxVlanID {
+			return fmt.Errorf("the static VID %d is not in range [1..%d]", vid, utils.MaxVlanID)
+		}
+		if vids[vid] {
+			return fmt.Errorf("the static VID %d is duplicated", vid)
+		}
+		vids[vid] = true
+	}
+
+	return nil
+}
+
+// checkUntaggedVID rejects the untagged VLAN ID out of range, and on the cluster networks whose untagged traffic isn't
+// on a VLAN of a VLAN filtering bridge
+func checkUntaggedVID(cn *networkv1.ClusterNetwork) error {
+	vid := cn.Spec.UntaggedVID
+	if vid == 0 || vid == utils.DefaultVlanID {
+		return nil
+	}
+	if vid > utils.MaxVlanID {
+		return fmt.Errorf("the untagged VID %d is not in range [1..%d]", vid, utils.MaxVlanID)
+	}
+
+	switch {
+	case cn.Name == utils.ManagementClusterNetworkName:
+		return fmt.Errorf("the cluster network %s can't have the untagged VID, the host network is untagged on it",
+			utils.ManagementClusterNetworkName)
+	case utils.IsOverlay(cn):
+		return fmt.Errorf("the untagged VID is not supported by a cluster network of type %s", networkv1.ClusterNetworkTypeVXLAN)
+	case utils.IsBridgePerVID(cn), utils.IsBridgeless(cn), utils.IsOVS(cn):
+		return fmt.Errorf("the untagged VID is not supported by the datapath %s", cn.Spec.Datapath)
+	}
+
+	return nil
+}
+
 func checkMTUOfNewClusterNetwork(cn *networkv1.ClusterNetwork) error {
 	if cn == nil || cn.Annotations == nil {
 		return nil
 	}
 
 	// for non-mgmt cluster network, this annotation can only be operated by controller
-	if _, ok := cn.Annotations[utils.KeyUplinkMTU]; ok {
+	if _, ok := annotations.UplinkMTU.Get(cn); ok {
 		return fmt.Errorf("annotation %v can't be added", utils.KeyUplinkMTU)
 	}
 	return nil
@@ -148,7 +523,7 @@ func (c *CnValidator) checkMTUOfUpdatedClusterNetwork(oldCn, newCn *networkv1.Cl
 
 	newMtu := utils.DefaultMTU
 	var err error
-	if mtuStr, ok := newCn.Annotations[utils.KeyUplinkMTU]; ok {
+	if mtuStr, ok := annotations.UplinkMTU.Get(newCn); ok {
 		if newMtu, err = utils.GetMTUFromString(mtuStr); err != nil {
 			return err
 		}
@@ -187,14 +562,14 @@ func (c *CnValidator) checkMTUOfUpdatedMgmtClusterNetwork(oldCn, newCn *networkv
 	// mgmt network, MTU can be updated
 	newMtu := utils.DefaultMTU
 	var err error
-	if mtuStr, ok := newCn.Annotations[utils.KeyUplinkMTU]; ok {
+	if mtuStr, ok := annotations.UplinkMTU.Get(newCn); ok {
 		if newMtu, err = utils.GetMTUFromString(mtuStr); err != nil {
 			return err
 		}
 	}
 
 	oldMtu := utils.DefaultMTU
-	if mtuStr, ok := oldCn.Annotations[utils.KeyUplinkMTU]; ok {
+	if mtuStr, ok := annotations.UplinkMTU.Get(oldCn); ok {
 		if oldMtu, err = utils.GetMTUFromString(mtuStr); err != nil {
 			return err
 		}
@@ -226,3 +601,27 @@ func (c *CnValidator) checkMTUOfUpdatedMgmtClusterNetwork(oldCn, newCn *networkv
 
 	return nil
 }
+
+func (c *CnValidator) checkVlanConfigsOfDeletedClusterNetwork(cn *networkv1.ClusterNetwork) error {
+	if cn.Spec.DeletionGracePeriodSeconds > 0 {
+		return nil
+	}
+
+	// all related vcs should be deleted/migrated to others
+	vcs, err := c.vcCache.List(labels.Set{
+		utils.KeyClusterNetworkLabel: cn.Name,
+	}.AsSelector())
+	if err != nil {
+		return fmt.Errorf("failed to list vlanconfig error %w", err)
+	}
+
+	if len(vcs) > 0 {
+		vcNameList := make([]string, 0, len(vcs))
+		for _, vc := range vcs {
+			vcNameList = append(vcNameList, vc.Name)
+		}
+		return fmt.Errorf("vlanconfig(s) %v under this clusternetwork are still existing", vcNameList)
+	}
+
+	return nil
+}
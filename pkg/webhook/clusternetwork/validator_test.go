@@ -1,6 +1,7 @@
 package clusternetwork
 
 import (
+	"net"
 	"strings"
 	"testing"
 
@@ -66,6 +67,395 @@ func TestCreateClusterNetwork(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "ClusterNetwork can't be created as the default MTU is out of range",
+			returnErr: true,
+			errKey:    "the default MTU",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					UplinkDefaults: &networkv1.UplinkDefaults{
+						LinkAttrs: &networkv1.LinkAttrs{MTU: 100},
+					},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created with a default hardware address",
+			returnErr: true,
+			errKey:    "hardware address",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					UplinkDefaults: &networkv1.UplinkDefaults{
+						LinkAttrs: &networkv1.LinkAttrs{HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 1}},
+					},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created with a default xmit hash policy in the balance-rr mode",
+			returnErr: true,
+			errKey:    "meaningless in the bond mode balance-rr",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					UplinkDefaults: &networkv1.UplinkDefaults{
+						BondOptions: &networkv1.BondOptions{Mode: networkv1.BondModeBalanceRr, XmitHashPolicy: networkv1.XmitHashPolicyLayer34},
+					},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created with a default LACP rate in the active-backup mode",
+			returnErr: true,
+			errKey:    "meaningless in the bond mode active-backup",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					UplinkDefaults: &networkv1.UplinkDefaults{
+						BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMoDeActiveBackup, LacpRate: networkv1.LacpRateFast},
+					},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created as the multicast querier is enabled without snooping",
+			returnErr: true,
+			errKey:    "querier requires snooping",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Multicast: &networkv1.MulticastOptions{Querier: true},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created as the multicast VID is duplicated",
+			returnErr: true,
+			errKey:    "duplicated",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Multicast: &networkv1.MulticastOptions{
+						Snooping: true,
+						VLANs:    []networkv1.VlanMulticastOptions{{VID: 10}, {VID: 10, Snooping: true}},
+					},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can be created with multicast querier on a VLAN",
+			returnErr: false,
+			errKey:    "",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Multicast: &networkv1.MulticastOptions{
+						Snooping: true,
+						VLANs:    []networkv1.VlanMulticastOptions{{VID: 10, Snooping: true, Querier: true}},
+					},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created as the static VID is out of range",
+			returnErr: true,
+			errKey:    "static VID",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					StaticVIDs: []uint16{10, 4095},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created as the static VID is duplicated",
+			returnErr: true,
+			errKey:    "duplicated",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					StaticVIDs: []uint16{10, 20, 10},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can be created with static VIDs",
+			returnErr: false,
+			errKey:    "",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					StaticVIDs: []uint16{10, 20},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can be created with the datapath BridgePerVID",
+			returnErr: false,
+			errKey:    "",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Datapath: networkv1.DatapathBridgePerVID,
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created with the datapath BridgePerVID as the name is too long",
+			returnErr: true,
+			errKey:    "length of the name",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-cn-long",
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Datapath: networkv1.DatapathBridgePerVID,
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created with the datapath BridgePerVID and multicast VLANs",
+			returnErr: true,
+			errKey:    "multicast options per VLAN",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Datapath:  networkv1.DatapathBridgePerVID,
+					Multicast: &networkv1.MulticastOptions{VLANs: []networkv1.VlanMulticastOptions{{VID: 100}}},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can be created with the datapath Macvlan",
+			returnErr: false,
+			errKey:    "",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Datapath: networkv1.DatapathMacvlan,
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created with the datapath Ipvlan and the neighbor options",
+			returnErr: true,
+			errKey:    "without a bridge",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Datapath: networkv1.DatapathIpvlan,
+					Neighbor: &networkv1.NeighborOptions{},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created with the datapath Macvlan and type VXLAN",
+			returnErr: true,
+			errKey:    "not supported by a cluster network of type",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Type:     networkv1.ClusterNetworkTypeVXLAN,
+					VXLAN:    &networkv1.VXLANOptions{VNI: 100},
+					Datapath: networkv1.DatapathMacvlan,
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork with a long name can be created with the datapath OVS",
+			returnErr: false,
+			errKey:    "",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-cn-ovs",
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Datapath: networkv1.DatapathOVS,
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created with the datapath OVS and the multicast options",
+			returnErr: true,
+			errKey:    "options of the Linux bridge are not supported by the datapath OVS",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Datapath:  networkv1.DatapathOVS,
+					Multicast: &networkv1.MulticastOptions{},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can be created with the untagged VID",
+			returnErr: false,
+			errKey:    "",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					UntaggedVID: 100,
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created with the untagged VID and the datapath BridgePerVID",
+			returnErr: true,
+			errKey:    "untagged VID is not supported",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Datapath:    networkv1.DatapathBridgePerVID,
+					UntaggedVID: 100,
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can be created with uplink defaults",
+			returnErr: false,
+			errKey:    "",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					UplinkDefaults: &networkv1.UplinkDefaults{
+						LinkAttrs:   &networkv1.LinkAttrs{MTU: 9000, TxQLen: -1},
+						BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: 100},
+					},
+				},
+			},
+		}, {
+			name:      "ClusterNetwork can be prioritized",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: "vm"},
+				Spec:       networkv1.ClusterNetworkSpec{QoS: &networkv1.QoSOptions{Priority: networkv1.QoSPriorityNormal}},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec:       networkv1.ClusterNetworkSpec{QoS: &networkv1.QoSOptions{Priority: networkv1.QoSPriorityHigh}},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created as the default MTU exceeds the maximum MTU",
+			returnErr: true,
+			errKey:    "exceeds the maximum MTU 1500",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec: networkv1.ClusterNetworkSpec{
+					MaxMTU:         1500,
+					UplinkDefaults: &networkv1.UplinkDefaults{LinkAttrs: &networkv1.LinkAttrs{MTU: 9000}},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be prioritized as another one is prioritized",
+			returnErr: true,
+			errKey:    "is already prioritized",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: "storage"},
+				Spec:       networkv1.ClusterNetworkSpec{QoS: &networkv1.QoSOptions{Priority: networkv1.QoSPriorityHigh}},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec:       networkv1.ClusterNetworkSpec{QoS: &networkv1.QoSOptions{Priority: networkv1.QoSPriorityHigh}},
+			},
+		},
+		{
+			name:      "ClusterNetwork of type VXLAN can be created",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: "overlay"},
+				Spec: networkv1.ClusterNetworkSpec{
+					Type:  networkv1.ClusterNetworkTypeVXLAN,
+					VXLAN: &networkv1.VXLANOptions{VNI: 100},
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec: networkv1.ClusterNetworkSpec{
+					Type:  networkv1.ClusterNetworkTypeVXLAN,
+					VXLAN: &networkv1.VXLANOptions{VNI: 200},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork of type VXLAN can't be created without the VXLAN options",
+			returnErr: true,
+			errKey:    "requires the VXLAN options",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec:       networkv1.ClusterNetworkSpec{Type: networkv1.ClusterNetworkTypeVXLAN},
+			},
+		},
+		{
+			name:      "ClusterNetwork of type VXLAN can't be created as the VNI is used",
+			returnErr: true,
+			errKey:    "the VNI 100 is already used by cluster network overlay",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: "overlay"},
+				Spec: networkv1.ClusterNetworkSpec{
+					Type:  networkv1.ClusterNetworkTypeVXLAN,
+					VXLAN: &networkv1.VXLANOptions{VNI: 100},
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec: networkv1.ClusterNetworkSpec{
+					Type:  networkv1.ClusterNetworkTypeVXLAN,
+					VXLAN: &networkv1.VXLANOptions{VNI: 100, Port: 8472},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork of type VLAN can't be created with the VXLAN options",
+			returnErr: true,
+			errKey:    "the VXLAN options require the type VXLAN",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec:       networkv1.ClusterNetworkSpec{VXLAN: &networkv1.VXLANOptions{VNI: 100}},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -79,6 +469,8 @@ func TestCreateClusterNetwork(t *testing.T) {
 			nadCache := fakeclients.NetworkAttachmentDefinitionCache(nchclientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions)
 			vmiCache := fakeclients.VirtualMachineInstanceCache(nchclientset.KubevirtV1().VirtualMachineInstances)
 			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
+			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
 			// client to inject test data
 			cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
 			vcClient := fakeclients.VlanConfigClient(nchclientset.NetworkV1beta1().VlanConfigs)
@@ -91,7 +483,7 @@ func TestCreateClusterNetwork(t *testing.T) {
 				_, err := cnClient.Create(tc.currentCN)
 				assert.NoError(t, err)
 			}
-			validator := NewCnValidator(nadCache, vmiCache, vcCache)
+			validator := NewCnValidator(nadCache, vmiCache, vcCache, vsCache, cnCache)
 			err := validator.Create(nil, tc.newCN)
 			assert.True(t, tc.returnErr == (err != nil))
 			if tc.returnErr {
@@ -109,8 +501,24 @@ func TestUpdateClusterNetwork(t *testing.T) {
 		errKey    string
 		currentCN *networkv1.ClusterNetwork
 		currentVC *networkv1.VlanConfig
+		currentVS *networkv1.VlanStatus
 		newCN     *networkv1.ClusterNetwork
 	}{
+		{
+			name:      "ClusterNetwork can't be changed to type VXLAN",
+			returnErr: true,
+			errKey:    "the type of the cluster network is immutable",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec: networkv1.ClusterNetworkSpec{
+					Type:  networkv1.ClusterNetworkTypeVXLAN,
+					VXLAN: &networkv1.VXLANOptions{VNI: 100},
+				},
+			},
+		},
 		{
 			name:      "ClusterNetwork can be updated",
 			returnErr: false,
@@ -145,6 +553,53 @@ func TestUpdateClusterNetwork(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "ClusterNetwork can't lower the maximum MTU below the MTU of a vlanconfig",
+			returnErr: true,
+			errKey:    "the MTU 9000 of vlanconfig vc1 exceeds the maximum MTU 1500",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{utils.KeyUplinkMTU: "9000"},
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "vc1",
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink:         networkv1.Uplink{LinkAttrs: &networkv1.LinkAttrs{MTU: 9000}},
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{utils.KeyUplinkMTU: "9000"},
+				},
+				Spec: networkv1.ClusterNetworkSpec{MaxMTU: 1500},
+			},
+		},
+		{
+			name:      "ClusterNetwork can set the maximum MTU above the MTU of the vlanconfigs",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "vc1",
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{ClusterNetwork: testCnName},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec:       networkv1.ClusterNetworkSpec{MaxMTU: 1500},
+			},
+		},
 		{
 			name:      "ClusterNetwork mgmt can't be changed as new MTU annotation is not in range",
 			returnErr: true,
@@ -162,6 +617,45 @@ func TestUpdateClusterNetwork(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "ClusterNetwork can't change its datapath",
+			returnErr: true,
+			errKey:    "immutable",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Datapath: networkv1.DatapathBridgePerVID,
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't change its datapath between Macvlan and Ipvlan",
+			returnErr: true,
+			errKey:    "immutable",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Datapath: networkv1.DatapathMacvlan,
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Datapath: networkv1.DatapathIpvlan,
+				},
+			},
+		},
 		{
 			name:      "ClusterNetwork mgmt can't be changed as new MTU annotation is invalid",
 			returnErr: true,
@@ -179,6 +673,73 @@ func TestUpdateClusterNetwork(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "ClusterNetwork mgmt can't have the untagged VID",
+			returnErr: true,
+			errKey:    "host network is untagged",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.ManagementClusterNetworkName,
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.ManagementClusterNetworkName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					UntaggedVID: 100,
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't enable multicast as the agent of a node doesn't understand it",
+			returnErr: true,
+			errKey:    "the agents on nodes [node1] don't understand the features [multicast]",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+			},
+			currentVS: &networkv1.VlanStatus{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   utils.Name("", testCnName, "node1"),
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Status: networkv1.VlStatus{
+					ClusterNetwork: testCnName,
+					Node:           "node1",
+					Features:       []string{utils.FeatureSharedBond},
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec: networkv1.ClusterNetworkSpec{
+					Multicast: &networkv1.MulticastOptions{Snooping: true},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can enable multicast as the agents of its nodes understand it",
+			returnErr: false,
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+			},
+			currentVS: &networkv1.VlanStatus{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   utils.Name("", testCnName, "node1"),
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Status: networkv1.VlStatus{
+					ClusterNetwork: testCnName,
+					Node:           "node1",
+					Features:       utils.AgentFeatures,
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec: networkv1.ClusterNetworkSpec{
+					Multicast: &networkv1.MulticastOptions{Snooping: true},
+				},
+			},
+		},
 		{
 			name:      "ClusterNetwork mgmt can be changed with new valid MTU annotation",
 			returnErr: false,
@@ -209,18 +770,25 @@ func TestUpdateClusterNetwork(t *testing.T) {
 			nadCache := fakeclients.NetworkAttachmentDefinitionCache(nchclientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions)
 			vmiCache := fakeclients.VirtualMachineInstanceCache(nchclientset.KubevirtV1().VirtualMachineInstances)
 			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
+			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
 			cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
 			vcClient := fakeclients.VlanConfigClient(nchclientset.NetworkV1beta1().VlanConfigs)
+			vsClient := fakeclients.VlanStatusClient(nchclientset.NetworkV1beta1().VlanStatuses)
 
 			if tc.currentVC != nil {
 				_, err := vcClient.Create(tc.currentVC)
 				assert.NoError(t, err)
 			}
+			if tc.currentVS != nil {
+				_, err := vsClient.Create(tc.currentVS)
+				assert.NoError(t, err)
+			}
 			if tc.currentCN != nil {
 				_, err := cnClient.Create(tc.currentCN)
 				assert.NoError(t, err)
 			}
-			validator := NewCnValidator(nadCache, vmiCache, vcCache)
+			validator := NewCnValidator(nadCache, vmiCache, vcCache, vsCache, cnCache)
 			err := validator.Update(nil, tc.currentCN, tc.newCN)
 			assert.True(t, tc.returnErr == (err != nil))
 			if tc.returnErr {
@@ -408,6 +976,8 @@ func TestUpdateMgmtClusterNetwork(t *testing.T) {
 
 			// no need to call vmiCache.AddIndexer(indexeres.VMByNetworkIndex, vmiByNetwork)
 			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
+			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
 			if tc.currentCN != nil {
 				err := nchclientset.Tracker().Add(tc.currentCN)
 				assert.Nil(t, err, "mock resource clusternetwork should add into fake controller tracker")
@@ -422,7 +992,7 @@ func TestUpdateMgmtClusterNetwork(t *testing.T) {
 				}
 			}
 
-			validator := NewCnValidator(nadCache, vmiCache, vcCache)
+			validator := NewCnValidator(nadCache, vmiCache, vcCache, vsCache, cnCache)
 			err := validator.Update(nil, tc.currentCN, tc.newCN)
 			assert.True(t, tc.returnErr == (err != nil))
 			if tc.returnErr {
@@ -482,6 +1052,35 @@ func TestDeleteClusterNetwork(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "ClusterNetwork with deletion grace period can be deleted as it has VlanConfig",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					DeletionGracePeriodSeconds: 600,
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "VC1",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						LinkAttrs: &networkv1.LinkAttrs{
+							MTU: 1500,
+						},
+					},
+				},
+			},
+		},
 		{
 			name:      "ClusterNetwork can't be deleted as it has nad",
 			returnErr: true,
@@ -558,6 +1157,8 @@ func TestDeleteClusterNetwork(t *testing.T) {
 			nadCache := fakeclients.NetworkAttachmentDefinitionCache(nchclientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions)
 			vmiCache := fakeclients.VirtualMachineInstanceCache(nchclientset.KubevirtV1().VirtualMachineInstances)
 			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
+			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
 			cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
 			vcClient := fakeclients.VlanConfigClient(nchclientset.NetworkV1beta1().VlanConfigs)
 
@@ -576,7 +1177,7 @@ func TestDeleteClusterNetwork(t *testing.T) {
 				}
 			}
 
-			validator := NewCnValidator(nadCache, vmiCache, vcCache)
+			validator := NewCnValidator(nadCache, vmiCache, vcCache, vsCache, cnCache)
 			err := validator.Delete(nil, tc.currentCN)
 			assert.True(t, tc.returnErr == (err != nil))
 			if tc.returnErr {
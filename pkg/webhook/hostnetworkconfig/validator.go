@@ -1,7 +1,6 @@
 package hostnetworkconfig
 
 import (
-	"encoding/json"
 	"fmt"
 	"net"
 	"reflect"
@@ -14,10 +13,12 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	ctlkubevirtv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubevirt.io/v1"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/matcher"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
@@ -86,9 +87,14 @@ func (v *Validator) Create(_ *admission.Request, newObj runtime.Object) error {
 	}
 
 	// check if clusternetwork exists
-	if _, err := v.cnCache.Get(hnc.Spec.ClusterNetwork); err != nil {
+	cn, err := v.cnCache.Get(hnc.Spec.ClusterNetwork)
+	if err != nil {
 		return fmt.Errorf(createErr, hnc.Name, fmt.Errorf("it refers to a none-existing cluster network %s or error %w", hnc.Spec.ClusterNetwork, err))
 	}
+	// the host network interface is a VLAN sub-interface of the Linux bridge
+	if utils.IsBridgeless(cn) || utils.IsOVS(cn) {
+		return fmt.Errorf(createErr, hnc.Name, fmt.Errorf("cluster network %s has no Linux bridge with the datapath %s", cn.Name, cn.Spec.Datapath))
+	}
 
 	if err := v.validateHostNetworkConfig(hnc, ValidateCreate); err != nil {
 		return fmt.Errorf(createErr, hnc.Name, err)
@@ -360,16 +366,11 @@ func (v *Validator) checkVlanStatusReady(clusterNetwork string) error {
 }
 
 func getMatchNodes(vc *networkv1.VlanConfig) ([]string, error) {
-	if vc.Annotations == nil || vc.Annotations[utils.KeyMatchedNodes] == "" {
+	if annotations.MatchedNodes.Value(vc) == "" {
 		return nil, fmt.Errorf("vlan config annotations is absent for matched nodes")
 	}
 
-	var matchedNodes []string
-	if err := json.Unmarshal([]byte(vc.Annotations[utils.KeyMatchedNodes]), &matchedNodes); err != nil {
-		return nil, err
-	}
-
-	return matchedNodes, nil
+	return matcher.MatchedNodesOf(vc)
 }
 
 func matchNode(node *v1.Node, selector labels.Selector) (bool, error) {
@@ -1,6 +1,7 @@
 package nad
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
@@ -12,6 +13,7 @@ import (
 	k8slabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
@@ -33,18 +35,32 @@ func NewNadMutator(cnCache ctlnetworkv1.ClusterNetworkCache,
 	}
 }
 
-func (m *Mutator) Create(_ *admission.Request, newObj runtime.Object) (admission.Patch, error) {
+func (m *Mutator) Create(req *admission.Request, newObj runtime.Object) (admission.Patch, error) {
 	nad := newObj.(*cniv1.NetworkAttachmentDefinition)
 
-	patch, err := m.patchMTU(nad)
+	config, err := m.bridgeConfig(req, nad)
 	if err != nil {
 		return nil, fmt.Errorf(createErr, nad.Namespace, nad.Name, err)
 	}
+	// the MTU is patched on top of the bridge per VID or the QinQ bridge
+	patched := nad
+	if config != nad.Spec.Config {
+		patched = nad.DeepCopy()
+		patched.Spec.Config = config
+	}
+
+	patch, err := m.patchMTU(req, patched)
+	if err != nil {
+		return nil, fmt.Errorf(createErr, nad.Namespace, nad.Name, err)
+	}
+	if patch == nil && config != nad.Spec.Config {
+		patch = configPatch(config)
+	}
 
 	return patch, nil
 }
 
-func (m *Mutator) Update(_ *admission.Request, oldObj, newObj runtime.Object) (admission.Patch, error) {
+func (m *Mutator) Update(req *admission.Request, oldObj, newObj runtime.Object) (admission.Patch, error) {
 	oldNad := oldObj.(*cniv1.NetworkAttachmentDefinition)
 	newNad := newObj.(*cniv1.NetworkAttachmentDefinition)
 
@@ -67,12 +83,21 @@ func (m *Mutator) Update(_ *admission.Request, oldObj, newObj runtime.Object) (a
 		return nil, fmt.Errorf(updateErr, newNad.Namespace, newNad.Name, err)
 	}
 
-	annotationPatch, err := tagRouteOutdated(oldNad, newNad, oldNetconf, newNetconf)
+	annotationPatch, err := tagRouteOutdated(req, oldNad, newNad, oldNetconf, newNetconf)
+	if err != nil {
+		return nil, fmt.Errorf(updateErr, newNad.Namespace, newNad.Name, err)
+	}
+	patch = append(patch, annotationPatch...)
+
+	config, err := m.bridgeConfig(req, newNad)
 	if err != nil {
 		return nil, fmt.Errorf(updateErr, newNad.Namespace, newNad.Name, err)
 	}
+	if config != newNad.Spec.Config {
+		patch = append(patch, configPatch(config)...)
+	}
 
-	return append(patch, annotationPatch...), nil
+	return patch, nil
 }
 
 func (m *Mutator) Resource() admission.Resource {
@@ -134,7 +159,7 @@ func (m *Mutator) ensureLabels(nad *cniv1.NetworkAttachmentDefinition, _, newCon
 }
 
 // If the vlan/route mode is changed, we need to tag the route annotation outdated
-func tagRouteOutdated(oldNad, newNad *cniv1.NetworkAttachmentDefinition, oldConf, newConf *utils.NetConf) (admission.Patch, error) {
+func tagRouteOutdated(req *admission.Request, oldNad, newNad *cniv1.NetworkAttachmentDefinition, oldConf, newConf *utils.NetConf) (admission.Patch, error) {
 	if newConf.IsKubeOVNCNI() {
 		return nil, nil
 	}
@@ -145,7 +170,7 @@ func tagRouteOutdated(oldNad, newNad *cniv1.NetworkAttachmentDefinition, oldConf
 	}
 
 	if oldConf.Vlan != newConf.Vlan {
-		logrus.Infof("nad %s/%s has new config, route is updated: %+v", newNad.Namespace, newNad.Name, newConf)
+		logPatch(req, "nad %s/%s has new config, route is updated: %+v", newNad.Namespace, newNad.Name, newConf)
 		annotations, err := utils.OutdateNadLayer3NetworkConf(newNad, newConf)
 		if err != nil {
 			return nil, err
@@ -169,7 +194,7 @@ func tagRouteOutdated(oldNad, newNad *cniv1.NetworkAttachmentDefinition, oldConf
 	if annotations == nil {
 		return nil, nil
 	}
-	logrus.Infof("nad %s/%s has new route mode, route is updated: %s", newNad.Namespace, newNad.Name, newNad.Annotations[utils.KeyNetworkRoute])
+	logPatch(req, "nad %s/%s has new route mode, route is updated: %s", newNad.Namespace, newNad.Name, newNad.Annotations[utils.KeyNetworkRoute])
 	return admission.Patch{
 		admission.PatchOp{
 			Op:    admission.PatchOpReplace,
@@ -179,7 +204,7 @@ func tagRouteOutdated(oldNad, newNad *cniv1.NetworkAttachmentDefinition, oldConf
 	}, nil
 }
 
-func (m *Mutator) patchMTU(nad *cniv1.NetworkAttachmentDefinition) (admission.Patch, error) {
+func (m *Mutator) patchMTU(req *admission.Request, nad *cniv1.NetworkAttachmentDefinition) (admission.Patch, error) {
 	config := nad.Spec.Config
 
 	netConf, err := utils.DecodeNadConfigToNetConf(nad)
@@ -205,7 +230,7 @@ func (m *Mutator) patchMTU(nad *cniv1.NetworkAttachmentDefinition) (admission.Pa
 	getMtu := false
 
 	// get MTU from clusternetwork
-	if mtuStr, ok := cn.Annotations[utils.KeyUplinkMTU]; ok {
+	if mtuStr, ok := annotations.UplinkMTU.Get(cn); ok {
 		mtu, err := utils.GetMTUFromString(mtuStr)
 		if err != nil {
 			return nil, fmt.Errorf("nad's host cluster network %v has invalid MTU annotation %v/%v %w", cn.Name, utils.KeyUplinkMTU, mtuStr, err)
@@ -238,7 +263,7 @@ func (m *Mutator) patchMTU(nad *cniv1.NetworkAttachmentDefinition) (admission.Pa
 		return nil, nil
 	}
 
-	logrus.Infof("nad %s/%s MTU is patched from %v to %v", nad.Namespace, nad.Name, netConf.MTU, targetMTU)
+	logPatch(req, "nad %s/%s MTU is patched from %v to %v", nad.Namespace, nad.Name, netConf.MTU, targetMTU)
 	// Don't modify the unmarshalled structure and marshal it again because some fields may be lost during unmarshalling.
 	newConfig, err := sjson.Set(config, "mtu", targetMTU)
 	if err != nil {
@@ -253,3 +278,216 @@ func (m *Mutator) patchMTU(nad *cniv1.NetworkAttachmentDefinition) (admission.Pa
 		},
 	}, nil
 }
+
+// bridgePerVIDConfig returns the config of the access NAD of a cluster network with the datapath BridgePerVID pointed
+// at the bridge of its VID without the VLAN, as the bridge CNI turns on the VLAN filtering of the bridge for a VLAN.
+// The config of any other NAD is returned as it is.
+func (m *Mutator) bridgePerVIDConfig(req *admission.Request, nad *cniv1.NetworkAttachmentDefinition) (string, error) {
+	netConf, err := utils.DecodeNadConfigToNetConf(nad)
+	if err != nil {
+		return "", err
+	}
+	if !netConf.IsBridgeCNI() || netConf.PerVIDBridge || netConf.Vlan == 0 || netConf.IsQinQ() || netConf.QinQBridge {
+		return nad.Spec.Config, nil
+	}
+
+	cnName, err := utils.GetClusterNetworkFromBridgeName(netConf.BrName)
+	if err != nil {
+		return "", err
+	}
+	cn, err := m.cnCache.Get(cnName)
+	if err != nil {
+		return "", err
+	}
+	if !utils.IsBridgePerVID(cn) {
+		return nad.Spec.Config, nil
+	}
+
+	bridge := utils.GeneratePerVIDBridgeName(cnName, uint16(netConf.Vlan)) //nolint:gosec
+	config, err := sjson.Set(nad.Spec.Config, "bridge", bridge)
+	if err != nil {
+		return "", fmt.Errorf("set bridge failed, error: %w", err)
+	}
+	if config, err = sjson.Set(config, "vlan", 0); err != nil {
+		return "", fmt.Errorf("set vlan failed, error: %w", err)
+	}
+	logPatch(req, "nad %s/%s is attached to the bridge %s of VID %d", nad.Namespace, nad.Name, bridge, netConf.Vlan)
+
+	return config, nil
+}
+
+// bridgeConfig returns the config of the NAD pointed at the bridge its cluster network attaches it to, see
+// bridgePerVIDConfig, bridgelessConfig, ovsConfig and qinqConfig
+func (m *Mutator) bridgeConfig(req *admission.Request, nad *cniv1.NetworkAttachmentDefinition) (string, error) {
+	config, err := m.bridgePerVIDConfig(req, nad)
+	if err != nil || config != nad.Spec.Config {
+		return config, err
+	}
+	if config, err = m.bridgelessConfig(req, nad); err != nil || config != nad.Spec.Config {
+		return config, err
+	}
+	if config, err = m.ovsConfig(req, nad); err != nil || config != nad.Spec.Config {
+		return config, err
+	}
+	return qinqConfig(req, nad)
+}
+
+// bridgelessConfig returns the config of the bridge NAD of a bridge-less cluster network turned into a macvlan NAD in
+// bridge mode, or an ipvlan NAD in l2 mode with the datapath Ipvlan, on the master of its VID. The config of any other
+// NAD is returned as it is.
+func (m *Mutator) bridgelessConfig(req *admission.Request, nad *cniv1.NetworkAttachmentDefinition) (string, error) {
+	netConf, err := utils.DecodeNadConfigToNetConf(nad)
+	if err != nil {
+		return "", err
+	}
+	// the validator rejects the trunk and QinQ NADs of a bridge-less cluster network
+	if !netConf.IsBridgeCNI() || netConf.BrName == "" || !netConf.IsVlanAccessMode() || netConf.IsQinQ() {
+		return nad.Spec.Config, nil
+	}
+
+	cnName, err := utils.GetClusterNetworkFromBridgeName(netConf.BrName)
+	if err != nil {
+		return "", err
+	}
+	cn, err := m.cnCache.Get(cnName)
+	if err != nil {
+		return "", err
+	}
+	if !utils.IsBridgeless(cn) {
+		return nad.Spec.Config, nil
+	}
+
+	cniType, mode := utils.CNITypeMacvlan, "bridge"
+	if cn.Spec.Datapath == networkv1.DatapathIpvlan {
+		cniType, mode = utils.CNITypeIpvlan, "l2"
+	}
+	if netConf.Type == cniType && netConf.Mode != "" {
+		mode = netConf.Mode
+	}
+	master := utils.GenerateBridgelessMaster(cnName, uint16(netConf.Vlan)) //nolint:gosec
+	config := nad.Spec.Config
+	for _, field := range []struct {
+		path  string
+		value string
+	}{{"type", cniType}, {"master", master}, {"mode", mode}} {
+		if config, err = sjson.Set(config, field.path, field.value); err != nil {
+			return "", fmt.Errorf("set %s failed, error: %w", field.path, err)
+		}
+	}
+	for _, path := range []string{"bridge", "vlan"} {
+		if config, err = sjson.Delete(config, path); err != nil {
+			return "", fmt.Errorf("delete %s failed, error: %w", path, err)
+		}
+	}
+	if config != nad.Spec.Config {
+		logPatch(req, "nad %s/%s is attached to the master %s as %s", nad.Namespace, nad.Name, master, cniType)
+	}
+
+	return config, nil
+}
+
+// ovsConfig returns the config of the bridge NAD of an OVS cluster network turned into a NAD of the OVS CNI on the OVS
+// bridge. The vlanTrunk is renamed to the trunk of the OVS CNI, and the untagged NAD is put on the default VID 1, which
+// leaves the uplink untagged, as the OVS CNI trunks all the VIDs on a port without any VID. The config of any other NAD
+// is returned as it is.
+func (m *Mutator) ovsConfig(req *admission.Request, nad *cniv1.NetworkAttachmentDefinition) (string, error) {
+	netConf, err := utils.DecodeNadConfigToNetConf(nad)
+	if err != nil {
+		return "", err
+	}
+	// the validator rejects the QinQ NADs of an OVS cluster network
+	if !netConf.IsBridgeCNI() || netConf.BrName == "" || netConf.Bridgeless || netConf.PerVIDBridge || netConf.IsQinQ() {
+		return nad.Spec.Config, nil
+	}
+
+	cnName, err := utils.GetClusterNetworkFromBridgeName(netConf.BrName)
+	if err != nil {
+		return "", err
+	}
+	cn, err := m.cnCache.Get(cnName)
+	if err != nil {
+		return "", err
+	}
+	if !utils.IsOVS(cn) {
+		return nad.Spec.Config, nil
+	}
+
+	config, err := sjson.Set(nad.Spec.Config, "type", utils.CNITypeOVS)
+	if err != nil {
+		return "", fmt.Errorf("set type failed, error: %w", err)
+	}
+	var raw struct {
+		VlanTrunk json.RawMessage `json:"vlanTrunk"`
+	}
+	if err := json.Unmarshal([]byte(config), &raw); err != nil {
+		return "", err
+	}
+	if raw.VlanTrunk != nil {
+		if config, err = sjson.SetRaw(config, "trunk", string(raw.VlanTrunk)); err != nil {
+			return "", fmt.Errorf("set trunk failed, error: %w", err)
+		}
+		if config, err = sjson.Delete(config, "vlanTrunk"); err != nil {
+			return "", fmt.Errorf("delete vlanTrunk failed, error: %w", err)
+		}
+	}
+	if netConf.Vlan == 0 && len(netConf.VlanTrunk) == 0 {
+		if config, err = sjson.Set(config, "vlan", utils.DefaultVlanID); err != nil {
+			return "", fmt.Errorf("set vlan failed, error: %w", err)
+		}
+	}
+	if config != nad.Spec.Config {
+		logPatch(req, "nad %s/%s is attached to the OVS bridge %s", nad.Namespace, nad.Name, netConf.BrName)
+	}
+
+	return config, nil
+}
+
+// qinqConfig returns the config of the QinQ NAD pointed at the QinQ bridge of its S-VLAN, which filters its C-VLAN like
+// the bridge of the cluster network. A NAD no longer QinQ is pointed back at the bridge of its cluster network. The
+// config of any other NAD is returned as it is.
+func qinqConfig(req *admission.Request, nad *cniv1.NetworkAttachmentDefinition) (string, error) {
+	netConf, err := utils.DecodeNadConfigToNetConf(nad)
+	if err != nil {
+		return "", err
+	}
+	if !netConf.IsBridgeCNI() || (!netConf.IsQinQ() && !netConf.QinQBridge) {
+		return nad.Spec.Config, nil
+	}
+
+	bridge := netConf.BrName
+	if netConf.IsQinQ() {
+		cnName, err := utils.GetClusterNetworkFromBridgeName(netConf.BrName)
+		if err != nil {
+			return "", err
+		}
+		bridge = utils.GenerateQinQBridgeName(cnName, uint16(netConf.OuterVlan)) //nolint:gosec
+	}
+	config, err := sjson.Set(nad.Spec.Config, "bridge", bridge)
+	if err != nil {
+		return "", fmt.Errorf("set bridge failed, error: %w", err)
+	}
+	if config != nad.Spec.Config {
+		logPatch(req, "nad %s/%s is attached to the bridge %s", nad.Namespace, nad.Name, bridge)
+	}
+
+	return config, nil
+}
+
+func configPatch(config string) admission.Patch {
+	return admission.Patch{
+		admission.PatchOp{
+			Op:    admission.PatchOpReplace,
+			Path:  "/spec/config",
+			Value: config,
+		},
+	}
+}
+
+// logPatch logs the patch at info level, or at debug level for a dry run request whose patch is never persisted
+func logPatch(req *admission.Request, format string, args ...interface{}) {
+	if utils.IsDryRun(req) {
+		logrus.Debugf("dry run: "+format, args...)
+		return
+	}
+	logrus.Infof(format, args...)
+}
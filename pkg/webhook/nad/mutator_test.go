@@ -4,7 +4,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/harvester/webhook/pkg/server/admission"
+	"github.com/rancher/wrangler/v3/pkg/webhook"
 	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -509,3 +512,269 @@ func TestMutatorUpdateNAD(t *testing.T) {
 		})
 	}
 }
+
+func TestMutatorDryRun(t *testing.T) {
+	oldNAD := &cniv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testNadName,
+			Namespace: testNamespace,
+			Annotations: map[string]string{
+				utils.KeyNetworkRoute: testNadConfigRoute,
+			},
+			Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+		},
+		Spec: cniv1.NetworkAttachmentDefinitionSpec{
+			Config: testNadConfigVlan300,
+		},
+	}
+	currentNAD := oldNAD.DeepCopy()
+	currentNAD.Spec.Config = testNadConfigVlan350
+
+	nchclientset := fake.NewSimpleClientset()
+	cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+	vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+	cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
+	if _, err := cnClient.Create(&networkv1.ClusterNetwork{ObjectMeta: metav1.ObjectMeta{Name: testCnName}}); err != nil {
+		t.Fatalf("failed to create cluster network %s", testCnName)
+	}
+	mutator := NewNadMutator(cnCache, vcCache)
+
+	dryRun := true
+	req := &admission.Request{Request: &webhook.Request{AdmissionRequest: admissionv1.AdmissionRequest{DryRun: &dryRun}}}
+	assert.True(t, utils.IsDryRun(req))
+
+	nchclientset.ClearActions()
+	createPatch, err := mutator.Create(req, currentNAD)
+	assert.Nil(t, err)
+	updatePatch, err := mutator.Update(req, oldNAD, currentNAD)
+	assert.Nil(t, err)
+	// the request is only read, nothing is written
+	for _, action := range nchclientset.Actions() {
+		assert.Contains(t, []string{"get", "list", "watch"}, action.GetVerb())
+	}
+
+	// a dry run gets the same patch as the real request
+	expectedCreatePatch, err := mutator.Create(nil, currentNAD)
+	assert.Nil(t, err)
+	expectedUpdatePatch, err := mutator.Update(nil, oldNAD, currentNAD)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedCreatePatch, createPatch)
+	assert.Equal(t, expectedUpdatePatch, updatePatch)
+}
+
+func TestMutatorBridgePerVID(t *testing.T) {
+	nad := &cniv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testNadName,
+			Namespace: testNamespace,
+			Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+		},
+		Spec: cniv1.NetworkAttachmentDefinitionSpec{
+			Config: testNadConfigVlan300,
+		},
+	}
+
+	nchclientset := fake.NewSimpleClientset()
+	cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+	vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+	cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
+	if _, err := cnClient.Create(&networkv1.ClusterNetwork{
+		ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+		Spec:       networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathBridgePerVID},
+	}); err != nil {
+		t.Fatalf("failed to create cluster network %s", testCnName)
+	}
+	mutator := NewNadMutator(cnCache, vcCache)
+
+	// the access NAD is pointed at the bridge of its VID
+	patch, err := mutator.Create(nil, nad)
+	assert.Nil(t, err)
+	assert.Len(t, patch, 1)
+	config, ok := patch[0].Value.(string)
+	assert.True(t, ok)
+	patched := nad.DeepCopy()
+	patched.Spec.Config = config
+	conf, err := utils.DecodeNadConfigToNetConf(patched)
+	assert.Nil(t, err)
+	assert.Contains(t, config, `"bridge":"`+utils.GeneratePerVIDBridgeName(testCnName, 300)+`"`)
+	assert.True(t, conf.PerVIDBridge)
+	assert.Equal(t, 300, conf.Vlan)
+
+	// the NAD already on the bridge of its VID is left alone
+	updatePatch, err := mutator.Update(nil, patched, patched)
+	assert.Nil(t, err)
+	for _, op := range updatePatch {
+		assert.NotEqual(t, "/spec/config", op.Path)
+	}
+
+	// the untagged NAD stays on the bridge of the cluster network
+	untagged := nad.DeepCopy()
+	untagged.Spec.Config = testNadConfigVlanUntag
+	config, err = mutator.bridgePerVIDConfig(nil, untagged)
+	assert.Nil(t, err)
+	assert.Equal(t, testNadConfigVlanUntag, config)
+}
+
+func TestMutatorQinQ(t *testing.T) {
+	nad := &cniv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testNadName,
+			Namespace: testNamespace,
+			Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+		},
+		Spec: cniv1.NetworkAttachmentDefinitionSpec{
+			Config: `{"cniVersion":"0.3.1","name":"net1-vlan","type":"bridge","bridge":"test-cn-br","vlan":200,"outerVlan":100,"ipam":{}}`,
+		},
+	}
+
+	nchclientset := fake.NewSimpleClientset()
+	cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+	vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+	cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
+	if _, err := cnClient.Create(&networkv1.ClusterNetwork{ObjectMeta: metav1.ObjectMeta{Name: testCnName}}); err != nil {
+		t.Fatalf("failed to create cluster network %s", testCnName)
+	}
+	mutator := NewNadMutator(cnCache, vcCache)
+
+	// the QinQ NAD is pointed at the QinQ bridge of its S-VLAN and keeps its C-VLAN
+	patch, err := mutator.Create(nil, nad)
+	assert.Nil(t, err)
+	assert.Len(t, patch, 1)
+	config, ok := patch[0].Value.(string)
+	assert.True(t, ok)
+	assert.Contains(t, config, `"bridge":"`+utils.GenerateQinQBridgeName(testCnName, 100)+`"`)
+	assert.Contains(t, config, `"vlan":200`)
+
+	// the NAD already on the QinQ bridge of its S-VLAN is left alone
+	patched := nad.DeepCopy()
+	patched.Spec.Config = config
+	updatePatch, err := mutator.Update(nil, patched, patched)
+	assert.Nil(t, err)
+	for _, op := range updatePatch {
+		assert.NotEqual(t, "/spec/config", op.Path)
+	}
+
+	// the NAD no longer QinQ is pointed back at the bridge of the cluster network
+	unqinq := patched.DeepCopy()
+	unqinq.Spec.Config = `{"cniVersion":"0.3.1","name":"net1-vlan","type":"bridge","bridge":"test-cn-q100","vlan":200,"ipam":{}}`
+	config, err = qinqConfig(nil, unqinq)
+	assert.Nil(t, err)
+	assert.Contains(t, config, `"bridge":"test-cn-br"`)
+}
+
+func TestMutatorBridgeless(t *testing.T) {
+	nad := &cniv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testNadName,
+			Namespace: testNamespace,
+			Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+		},
+		Spec: cniv1.NetworkAttachmentDefinitionSpec{
+			Config: `{"cniVersion":"0.3.1","name":"net1-vlan","type":"bridge","bridge":"test-cn-br","promiscMode":true,"vlan":300,"ipam":{}}`,
+		},
+	}
+
+	nchclientset := fake.NewSimpleClientset()
+	cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+	vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+	cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
+	cn := &networkv1.ClusterNetwork{
+		ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+		Spec:       networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathMacvlan},
+	}
+	if _, err := cnClient.Create(cn); err != nil {
+		t.Fatalf("failed to create cluster network %s", testCnName)
+	}
+	mutator := NewNadMutator(cnCache, vcCache)
+
+	// the bridge NAD is turned into a macvlan NAD on the VLAN sub-interface of its VID
+	patch, err := mutator.Create(nil, nad)
+	assert.Nil(t, err)
+	assert.Len(t, patch, 1)
+	config, ok := patch[0].Value.(string)
+	assert.True(t, ok)
+	assert.Contains(t, config, `"type":"macvlan"`)
+	assert.Contains(t, config, `"master":"test-cn.300"`)
+	assert.Contains(t, config, `"mode":"bridge"`)
+	assert.NotContains(t, config, `"bridge":`)
+	assert.NotContains(t, config, `"vlan":`)
+
+	// the macvlan NAD is left alone
+	patched := nad.DeepCopy()
+	patched.Spec.Config = config
+	updatePatch, err := mutator.Update(nil, patched, patched)
+	assert.Nil(t, err)
+	for _, op := range updatePatch {
+		assert.NotEqual(t, "/spec/config", op.Path)
+	}
+
+	// the VID updated by the user moves the NAD to the master of the new VID
+	moved := patched.DeepCopy()
+	moved.Spec.Config = `{"cniVersion":"0.3.1","name":"net1-vlan","type":"macvlan","master":"test-cn.300","mode":"bridge","vlan":301,"ipam":{}}`
+	config, err = mutator.bridgelessConfig(nil, moved)
+	assert.Nil(t, err)
+	assert.Contains(t, config, `"master":"test-cn.301"`)
+	assert.NotContains(t, config, `"vlan":`)
+
+	// the untagged NAD is an ipvlan NAD on the bond with the datapath Ipvlan
+	cn.Spec.Datapath = networkv1.DatapathIpvlan
+	if _, err := cnClient.Update(cn); err != nil {
+		t.Fatalf("failed to update cluster network %s", testCnName)
+	}
+	untagged := nad.DeepCopy()
+	untagged.Spec.Config = `{"cniVersion":"0.3.1","name":"net1-vlan","type":"bridge","bridge":"test-cn-br","ipam":{}}`
+	config, err = mutator.bridgelessConfig(nil, untagged)
+	assert.Nil(t, err)
+	assert.Contains(t, config, `"type":"ipvlan"`)
+	assert.Contains(t, config, `"master":"test-cn-bo"`)
+	assert.Contains(t, config, `"mode":"l2"`)
+}
+
+func TestMutatorOVS(t *testing.T) {
+	nad := &cniv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testNadName,
+			Namespace: testNamespace,
+			Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+		},
+		Spec: cniv1.NetworkAttachmentDefinitionSpec{
+			Config: `{"cniVersion":"0.3.1","name":"net1-vlan","type":"bridge","bridge":"test-cn-br","vlanTrunk":[{"minID":100,"maxID":200}],"ipam":{}}`,
+		},
+	}
+
+	nchclientset := fake.NewSimpleClientset()
+	cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+	vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+	cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
+	cn := &networkv1.ClusterNetwork{
+		ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+		Spec:       networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathOVS},
+	}
+	if _, err := cnClient.Create(cn); err != nil {
+		t.Fatalf("failed to create cluster network %s", testCnName)
+	}
+	mutator := NewNadMutator(cnCache, vcCache)
+
+	// the trunk NAD is turned into an OVS NAD with the trunk of the OVS CNI
+	config, err := mutator.bridgeConfig(nil, nad)
+	assert.Nil(t, err)
+	assert.Contains(t, config, `"type":"ovs"`)
+	assert.Contains(t, config, `"bridge":"test-cn-br"`)
+	assert.Contains(t, config, `"trunk":[{"minID":100,"maxID":200}]`)
+	assert.NotContains(t, config, `"vlanTrunk":`)
+
+	// the OVS NAD is left alone
+	patched := nad.DeepCopy()
+	patched.Spec.Config = config
+	config, err = mutator.bridgeConfig(nil, patched)
+	assert.Nil(t, err)
+	assert.Equal(t, patched.Spec.Config, config)
+
+	// the untagged NAD is put on the default VID
+	untagged := nad.DeepCopy()
+	untagged.Spec.Config = `{"cniVersion":"0.3.1","name":"net1-vlan","type":"bridge","bridge":"test-cn-br","ipam":{}}`
+	config, err = mutator.bridgeConfig(nil, untagged)
+	assert.Nil(t, err)
+	assert.Contains(t, config, `"type":"ovs"`)
+	assert.Contains(t, config, `"vlan":1`)
+}
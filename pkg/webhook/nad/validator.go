@@ -3,6 +3,7 @@ package nad
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/harvester/webhook/pkg/server/admission"
@@ -11,6 +12,8 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	kubeovnnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubeovn.io/v1"
 	ctlkubevirtv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubevirt.io/v1"
@@ -61,6 +64,10 @@ func (v *Validator) Create(_ *admission.Request, newObj runtime.Object) error {
 		return fmt.Errorf(createErr, nad.Namespace, nad.Name, err)
 	}
 
+	if err := utils.ValidateNetConfFields(nad.Spec.Config); err != nil {
+		return fmt.Errorf(createErr, nad.Namespace, nad.Name, err)
+	}
+
 	if err := v.checkNadConfig(conf, nad); err != nil {
 		return fmt.Errorf(createErr, nad.Namespace, nad.Name, err)
 	}
@@ -69,6 +76,18 @@ func (v *Validator) Create(_ *admission.Request, newObj runtime.Object) error {
 		return fmt.Errorf(createErr, nad.Namespace, nad.Name, err)
 	}
 
+	if err := checkUplink(nad); err != nil {
+		return fmt.Errorf(createErr, nad.Namespace, nad.Name, err)
+	}
+
+	if _, _, err := annotations.GetTTL(nad); err != nil {
+		return fmt.Errorf(createErr, nad.Namespace, nad.Name, err)
+	}
+
+	if err := v.checkQoSMarking(conf, nad); err != nil {
+		return fmt.Errorf(createErr, nad.Namespace, nad.Name, err)
+	}
+
 	return nil
 }
 
@@ -90,10 +109,29 @@ func (v *Validator) Update(_ *admission.Request, oldObj, newObj runtime.Object)
 		return fmt.Errorf(updateErr, oldNad.Namespace, oldNad.Name, err)
 	}
 
+	// the existing configs are only checked strictly when they are changed
+	if newNad.Spec.Config != oldNad.Spec.Config {
+		if err := utils.ValidateNetConfFields(newNad.Spec.Config); err != nil {
+			return fmt.Errorf(updateErr, newNad.Namespace, newNad.Name, err)
+		}
+	}
+
 	if err := v.checkRoute(newNad.Annotations[utils.KeyNetworkRoute]); err != nil {
 		return fmt.Errorf(updateErr, newNad.Namespace, newNad.Name, err)
 	}
 
+	if err := checkUplink(newNad); err != nil {
+		return fmt.Errorf(updateErr, newNad.Namespace, newNad.Name, err)
+	}
+
+	if _, _, err := annotations.GetTTL(newNad); err != nil {
+		return fmt.Errorf(updateErr, newNad.Namespace, newNad.Name, err)
+	}
+
+	if err := v.checkQoSMarking(newConf, newNad); err != nil {
+		return fmt.Errorf(updateErr, newNad.Namespace, newNad.Name, err)
+	}
+
 	// skip the following check if the config is not changed
 	if reflect.DeepEqual(newConf, oldConf) {
 		return nil
@@ -111,8 +149,12 @@ func (v *Validator) Update(_ *admission.Request, oldObj, newObj runtime.Object)
 		return fmt.Errorf(updateErr, newNad.Namespace, newNad.Name, err)
 	}
 
-	if err := v.checkVmi(newNad); err != nil {
-		return fmt.Errorf(updateErr, newNad.Namespace, newNad.Name, err)
+	// the VLAN ID can be changed while VMs use the NAD, the running VMs keep using the previous VLAN ID, which is kept
+	// on the uplinks, until they are restarted or migrated
+	if !isVlanIDChangeOnly(oldConf, newConf) {
+		if err := v.checkVmi(newNad); err != nil {
+			return fmt.Errorf(updateErr, newNad.Namespace, newNad.Name, err)
+		}
 	}
 
 	// storagenetwork nad's params can't be changed, the only way is to clear & set storagenetwork
@@ -129,7 +171,7 @@ func (v *Validator) Delete(_ *admission.Request, oldObj runtime.Object) error {
 
 	nadConf, err := utils.DecodeNadConfigToNetConf(nad)
 	if err != nil {
-		return fmt.Errorf(updateErr, nad.Namespace, nad.Name, err)
+		return fmt.Errorf(deleteErr, nad.Namespace, nad.Name, err)
 	}
 
 	// do not delete nad when a subnet is using it
@@ -224,13 +266,29 @@ func (v *Validator) checkNadConfig(nadConf *utils.NetConf, nad *cniv1.NetworkAtt
 		return fmt.Errorf("nad refers to a none-existing cluster network %s or error %w", cnName, err)
 	}
 
+	if err := v.checkVIDCount(cn, nad); err != nil {
+		return err
+	}
+
+	if err := checkDatapath(cn, nadConf); err != nil {
+		return err
+	}
+
+	if err := checkQinQ(cn, nadConf); err != nil {
+		return err
+	}
+
+	if err := utils.CheckMaxMTU(cn, nadConf.MTU, "nad "+nad.Namespace+"/"+nad.Name); err != nil {
+		return err
+	}
+
 	// for new NAD, the mutator patchs the MTU
 	// for updated NAD, the MTU should keep same with cluster network
 	targetMTU := utils.DefaultMTU
 	getMtu := false
 
 	// get MTU from clusternetwork
-	if mtuStr, ok := cn.Annotations[utils.KeyUplinkMTU]; ok {
+	if mtuStr, ok := annotations.UplinkMTU.Get(cn); ok {
 		mtu, err := utils.GetMTUFromString(mtuStr)
 		if err != nil {
 			return fmt.Errorf("nad's host cluster network %v has invalid MTU annotation %v/%v %w", cn.Name, utils.KeyUplinkMTU, mtuStr, err)
@@ -266,6 +324,106 @@ func (v *Validator) checkNadConfig(nadConf *utils.NetConf, nad *cniv1.NetworkAtt
 	return nil
 }
 
+// checkDatapath rejects the NADs the datapath of the cluster network can't carry, a bridge per VID and a bridge-less
+// cluster network have no VLAN trunk
+func checkDatapath(cn *networkv1.ClusterNetwork, conf *utils.NetConf) error {
+	perVID, bridgeless := utils.IsBridgePerVID(cn), utils.IsBridgeless(cn)
+	if (perVID || bridgeless) && conf.IsL2VlanTrunkNetwork() {
+		return fmt.Errorf("the VLAN trunk is not supported by the datapath %s of cluster network %s", cn.Spec.Datapath, cn.Name)
+	}
+	if !perVID && conf.PerVIDBridge {
+		return fmt.Errorf("the bridge per VID requires the datapath %s of cluster network %s", networkv1.DatapathBridgePerVID, cn.Name)
+	}
+	if !bridgeless && conf.Bridgeless {
+		return fmt.Errorf("the macvlan and ipvlan NADs require the datapath %s or %s of cluster network %s",
+			networkv1.DatapathMacvlan, networkv1.DatapathIpvlan, cn.Name)
+	}
+	if !utils.IsOVS(cn) && conf.OVS {
+		return fmt.Errorf("the OVS NADs require the datapath %s of cluster network %s", networkv1.DatapathOVS, cn.Name)
+	}
+
+	return nil
+}
+
+// checkQinQ rejects the QinQ NADs the cluster network can't carry, the QinQ bridge of the S-VLAN is built next to the
+// VLAN filtering bridge of a cluster network of type VLAN and named after both
+func checkQinQ(cn *networkv1.ClusterNetwork, conf *utils.NetConf) error {
+	if !conf.IsQinQ() {
+		return nil
+	}
+	if conf.OuterVlan < utils.MinTrunkVlanID || conf.OuterVlan > utils.MaxVlanID {
+		return fmt.Errorf("the S-VLAN %d is out of the range [%d, %d]", conf.OuterVlan, utils.MinTrunkVlanID, utils.MaxVlanID)
+	}
+	// the C-VLAN 1 would leave the QinQ bridge untagged as its default PVID
+	if conf.Vlan <= utils.DefaultVlanID || conf.IsVlanTrunkMode() {
+		return fmt.Errorf("the C-VLAN of QinQ must be a single VLAN in the range [%d, %d]", utils.DefaultVlanID+1, utils.MaxVlanID)
+	}
+	switch {
+	case cn.Name == utils.ManagementClusterNetworkName:
+		return fmt.Errorf("QinQ is not supported by cluster network %s", cn.Name)
+	case utils.IsOverlay(cn):
+		return fmt.Errorf("QinQ is not supported by a cluster network of type %s", cn.Spec.Type)
+	case utils.IsBridgePerVID(cn), utils.IsBridgeless(cn), utils.IsOVS(cn):
+		return fmt.Errorf("QinQ is not supported by the datapath %s of cluster network %s", cn.Spec.Datapath, cn.Name)
+	case len(cn.Name) > utils.MaxQinQClusterNetworkNameLen:
+		return fmt.Errorf("the length of the name of cluster network %s can't be more than %d for QinQ", cn.Name,
+			utils.MaxQinQClusterNetworkNameLen)
+	}
+
+	return nil
+}
+
+// checkVIDCount caps the VLAN IDs on the uplinks of the cluster network with the NAD in place of its previous version
+func (v *Validator) checkVIDCount(cn *networkv1.ClusterNetwork, nad *cniv1.NetworkAttachmentDefinition) error {
+	if utils.IsLocalOnlyNad(nad) {
+		return nil
+	}
+
+	nads, err := utils.NewNadGetter(v.nadCache).ListNadsOnClusterNetwork(cn.Name)
+	if err != nil {
+		return err
+	}
+	others := make([]*cniv1.NetworkAttachmentDefinition, 0, len(nads)+1)
+	for _, other := range nads {
+		if other.Namespace != nad.Namespace || other.Name != nad.Name {
+			others = append(others, other)
+		}
+	}
+
+	return utils.CheckVIDCount(cn, append(others, nad))
+}
+
+// checkQoSMarking rejects the marking of an untagged network, which has no VLAN tag and no VID to match, and the
+// marking of the VIDs other NADs of the cluster network mark differently
+func (v *Validator) checkQoSMarking(conf *utils.NetConf, nad *cniv1.NetworkAttachmentDefinition) error {
+	marking, err := annotations.GetQoSMarking(nad)
+	if err != nil || marking == nil {
+		return err
+	}
+
+	if !conf.IsBridgeCNI() || (!conf.IsL2VlanNetwork() && !conf.IsVlanTrunkMode()) {
+		return fmt.Errorf("annotation %s is only allowed on the VLAN networks", utils.KeyQoSMarking)
+	}
+	cnName, err := utils.GetBridgeNamePrefix(conf.BrName)
+	if err != nil {
+		return err
+	}
+
+	nads, err := utils.NewNadGetter(v.nadCache).ListNadsOnClusterNetwork(cnName)
+	if err != nil {
+		return err
+	}
+	others := make([]*cniv1.NetworkAttachmentDefinition, 0, len(nads)+1)
+	for _, other := range nads {
+		if other.Namespace != nad.Namespace || other.Name != nad.Name {
+			others = append(others, other)
+		}
+	}
+	_, err = utils.QoSMarkingsOfNads(append(others, nad))
+
+	return err
+}
+
 func (v *Validator) checkNadTypes(oldNC, newNC *utils.NetConf) error {
 	if oldNC == nil {
 		return fmt.Errorf("old nad config is empty")
@@ -305,12 +463,31 @@ func (v *Validator) checkRoute(config string) error {
 	return err
 }
 
+// checkUplink allows the uplink annotation to be true or false only
+func checkUplink(nad *cniv1.NetworkAttachmentDefinition) error {
+	value, ok := nad.Annotations[utils.KeyUplink]
+	if !ok || value == "true" || value == "false" {
+		return nil
+	}
+
+	return fmt.Errorf("annotation %s must be true or false, got %q", utils.KeyUplink, value)
+}
+
+// isVlanIDChangeOnly tells whether the configs differ in the VLAN ID or the VLAN trunk only
+func isVlanIDChangeOnly(oldConf, newConf *utils.NetConf) bool {
+	oldCopy, newCopy := *oldConf, *newConf
+	oldCopy.Vlan, newCopy.Vlan = 0, 0
+	oldCopy.VlanTrunk, newCopy.VlanTrunk = nil, nil
+	return reflect.DeepEqual(&oldCopy, &newCopy)
+}
+
 func (v *Validator) checkVmi(nad *cniv1.NetworkAttachmentDefinition) error {
 	vmiGetter := utils.NewVmiGetter(v.vmiCache)
 	// get all, no filter
 	if vmiStrList, err := vmiGetter.VmiNamesWhoUseNad(nad, false, nil); err != nil {
 		return err
 	} else if len(vmiStrList) > 0 {
+		sort.Strings(vmiStrList)
 		return fmt.Errorf("it's still used by VM(s) %s which must be stopped at first", strings.Join(vmiStrList, ", "))
 	}
 
@@ -323,6 +500,7 @@ func (v *Validator) checkVM(nad *cniv1.NetworkAttachmentDefinition) error {
 	if vmStrList, err := vmGetter.VMNamesWhoUseNad(nad); err != nil {
 		return err
 	} else if len(vmStrList) > 0 {
+		sort.Strings(vmStrList)
 		return fmt.Errorf("it's still used by VM(s) %s which must remove the related networks and interfaces", strings.Join(vmStrList, ", "))
 	}
 
@@ -1,6 +1,7 @@
 package nad
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -46,6 +47,28 @@ func TestCreateNAD(t *testing.T) {
 		currentNAD *cniv1.NetworkAttachmentDefinition
 		newNAD     *cniv1.NetworkAttachmentDefinition
 	}{
+		{
+			name:      "NAD can't be created as the bridge field is misspelled",
+			returnErr: true,
+			errKey:    `did you mean "bridge"`,
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNadName,
+					Namespace:   testNamespace,
+					Annotations: map[string]string{"test": "test"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: `{"cniVersion":"0.3.1","name":"net1-vlan","type":"bridge","brdige":"test-cn-br","promiscMode":true,"vlan":300,"ipam":{}}`,
+				},
+			},
+		},
 		{
 			name:      "valid NAD can be created",
 			returnErr: false,
@@ -89,6 +112,72 @@ func TestCreateNAD(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "local-only NAD can be created",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNadName,
+					Namespace:   testNamespace,
+					Annotations: map[string]string{utils.KeyUplink: "false"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: testNadConfig,
+				},
+			},
+		},
+		{
+			name:      "NAD can't be created as it has invalid uplink annotation",
+			returnErr: true,
+			errKey:    "must be true or false",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNadName,
+					Namespace:   testNamespace,
+					Annotations: map[string]string{utils.KeyUplink: "no"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: testNadConfig,
+				},
+			},
+		},
+		{
+			name:      "NAD can't be created as it has invalid TTL",
+			returnErr: true,
+			errKey:    "it must be positive",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNadName,
+					Namespace:   testNamespace,
+					Annotations: map[string]string{utils.KeyTTL: "0s"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: testNadConfig,
+				},
+			},
+		},
 		{
 			name:      "NAD can't be created as it's config is an invalid JSON string",
 			returnErr: true,
@@ -353,6 +442,401 @@ func TestCreateNAD(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "NAD can't be created as its trunk exceeds the default VID cap of the cluster network",
+			returnErr: true,
+			errKey:    "600 VLAN IDs, more than the cap 512",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":0,\"vlanTrunk\":[{\"minID\":100,\"maxID\":699}],\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "NAD can't be created as the VLAN trunk is not supported by the datapath BridgePerVID",
+			returnErr: true,
+			errKey:    "VLAN trunk is not supported",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathBridgePerVID},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":0,\"vlanTrunk\":[{\"minID\":100,\"maxID\":110}],\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "NAD can't be created on the bridge per VID of a cluster network with VLAN filtering",
+			returnErr: true,
+			errKey:    "requires the datapath",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-300\",\"promiscMode\":true,\"vlan\":0,\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "QinQ NAD can be created",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-q100\",\"promiscMode\":true,\"vlan\":200,\"outerVlan\":100,\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "QinQ NAD can't be created without a C-VLAN",
+			returnErr: true,
+			errKey:    "C-VLAN of QinQ",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-q100\",\"promiscMode\":true,\"vlan\":0,\"outerVlan\":100,\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "QinQ NAD can't be created with an S-VLAN out of range",
+			returnErr: true,
+			errKey:    "S-VLAN 4095 is out of the range",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":200,\"outerVlan\":4095,\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "QinQ NAD can't be created on a cluster network with the datapath BridgePerVID",
+			returnErr: true,
+			errKey:    "QinQ is not supported by the datapath",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathBridgePerVID},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-q100\",\"promiscMode\":true,\"vlan\":200,\"outerVlan\":100,\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "Macvlan NAD can be created on a cluster network with the datapath Macvlan",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathMacvlan},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"macvlan\",\"master\":\"test-cn.100\",\"mode\":\"bridge\",\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "Macvlan NAD can't be created on a cluster network with the datapath VLANFiltering",
+			returnErr: true,
+			errKey:    "require the datapath",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathVLANFiltering},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"macvlan\",\"master\":\"test-cn.100\",\"mode\":\"bridge\",\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "Trunk NAD can't be created on a cluster network with the datapath Ipvlan",
+			returnErr: true,
+			errKey:    "VLAN trunk is not supported",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathIpvlan},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"vlan\":0,\"vlanTrunk\":[{\"minID\":100,\"maxID\":199}],\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "OVS trunk NAD can be created on a cluster network with the datapath OVS",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathOVS},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"ovs\",\"bridge\":\"test-cn-br\",\"trunk\":[{\"minID\":100,\"maxID\":199}],\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "OVS NAD can't be created on a cluster network with the datapath VLANFiltering",
+			returnErr: true,
+			errKey:    "require the datapath OVS",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathVLANFiltering},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"ovs\",\"bridge\":\"test-cn-br\",\"vlan\":100,\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "QinQ NAD can't be created on a cluster network with the datapath OVS",
+			returnErr: true,
+			errKey:    "QinQ is not supported by the datapath OVS",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathOVS},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"ovs\",\"bridge\":\"test-cn-br\",\"vlan\":200,\"outerVlan\":100,\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "NAD can be created as the cluster network raises its VID cap",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{MaxVIDs: 1000},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":0,\"vlanTrunk\":[{\"minID\":100,\"maxID\":699}],\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "NAD can't be created as its MTU exceeds the maximum MTU of the cluster network",
+			returnErr: true,
+			errKey:    "the MTU 9000 of nad test/net1-vlan exceeds the maximum MTU 1500",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{MaxMTU: 1500},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":300,\"mtu\":9000,\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "NAD can be created with a QoS marking",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "voice-b",
+					Namespace:   testNamespace,
+					Annotations: map[string]string{utils.KeyQoSMarking: `{"pcp":5,"dscp":46}`},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"voice-b\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":300,\"ipam\":{}}",
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNadName,
+					Namespace:   testNamespace,
+					Annotations: map[string]string{utils.KeyQoSMarking: `{"pcp":5,"dscp":46}`},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":300,\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "NAD can't be created as another NAD marks the VID differently",
+			returnErr: true,
+			errKey:    "is marked differently by nad test/voice-b",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "voice-b",
+					Namespace:   testNamespace,
+					Annotations: map[string]string{utils.KeyQoSMarking: `{"pcp":5}`},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"voice-b\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":300,\"ipam\":{}}",
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "voice-c",
+					Namespace:   testNamespace,
+					Annotations: map[string]string{utils.KeyQoSMarking: `{"pcp":3}`},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"voice-c\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":0,\"vlanTrunk\":[{\"minID\":290,\"maxID\":310}],\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "NAD can't be created as an untagged network can't be marked",
+			returnErr: true,
+			errKey:    "only allowed on the VLAN networks",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNadName,
+					Namespace:   testNamespace,
+					Annotations: map[string]string{utils.KeyQoSMarking: `{"dscp":46}`},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"net1-vlan\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":0,\"ipam\":{}}",
+				},
+			},
+		},
 		{
 			name:      "NAD can't be created as trunk vid error incorrect trunk minID",
 			returnErr: true,
@@ -594,6 +1078,11 @@ func TestCreateNAD(t *testing.T) {
 				_, err := cnClient.Create(tc.currentCN)
 				assert.NoError(t, err)
 			}
+			if tc.currentNAD != nil {
+				_, err := nchclientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions(tc.currentNAD.Namespace).Create(
+					context.TODO(), tc.currentNAD, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
 
 			validator := NewNadValidator(vmCache, vmiCache, cnCache, vcCache, subnetCache, true, hncCache, nadCache)
 
@@ -946,3 +1435,141 @@ func TestDeleteNAD(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteNADReferencedByShortName(t *testing.T) {
+	newVmi := func(namespace, name, networkName string) *kubevirtv1.VirtualMachineInstance {
+		return &kubevirtv1.VirtualMachineInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: kubevirtv1.VirtualMachineInstanceSpec{
+				Networks: []kubevirtv1.Network{{
+					Name: "nic-1",
+					NetworkSource: kubevirtv1.NetworkSource{
+						Multus: &kubevirtv1.MultusNetwork{NetworkName: networkName},
+					},
+				}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		returnErr bool
+		errKey    string
+		vmis      []*kubevirtv1.VirtualMachineInstance
+	}{
+		{
+			name:      "NAD can't be deleted as a VMI in the same namespace refers to it by the short name",
+			returnErr: true,
+			errKey:    "still used by VM(s) test/vm1, test/vm2",
+			vmis: []*kubevirtv1.VirtualMachineInstance{
+				newVmi(testNamespace, testVMName, testNadName),
+				newVmi(testNamespace, "vm2", testNamespace+"/"+testNadName),
+			},
+		},
+		{
+			name:      "NAD can be deleted as the VMI refers to the NAD of the same name in another namespace",
+			returnErr: false,
+			vmis: []*kubevirtv1.VirtualMachineInstance{
+				newVmi("other", testVMName, testNadName),
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nchclientset := fake.NewSimpleClientset()
+			vmCache := fakeclients.VirtualMachineCache(nchclientset.KubevirtV1().VirtualMachines)
+			vmiCache := fakeclients.VirtualMachineInstanceCache(nchclientset.KubevirtV1().VirtualMachineInstances)
+			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+			subnetCache := fakeclients.SubnetCache(nchclientset.KubeovnV1().Subnets)
+			nadCache := fakeclients.NetworkAttachmentDefinitionCache(nchclientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions)
+			hncCache := fakeclients.HostNetworkConfigCache(nchclientset.NetworkV1beta1().HostNetworkConfigs)
+			validator := NewNadValidator(vmCache, vmiCache, cnCache, vcCache, subnetCache, true, hncCache, nadCache)
+
+			for _, vmi := range tc.vmis {
+				assert.NoError(t, nchclientset.Tracker().Add(vmi))
+			}
+
+			err := validator.Delete(nil, &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{Config: testNadConfig},
+			})
+			assert.True(t, tc.returnErr == (err != nil))
+			if tc.returnErr && err != nil {
+				assert.True(t, strings.Contains(err.Error(), tc.errKey), err.Error())
+			}
+		})
+	}
+}
+
+func TestUpdateNADUsedByVmi(t *testing.T) {
+	tests := []struct {
+		name      string
+		returnErr bool
+		errKey    string
+		config    string
+	}{
+		{
+			name:      "VLAN ID of the NAD can be changed while a VMI uses it",
+			returnErr: false,
+			config:    strings.Replace(testNadConfig, "\"vlan\":300", "\"vlan\":400", 1),
+		},
+		{
+			name:      "other params of the NAD can't be changed while a VMI uses it",
+			returnErr: true,
+			errKey:    "still used by VM(s) test/vm1",
+			config:    strings.Replace(testNadConfig, "\"promiscMode\":true", "\"promiscMode\":false", 1),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nchclientset := fake.NewSimpleClientset()
+			vmCache := fakeclients.VirtualMachineCache(nchclientset.KubevirtV1().VirtualMachines)
+			vmiCache := fakeclients.VirtualMachineInstanceCache(nchclientset.KubevirtV1().VirtualMachineInstances)
+			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+			subnetCache := fakeclients.SubnetCache(nchclientset.KubeovnV1().Subnets)
+			nadCache := fakeclients.NetworkAttachmentDefinitionCache(nchclientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions)
+			hncCache := fakeclients.HostNetworkConfigCache(nchclientset.NetworkV1beta1().HostNetworkConfigs)
+			validator := NewNadValidator(vmCache, vmiCache, cnCache, vcCache, subnetCache, true, hncCache, nadCache)
+
+			assert.NoError(t, nchclientset.Tracker().Add(&networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+			}))
+			assert.NoError(t, nchclientset.Tracker().Add(&kubevirtv1.VirtualMachineInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: testVMName, Namespace: testNamespace},
+				Spec: kubevirtv1.VirtualMachineInstanceSpec{
+					Networks: []kubevirtv1.Network{{
+						Name: "nic-1",
+						NetworkSource: kubevirtv1.NetworkSource{
+							Multus: &kubevirtv1.MultusNetwork{NetworkName: testNamespace + "/" + testNadName},
+						},
+					}},
+				},
+			}))
+
+			oldNad := &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{Config: testNadConfig},
+			}
+			newNad := oldNad.DeepCopy()
+			newNad.Spec.Config = tc.config
+
+			err := validator.Update(nil, oldNad, newNad)
+			assert.True(t, tc.returnErr == (err != nil))
+			if tc.returnErr && err != nil {
+				assert.True(t, strings.Contains(err.Error(), tc.errKey), err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package nicclaim
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/harvester/webhook/pkg/server/admission"
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/matcher"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	createErr = "can't create networkInterfaceClaim %s because %w"
+	updateErr = "can't update networkInterfaceClaim %s because %w"
+)
+
+type Validator struct {
+	admission.DefaultValidator
+
+	nicClaimCache ctlnetworkv1.NetworkInterfaceClaimCache
+	vcCache       ctlnetworkv1.VlanConfigCache
+	vsCache       ctlnetworkv1.VlanStatusCache
+}
+
+func NewNetworkInterfaceClaimValidator(
+	nicClaimCache ctlnetworkv1.NetworkInterfaceClaimCache,
+	vcCache ctlnetworkv1.VlanConfigCache,
+	vsCache ctlnetworkv1.VlanStatusCache,
+) *Validator {
+	return &Validator{
+		nicClaimCache: nicClaimCache,
+		vcCache:       vcCache,
+		vsCache:       vsCache,
+	}
+}
+
+var _ admission.Validator = &Validator{}
+
+func (v *Validator) Create(_ *admission.Request, newObj runtime.Object) error {
+	claim := newObj.(*networkv1.NetworkInterfaceClaim)
+
+	if err := v.validate(claim); err != nil {
+		return fmt.Errorf(createErr, claim.Name, err)
+	}
+
+	return nil
+}
+
+func (v *Validator) Update(_ *admission.Request, oldObj, newObj runtime.Object) error {
+	oldClaim := oldObj.(*networkv1.NetworkInterfaceClaim)
+	newClaim := newObj.(*networkv1.NetworkInterfaceClaim)
+
+	// ignore the update if the resource is being deleted
+	if newClaim.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if oldClaim.Spec.Node != newClaim.Spec.Node {
+		return fmt.Errorf(updateErr, newClaim.Name, fmt.Errorf("the node can't be changed"))
+	}
+
+	if err := v.validate(newClaim); err != nil {
+		return fmt.Errorf(updateErr, newClaim.Name, err)
+	}
+
+	return nil
+}
+
+func (v *Validator) validate(claim *networkv1.NetworkInterfaceClaim) error {
+	if err := checkDuplicatedNICs(claim); err != nil {
+		return err
+	}
+
+	if err := v.checkClaimedNICs(claim); err != nil {
+		return err
+	}
+
+	return v.checkVlanConfigs(claim)
+}
+
+func checkDuplicatedNICs(claim *networkv1.NetworkInterfaceClaim) error {
+	nics := make(map[string]bool, len(claim.Spec.NICs))
+	for _, nic := range claim.Spec.NICs {
+		if nic == "" {
+			return fmt.Errorf("the NIC name can't be empty")
+		}
+		if nics[nic] {
+			return fmt.Errorf("NIC %s is duplicated", nic)
+		}
+		nics[nic] = true
+	}
+
+	return nil
+}
+
+// checkClaimedNICs denies claiming the NICs which have been claimed by another claim on the same node
+func (v *Validator) checkClaimedNICs(claim *networkv1.NetworkInterfaceClaim) error {
+	claimed, err := utils.NICClaimsOnNode(v.nicClaimCache, claim.Spec.Node)
+	if err != nil {
+		return err
+	}
+
+	conflicts := make([]string, 0)
+	for _, nic := range claim.Spec.NICs {
+		if other, ok := claimed[nic]; ok && other.Name != claim.Name {
+			conflicts = append(conflicts, fmt.Sprintf("NIC %s is claimed by %s with claim %s", nic, other.Spec.Owner, other.Name))
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("%s", strings.Join(conflicts, "; "))
+	}
+
+	return nil
+}
+
+// checkVlanConfigs denies claiming the NICs which are used by the vlanconfigs on the node, both the vlanconfigs
+// matching the node and the ones which have actually taken effect on the node are taken into account
+func (v *Validator) checkVlanConfigs(claim *networkv1.NetworkInterfaceClaim) error {
+	node := claim.Spec.Node
+	users := make(map[string]*networkv1.VlanConfig)
+
+	vcs, err := v.vcCache.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, vc := range vcs {
+		if vc.DeletionTimestamp != nil {
+			continue
+		}
+		nodes, err := matcher.MatchedNodesOf(vc)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(nodes, node) {
+			users[vc.Name] = vc
+		}
+	}
+
+	vss, err := v.vsCache.List(labels.Set(map[string]string{
+		utils.KeyNodeLabel: utils.LabelValue(node),
+	}).AsSelector())
+	if err != nil {
+		return err
+	}
+	for _, vs := range vss {
+		vc, err := v.vcCache.Get(vs.Status.VlanConfig)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		users[vc.Name] = vc
+	}
+
+	conflicts := make([]string, 0)
+	for _, vc := range users {
+		for _, nic := range uplinkNICs(vc) {
+			if slices.Contains(claim.Spec.NICs, nic) {
+				conflicts = append(conflicts, fmt.Sprintf("NIC %s is used by vlanconfig %s", nic, vc.Name))
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("%s on node %s", strings.Join(conflicts, "; "), node)
+	}
+
+	return nil
+}
+
+func uplinkNICs(vc *networkv1.VlanConfig) []string {
+	if vc.Spec.Uplink.FabricB == nil {
+		return vc.Spec.Uplink.NICs
+	}
+
+	nics := make([]string, 0, len(vc.Spec.Uplink.NICs)+len(vc.Spec.Uplink.FabricB.NICs))
+	nics = append(nics, vc.Spec.Uplink.NICs...)
+	return append(nics, vc.Spec.Uplink.FabricB.NICs...)
+}
+
+func (v *Validator) Resource() admission.Resource {
+	return admission.Resource{
+		Names:      []string{"networkinterfaceclaims"},
+		Scope:      admissionregv1.ClusterScope,
+		APIGroup:   networkv1.SchemeGroupVersion.Group,
+		APIVersion: networkv1.SchemeGroupVersion.Version,
+		ObjectType: &networkv1.NetworkInterfaceClaim{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
+			admissionregv1.Update,
+		},
+	}
+}
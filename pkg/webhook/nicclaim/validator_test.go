@@ -0,0 +1,191 @@
+package nicclaim
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+	"github.com/harvester/harvester-network-controller/pkg/utils/fakeclients"
+)
+
+const (
+	testNode      = "node1"
+	testClaimName = "sriov-node1"
+	testOwner     = "sriov-network-operator"
+)
+
+func newClaim(name, node string, nics ...string) *networkv1.NetworkInterfaceClaim {
+	return &networkv1.NetworkInterfaceClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: networkv1.NetworkInterfaceClaimSpec{
+			Node:  node,
+			NICs:  nics,
+			Owner: testOwner,
+		},
+	}
+}
+
+func TestCreateNetworkInterfaceClaim(t *testing.T) {
+	tests := []struct {
+		name         string
+		returnErr    bool
+		errKey       string
+		currentClaim *networkv1.NetworkInterfaceClaim
+		currentVC    *networkv1.VlanConfig
+		currentVS    *networkv1.VlanStatus
+		newClaim     *networkv1.NetworkInterfaceClaim
+	}{
+		{
+			name:      "NetworkInterfaceClaim can be created",
+			returnErr: false,
+			errKey:    "",
+			newClaim:  newClaim(testClaimName, testNode, "eth1", "eth2"),
+		},
+		{
+			name:      "NetworkInterfaceClaim can't be created as the NIC is duplicated",
+			returnErr: true,
+			errKey:    "NIC eth1 is duplicated",
+			newClaim:  newClaim(testClaimName, testNode, "eth1", "eth1"),
+		},
+		{
+			name:         "NetworkInterfaceClaim can't be created as the NIC is claimed by another claim on the node",
+			returnErr:    true,
+			errKey:       "NIC eth1 is claimed by sriov-network-operator with claim other",
+			currentClaim: newClaim("other", testNode, "eth1"),
+			newClaim:     newClaim(testClaimName, testNode, "eth1", "eth2"),
+		},
+		{
+			name:         "NetworkInterfaceClaim can be created as the NIC is claimed on another node",
+			returnErr:    false,
+			errKey:       "",
+			currentClaim: newClaim("other", "node2", "eth1"),
+			newClaim:     newClaim(testClaimName, testNode, "eth1"),
+		},
+		{
+			name:      "NetworkInterfaceClaim can't be created as the NIC is used by a vlanconfig matching the node",
+			returnErr: true,
+			errKey:    "NIC eth2 is used by vlanconfig test-vc on node node1",
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-vc",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "test-cn",
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth2", "eth3"},
+					},
+				},
+			},
+			newClaim: newClaim(testClaimName, testNode, "eth1", "eth2"),
+		},
+		{
+			name:      "NetworkInterfaceClaim can't be created as the NIC is still used by a vlanconfig on the node",
+			returnErr: true,
+			errKey:    "NIC eth2 is used by vlanconfig test-vc on node node1",
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-vc",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node2\"]"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "test-cn",
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth2"},
+					},
+				},
+			},
+			currentVS: &networkv1.VlanStatus{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test-vs",
+					Labels: map[string]string{utils.KeyNodeLabel: testNode},
+				},
+				Status: networkv1.VlStatus{
+					ClusterNetwork: "test-cn",
+					VlanConfig:     "test-vc",
+					Node:           testNode,
+				},
+			},
+			newClaim: newClaim(testClaimName, testNode, "eth2"),
+		},
+		{
+			name:      "NetworkInterfaceClaim can be created as the vlanconfig uses the NIC on other nodes",
+			returnErr: false,
+			errKey:    "",
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-vc",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node2\"]"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "test-cn",
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth2"},
+					},
+				},
+			},
+			newClaim: newClaim(testClaimName, testNode, "eth2"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nchclientset := fake.NewSimpleClientset()
+			nicClaimCache := fakeclients.NetworkInterfaceClaimCache(nchclientset.NetworkV1beta1().NetworkInterfaceClaims)
+			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
+
+			// client to inject test data
+			nicClaimClient := fakeclients.NetworkInterfaceClaimClient(nchclientset.NetworkV1beta1().NetworkInterfaceClaims)
+			vcClient := fakeclients.VlanConfigClient(nchclientset.NetworkV1beta1().VlanConfigs)
+			vsClient := fakeclients.VlanStatusClient(nchclientset.NetworkV1beta1().VlanStatuses)
+
+			if tc.currentClaim != nil {
+				_, err := nicClaimClient.Create(tc.currentClaim)
+				assert.NoError(t, err)
+			}
+			if tc.currentVC != nil {
+				_, err := vcClient.Create(tc.currentVC)
+				assert.NoError(t, err)
+			}
+			if tc.currentVS != nil {
+				_, err := vsClient.Create(tc.currentVS)
+				assert.NoError(t, err)
+			}
+			validator := NewNetworkInterfaceClaimValidator(nicClaimCache, vcCache, vsCache)
+
+			err := validator.Create(nil, tc.newClaim)
+			assert.True(t, tc.returnErr == (err != nil))
+			if tc.returnErr && err != nil {
+				assert.True(t, strings.Contains(err.Error(), tc.errKey), err.Error())
+			}
+		})
+	}
+}
+
+func TestUpdateNetworkInterfaceClaim(t *testing.T) {
+	nchclientset := fake.NewSimpleClientset()
+	nicClaimCache := fakeclients.NetworkInterfaceClaimCache(nchclientset.NetworkV1beta1().NetworkInterfaceClaims)
+	vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+	vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
+	nicClaimClient := fakeclients.NetworkInterfaceClaimClient(nchclientset.NetworkV1beta1().NetworkInterfaceClaims)
+
+	oldClaim := newClaim(testClaimName, testNode, "eth1")
+	_, err := nicClaimClient.Create(oldClaim)
+	assert.NoError(t, err)
+	validator := NewNetworkInterfaceClaimValidator(nicClaimCache, vcCache, vsCache)
+
+	// the claim doesn't conflict with itself
+	assert.NoError(t, validator.Update(nil, oldClaim, newClaim(testClaimName, testNode, "eth1", "eth2")))
+
+	err = validator.Update(nil, oldClaim, newClaim(testClaimName, "node2", "eth1"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the node can't be changed")
+}
@@ -0,0 +1,108 @@
+package packetcapture
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/harvester/webhook/pkg/server/admission"
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	createErr = "can't create packetCapture %s because %w"
+	updateErr = "can't update packetCapture %s because %w"
+)
+
+type Validator struct {
+	admission.DefaultValidator
+
+	cnCache ctlnetworkv1.ClusterNetworkCache
+	vsCache ctlnetworkv1.VlanStatusCache
+}
+
+func NewPacketCaptureValidator(cnCache ctlnetworkv1.ClusterNetworkCache, vsCache ctlnetworkv1.VlanStatusCache) *Validator {
+	return &Validator{
+		cnCache: cnCache,
+		vsCache: vsCache,
+	}
+}
+
+var _ admission.Validator = &Validator{}
+
+func (v *Validator) Create(_ *admission.Request, newObj runtime.Object) error {
+	pc := newObj.(*networkv1.PacketCapture)
+
+	if err := v.validate(pc); err != nil {
+		return fmt.Errorf(createErr, pc.Name, err)
+	}
+
+	return nil
+}
+
+// Update denies changing the spec, the capture runs only once
+func (v *Validator) Update(_ *admission.Request, oldObj, newObj runtime.Object) error {
+	oldPC := oldObj.(*networkv1.PacketCapture)
+	newPC := newObj.(*networkv1.PacketCapture)
+
+	if newPC.DeletionTimestamp != nil || reflect.DeepEqual(oldPC.Spec, newPC.Spec) {
+		return nil
+	}
+
+	return fmt.Errorf(updateErr, newPC.Name, fmt.Errorf("the spec can't be changed, create another packet capture instead"))
+}
+
+func (v *Validator) validate(pc *networkv1.PacketCapture) error {
+	cn, err := v.cnCache.Get(pc.Spec.ClusterNetwork)
+	if err != nil {
+		return fmt.Errorf("it refers to a none-existing cluster network %s or error %w", pc.Spec.ClusterNetwork, err)
+	}
+
+	if pc.Spec.Interface != "" {
+		// the ports of the VMs are attached to the VLAN of the Linux bridge untagged
+		if pc.Spec.VID != 0 {
+			return fmt.Errorf("the VID only filters the frames of the uplink, it can't be set with the interface %s",
+				pc.Spec.Interface)
+		}
+		if utils.IsBridgeless(cn) || utils.IsOVS(cn) {
+			return fmt.Errorf("cluster network %s has no Linux bridge with the datapath %s, only its uplink can be captured",
+				cn.Name, cn.Spec.Datapath)
+		}
+	}
+
+	// the management network is set up on every node without any vlanconfig
+	if cn.Name == utils.ManagementClusterNetworkName {
+		return nil
+	}
+	vss, err := v.vsCache.List(labels.Set(map[string]string{
+		utils.KeyClusterNetworkLabel: cn.Name,
+		utils.KeyNodeLabel:           utils.LabelValue(pc.Spec.NodeName),
+	}).AsSelector())
+	if err != nil {
+		return err
+	}
+	if len(vss) == 0 {
+		return fmt.Errorf("cluster network %s is not set up on node %s", cn.Name, pc.Spec.NodeName)
+	}
+
+	return nil
+}
+
+func (v *Validator) Resource() admission.Resource {
+	return admission.Resource{
+		Names:      []string{"packetcaptures"},
+		Scope:      admissionregv1.ClusterScope,
+		APIGroup:   networkv1.SchemeGroupVersion.Group,
+		APIVersion: networkv1.SchemeGroupVersion.Version,
+		ObjectType: &networkv1.PacketCapture{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
+			admissionregv1.Update,
+		},
+	}
+}
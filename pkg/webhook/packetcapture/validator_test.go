@@ -0,0 +1,141 @@
+package packetcapture
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+	"github.com/harvester/harvester-network-controller/pkg/utils/fakeclients"
+)
+
+const (
+	testNode = "node1"
+	testCN   = "data"
+	testPC   = "pcap-data"
+)
+
+func newPacketCapture(cn, node string, vid uint16, intf string) *networkv1.PacketCapture {
+	return &networkv1.PacketCapture{
+		ObjectMeta: metav1.ObjectMeta{Name: testPC},
+		Spec: networkv1.PacketCaptureSpec{
+			NodeName:        node,
+			ClusterNetwork:  cn,
+			VID:             vid,
+			Interface:       intf,
+			DurationSeconds: 60,
+			MaxSizeMiB:      16,
+		},
+	}
+}
+
+func newClusterNetwork(name string, dp networkv1.Datapath) *networkv1.ClusterNetwork {
+	return &networkv1.ClusterNetwork{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       networkv1.ClusterNetworkSpec{Datapath: dp},
+	}
+}
+
+func TestCreatePacketCapture(t *testing.T) {
+	tests := []struct {
+		name      string
+		returnErr bool
+		errKey    string
+		currentCN *networkv1.ClusterNetwork
+		newPC     *networkv1.PacketCapture
+	}{
+		{
+			name:      "the uplink can be captured on a VID",
+			currentCN: newClusterNetwork(testCN, networkv1.DatapathVLANFiltering),
+			newPC:     newPacketCapture(testCN, testNode, 100, ""),
+		},
+		{
+			name:      "a port of the bridge can be captured",
+			currentCN: newClusterNetwork(testCN, networkv1.DatapathVLANFiltering),
+			newPC:     newPacketCapture(testCN, testNode, 0, "tap0"),
+		},
+		{
+			name:      "the management network is set up on every node",
+			currentCN: newClusterNetwork(utils.ManagementClusterNetworkName, ""),
+			newPC:     newPacketCapture(utils.ManagementClusterNetworkName, "node2", 0, ""),
+		},
+		{
+			name:      "the cluster network doesn't exist",
+			returnErr: true,
+			errKey:    "none-existing cluster network",
+			newPC:     newPacketCapture(testCN, testNode, 0, ""),
+		},
+		{
+			name:      "the cluster network is not set up on the node",
+			returnErr: true,
+			errKey:    "cluster network data is not set up on node node2",
+			currentCN: newClusterNetwork(testCN, networkv1.DatapathVLANFiltering),
+			newPC:     newPacketCapture(testCN, "node2", 0, ""),
+		},
+		{
+			name:      "the VID can't filter the frames of a port of the bridge",
+			returnErr: true,
+			errKey:    "the VID only filters the frames of the uplink",
+			currentCN: newClusterNetwork(testCN, networkv1.DatapathVLANFiltering),
+			newPC:     newPacketCapture(testCN, testNode, 100, "tap0"),
+		},
+		{
+			name:      "a bridge-less cluster network has no port to capture",
+			returnErr: true,
+			errKey:    "has no Linux bridge with the datapath Macvlan",
+			currentCN: newClusterNetwork(testCN, networkv1.DatapathMacvlan),
+			newPC:     newPacketCapture(testCN, testNode, 0, "tap0"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nchclientset := fake.NewSimpleClientset()
+			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
+			cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
+			vsClient := fakeclients.VlanStatusClient(nchclientset.NetworkV1beta1().VlanStatuses)
+
+			if tc.currentCN != nil {
+				_, err := cnClient.Create(tc.currentCN)
+				assert.NoError(t, err)
+			}
+			_, err := vsClient.Create(&networkv1.VlanStatus{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "data-vc-node1",
+					Labels: map[string]string{
+						utils.KeyClusterNetworkLabel: testCN,
+						utils.KeyNodeLabel:           testNode,
+					},
+				},
+			})
+			assert.NoError(t, err)
+
+			validator := NewPacketCaptureValidator(cnCache, vsCache)
+			err = validator.Create(nil, tc.newPC)
+			assert.Equal(t, tc.returnErr, err != nil, err)
+			if tc.returnErr && err != nil {
+				assert.Contains(t, err.Error(), tc.errKey)
+			}
+		})
+	}
+}
+
+func TestUpdatePacketCapture(t *testing.T) {
+	nchclientset := fake.NewSimpleClientset()
+	validator := NewPacketCaptureValidator(fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks),
+		fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses))
+
+	oldPC := newPacketCapture(testCN, testNode, 100, "")
+	withStatus := oldPC.DeepCopy()
+	withStatus.Status.Phase = networkv1.PacketCaptureRunning
+	// the agent records the status
+	assert.NoError(t, validator.Update(nil, oldPC, withStatus))
+
+	err := validator.Update(nil, oldPC, newPacketCapture(testCN, testNode, 200, ""))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the spec can't be changed")
+}
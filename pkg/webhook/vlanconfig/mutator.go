@@ -1,17 +1,18 @@
 package vlanconfig
 
 import (
-	"encoding/json"
 	"fmt"
+	"reflect"
 
 	"github.com/harvester/webhook/pkg/server/admission"
 	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
-	"k8s.io/apimachinery/pkg/labels"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/selection"
 
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/matcher"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
 
@@ -19,25 +20,34 @@ type Mutator struct {
 	admission.DefaultMutator
 
 	nodeCache ctlcorev1.NodeCache
+	cnCache   ctlnetworkv1.ClusterNetworkCache
 }
 
 var _ admission.Mutator = &Mutator{}
 
-func NewVlanConfigMutator(nodeCache ctlcorev1.NodeCache) *Mutator {
+func NewVlanConfigMutator(nodeCache ctlcorev1.NodeCache, cnCache ctlnetworkv1.ClusterNetworkCache) *Mutator {
 	return &Mutator{
 		nodeCache: nodeCache,
+		cnCache:   cnCache,
 	}
 }
 
 func (m *Mutator) Create(_ *admission.Request, newObj runtime.Object) (admission.Patch, error) {
 	vlanConfig := newObj.(*networkv1.VlanConfig)
 
+	// inherit the uplink defaults before matching nodes, both of them patch the annotations
+	inheritPatch, err := m.inheritUplinkDefaults(nil, vlanConfig)
+	if err != nil {
+		return nil, fmt.Errorf(createErr, vlanConfig.Name, err)
+	}
+
 	annotationPatch, err := m.matchNodes(vlanConfig)
 	if err != nil {
 		return nil, fmt.Errorf(createErr, vlanConfig.Name, err)
 	}
 
-	return append(getCnLabelPatch(vlanConfig), annotationPatch...), nil
+	patch := append(getCnLabelPatch(vlanConfig), inheritPatch...)
+	return append(patch, annotationPatch...), nil
 }
 
 func (m *Mutator) Update(_ *admission.Request, oldObj, newObj runtime.Object) (admission.Patch, error) {
@@ -55,12 +65,18 @@ func (m *Mutator) Update(_ *admission.Request, oldObj, newObj runtime.Object) (a
 		cnLabelPatch = getCnLabelPatch(newVc)
 	}
 
-	annotationPatch, err := m.matchNodes(newVc)
+	inheritPatch, err := m.inheritUplinkDefaults(oldVc, newVc)
 	if err != nil {
 		return nil, fmt.Errorf(updateErr, newVc.Name, err)
 	}
 
-	return append(cnLabelPatch, annotationPatch...), nil
+	annotationPatch, err = m.matchNodes(newVc)
+	if err != nil {
+		return nil, fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	patch := append(cnLabelPatch, inheritPatch...)
+	return append(patch, annotationPatch...), nil
 }
 
 func getCnLabelPatch(v *networkv1.VlanConfig) admission.Patch {
@@ -82,13 +98,57 @@ func getCnLabelPatch(v *networkv1.VlanConfig) admission.Patch {
 		}}
 }
 
+// inheritUplinkDefaults fills the uplink sections with the defaults of the cluster network, and records the
+// inherited sections into the annotations of the vlanconfig in place
+func (m *Mutator) inheritUplinkDefaults(oldVc, vc *networkv1.VlanConfig) (admission.Patch, error) {
+	cn, err := m.cnCache.Get(vc.Spec.ClusterNetwork)
+	if apierrors.IsNotFound(err) {
+		// leave it to the validator
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	uplink, inherited := utils.InheritUplinkDefaults(oldVc, vc, cn.Spec.UplinkDefaults)
+
+	patch := admission.Patch{}
+	patch = append(patch, uplinkFieldPatch(utils.UplinkFieldLinkAttrs, vc.Spec.Uplink.LinkAttrs, uplink.LinkAttrs)...)
+	patch = append(patch, uplinkFieldPatch(utils.UplinkFieldBondOptions, vc.Spec.Uplink.BondOptions, uplink.BondOptions)...)
+	patch = append(patch, uplinkFieldPatch(utils.UplinkFieldQueueOptions, vc.Spec.Uplink.QueueOptions, uplink.QueueOptions)...)
+
+	if len(inherited) == 0 && vc.Annotations[utils.KeyInheritedUplinkFields] == "" {
+		return patch, nil
+	}
+	if vc.Annotations == nil {
+		vc.Annotations = map[string]string{}
+	}
+	utils.SetInheritedUplinkFields(vc.Annotations, inherited)
+
+	return append(patch, admission.PatchOp{
+		Op:    admission.PatchOpReplace,
+		Path:  "/metadata/annotations",
+		Value: vc.Annotations,
+	}), nil
+}
+
+func uplinkFieldPatch[T any](field string, current, desired *T) admission.Patch {
+	if reflect.DeepEqual(current, desired) {
+		return nil
+	}
+
+	path := "/spec/uplink/" + field
+	if desired == nil {
+		return admission.Patch{admission.PatchOp{Op: admission.PatchOpRemove, Path: path}}
+	}
+
+	return admission.Patch{admission.PatchOp{Op: admission.PatchOpAdd, Path: path, Value: desired}}
+}
+
 func (m *Mutator) matchNodes(vc *networkv1.VlanConfig) (admission.Patch, error) {
-	selector := labels.Set(vc.Spec.NodeSelector).AsSelector()
-	witnessFilter, err := labels.NewRequirement(utils.HarvesterWitnessNodeLabelKey, selection.DoesNotExist, nil)
+	selector, err := matcher.Selector(vc.Spec.NodeSelector)
 	if err != nil {
 		return nil, err
 	}
-	selector = selector.Add(*witnessFilter)
 	nodes, err := m.nodeCache.List(selector)
 	if err != nil {
 		return nil, err
@@ -111,11 +171,11 @@ func matchedNodesToPatch(vc *networkv1.VlanConfig, matchedNodes []string) (admis
 		annotations = map[string]string{}
 	}
 
-	nodesBytes, err := json.Marshal(matchedNodes)
+	value, err := matcher.EncodeMatchedNodes(matchedNodes)
 	if err != nil {
 		return nil, err
 	}
-	annotations[utils.KeyMatchedNodes] = string(nodesBytes)
+	annotations[utils.KeyMatchedNodes] = value
 
 	return admission.Patch{
 		admission.PatchOp{
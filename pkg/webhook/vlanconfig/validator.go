@@ -1,22 +1,29 @@
 package vlanconfig
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
+	"net"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/harvester/webhook/pkg/server/admission"
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 
+	"github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/annotations"
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	ctlkubevirtv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubevirt.io/v1"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/matcher"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
 
@@ -29,11 +36,15 @@ const (
 type Validator struct {
 	admission.DefaultValidator
 
-	nadCache ctlcniv1.NetworkAttachmentDefinitionCache
-	vcCache  ctlnetworkv1.VlanConfigCache
-	vsCache  ctlnetworkv1.VlanStatusCache
-	vmiCache ctlkubevirtv1.VirtualMachineInstanceCache
-	cnCache  ctlnetworkv1.ClusterNetworkCache
+	nadCache      ctlcniv1.NetworkAttachmentDefinitionCache
+	vcCache       ctlnetworkv1.VlanConfigCache
+	vsCache       ctlnetworkv1.VlanStatusCache
+	vmiCache      ctlkubevirtv1.VirtualMachineInstanceCache
+	cnCache       ctlnetworkv1.ClusterNetworkCache
+	nicClaimCache ctlnetworkv1.NetworkInterfaceClaimCache
+	lmCache       ctlnetworkv1.LinkMonitorCache
+	nnsCache      ctlnetworkv1.NodeNetworkStateCache
+	nodeCache     ctlcorev1.NodeCache
 }
 
 func NewVlanConfigValidator(
@@ -42,13 +53,21 @@ func NewVlanConfigValidator(
 	vsCache ctlnetworkv1.VlanStatusCache,
 	vmiCache ctlkubevirtv1.VirtualMachineInstanceCache,
 	cnCache ctlnetworkv1.ClusterNetworkCache,
+	nicClaimCache ctlnetworkv1.NetworkInterfaceClaimCache,
+	lmCache ctlnetworkv1.LinkMonitorCache,
+	nnsCache ctlnetworkv1.NodeNetworkStateCache,
+	nodeCache ctlcorev1.NodeCache,
 ) *Validator {
 	return &Validator{
-		nadCache: nadCache,
-		vcCache:  vcCache,
-		vsCache:  vsCache,
-		vmiCache: vmiCache,
-		cnCache:  cnCache,
+		nadCache:      nadCache,
+		vcCache:       vcCache,
+		vsCache:       vsCache,
+		vmiCache:      vmiCache,
+		cnCache:       cnCache,
+		nicClaimCache: nicClaimCache,
+		lmCache:       lmCache,
+		nnsCache:      nnsCache,
+		nodeCache:     nodeCache,
 	}
 }
 
@@ -85,6 +104,78 @@ func (v *Validator) Create(_ *admission.Request, newObj runtime.Object) error {
 		return fmt.Errorf(createErr, vc.Name, err)
 	}
 
+	if err := checkFabricB(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := checkQueueOptions(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := checkBondOptions(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := checkBackupNICs(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := checkNICTuning(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := checkTopologyOverrides(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := checkJumboVerification(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := checkServiceVLAN(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := v.checkOverlayUplink(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := v.checkDatapathUplink(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := v.checkHardwareAddr(vc, nodes); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if _, err := utils.GetCloneRequest(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if _, _, err := annotations.GetTTL(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := v.checkSharedBond(vc, nodes); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := v.checkNICConflicts(vc, nodes); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := v.checkNICClaims(vc, nodes); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := v.checkAgentFeatures(nil, vc, nodes); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
+	if err := v.checkNodeCapabilities(vc, nodes); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
 	return nil
 }
 
@@ -123,6 +214,82 @@ func (v *Validator) Update(_ *admission.Request, oldObj, newObj runtime.Object)
 		return fmt.Errorf(updateErr, newVc.Name, err)
 	}
 
+	if err := checkFabricB(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := checkQueueOptions(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := checkBondOptions(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := checkBackupNICs(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := checkNICTuning(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := checkTopologyOverrides(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := checkJumboVerification(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := checkServiceVLAN(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := v.checkOverlayUplink(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := v.checkDatapathUplink(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := v.checkHardwareAddr(newVc, newNodes); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if _, err := utils.GetCloneRequest(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if _, _, err := annotations.GetTTL(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := v.checkSharedBond(newVc, newNodes); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := v.checkNICConflicts(newVc, newNodes); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := v.checkNICClaims(newVc, newNodes); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := v.checkAgentFeatures(oldVc, newVc, newNodes); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := v.checkNodeCapabilities(newVc, newNodes); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
+	if err := v.checkMigration(oldVc, newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
 	oldNodes, err := getMatchNodes(oldVc)
 	if err != nil {
 		return fmt.Errorf(updateErr, oldVc.Name, err)
@@ -143,6 +310,178 @@ func (v *Validator) Update(_ *admission.Request, oldObj, newObj runtime.Object)
 	return nil
 }
 
+// checkAgentFeatures rejects the features newly used by the vlanconfig if the agent of any matched node doesn't
+// understand them yet, e.g. during an upgrade, instead of letting the agent ignore them silently. The nodes without
+// any vlanstatus are skipped as their agents haven't reported the features.
+func (v *Validator) checkAgentFeatures(oldVc, newVc *networkv1.VlanConfig, nodes mapset.Set[string]) error {
+	newFeatures, err := v.featuresOf(newVc)
+	if err != nil {
+		return err
+	}
+	oldFeatures, err := v.featuresOf(oldVc)
+	if err != nil {
+		return err
+	}
+	required := utils.MissingFeatures(newFeatures, oldFeatures)
+	if len(required) == 0 {
+		return nil
+	}
+
+	lagging := make([]string, 0)
+	missing := mapset.NewSet[string]()
+	for _, node := range nodes.ToSlice() {
+		vss, err := v.vsCache.GetByIndex(utils.VlanStatusByNodeIndex, node)
+		if err != nil {
+			return err
+		}
+		if len(vss) == 0 {
+			continue
+		}
+		// all the vlanstatuses of a node are reported by the same agent, the one reconciled last is the most recent
+		latest := vss[0]
+		for _, vs := range vss[1:] {
+			if latest.Status.LastReconciledAt == nil || (vs.Status.LastReconciledAt != nil &&
+				vs.Status.LastReconciledAt.After(latest.Status.LastReconciledAt.Time)) {
+				latest = vs
+			}
+		}
+		if m := utils.MissingFeatures(required, latest.Status.Features); len(m) > 0 {
+			lagging = append(lagging, node)
+			missing.Append(m...)
+		}
+	}
+	if len(lagging) == 0 {
+		return nil
+	}
+
+	sort.Strings(lagging)
+	features := missing.ToSlice()
+	sort.Strings(features)
+	return fmt.Errorf("the agents on nodes %v don't understand the features %v yet, upgrade them first", lagging, features)
+}
+
+// featuresOf returns the features of the vlanconfig and of its cluster network, which the agents apply along with the
+// vlanconfig
+func (v *Validator) featuresOf(vc *networkv1.VlanConfig) ([]string, error) {
+	if vc == nil {
+		return nil, nil
+	}
+	cn, err := v.cnCache.Get(vc.Spec.ClusterNetwork)
+	if apierrors.IsNotFound(err) {
+		return utils.VlanConfigFeatures(vc), nil
+	} else if err != nil {
+		return nil, err
+	}
+	return append(utils.VlanConfigFeatures(vc), utils.ClusterNetworkFeatures(cn)...), nil
+}
+
+// checkNodeCapabilities rejects the vlanconfig if any matched node physically can't implement it, i.e. the MTU of
+// the uplink exceeds the maximum MTU of an uplink NIC, the service VLAN needs the kernel to stack VLAN tags, or the
+// datapath OVS needs Open vSwitch. The nodes without NodeNetworkState and the NICs not reporting the maximum MTU are
+// skipped.
+func (v *Validator) checkNodeCapabilities(vc *networkv1.VlanConfig, nodes mapset.Set[string]) error {
+	// the datapath of a removed cluster network doesn't matter
+	cn, err := v.cnCache.Get(vc.Spec.ClusterNetwork)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	names := nodes.ToSlice()
+	sort.Strings(names)
+	for _, node := range names {
+		nns, err := v.nnsCache.Get(node)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		effective, err := v.effectiveOn(vc, node)
+		if err != nil {
+			return err
+		}
+		if err := checkCapabilities(effective, cn, node, &nns.Status); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// effectiveOn returns the vlanconfig effective on the node, i.e. with the topology override matching the node labels
+// applied, a node which is gone takes the default uplink
+func (v *Validator) effectiveOn(vc *networkv1.VlanConfig, node string) (*networkv1.VlanConfig, error) {
+	if len(vc.Spec.TopologyOverrides) == 0 {
+		return vc, nil
+	}
+
+	n, err := v.nodeCache.Get(node)
+	if apierrors.IsNotFound(err) {
+		return utils.ApplyTopologyOverride(vc, nil), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return utils.ApplyTopologyOverride(vc, n.Labels), nil
+}
+
+// checkCapabilities checks the vlanconfig effective on the node against the capabilities of the node
+func checkCapabilities(vc *networkv1.VlanConfig, cn *networkv1.ClusterNetwork, node string,
+	status *networkv1.NodeNetworkStateStatus) error {
+	if cn != nil && utils.IsOVS(cn) && !status.Capabilities.OVS {
+		return fmt.Errorf("the datapath %s of cluster network %s requires Open vSwitch, which the agent of node %s can't "+
+			"reach", cn.Spec.Datapath, cn.Name, node)
+	}
+	if vid := vc.Spec.Uplink.ServiceVLAN; vid != 0 && !status.Capabilities.QinQ {
+		return fmt.Errorf("the service VLAN %d requires stacking VLAN tags, which the kernel of node %s doesn't support",
+			vid, node)
+	}
+
+	mtu := utils.MTUDefaultTo(utils.GetMTUFromVlanConfig(vc))
+	for _, name := range uplinkNICs(vc) {
+		for _, nic := range status.NICs {
+			if nic.Name == name && nic.MaxMTU != 0 && mtu > nic.MaxMTU {
+				return fmt.Errorf("the MTU %d exceeds the maximum MTU %d of NIC %s on node %s", mtu, nic.MaxMTU, name, node)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkMigration rejects moving the vlanconfig to another cluster network while its previous move is not finished,
+// i.e. the VLAN of a third cluster network is still being torn down on some nodes
+func (v *Validator) checkMigration(oldVc, newVc *networkv1.VlanConfig) error {
+	if oldVc.Spec.ClusterNetwork == newVc.Spec.ClusterNetwork {
+		return nil
+	}
+
+	vss, err := v.vsCache.List(labels.Set{utils.KeyVlanConfigLabel: utils.LabelValue(oldVc.Name)}.AsSelector())
+	if err != nil {
+		return err
+	}
+
+	pending := make(map[string][]string)
+	for _, vs := range vss {
+		cn := vs.Status.ClusterNetwork
+		if cn != oldVc.Spec.ClusterNetwork && cn != newVc.Spec.ClusterNetwork {
+			pending[cn] = append(pending[cn], vs.Status.Node)
+		}
+	}
+	cns := make([]string, 0, len(pending))
+	for cn := range pending {
+		cns = append(cns, cn)
+	}
+	sort.Strings(cns)
+	if len(cns) > 0 {
+		nodes := pending[cns[0]]
+		sort.Strings(nodes)
+		return fmt.Errorf("the previous migration from cluster network %s is not finished on node(s) %v", cns[0], nodes)
+	}
+
+	return nil
+}
+
 func getAffectedNodes(oldVc, newVc *networkv1.VlanConfig, oldNodes, newNodes mapset.Set[string]) mapset.Set[string] {
 	// when vlanconfig's MTU/uplink/... is changed, all oldNodes are always affected, all vmis on them should be stopped
 	if (oldVc.Spec.ClusterNetwork != newVc.Spec.ClusterNetwork) || !reflect.DeepEqual(oldVc.Spec.Uplink, newVc.Spec.Uplink) {
@@ -194,8 +533,7 @@ func (v *Validator) checkOverlaps(vc *networkv1.VlanConfig, nodes mapset.Set[str
 	}
 	overlapNods := mapset.NewSet[string]()
 	for node := range nodes.Iter() {
-		vsName := utils.Name("", vc.Spec.ClusterNetwork, node)
-		if vs, err := v.vsCache.Get(vsName); err != nil && !apierrors.IsNotFound(err) {
+		if vs, err := utils.GetVlanStatus(v.vsCache, vc.Spec.ClusterNetwork, node); err != nil && !apierrors.IsNotFound(err) {
 			return err
 		} else if err == nil && vs.Status.VlanConfig != vc.Name {
 			// The vlanconfig is found means a vlanconfig with the same cluster network has been taken effect on this node.
@@ -203,6 +541,27 @@ func (v *Validator) checkOverlaps(vc *networkv1.VlanConfig, nodes mapset.Set[str
 		}
 	}
 
+	// the other vlanconfigs may match the same nodes without having taken effect yet
+	vcs, err := v.vcCache.List(labels.Set{utils.KeyClusterNetworkLabel: vc.Spec.ClusterNetwork}.AsSelector())
+	if err != nil {
+		return err
+	}
+	candidates := []*networkv1.VlanConfig{vc}
+	for _, other := range vcs {
+		if other.Name != vc.Name && other.Spec.ClusterNetwork == vc.Spec.ClusterNetwork {
+			candidates = append(candidates, other)
+		}
+	}
+	overlaps, err := matcher.Overlaps(candidates)
+	if err != nil {
+		return err
+	}
+	for _, overlap := range overlaps {
+		if slices.Contains(overlap.VlanConfigs, vc.Name) {
+			overlapNods.Add(overlap.Node)
+		}
+	}
+
 	if overlapNods.Cardinality() > 0 {
 		return fmt.Errorf("it overlaps with other vlanconfigs matching node(s) %v", overlapNods.ToSlice())
 	}
@@ -210,6 +569,555 @@ func (v *Validator) checkOverlaps(vc *networkv1.VlanConfig, nodes mapset.Set[str
 	return nil
 }
 
+// nicUsage records which cluster networks have enslaved a NIC on which nodes, nic -> cluster network -> nodes
+type nicUsage map[string]map[string]mapset.Set[string]
+
+func (u nicUsage) add(nic, cn string, nodes ...string) {
+	if u[nic] == nil {
+		u[nic] = make(map[string]mapset.Set[string])
+	}
+	if u[nic][cn] == nil {
+		u[nic][cn] = mapset.NewSet[string]()
+	}
+	u[nic][cn].Append(nodes...)
+}
+
+// buildNICUsage collects the NICs used by the vlanconfigs of other cluster networks on the given nodes.
+// Both the matched nodes of the vlanconfigs and the vlanstatuses are taken into account, the latter
+// reflects the vlanconfig which has actually taken effect on a node. The NICs of a vlanconfig are the ones
+// effective on each node, the topology overrides may pick different NICs.
+func (v *Validator) buildNICUsage(vc *networkv1.VlanConfig, nodes mapset.Set[string]) (nicUsage, error) {
+	usage := make(nicUsage)
+
+	vcs, err := v.vcCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range vcs {
+		if other.Name == vc.Name || other.Spec.ClusterNetwork == vc.Spec.ClusterNetwork || other.DeletionTimestamp != nil ||
+			sharesBond(vc, other) {
+			continue
+		}
+		otherNodes, err := getMatchNodes(other)
+		if err != nil {
+			return nil, err
+		}
+		for node := range nodes.Intersect(otherNodes).Iter() {
+			effective, err := v.effectiveOn(other, node)
+			if err != nil {
+				return nil, err
+			}
+			for _, nic := range uplinkNICs(effective) {
+				usage.add(nic, other.Spec.ClusterNetwork, node)
+			}
+		}
+	}
+
+	for node := range nodes.Iter() {
+		vss, err := v.vsCache.List(labels.Set(map[string]string{
+			utils.KeyNodeLabel: utils.LabelValue(node),
+		}).AsSelector())
+		if err != nil {
+			return nil, err
+		}
+		for _, vs := range vss {
+			if vs.Status.ClusterNetwork == vc.Spec.ClusterNetwork || vs.Status.VlanConfig == vc.Name {
+				continue
+			}
+			other, err := v.vcCache.Get(vs.Status.VlanConfig)
+			if apierrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			if other.DeletionTimestamp != nil || sharesBond(vc, other) {
+				continue
+			}
+			effective, err := v.effectiveOn(other, node)
+			if err != nil {
+				return nil, err
+			}
+			for _, nic := range uplinkNICs(effective) {
+				usage.add(nic, vs.Status.ClusterNetwork, node)
+			}
+		}
+	}
+
+	return usage, nil
+}
+
+// checkQueueOptions rejects the meaningless combinations of the queue options
+func checkQueueOptions(vc *networkv1.VlanConfig) error {
+	opts := vc.Spec.Uplink.QueueOptions
+	if opts == nil {
+		return nil
+	}
+
+	if opts.PerQueueQdisc != "" && opts.Qdisc != networkv1.QdiscMq {
+		return fmt.Errorf("the per-queue qdisc %s requires the root qdisc %s", opts.PerQueueQdisc, networkv1.QdiscMq)
+	}
+	if opts.Limit != 0 && opts.Qdisc != networkv1.QdiscFq && opts.Qdisc != networkv1.QdiscFqCodel && opts.PerQueueQdisc == "" {
+		return fmt.Errorf("the qdisc limit requires the qdisc %s or %s", networkv1.QdiscFq, networkv1.QdiscFqCodel)
+	}
+	if opts.ApplyToBridge && opts.Qdisc == networkv1.QdiscMq {
+		return fmt.Errorf("the qdisc %s can't be applied to the bridge which has a single TX queue", networkv1.QdiscMq)
+	}
+
+	return nil
+}
+
+// checkBondOptions makes sure the xmit hash policy, the LACP rate and the ARP monitoring apply to the bond mode and
+// the delays are multiples of miimon, the kernel rounds them down otherwise and ignores them if miimon is 0
+func checkBondOptions(vc *networkv1.VlanConfig) error {
+	opts := vc.Spec.Uplink.BondOptions
+	if err := utils.CheckXmitHashPolicy(opts); err != nil {
+		return err
+	}
+	if err := utils.CheckLacpRate(opts); err != nil {
+		return err
+	}
+	if err := utils.CheckArpMonitor(opts); err != nil {
+		return err
+	}
+	if opts == nil || (opts.UpDelay == 0 && opts.DownDelay == 0) {
+		return nil
+	}
+
+	miimon := opts.Miimon
+	if miimon == -1 {
+		miimon = utils.DefaultValueMiimon
+	}
+	// the ARP monitoring turns miimon off
+	if opts.ArpInterval > 0 {
+		miimon = 0
+	}
+	if miimon == 0 {
+		return fmt.Errorf("the bond delays require miimon to be enabled")
+	}
+	if opts.UpDelay%miimon != 0 {
+		return fmt.Errorf("the bond updelay %d is not a multiple of miimon %d", opts.UpDelay, miimon)
+	}
+	if opts.DownDelay%miimon != 0 {
+		return fmt.Errorf("the bond downdelay %d is not a multiple of miimon %d", opts.DownDelay, miimon)
+	}
+
+	return nil
+}
+
+// checkBackupNICs makes sure the backup NICs are NICs of the fabric A uplink in the active-backup mode, and at least
+// one NIC is not a backup NIC to carry the traffic
+func checkBackupNICs(vc *networkv1.VlanConfig) error {
+	backup := vc.Spec.Uplink.BackupNICs
+	if len(backup) == 0 {
+		return nil
+	}
+
+	if opts := vc.Spec.Uplink.BondOptions; opts != nil && opts.Mode != "" && opts.Mode != networkv1.BondMoDeActiveBackup {
+		return fmt.Errorf("the backup NICs require the bond mode %s", networkv1.BondMoDeActiveBackup)
+	}
+	nics := mapset.NewSet[string](vc.Spec.Uplink.NICs...)
+	backupSet := mapset.NewSet[string]()
+	for _, nic := range backup {
+		if !nics.Contains(nic) {
+			return fmt.Errorf("the backup NIC %s is not a NIC of the uplink", nic)
+		}
+		if !backupSet.Add(nic) {
+			return fmt.Errorf("the backup NIC %s is listed more than once", nic)
+		}
+	}
+	if nics.IsSubset(backupSet) {
+		return fmt.Errorf("all NICs of the uplink are backup NICs")
+	}
+
+	return nil
+}
+
+// checkNICTuning makes sure the tuning is only applied to the NICs of the uplink, and at most once per NIC
+func checkNICTuning(vc *networkv1.VlanConfig) error {
+	if len(vc.Spec.Uplink.NICTuning) == 0 {
+		return nil
+	}
+
+	nics := mapset.NewSet[string](uplinkNICs(vc)...)
+	tuned := mapset.NewSet[string]()
+	for _, t := range vc.Spec.Uplink.NICTuning {
+		if !nics.Contains(t.Name) {
+			return fmt.Errorf("the tuned NIC %s is not a NIC of the uplink", t.Name)
+		}
+		if !tuned.Add(t.Name) {
+			return fmt.Errorf("the NIC %s is tuned more than once", t.Name)
+		}
+	}
+
+	return nil
+}
+
+// checkTopologyOverrides makes sure every topology value is overridden at most once and the uplink of each zone is
+// valid on its own. The MTU of a zone can't be less than the MTU of the vlanconfig, which the NADs of the cluster
+// network follow. The vlanconfigs sharing a bond must agree on the bond, so they can't be overridden per zone.
+func checkTopologyOverrides(vc *networkv1.VlanConfig) error {
+	if len(vc.Spec.TopologyOverrides) == 0 {
+		return nil
+	}
+
+	if vc.Spec.Uplink.SharedBond != nil {
+		return fmt.Errorf("the topology overrides can't be applied to the shared bond %s", vc.Spec.Uplink.SharedBond.Name)
+	}
+	mtu := utils.MTUDefaultTo(utils.GetMTUFromVlanConfig(vc))
+	seen := mapset.NewSet[string]()
+	for i := range vc.Spec.TopologyOverrides {
+		override := &vc.Spec.TopologyOverrides[i]
+		key := utils.TopologyKeyOf(override)
+		if errs := validation.IsQualifiedName(key); len(errs) != 0 {
+			return fmt.Errorf("the topology key %s is invalid: %s", key, strings.Join(errs, ", "))
+		}
+		if len(override.Values) == 0 {
+			return fmt.Errorf("the topology override of %s has no value", key)
+		}
+		for _, value := range override.Values {
+			if errs := validation.IsValidLabelValue(value); len(errs) != 0 {
+				return fmt.Errorf("the topology value %s=%s is invalid: %s", key, value, strings.Join(errs, ", "))
+			}
+			if !seen.Add(key + "=" + value) {
+				return fmt.Errorf("the topology %s=%s is overridden more than once", key, value)
+			}
+		}
+		if len(override.BackupNICs) != 0 && len(override.NICs) == 0 {
+			return fmt.Errorf("the backup NICs of topology %s require its NICs", key)
+		}
+		if override.MTU != 0 {
+			if !utils.IsValidMTU(override.MTU) {
+				minMTU, maxMTU := utils.MTUBounds()
+				return fmt.Errorf("the MTU %v of topology %s is out of range [%v..%v]", override.MTU, key, minMTU, maxMTU)
+			}
+			if override.MTU < mtu {
+				return fmt.Errorf("the MTU %v of topology %s is less than the MTU %v of the vlanconfig", override.MTU, key, mtu)
+			}
+		}
+
+		effective := utils.ApplyTopologyOverride(vc, map[string]string{key: override.Values[0]})
+		for _, check := range []func(*networkv1.VlanConfig) error{checkFabricB, checkBondOptions, checkBackupNICs, checkNICTuning} {
+			if err := check(effective); err != nil {
+				return fmt.Errorf("the topology override %s=%s is invalid: %w", key, override.Values[0], err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkJumboVerification makes sure the pings are sent between two distinct unicast IPv4 addresses, and the MTU of the
+// uplink reaches the jumbo MTU on some nodes at least, the verification is skipped below it
+func checkJumboVerification(vc *networkv1.VlanConfig) error {
+	opts := vc.Spec.Uplink.JumboVerification
+	if opts == nil {
+		return nil
+	}
+
+	source, target := net.ParseIP(opts.Source).To4(), net.ParseIP(opts.Target).To4()
+	if source == nil || !source.IsGlobalUnicast() {
+		return fmt.Errorf("the source %q of the jumbo verification is not a unicast IPv4 address", opts.Source)
+	}
+	if target == nil || !target.IsGlobalUnicast() {
+		return fmt.Errorf("the target %q of the jumbo verification is not a unicast IPv4 address", opts.Target)
+	}
+	if source.Equal(target) {
+		return fmt.Errorf("the source and the target of the jumbo verification are both %s", opts.Source)
+	}
+
+	if utils.GetMTUFromVlanConfig(vc) >= utils.JumboMTU {
+		return nil
+	}
+	for _, override := range vc.Spec.TopologyOverrides {
+		if override.MTU >= utils.JumboMTU {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("the jumbo verification requires the MTU %d", utils.JumboMTU)
+}
+
+// checkServiceVLAN makes sure the service VLAN is only delivered on the dedicated bond of fabric A, the shared bond has
+// its own VLAN sub-interface per cluster network and the bond of fabric B would take over the bridge without the tag
+func checkServiceVLAN(vc *networkv1.VlanConfig) error {
+	vid := vc.Spec.Uplink.ServiceVLAN
+	if vid == 0 {
+		return nil
+	}
+
+	if vid < 2 || vid > 4094 {
+		return fmt.Errorf("the service VLAN %d is out of the range [2, 4094]", vid)
+	}
+	if vc.Spec.Uplink.SharedBond != nil {
+		return fmt.Errorf("the service VLAN can't be configured together with the shared bond")
+	}
+	if vc.Spec.Uplink.FabricB != nil {
+		return fmt.Errorf("the service VLAN can't be configured together with fabric B")
+	}
+
+	return nil
+}
+
+// checkOverlayUplink rejects the uplink settings of the physical NICs on a vlanconfig of an overlay cluster network,
+// the VTEP on the internal IP of the node takes the place of the uplink bond
+func (v *Validator) checkOverlayUplink(vc *networkv1.VlanConfig) error {
+	cn, err := v.cnCache.Get(vc.Spec.ClusterNetwork)
+	if err != nil || !utils.IsOverlay(cn) {
+		return nil
+	}
+
+	uplink := vc.Spec.Uplink
+	switch {
+	case len(uplink.NICs) != 0 || len(uplink.BackupNICs) != 0:
+		return fmt.Errorf("the NICs can't be configured on the cluster network %s of type %s", cn.Name, cn.Spec.Type)
+	case uplink.SharedBond != nil:
+		return fmt.Errorf("the shared bond can't be configured on the cluster network %s of type %s", cn.Name, cn.Spec.Type)
+	case uplink.FabricB != nil:
+		return fmt.Errorf("fabric B can't be configured on the cluster network %s of type %s", cn.Name, cn.Spec.Type)
+	case uplink.ServiceVLAN != 0:
+		return fmt.Errorf("the service VLAN can't be configured on the cluster network %s of type %s", cn.Name, cn.Spec.Type)
+	case uplink.JumboVerification != nil:
+		return fmt.Errorf("the jumbo verification can't be configured on the cluster network %s of type %s", cn.Name, cn.Spec.Type)
+	case len(vc.Spec.TopologyOverrides) != 0:
+		return fmt.Errorf("the topology overrides can't be configured on the cluster network %s of type %s", cn.Name, cn.Spec.Type)
+	}
+
+	return nil
+}
+
+// checkDatapathUplink rejects the uplinks other than the dedicated bond of fabric A on a vlanconfig of a bridge-less or
+// OVS cluster network. The macvlan and ipvlan links of the VMs are created on the bond and its VLAN sub-interfaces,
+// and the bond is the trunk port of the OVS bridge, neither is moved between the bonds of the fabrics by netlink.
+func (v *Validator) checkDatapathUplink(vc *networkv1.VlanConfig) error {
+	cn, err := v.cnCache.Get(vc.Spec.ClusterNetwork)
+	if err != nil || (!utils.IsBridgeless(cn) && !utils.IsOVS(cn)) {
+		return nil
+	}
+
+	uplink := vc.Spec.Uplink
+	switch {
+	case uplink.SharedBond != nil:
+		return fmt.Errorf("the shared bond can't be configured on the cluster network %s with the datapath %s", cn.Name, cn.Spec.Datapath)
+	case uplink.FabricB != nil:
+		return fmt.Errorf("fabric B can't be configured on the cluster network %s with the datapath %s", cn.Name, cn.Spec.Datapath)
+	case uplink.ServiceVLAN != 0:
+		return fmt.Errorf("the service VLAN can't be configured on the cluster network %s with the datapath %s", cn.Name, cn.Spec.Datapath)
+	}
+
+	return nil
+}
+
+func hardwareAddrOf(vc *networkv1.VlanConfig) net.HardwareAddr {
+	if vc.Spec.Uplink.LinkAttrs == nil {
+		return nil
+	}
+	return vc.Spec.Uplink.LinkAttrs.HardwareAddr
+}
+
+// checkHardwareAddr makes sure the hardware address of the uplink bond is a unicast MAC not used by the bond of any
+// other vlanconfig, the bond setup would fail or the nodes would fight over the address otherwise. The bonds of the
+// vlanconfigs sharing a bond are the same one, and the bond of each node needs its own address.
+func (v *Validator) checkHardwareAddr(vc *networkv1.VlanConfig, nodes mapset.Set[string]) error {
+	hwaddr := hardwareAddrOf(vc)
+	if len(hwaddr) == 0 {
+		return nil
+	}
+
+	if len(hwaddr) != 6 {
+		return fmt.Errorf("the hardware address %s is not an Ethernet MAC address", hwaddr)
+	}
+	if hwaddr[0]&0x01 != 0 {
+		return fmt.Errorf("the hardware address %s is not a unicast address", hwaddr)
+	}
+	if bytes.Equal(hwaddr, make(net.HardwareAddr, 6)) {
+		return fmt.Errorf("the hardware address %s is all zeros", hwaddr)
+	}
+	if nodes != nil && nodes.Cardinality() > 1 {
+		return fmt.Errorf("the hardware address %s can't be assigned to the bonds of %d nodes", hwaddr, nodes.Cardinality())
+	}
+
+	vcs, err := v.vcCache.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, other := range vcs {
+		if other.Name == vc.Name || other.DeletionTimestamp != nil || sharesBond(vc, other) {
+			continue
+		}
+		if bytes.Equal(hwaddr, hardwareAddrOf(other)) {
+			return fmt.Errorf("the hardware address %s is used by the bond of vlanconfig %s", hwaddr, other.Name)
+		}
+	}
+
+	return nil
+}
+
+// uplinkNICs returns the NICs of all uplink groups
+func uplinkNICs(vc *networkv1.VlanConfig) []string {
+	if vc.Spec.Uplink.FabricB == nil {
+		return vc.Spec.Uplink.NICs
+	}
+
+	nics := make([]string, 0, len(vc.Spec.Uplink.NICs)+len(vc.Spec.Uplink.FabricB.NICs))
+	nics = append(nics, vc.Spec.Uplink.NICs...)
+	return append(nics, vc.Spec.Uplink.FabricB.NICs...)
+}
+
+// checkFabricB ensures the uplink groups of fabric A and fabric B are disjoint
+func checkFabricB(vc *networkv1.VlanConfig) error {
+	fabricB := vc.Spec.Uplink.FabricB
+	if fabricB == nil {
+		return nil
+	}
+
+	if vc.Spec.Uplink.SharedBond != nil {
+		return fmt.Errorf("fabric B can't be configured together with the shared bond")
+	}
+	if len(vc.Spec.Uplink.NICs) == 0 || len(fabricB.NICs) == 0 {
+		return fmt.Errorf("both fabric A and fabric B require at least one NIC")
+	}
+	if common := mapset.NewSet(vc.Spec.Uplink.NICs...).Intersect(mapset.NewSet(fabricB.NICs...)); common.Cardinality() > 0 {
+		nics := common.ToSlice()
+		sort.Strings(nics)
+		return fmt.Errorf("NIC(s) %v can't be in both fabric A and fabric B", nics)
+	}
+
+	return nil
+}
+
+// sharesBond returns true if both vlanconfigs are attached to the same shared bond
+func sharesBond(vc, other *networkv1.VlanConfig) bool {
+	return vc.Spec.Uplink.SharedBond != nil && other.Spec.Uplink.SharedBond != nil &&
+		vc.Spec.Uplink.SharedBond.Name == other.Spec.Uplink.SharedBond.Name
+}
+
+// checkSharedBond ensures the vlanconfigs sharing one bond on the same nodes agree on the bond settings
+// and use different VIDs for their VLAN sub-interfaces
+func (v *Validator) checkSharedBond(vc *networkv1.VlanConfig, nodes mapset.Set[string]) error {
+	sharedBond := vc.Spec.Uplink.SharedBond
+	if sharedBond == nil {
+		return nil
+	}
+
+	if strings.HasSuffix(sharedBond.Name, utils.BondSuffix) {
+		return fmt.Errorf("the shared bond name %s can't have the suffix %s which is reserved for the bond of a cluster network",
+			sharedBond.Name, utils.BondSuffix)
+	}
+	if name := utils.GetClusterNetworkBrVlanDevice(sharedBond.Name, sharedBond.VID); len(name) > utils.MaxDeviceNameLen {
+		return fmt.Errorf("the length of the VLAN sub-interface name %s of the shared bond can't be more than %d", name, utils.MaxDeviceNameLen)
+	}
+	if sharedBond.VID <= utils.DefaultVlanID || sharedBond.VID > utils.MaxVlanID {
+		return fmt.Errorf("the VID %d of the shared bond %s is out of range [%d, %d]", sharedBond.VID, sharedBond.Name, utils.DefaultVlanID+1, utils.MaxVlanID)
+	}
+
+	if nodes == nil || nodes.Cardinality() == 0 {
+		return nil
+	}
+
+	vcs, err := v.vcCache.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, other := range vcs {
+		if other.Name == vc.Name || other.DeletionTimestamp != nil || !sharesBond(vc, other) {
+			continue
+		}
+		otherNodes, err := getMatchNodes(other)
+		if err != nil {
+			return err
+		}
+		if nodes.Intersect(otherNodes).Cardinality() == 0 {
+			continue
+		}
+		if other.Spec.Uplink.SharedBond.VID == sharedBond.VID {
+			return fmt.Errorf("the VID %d of the shared bond %s is used by vlanconfig %s", sharedBond.VID, sharedBond.Name, other.Name)
+		}
+		if !mapset.NewSet(vc.Spec.Uplink.NICs...).Equal(mapset.NewSet(other.Spec.Uplink.NICs...)) ||
+			!reflect.DeepEqual(vc.Spec.Uplink.LinkAttrs, other.Spec.Uplink.LinkAttrs) ||
+			!reflect.DeepEqual(vc.Spec.Uplink.BondOptions, other.Spec.Uplink.BondOptions) ||
+			!reflect.DeepEqual(vc.Spec.Uplink.QueueOptions, other.Spec.Uplink.QueueOptions) {
+			return fmt.Errorf("the NICs, link attributes, bond options and queue options of the shared bond %s are different from vlanconfig %s",
+				sharedBond.Name, other.Name)
+		}
+	}
+
+	return nil
+}
+
+// checkNICConflicts denies a vlanconfig which tries to enslave a NIC that has been used by another cluster network
+// on the same node, the uplink setup would fail with "device or resource busy" otherwise. The NICs are the ones
+// effective on each node.
+func (v *Validator) checkNICConflicts(vc *networkv1.VlanConfig, nodes mapset.Set[string]) error {
+	if nodes == nil || nodes.Cardinality() == 0 {
+		return nil
+	}
+
+	usage, err := v.buildNICUsage(vc, nodes)
+	if err != nil {
+		return err
+	}
+
+	used := make(nicUsage)
+	for node := range nodes.Iter() {
+		effective, err := v.effectiveOn(vc, node)
+		if err != nil {
+			return err
+		}
+		for _, nic := range uplinkNICs(effective) {
+			for cn, cnNodes := range usage[nic] {
+				if cnNodes.Contains(node) {
+					used.add(nic, cn, node)
+				}
+			}
+		}
+	}
+
+	conflicts := make([]string, 0)
+	for nic, cns := range used {
+		for cn, cnNodes := range cns {
+			conflictNodes := cnNodes.ToSlice()
+			sort.Strings(conflictNodes)
+			conflicts = append(conflicts, fmt.Sprintf("NIC %s is used by cluster network %s on node(s) %v", nic, cn, conflictNodes))
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("it conflicts with other cluster networks: %s", strings.Join(conflicts, "; "))
+	}
+
+	return nil
+}
+
+// checkNICClaims denies a vlanconfig which tries to enslave a NIC claimed by another component, or owned by another
+// component according to the link monitors, on any of the nodes. The NICs are the ones effective on each node.
+func (v *Validator) checkNICClaims(vc *networkv1.VlanConfig, nodes mapset.Set[string]) error {
+	if nodes == nil || nodes.Cardinality() == 0 {
+		return nil
+	}
+
+	sortedNodes := nodes.ToSlice()
+	sort.Strings(sortedNodes)
+	for _, node := range sortedNodes {
+		effective, err := v.effectiveOn(vc, node)
+		if err != nil {
+			return err
+		}
+		nics := uplinkNICs(effective)
+		if len(nics) == 0 {
+			continue
+		}
+		if err := utils.CheckNICsNotClaimed(v.nicClaimCache, node, nics); err != nil {
+			return err
+		}
+		if err := utils.CheckNICsNotOwned(v.lmCache, node, nics); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // checkVmi is to confirm if any VMI exists on the affected nodes. Those VMIs must be stopped in advance.
 func (v *Validator) checkVmi(vc *networkv1.VlanConfig, nodes mapset.Set[string]) error {
 	// note: the vlanconfig's selector may select empty node, e.g. a place-holder vlanconfig
@@ -237,14 +1145,9 @@ func (v *Validator) checkVmi(vc *networkv1.VlanConfig, nodes mapset.Set[string])
 // getMatchNodes retrieves the matched nodes from the VlanConfig's annotations
 // and returns them as a set.
 func getMatchNodes(vc *networkv1.VlanConfig) (mapset.Set[string], error) {
-	empty := mapset.NewSet[string]()
-	if vc == nil || vc.Annotations == nil || vc.Annotations[utils.KeyMatchedNodes] == "" {
-		return empty, nil
-	}
-
-	var matchedNodes []string
-	if err := json.Unmarshal([]byte(vc.Annotations[utils.KeyMatchedNodes]), &matchedNodes); err != nil {
-		return empty, err
+	matchedNodes, err := matcher.MatchedNodesOf(vc)
+	if err != nil {
+		return mapset.NewSet[string](), err
 	}
 
 	return mapset.NewSet(matchedNodes...), nil
@@ -254,7 +1157,12 @@ func (v *Validator) validateMTU(current *networkv1.VlanConfig) error {
 	// MTU can be 0, it means user does not input and the default value is used
 	mtu := utils.GetMTUFromVlanConfig(current)
 	if !utils.IsValidMTU(mtu) {
-		return fmt.Errorf("the MTU %v is out of range [0, %v..%v]", mtu, utils.MinMTU, utils.MaxMTU)
+		minMTU, maxMTU := utils.MTUBounds()
+		return fmt.Errorf("the MTU %v is out of range [0, %v..%v]", mtu, minMTU, maxMTU)
+	}
+
+	if err := v.checkMaxMTU(current); err != nil {
+		return err
 	}
 
 	// ensure all vlanconfigs on one clusternetwork have the same MTU
@@ -278,6 +1186,30 @@ func (v *Validator) validateMTU(current *networkv1.VlanConfig) error {
 	return nil
 }
 
+// checkMaxMTU rejects the MTU of the vlanconfig and of its topology overrides beyond the maximum MTU of the cluster
+// network
+func (v *Validator) checkMaxMTU(vc *networkv1.VlanConfig) error {
+	cn, err := v.cnCache.Get(vc.Spec.ClusterNetwork)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.CheckMaxMTU(cn, utils.GetMTUFromVlanConfig(vc), "vlanconfig "+vc.Name); err != nil {
+		return err
+	}
+	for i := range vc.Spec.TopologyOverrides {
+		override := &vc.Spec.TopologyOverrides[i]
+		if override.MTU == 0 {
+			continue
+		}
+		if err := utils.CheckMaxMTU(cn, override.MTU, "topology "+utils.TopologyKeyOf(override)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // if storagenetwork nad is there, and affected node number > 0, then deny
 func (v *Validator) checkStorageNetwork(vc *networkv1.VlanConfig, nodes mapset.Set[string]) error {
 	// affect no nodes
@@ -1,10 +1,14 @@
 package vlanconfig
 
 import (
+	"context"
+
+	"net"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	kubevirtv1 "kubevirt.io/api/core/v1"
@@ -34,206 +38,1977 @@ func TestCreateVlanConfig(t *testing.T) {
 		currentCN *networkv1.ClusterNetwork
 		currentVC *networkv1.VlanConfig
 		currentVS *networkv1.VlanStatus
-		newVC     *networkv1.VlanConfig
+		// the NICs claimed by another component
+		currentClaim *networkv1.NetworkInterfaceClaim
+		// the links reported by the link monitors
+		currentLM *networkv1.LinkMonitor
+		// the capabilities reported by the agent of node1
+		currentNNS *networkv1.NodeNetworkState
+		// node1 with its topology labels
+		currentNode *corev1.Node
+		newVC       *networkv1.VlanConfig
 	}{
 		{
-			name:      "VlanConfig can't be created on mgmt network",
+			name:      "VlanConfig can't be created on mgmt network",
+			returnErr: true,
+			errKey:    "mgmt",
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "testOnMgmt",
+					Annotations: map[string]string{"test": "test"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: utils.ManagementClusterNetworkName,
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as MTU is too small",
+			returnErr: true,
+			errKey:    "out of range",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{"test": "test"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						LinkAttrs: &networkv1.LinkAttrs{
+							MTU: utils.MinMTU - 1,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as MTU is too big",
+			returnErr: true,
+			errKey:    "out of range",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{"test": "test"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						LinkAttrs: &networkv1.LinkAttrs{
+							MTU: utils.MaxMTU + 1,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as MTU is valid",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{"test": "test"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						LinkAttrs: &networkv1.LinkAttrs{
+							MTU: utils.DefaultMTU,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as MTU exceeds the maximum MTU of the cluster network",
+			returnErr: true,
+			errKey:    "exceeds the maximum MTU 1500",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec:       networkv1.ClusterNetworkSpec{MaxMTU: 1500},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink:         networkv1.Uplink{LinkAttrs: &networkv1.LinkAttrs{MTU: 9000}},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with MTU 0 and MTU will fallback to default value",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{"test": "test"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						LinkAttrs: &networkv1.LinkAttrs{
+							MTU: 0,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with empty Uplink",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{"test": "test"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as VlanConfigs under one ClusterNetwork have different MTUs",
+			returnErr: true,
+			errKey:    "MTU",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "currentVC",
+					Annotations: map[string]string{"test": "test"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						LinkAttrs: &networkv1.LinkAttrs{
+							MTU: utils.DefaultMTU,
+						},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{"test": "test"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						LinkAttrs: &networkv1.LinkAttrs{
+							MTU: utils.DefaultMTU + 1,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as VlanConfigs have overlaps",
+			returnErr: true,
+			errKey:    "overlaps",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			currentVS: &networkv1.VlanStatus{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        utils.Name("", testCnName, "node1"),
+					Annotations: map[string]string{"test": "test"},
+				},
+				Status: networkv1.VlStatus{
+					Node:           "node1",
+					ClusterNetwork: testCnName,
+					VlanConfig:     "oldVC", // belongs to another vc
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						LinkAttrs: &networkv1.LinkAttrs{
+							MTU: utils.DefaultMTU,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as its NIC is used by another cluster network on the same node",
+			returnErr: true,
+			errKey:    "NIC eth1 is used by cluster network other-cn",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "otherVC",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\",\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: "other-cn"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "other-cn",
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1", "eth2"},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the NIC of its topology override is used by another cluster network",
+			returnErr: true,
+			errKey:    "NIC ens1f0 is used by cluster network other-cn on node(s) [node1]",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentNode: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "node1",
+					Labels: map[string]string{corev1.LabelTopologyZone: "zone1"},
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "otherVC",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\",\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: "other-cn"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "other-cn",
+					Uplink: networkv1.Uplink{
+						NICs: []string{"ens1f0"},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\",\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+					TopologyOverrides: []networkv1.TopologyOverride{
+						{Values: []string{"zone1"}, NICs: []string{"ens1f0"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the NIC of its topology override is claimed on the node",
+			returnErr: true,
+			errKey:    "NIC ens1f0 is claimed by sriov-network-operator with claim sriov-node1 on node node1",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentNode: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "node1",
+					Labels: map[string]string{corev1.LabelTopologyZone: "zone1"},
+				},
+			},
+			currentClaim: &networkv1.NetworkInterfaceClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "sriov-node1",
+				},
+				Spec: networkv1.NetworkInterfaceClaimSpec{
+					Node:  "node1",
+					NICs:  []string{"ens1f0"},
+					Owner: "sriov-network-operator",
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+					TopologyOverrides: []networkv1.TopologyOverride{
+						{Values: []string{"zone1"}, NICs: []string{"ens1f0"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as its NIC is claimed by another component on the node",
+			returnErr: true,
+			errKey:    "NIC eth1 is claimed by sriov-network-operator with claim sriov-node1 on node node1",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentClaim: &networkv1.NetworkInterfaceClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "sriov-node1",
+				},
+				Spec: networkv1.NetworkInterfaceClaimSpec{
+					Node:  "node1",
+					NICs:  []string{"eth1"},
+					Owner: "sriov-network-operator",
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\",\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1", "eth2"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as its NIC is owned by the CNI on the node",
+			returnErr: true,
+			errKey:    "NIC cali1234 is owned by canal on node node2",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentLM: &networkv1.LinkMonitor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "all",
+				},
+				Status: networkv1.LinkMonitorStatus{
+					LinkStatus: map[string][]networkv1.LinkStatus{
+						"node1": {{Name: "eth1"}},
+						"node2": {{Name: "eth1", Owner: networkv1.LinkOwnerController}, {Name: "cali1234", Owner: networkv1.LinkOwnerCanal}},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\",\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1", "cali1234"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created as its NIC is claimed on other nodes",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentClaim: &networkv1.NetworkInterfaceClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "sriov-node3",
+				},
+				Spec: networkv1.NetworkInterfaceClaimSpec{
+					Node:  "node3",
+					NICs:  []string{"eth1"},
+					Owner: "sriov-network-operator",
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\",\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1", "eth2"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created as the NIC is used by another cluster network on other nodes",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "otherVC",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: "other-cn"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "other-cn",
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as its NIC is reported in use by the vlanstatus of another cluster network",
+			returnErr: true,
+			errKey:    "NIC eth1 is used by cluster network other-cn on node(s) [node1]",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "otherVC",
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: "other-cn"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "other-cn",
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+				},
+			},
+			currentVS: &networkv1.VlanStatus{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.Name("", "other-cn", "node1"),
+					Labels: map[string]string{
+						utils.KeyVlanConfigLabel:     "otherVC",
+						utils.KeyClusterNetworkLabel: "other-cn",
+						utils.KeyNodeLabel:           "node1",
+					},
+				},
+				Status: networkv1.VlStatus{
+					ClusterNetwork: "other-cn",
+					VlanConfig:     "otherVC",
+					Node:           "node1",
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created as it shares the bond with another cluster network",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "otherVC",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: "other-cn"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "other-cn",
+					Uplink: networkv1.Uplink{
+						NICs:       []string{"eth1", "eth2"},
+						SharedBond: &networkv1.SharedBond{Name: "shared", VID: 100},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:       []string{"eth2", "eth1"},
+						SharedBond: &networkv1.SharedBond{Name: "shared", VID: 200},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the VID of the shared bond is used",
+			returnErr: true,
+			errKey:    "VID 100 of the shared bond shared is used",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "otherVC",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: "other-cn"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "other-cn",
+					Uplink: networkv1.Uplink{
+						NICs:       []string{"eth1"},
+						SharedBond: &networkv1.SharedBond{Name: "shared", VID: 100},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:       []string{"eth1"},
+						SharedBond: &networkv1.SharedBond{Name: "shared", VID: 100},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the NICs of the shared bond are different",
+			returnErr: true,
+			errKey:    "are different from vlanconfig otherVC",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "otherVC",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: "other-cn"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "other-cn",
+					Uplink: networkv1.Uplink{
+						NICs:       []string{"eth1"},
+						SharedBond: &networkv1.SharedBond{Name: "shared", VID: 100},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:       []string{"eth1", "eth2"},
+						SharedBond: &networkv1.SharedBond{Name: "shared", VID: 200},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the shared bond name has the reserved suffix",
+			returnErr: true,
+			errKey:    "reserved",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:       []string{"eth1"},
+						SharedBond: &networkv1.SharedBond{Name: "shared-bo", VID: 200},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as a NIC is in both fabric A and fabric B",
+			returnErr: true,
+			errKey:    "both fabric A and fabric B",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:    []string{"eth1", "eth2"},
+						FabricB: &networkv1.FabricUplink{NICs: []string{"eth2", "eth3"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as its fabric B NIC is used by another cluster network",
+			returnErr: true,
+			errKey:    "NIC eth3 is used by cluster network other-cn",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "otherVC",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: "other-cn"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "other-cn",
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth3"},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:    []string{"eth1"},
+						FabricB: &networkv1.FabricUplink{NICs: []string{"eth3"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with fabric A and fabric B",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:    []string{"eth1", "eth2"},
+						FabricB: &networkv1.FabricUplink{NICs: []string{"eth3", "eth4"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the agent of its node doesn't understand fabric B",
+			returnErr: true,
+			errKey:    "don't understand the features [fabricB]",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentVS: &networkv1.VlanStatus{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.Name("", "other-cn", "node1"),
+					Labels: map[string]string{
+						utils.KeyVlanConfigLabel:     "otherVC",
+						utils.KeyClusterNetworkLabel: "other-cn",
+						utils.KeyNodeLabel:           "node1",
+					},
+				},
+				Status: networkv1.VlStatus{
+					ClusterNetwork: "other-cn",
+					VlanConfig:     "otherVC",
+					Node:           "node1",
+					Features:       []string{utils.FeatureSharedBond},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:    []string{"eth1", "eth2"},
+						FabricB: &networkv1.FabricUplink{NICs: []string{"eth3", "eth4"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with fabric B as the agent of its node understands it",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentVS: &networkv1.VlanStatus{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.Name("", "other-cn", "node1"),
+					Labels: map[string]string{
+						utils.KeyVlanConfigLabel:     "otherVC",
+						utils.KeyClusterNetworkLabel: "other-cn",
+						utils.KeyNodeLabel:           "node1",
+					},
+				},
+				Status: networkv1.VlStatus{
+					ClusterNetwork: "other-cn",
+					VlanConfig:     "otherVC",
+					Node:           "node1",
+					Features:       utils.AgentFeatures,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:    []string{"eth1", "eth2"},
+						FabricB: &networkv1.FabricUplink{NICs: []string{"eth3", "eth4"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the agent of its node doesn't understand the datapath of its cluster network",
+			returnErr: true,
+			errKey:    "don't understand the features [macvlan]",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathMacvlan},
+			},
+			currentVS: &networkv1.VlanStatus{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.Name("", "other-cn", "node1"),
+					Labels: map[string]string{
+						utils.KeyVlanConfigLabel:     "otherVC",
+						utils.KeyClusterNetworkLabel: "other-cn",
+						utils.KeyNodeLabel:           "node1",
+					},
+				},
+				Status: networkv1.VlStatus{
+					ClusterNetwork: "other-cn",
+					VlanConfig:     "otherVC",
+					Node:           "node1",
+					Features:       []string{utils.FeatureSharedBond},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink:         networkv1.Uplink{NICs: []string{"eth1", "eth2"}},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the per-queue qdisc requires mq",
+			returnErr: true,
+			errKey:    "requires the root qdisc mq",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+						QueueOptions: &networkv1.QueueOptions{
+							Qdisc:         networkv1.QdiscFq,
+							PerQueueQdisc: networkv1.QdiscFqCodel,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as mq is applied to the bridge",
+			returnErr: true,
+			errKey:    "single TX queue",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+						QueueOptions: &networkv1.QueueOptions{
+							Qdisc:         networkv1.QdiscMq,
+							ApplyToBridge: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with mq and per-queue fq_codel",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+						QueueOptions: &networkv1.QueueOptions{
+							Qdisc:         networkv1.QdiscMq,
+							PerQueueQdisc: networkv1.QdiscFqCodel,
+							Limit:         10240,
+							NumTxQueues:   8,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the updelay is not a multiple of miimon",
+			returnErr: true,
+			errKey:    "not a multiple of miimon 100",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:        []string{"eth1", "eth2"},
+						BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: -1, UpDelay: 250},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with a multicast hardware address",
+			returnErr: true,
+			errKey:    "not a unicast address",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: `["node1"]`},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:      []string{"eth1"},
+						LinkAttrs: &networkv1.LinkAttrs{HardwareAddr: net.HardwareAddr{0x01, 0, 0x5e, 0, 0, 1}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with a hardware address which is not a MAC address",
+			returnErr: true,
+			errKey:    "not an Ethernet MAC address",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: `["node1"]`},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:      []string{"eth1"},
+						LinkAttrs: &networkv1.LinkAttrs{HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 0, 0, 1}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with the hardware address of the bonds of two nodes",
+			returnErr: true,
+			errKey:    "bonds of 2 nodes",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: `["node1", "node2"]`},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:      []string{"eth1"},
+						LinkAttrs: &networkv1.LinkAttrs{HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 2}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with the hardware address used by another vlanconfig",
+			returnErr: true,
+			errKey:    "used by the bond of vlanconfig",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "existingVC",
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:      []string{"eth3"},
+						LinkAttrs: &networkv1.LinkAttrs{HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 1}},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: `["node1"]`},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:      []string{"eth1"},
+						LinkAttrs: &networkv1.LinkAttrs{HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 1}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with a unique unicast hardware address",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "existingVC",
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:      []string{"eth3"},
+						LinkAttrs: &networkv1.LinkAttrs{HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 1}},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: `["node1"]`},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:      []string{"eth1"},
+						LinkAttrs: &networkv1.LinkAttrs{HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 2}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with the bond delays but without miimon",
+			returnErr: true,
+			errKey:    "require miimon",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:        []string{"eth1", "eth2"},
+						BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: 0, DownDelay: 200},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with the bond delays",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:        []string{"eth1", "eth2"},
+						BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: 100, UpDelay: 2000, DownDelay: 200},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with the xmit hash policy in the 802.3ad mode",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:        []string{"eth1", "eth2"},
+						BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: -1, XmitHashPolicy: networkv1.XmitHashPolicyLayer34},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with the xmit hash policy in the active-backup mode",
+			returnErr: true,
+			errKey:    "meaningless in the bond mode active-backup",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:        []string{"eth1", "eth2"},
+						BondOptions: &networkv1.BondOptions{Miimon: -1, XmitHashPolicy: networkv1.XmitHashPolicyLayer23},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with the LACP rate in the balance-xor mode",
+			returnErr: true,
+			errKey:    "meaningless in the bond mode balance-xor",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:        []string{"eth1", "eth2"},
+						BondOptions: &networkv1.BondOptions{Mode: networkv1.BondModeBalanceXor, Miimon: -1, LacpRate: networkv1.LacpRateFast},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with the ARP monitoring",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1", "eth2"},
+						BondOptions: &networkv1.BondOptions{Miimon: -1, ArpInterval: 500, ArpIPTargets: []string{"10.0.0.1"},
+							ArpValidate: networkv1.ArpValidateActive},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with the bond delays under the ARP monitoring",
+			returnErr: true,
+			errKey:    "the bond delays require miimon",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1", "eth2"},
+						BondOptions: &networkv1.BondOptions{Miimon: -1, UpDelay: 200, ArpInterval: 500,
+							ArpIPTargets: []string{"10.0.0.1"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with the backup NICs in the 802.3ad mode",
+			returnErr: true,
+			errKey:    "require the bond mode active-backup",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:        []string{"eth1", "usb0"},
+						BackupNICs:  []string{"usb0"},
+						BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: -1},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the backup NIC is not a NIC of the uplink",
+			returnErr: true,
+			errKey:    "is not a NIC of the uplink",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:       []string{"eth1"},
+						BackupNICs: []string{"usb0"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as all NICs are backup NICs",
+			returnErr: true,
+			errKey:    "all NICs of the uplink are backup NICs",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:       []string{"eth1", "usb0"},
+						BackupNICs: []string{"usb0", "eth1"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with a backup NIC",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:       []string{"eth1", "usb0"},
+						BackupNICs: []string{"usb0"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with an invalid TTL",
+			returnErr: true,
+			errKey:    "invalid network.harvesterhci.io/ttl",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyTTL: "1d"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with a TTL",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyTTL: "24h"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the tuned NIC is not a NIC of the uplink",
+			returnErr: true,
+			errKey:    "is not a NIC of the uplink",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+						NICTuning: []networkv1.NICTuning{
+							{Name: "eth2", Ring: &networkv1.RingOptions{RX: 4096}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the NIC is tuned more than once",
+			returnErr: true,
+			errKey:    "tuned more than once",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+						NICTuning: []networkv1.NICTuning{
+							{Name: "eth1", Ring: &networkv1.RingOptions{RX: 4096}},
+							{Name: "eth1", Ring: &networkv1.RingOptions{TX: 4096}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with the tuning of the fabric B NIC",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:    []string{"eth1"},
+						FabricB: &networkv1.FabricUplink{NICs: []string{"eth2"}},
+						NICTuning: []networkv1.NICTuning{
+							{Name: "eth1", Ring: &networkv1.RingOptions{RX: 4096, TX: 4096}},
+							{Name: "eth2", Coalesce: &networkv1.CoalesceOptions{}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with the NICs and MTU overridden per zone",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1", "eth2"},
+					},
+					TopologyOverrides: []networkv1.TopologyOverride{
+						{Values: []string{"zone1", "zone2"}, NICs: []string{"ens1f0", "ens1f1"}, BackupNICs: []string{"ens1f1"}, MTU: 9000},
+						{TopologyKey: "rack", Values: []string{"r1"}, BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: 100}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as a zone is overridden more than once",
+			returnErr: true,
+			errKey:    "overridden more than once",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1", "eth2"},
+					},
+					TopologyOverrides: []networkv1.TopologyOverride{
+						{Values: []string{"zone1"}, MTU: 9000},
+						{TopologyKey: "topology.kubernetes.io/zone", Values: []string{"zone1"}, NICs: []string{"eth3"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the MTU of a zone is less than the vlanconfig",
+			returnErr: true,
+			errKey:    "is less than the MTU 9000",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:      []string{"eth1", "eth2"},
+						LinkAttrs: &networkv1.LinkAttrs{MTU: 9000},
+					},
+					TopologyOverrides: []networkv1.TopologyOverride{
+						{Values: []string{"zone1"}, MTU: 1500},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as the backup NICs of a zone are invalid",
 			returnErr: true,
-			errKey:    "mgmt",
+			errKey:    "is not a NIC of the uplink",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
 			newVC: &networkv1.VlanConfig{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        "testOnMgmt",
-					Annotations: map[string]string{"test": "test"},
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
 				},
 				Spec: networkv1.VlanConfigSpec{
-					ClusterNetwork: utils.ManagementClusterNetworkName,
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1", "eth2"},
+					},
+					TopologyOverrides: []networkv1.TopologyOverride{
+						{Values: []string{"zone1"}, NICs: []string{"ens1f0", "ens1f1"}, BackupNICs: []string{"eth2"}},
+					},
 				},
 			},
 		},
 		{
-			name:      "VlanConfig can't be created as MTU is too small",
+			name:      "VlanConfig can be created with the jumbo verification",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:              []string{"eth1"},
+						LinkAttrs:         &networkv1.LinkAttrs{MTU: 9000},
+						JumboVerification: &networkv1.JumboVerification{VID: 100, Source: "10.0.100.250", Target: "10.0.100.1"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with the jumbo verification from the target itself",
 			returnErr: true,
-			errKey:    "out of range",
+			errKey:    "are both 10.0.100.1",
 			currentCN: &networkv1.ClusterNetwork{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        testCnName,
-					Annotations: map[string]string{"test": "test"},
+					Name: testCnName,
 				},
 			},
 			newVC: &networkv1.VlanConfig{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        testNewVCName,
-					Annotations: map[string]string{"test": "test"},
-					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
 				},
 				Spec: networkv1.VlanConfigSpec{
 					ClusterNetwork: testCnName,
 					Uplink: networkv1.Uplink{
-						LinkAttrs: &networkv1.LinkAttrs{
-							MTU: utils.MinMTU - 1,
-						},
+						NICs:              []string{"eth1"},
+						LinkAttrs:         &networkv1.LinkAttrs{MTU: 9000},
+						JumboVerification: &networkv1.JumboVerification{VID: 100, Source: "10.0.100.1", Target: "10.0.100.1"},
 					},
 				},
 			},
 		},
 		{
-			name:      "VlanConfig can't be created as MTU is too big",
+			name:      "VlanConfig can't be created with the jumbo verification below the jumbo MTU",
 			returnErr: true,
-			errKey:    "out of range",
+			errKey:    "requires the MTU 9000",
 			currentCN: &networkv1.ClusterNetwork{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        testCnName,
-					Annotations: map[string]string{"test": "test"},
+					Name: testCnName,
 				},
 			},
 			newVC: &networkv1.VlanConfig{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        testNewVCName,
-					Annotations: map[string]string{"test": "test"},
-					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
 				},
 				Spec: networkv1.VlanConfigSpec{
 					ClusterNetwork: testCnName,
 					Uplink: networkv1.Uplink{
-						LinkAttrs: &networkv1.LinkAttrs{
-							MTU: utils.MaxMTU + 1,
-						},
+						NICs:              []string{"eth1"},
+						LinkAttrs:         &networkv1.LinkAttrs{MTU: 1500},
+						JumboVerification: &networkv1.JumboVerification{VID: 100, Source: "10.0.100.250", Target: "10.0.100.1"},
 					},
 				},
 			},
 		},
 		{
-			name:      "VlanConfig can't be created as MTU is valid",
+			name:      "VlanConfig can be created with the service VLAN",
 			returnErr: false,
 			errKey:    "",
 			currentCN: &networkv1.ClusterNetwork{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        testCnName,
-					Annotations: map[string]string{"test": "test"},
+					Name: testCnName,
 				},
 			},
 			newVC: &networkv1.VlanConfig{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        testNewVCName,
-					Annotations: map[string]string{"test": "test"},
-					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
 				},
 				Spec: networkv1.VlanConfigSpec{
 					ClusterNetwork: testCnName,
 					Uplink: networkv1.Uplink{
-						LinkAttrs: &networkv1.LinkAttrs{
-							MTU: utils.DefaultMTU,
-						},
+						NICs:        []string{"eth1", "eth2"},
+						ServiceVLAN: 4000,
 					},
 				},
 			},
 		},
 		{
-			name:      "VlanConfig can be created with MTU 0 and MTU will fallback to default value",
+			name:      "VlanConfig can't be created with the service VLAN and fabric B",
+			returnErr: true,
+			errKey:    "together with fabric B",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testNewVCName,
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:        []string{"eth1"},
+						FabricB:     &networkv1.FabricUplink{NICs: []string{"eth2"}},
+						ServiceVLAN: 4000,
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created with the MTU supported by the NICs of the matched nodes",
 			returnErr: false,
 			errKey:    "",
 			currentCN: &networkv1.ClusterNetwork{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        testCnName,
-					Annotations: map[string]string{"test": "test"},
+					Name: testCnName,
+				},
+			},
+			currentNNS: &networkv1.NodeNetworkState{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "node1",
+				},
+				Status: networkv1.NodeNetworkStateStatus{
+					Capabilities: networkv1.NodeCapabilities{MaxMTU: 9216},
+					NICs: []networkv1.NICCapabilities{
+						{Name: "eth1", MaxMTU: 9216},
+						{Name: "eth2", MaxMTU: 1500},
+					},
 				},
 			},
 			newVC: &networkv1.VlanConfig{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:        testNewVCName,
-					Annotations: map[string]string{"test": "test"},
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
 					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
 				},
 				Spec: networkv1.VlanConfigSpec{
 					ClusterNetwork: testCnName,
 					Uplink: networkv1.Uplink{
-						LinkAttrs: &networkv1.LinkAttrs{
-							MTU: 0,
-						},
+						NICs:      []string{"eth1"},
+						LinkAttrs: &networkv1.LinkAttrs{MTU: 9000},
 					},
 				},
 			},
 		},
 		{
-			name:      "VlanConfig can be created with empty Uplink",
-			returnErr: false,
-			errKey:    "",
+			name:      "VlanConfig of an OVS cluster network can't be created on a node without Open vSwitch",
+			returnErr: true,
+			errKey:    "requires Open vSwitch, which the agent of node node1 can't reach",
 			currentCN: &networkv1.ClusterNetwork{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        testCnName,
-					Annotations: map[string]string{"test": "test"},
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{Datapath: networkv1.DatapathOVS},
+			},
+			currentNNS: &networkv1.NodeNetworkState{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "node1",
+				},
+				Status: networkv1.NodeNetworkStateStatus{
+					Capabilities: networkv1.NodeCapabilities{QinQ: true},
 				},
 			},
 			newVC: &networkv1.VlanConfig{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:        testNewVCName,
-					Annotations: map[string]string{"test": "test"},
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
 					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
 				},
 				Spec: networkv1.VlanConfigSpec{
 					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
 				},
 			},
 		},
 		{
-			name:      "VlanConfig can't be created as VlanConfigs under one ClusterNetwork have different MTUs",
+			name:      "VlanConfig can't be created with the MTU beyond the maximum MTU of a NIC",
 			returnErr: true,
-			errKey:    "MTU",
+			errKey:    "maximum MTU 1500 of NIC eth2 on node node1",
 			currentCN: &networkv1.ClusterNetwork{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        testCnName,
-					Annotations: map[string]string{"test": "test"},
+					Name: testCnName,
 				},
 			},
-			currentVC: &networkv1.VlanConfig{
+			currentNNS: &networkv1.NodeNetworkState{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        "currentVC",
-					Annotations: map[string]string{"test": "test"},
+					Name: "node1",
+				},
+				Status: networkv1.NodeNetworkStateStatus{
+					Capabilities: networkv1.NodeCapabilities{MaxMTU: 9216},
+					NICs: []networkv1.NICCapabilities{
+						{Name: "eth1", MaxMTU: 9216},
+						{Name: "eth2", MaxMTU: 1500},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
 					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
 				},
 				Spec: networkv1.VlanConfigSpec{
 					ClusterNetwork: testCnName,
 					Uplink: networkv1.Uplink{
-						LinkAttrs: &networkv1.LinkAttrs{
-							MTU: utils.DefaultMTU,
-						},
+						NICs:      []string{"eth1", "eth2"},
+						LinkAttrs: &networkv1.LinkAttrs{MTU: 9000},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with the service VLAN on the node not stacking VLAN tags",
+			returnErr: true,
+			errKey:    "stacking VLAN tags",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			currentNNS: &networkv1.NodeNetworkState{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "node1",
+				},
+				Status: networkv1.NodeNetworkStateStatus{
+					Capabilities: networkv1.NodeCapabilities{MaxMTU: 9216},
+					NICs: []networkv1.NICCapabilities{
+						{Name: "eth1", MaxMTU: 9216},
+						{Name: "eth2", MaxMTU: 1500},
 					},
 				},
 			},
 			newVC: &networkv1.VlanConfig{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:        testNewVCName,
-					Annotations: map[string]string{"test": "test"},
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
 					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
 				},
 				Spec: networkv1.VlanConfigSpec{
 					ClusterNetwork: testCnName,
 					Uplink: networkv1.Uplink{
-						LinkAttrs: &networkv1.LinkAttrs{
-							MTU: utils.DefaultMTU + 1,
-						},
+						NICs:        []string{"eth1"},
+						ServiceVLAN: 4000,
 					},
 				},
 			},
 		},
 		{
-			name:      "VlanConfig can't be created as VlanConfigs have overlaps",
+			name:      "VlanConfig can't be created with a clone request without name",
+			returnErr: true,
+			errKey:    "the name is empty",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+					Annotations: map[string]string{utils.KeyCloneRequest: `{"clusterNetwork":"rack2"}`},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as it matches the nodes of another VlanConfig not taking effect yet",
 			returnErr: true,
 			errKey:    "overlaps",
 			currentCN: &networkv1.ClusterNetwork{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        testCnName,
-					Annotations: map[string]string{"test": "test"},
+					Name: testCnName,
 				},
 			},
-			currentVS: &networkv1.VlanStatus{
+			currentVC: &networkv1.VlanConfig{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        utils.Name("", testCnName, "node1"),
-					Annotations: map[string]string{"test": "test"},
+					Name:        "currentVC",
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\",\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
 				},
-				Status: networkv1.VlStatus{
+				Spec: networkv1.VlanConfigSpec{
 					ClusterNetwork: testCnName,
-					VlanConfig:     "oldVC", // belongs to another vc
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can be created on a cluster network of type VXLAN without NICs",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec: networkv1.ClusterNetworkSpec{
+					Type:  networkv1.ClusterNetworkTypeVXLAN,
+					VXLAN: &networkv1.VXLANOptions{VNI: 100},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created with NICs on a cluster network of type VXLAN",
+			returnErr: true,
+			errKey:    "the NICs can't be configured on the cluster network",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{Name: testCnName},
+				Spec: networkv1.ClusterNetworkSpec{
+					Type:  networkv1.ClusterNetworkTypeVXLAN,
+					VXLAN: &networkv1.VXLANOptions{VNI: 100},
 				},
 			},
 			newVC: &networkv1.VlanConfig{
@@ -245,9 +2020,7 @@ func TestCreateVlanConfig(t *testing.T) {
 				Spec: networkv1.VlanConfigSpec{
 					ClusterNetwork: testCnName,
 					Uplink: networkv1.Uplink{
-						LinkAttrs: &networkv1.LinkAttrs{
-							MTU: utils.DefaultMTU,
-						},
+						NICs: []string{"eth1"},
 					},
 				},
 			},
@@ -268,11 +2041,16 @@ func TestCreateVlanConfig(t *testing.T) {
 			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
 			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
 			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+			nicClaimCache := fakeclients.NetworkInterfaceClaimCache(nchclientset.NetworkV1beta1().NetworkInterfaceClaims)
+			lmCache := fakeclients.LinkMonitorCache(nchclientset.NetworkV1beta1().LinkMonitors)
+			nnsCache := fakeclients.NodeNetworkStateCache(nchclientset.NetworkV1beta1().NodeNetworkStates)
+			nodeCache := fakeclients.NodeCache(nchclientset.CoreV1().Nodes)
 
 			// client to inject test data
 			vcClient := fakeclients.VlanConfigClient(nchclientset.NetworkV1beta1().VlanConfigs)
 			cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
 			vsClient := fakeclients.VlanStatusClient(nchclientset.NetworkV1beta1().VlanStatuses)
+			nicClaimClient := fakeclients.NetworkInterfaceClaimClient(nchclientset.NetworkV1beta1().NetworkInterfaceClaims)
 
 			if tc.currentVC != nil {
 				_, err := vcClient.Create(tc.currentVC)
@@ -286,7 +2064,23 @@ func TestCreateVlanConfig(t *testing.T) {
 				_, err := vsClient.Create(tc.currentVS)
 				assert.NoError(t, err)
 			}
-			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache)
+			if tc.currentClaim != nil {
+				_, err := nicClaimClient.Create(tc.currentClaim)
+				assert.NoError(t, err)
+			}
+			if tc.currentLM != nil {
+				_, err := nchclientset.NetworkV1beta1().LinkMonitors().Create(context.TODO(), tc.currentLM, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+			if tc.currentNNS != nil {
+				_, err := nchclientset.NetworkV1beta1().NodeNetworkStates().Create(context.TODO(), tc.currentNNS, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+			if tc.currentNode != nil {
+				_, err := nchclientset.CoreV1().Nodes().Create(context.TODO(), tc.currentNode, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache, nicClaimCache, lmCache, nnsCache, nodeCache)
 
 			err := validator.Create(nil, tc.newVC)
 			assert.True(t, tc.returnErr == (err != nil))
@@ -334,6 +2128,7 @@ func TestUpdateVlanConfig(t *testing.T) {
 					Labels:      map[string]string{utils.KeyVlanConfigLabel: "others"},
 				},
 				Status: networkv1.VlStatus{
+					Node:           "node1",
 					ClusterNetwork: testCnName,
 					VlanConfig:     "others", // belongs to another vc
 				},
@@ -386,6 +2181,7 @@ func TestUpdateVlanConfig(t *testing.T) {
 					Labels:      map[string]string{utils.KeyVlanConfigLabel: "others"},
 				},
 				Status: networkv1.VlStatus{
+					Node:           "node1",
 					ClusterNetwork: testCnName,
 					VlanConfig:     "others", // belongs to another vc
 				},
@@ -434,6 +2230,7 @@ func TestUpdateVlanConfig(t *testing.T) {
 					Labels:      map[string]string{utils.KeyVlanConfigLabel: testNewVCName},
 				},
 				Status: networkv1.VlStatus{
+					Node:           "node1",
 					ClusterNetwork: testCnName,
 					VlanConfig:     testNewVCName,
 				},
@@ -510,6 +2307,53 @@ func TestUpdateVlanConfig(t *testing.T) {
 				},
 			}, // vmi
 		},
+		{
+			name:      "VlanConfig can't be moved again as the previous migration is not finished",
+			returnErr: true,
+			errKey:    "the previous migration from cluster network old-cn is not finished on node(s) [node1]",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "new-cn",
+				},
+			},
+			currentVS: &networkv1.VlanStatus{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   utils.Name("", "old-cn", "node1"),
+					Labels: map[string]string{utils.KeyVlanConfigLabel: testNewVCName},
+				},
+				Status: networkv1.VlStatus{
+					ClusterNetwork: "old-cn",
+					VlanConfig:     testNewVCName,
+					Node:           "node1",
+				},
+			},
+			oldVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: "new-cn"},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: "new-cn",
+					Uplink: networkv1.Uplink{
+						NICs: []string{"eth1"},
+					},
+				},
+			},
+		},
 	}
 
 	nadGvr := schema.GroupVersionResource{
@@ -532,6 +2376,10 @@ func TestUpdateVlanConfig(t *testing.T) {
 			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
 			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
 			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+			nicClaimCache := fakeclients.NetworkInterfaceClaimCache(nchclientset.NetworkV1beta1().NetworkInterfaceClaims)
+			lmCache := fakeclients.LinkMonitorCache(nchclientset.NetworkV1beta1().LinkMonitors)
+			nnsCache := fakeclients.NodeNetworkStateCache(nchclientset.NetworkV1beta1().NodeNetworkStates)
+			nodeCache := fakeclients.NodeCache(nchclientset.CoreV1().Nodes)
 
 			// client to inject test data
 			vcClient := fakeclients.VlanConfigClient(nchclientset.NetworkV1beta1().VlanConfigs)
@@ -577,7 +2425,7 @@ func TestUpdateVlanConfig(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache)
+			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache, nicClaimCache, lmCache, nnsCache, nodeCache)
 
 			err := validator.Update(nil, tc.oldVC, tc.newVC)
 			assert.True(t, tc.returnErr == (err != nil))
@@ -600,12 +2448,16 @@ func TestUpdateVlanConfig_NoMatchedNodesAnnotationDoesNotPanic(t *testing.T) {
 	vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
 	vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
 	cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+	nicClaimCache := fakeclients.NetworkInterfaceClaimCache(nchclientset.NetworkV1beta1().NetworkInterfaceClaims)
+	lmCache := fakeclients.LinkMonitorCache(nchclientset.NetworkV1beta1().LinkMonitors)
+	nnsCache := fakeclients.NodeNetworkStateCache(nchclientset.NetworkV1beta1().NodeNetworkStates)
+	nodeCache := fakeclients.NodeCache(nchclientset.CoreV1().Nodes)
 
 	cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
 	_, err := cnClient.Create(&networkv1.ClusterNetwork{ObjectMeta: metav1.ObjectMeta{Name: testCnName}})
 	assert.NoError(t, err)
 
-	validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache)
+	validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache, nicClaimCache, lmCache, nnsCache, nodeCache)
 
 	oldVC := &networkv1.VlanConfig{
 		ObjectMeta: metav1.ObjectMeta{
@@ -656,6 +2508,7 @@ func TestDeleteVlanConfig(t *testing.T) {
 					Annotations: map[string]string{"test": "test"},
 				},
 				Status: networkv1.VlStatus{
+					Node:           "node1",
 					ClusterNetwork: testCnName,
 					VlanConfig:     "VC1",
 				},
@@ -718,6 +2571,7 @@ func TestDeleteVlanConfig(t *testing.T) {
 					Labels:      map[string]string{utils.KeyVlanConfigLabel: "VC1"},
 				},
 				Status: networkv1.VlStatus{
+					Node:           "node1",
 					ClusterNetwork: testCnName,
 					VlanConfig:     "VC1",
 				},
@@ -755,6 +2609,7 @@ func TestDeleteVlanConfig(t *testing.T) {
 					Labels:      map[string]string{utils.KeyVlanConfigLabel: "VC1"},
 				},
 				Status: networkv1.VlStatus{
+					Node:           "node1",
 					ClusterNetwork: testCnName,
 					VlanConfig:     "VC1",
 				},
@@ -790,6 +2645,10 @@ func TestDeleteVlanConfig(t *testing.T) {
 			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
 			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
 			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+			nicClaimCache := fakeclients.NetworkInterfaceClaimCache(nchclientset.NetworkV1beta1().NetworkInterfaceClaims)
+			lmCache := fakeclients.LinkMonitorCache(nchclientset.NetworkV1beta1().LinkMonitors)
+			nnsCache := fakeclients.NodeNetworkStateCache(nchclientset.NetworkV1beta1().NodeNetworkStates)
+			nodeCache := fakeclients.NodeCache(nchclientset.CoreV1().Nodes)
 
 			// client to inject test data
 			vcClient := fakeclients.VlanConfigClient(nchclientset.NetworkV1beta1().VlanConfigs)
@@ -827,7 +2686,7 @@ func TestDeleteVlanConfig(t *testing.T) {
 				_, err := hncClient.Create(tc.currentHostNetworkConfig)
 				assert.NoError(t, err)
 			}
-			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache)
+			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache, nicClaimCache, lmCache, nnsCache, nodeCache)
 
 			err := validator.Delete(nil, tc.currentVC)
 			assert.True(t, tc.returnErr == (err != nil))